@@ -68,6 +68,11 @@ var (
 		"139.162.98.190:6000",
 	}
 
+	// Checkpoints main chain blocks known to be valid, in the format "seq:hash"
+	Checkpoints = []string{}
+	// AssumeValid block in the format "seq:hash", whose transaction signatures and those of its main chain ancestors are not verified once it is reached
+	AssumeValid = ""
+
 	nodeConfig = skycoin.NewNodeConfig(ConfigMode, fiber.NodeConfig{
 		CoinName:            CoinName,
 		GenesisSignatureStr: GenesisSignatureStr,
@@ -78,6 +83,8 @@ var (
 		BlockchainSeckeyStr: BlockchainSeckeyStr,
 		DefaultConnections:  DefaultConnections,
 		PeerListURL:         "https://downloads.skycoin.net/blockchain/peers.txt",
+		Checkpoints:         Checkpoints,
+		AssumeValid:         AssumeValid,
 		Port:                6000,
 		WebInterfacePort:    6420,
 		DataDirectory:       "$HOME/.skycoin",
@@ -2,9 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+	"github.com/skycoin/skycoin/src/wallet"
 )
 
 // VerifyAddressRequest is the request data for POST /api/v2/address/verify
@@ -54,3 +63,449 @@ func addressVerifyHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// AddressSummaryRequest is the request data for POST /api/v2/address/summary
+type AddressSummaryRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// AddressSummary is the history summary of an address, returned by POST /api/v2/address/summary
+type AddressSummary struct {
+	Address string `json:"address"`
+	// Received is the total of coins received by the address
+	Received string `json:"received"`
+	// Sent is the total of coins spent by the address
+	Sent string `json:"sent"`
+	// Balance is the confirmed balance of the address, received minus sent
+	Balance string `json:"balance"`
+	// Transactions is the number of transactions that spend from or send to the address
+	Transactions uint64 `json:"transactions"`
+	// FirstSeenBlock and LastSeenBlock are the first and last blocks with a transaction of the address,
+	// omitted if the address has no transactions
+	FirstSeenBlock *uint64 `json:"first_seen_block,omitempty"`
+	LastSeenBlock  *uint64 `json:"last_seen_block,omitempty"`
+}
+
+// NewAddressSummary creates an AddressSummary from a historydb.AddressSummary, which is nil if the address has no history
+func NewAddressSummary(addr cipher.Address, s *historydb.AddressSummary) (*AddressSummary, error) {
+	if s == nil {
+		s = &historydb.AddressSummary{}
+	}
+
+	received, err := droplet.ToString(s.Received)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := droplet.ToString(s.Sent)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Sent > s.Received {
+		return nil, fmt.Errorf("address %s sent more coins than it received", addr)
+	}
+
+	balance, err := droplet.ToString(s.Received - s.Sent)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &AddressSummary{
+		Address:      addr.String(),
+		Received:     received,
+		Sent:         sent,
+		Balance:      balance,
+		Transactions: s.TxnCount,
+	}
+
+	if s.TxnCount > 0 {
+		firstSeq := s.FirstSeq
+		lastSeq := s.LastSeq
+		summary.FirstSeenBlock = &firstSeq
+		summary.LastSeenBlock = &lastSeq
+	}
+
+	return summary, nil
+}
+
+// addressSummaryHandler returns the total received and sent coins, the transaction count
+// and the first and last blocks with a transaction of a list of addresses.
+// The summaries are maintained by the historydb as blocks are executed.
+// Method: POST
+// URI: /api/v2/address/summary
+// Args:
+//	addresses: list of addresses [required]
+func addressSummaryHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req AddressSummaryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.Addresses) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrs := make([]cipher.Address, len(req.Addresses))
+		for i, a := range req.Addresses {
+			addr, err := cipher.DecodeBase58Address(a)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("address %q is invalid: %v", a, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			addrs[i] = addr
+		}
+
+		summaries, err := gateway.GetAddressSummaries(addrs)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		out := make([]AddressSummary, len(addrs))
+		for i, addr := range addrs {
+			s, err := NewAddressSummary(addr, summaries[i])
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+			out[i] = *s
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: out,
+		})
+	}
+}
+
+// AddressBalanceRequest is the request data for POST /api/v2/address/balance
+type AddressBalanceRequest struct {
+	Addresses []string `json:"addresses"`
+	Seq       *uint64  `json:"seq,omitempty"`
+	Time      *uint64  `json:"time,omitempty"`
+}
+
+// AddressBalance is the confirmed balance of a set of addresses as of a block, returned by POST /api/v2/address/balance
+type AddressBalance struct {
+	// Head is the header of the block that the balances were calculated at
+	Head      readable.BlockHeader        `json:"head"`
+	Confirmed readable.Balance            `json:"confirmed"`
+	Addresses map[string]readable.Balance `json:"addresses"`
+}
+
+// NewAddressBalance creates an AddressBalance from visor.HistoricalBalances
+func NewAddressBalance(addrs []cipher.Address, hb visor.HistoricalBalances) (*AddressBalance, error) {
+	if len(addrs) != len(hb.Balances) {
+		return nil, errors.New("NewAddressBalance: len(addrs) != len(hb.Balances)")
+	}
+
+	var total wallet.Balance
+	balances := make(map[string]readable.Balance, len(addrs))
+	for i, addr := range addrs {
+		b := hb.Balances[i]
+		if _, ok := balances[addr.String()]; !ok {
+			var err error
+			total, err = total.Add(b)
+			if err != nil {
+				return nil, err
+			}
+		}
+		balances[addr.String()] = readable.NewBalance(b)
+	}
+
+	return &AddressBalance{
+		Head:      readable.NewBlockHeader(hb.Block),
+		Confirmed: readable.NewBalance(total),
+		Addresses: balances,
+	}, nil
+}
+
+// addressBalanceHandler returns the confirmed balance of a list of addresses as of a block in the past.
+// The block is given by its seq, or by a unix timestamp, in which case the most recent block at or before the time is used.
+// Coin hours are calculated at the time of the block.
+// The balances are rebuilt from the outputs recorded by the historydb.
+// Method: POST
+// URI: /api/v2/address/balance
+// Args:
+//	addresses: list of addresses [required]
+//	seq: block seq [seq or time is required]
+//	time: unix timestamp [seq or time is required]
+func addressBalanceHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req AddressBalanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.Addresses) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if (req.Seq == nil) == (req.Time == nil) {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "one of seq or time is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrs := make([]cipher.Address, len(req.Addresses))
+		for i, a := range req.Addresses {
+			addr, err := cipher.DecodeBase58Address(a)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("address %q is invalid: %v", a, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			addrs[i] = addr
+		}
+
+		var hb *visor.HistoricalBalances
+		var err error
+		if req.Seq != nil {
+			hb, err = gateway.GetBalanceOfAddrsAtSeq(addrs, *req.Seq)
+		} else {
+			hb, err = gateway.GetBalanceOfAddrsAtTime(addrs, *req.Time)
+		}
+		if err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case visor.ErrBlockNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+			default:
+				switch err {
+				case visor.ErrTimeBeforeGenesis:
+					resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		out, err := NewAddressBalance(addrs, *hb)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: out,
+		})
+	}
+}
+
+// AddressTransactions is a page of the confirmed transactions of an address
+type AddressTransactions struct {
+	Transactions []readable.TransactionWithStatus `json:"transactions"`
+	// Next is the cursor of the next page, omitted if the page is the last one
+	Next string `json:"next,omitempty"`
+}
+
+// AddressTransactionsVerbose is a page of the confirmed transactions of an address, with verbose transaction input data
+type AddressTransactionsVerbose struct {
+	Transactions []readable.TransactionWithStatusVerbose `json:"transactions"`
+	// Next is the cursor of the next page, omitted if the page is the last one
+	Next string `json:"next,omitempty"`
+}
+
+const (
+	defaultAddressTransactionsLimit = 100
+	maxAddressTransactionsLimit     = 1000
+)
+
+// addressTransactionsHandler returns a page of the confirmed transactions of an address,
+// ordered by the block that executed them and by their position in the block.
+// The next cursor of a page is the txid of its last transaction, so it remains valid as new blocks are executed.
+// Method: GET
+// URI: /api/v2/address/transactions
+// Args:
+//	address: address [required]
+//	limit: maximum number of transactions in the page [optional, default 100, maximum 1000]
+//	cursor: next cursor returned by the previous page [optional]
+//	order: "asc" for oldest first or "desc" for newest first [optional, default "asc"]
+//	start_seq: only include transactions of blocks with this seq or higher [optional]
+//	end_seq: only include transactions of blocks with this seq or lower [optional]
+//	verbose: [bool] include verbose transaction input data
+func addressTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrStr := r.FormValue("address")
+		if addrStr == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addr, err := cipher.DecodeBase58Address(addrStr)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		verbose, err := parseBoolFlag(r.FormValue("verbose"))
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "Invalid value for verbose")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p := historydb.AddressTxnsPageParams{
+			MaxSeq: math.MaxUint64,
+			Limit:  defaultAddressTransactionsLimit,
+		}
+
+		if s := r.FormValue("limit"); s != "" {
+			p.Limit, err = strconv.ParseUint(s, 10, 64)
+			if err != nil || p.Limit == 0 || p.Limit > maxAddressTransactionsLimit {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxAddressTransactionsLimit))
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if s := r.FormValue("cursor"); s != "" {
+			cursor, err := cipher.SHA256FromHex(s)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid cursor: %v", err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			p.After = &cursor
+		}
+
+		switch r.FormValue("order") {
+		case "", "asc":
+		case "desc":
+			p.Reverse = true
+		default:
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, `order must be "asc" or "desc"`)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if s := r.FormValue("start_seq"); s != "" {
+			p.MinSeq, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid start_seq: %v", err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if s := r.FormValue("end_seq"); s != "" {
+			p.MaxSeq, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid end_seq: %v", err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if p.MinSeq > p.MaxSeq {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "start_seq must not be greater than end_seq")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		page, err := gateway.GetTransactionsPageForAddress(addr, p, verbose)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case historydb.ErrAddressTxnsCursorNotFound:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var next string
+		if page.Next != nil {
+			next = page.Next.Hex()
+		}
+
+		notes, err := getTransactionNotes(gateway)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if verbose {
+			rTxns, err := NewTransactionsWithStatusVerbose(page.Transactions, page.Inputs)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			setTransactionNotesVerbose(rTxns.Transactions, notes)
+
+			writeHTTPResponse(w, HTTPResponse{
+				Data: AddressTransactionsVerbose{
+					Transactions: rTxns.Transactions,
+					Next:         next,
+				},
+			})
+			return
+		}
+
+		rTxns, err := NewTransactionsWithStatus(page.Transactions)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		setTransactionNotes(rTxns.Transactions, notes)
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: AddressTransactions{
+				Transactions: rTxns.Transactions,
+				Next:         next,
+			},
+		})
+	}
+}
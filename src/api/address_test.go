@@ -2,12 +2,24 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/kvstorage"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+	"github.com/skycoin/skycoin/src/wallet"
 )
 
 func toJSON(t *testing.T, r interface{}) string {
@@ -146,3 +158,632 @@ func TestVerifyAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressSummary(t *testing.T) {
+	addr1 := "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv"
+	addr2 := "2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS"
+	addrs := []cipher.Address{
+		cipher.MustDecodeBase58Address(addr1),
+		cipher.MustDecodeBase58Address(addr2),
+	}
+
+	seq3 := uint64(3)
+	seq10 := uint64(10)
+
+	cases := []struct {
+		name                        string
+		method                      string
+		status                      int
+		contentType                 string
+		httpBody                    string
+		gatewayGetAddressSummaries  []*historydb.AddressSummary
+		gatewayGetAddressSummaryErr error
+		httpResponse                HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "415 - Unsupported Media Type",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+
+		{
+			name:         "400 - Missing addresses",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required"),
+		},
+
+		{
+			name:   "400 - Invalid address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			httpBody: toJSON(t, AddressSummaryRequest{
+				Addresses: []string{addr1, "7apQ7t3PZZXvjTst8G7Uvs7XH4LeM8fBPD"},
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `address "7apQ7t3PZZXvjTst8G7Uvs7XH4LeM8fBPD" is invalid: Invalid checksum`),
+		},
+
+		{
+			name:   "500 - gateway error",
+			method: http.MethodPost,
+			status: http.StatusInternalServerError,
+			httpBody: toJSON(t, AddressSummaryRequest{
+				Addresses: []string{addr1, addr2},
+			}),
+			gatewayGetAddressSummaryErr: errors.New("failed"),
+			httpResponse:                NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, AddressSummaryRequest{
+				Addresses: []string{addr1, addr2},
+			}),
+			gatewayGetAddressSummaries: []*historydb.AddressSummary{
+				{
+					Received: 12e6,
+					Sent:     2500000,
+					TxnCount: 4,
+					FirstSeq: 3,
+					LastSeq:  10,
+				},
+				nil,
+			},
+			httpResponse: HTTPResponse{
+				Data: []AddressSummary{
+					{
+						Address:        addr1,
+						Received:       "12.000000",
+						Sent:           "2.500000",
+						Balance:        "9.500000",
+						Transactions:   4,
+						FirstSeenBlock: &seq3,
+						LastSeenBlock:  &seq10,
+					},
+					{
+						Address:  addr2,
+						Received: "0.000000",
+						Sent:     "0.000000",
+						Balance:  "0.000000",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/address/summary"
+			gateway := &MockGatewayer{}
+			gateway.On("GetAddressSummaries", addrs).Return(tc.gatewayGetAddressSummaries, tc.gatewayGetAddressSummaryErr)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			req.Header.Set("Content-Type", contentType)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var summaries []AddressSummary
+				err := json.Unmarshal(rsp.Data, &summaries)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.([]AddressSummary), summaries)
+			}
+		})
+	}
+}
+
+func TestAddressBalance(t *testing.T) {
+	addr1 := "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv"
+	addr2 := "2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS"
+	addrs := []cipher.Address{
+		cipher.MustDecodeBase58Address(addr1),
+		cipher.MustDecodeBase58Address(addr2),
+	}
+
+	seq := uint64(10)
+	ts := uint64(1500000000)
+
+	hb := &visor.HistoricalBalances{
+		Block: coin.BlockHeader{
+			BkSeq: 10,
+			Time:  1499999000,
+		},
+		Balances: []wallet.Balance{
+			{
+				Coins: 12e6,
+				Hours: 100,
+			},
+			{
+				Coins: 1e6,
+				Hours: 5,
+			},
+		},
+	}
+
+	cases := []struct {
+		name                string
+		method              string
+		status              int
+		contentType         string
+		httpBody            string
+		gatewayAtSeqResult  *visor.HistoricalBalances
+		gatewayAtSeqErr     error
+		gatewayAtTimeResult *visor.HistoricalBalances
+		gatewayAtTimeErr    error
+		httpResponse        HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "415 - Unsupported Media Type",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+
+		{
+			name:         "400 - Missing addresses",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required"),
+		},
+
+		{
+			name:   "400 - Missing seq and time",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "one of seq or time is required"),
+		},
+
+		{
+			name:   "400 - Both seq and time",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Seq:       &seq,
+				Time:      &ts,
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "one of seq or time is required"),
+		},
+
+		{
+			name:   "400 - Invalid address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, "7apQ7t3PZZXvjTst8G7Uvs7XH4LeM8fBPD"},
+				Seq:       &seq,
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `address "7apQ7t3PZZXvjTst8G7Uvs7XH4LeM8fBPD" is invalid: Invalid checksum`),
+		},
+
+		{
+			name:   "404 - block does not exist",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Seq:       &seq,
+			}),
+			gatewayAtSeqErr: visor.NewErrBlockNotExist(10),
+			httpResponse:    NewHTTPErrorResponse(http.StatusNotFound, "block does not exist seq=10"),
+		},
+
+		{
+			name:   "404 - time before genesis",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Time:      &ts,
+			}),
+			gatewayAtTimeErr: visor.ErrTimeBeforeGenesis,
+			httpResponse:     NewHTTPErrorResponse(http.StatusNotFound, "time is before the genesis block"),
+		},
+
+		{
+			name:   "503 - history disabled",
+			method: http.MethodPost,
+			status: http.StatusServiceUnavailable,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Seq:       &seq,
+			}),
+			gatewayAtSeqErr: visor.ErrHistoryDisabled,
+			httpResponse:    NewHTTPErrorResponse(http.StatusServiceUnavailable, "history is disabled"),
+		},
+
+		{
+			name:   "500 - gateway error",
+			method: http.MethodPost,
+			status: http.StatusInternalServerError,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Time:      &ts,
+			}),
+			gatewayAtTimeErr: errors.New("failed"),
+			httpResponse:     NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+
+		{
+			name:   "200 - seq",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Seq:       &seq,
+			}),
+			gatewayAtSeqResult: hb,
+			httpResponse: HTTPResponse{
+				Data: AddressBalance{
+					Head: readable.NewBlockHeader(hb.Block),
+					Confirmed: readable.Balance{
+						Coins: 13e6,
+						Hours: 105,
+					},
+					Addresses: map[string]readable.Balance{
+						addr1: readable.Balance{
+							Coins: 12e6,
+							Hours: 100,
+						},
+						addr2: readable.Balance{
+							Coins: 1e6,
+							Hours: 5,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name:   "200 - time",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, AddressBalanceRequest{
+				Addresses: []string{addr1, addr2},
+				Time:      &ts,
+			}),
+			gatewayAtTimeResult: hb,
+			httpResponse: HTTPResponse{
+				Data: AddressBalance{
+					Head: readable.NewBlockHeader(hb.Block),
+					Confirmed: readable.Balance{
+						Coins: 13e6,
+						Hours: 105,
+					},
+					Addresses: map[string]readable.Balance{
+						addr1: readable.Balance{
+							Coins: 12e6,
+							Hours: 100,
+						},
+						addr2: readable.Balance{
+							Coins: 1e6,
+							Hours: 5,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/address/balance"
+			gateway := &MockGatewayer{}
+			gateway.On("GetBalanceOfAddrsAtSeq", addrs, seq).Return(tc.gatewayAtSeqResult, tc.gatewayAtSeqErr)
+			gateway.On("GetBalanceOfAddrsAtTime", addrs, ts).Return(tc.gatewayAtTimeResult, tc.gatewayAtTimeErr)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			req.Header.Set("Content-Type", contentType)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var balance AddressBalance
+				err := json.Unmarshal(rsp.Data, &balance)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(AddressBalance), balance)
+			}
+		})
+	}
+}
+
+func TestAddressTransactions(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	txn := makeTransaction(t)
+	txns := []visor.Transaction{
+		{
+			Transaction: txn,
+			Status:      visor.NewConfirmedTransactionStatus(2, 4),
+			Time:        1540000000,
+		},
+	}
+
+	rTxns, err := NewTransactionsWithStatus(txns)
+	require.NoError(t, err)
+	rTxns.Transactions[0].Note = "note"
+
+	cursor := testutil.RandSHA256(t)
+	next := txn.Hash()
+
+	defaultParams := historydb.AddressTxnsPageParams{
+		MaxSeq: math.MaxUint64,
+		Limit:  defaultAddressTransactionsLimit,
+	}
+
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		query        url.Values
+		gatewayArg   historydb.AddressTxnsPageParams
+		gatewayPage  *visor.AddressTransactionsPage
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "400 - missing address",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address is required"),
+		},
+
+		{
+			name:   "400 - invalid address",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address": []string{"7apQ7t3PZZXvjTst8G7Uvs7XH4LeM8fBPD"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid address: Invalid checksum"),
+		},
+
+		{
+			name:   "400 - invalid limit",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address": []string{addr.String()},
+				"limit":   []string{"1001"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "limit must be an integer between 1 and 1000"),
+		},
+
+		{
+			name:   "400 - invalid cursor",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address": []string{addr.String()},
+				"cursor":  []string{"foo"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid cursor: encoding/hex: invalid byte: U+006F 'o'"),
+		},
+
+		{
+			name:   "400 - invalid order",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address": []string{addr.String()},
+				"order":   []string{"newest"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `order must be "asc" or "desc"`),
+		},
+
+		{
+			name:   "400 - start_seq greater than end_seq",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address":   []string{addr.String()},
+				"start_seq": []string{"10"},
+				"end_seq":   []string{"9"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "start_seq must not be greater than end_seq"),
+		},
+
+		{
+			name:   "400 - cursor not found",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			query: url.Values{
+				"address": []string{addr.String()},
+			},
+			gatewayArg:   defaultParams,
+			gatewayErr:   historydb.ErrAddressTxnsCursorNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, historydb.ErrAddressTxnsCursorNotFound.Error()),
+		},
+
+		{
+			name:   "500 - gateway error",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			query: url.Values{
+				"address": []string{addr.String()},
+			},
+			gatewayArg:   defaultParams,
+			gatewayErr:   errors.New("failed"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+
+		{
+			name:   "200 - last page",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			query: url.Values{
+				"address": []string{addr.String()},
+			},
+			gatewayArg: defaultParams,
+			gatewayPage: &visor.AddressTransactionsPage{
+				Transactions: txns,
+			},
+			httpResponse: HTTPResponse{
+				Data: AddressTransactions{
+					Transactions: rTxns.Transactions,
+				},
+			},
+		},
+
+		{
+			name:   "200 - all params",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			query: url.Values{
+				"address":   []string{addr.String()},
+				"limit":     []string{"1"},
+				"cursor":    []string{cursor.Hex()},
+				"order":     []string{"desc"},
+				"start_seq": []string{"2"},
+				"end_seq":   []string{"8"},
+			},
+			gatewayArg: historydb.AddressTxnsPageParams{
+				MinSeq:  2,
+				MaxSeq:  8,
+				Reverse: true,
+				After:   &cursor,
+				Limit:   1,
+			},
+			gatewayPage: &visor.AddressTransactionsPage{
+				Transactions: txns,
+				Next:         &next,
+			},
+			httpResponse: HTTPResponse{
+				Data: AddressTransactions{
+					Transactions: rTxns.Transactions,
+					Next:         next.Hex(),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/address/transactions"
+			gateway := &MockGatewayer{}
+			gateway.On("GetTransactionsPageForAddress", addr, tc.gatewayArg, false).Return(tc.gatewayPage, tc.gatewayErr)
+			gateway.On("GetAllStorageValues", kvstorage.TypeTxIDNotes).Return(map[string]string{
+				txn.Hash().Hex(): "note",
+			}, nil)
+
+			if len(tc.query) > 0 {
+				endpoint += "?" + tc.query.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var page AddressTransactions
+				err := json.Unmarshal(rsp.Data, &page)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(AddressTransactions), page)
+			}
+		})
+	}
+}
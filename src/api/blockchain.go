@@ -148,7 +148,17 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err.(type) {
+				case visor.ErrBlockPruned:
+					wh.Error503(w, err.Error())
+				default:
+					switch err {
+					case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+						wh.Error503(w, err.Error())
+					default:
+						wh.Error500(w, err.Error())
+					}
+				}
 				return
 			}
 
@@ -176,7 +186,12 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		if err != nil {
-			wh.Error500(w, err.Error())
+			switch err.(type) {
+			case visor.ErrBlockPruned:
+				wh.Error503(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 
@@ -291,8 +306,15 @@ func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 				switch err.(type) {
 				case visor.ErrBlockNotExist:
 					wh.Error404(w, err.Error())
+				case visor.ErrBlockPruned:
+					wh.Error503(w, err.Error())
 				default:
-					wh.Error500(w, err.Error())
+					switch err {
+					case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+						wh.Error503(w, err.Error())
+					default:
+						wh.Error500(w, err.Error())
+					}
 				}
 				return
 			}
@@ -318,6 +340,8 @@ func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 				switch err.(type) {
 				case visor.ErrBlockNotExist:
 					wh.Error404(w, err.Error())
+				case visor.ErrBlockPruned:
+					wh.Error503(w, err.Error())
 				default:
 					wh.Error500(w, err.Error())
 				}
@@ -364,7 +388,17 @@ func lastBlocksHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			blocks, inputs, err := gateway.GetLastBlocksVerbose(n)
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err.(type) {
+				case visor.ErrBlockPruned:
+					wh.Error503(w, err.Error())
+				default:
+					switch err {
+					case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+						wh.Error503(w, err.Error())
+					default:
+						wh.Error500(w, err.Error())
+					}
+				}
 				return
 			}
 
@@ -380,7 +414,12 @@ func lastBlocksHandler(gateway Gatewayer) http.HandlerFunc {
 
 		blocks, err := gateway.GetLastBlocks(n)
 		if err != nil {
-			wh.Error500(w, err.Error())
+			switch err.(type) {
+			case visor.ErrBlockPruned:
+				wh.Error503(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 
@@ -371,6 +371,15 @@ func TestGetBlock(t *testing.T) {
 			seq:                     1,
 			gatewayGetBlockBySeqErr: errors.New("GetSignedBlockBySeq failed"),
 		},
+		{
+			name:                    "503 - get block by seq pruned",
+			method:                  http.MethodGet,
+			status:                  http.StatusServiceUnavailable,
+			err:                     "503 Service Unavailable - block body pruned seq=1",
+			seqStr:                  "1",
+			seq:                     1,
+			gatewayGetBlockBySeqErr: visor.NewErrBlockPruned(1),
+		},
 		{
 			name:                       "200 - get block by seq",
 			method:                     http.MethodGet,
@@ -500,6 +509,30 @@ func TestGetBlock(t *testing.T) {
 			err:                            "500 Internal Server Error - GetSignedBlockBySeqVerbose failed",
 		},
 
+		{
+			name:                           "503 - get block by seq verbose pruned",
+			method:                         http.MethodGet,
+			status:                         http.StatusServiceUnavailable,
+			seq:                            1,
+			seqStr:                         "1",
+			verbose:                        true,
+			verboseStr:                     "1",
+			gatewayGetBlockBySeqVerboseErr: visor.NewErrBlockPruned(1),
+			err:                            "503 Service Unavailable - block body pruned seq=1",
+		},
+
+		{
+			name:                           "503 - get block by seq verbose history disabled",
+			method:                         http.MethodGet,
+			status:                         http.StatusServiceUnavailable,
+			seq:                            1,
+			seqStr:                         "1",
+			verbose:                        true,
+			verboseStr:                     "1",
+			gatewayGetBlockBySeqVerboseErr: visor.ErrHistoryDisabled,
+			err:                            "503 Service Unavailable - history is disabled",
+		},
+
 		{
 			name:       "404 - get block by hash verbose not found",
 			method:     http.MethodGet,
@@ -756,6 +789,20 @@ func TestGetBlocks(t *testing.T) {
 			gatewayGetBlocksVerboseError: visor.NewErrBlockNotExist(4),
 		},
 
+		{
+			name:   "503 - block seq pruned",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - block body pruned seq=1",
+			body: &httpBody{
+				Start: "1",
+				End:   "3",
+			},
+			start:                        1,
+			end:                          3,
+			gatewayGetBlocksInRangeError: visor.NewErrBlockPruned(1),
+		},
+
 		{
 			name:   "500 - gatewayGetBlocksInRangeError",
 			method: http.MethodGet,
@@ -784,6 +831,21 @@ func TestGetBlocks(t *testing.T) {
 			verbose:                             true,
 			gatewayGetBlocksInRangeVerboseError: errors.New("gatewayGetBlocksInRangeVerboseError"),
 		},
+		{
+			name:   "503 - gatewayGetBlocksInRangeVerboseError history rebuilding",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is being rebuilt",
+			body: &httpBody{
+				Start:   "1",
+				End:     "3",
+				Verbose: "1",
+			},
+			start:                               1,
+			end:                                 3,
+			verbose:                             true,
+			gatewayGetBlocksInRangeVerboseError: visor.ErrHistoryRebuilding,
+		},
 
 		{
 			name:   "500 - gatewayGetBlocksError",
@@ -809,6 +871,19 @@ func TestGetBlocks(t *testing.T) {
 			verbose:                      true,
 			gatewayGetBlocksVerboseError: errors.New("gatewayGetBlocksVerboseError"),
 		},
+		{
+			name:   "503 - gatewayGetBlocksVerboseError history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			body: &httpBody{
+				Seqs:    "1,2,3",
+				Verbose: "1",
+			},
+			seqs:                         []uint64{1, 2, 3},
+			verbose:                      true,
+			gatewayGetBlocksVerboseError: visor.ErrHistoryDisabled,
+		},
 
 		{
 			name:   "200 range",
@@ -1097,6 +1172,17 @@ func TestGetLastBlocks(t *testing.T) {
 			num:                       1,
 			gatewayGetLastBlocksError: errors.New("gatewayGetLastBlocksError"),
 		},
+		{
+			name:   "503 - gatewayGetLastBlocksError pruned",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - block body pruned seq=1",
+			body: httpBody{
+				Num: "200",
+			},
+			num:                       200,
+			gatewayGetLastBlocksError: visor.NewErrBlockPruned(1),
+		},
 		{
 			name:   "500 - gatewayGetLastBlocksVerboseError",
 			method: http.MethodGet,
@@ -1110,6 +1196,19 @@ func TestGetLastBlocks(t *testing.T) {
 			verbose:                          true,
 			gatewayGetLastBlocksVerboseError: errors.New("gatewayGetLastBlocksVerboseError"),
 		},
+		{
+			name:   "503 - gatewayGetLastBlocksVerboseError history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			body: httpBody{
+				Num:     "1",
+				Verbose: "1",
+			},
+			num:                              1,
+			verbose:                          true,
+			gatewayGetLastBlocksVerboseError: visor.ErrHistoryDisabled,
+		},
 		{
 			name:   "200",
 			method: http.MethodGet,
@@ -2,6 +2,8 @@ package api
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,13 +12,16 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/visor"
 )
 
 const (
@@ -28,6 +33,8 @@ const (
 	ContentTypeJSON = "application/json"
 	// ContentTypeForm form data content type header
 	ContentTypeForm = "application/x-www-form-urlencoded"
+	// ContentTypeOctetStream binary data content type header
+	ContentTypeOctetStream = "application/octet-stream"
 )
 
 // ClientError is used for non-200 API responses
@@ -409,6 +416,50 @@ func (c *Client) CoinSupply() (*CoinSupply, error) {
 	return &cs, nil
 }
 
+// SupplyStatsParams are arguments to the /api/v2/coinSupply/stats endpoint
+type SupplyStatsParams struct {
+	StartSeq  *uint64
+	EndSeq    *uint64
+	StartTime *uint64
+	EndTime   *uint64
+	Interval  uint64
+	Limit     uint64
+}
+
+func (p SupplyStatsParams) values() url.Values {
+	v := url.Values{}
+	if p.StartSeq != nil {
+		v.Add("start_seq", fmt.Sprint(*p.StartSeq))
+	}
+	if p.EndSeq != nil {
+		v.Add("end_seq", fmt.Sprint(*p.EndSeq))
+	}
+	if p.StartTime != nil {
+		v.Add("start_time", fmt.Sprint(*p.StartTime))
+	}
+	if p.EndTime != nil {
+		v.Add("end_time", fmt.Sprint(*p.EndTime))
+	}
+	if p.Interval != 0 {
+		v.Add("interval", fmt.Sprint(p.Interval))
+	}
+	if p.Limit != 0 {
+		v.Add("limit", fmt.Sprint(p.Limit))
+	}
+	return v
+}
+
+// CoinSupplyStats makes a request to GET /api/v2/coinSupply/stats
+func (c *Client) CoinSupplyStats(params SupplyStatsParams) ([]SupplyStats, error) {
+	var rsp []SupplyStats
+	ok, err := c.GetV2("/api/v2/coinSupply/stats?"+params.values().Encode(), &rsp)
+	if ok {
+		return rsp, err
+	}
+
+	return nil, err
+}
+
 // BlockByHash makes a request to GET /api/v1/block?hash=xxx
 func (c *Client) BlockByHash(hash string) (*readable.Block, error) {
 	v := url.Values{}
@@ -719,6 +770,7 @@ type CreateTransactionRequest struct {
 	To                []Receiver     `json:"to"`
 	UxOuts            []string       `json:"unspents,omitempty"`
 	Addresses         []string       `json:"addresses,omitempty"`
+	Note              string         `json:"note,omitempty"`
 }
 
 // HoursSelection defines options for hours distribution
@@ -954,6 +1006,26 @@ func (c *Client) PendingTransactionsVerbose() ([]readable.UnconfirmedTransaction
 	return v, nil
 }
 
+// PendingTransactionsEvicted makes a request to GET /api/v1/pendingTxs?evicted=1
+func (c *Client) PendingTransactionsEvicted() ([]EvictedTransaction, error) {
+	var v []EvictedTransaction
+	if err := c.Get("/api/v1/pendingTxs?evicted=1", &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PendingTransactionsAssembly makes a request to GET /api/v2/pendingTxs/assembly
+func (c *Client) PendingTransactionsAssembly() (*BlockAssemblyReport, error) {
+	var rsp BlockAssemblyReport
+	ok, err := c.GetV2("/api/v2/pendingTxs/assembly", &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // Transaction makes a request to GET /api/v1/transaction
 func (c *Client) Transaction(txid string) (*readable.TransactionWithStatus, error) {
 	v := url.Values{}
@@ -1092,10 +1164,18 @@ func (c *Client) InjectTransaction(txn *coin.Transaction) (string, error) {
 // InjectEncodedTransaction makes a request to POST /api/v1/injectTransaction.
 // rawTxn is a hex-encoded, serialized transaction
 func (c *Client) InjectEncodedTransaction(rawTxn string) (string, error) {
+	return c.InjectEncodedTransactionWithNote(rawTxn, "")
+}
+
+// InjectEncodedTransactionWithNote makes a request to POST /api/v1/injectTransaction.
+// rawTxn is a hex-encoded, serialized transaction. The note is stored if the transaction is injected.
+func (c *Client) InjectEncodedTransactionWithNote(rawTxn, note string) (string, error) {
 	v := struct {
 		Rawtxn string `json:"rawtx"`
+		Note   string `json:"note,omitempty"`
 	}{
 		Rawtxn: rawTxn,
+		Note:   note,
 	}
 
 	var txid string
@@ -1139,6 +1219,37 @@ func (c *Client) VerifyTransaction(req VerifyTransactionRequest) (*VerifyTransac
 	return nil, err
 }
 
+// TransactionProof makes a request to GET /api/v2/transaction/proof.
+// The proof should be checked with VerifyTransactionProof before it is trusted.
+func (c *Client) TransactionProof(txid string) (*TransactionProof, error) {
+	v := url.Values{}
+	v.Add("txid", txid)
+
+	var rsp TransactionProof
+	ok, err := c.GetV2("/api/v2/transaction/proof?"+v.Encode(), &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// VerifyTransactionProof checks that the transaction txid is included in the block of a TransactionProof,
+// without trusting the node that returned it. The block header must be signed by the blockchain pubkey,
+// and the merkle proof of txid must lead to the header's body hash.
+func VerifyTransactionProof(pubkey cipher.PubKey, txid cipher.SHA256, p TransactionProof) error {
+	proof, err := p.ToVisorTransactionProof()
+	if err != nil {
+		return err
+	}
+
+	if proof.Txid != txid {
+		return fmt.Errorf("transaction proof is for transaction %s, not %s", proof.Txid.Hex(), txid.Hex())
+	}
+
+	return proof.Verify(pubkey)
+}
+
 // VerifyAddress makes a request to POST /api/v2/address/verify
 // The API may respond with an error but include data useful for processing,
 // so both return values may be non-nil.
@@ -1156,10 +1267,94 @@ func (c *Client) VerifyAddress(addr string) (*VerifyAddressResponse, error) {
 	return nil, err
 }
 
+// AddressSummary makes a request to POST /api/v2/address/summary
+func (c *Client) AddressSummary(addrs []string) ([]AddressSummary, error) {
+	req := AddressSummaryRequest{
+		Addresses: addrs,
+	}
+
+	var rsp []AddressSummary
+	ok, err := c.PostJSONV2("/api/v2/address/summary", req, &rsp)
+	if ok {
+		return rsp, err
+	}
+
+	return nil, err
+}
+
+// AddressBalance makes a request to POST /api/v2/address/balance
+func (c *Client) AddressBalance(req AddressBalanceRequest) (*AddressBalance, error) {
+	var rsp AddressBalance
+	ok, err := c.PostJSONV2("/api/v2/address/balance", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// AddressTransactionsParams are arguments to the /api/v2/address/transactions endpoint
+type AddressTransactionsParams struct {
+	Address  string
+	Limit    uint64
+	Cursor   string
+	Desc     bool
+	StartSeq *uint64
+	EndSeq   *uint64
+}
+
+func (p AddressTransactionsParams) values() url.Values {
+	v := url.Values{}
+	v.Add("address", p.Address)
+	if p.Limit != 0 {
+		v.Add("limit", fmt.Sprint(p.Limit))
+	}
+	if p.Cursor != "" {
+		v.Add("cursor", p.Cursor)
+	}
+	if p.Desc {
+		v.Add("order", "desc")
+	}
+	if p.StartSeq != nil {
+		v.Add("start_seq", fmt.Sprint(*p.StartSeq))
+	}
+	if p.EndSeq != nil {
+		v.Add("end_seq", fmt.Sprint(*p.EndSeq))
+	}
+	return v
+}
+
+// AddressTransactions makes a request to GET /api/v2/address/transactions
+func (c *Client) AddressTransactions(params AddressTransactionsParams) (*AddressTransactions, error) {
+	var rsp AddressTransactions
+	ok, err := c.GetV2("/api/v2/address/transactions?"+params.values().Encode(), &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// AddressTransactionsVerbose makes a request to GET /api/v2/address/transactions?verbose=1
+func (c *Client) AddressTransactionsVerbose(params AddressTransactionsParams) (*AddressTransactionsVerbose, error) {
+	v := params.values()
+	v.Add("verbose", "1")
+
+	var rsp AddressTransactionsVerbose
+	ok, err := c.GetV2("/api/v2/address/transactions?"+v.Encode(), &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // RichlistParams are arguments to the /richlist endpoint
 type RichlistParams struct {
 	N                   int
+	Offset              uint64
 	IncludeDistribution bool
+	Holders             bool
 }
 
 // Richlist makes a request to GET /api/v1/richlist
@@ -1169,7 +1364,13 @@ func (c *Client) Richlist(params *RichlistParams) (*Richlist, error) {
 	if params != nil {
 		v := url.Values{}
 		v.Add("n", fmt.Sprint(params.N))
+		if params.Offset != 0 {
+			v.Add("offset", fmt.Sprint(params.Offset))
+		}
 		v.Add("include-distribution", fmt.Sprint(params.IncludeDistribution))
+		if params.Holders {
+			v.Add("holders", "true")
+		}
 		endpoint = "/api/v1/richlist?" + v.Encode()
 	}
 
@@ -1275,6 +1476,21 @@ func (c *Client) GetAllStorageValues(storageType kvstorage.Type) (map[string]str
 	return values, err
 }
 
+// TransactionNotes makes a GET request to /api/v2/transaction/notes to search transaction notes
+func (c *Client) TransactionNotes(search string) ([]TransactionNote, error) {
+	v := url.Values{}
+	v.Add("search", search)
+	endpoint := "/api/v2/transaction/notes?" + v.Encode()
+
+	var notes []TransactionNote
+	ok, err := c.GetV2(endpoint, &notes)
+	if !ok {
+		return nil, err
+	}
+
+	return notes, err
+}
+
 // GetStorageValue makes a GET request to /api/v2/data to get the value associated with `key` from storage
 // of `storageType` type
 func (c *Client) GetStorageValue(storageType kvstorage.Type, key string) (string, error) {
@@ -1306,3 +1522,110 @@ func (c *Client) RemoveStorageValue(storageType kvstorage.Type, key string) erro
 
 	return err
 }
+
+// DatabaseBackup makes a GET request to /api/v2/db/backup and writes the database backup to w.
+// The data written to w is checked against the size and checksum sent by the node.
+func (c *Client) DatabaseBackup(w io.Writer) (*visor.BackupManifest, error) {
+	resp, err := c.get("/api/v2/db/backup")
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var wrapObj ReceivedHTTPResponse
+		if err := json.Unmarshal(body, &wrapObj); err == nil && wrapObj.Error != nil {
+			return nil, NewClientError(resp.Status, resp.StatusCode, wrapObj.Error.Message)
+		}
+
+		return nil, NewClientError(resp.Status, resp.StatusCode, string(body))
+	}
+
+	var m visor.BackupManifest
+
+	m.HeadSeq, err = strconv.ParseUint(resp.Header.Get(BackupHeadSeqHeader), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %v", BackupHeadSeqHeader, err)
+	}
+
+	m.Size, err = strconv.ParseInt(resp.Header.Get(BackupSizeHeader), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %v", BackupSizeHeader, err)
+	}
+
+	m.CreatedAt, err = time.Parse(time.RFC3339Nano, resp.Header.Get(BackupCreatedAtHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %v", BackupCreatedAtHeader, err)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The trailer is only available after the body has been read
+	m.SHA256 = resp.Trailer.Get(BackupSHA256Trailer)
+	if m.SHA256 == "" {
+		return nil, errors.New("database backup did not complete, the checksum trailer is missing")
+	}
+
+	if n != m.Size {
+		return nil, fmt.Errorf("received %d bytes of the database backup, expected %d", n, m.Size)
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != m.SHA256 {
+		return nil, visor.ErrBackupChecksumMismatch
+	}
+
+	return &m, nil
+}
+
+// BlockchainExport makes a GET request to /api/v2/blockchain/export and writes the exported rows to w.
+// Returns the number of rows, which the node sends after the export is complete.
+func (c *Client) BlockchainExport(p visor.ExportParams, w io.Writer) (uint64, error) {
+	v := url.Values{}
+	v.Add("table", string(p.Table))
+	v.Add("format", string(p.Format))
+	v.Add("start_seq", strconv.FormatUint(p.StartSeq, 10))
+	v.Add("end_seq", strconv.FormatUint(p.EndSeq, 10))
+
+	resp, err := c.get("/api/v2/blockchain/export?" + v.Encode())
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+
+		var wrapObj ReceivedHTTPResponse
+		if err := json.Unmarshal(body, &wrapObj); err == nil && wrapObj.Error != nil {
+			return 0, NewClientError(resp.Status, resp.StatusCode, wrapObj.Error.Message)
+		}
+
+		return 0, NewClientError(resp.Status, resp.StatusCode, string(body))
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return 0, err
+	}
+
+	// The trailer is only available after the body has been read
+	rows := resp.Trailer.Get(ExportRowsTrailer)
+	if rows == "" {
+		return 0, errors.New("blockchain export did not complete, the rows trailer is missing")
+	}
+
+	return strconv.ParseUint(rows, 10, 64)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+const (
+	// BackupHeadSeqHeader is the response header of the database backup endpoint with the head block of the backup
+	BackupHeadSeqHeader = "X-Backup-Head-Seq"
+	// BackupSizeHeader is the response header of the database backup endpoint with the size of the backup in bytes
+	BackupSizeHeader = "X-Backup-Size"
+	// BackupCreatedAtHeader is the response header of the database backup endpoint with the RFC3339 time of the backup
+	BackupCreatedAtHeader = "X-Backup-Created-At"
+	// BackupSHA256Trailer is the response trailer of the database backup endpoint with the hex encoded SHA256 checksum of the backup
+	BackupSHA256Trailer = "X-Backup-Sha256"
+)
+
+// dbBackupHandler streams a consistent copy of the blockchain database while the node is running.
+// The head block and size of the backup are sent as headers.
+// The checksum is sent as a trailer, after the backup has been written.
+// If the backup fails after it has started, the response ends without the checksum trailer.
+// Method: GET
+// URI: /api/v2/db/backup
+func dbBackupHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		started := false
+		m, err := gateway.Backup(w, func(m visor.BackupManifest) {
+			started = true
+			w.Header().Set("Trailer", BackupSHA256Trailer)
+			w.Header().Set("Content-Type", ContentTypeOctetStream)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-%d.db"`, m.HeadSeq))
+			w.Header().Set(BackupHeadSeqHeader, strconv.FormatUint(m.HeadSeq, 10))
+			w.Header().Set(BackupSizeHeader, strconv.FormatInt(m.Size, 10))
+			w.Header().Set(BackupCreatedAtHeader, m.CreatedAt.Format(time.RFC3339Nano))
+			w.WriteHeader(http.StatusOK)
+		})
+		if err != nil {
+			if started {
+				logger.WithError(err).Error("Database backup failed")
+				return
+			}
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		w.Header().Set(BackupSHA256Trailer, m.SHA256)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func TestDBBackupHandler(t *testing.T) {
+	backupData := []byte("backup data")
+	createdAt := time.Date(2019, 3, 11, 9, 23, 15, 0, time.UTC)
+	manifest := visor.BackupManifest{
+		HeadSeq:   180,
+		Size:      int64(len(backupData)),
+		CreatedAt: createdAt,
+	}
+	completeManifest := manifest
+	completeManifest.SHA256 = "5b1e7c0f1b5b0cd8a4f3b8e1c3ce6a9a47b3a93f13d6e2ed5b3b2fbb4a7f0d21"
+
+	// backup starts the response like Visor.Backup and writes data to it
+	backup := func(data []byte) func(io.Writer, func(visor.BackupManifest)) error {
+		return func(w io.Writer, begin func(visor.BackupManifest)) error {
+			begin(manifest)
+			_, err := w.Write(data)
+			return err
+		}
+	}
+
+	tt := []struct {
+		name         string
+		method       string
+		status       int
+		backup       func(io.Writer, func(visor.BackupManifest)) error
+		backupResult *visor.BackupManifest
+		backupErr    error
+		httpResponse HTTPResponse
+		body         []byte
+		headers      map[string]string
+		trailer      string
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "500 - backup failed before starting",
+			method:       http.MethodGet,
+			status:       http.StatusInternalServerError,
+			backupErr:    errors.New("backup failed"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "backup failed"),
+		},
+		{
+			name:      "200 - backup failed after starting",
+			method:    http.MethodGet,
+			status:    http.StatusOK,
+			backup:    backup(backupData[:4]),
+			backupErr: errors.New("backup failed"),
+			body:      backupData[:4],
+		},
+		{
+			name:         "200",
+			method:       http.MethodGet,
+			status:       http.StatusOK,
+			backup:       backup(backupData),
+			backupResult: &completeManifest,
+			body:         backupData,
+			headers: map[string]string{
+				"Content-Type":        ContentTypeOctetStream,
+				"Content-Disposition": `attachment; filename="data-180.db"`,
+				BackupHeadSeqHeader:   "180",
+				BackupSizeHeader:      "11",
+				BackupCreatedAtHeader: "2019-03-11T09:23:15Z",
+			},
+			trailer: completeManifest.SHA256,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			call := gateway.On("Backup", mock.Anything, mock.Anything).Return(tc.backupResult, tc.backupErr)
+			if tc.backup != nil {
+				call.Run(func(args mock.Arguments) {
+					err := tc.backup(args.Get(0).(io.Writer), args.Get(1).(func(visor.BackupManifest)))
+					require.NoError(t, err)
+				})
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v2/db/backup", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if tc.body == nil {
+				var rsp ReceivedHTTPResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Error, rsp.Error)
+				return
+			}
+
+			require.Equal(t, tc.body, rr.Body.Bytes())
+
+			resp := rr.Result()
+			for k, v := range tc.headers {
+				require.Equal(t, v, resp.Header.Get(k), k)
+			}
+			require.Equal(t, tc.trailer, resp.Trailer.Get(BackupSHA256Trailer))
+		})
+	}
+}
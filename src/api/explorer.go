@@ -1,7 +1,10 @@
 package api
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/skycoin/skycoin/src/util/droplet"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor"
 )
 
 // CoinSupply records the coin supply info
@@ -158,14 +162,17 @@ func coinSupplyHandler(gateway Gatewayer) http.HandlerFunc {
 // Richlist contains top address balances
 type Richlist struct {
 	Richlist []readable.RichlistBalance `json:"richlist"`
+	Holders  *readable.RichlistHolders  `json:"holders,omitempty"`
 }
 
 // richlistHandler returns the top skycoin holders
 // Method: GET
-// URI: /richlist?n=${number}&include-distribution=${bool}
+// URI: /richlist?n=${number}&offset=${number}&include-distribution=${bool}&holders=${bool}
 // Args:
 //	n [int, number of results to include]
+//	offset [int, number of results to skip]
 //  include-distribution [bool, include the distribution addresses in the richlist]
+//	holders [bool, include the number of addresses holding coins]
 func richlistHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -186,6 +193,21 @@ func richlistHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
+		if topn < 0 {
+			topn = 0
+		}
+
+		var offset uint64
+		offsetStr := r.FormValue("offset")
+		if offsetStr != "" {
+			var err error
+			offset, err = strconv.ParseUint(offsetStr, 10, 64)
+			if err != nil {
+				wh.Error400(w, "invalid offset")
+				return
+			}
+		}
+
 		var includeDistribution bool
 		includeDistributionStr := r.FormValue("include-distribution")
 		if includeDistributionStr == "" {
@@ -199,25 +221,48 @@ func richlistHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
-		richlist, err := gateway.GetRichlist(includeDistribution)
+		var includeHolders bool
+		includeHoldersStr := r.FormValue("holders")
+		if includeHoldersStr != "" {
+			var err error
+			includeHolders, err = strconv.ParseBool(includeHoldersStr)
+			if err != nil {
+				wh.Error400(w, "invalid holders")
+				return
+			}
+		}
+
+		richlist, err := gateway.GetRichlist(includeDistribution, offset, uint64(topn))
 		if err != nil {
 			wh.Error500(w, err.Error())
 			return
 		}
 
-		if topn > 0 && topn < len(richlist) {
-			richlist = richlist[:topn]
-		}
-
 		readableRichlist, err := readable.NewRichlistBalances(richlist)
 		if err != nil {
 			wh.Error500(w, err.Error())
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, Richlist{
+		resp := Richlist{
 			Richlist: readableRichlist,
-		})
+		}
+
+		if includeHolders {
+			holders, err := gateway.GetRichlistHolders(includeDistribution)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			resp.Holders, err = readable.NewRichlistHolders(holders)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+		}
+
+		wh.SendJSONOr500(logger, w, resp)
 	}
 }
 
@@ -240,3 +285,211 @@ func addressCountHandler(gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, &map[string]uint64{"count": addrCount})
 	}
 }
+
+const (
+	defaultSupplyStatsLimit = 100
+	maxSupplyStatsLimit     = 10000
+)
+
+// SupplyStats are the coin supply and distribution statistics of a period of blocks.
+// The supply, coin hours and active addresses are as of the last block of the period.
+type SupplyStats struct {
+	PeriodStart       uint64 `json:"period_start"`
+	Blocks            uint64 `json:"blocks"`
+	BlockSeq          uint64 `json:"block_seq"`
+	BlockTime         uint64 `json:"block_time"`
+	TotalSupply       string `json:"total_supply"`
+	CirculatingSupply string `json:"circulating_supply"`
+	TotalCoinHours    uint64 `json:"total_coin_hours"`
+	ActiveAddresses   uint64 `json:"active_addresses"`
+	Transactions      uint64 `json:"transactions"`
+	BurnedCoinHours   uint64 `json:"burned_coin_hours"`
+}
+
+// NewSupplyStats creates SupplyStats from visor.SupplyStats
+func NewSupplyStats(s visor.SupplyStats) (SupplyStats, error) {
+	totalSupply, err := droplet.ToString(s.TotalSupply)
+	if err != nil {
+		return SupplyStats{}, err
+	}
+
+	circulatingSupply, err := droplet.ToString(s.CirculatingSupply)
+	if err != nil {
+		return SupplyStats{}, err
+	}
+
+	return SupplyStats{
+		PeriodStart:       s.PeriodStart,
+		Blocks:            s.Blocks,
+		BlockSeq:          s.BlockSeq,
+		BlockTime:         s.BlockTime,
+		TotalSupply:       totalSupply,
+		CirculatingSupply: circulatingSupply,
+		TotalCoinHours:    s.TotalCoinHours,
+		ActiveAddresses:   s.ActiveAddresses,
+		Transactions:      s.TxnCount,
+		BurnedCoinHours:   s.BurnedHours,
+	}, nil
+}
+
+// supplyStatsCSVHeader are the columns of the CSV written by WriteSupplyStatsCSV, in the order of the SupplyStats JSON fields
+var supplyStatsCSVHeader = []string{
+	"period_start",
+	"blocks",
+	"block_seq",
+	"block_time",
+	"total_supply",
+	"circulating_supply",
+	"total_coin_hours",
+	"active_addresses",
+	"transactions",
+	"burned_coin_hours",
+}
+
+// WriteSupplyStatsCSV writes supply statistics to w as CSV, with a header row
+func WriteSupplyStatsCSV(w io.Writer, stats []SupplyStats) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(supplyStatsCSVHeader); err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		if err := cw.Write([]string{
+			strconv.FormatUint(s.PeriodStart, 10),
+			strconv.FormatUint(s.Blocks, 10),
+			strconv.FormatUint(s.BlockSeq, 10),
+			strconv.FormatUint(s.BlockTime, 10),
+			s.TotalSupply,
+			s.CirculatingSupply,
+			strconv.FormatUint(s.TotalCoinHours, 10),
+			strconv.FormatUint(s.ActiveAddresses, 10),
+			strconv.FormatUint(s.Transactions, 10),
+			strconv.FormatUint(s.BurnedCoinHours, 10),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// supplyStatsHandler returns the coin supply and distribution statistics of a range of blocks,
+// for each block or grouped into periods of time
+// Method: GET
+// URI: /api/v2/coinSupply/stats
+// Args:
+//	start_seq: only include blocks with this seq or higher [optional]
+//	end_seq: only include blocks with this seq or lower [optional]
+//	start_time: only include blocks created at or after this unix time [optional]
+//	end_time: only include blocks created at or before this unix time [optional]
+//	interval: group the blocks into periods of this many seconds [optional, default 0 for no grouping]
+//	limit: maximum number of periods to return [optional, default 100, maximum 10000]
+//	format: "json" or "csv" [optional, default "json"]
+func supplyStatsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p := visor.SupplyStatsParams{
+			MaxSeq:  math.MaxUint64,
+			MaxTime: math.MaxUint64,
+			Limit:   defaultSupplyStatsLimit,
+		}
+
+		for _, f := range []struct {
+			name string
+			v    *uint64
+		}{
+			{"start_seq", &p.MinSeq},
+			{"end_seq", &p.MaxSeq},
+			{"start_time", &p.MinTime},
+			{"end_time", &p.MaxTime},
+			{"interval", &p.Interval},
+		} {
+			s := r.FormValue(f.name)
+			if s == "" {
+				continue
+			}
+
+			v, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", f.name, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			*f.v = v
+		}
+
+		if s := r.FormValue("limit"); s != "" {
+			var err error
+			p.Limit, err = strconv.ParseUint(s, 10, 64)
+			if err != nil || p.Limit == 0 || p.Limit > maxSupplyStatsLimit {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxSupplyStatsLimit))
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if p.MinSeq > p.MaxSeq {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "start_seq must not be greater than end_seq")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if p.MinTime > p.MaxTime {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "start_time must not be greater than end_time")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		format := r.FormValue("format")
+		switch format {
+		case "", "json", "csv":
+		default:
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, `format must be "json" or "csv"`)
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		stats, err := gateway.GetSupplyStats(p)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		rStats := make([]SupplyStats, len(stats))
+		for i, s := range stats {
+			rStats[i], err = NewSupplyStats(s)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="coin_supply_stats.csv"`)
+			if err := WriteSupplyStatsCSV(w, rStats); err != nil {
+				logger.WithError(err).Error("WriteSupplyStatsCSV failed")
+			}
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rStats,
+		})
+	}
+}
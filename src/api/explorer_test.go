@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -246,19 +247,25 @@ func TestCoinSupply(t *testing.T) {
 func TestGetRichlist(t *testing.T) {
 	type httpParams struct {
 		topn                string
+		offset              string
 		includeDistribution string
+		holders             string
 	}
 	tt := []struct {
-		name                     string
-		method                   string
-		status                   int
-		err                      string
-		httpParams               *httpParams
-		includeDistribution      bool
-		gatewayGetRichlistResult visor.Richlist
-		gatewayGetRichlistErr    error
-		result                   Richlist
-		csrfDisabled             bool
+		name                            string
+		method                          string
+		status                          int
+		err                             string
+		httpParams                      *httpParams
+		includeDistribution             bool
+		offset                          uint64
+		n                               uint64
+		gatewayGetRichlistResult        visor.Richlist
+		gatewayGetRichlistErr           error
+		gatewayGetRichlistHoldersResult *visor.RichlistHolders
+		gatewayGetRichlistHoldersErr    error
+		result                          Richlist
+		csrfDisabled                    bool
 	}{
 		{
 			name:   "405",
@@ -294,8 +301,95 @@ func TestGetRichlist(t *testing.T) {
 				topn:                "1",
 				includeDistribution: "false",
 			},
+			n:                     1,
 			gatewayGetRichlistErr: errors.New("gatewayGetRichlistErr"),
 		},
+		{
+			name:   "400 - bad offset",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid offset",
+			httpParams: &httpParams{
+				offset: "-1",
+			},
+		},
+		{
+			name:   "400 - bad holders",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid holders",
+			httpParams: &httpParams{
+				holders: "bad holders",
+			},
+		},
+		{
+			name:   "500 - gw GetRichlistHolders error",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - gatewayGetRichlistHoldersErr",
+			httpParams: &httpParams{
+				holders: "true",
+			},
+			n:                            20,
+			gatewayGetRichlistResult:     visor.Richlist{},
+			gatewayGetRichlistHoldersErr: errors.New("gatewayGetRichlistHoldersErr"),
+		},
+		{
+			name:   "200 offset and holders",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			httpParams: &httpParams{
+				topn:                "1",
+				offset:              "2",
+				includeDistribution: "true",
+				holders:             "true",
+			},
+			includeDistribution: true,
+			offset:              2,
+			n:                   1,
+			gatewayGetRichlistResult: visor.Richlist{
+				{
+					Address: cipher.MustDecodeBase58Address("2fGi2jhvp6ppHg3DecguZgzqvpJj2Gd4KHW"),
+					Coins:   500000e6,
+					Locked:  true,
+				},
+			},
+			gatewayGetRichlistHoldersResult: &visor.RichlistHolders{
+				Addresses: 120,
+				Thresholds: []visor.RichlistHoldersThreshold{
+					{
+						Coins:     1e6,
+						Addresses: 100,
+					},
+					{
+						Coins:     10e6,
+						Addresses: 3,
+					},
+				},
+			},
+			result: Richlist{
+				Richlist: []readable.RichlistBalance{
+					{
+						Address: "2fGi2jhvp6ppHg3DecguZgzqvpJj2Gd4KHW",
+						Coins:   "500000.000000",
+						Locked:  true,
+					},
+				},
+				Holders: &readable.RichlistHolders{
+					Addresses: 120,
+					Thresholds: []readable.RichlistHoldersThreshold{
+						{
+							Coins:     "1.000000",
+							Addresses: 100,
+						},
+						{
+							Coins:     "10.000000",
+							Addresses: 3,
+						},
+					},
+				},
+			},
+		},
 		{
 			name:   "200",
 			method: http.MethodGet,
@@ -304,6 +398,7 @@ func TestGetRichlist(t *testing.T) {
 				topn:                "3",
 				includeDistribution: "false",
 			},
+			n: 3,
 			gatewayGetRichlistResult: visor.Richlist{
 				{
 					Address: cipher.MustDecodeBase58Address("2fGC7kwAM9yZyEF1QqBqp8uo9RUsF6ENGJF"),
@@ -320,16 +415,6 @@ func TestGetRichlist(t *testing.T) {
 					Coins:   500000e6,
 					Locked:  false,
 				},
-				{
-					Address: cipher.MustDecodeBase58Address("2TmvdBWJgxMwGs84R4drS9p5fYkva4dGdfs"),
-					Coins:   244458e6,
-					Locked:  false,
-				},
-				{
-					Address: cipher.MustDecodeBase58Address("24gvUHXHtSg5drKiFsMw7iMgoN2PbLub53C"),
-					Coins:   195503e6,
-					Locked:  false,
-				},
 			},
 			result: Richlist{
 				Richlist: []readable.RichlistBalance{
@@ -422,16 +507,23 @@ func TestGetRichlist(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			endpoint := "/api/v1/richlist"
 			gateway := &MockGatewayer{}
-			gateway.On("GetRichlist", tc.includeDistribution).Return(tc.gatewayGetRichlistResult, tc.gatewayGetRichlistErr)
+			gateway.On("GetRichlist", tc.includeDistribution, tc.offset, tc.n).Return(tc.gatewayGetRichlistResult, tc.gatewayGetRichlistErr)
+			gateway.On("GetRichlistHolders", tc.includeDistribution).Return(tc.gatewayGetRichlistHoldersResult, tc.gatewayGetRichlistHoldersErr)
 
 			v := url.Values{}
 			if tc.httpParams != nil {
 				if tc.httpParams.topn != "" {
 					v.Add("n", tc.httpParams.topn)
 				}
+				if tc.httpParams.offset != "" {
+					v.Add("offset", tc.httpParams.offset)
+				}
 				if tc.httpParams.includeDistribution != "" {
 					v.Add("include-distribution", tc.httpParams.includeDistribution)
 				}
+				if tc.httpParams.holders != "" {
+					v.Add("holders", tc.httpParams.holders)
+				}
 			}
 			if len(v) > 0 {
 				endpoint += "?" + v.Encode()
@@ -551,3 +643,179 @@ func TestGetAddressCount(t *testing.T) {
 		})
 	}
 }
+
+func TestSupplyStats(t *testing.T) {
+	stats := []visor.SupplyStats{
+		{
+			PeriodStart:       1500000000,
+			Blocks:            3,
+			BlockSeq:          12,
+			BlockTime:         1500003000,
+			TotalSupply:       25000000e6,
+			CirculatingSupply: 12345678901234,
+			TotalCoinHours:    100000,
+			ActiveAddresses:   40,
+			TxnCount:          5,
+			BurnedHours:       600,
+		},
+	}
+
+	rStats := []SupplyStats{
+		{
+			PeriodStart:       1500000000,
+			Blocks:            3,
+			BlockSeq:          12,
+			BlockTime:         1500003000,
+			TotalSupply:       "25000000.000000",
+			CirculatingSupply: "12345678.901234",
+			TotalCoinHours:    100000,
+			ActiveAddresses:   40,
+			Transactions:      5,
+			BurnedCoinHours:   600,
+		},
+	}
+
+	defaultParams := visor.SupplyStatsParams{
+		MaxSeq:  math.MaxUint64,
+		MaxTime: math.MaxUint64,
+		Limit:   defaultSupplyStatsLimit,
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		query         string
+		gatewayParams visor.SupplyStatsParams
+		gatewayResult []visor.SupplyStats
+		gatewayErr    error
+		httpResponse  HTTPResponse
+		csv           string
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - invalid start_seq",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			query:        "start_seq=foo",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid start_seq: strconv.ParseUint: parsing "foo": invalid syntax`),
+		},
+		{
+			name:         "400 - invalid limit",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			query:        "limit=10001",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "limit must be an integer between 1 and 10000"),
+		},
+		{
+			name:         "400 - start_time greater than end_time",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			query:        "start_time=10&end_time=9",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "start_time must not be greater than end_time"),
+		},
+		{
+			name:         "400 - invalid format",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			query:        "format=xml",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `format must be "json" or "csv"`),
+		},
+		{
+			name:          "503 - history disabled",
+			method:        http.MethodGet,
+			status:        http.StatusServiceUnavailable,
+			gatewayParams: defaultParams,
+			gatewayErr:    visor.ErrHistoryDisabled,
+			httpResponse:  NewHTTPErrorResponse(http.StatusServiceUnavailable, "history is disabled"),
+		},
+		{
+			name:          "500 - gateway error",
+			method:        http.MethodGet,
+			status:        http.StatusInternalServerError,
+			gatewayParams: defaultParams,
+			gatewayErr:    errors.New("failed"),
+			httpResponse:  NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			query:  "start_seq=10&end_seq=20&start_time=1500000000&end_time=1600000000&interval=3600&limit=5",
+			gatewayParams: visor.SupplyStatsParams{
+				MinSeq:   10,
+				MaxSeq:   20,
+				MinTime:  1500000000,
+				MaxTime:  1600000000,
+				Interval: 3600,
+				Limit:    5,
+			},
+			gatewayResult: stats,
+			httpResponse: HTTPResponse{
+				Data: rStats,
+			},
+		},
+		{
+			name:          "200 - csv",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			query:         "format=csv",
+			gatewayParams: defaultParams,
+			gatewayResult: stats,
+			csv: "period_start,blocks,block_seq,block_time,total_supply,circulating_supply,total_coin_hours,active_addresses,transactions,burned_coin_hours\n" +
+				"1500000000,3,12,1500003000,25000000.000000,12345678.901234,100000,40,5,600\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/coinSupply/stats"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			gateway := &MockGatewayer{}
+			gateway.On("GetSupplyStats", tc.gatewayParams).Return(tc.gatewayResult, tc.gatewayErr)
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if tc.csv != "" {
+				require.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+				require.Equal(t, tc.csv, rr.Body.String())
+				return
+			}
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var stats []SupplyStats
+				err := json.Unmarshal(rsp.Data, &stats)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.([]SupplyStats), stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+const (
+	// ExportStartSeqHeader is the response header of the blockchain export endpoint with the first block exported
+	ExportStartSeqHeader = "X-Export-Start-Seq"
+	// ExportEndSeqHeader is the response header of the blockchain export endpoint with the last block exported
+	ExportEndSeqHeader = "X-Export-End-Seq"
+	// ExportRowsTrailer is the response trailer of the blockchain export endpoint with the number of rows exported
+	ExportRowsTrailer = "X-Export-Rows"
+
+	// ContentTypeJSONL JSON lines content type header
+	ContentTypeJSONL = "application/x-ndjson"
+	// ContentTypeCSV CSV content type header
+	ContentTypeCSV = "text/csv"
+)
+
+// blockchainExportHandler streams the rows of a table of blockchain data for a range of blocks.
+// The block range is sent as headers, after the end of the range was lowered to the head block.
+// The number of rows is sent as a trailer, after the export has been written.
+// If the export fails after it has started, the response ends without the rows trailer.
+// Method: GET
+// URI: /api/v2/blockchain/export
+// Args:
+//     table: "blocks", "transactions", "inputs" or "outputs" [required]
+//     format: "jsonl" or "csv" [optional, default "jsonl"]
+//     start_seq: First block to export [optional, default 0]
+//     end_seq: Last block to export [optional, default the head block]
+func blockchainExportHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p := visor.ExportParams{
+			Format: visor.ExportFormatJSONL,
+			EndSeq: math.MaxUint64,
+		}
+
+		table := r.FormValue("table")
+		if table == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "table is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var err error
+		p.Table, err = visor.ParseExportTable(table)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if format := r.FormValue("format"); format != "" {
+			p.Format, err = visor.ParseExportFormat(format)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		for _, f := range []struct {
+			name string
+			v    *uint64
+		}{
+			{"start_seq", &p.StartSeq},
+			{"end_seq", &p.EndSeq},
+		} {
+			s := r.FormValue(f.name)
+			if s == "" {
+				continue
+			}
+
+			v, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", f.name, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			*f.v = v
+		}
+
+		if p.StartSeq > p.EndSeq {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "start_seq must not be greater than end_seq")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		started := false
+		rows, err := gateway.ExportChain(p, w, func(p visor.ExportParams) {
+			started = true
+
+			contentType := ContentTypeJSONL
+			if p.Format == visor.ExportFormatCSV {
+				contentType = ContentTypeCSV
+			}
+
+			w.Header().Set("Trailer", ExportRowsTrailer)
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d-%d.%s"`, p.Table, p.StartSeq, p.EndSeq, p.Format))
+			w.Header().Set(ExportStartSeqHeader, strconv.FormatUint(p.StartSeq, 10))
+			w.Header().Set(ExportEndSeqHeader, strconv.FormatUint(p.EndSeq, 10))
+			w.WriteHeader(http.StatusOK)
+		})
+		if err != nil {
+			if started {
+				logger.WithError(err).Error("Blockchain export failed")
+				return
+			}
+
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		w.Header().Set(ExportRowsTrailer, strconv.FormatUint(rows, 10))
+	}
+}
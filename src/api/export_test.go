@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func TestBlockchainExportHandler(t *testing.T) {
+	exportData := []byte("seq,hash\n1,abc\n")
+
+	// export starts the response like Visor.ExportChain and writes data to it
+	export := func(data []byte) func(visor.ExportParams, io.Writer, func(visor.ExportParams)) error {
+		return func(p visor.ExportParams, w io.Writer, begin func(visor.ExportParams)) error {
+			p.EndSeq = 20
+			begin(p)
+			_, err := w.Write(data)
+			return err
+		}
+	}
+
+	tt := []struct {
+		name         string
+		method       string
+		query        string
+		status       int
+		exportParams visor.ExportParams
+		export       func(visor.ExportParams, io.Writer, func(visor.ExportParams)) error
+		exportRows   uint64
+		exportErr    error
+		httpResponse HTTPResponse
+		body         []byte
+		headers      map[string]string
+		trailer      string
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing table",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "table is required"),
+		},
+		{
+			name:         "400 - invalid table",
+			method:       http.MethodGet,
+			query:        "table=unspents",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, visor.ErrExportTableInvalid.Error()),
+		},
+		{
+			name:         "400 - invalid format",
+			method:       http.MethodGet,
+			query:        "table=blocks&format=json",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, visor.ErrExportFormatInvalid.Error()),
+		},
+		{
+			name:         "400 - invalid start_seq",
+			method:       http.MethodGet,
+			query:        "table=blocks&start_seq=foo",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid start_seq: strconv.ParseUint: parsing "foo": invalid syntax`),
+		},
+		{
+			name:         "400 - start_seq greater than end_seq",
+			method:       http.MethodGet,
+			query:        "table=blocks&start_seq=10&end_seq=9",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "start_seq must not be greater than end_seq"),
+		},
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			query:  "table=inputs",
+			status: http.StatusServiceUnavailable,
+			exportParams: visor.ExportParams{
+				Table:  visor.ExportTableInputs,
+				Format: visor.ExportFormatJSONL,
+				EndSeq: math.MaxUint64,
+			},
+			exportErr:    visor.ErrHistoryDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusServiceUnavailable, visor.ErrHistoryDisabled.Error()),
+		},
+		{
+			name:   "500 - export failed before starting",
+			method: http.MethodGet,
+			query:  "table=blocks",
+			status: http.StatusInternalServerError,
+			exportParams: visor.ExportParams{
+				Table:  visor.ExportTableBlocks,
+				Format: visor.ExportFormatJSONL,
+				EndSeq: math.MaxUint64,
+			},
+			exportErr:    errors.New("export failed"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "export failed"),
+		},
+		{
+			name:   "200 - export failed after starting",
+			method: http.MethodGet,
+			query:  "table=blocks&format=csv&start_seq=1",
+			status: http.StatusOK,
+			exportParams: visor.ExportParams{
+				Table:    visor.ExportTableBlocks,
+				Format:   visor.ExportFormatCSV,
+				StartSeq: 1,
+				EndSeq:   math.MaxUint64,
+			},
+			export:    export(exportData[:4]),
+			exportErr: errors.New("export failed"),
+			body:      exportData[:4],
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			query:  "table=blocks&format=csv&start_seq=1&end_seq=30",
+			status: http.StatusOK,
+			exportParams: visor.ExportParams{
+				Table:    visor.ExportTableBlocks,
+				Format:   visor.ExportFormatCSV,
+				StartSeq: 1,
+				EndSeq:   30,
+			},
+			export:     export(exportData),
+			exportRows: 1,
+			body:       exportData,
+			headers: map[string]string{
+				"Content-Type":        ContentTypeCSV,
+				"Content-Disposition": `attachment; filename="blocks-1-20.csv"`,
+				ExportStartSeqHeader:  "1",
+				ExportEndSeqHeader:    "20",
+			},
+			trailer: "1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			call := gateway.On("ExportChain", tc.exportParams, mock.Anything, mock.Anything).Return(tc.exportRows, tc.exportErr)
+			if tc.export != nil {
+				call.Run(func(args mock.Arguments) {
+					err := tc.export(args.Get(0).(visor.ExportParams), args.Get(1).(io.Writer), args.Get(2).(func(visor.ExportParams)))
+					require.NoError(t, err)
+				})
+			}
+
+			endpoint := "/api/v2/blockchain/export"
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if tc.body == nil {
+				var rsp ReceivedHTTPResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Error, rsp.Error)
+				return
+			}
+
+			require.Equal(t, tc.body, rr.Body.Bytes())
+
+			resp := rr.Result()
+			for k, v := range tc.headers {
+				require.Equal(t, v, resp.Header.Get(k), k)
+			}
+			require.Equal(t, tc.trailer, resp.Trailer.Get(ExportRowsTrailer))
+		})
+	}
+}
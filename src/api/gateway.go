@@ -1,6 +1,7 @@
 package api
 
 import (
+	"io"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -60,6 +61,10 @@ type Visorer interface {
 	StartedAt() time.Time
 	HeadBkSeq() (uint64, bool, error)
 	GetBlockchainMetadata() (*visor.BlockchainMetadata, error)
+	GetHistoryRebuildProgress() *visor.HistoryRebuildProgress
+	GetLightClientStatus() (*visor.LightClientStatus, error)
+	Backup(w io.Writer, begin func(visor.BackupManifest)) (*visor.BackupManifest, error)
+	ExportChain(p visor.ExportParams, w io.Writer, begin func(visor.ExportParams)) (uint64, error)
 	ResendUnconfirmedTxns() ([]cipher.SHA256, error)
 	GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error)
 	GetSignedBlockByHashVerbose(hash cipher.SHA256) (*coin.SignedBlock, [][]visor.TransactionInput, error)
@@ -73,16 +78,26 @@ type Visorer interface {
 	GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [][][]visor.TransactionInput, error)
 	GetUnspentOutputsSummary(filters []visor.OutputsFilter) (*visor.UnspentOutputsSummary, error)
 	GetBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair, error)
+	GetBalanceOfAddrsAtSeq(addrs []cipher.Address, seq uint64) (*visor.HistoricalBalances, error)
+	GetBalanceOfAddrsAtTime(addrs []cipher.Address, t uint64) (*visor.HistoricalBalances, error)
 	VerifyTxnVerbose(txn *coin.Transaction, signed visor.TxnSignedFlag) ([]visor.TransactionInput, bool, error)
 	AddressCount() (uint64, error)
 	GetUxOutByID(id cipher.SHA256) (*historydb.UxOut, error)
 	GetSpentOutputsForAddresses(addr []cipher.Address) ([][]historydb.UxOut, error)
+	GetAddressSummaries(addrs []cipher.Address) ([]*historydb.AddressSummary, error)
+	GetSupplyStats(p visor.SupplyStatsParams) ([]visor.SupplyStats, error)
+	GetTransactionsPageForAddress(a cipher.Address, p historydb.AddressTxnsPageParams, verbose bool) (*visor.AddressTransactionsPage, error)
 	GetVerboseTransactionsForAddress(a cipher.Address) ([]visor.Transaction, [][]visor.TransactionInput, error)
-	GetRichlist(includeDistribution bool) (visor.Richlist, error)
+	GetRichlist(includeDistribution bool, offset, n uint64) (visor.Richlist, error)
+	GetRichlistHolders(includeDistribution bool) (*visor.RichlistHolders, error)
 	GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error)
 	GetAllUnconfirmedTransactionsVerbose() ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
+	GetEvictedTransactions() []visor.EvictedTransaction
+	GetUnconfirmedPoolStatus() (*visor.UnconfirmedPoolStatus, error)
+	LastBlockAssembly() *visor.BlockAssemblyReport
 	GetTransaction(txid cipher.SHA256) (*visor.Transaction, error)
 	GetTransactionWithInputs(txid cipher.SHA256) (*visor.Transaction, []visor.TransactionInput, error)
+	GetTransactionProof(txid cipher.SHA256) (*visor.TransactionProof, error)
 	GetTransactions(flts []visor.TxFilter) ([]visor.Transaction, error)
 	GetTransactionsWithInputs(flts []visor.TxFilter) ([]visor.Transaction, [][]visor.TransactionInput, error)
 	GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error)
@@ -9,6 +9,7 @@ import (
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/visor"
 )
 
 // BlockchainMetadata extends visor.BlockchainMetadata to include the time since the last block
@@ -17,6 +18,37 @@ type BlockchainMetadata struct {
 	TimeSinceLastBlock wh.Duration `json:"time_since_last_block"`
 }
 
+// HistoryRebuild is the progress of the historydb rebuild started when the node started
+type HistoryRebuild struct {
+	ParsedBlocks uint64 `json:"parsed_blocks"`
+	Blocks       uint64 `json:"blocks"`
+	Done         bool   `json:"done"`
+	StartedAt    int64  `json:"started_at"`
+	FinishedAt   int64  `json:"finished_at,omitempty"`
+}
+
+// NewHistoryRebuild creates a HistoryRebuild from visor.HistoryRebuildProgress
+func NewHistoryRebuild(p visor.HistoryRebuildProgress) *HistoryRebuild {
+	r := &HistoryRebuild{
+		ParsedBlocks: p.ParsedBlocks,
+		Blocks:       p.Blocks,
+		Done:         p.Done,
+		StartedAt:    p.StartedAt.Unix(),
+	}
+
+	if p.Done {
+		r.FinishedAt = p.FinishedAt.Unix()
+	}
+
+	return r
+}
+
+// LightClient is the sync status of a header-only light client
+type LightClient struct {
+	HeadSeq          uint64 `json:"head_seq"`
+	WatchedAddresses int    `json:"watched_addresses"`
+}
+
 // HealthResponse is returned by the /health endpoint
 type HealthResponse struct {
 	BlockchainMetadata   BlockchainMetadata   `json:"blockchain"`
@@ -36,6 +68,8 @@ type HealthResponse struct {
 	UnconfirmedVerifyTxn readable.VerifyTxn   `json:"unconfirmed_verify_transaction"`
 	StartedAt            int64                `json:"started_at"`
 	Fiber                readable.FiberConfig `json:"fiber"`
+	HistoryRebuild       *HistoryRebuild      `json:"history_rebuild,omitempty"`
+	LightClient          *LightClient         `json:"light_client,omitempty"`
 }
 
 func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
@@ -71,6 +105,24 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		return nil, err
 	}
 
+	var historyRebuild *HistoryRebuild
+	if p := gateway.GetHistoryRebuildProgress(); p != nil {
+		historyRebuild = NewHistoryRebuild(*p)
+	}
+
+	lightStatus, err := gateway.GetLightClientStatus()
+	if err != nil {
+		return nil, fmt.Errorf("gateway.GetLightClientStatus failed: %v", err)
+	}
+
+	var lightClient *LightClient
+	if lightStatus != nil {
+		lightClient = &LightClient{
+			HeadSeq:          lightStatus.HeadSeq,
+			WatchedAddresses: lightStatus.WatchedAddresses,
+		}
+	}
+
 	return &HealthResponse{
 		BlockchainMetadata: BlockchainMetadata{
 			BlockchainMetadata: readable.NewBlockchainMetadata(*metadata),
@@ -92,6 +144,8 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		UnconfirmedVerifyTxn: readable.NewVerifyTxn(gateway.DaemonConfig().UnconfirmedVerifyTxn),
 		Uptime:               wh.FromDuration(time.Since(gateway.StartedAt())),
 		StartedAt:            gateway.StartedAt().Unix(),
+		HistoryRebuild:       historyRebuild,
+		LightClient:          lightClient,
 	}, nil
 }
 
@@ -32,6 +32,10 @@ func TestHealthHandler(t *testing.T) {
 		getConnectionsErr        error
 		cfg                      muxConfig
 		walletAPIEnabled         bool
+		historyRebuildProgress   *visor.HistoryRebuildProgress
+		historyRebuild           *HistoryRebuild
+		lightClientStatus        *visor.LightClientStatus
+		lightClient              *LightClient
 	}{
 		{
 			name:   "405 method not allowed",
@@ -84,6 +88,40 @@ func TestHealthHandler(t *testing.T) {
 			},
 			walletAPIEnabled: false,
 		},
+
+		{
+			name:             "valid response, history rebuilding",
+			method:           http.MethodGet,
+			code:             http.StatusOK,
+			cfg:              defaultMuxConfig(),
+			walletAPIEnabled: true,
+			historyRebuildProgress: &visor.HistoryRebuildProgress{
+				ParsedBlocks: 12000,
+				Blocks:       21176,
+				StartedAt:    time.Unix(1523168000, 0),
+			},
+			historyRebuild: &HistoryRebuild{
+				ParsedBlocks: 12000,
+				Blocks:       21176,
+				StartedAt:    1523168000,
+			},
+		},
+
+		{
+			name:             "valid response, light client",
+			method:           http.MethodGet,
+			code:             http.StatusOK,
+			cfg:              defaultMuxConfig(),
+			walletAPIEnabled: true,
+			lightClientStatus: &visor.LightClientStatus{
+				HeadSeq:          21175,
+				WatchedAddresses: 2,
+			},
+			lightClient: &LightClient{
+				HeadSeq:          21175,
+				WatchedAddresses: 2,
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -182,6 +220,8 @@ func TestHealthHandler(t *testing.T) {
 			startedAt := time.Now().Add(time.Second * -4)
 
 			gateway.On("StartedAt").Return(startedAt)
+			gateway.On("GetHistoryRebuildProgress").Return(tc.historyRebuildProgress)
+			gateway.On("GetLightClientStatus").Return(tc.lightClientStatus, nil)
 
 			dc := daemon.DaemonConfig{
 				UnconfirmedVerifyTxn: params.VerifyTxn{
@@ -250,6 +290,8 @@ func TestHealthHandler(t *testing.T) {
 			require.Equal(t, dc.UnconfirmedVerifyTxn.MaxTransactionSize, r.UnconfirmedVerifyTxn.MaxTransactionSize)
 			require.Equal(t, dc.UnconfirmedVerifyTxn.MaxDropletPrecision, r.UnconfirmedVerifyTxn.MaxDropletPrecision)
 			require.True(t, time.Now().Unix() > r.StartedAt)
+			require.Equal(t, tc.historyRebuild, r.HistoryRebuild)
+			require.Equal(t, tc.lightClient, r.LightClient)
 
 		})
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/rs/cors"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/file"
 	wh "github.com/skycoin/skycoin/src/util/http"
@@ -58,6 +59,8 @@ const (
 	EndpointsNetCtrl = "NET_CTRL"
 	// EndpointsStorage endpoints implement interface for key-value storage for arbitrary data
 	EndpointsStorage = "STORAGE"
+	// EndpointsDatabase endpoints for backing up the blockchain database
+	EndpointsDatabase = "DATABASE"
 )
 
 // Server exposes an HTTP API
@@ -82,6 +85,7 @@ type Config struct {
 	EnabledAPISets     map[string]struct{}
 	Username           string
 	Password           string
+	EventBus           *eventbus.Bus
 }
 
 // HealthConfig configuration data exposed in /health
@@ -103,6 +107,7 @@ type muxConfig struct {
 	username           string
 	password           string
 	health             HealthConfig
+	eventBus           *eventbus.Bus
 }
 
 // HTTPResponse represents the http response struct
@@ -197,6 +202,7 @@ func create(host string, c Config, gateway Gatewayer) (*Server, error) {
 		hostWhitelist:      c.HostWhitelist,
 		username:           c.Username,
 		password:           c.Password,
+		eventBus:           c.EventBus,
 	}
 
 	srvMux := newServerMux(mc, gateway)
@@ -313,6 +319,7 @@ func (s *Server) Shutdown() {
 // newServerMux creates an http.ServeMux with handlers registered
 func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	mux := http.NewServeMux()
+	notes := newPendingNotes(maxPendingNotes)
 
 	allowedOrigins := []string{fmt.Sprintf("http://%s", c.host)}
 	for _, s := range c.hostWhitelist {
@@ -469,7 +476,7 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/wallet/balance", walletBalanceHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
-	webHandlerV1("/wallet/transaction", walletCreateTransactionHandler(gateway), map[string][]string{
+	webHandlerV1("/wallet/transaction", walletCreateTransactionHandler(gateway, notes), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
 	webHandlerV2("/wallet/transaction/sign", walletSignTransactionHandler(gateway), map[string][]string{
@@ -524,6 +531,9 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/blockchain/export", blockchainExportHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/last_blocks", lastBlocksHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
@@ -554,23 +564,32 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/pendingTxs", pendingTxnsHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV2("/pendingTxs/assembly", pendingTxnsAssemblyHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/transaction", transactionHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
-	webHandlerV2("/transaction", transactionHandlerV2(gateway), map[string][]string{
+	webHandlerV2("/transaction", transactionHandlerV2(gateway, notes), map[string][]string{
 		// http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsTransaction},
 	})
 	webHandlerV2("/transaction/verify", verifyTxnHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/transaction/proof", transactionProofHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/transactions", transactionsHandler(gateway), map[string][]string{
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
 	})
-	webHandlerV1("/injectTransaction", injectTransactionHandler(gateway), map[string][]string{
+	webHandlerV1("/injectTransaction", injectTransactionHandler(gateway, notes), map[string][]string{
 		http.MethodPost: []string{EndpointsTransaction, EndpointsWallet},
 	})
+	webHandlerV2("/transaction/notes", transactionNotesHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsStorage},
+	})
 	webHandlerV1("/resendUnconfirmedTxns", resendUnconfirmedTxnsHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsTransaction, EndpointsWallet},
 	})
@@ -603,11 +622,23 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/address/verify", http.HandlerFunc(addressVerifyHandler), map[string][]string{
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/address/summary", addressSummaryHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/address/balance", addressBalanceHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/address/transactions", addressTransactionsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 
 	// Explorer endpoints
 	webHandlerV1("/coinSupply", coinSupplyHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV2("/coinSupply/stats", supplyStatsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 	webHandlerV1("/richlist", richlistHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
@@ -622,6 +653,17 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 		http.MethodDelete: []string{EndpointsStorage},
 	})
 
+	// Streaming endpoints
+	_, walletAPIEnabled := c.enabledAPISets[EndpointsWallet]
+	webHandlerV2("/ws", websocketHandler(gateway, c.eventBus, walletAPIEnabled), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
+
+	// Database endpoints
+	webHandlerV2("/db/backup", dbBackupHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsDatabase},
+	})
+
 	return mux
 }
 
@@ -23,6 +23,7 @@ var allAPISetsEnabled = map[string]struct{}{
 	EndpointsPrometheus:         struct{}{},
 	EndpointsNetCtrl:            struct{}{},
 	EndpointsStorage:            struct{}{},
+	EndpointsDatabase:           struct{}{},
 }
 
 func defaultMuxConfig() muxConfig {
@@ -158,9 +159,30 @@ var endpointsMethods = map[string][]string{
 	"/api/v2/transaction/verify": []string{
 		http.MethodPost,
 	},
+	"/api/v2/transaction/proof": []string{
+		http.MethodGet,
+	},
+	"/api/v2/pendingTxs/assembly": []string{
+		http.MethodGet,
+	},
 	"/api/v2/address/verify": []string{
 		http.MethodPost,
 	},
+	"/api/v2/address/summary": []string{
+		http.MethodPost,
+	},
+	"/api/v2/address/balance": []string{
+		http.MethodPost,
+	},
+	"/api/v2/address/transactions": []string{
+		http.MethodGet,
+	},
+	"/api/v2/coinSupply/stats": []string{
+		http.MethodGet,
+	},
+	"/api/v2/blockchain/export": []string{
+		http.MethodGet,
+	},
 	"/api/v2/wallet/recover": []string{
 		http.MethodPost,
 	},
@@ -179,6 +201,15 @@ var endpointsMethods = map[string][]string{
 		http.MethodPost,
 		http.MethodDelete,
 	},
+	"/api/v2/transaction/notes": []string{
+		http.MethodGet,
+	},
+	"/api/v2/db/backup": []string{
+		http.MethodGet,
+	},
+	"/api/v2/ws": []string{
+		http.MethodGet,
+	},
 }
 
 func allEndpoints() []string {
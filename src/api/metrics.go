@@ -20,6 +20,16 @@ var (
 			Name: "unconfirmed_txns",
 			Help: "Number of unconfirmed transactions",
 		})
+	promUnconfirmedTxnsBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "unconfirmed_txns_bytes",
+			Help: "Total size of the unconfirmed transactions, in bytes",
+		})
+	promUnconfirmedTxnsEvicted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "unconfirmed_txns_evicted",
+			Help: "Number of transactions evicted from the unconfirmed pool since startup, by reason",
+		}, []string{"reason"})
 	promTimeSinceLastBlock = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "time_since_last_block_seconds",
@@ -55,6 +65,8 @@ var (
 func init() {
 	prometheus.MustRegister(promUnspents)
 	prometheus.MustRegister(promUnconfirmedTxns)
+	prometheus.MustRegister(promUnconfirmedTxnsBytes)
+	prometheus.MustRegister(promUnconfirmedTxnsEvicted)
 	prometheus.MustRegister(promTimeSinceLastBlock)
 	prometheus.MustRegister(promOpenConns)
 	prometheus.MustRegister(promOutgoingConns)
@@ -71,8 +83,18 @@ func metricsHandler(c muxConfig, gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		poolStatus, err := gateway.GetUnconfirmedPoolStatus()
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
 		promUnspents.Set(float64(health.BlockchainMetadata.Unspents))
 		promUnconfirmedTxns.Set(float64(health.BlockchainMetadata.Unconfirmed))
+		promUnconfirmedTxnsBytes.Set(float64(poolStatus.Bytes))
+		for reason, n := range poolStatus.Evicted {
+			promUnconfirmedTxnsEvicted.WithLabelValues(string(reason)).Set(float64(n))
+		}
 		promTimeSinceLastBlock.Set(health.BlockchainMetadata.TimeSinceLastBlock.Seconds())
 		promOpenConns.Set(float64(health.OpenConnections))
 		promOutgoingConns.Set(float64(health.OutgoingConnections))
@@ -6,6 +6,7 @@ import cipher "github.com/skycoin/skycoin/src/cipher"
 import coin "github.com/skycoin/skycoin/src/coin"
 import daemon "github.com/skycoin/skycoin/src/daemon"
 import historydb "github.com/skycoin/skycoin/src/visor/historydb"
+import io "io"
 import kvstorage "github.com/skycoin/skycoin/src/kvstorage"
 import mock "github.com/stretchr/testify/mock"
 import time "time"
@@ -53,6 +54,29 @@ func (_m *MockGatewayer) AddressCount() (uint64, error) {
 	return r0, r1
 }
 
+// Backup provides a mock function with given fields: w, begin
+func (_m *MockGatewayer) Backup(w io.Writer, begin func(visor.BackupManifest)) (*visor.BackupManifest, error) {
+	ret := _m.Called(w, begin)
+
+	var r0 *visor.BackupManifest
+	if rf, ok := ret.Get(0).(func(io.Writer, func(visor.BackupManifest)) *visor.BackupManifest); ok {
+		r0 = rf(w, begin)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BackupManifest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(io.Writer, func(visor.BackupManifest)) error); ok {
+		r1 = rf(w, begin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateTransaction provides a mock function with given fields: p, wp
 func (_m *MockGatewayer) CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(p, wp)
@@ -182,6 +206,50 @@ func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte) (*wallet.W
 	return r0, r1
 }
 
+// ExportChain provides a mock function with given fields: p, w, begin
+func (_m *MockGatewayer) ExportChain(p visor.ExportParams, w io.Writer, begin func(visor.ExportParams)) (uint64, error) {
+	ret := _m.Called(p, w, begin)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(visor.ExportParams, io.Writer, func(visor.ExportParams)) uint64); ok {
+		r0 = rf(p, w, begin)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(visor.ExportParams, io.Writer, func(visor.ExportParams)) error); ok {
+		r1 = rf(p, w, begin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddressSummaries provides a mock function with given fields: addrs
+func (_m *MockGatewayer) GetAddressSummaries(addrs []cipher.Address) ([]*historydb.AddressSummary, error) {
+	ret := _m.Called(addrs)
+
+	var r0 []*historydb.AddressSummary
+	if rf, ok := ret.Get(0).(func([]cipher.Address) []*historydb.AddressSummary); ok {
+		r0 = rf(addrs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*historydb.AddressSummary)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
+		r1 = rf(addrs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllStorageValues provides a mock function with given fields: storageType
 func (_m *MockGatewayer) GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error) {
 	ret := _m.Called(storageType)
@@ -283,6 +351,52 @@ func (_m *MockGatewayer) GetBalanceOfAddrs(addrs []cipher.Address) ([]wallet.Bal
 	return r0, r1
 }
 
+// GetBalanceOfAddrsAtSeq provides a mock function with given fields: addrs, seq
+func (_m *MockGatewayer) GetBalanceOfAddrsAtSeq(addrs []cipher.Address, seq uint64) (*visor.HistoricalBalances, error) {
+	ret := _m.Called(addrs, seq)
+
+	var r0 *visor.HistoricalBalances
+	if rf, ok := ret.Get(0).(func([]cipher.Address, uint64) *visor.HistoricalBalances); ok {
+		r0 = rf(addrs, seq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.HistoricalBalances)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]cipher.Address, uint64) error); ok {
+		r1 = rf(addrs, seq)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBalanceOfAddrsAtTime provides a mock function with given fields: addrs, t
+func (_m *MockGatewayer) GetBalanceOfAddrsAtTime(addrs []cipher.Address, t uint64) (*visor.HistoricalBalances, error) {
+	ret := _m.Called(addrs, t)
+
+	var r0 *visor.HistoricalBalances
+	if rf, ok := ret.Get(0).(func([]cipher.Address, uint64) *visor.HistoricalBalances); ok {
+		r0 = rf(addrs, t)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.HistoricalBalances)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]cipher.Address, uint64) error); ok {
+		r1 = rf(addrs, t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBlockchainMetadata provides a mock function with given fields:
 func (_m *MockGatewayer) GetBlockchainMetadata() (*visor.BlockchainMetadata, error) {
 	ret := _m.Called()
@@ -494,6 +608,22 @@ func (_m *MockGatewayer) GetDefaultConnections() []string {
 	return r0
 }
 
+// GetEvictedTransactions provides a mock function with given fields:
+func (_m *MockGatewayer) GetEvictedTransactions() []visor.EvictedTransaction {
+	ret := _m.Called()
+
+	var r0 []visor.EvictedTransaction
+	if rf, ok := ret.Get(0).(func() []visor.EvictedTransaction); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.EvictedTransaction)
+		}
+	}
+
+	return r0
+}
+
 // GetExchgConnection provides a mock function with given fields:
 func (_m *MockGatewayer) GetExchgConnection() []string {
 	ret := _m.Called()
@@ -510,6 +640,22 @@ func (_m *MockGatewayer) GetExchgConnection() []string {
 	return r0
 }
 
+// GetHistoryRebuildProgress provides a mock function with given fields:
+func (_m *MockGatewayer) GetHistoryRebuildProgress() *visor.HistoryRebuildProgress {
+	ret := _m.Called()
+
+	var r0 *visor.HistoryRebuildProgress
+	if rf, ok := ret.Get(0).(func() *visor.HistoryRebuildProgress); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.HistoryRebuildProgress)
+		}
+	}
+
+	return r0
+}
+
 // GetLastBlocks provides a mock function with given fields: num
 func (_m *MockGatewayer) GetLastBlocks(num uint64) ([]coin.SignedBlock, error) {
 	ret := _m.Called(num)
@@ -565,19 +711,65 @@ func (_m *MockGatewayer) GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [
 	return r0, r1, r2
 }
 
-// GetRichlist provides a mock function with given fields: includeDistribution
-func (_m *MockGatewayer) GetRichlist(includeDistribution bool) (visor.Richlist, error) {
-	ret := _m.Called(includeDistribution)
+// GetLightClientStatus provides a mock function with given fields:
+func (_m *MockGatewayer) GetLightClientStatus() (*visor.LightClientStatus, error) {
+	ret := _m.Called()
+
+	var r0 *visor.LightClientStatus
+	if rf, ok := ret.Get(0).(func() *visor.LightClientStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.LightClientStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRichlist provides a mock function with given fields: includeDistribution, offset, n
+func (_m *MockGatewayer) GetRichlist(includeDistribution bool, offset uint64, n uint64) (visor.Richlist, error) {
+	ret := _m.Called(includeDistribution, offset, n)
 
 	var r0 visor.Richlist
-	if rf, ok := ret.Get(0).(func(bool) visor.Richlist); ok {
-		r0 = rf(includeDistribution)
+	if rf, ok := ret.Get(0).(func(bool, uint64, uint64) visor.Richlist); ok {
+		r0 = rf(includeDistribution, offset, n)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(visor.Richlist)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool, uint64, uint64) error); ok {
+		r1 = rf(includeDistribution, offset, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRichlistHolders provides a mock function with given fields: includeDistribution
+func (_m *MockGatewayer) GetRichlistHolders(includeDistribution bool) (*visor.RichlistHolders, error) {
+	ret := _m.Called(includeDistribution)
+
+	var r0 *visor.RichlistHolders
+	if rf, ok := ret.Get(0).(func(bool) *visor.RichlistHolders); ok {
+		r0 = rf(includeDistribution)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.RichlistHolders)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(bool) error); ok {
 		r1 = rf(includeDistribution)
@@ -742,6 +934,29 @@ func (_m *MockGatewayer) GetStorageValue(storageType kvstorage.Type, key string)
 	return r0, r1
 }
 
+// GetSupplyStats provides a mock function with given fields: p
+func (_m *MockGatewayer) GetSupplyStats(p visor.SupplyStatsParams) ([]visor.SupplyStats, error) {
+	ret := _m.Called(p)
+
+	var r0 []visor.SupplyStats
+	if rf, ok := ret.Get(0).(func(visor.SupplyStatsParams) []visor.SupplyStats); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.SupplyStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(visor.SupplyStatsParams) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransaction provides a mock function with given fields: txid
 func (_m *MockGatewayer) GetTransaction(txid cipher.SHA256) (*visor.Transaction, error) {
 	ret := _m.Called(txid)
@@ -765,6 +980,29 @@ func (_m *MockGatewayer) GetTransaction(txid cipher.SHA256) (*visor.Transaction,
 	return r0, r1
 }
 
+// GetTransactionProof provides a mock function with given fields: txid
+func (_m *MockGatewayer) GetTransactionProof(txid cipher.SHA256) (*visor.TransactionProof, error) {
+	ret := _m.Called(txid)
+
+	var r0 *visor.TransactionProof
+	if rf, ok := ret.Get(0).(func(cipher.SHA256) *visor.TransactionProof); ok {
+		r0 = rf(txid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.TransactionProof)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(cipher.SHA256) error); ok {
+		r1 = rf(txid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionWithInputs provides a mock function with given fields: txid
 func (_m *MockGatewayer) GetTransactionWithInputs(txid cipher.SHA256) (*visor.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(txid)
@@ -820,6 +1058,29 @@ func (_m *MockGatewayer) GetTransactions(flts []visor.TxFilter) ([]visor.Transac
 	return r0, r1
 }
 
+// GetTransactionsPageForAddress provides a mock function with given fields: a, p, verbose
+func (_m *MockGatewayer) GetTransactionsPageForAddress(a cipher.Address, p historydb.AddressTxnsPageParams, verbose bool) (*visor.AddressTransactionsPage, error) {
+	ret := _m.Called(a, p, verbose)
+
+	var r0 *visor.AddressTransactionsPage
+	if rf, ok := ret.Get(0).(func(cipher.Address, historydb.AddressTxnsPageParams, bool) *visor.AddressTransactionsPage); ok {
+		r0 = rf(a, p, verbose)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.AddressTransactionsPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(cipher.Address, historydb.AddressTxnsPageParams, bool) error); ok {
+		r1 = rf(a, p, verbose)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionsWithInputs provides a mock function with given fields: flts
 func (_m *MockGatewayer) GetTransactionsWithInputs(flts []visor.TxFilter) ([]visor.Transaction, [][]visor.TransactionInput, error) {
 	ret := _m.Called(flts)
@@ -868,6 +1129,29 @@ func (_m *MockGatewayer) GetTrustConnections() []string {
 	return r0
 }
 
+// GetUnconfirmedPoolStatus provides a mock function with given fields:
+func (_m *MockGatewayer) GetUnconfirmedPoolStatus() (*visor.UnconfirmedPoolStatus, error) {
+	ret := _m.Called()
+
+	var r0 *visor.UnconfirmedPoolStatus
+	if rf, ok := ret.Get(0).(func() *visor.UnconfirmedPoolStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.UnconfirmedPoolStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUnspentOutputsSummary provides a mock function with given fields: filters
 func (_m *MockGatewayer) GetUnspentOutputsSummary(filters []visor.OutputsFilter) (*visor.UnspentOutputsSummary, error) {
 	ret := _m.Called(filters)
@@ -1140,6 +1424,22 @@ func (_m *MockGatewayer) InjectBroadcastTransaction(txn coin.Transaction) error
 	return r0
 }
 
+// LastBlockAssembly provides a mock function with given fields:
+func (_m *MockGatewayer) LastBlockAssembly() *visor.BlockAssemblyReport {
+	ret := _m.Called()
+
+	var r0 *visor.BlockAssemblyReport
+	if rf, ok := ret.Get(0).(func() *visor.BlockAssemblyReport); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.BlockAssemblyReport)
+		}
+	}
+
+	return r0
+}
+
 // NewAddresses provides a mock function with given fields: wltID, password, n
 func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error) {
 	ret := _m.Called(wltID, password, n)
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/kvstorage"
+	"github.com/skycoin/skycoin/src/readable"
+)
+
+const (
+	// maxTransactionNoteLength is the maximum length of a transaction note
+	maxTransactionNoteLength = 1024
+	// pendingNoteTTL is how long a note for a created transaction is kept while waiting for the transaction to be injected
+	pendingNoteTTL = time.Hour * 24
+	// maxPendingNotes is the maximum number of notes kept for created transactions, the oldest notes are evicted first
+	maxPendingNotes = 10000
+)
+
+// validateTransactionNote checks the note sent with a transaction request
+func validateTransactionNote(note string) error {
+	if len(note) > maxTransactionNoteLength {
+		return fmt.Errorf("note must not be longer than %d bytes", maxTransactionNoteLength)
+	}
+	return nil
+}
+
+// pendingNotes holds notes for transactions that were created but not injected yet.
+// Notes are keyed by the transaction's inner hash, which does not change when the transaction is signed.
+// Notes are kept in the order they were added, so that expired notes and, once the limit is reached,
+// the oldest notes are removed from the front of the list without scanning all of them.
+type pendingNotes struct {
+	sync.Mutex
+	max   int
+	notes map[cipher.SHA256]*list.Element
+	order *list.List
+}
+
+type pendingNote struct {
+	innerHash cipher.SHA256
+	note      string
+	created   time.Time
+}
+
+// newPendingNotes creates pendingNotes holding at most max notes
+func newPendingNotes(max int) *pendingNotes {
+	return &pendingNotes{
+		max:   max,
+		notes: make(map[cipher.SHA256]*list.Element),
+		order: list.New(),
+	}
+}
+
+// add adds a note for a transaction's inner hash, replacing its previous note.
+// Expired notes are removed, and the oldest notes are evicted if there are more than the maximum
+func (p *pendingNotes) add(innerHash cipher.SHA256, note string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if e, ok := p.notes[innerHash]; ok {
+		p.remove(e)
+	}
+
+	now := time.Now()
+	p.notes[innerHash] = p.order.PushBack(pendingNote{
+		innerHash: innerHash,
+		note:      note,
+		created:   now,
+	})
+
+	for e := p.order.Front(); e != nil; e = p.order.Front() {
+		if p.order.Len() <= p.max && now.Sub(e.Value.(pendingNote).created) <= pendingNoteTTL {
+			break
+		}
+		p.remove(e)
+	}
+}
+
+// pop removes and returns the note for a transaction's inner hash
+func (p *pendingNotes) pop(innerHash cipher.SHA256) (string, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	e, ok := p.notes[innerHash]
+	if !ok {
+		return "", false
+	}
+
+	n := p.remove(e)
+
+	if time.Since(n.created) > pendingNoteTTL {
+		return "", false
+	}
+
+	return n.note, true
+}
+
+// remove removes a note from the list and the map
+func (p *pendingNotes) remove(e *list.Element) pendingNote {
+	n := p.order.Remove(e).(pendingNote)
+	delete(p.notes, n.innerHash)
+	return n
+}
+
+// getTransactionNote returns the note of a transaction, or an empty string if it has no note.
+// Returns an empty string if the storage API is disabled or the notes storage is not loaded.
+func getTransactionNote(gateway Storer, txid string) (string, error) {
+	note, err := gateway.GetStorageValue(kvstorage.TypeTxIDNotes, txid)
+	switch err {
+	case nil:
+		return note, nil
+	case kvstorage.ErrNoSuchKey, kvstorage.ErrStorageAPIDisabled, kvstorage.ErrNoSuchStorage:
+		return "", nil
+	default:
+		return "", err
+	}
+}
+
+// getTransactionNotes returns all transaction notes, keyed by txid.
+// Returns a nil map if the storage API is disabled or the notes storage is not loaded.
+func getTransactionNotes(gateway Storer) (map[string]string, error) {
+	notes, err := gateway.GetAllStorageValues(kvstorage.TypeTxIDNotes)
+	switch err {
+	case nil:
+		return notes, nil
+	case kvstorage.ErrStorageAPIDisabled, kvstorage.ErrNoSuchStorage:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// storeTransactionNote saves the note of an injected transaction.
+// The transaction has already been injected, so failures are logged but not returned.
+func storeTransactionNote(gateway Storer, txid cipher.SHA256, note string) {
+	if note == "" {
+		return
+	}
+
+	if err := gateway.AddStorageValue(kvstorage.TypeTxIDNotes, txid.Hex(), note); err != nil {
+		logger.WithError(err).WithField("txid", txid.Hex()).Error("Failed to store transaction note")
+	}
+}
+
+// TransactionNote is a transaction note matched by a search
+type TransactionNote struct {
+	TxID string `json:"txid"`
+	Note string `json:"note"`
+}
+
+// transactionNotesHandler searches transaction notes
+// Method: GET
+// URI: /api/v2/transaction/notes
+// Args:
+//	search: case insensitive substring to search for [optional, returns all notes if not provided]
+func transactionNotesHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		notes, err := gateway.GetAllStorageValues(kvstorage.TypeTxIDNotes)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case kvstorage.ErrStorageAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			case kvstorage.ErrNoSuchStorage:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "storage is not loaded")
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		search := strings.ToLower(r.FormValue("search"))
+
+		matches := make([]TransactionNote, 0)
+		for txid, note := range notes {
+			if strings.Contains(strings.ToLower(note), search) {
+				matches = append(matches, TransactionNote{
+					TxID: txid,
+					Note: note,
+				})
+			}
+		}
+
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i].TxID < matches[j].TxID
+		})
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: matches,
+		})
+	}
+}
+
+// setTransactionNotes sets the notes of readable transactions
+func setTransactionNotes(txns []readable.TransactionWithStatus, notes map[string]string) {
+	for i := range txns {
+		txns[i].Note = notes[txns[i].Transaction.Hash]
+	}
+}
+
+// setTransactionNotesVerbose sets the notes of verbose readable transactions
+func setTransactionNotesVerbose(txns []readable.TransactionWithStatusVerbose, notes map[string]string) {
+	for i := range txns {
+		txns[i].Note = notes[txns[i].Transaction.Hash]
+	}
+}
+
+// setUnconfirmedTransactionNotes sets the notes of readable unconfirmed transactions
+func setUnconfirmedTransactionNotes(txns []readable.UnconfirmedTransactions, notes map[string]string) {
+	for i := range txns {
+		txns[i].Note = notes[txns[i].Transaction.Hash]
+	}
+}
+
+// setUnconfirmedTransactionNotesVerbose sets the notes of verbose readable unconfirmed transactions
+func setUnconfirmedTransactionNotesVerbose(txns []readable.UnconfirmedTransactionVerbose, notes map[string]string) {
+	for i := range txns {
+		txns[i].Note = notes[txns[i].Transaction.Hash]
+	}
+}
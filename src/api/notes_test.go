@@ -0,0 +1,269 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/kvstorage"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestTransactionNotesHandler(t *testing.T) {
+	notes := map[string]string{
+		"b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52": "Invoice 1234",
+		"3bcf9e8a0e85bd05f4f1a4f4a8c3a5bdcf0e9d7c1ab5b74d5c1cfd5b6e8d3a2f": "rent",
+		"0a8b2c7de3f1a4c5b6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3": "invoice 5678",
+	}
+
+	tt := []struct {
+		name                      string
+		method                    string
+		query                     string
+		status                    int
+		getAllStorageValuesResult map[string]string
+		getAllStorageValuesErr    error
+		httpResponse              HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:                   "403",
+			method:                 http.MethodGet,
+			status:                 http.StatusForbidden,
+			getAllStorageValuesErr: kvstorage.ErrStorageAPIDisabled,
+			httpResponse:           NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:                   "404 - storage not loaded",
+			method:                 http.MethodGet,
+			status:                 http.StatusNotFound,
+			getAllStorageValuesErr: kvstorage.ErrNoSuchStorage,
+			httpResponse:           NewHTTPErrorResponse(http.StatusNotFound, "storage is not loaded"),
+		},
+		{
+			name:                   "500",
+			method:                 http.MethodGet,
+			status:                 http.StatusInternalServerError,
+			getAllStorageValuesErr: errors.New("getAllStorageValuesErr"),
+			httpResponse:           NewHTTPErrorResponse(http.StatusInternalServerError, "getAllStorageValuesErr"),
+		},
+		{
+			name:                      "200 - no notes",
+			method:                    http.MethodGet,
+			status:                    http.StatusOK,
+			getAllStorageValuesResult: map[string]string{},
+			httpResponse: HTTPResponse{
+				Data: []TransactionNote{},
+			},
+		},
+		{
+			name:                      "200 - all notes",
+			method:                    http.MethodGet,
+			status:                    http.StatusOK,
+			getAllStorageValuesResult: notes,
+			httpResponse: HTTPResponse{
+				Data: []TransactionNote{
+					{
+						TxID: "0a8b2c7de3f1a4c5b6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3",
+						Note: "invoice 5678",
+					},
+					{
+						TxID: "3bcf9e8a0e85bd05f4f1a4f4a8c3a5bdcf0e9d7c1ab5b74d5c1cfd5b6e8d3a2f",
+						Note: "rent",
+					},
+					{
+						TxID: "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+						Note: "Invoice 1234",
+					},
+				},
+			},
+		},
+		{
+			name:   "200 - search is case insensitive",
+			method: http.MethodGet,
+			query: url.Values{
+				"search": []string{"INVOICE"},
+			}.Encode(),
+			status:                    http.StatusOK,
+			getAllStorageValuesResult: notes,
+			httpResponse: HTTPResponse{
+				Data: []TransactionNote{
+					{
+						TxID: "0a8b2c7de3f1a4c5b6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3",
+						Note: "invoice 5678",
+					},
+					{
+						TxID: "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+						Note: "Invoice 1234",
+					},
+				},
+			},
+		},
+		{
+			name:   "200 - no match",
+			method: http.MethodGet,
+			query: url.Values{
+				"search": []string{"groceries"},
+			}.Encode(),
+			status:                    http.StatusOK,
+			getAllStorageValuesResult: notes,
+			httpResponse: HTTPResponse{
+				Data: []TransactionNote{},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetAllStorageValues", kvstorage.TypeTxIDNotes).Return(tc.getAllStorageValuesResult,
+				tc.getAllStorageValuesErr)
+
+			endpoint := "/api/v2/transaction/notes"
+
+			if tc.query != "" {
+				endpoint += "?" + tc.query
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(""))
+			require.NoError(t, err)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var notesRsp []TransactionNote
+				err := json.Unmarshal(rsp.Data, &notesRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data, notesRsp)
+			}
+		})
+	}
+}
+
+func TestPendingNotes(t *testing.T) {
+	p := newPendingNotes(maxPendingNotes)
+
+	h1 := testutil.RandSHA256(t)
+	h2 := testutil.RandSHA256(t)
+
+	_, ok := p.pop(h1)
+	require.False(t, ok)
+
+	p.add(h1, "foo")
+	p.add(h2, "")
+
+	note, ok := p.pop(h1)
+	require.True(t, ok)
+	require.Equal(t, "foo", note)
+
+	// A note can only be popped once
+	_, ok = p.pop(h1)
+	require.False(t, ok)
+
+	// Empty notes are recorded too
+	note, ok = p.pop(h2)
+	require.True(t, ok)
+	require.Equal(t, "", note)
+	require.Len(t, p.notes, 0)
+	require.Equal(t, 0, p.order.Len())
+
+	expire := func(h cipher.SHA256) {
+		e := p.notes[h]
+		n := e.Value.(pendingNote)
+		n.created = time.Now().Add(-pendingNoteTTL - time.Minute)
+		e.Value = n
+	}
+
+	// Expired notes are not returned
+	p.add(h1, "foo")
+	expire(h1)
+	_, ok = p.pop(h1)
+	require.False(t, ok)
+
+	// Expired notes are pruned when a note is added
+	p.add(h1, "foo")
+	expire(h1)
+	p.add(h2, "bar")
+	require.Len(t, p.notes, 1)
+	require.Equal(t, 1, p.order.Len())
+	_, ok = p.notes[h1]
+	require.False(t, ok)
+
+	// Adding a note again replaces it
+	p.add(h2, "baz")
+	require.Len(t, p.notes, 1)
+	require.Equal(t, 1, p.order.Len())
+	note, ok = p.pop(h2)
+	require.True(t, ok)
+	require.Equal(t, "baz", note)
+
+	var empty cipher.SHA256
+	_, ok = p.pop(empty)
+	require.False(t, ok)
+}
+
+func TestPendingNotesMax(t *testing.T) {
+	p := newPendingNotes(3)
+
+	hashes := make([]cipher.SHA256, 5)
+	for i := range hashes {
+		hashes[i] = testutil.RandSHA256(t)
+		p.add(hashes[i], fmt.Sprint(i))
+		require.True(t, len(p.notes) <= 3)
+		require.Equal(t, len(p.notes), p.order.Len())
+	}
+
+	// The oldest notes are evicted
+	for _, h := range hashes[:2] {
+		_, ok := p.pop(h)
+		require.False(t, ok)
+	}
+
+	// Re-adding a note makes it the newest, so the next oldest note is evicted instead
+	p.add(hashes[2], "2")
+	p.add(testutil.RandSHA256(t), "5")
+	_, ok := p.pop(hashes[3])
+	require.False(t, ok)
+
+	for _, i := range []int{2, 4} {
+		note, ok := p.pop(hashes[i])
+		require.True(t, ok)
+		require.Equal(t, fmt.Sprint(i), note)
+	}
+}
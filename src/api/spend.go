@@ -268,6 +268,7 @@ type createTransactionRequest struct {
 	To                []receiver     `json:"to"`
 	UxOuts            []wh.SHA256    `json:"unspents,omitempty"`
 	Addresses         []wh.Address   `json:"addresses,omitempty"`
+	Note              string         `json:"note,omitempty"`
 }
 
 // hoursSelection defines options for hours distribution
@@ -317,6 +318,25 @@ func (r createTransactionRequest) Validate() error {
 			return errors.New("hours_selection.mode cannot be used for manual hours_selection.type")
 		}
 
+	case transaction.HoursSelectionTypeTarget:
+		for i, to := range r.To {
+			if to.Hours == nil {
+				return fmt.Errorf("to[%d].hours must be specified for target hours_selection.type", i)
+			}
+
+			if to.Hours.Value() == 0 {
+				return fmt.Errorf("to[%d].hours must not be zero for target hours_selection.type", i)
+			}
+		}
+
+		switch r.HoursSelection.Mode {
+		case transaction.HoursSelectionModeExact, transaction.HoursSelectionModeMinimum:
+		case "":
+			return errors.New("missing hours_selection.mode")
+		default:
+			return errors.New("invalid hours_selection.mode")
+		}
+
 	case "":
 		return errors.New("missing hours_selection.type")
 	default:
@@ -324,12 +344,17 @@ func (r createTransactionRequest) Validate() error {
 	}
 
 	if r.HoursSelection.ShareFactor == nil {
-		if r.HoursSelection.Mode == transaction.HoursSelectionModeShare {
+		switch r.HoursSelection.Mode {
+		case transaction.HoursSelectionModeShare:
 			return errors.New("missing hours_selection.share_factor when hours_selection.mode is share")
+		case transaction.HoursSelectionModeMinimum:
+			return errors.New("missing hours_selection.share_factor when hours_selection.mode is minimum")
 		}
 	} else {
-		if r.HoursSelection.Mode != transaction.HoursSelectionModeShare {
-			return errors.New("hours_selection.share_factor can only be used when hours_selection.mode is share")
+		switch r.HoursSelection.Mode {
+		case transaction.HoursSelectionModeShare, transaction.HoursSelectionModeMinimum:
+		default:
+			return errors.New("hours_selection.share_factor can only be used when hours_selection.mode is share or minimum")
 		}
 
 		switch {
@@ -344,6 +369,10 @@ func (r createTransactionRequest) Validate() error {
 		return errors.New("unspents and addresses cannot be combined")
 	}
 
+	if err := validateTransactionNote(r.Note); err != nil {
+		return err
+	}
+
 	addressMap := make(map[cipher.Address]struct{}, len(r.Addresses))
 	for i, a := range r.Addresses {
 		if a.Null() {
@@ -479,7 +508,7 @@ func (r createTransactionRequest) uxOuts() []cipher.SHA256 {
 // Method: POST
 // URI: /api/v2/transaction
 // Args: JSON body
-func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
+func transactionHandlerV2(gateway Gatewayer, notes *pendingNotes) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
@@ -534,6 +563,10 @@ func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if req.Note != "" {
+			notes.add(txn.InnerHash, req.Note)
+		}
+
 		writeHTTPResponse(w, HTTPResponse{
 			Data: txnResp,
 		})
@@ -565,7 +598,7 @@ func (r walletCreateTransactionRequest) Validate() error {
 // Method: POST
 // URI: /api/v1/wallet/transaction
 // Args: JSON body
-func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
+func walletCreateTransactionHandler(gateway Gatewayer, notes *pendingNotes) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			wh.Error405(w)
@@ -632,6 +665,10 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if req.Note != "" {
+			notes.add(txn.InnerHash, req.Note)
+		}
+
 		wh.SendJSONOr500(logger, w, txnResp)
 	}
 }
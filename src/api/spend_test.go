@@ -205,7 +205,86 @@ func TestCreateTransaction(t *testing.T) {
 				},
 			},
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor can only be used when hours_selection.mode is share"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor can only be used when hours_selection.mode is share or minimum"),
+		},
+
+		{
+			name:   "400 - missing hours for target hours selection type",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeTarget,
+					Mode: transaction.HoursSelectionModeExact,
+				},
+				To: []rawReceiver{
+					{
+						Address: destinationAddress.String(),
+						Coins:   "100",
+					},
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].hours must be specified for target hours_selection.type"),
+		},
+
+		{
+			name:   "400 - zero hours for target hours selection type",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeTarget,
+					Mode: transaction.HoursSelectionModeExact,
+				},
+				To: []rawReceiver{
+					{
+						Address: destinationAddress.String(),
+						Coins:   "100",
+						Hours:   "0",
+					},
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].hours must not be zero for target hours_selection.type"),
+		},
+
+		{
+			name:   "400 - invalid hours selection mode for target type",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeTarget,
+					Mode: transaction.HoursSelectionModeShare,
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid hours_selection.mode"),
+		},
+
+		{
+			name:   "400 - missing hours selection share factor for minimum mode",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeTarget,
+					Mode: transaction.HoursSelectionModeMinimum,
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "missing hours_selection.share_factor when hours_selection.mode is minimum"),
+		},
+
+		{
+			name:   "400 - share factor set for exact mode",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type:        transaction.HoursSelectionTypeTarget,
+					Mode:        transaction.HoursSelectionModeExact,
+					ShareFactor: newStrPtr("0.5"),
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor can only be used when hours_selection.mode is share or minimum"),
 		},
 
 		{
@@ -1058,7 +1137,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 				WalletID: "foo.wlt",
 			},
 			status: http.StatusBadRequest,
-			err:    "400 Bad Request - hours_selection.share_factor can only be used when hours_selection.mode is share",
+			err:    "400 Bad Request - hours_selection.share_factor can only be used when hours_selection.mode is share or minimum",
 		},
 
 		{
@@ -24,6 +24,7 @@ import (
 // URI: /api/v1/pendingTxs
 // Args:
 //	verbose: [bool] include verbose transaction input data
+//	evicted: [bool] return the transactions recently evicted from the pool instead, with the eviction reason
 func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -37,10 +38,28 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		if verbose {
+		evicted, err := parseBoolFlag(r.FormValue("evicted"))
+		if err != nil {
+			wh.Error400(w, "Invalid value for evicted")
+			return
+		}
+
+		if evicted && verbose {
+			wh.Error400(w, "verbose and evicted cannot be combined")
+			return
+		}
+
+		if evicted {
+			wh.SendJSONOr500(logger, w, NewEvictedTransactions(gateway.GetEvictedTransactions()))
+		} else if verbose {
 			txns, inputs, err := gateway.GetAllUnconfirmedTransactionsVerbose()
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err {
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 
@@ -69,6 +88,89 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// EvictedTransaction is a transaction that was evicted from the unconfirmed pool
+type EvictedTransaction struct {
+	Txid    string `json:"txid"`
+	Reason  string `json:"reason"`
+	Evicted int64  `json:"evicted"`
+}
+
+// NewEvictedTransactions creates []EvictedTransaction from []visor.EvictedTransaction
+func NewEvictedTransactions(txns []visor.EvictedTransaction) []EvictedTransaction {
+	evicted := make([]EvictedTransaction, len(txns))
+	for i, txn := range txns {
+		evicted[i] = EvictedTransaction{
+			Txid:    txn.Hash.Hex(),
+			Reason:  string(txn.Reason),
+			Evicted: txn.Time.Unix(),
+		}
+	}
+	return evicted
+}
+
+// SkippedTransaction is a pending transaction that was not included in the last assembled block
+type SkippedTransaction struct {
+	Txid   string `json:"txid"`
+	Reason string `json:"reason"`
+}
+
+// BlockAssemblyReport is the response data struct for /api/v2/pendingTxs/assembly
+type BlockAssemblyReport struct {
+	Time     uint64               `json:"time"`
+	Held     bool                 `json:"held"`
+	Included []string             `json:"included"`
+	Skipped  []SkippedTransaction `json:"skipped"`
+}
+
+// NewBlockAssemblyReport creates a BlockAssemblyReport from a visor.BlockAssemblyReport
+func NewBlockAssemblyReport(r visor.BlockAssemblyReport) BlockAssemblyReport {
+	included := make([]string, len(r.Included))
+	for i, h := range r.Included {
+		included[i] = h.Hex()
+	}
+
+	skipped := make([]SkippedTransaction, len(r.Skipped))
+	for i, s := range r.Skipped {
+		skipped[i] = SkippedTransaction{
+			Txid:   s.Hash.Hex(),
+			Reason: string(s.Reason),
+		}
+	}
+
+	return BlockAssemblyReport{
+		Time:     r.Time,
+		Held:     r.Held,
+		Included: included,
+		Skipped:  skipped,
+	}
+}
+
+// pendingTxnsAssemblyHandler returns the result of the block publisher's most recent block assembly,
+// with the reason each pending transaction was not included in the block
+// Method: GET
+// URI: /api/v2/pendingTxs/assembly
+// Returns 404 if no block has been assembled since startup, or if not a block publisher.
+func pendingTxnsAssemblyHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		report := gateway.LastBlockAssembly()
+		if report == nil {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "no block has been assembled")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewBlockAssemblyReport(*report),
+		})
+	}
+}
+
 // TransactionEncodedResponse represents the data struct of the response to /api/v1/transaction?encoded=1
 type TransactionEncodedResponse struct {
 	Status             readable.TransactionStatus `json:"status"`
@@ -122,7 +224,12 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			txn, inputs, err := gateway.GetTransactionWithInputs(h)
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err {
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 			if txn == nil {
@@ -136,13 +243,24 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			rTxn.Note, err = getTransactionNote(gateway, rTxn.Transaction.Hash)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
 			wh.SendJSONOr500(logger, w, rTxn)
 			return
 		}
 
 		txn, err := gateway.GetTransaction(h)
 		if err != nil {
-			wh.Error500(w, err.Error())
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				wh.Error503(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 		if txn == nil {
@@ -171,6 +289,12 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		rTxn.Note, err = getTransactionNote(gateway, rTxn.Transaction.Hash)
+		if err != nil {
+			wh.Error500(w, err.Error())
+			return
+		}
+
 		wh.SendJSONOr500(logger, w, rTxn)
 	}
 }
@@ -294,7 +418,12 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			txns, inputs, err := gateway.GetTransactionsWithInputs(flts)
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err {
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 
@@ -304,13 +433,25 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			notes, err := getTransactionNotes(gateway)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			setTransactionNotesVerbose(rTxns.Transactions, notes)
 			rTxns.Sort()
 
 			wh.SendJSONOr500(logger, w, rTxns.Transactions)
 		} else {
 			txns, err := gateway.GetTransactions(flts)
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err {
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 
@@ -320,6 +461,13 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			notes, err := getTransactionNotes(gateway)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			setTransactionNotes(rTxns.Transactions, notes)
 			rTxns.Sort()
 
 			wh.SendJSONOr500(logger, w, rTxns.Transactions)
@@ -330,13 +478,15 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 // URI: /api/v1/injectTransaction
 // Method: POST
 // Content-Type: application/json
-// Body: {"rawtx": "<hex encoded transaction>"}
+// Body: {"rawtx": "<hex encoded transaction>", "note": "<optional note>"}
+// If no note is provided, the note given when the transaction was created is used.
+// The note is saved to the transaction notes storage if the transaction is injected.
 // Response:
 //      200 - ok, returns the transaction hash in hex as string
 //      400 - bad transaction
 //		500 - other error
 //      503 - network unavailable for broadcasting transaction
-func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
+func injectTransactionHandler(gateway Gatewayer, notes *pendingNotes) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			wh.Error405(w)
@@ -345,6 +495,7 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		// get the rawtransaction
 		v := struct {
 			Rawtx string `json:"rawtx"`
+			Note  string `json:"note"`
 		}{}
 
 		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
@@ -357,6 +508,11 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if err := validateTransactionNote(v.Note); err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
 		txn, err := coin.DeserializeTransactionHex(v.Rawtx)
 		if err != nil {
 			wh.Error400(w, err.Error())
@@ -370,7 +526,9 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 				visor.ErrTxnViolatesSoftConstraint:
 				wh.Error400(w, err.Error())
 			default:
-				if daemon.IsBroadcastFailure(err) {
+				if err == visor.ErrUnconfirmedPoolFull {
+					wh.Error400(w, err.Error())
+				} else if daemon.IsBroadcastFailure(err) {
 					wh.Error503(w, err.Error())
 				} else {
 					wh.Error500(w, err.Error())
@@ -379,6 +537,12 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		note, ok := notes.pop(txn.InnerHash)
+		if v.Note != "" || !ok {
+			note = v.Note
+		}
+		storeTransactionNote(gateway, txn.Hash(), note)
+
 		wh.SendJSONOr500(logger, w, txn.Hash().Hex())
 	}
 }
@@ -455,7 +619,12 @@ func rawTxnHandler(gateway Gatewayer) http.HandlerFunc {
 
 		txn, err := gateway.GetTransaction(h)
 		if err != nil {
-			wh.Error400(w, err.Error())
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				wh.Error503(w, err.Error())
+			default:
+				wh.Error400(w, err.Error())
+			}
 			return
 		}
 
@@ -535,7 +704,12 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 					Message: err.Error(),
 				}
 			default:
-				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				status := http.StatusInternalServerError
+				switch err {
+				case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+					status = http.StatusServiceUnavailable
+				}
+				resp := NewHTTPErrorResponse(status, err.Error())
 				writeHTTPResponse(w, resp)
 				return
 			}
@@ -571,6 +745,121 @@ func verifyTxnHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// TransactionProof is the response data struct for /api/v2/transaction/proof.
+// Hashes is the merkle proof of the txid against the header's tx_body_hash, leaf level first.
+// Index is the position of the transaction in the block.
+type TransactionProof struct {
+	Txid      string               `json:"txid"`
+	Header    readable.BlockHeader `json:"header"`
+	Signature string               `json:"signature"`
+	Index     uint64               `json:"index"`
+	Hashes    []string             `json:"hashes"`
+}
+
+// NewTransactionProof creates a TransactionProof from a visor.TransactionProof
+func NewTransactionProof(p visor.TransactionProof) TransactionProof {
+	hashes := make([]string, len(p.Hashes))
+	for i, h := range p.Hashes {
+		hashes[i] = h.Hex()
+	}
+
+	return TransactionProof{
+		Txid:      p.Txid.Hex(),
+		Header:    readable.NewBlockHeader(p.Header),
+		Signature: p.Sig.Hex(),
+		Index:     p.Index,
+		Hashes:    hashes,
+	}
+}
+
+// ToVisorTransactionProof converts TransactionProof back to visor.TransactionProof
+func (p TransactionProof) ToVisorTransactionProof() (*visor.TransactionProof, error) {
+	txid, err := cipher.SHA256FromHex(p.Txid)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := p.Header.ToCoinBlockHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := cipher.SigFromHex(p.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]cipher.SHA256, len(p.Hashes))
+	for i, h := range p.Hashes {
+		hashes[i], err = cipher.SHA256FromHex(h)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &visor.TransactionProof{
+		Txid:   txid,
+		Header: header,
+		Sig:    sig,
+		Index:  p.Index,
+		Hashes: hashes,
+	}, nil
+}
+
+// Returns a merkle proof that a confirmed transaction is included in a signed block
+// Method: GET
+// URI: /api/v2/transaction/proof
+// Args:
+//	txid: transaction ID hash
+// The proof can be verified with only the block header, its signature and the blockchain pubkey.
+// Unconfirmed transactions have no proof and return 404.
+func transactionProofHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txid := r.FormValue("txid")
+		if txid == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "txid is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		h, err := cipher.SHA256FromHex(txid)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid txid: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		proof, err := gateway.GetTransactionProof(h)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if proof == nil {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewTransactionProof(*proof),
+		})
+	}
+}
+
 func decodeTxn(encodedTxn string) (*coin.Transaction, error) {
 	var txn coin.Transaction
 	b, err := hex.DecodeString(encodedTxn)
@@ -21,6 +21,7 @@ import (
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor"
@@ -84,6 +85,7 @@ func TestGetPendingTxs(t *testing.T) {
 	invalidTxn.Transaction.Out = append(invalidTxn.Transaction.Out, coin.TransactionOutput{
 		Coins: math.MaxInt64 + 1,
 	})
+	evictedHash := testutil.RandSHA256(t)
 
 	type verboseResult struct {
 		Transactions []visor.UnconfirmedTransaction
@@ -101,6 +103,9 @@ func TestGetPendingTxs(t *testing.T) {
 		getAllUnconfirmedTxnsErr             error
 		getAllUnconfirmedTxnsVerboseResponse verboseResult
 		getAllUnconfirmedTxnsVerboseErr      error
+		evicted                              bool
+		evictedStr                           string
+		getEvictedTxnsResponse               []visor.EvictedTransaction
 		httpResponse                         interface{}
 	}{
 		{
@@ -117,6 +122,21 @@ func TestGetPendingTxs(t *testing.T) {
 			err:        "400 Bad Request - Invalid value for verbose",
 			verboseStr: "foo",
 		},
+		{
+			name:       "400 - bad evicted",
+			method:     http.MethodGet,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - Invalid value for evicted",
+			evictedStr: "foo",
+		},
+		{
+			name:       "400 - verbose and evicted",
+			method:     http.MethodGet,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - verbose and evicted cannot be combined",
+			verboseStr: "1",
+			evictedStr: "1",
+		},
 		{
 			name:   "500 - bad unconfirmedTxn",
 			method: http.MethodGet,
@@ -142,6 +162,15 @@ func TestGetPendingTxs(t *testing.T) {
 			err:                             "500 Internal Server Error - GetAllUnconfirmedTransactionsVerbose failed",
 			getAllUnconfirmedTxnsVerboseErr: errors.New("GetAllUnconfirmedTransactionsVerbose failed"),
 		},
+		{
+			name:                            "503 - get unconfirmedTxnVerbose history disabled",
+			method:                          http.MethodGet,
+			status:                          http.StatusServiceUnavailable,
+			verboseStr:                      "1",
+			verbose:                         true,
+			err:                             "503 Service Unavailable - history is disabled",
+			getAllUnconfirmedTxnsVerboseErr: visor.ErrHistoryDisabled,
+		},
 		{
 			name:                          "200",
 			method:                        http.MethodGet,
@@ -161,6 +190,27 @@ func TestGetPendingTxs(t *testing.T) {
 			},
 			httpResponse: []readable.UnconfirmedTransactionVerbose{},
 		},
+		{
+			name:       "200 evicted",
+			method:     http.MethodGet,
+			status:     http.StatusOK,
+			evictedStr: "1",
+			evicted:    true,
+			getEvictedTxnsResponse: []visor.EvictedTransaction{
+				{
+					Hash:   evictedHash,
+					Reason: visor.EvictPoolFull,
+					Time:   time.Unix(1540000000, 0),
+				},
+			},
+			httpResponse: []EvictedTransaction{
+				{
+					Txid:    evictedHash.Hex(),
+					Reason:  "pool full",
+					Evicted: 1540000000,
+				},
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -170,11 +220,15 @@ func TestGetPendingTxs(t *testing.T) {
 			gateway.On("GetAllUnconfirmedTransactions").Return(tc.getAllUnconfirmedTxnsResponse, tc.getAllUnconfirmedTxnsErr)
 			gateway.On("GetAllUnconfirmedTransactionsVerbose").Return(tc.getAllUnconfirmedTxnsVerboseResponse.Transactions,
 				tc.getAllUnconfirmedTxnsVerboseResponse.Inputs, tc.getAllUnconfirmedTxnsVerboseErr)
+			gateway.On("GetEvictedTransactions").Return(tc.getEvictedTxnsResponse)
 
 			v := url.Values{}
 			if tc.verboseStr != "" {
 				v.Add("verbose", tc.verboseStr)
 			}
+			if tc.evictedStr != "" {
+				v.Add("evicted", tc.evictedStr)
+			}
 			if len(v) > 0 {
 				endpoint += "?" + v.Encode()
 			}
@@ -199,7 +253,12 @@ func TestGetPendingTxs(t *testing.T) {
 				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
-				if tc.verbose {
+				if tc.evicted {
+					var msg []EvictedTransaction
+					err = json.Unmarshal(rr.Body.Bytes(), &msg)
+					require.NoError(t, err)
+					require.Equal(t, tc.httpResponse, msg, tc.name)
+				} else if tc.verbose {
 					var msg []readable.UnconfirmedTransactionVerbose
 					err = json.Unmarshal(rr.Body.Bytes(), &msg)
 					require.NoError(t, err)
@@ -254,6 +313,8 @@ func TestGetTransactionByID(t *testing.T) {
 		getTransactionError                error
 		getTransactionResultVerboseReponse verboseResult
 		getTransactionResultVerboseError   error
+		getTransactionNote                 string
+		getTransactionNoteError            error
 		httpResponse                       interface{}
 	}{
 		{
@@ -357,6 +418,18 @@ func TestGetTransactionByID(t *testing.T) {
 			getTransactionError: errors.New("getTransactionError"),
 		},
 
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			txid:                testutil.SHA256FromHex(t, validHash),
+			getTransactionError: visor.ErrHistoryDisabled,
+		},
+
 		{
 			name:   "500 - getTransactionResultVerboseError",
 			method: http.MethodGet,
@@ -520,6 +593,137 @@ func TestGetTransactionByID(t *testing.T) {
 			},
 		},
 
+		{
+			name:   "500 - getTransactionNoteError",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - getTransactionNoteError",
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			txid: testutil.SHA256FromHex(t, validHash),
+			getTransactionReponse: &visor.Transaction{
+				Transaction: coin.Transaction{
+					Sigs: []cipher.Sig{validSigRaw},
+					In:   []cipher.SHA256{validHashRaw},
+					Out: []coin.TransactionOutput{
+						{
+							Coins:   9999,
+							Hours:   1111,
+							Address: validAddrRaw,
+						},
+					},
+				},
+				Status: visor.TransactionStatus{
+					Confirmed: true,
+					BlockSeq:  100,
+					Height:    9,
+				},
+			},
+			getTransactionNoteError: errors.New("getTransactionNoteError"),
+		},
+
+		{
+			name:   "200 with note",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			txid: testutil.SHA256FromHex(t, validHash),
+			getTransactionReponse: &visor.Transaction{
+				Transaction: coin.Transaction{
+					Sigs: []cipher.Sig{validSigRaw},
+					In:   []cipher.SHA256{validHashRaw},
+					Out: []coin.TransactionOutput{
+						{
+							Coins:   9999,
+							Hours:   1111,
+							Address: validAddrRaw,
+						},
+					},
+				},
+				Status: visor.TransactionStatus{
+					Confirmed: true,
+					BlockSeq:  100,
+					Height:    9,
+				},
+			},
+			getTransactionNote: "invoice 1234",
+			httpResponse: &readable.TransactionWithStatus{
+				Status: readable.TransactionStatus{
+					Confirmed: true,
+					BlockSeq:  100,
+					Height:    9,
+				},
+				Transaction: readable.Transaction{
+					Hash:      "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+					InnerHash: "0000000000000000000000000000000000000000000000000000000000000000",
+					Sigs:      []string{validSig},
+					In:        []string{validHash},
+					Out: []readable.TransactionOutput{
+						{
+							Hash:    "87ec4d440fd64bb4c26839d58684e567e499265ca396649c03304b928378720b",
+							Coins:   "0.009999",
+							Hours:   1111,
+							Address: validAddr,
+						},
+					},
+				},
+				Note: "invoice 1234",
+			},
+		},
+
+		{
+			name:   "200 storage disabled",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			txid: testutil.SHA256FromHex(t, validHash),
+			getTransactionReponse: &visor.Transaction{
+				Transaction: coin.Transaction{
+					Sigs: []cipher.Sig{validSigRaw},
+					In:   []cipher.SHA256{validHashRaw},
+					Out: []coin.TransactionOutput{
+						{
+							Coins:   9999,
+							Hours:   1111,
+							Address: validAddrRaw,
+						},
+					},
+				},
+				Status: visor.TransactionStatus{
+					Confirmed: true,
+					BlockSeq:  100,
+					Height:    9,
+				},
+			},
+			getTransactionNoteError: kvstorage.ErrStorageAPIDisabled,
+			httpResponse: &readable.TransactionWithStatus{
+				Status: readable.TransactionStatus{
+					Confirmed: true,
+					BlockSeq:  100,
+					Height:    9,
+				},
+				Transaction: readable.Transaction{
+					Hash:      "b64525bc14edb3c838ff3ef4f01bd74712432b32c18463dbda59b431959b2e52",
+					InnerHash: "0000000000000000000000000000000000000000000000000000000000000000",
+					Sigs:      []string{validSig},
+					In:        []string{validHash},
+					Out: []readable.TransactionOutput{
+						{
+							Hash:    "87ec4d440fd64bb4c26839d58684e567e499265ca396649c03304b928378720b",
+							Coins:   "0.009999",
+							Hours:   1111,
+							Address: validAddr,
+						},
+					},
+				},
+			},
+		},
+
 		{
 			name:   "200 encoded",
 			method: http.MethodGet,
@@ -566,6 +770,7 @@ func TestGetTransactionByID(t *testing.T) {
 			gateway.On("GetTransaction", tc.txid).Return(tc.getTransactionReponse, tc.getTransactionError)
 			gateway.On("GetTransactionWithInputs", tc.txid).Return(tc.getTransactionResultVerboseReponse.Transaction,
 				tc.getTransactionResultVerboseReponse.Inputs, tc.getTransactionResultVerboseError)
+			gateway.On("GetStorageValue", kvstorage.TypeTxIDNotes, mock.Anything).Return(tc.getTransactionNote, tc.getTransactionNoteError)
 
 			v := url.Values{}
 			if tc.httpBody != nil {
@@ -630,6 +835,7 @@ func TestInjectTransaction(t *testing.T) {
 
 	type httpBody struct {
 		Rawtx string `json:"rawtx"`
+		Note  string `json:"note,omitempty"`
 	}
 
 	validTxnBody := &httpBody{
@@ -644,6 +850,18 @@ func TestInjectTransaction(t *testing.T) {
 	invalidTxnBodyJSON, err := json.Marshal(b)
 	require.NoError(t, err)
 
+	noteTxnBodyJSON, err := json.Marshal(&httpBody{
+		Rawtx: validTransaction.MustSerializeHex(),
+		Note:  "invoice 1234",
+	})
+	require.NoError(t, err)
+
+	longNoteTxnBodyJSON, err := json.Marshal(&httpBody{
+		Rawtx: validTransaction.MustSerializeHex(),
+		Note:  strings.Repeat("a", maxTransactionNoteLength+1),
+	})
+	require.NoError(t, err)
+
 	tt := []struct {
 		name                   string
 		method                 string
@@ -652,6 +870,7 @@ func TestInjectTransaction(t *testing.T) {
 		httpBody               string
 		injectTransactionArg   coin.Transaction
 		injectTransactionError error
+		addStorageValueArg     string
 		httpResponse           string
 		csrfDisabled           bool
 	}{
@@ -689,6 +908,22 @@ func TestInjectTransaction(t *testing.T) {
 			err:      "400 Bad Request - Invalid transaction: Not enough buffer data to deserialize",
 			httpBody: string(invalidTxnBodyJSON),
 		},
+		{
+			name:     "400 - note too long",
+			method:   http.MethodPost,
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - note must not be longer than 1024 bytes",
+			httpBody: string(longNoteTxnBodyJSON),
+		},
+		{
+			name:                   "400 - unconfirmed pool full",
+			method:                 http.MethodPost,
+			status:                 http.StatusBadRequest,
+			err:                    "400 Bad Request - " + visor.ErrUnconfirmedPoolFull.Error(),
+			httpBody:               string(validTxnBodyJSON),
+			injectTransactionArg:   validTransaction,
+			injectTransactionError: visor.ErrUnconfirmedPoolFull,
+		},
 		{
 			name:                   "503 - daemon.ErrNetworkingDisabled",
 			method:                 http.MethodPost,
@@ -733,6 +968,15 @@ func TestInjectTransaction(t *testing.T) {
 			injectTransactionArg: validTransaction,
 			httpResponse:         validTransaction.Hash().Hex(),
 		},
+		{
+			name:                 "200 with note",
+			method:               http.MethodPost,
+			status:               http.StatusOK,
+			httpBody:             string(noteTxnBodyJSON),
+			injectTransactionArg: validTransaction,
+			addStorageValueArg:   "invoice 1234",
+			httpResponse:         validTransaction.Hash().Hex(),
+		},
 		{
 			name:                 "200 - csrf disabled",
 			method:               http.MethodPost,
@@ -749,6 +993,7 @@ func TestInjectTransaction(t *testing.T) {
 			endpoint := "/api/v1/injectTransaction"
 			gateway := &MockGatewayer{}
 			gateway.On("InjectBroadcastTransaction", tc.injectTransactionArg).Return(tc.injectTransactionError)
+			gateway.On("AddStorageValue", kvstorage.TypeTxIDNotes, tc.injectTransactionArg.Hash().Hex(), tc.addStorageValueArg).Return(nil)
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
@@ -938,6 +1183,17 @@ func TestGetRawTxn(t *testing.T) {
 			getTransactionArg:   testutil.SHA256FromHex(t, validHash),
 			getTransactionError: errors.New("getTransactionError"),
 		},
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			getTransactionArg:   testutil.SHA256FromHex(t, validHash),
+			getTransactionError: visor.ErrHistoryDisabled,
+		},
 		{
 			name:   "404",
 			method: http.MethodGet,
@@ -1038,6 +1294,8 @@ func TestGetTransactions(t *testing.T) {
 		getTransactionsError           error
 		getTransactionsVerboseResponse verboseResult
 		getTransactionsVerboseError    error
+		getTransactionNotes            map[string]string
+		getTransactionNotesError       error
 		httpResponse                   interface{}
 	}{
 		{
@@ -1104,6 +1362,22 @@ func TestGetTransactions(t *testing.T) {
 			getTransactionsError: errors.New("getTransactionsError"),
 		},
 
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			httpBody: &httpBody{
+				addrs:     addrsStr,
+				confirmed: "true",
+			},
+			getTransactionsArg: []visor.TxFilter{
+				visor.NewAddrsFilter(addrs),
+				visor.NewConfirmedTxFilter(true),
+			},
+			getTransactionsError: visor.ErrHistoryDisabled,
+		},
+
 		{
 			name:   "500 - getTransactionsVerboseError",
 			method: http.MethodGet,
@@ -1159,6 +1433,23 @@ func TestGetTransactions(t *testing.T) {
 			httpResponse: []readable.TransactionWithStatusVerbose{},
 		},
 
+		{
+			name:   "500 - getTransactionNotesError",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - getTransactionNotesError",
+			httpBody: &httpBody{
+				addrs:     addrsStr,
+				confirmed: "true",
+			},
+			getTransactionsArg: []visor.TxFilter{
+				visor.NewAddrsFilter(addrs),
+				visor.NewConfirmedTxFilter(true),
+			},
+			getTransactionsResponse:  []visor.Transaction{},
+			getTransactionNotesError: errors.New("getTransactionNotesError"),
+		},
+
 		{
 			name:   "200 POST",
 			method: http.MethodPost,
@@ -1235,6 +1526,7 @@ func TestGetTransactions(t *testing.T) {
 			gateway.On("GetTransactions", matchFunc).Return(tc.getTransactionsResponse, tc.getTransactionsError)
 			gateway.On("GetTransactionsWithInputs", matchFunc).Return(tc.getTransactionsVerboseResponse.Transactions,
 				tc.getTransactionsVerboseResponse.Inputs, tc.getTransactionsVerboseError)
+			gateway.On("GetAllStorageValues", kvstorage.TypeTxIDNotes).Return(tc.getTransactionNotes, tc.getTransactionNotesError)
 
 			v := url.Values{}
 			if tc.httpBody != nil {
@@ -1518,6 +1810,19 @@ func TestVerifyTransaction(t *testing.T) {
 			},
 			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "verify transaction failed"),
 		},
+		{
+			name:                          "503 - history disabled",
+			method:                        http.MethodPost,
+			contentType:                   ContentTypeJSON,
+			status:                        http.StatusServiceUnavailable,
+			httpBody:                      string(validTxnBodyJSON),
+			gatewayVerifyTxnVerboseArg:    txnAndInputs.txn,
+			gatewayVerifyTxnVerboseSigned: visor.TxnSigned,
+			gatewayVerifyTxnVerboseResult: verifyTxnVerboseResult{
+				Err: visor.ErrHistoryDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusServiceUnavailable, visor.ErrHistoryDisabled.Error()),
+		},
 		{
 			name:                          "422 - txn is confirmed",
 			method:                        http.MethodPost,
@@ -1618,3 +1923,284 @@ func TestVerifyTransaction(t *testing.T) {
 		})
 	}
 }
+
+func makeTransactionProof(t *testing.T) (visor.TransactionProof, cipher.PubKey) {
+	pubkey, seckey := cipher.GenerateKeyPair()
+
+	txns := coin.Transactions{makeTransaction(t), makeTransaction(t), makeTransaction(t)}
+	body := coin.BlockBody{
+		Transactions: txns,
+	}
+	header := coin.BlockHeader{
+		Version:  2,
+		Time:     1540000000,
+		BkSeq:    4,
+		Fee:      10,
+		PrevHash: testutil.RandSHA256(t),
+		BodyHash: body.Hash(),
+		UxHash:   testutil.RandSHA256(t),
+	}
+
+	hashes, err := body.TransactionProof(1)
+	require.NoError(t, err)
+
+	return visor.TransactionProof{
+		Txid:   txns[1].Hash(),
+		Header: header,
+		Sig:    cipher.MustSignHash(header.Hash(), seckey),
+		Index:  1,
+		Hashes: hashes,
+	}, pubkey
+}
+
+func TestTransactionProof(t *testing.T) {
+	proof, pubkey := makeTransactionProof(t)
+	txid := proof.Txid.Hex()
+
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		txid         string
+		gatewayArg   cipher.SHA256
+		gatewayProof *visor.TransactionProof
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "400 - missing txid",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "txid is required"),
+		},
+
+		{
+			name:         "400 - invalid txid",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			txid:         "abcd",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid txid: Invalid hex length"),
+		},
+
+		{
+			name:         "404 - transaction not confirmed",
+			method:       http.MethodGet,
+			status:       http.StatusNotFound,
+			txid:         txid,
+			gatewayArg:   proof.Txid,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+
+		{
+			name:         "500 - gateway error",
+			method:       http.MethodGet,
+			status:       http.StatusInternalServerError,
+			txid:         txid,
+			gatewayArg:   proof.Txid,
+			gatewayErr:   errors.New("failed"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+
+		{
+			name:         "200",
+			method:       http.MethodGet,
+			status:       http.StatusOK,
+			txid:         txid,
+			gatewayArg:   proof.Txid,
+			gatewayProof: &proof,
+			httpResponse: HTTPResponse{
+				Data: NewTransactionProof(proof),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/transaction/proof"
+			gateway := &MockGatewayer{}
+			gateway.On("GetTransactionProof", tc.gatewayArg).Return(tc.gatewayProof, tc.gatewayErr)
+
+			if tc.txid != "" {
+				endpoint += "?" + url.Values{"txid": []string{tc.txid}}.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var p TransactionProof
+				err := json.Unmarshal(rsp.Data, &p)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(TransactionProof), p)
+				require.NoError(t, VerifyTransactionProof(pubkey, proof.Txid, p))
+			}
+		})
+	}
+}
+
+func TestPendingTxnsAssembly(t *testing.T) {
+	included := testutil.RandSHA256(t)
+	skipped := testutil.RandSHA256(t)
+	report := &visor.BlockAssemblyReport{
+		Time:     1540000000,
+		Held:     true,
+		Included: []cipher.SHA256{included},
+		Skipped: []visor.SkippedTransaction{
+			{
+				Hash:   skipped,
+				Reason: visor.SkipSenderLimit,
+			},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		method         string
+		status         int
+		gatewayReport  *visor.BlockAssemblyReport
+		httpResponse   HTTPResponse
+		expectedReport *BlockAssemblyReport
+	}{
+		{
+			name:         "405",
+			method:       http.MethodDelete,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "404 - no block assembled",
+			method:       http.MethodGet,
+			status:       http.StatusNotFound,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "no block has been assembled"),
+		},
+
+		{
+			name:          "200",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			gatewayReport: report,
+			httpResponse: HTTPResponse{
+				Data: NewBlockAssemblyReport(*report),
+			},
+			expectedReport: &BlockAssemblyReport{
+				Time:     1540000000,
+				Held:     true,
+				Included: []string{included.Hex()},
+				Skipped: []SkippedTransaction{
+					{
+						Txid:   skipped.Hex(),
+						Reason: "sender transaction limit reached",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/pendingTxs/assembly"
+			gateway := &MockGatewayer{}
+			gateway.On("LastBlockAssembly").Return(tc.gatewayReport)
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var r BlockAssemblyReport
+				err := json.Unmarshal(rsp.Data, &r)
+				require.NoError(t, err)
+
+				require.Equal(t, *tc.expectedReport, r)
+			}
+		})
+	}
+}
+
+func TestVerifyTransactionProof(t *testing.T) {
+	proof, pubkey := makeTransactionProof(t)
+	p := NewTransactionProof(proof)
+
+	require.NoError(t, VerifyTransactionProof(pubkey, proof.Txid, p))
+
+	vp, err := p.ToVisorTransactionProof()
+	require.NoError(t, err)
+	require.Equal(t, proof, *vp)
+
+	// Another signer
+	otherPubkey, _ := cipher.GenerateKeyPair()
+	require.Error(t, VerifyTransactionProof(otherPubkey, proof.Txid, p))
+
+	// Another transaction
+	otherTxid := testutil.RandSHA256(t)
+	err = VerifyTransactionProof(pubkey, otherTxid, p)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "transaction proof is for transaction")
+
+	// Tampered index
+	q := p
+	q.Index = 0
+	require.Equal(t, cipher.ErrMerkleProofInvalid, VerifyTransactionProof(pubkey, proof.Txid, q))
+
+	// Tampered merkle proof
+	q = p
+	q.Hashes = append([]string{}, p.Hashes...)
+	q.Hashes[0] = testutil.RandSHA256(t).Hex()
+	require.Equal(t, cipher.ErrMerkleProofInvalid, VerifyTransactionProof(pubkey, proof.Txid, q))
+
+	// Tampered header, the header hash no longer matches
+	q = p
+	q.Header.Fee++
+	require.Error(t, VerifyTransactionProof(pubkey, proof.Txid, q))
+
+	// Tampered header with a recomputed header hash, the signature no longer matches
+	q = p
+	h := proof.Header
+	h.Fee++
+	q.Header = readable.NewBlockHeader(h)
+	require.Error(t, VerifyTransactionProof(pubkey, proof.Txid, q))
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/visor/historydb"
 )
 
@@ -87,6 +88,18 @@ func TestGetUxOutByID(t *testing.T) {
 			getGetUxOutByIDArg:   testutil.SHA256FromHex(t, validHash),
 			getGetUxOutByIDError: errors.New("getGetUxOutByIDError"),
 		},
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			httpBody: &httpBody{
+				uxid: validHash,
+			},
+			uxid:                 validHash,
+			getGetUxOutByIDArg:   testutil.SHA256FromHex(t, validHash),
+			getGetUxOutByIDError: visor.ErrHistoryDisabled,
+		},
 		{
 			name:   "404 - uxout == nil",
 			method: http.MethodGet,
@@ -217,6 +230,17 @@ func TestGetAddrUxOuts(t *testing.T) {
 			getSpentOutputsForAddressesArg:   []cipher.Address{addressForGwError},
 			getSpentOutputsForAddressesError: errors.New("getSpentOutputsForAddressesError"),
 		},
+		{
+			name:   "503 - history disabled",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - history is disabled",
+			httpBody: &httpBody{
+				address: addressForGwError.String(),
+			},
+			getSpentOutputsForAddressesArg:   []cipher.Address{addressForGwError},
+			getSpentOutputsForAddressesError: visor.ErrHistoryDisabled,
+		},
 		{
 			name:   "200",
 			method: http.MethodGet,
@@ -12,6 +12,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/wallet"
 )
 
@@ -142,8 +143,13 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 
 		bals, err := gateway.GetBalanceOfAddrs(addrs)
 		if err != nil {
-			err = fmt.Errorf("gateway.GetBalanceOfAddrs failed: %v", err)
-			wh.Error500(w, err.Error())
+			switch err {
+			case visor.ErrAddressNotWatched:
+				wh.Error400(w, err.Error())
+			default:
+				err = fmt.Errorf("gateway.GetBalanceOfAddrs failed: %v", err)
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 
@@ -454,6 +460,8 @@ func walletTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
 				wh.Error404(w, "")
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
+			case visor.ErrHistoryDisabled, visor.ErrHistoryRebuilding:
+				wh.Error503(w, err.Error())
 			default:
 				wh.Error500(w, err.Error())
 			}
@@ -477,6 +485,14 @@ func walletTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
 				vb[i] = *v
 			}
 
+			notes, err := getTransactionNotes(gateway)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			setUnconfirmedTransactionNotesVerbose(vb, notes)
+
 			wh.SendJSONOr500(logger, w, UnconfirmedTxnsVerboseResponse{
 				Transactions: vb,
 			})
@@ -494,6 +510,14 @@ func walletTransactionsHandler(gateway Gatewayer) http.HandlerFunc {
 				return
 			}
 
+			notes, err := getTransactionNotes(gateway)
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			setUnconfirmedTransactionNotes(unconfirmedTxns, notes)
+
 			wh.SendJSONOr500(logger, w, UnconfirmedTxnsResponse{
 				Transactions: unconfirmedTxns,
 			})
@@ -18,6 +18,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor"
@@ -65,6 +66,17 @@ func TestGetBalanceHandler(t *testing.T) {
 			err:      "400 Bad Request - addrs is required",
 			httpBody: &httpBody{},
 		},
+		{
+			name:   "400 - address not watched by light client",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - address is not watched by the light client",
+			httpBody: &httpBody{
+				addrs: validAddr,
+			},
+			getBalanceOfAddrsArg:   []cipher.Address{address},
+			getBalanceOfAddrsError: visor.ErrAddressNotWatched,
+		},
 		{
 			name:   "500 - GetBalanceOfAddrsError",
 			method: http.MethodGet,
@@ -646,8 +658,11 @@ func TestWalletTransactionsHandler(t *testing.T) {
 		verbose                                      bool
 		gatewayGetWalletUnconfirmedTxnsResult        []visor.UnconfirmedTransaction
 		gatewayGetWalletUnconfirmedTxnsErr           error
-		gatewayGetWalletUnconfirmedTxnsVerboseResult []readable.UnconfirmedTransactionVerbose
+		gatewayGetWalletUnconfirmedTxnsVerboseResult []visor.UnconfirmedTransaction
+		gatewayGetWalletUnconfirmedTxnsVerboseInputs [][]visor.TransactionInput
 		gatewayGetWalletUnconfirmedTxnsVerboseErr    error
+		getTransactionNotes                          map[string]string
+		getTransactionNotesErr                       error
 		responseBody                                 interface{}
 	}{
 		{
@@ -700,6 +715,20 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			gatewayGetWalletUnconfirmedTxnsVerboseErr: errors.New("gateway.GetWalletUnconfirmedTransactionsVerbose error"),
 		},
 
+		{
+			name:   "503 - gateway.GetWalletUnconfirmedTransactionsVerbose history rebuilding",
+			method: http.MethodGet,
+			body: &httpBody{
+				walletID: "foo",
+				verbose:  "1",
+			},
+			verbose:  true,
+			status:   http.StatusServiceUnavailable,
+			err:      "503 Service Unavailable - history is being rebuilt",
+			walletID: "foo",
+			gatewayGetWalletUnconfirmedTxnsVerboseErr: visor.ErrHistoryRebuilding,
+		},
+
 		{
 			name:   "404 - wallet doesn't exist",
 			method: http.MethodGet,
@@ -760,7 +789,7 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			},
 			status:                                http.StatusOK,
 			walletID:                              "foo",
-			gatewayGetWalletUnconfirmedTxnsResult: make([]visor.UnconfirmedTransaction, 1),
+			gatewayGetWalletUnconfirmedTxnsResult: []visor.UnconfirmedTransaction{*uTxn},
 			responseBody: UnconfirmedTxnsResponse{
 				Transactions: []readable.UnconfirmedTransactions{
 					*unconfirmedTxn,
@@ -778,7 +807,8 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			verbose:  true,
 			status:   http.StatusOK,
 			walletID: "foo",
-			gatewayGetWalletUnconfirmedTxnsVerboseResult: make([]readable.UnconfirmedTransactionVerbose, 1),
+			gatewayGetWalletUnconfirmedTxnsVerboseResult: []visor.UnconfirmedTransaction{*uTxn},
+			gatewayGetWalletUnconfirmedTxnsVerboseInputs: [][]visor.TransactionInput{{visor.TransactionInput{}}},
 			responseBody: UnconfirmedTxnsVerboseResponse{
 				Transactions: []readable.UnconfirmedTransactionVerbose{
 					*unconfirmedTxnVerbose,
@@ -790,7 +820,8 @@ func TestWalletTransactionsHandler(t *testing.T) {
 	for _, tc := range tt {
 		gateway := &MockGatewayer{}
 		gateway.On("GetWalletUnconfirmedTransactions", tc.walletID).Return(tc.gatewayGetWalletUnconfirmedTxnsResult, tc.gatewayGetWalletUnconfirmedTxnsErr)
-		gateway.On("GetWalletUnconfirmedTransactionsVerbose", tc.walletID).Return(tc.gatewayGetWalletUnconfirmedTxnsVerboseResult, tc.gatewayGetWalletUnconfirmedTxnsVerboseErr)
+		gateway.On("GetWalletUnconfirmedTransactionsVerbose", tc.walletID).Return(tc.gatewayGetWalletUnconfirmedTxnsVerboseResult, tc.gatewayGetWalletUnconfirmedTxnsVerboseInputs, tc.gatewayGetWalletUnconfirmedTxnsVerboseErr)
+		gateway.On("GetAllStorageValues", kvstorage.TypeTxIDNotes).Return(tc.getTransactionNotes, tc.getTransactionNotesErr)
 
 		endpoint := "/api/v1/wallet/transactions"
 
@@ -799,6 +830,9 @@ func TestWalletTransactionsHandler(t *testing.T) {
 			if tc.body.walletID != "" {
 				v.Add("id", tc.body.walletID)
 			}
+			if tc.body.verbose != "" {
+				v.Add("verbose", tc.body.verbose)
+			}
 		}
 		if len(v) > 0 {
 			endpoint += "?" + v.Encode()
@@ -823,7 +857,7 @@ func TestWalletTransactionsHandler(t *testing.T) {
 		if status != http.StatusOK {
 			require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 				strings.TrimSpace(rr.Body.String()), status, tc.err)
-			return
+			continue
 		}
 
 		if tc.verbose {
@@ -0,0 +1,618 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+const (
+	// WebSocketTopicBlocks streams new blocks
+	WebSocketTopicBlocks = "blocks"
+	// WebSocketTopicTransactions streams new unconfirmed transactions
+	WebSocketTopicTransactions = "transactions"
+	// WebSocketTopicAddresses streams transactions that involve the subscribed addresses and wallets
+	WebSocketTopicAddresses = "addresses"
+
+	// WebSocketOpSubscribe replaces the subscription of a websocket connection
+	WebSocketOpSubscribe = "subscribe"
+	// WebSocketOpUnsubscribe clears the subscription of a websocket connection
+	WebSocketOpUnsubscribe = "unsubscribe"
+
+	// WebSocketMessageSubscribed is sent after a subscription is applied and any replay is complete
+	WebSocketMessageSubscribed = "subscribed"
+	// WebSocketMessageBlock is sent for a block
+	WebSocketMessageBlock = "block"
+	// WebSocketMessageRollback is sent for a block that was removed from the main chain by a reorganization.
+	// The blocks that replace it are sent after it
+	WebSocketMessageRollback = "rollback"
+	// WebSocketMessageTransaction is sent for a new unconfirmed transaction
+	WebSocketMessageTransaction = "transaction"
+	// WebSocketMessageAddress is sent for a transaction that involves a subscribed address
+	WebSocketMessageAddress = "address"
+	// WebSocketMessageError is sent when a request fails, or when an event can't be sent
+	WebSocketMessageError = "error"
+
+	// maxWebSocketAddresses is the maximum number of addresses a websocket connection can subscribe to,
+	// including the addresses of subscribed wallets
+	maxWebSocketAddresses = 1000
+	// maxWebSocketReplayBlocks is the maximum number of blocks replayed for since_seq
+	maxWebSocketReplayBlocks = 1000
+	// webSocketReplayBatchSize is the number of blocks loaded at a time when replaying
+	webSocketReplayBatchSize = 100
+	// webSocketEventBufferSize is the number of events buffered for a websocket connection.
+	// If the buffer fills up, the connection is closed and the client must reconnect with since_seq.
+	webSocketEventBufferSize = 1024
+	// webSocketMaxRequestSize is the maximum size of a request sent by a client
+	webSocketMaxRequestSize = 64 * 1024
+
+	webSocketWriteWait  = 10 * time.Second
+	webSocketPongWait   = 60 * time.Second
+	webSocketPingPeriod = webSocketPongWait * 9 / 10
+)
+
+// errWebSocketClosed is returned after a close message was sent to the client
+var errWebSocketClosed = errors.New("websocket connection closed")
+
+// WebSocketRequest is a request sent by a websocket client
+type WebSocketRequest struct {
+	Op        string   `json:"op"`
+	Topics    []string `json:"topics,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Wallets   []string `json:"wallets,omitempty"`
+	SinceSeq  *uint64  `json:"since_seq,omitempty"`
+}
+
+// WebSocketSubscription describes the subscription of a websocket connection
+type WebSocketSubscription struct {
+	Topics    []string `json:"topics"`
+	Addresses int      `json:"addresses"`
+	HeadSeq   uint64   `json:"head_seq"`
+	Replayed  uint64   `json:"replayed"`
+}
+
+// AddressActivity is a transaction that spends from or sends to a subscribed address
+type AddressActivity struct {
+	Address   string `json:"address"`
+	Txid      string `json:"txid"`
+	Confirmed bool   `json:"confirmed"`
+	BlockSeq  uint64 `json:"block_seq"`
+}
+
+// BlockRollback is a block that was removed from the main chain by a reorganization
+type BlockRollback struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// WebSocketMessage is a message sent to a websocket client. Only the field for the message's type is set.
+type WebSocketMessage struct {
+	Type         string                       `json:"type"`
+	Error        string                       `json:"error,omitempty"`
+	Subscription *WebSocketSubscription       `json:"subscription,omitempty"`
+	Block        *readable.BlockVerbose       `json:"block,omitempty"`
+	Rollback     *BlockRollback               `json:"rollback,omitempty"`
+	Transaction  *readable.TransactionVerbose `json:"transaction,omitempty"`
+	Activity     *AddressActivity             `json:"activity,omitempty"`
+}
+
+// websocketHandler upgrades the connection to a websocket that streams new blocks, new unconfirmed transactions
+// and the activity of addresses and wallets.
+// The client sends WebSocketRequests to choose what is streamed. A subscribe request replaces the previous subscription.
+// If since_seq is set in a subscribe request, the blocks after since_seq are replayed before the live events,
+// so that a client that reconnects does not miss any blocks.
+// When the blockchain is reorganized, a rollback message is sent for each block the client received that was
+// removed from the main chain, newest first, followed by the blocks of the new main chain.
+// If the transaction history is disabled or being rebuilt, blocks and transactions are not sent,
+// and an error message is sent for each of them instead.
+// If the client does not read events quickly enough, the connection is closed with code 1013 (try again later).
+// Method: GET
+// URI: /api/v2/ws
+func websocketHandler(gateway Gatewayer, bus *eventbus.Bus, walletAPIEnabled bool) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		// The origin is checked by the originRefererCheck middleware, the same as for the other endpoints
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if bus == nil {
+			resp := NewHTTPErrorResponse(http.StatusServiceUnavailable, "Event streaming is not available")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already written an error response
+			logger.WithError(err).Error("Websocket upgrade failed")
+			return
+		}
+
+		// Subscribe before handling any requests, so that no blocks are missed between a replay and the live events
+		sub := bus.Subscribe(webSocketEventBufferSize, eventbus.TopicBlockExecuted, eventbus.TopicBlockRolledBack, eventbus.TopicTransactionAdded)
+		defer sub.Unsubscribe()
+
+		c := &webSocketClient{
+			conn:             conn,
+			gateway:          gateway,
+			walletAPIEnabled: walletAPIEnabled,
+		}
+		c.run(sub)
+	}
+}
+
+// webSocketClient is a websocket connection. Its methods are not safe for concurrent use,
+// except for readRequests which is the only reader of the connection.
+type webSocketClient struct {
+	conn             *websocket.Conn
+	gateway          Gatewayer
+	walletAPIEnabled bool
+
+	topics    map[string]struct{}
+	addresses map[string]struct{}
+	// lastSeq is the seq of the last block that was replayed or streamed
+	lastSeq uint64
+	// lastHash is the hash of the block at lastSeq
+	lastHash cipher.SHA256
+}
+
+func (c *webSocketClient) run(sub *eventbus.Subscription) {
+	defer c.conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	requests := make(chan []byte)
+	readErrC := make(chan error, 1)
+	go c.readRequests(requests, readErrC, done)
+
+	ticker := time.NewTicker(webSocketPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data := <-requests:
+			if err := c.handleRequest(data); err != nil {
+				c.closeWithError(err)
+				return
+			}
+
+		case err := <-readErrC:
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.WithError(err).Debug("Websocket read failed")
+			}
+			return
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				c.close(websocket.CloseGoingAway, "Node is shutting down")
+				return
+			}
+
+			if sub.Dropped() != 0 {
+				c.close(websocket.CloseTryAgainLater, "Events were dropped, reconnect and subscribe with since_seq")
+				return
+			}
+
+			if err := c.handleEvent(e); err != nil {
+				if err != errWebSocketClosed {
+					c.closeWithError(err)
+				}
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteWait)); err != nil {
+				logger.WithError(err).Debug("Websocket ping failed")
+				return
+			}
+		}
+	}
+}
+
+// readRequests reads requests from the connection until the connection fails or done is closed
+func (c *webSocketClient) readRequests(requests chan<- []byte, errC chan<- error, done <-chan struct{}) {
+	c.conn.SetReadLimit(webSocketMaxRequestSize)
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	})
+
+	// Replaces the read deadline of the HTTP server
+	if err := c.conn.SetReadDeadline(time.Now().Add(webSocketPongWait)); err != nil {
+		errC <- err
+		return
+	}
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			errC <- err
+			return
+		}
+
+		select {
+		case requests <- data:
+		case <-done:
+			return
+		}
+	}
+}
+
+// handleRequest handles a request from the client. Invalid requests are answered with an error message.
+// An error is returned only if the connection can't be used anymore.
+func (c *webSocketClient) handleRequest(data []byte) error {
+	var req WebSocketRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return c.sendError(fmt.Sprintf("Invalid request: %v", err))
+	}
+
+	switch req.Op {
+	case WebSocketOpSubscribe:
+		return c.subscribe(req)
+	case WebSocketOpUnsubscribe:
+		c.topics = nil
+		c.addresses = nil
+		return c.send(WebSocketMessage{
+			Type: WebSocketMessageSubscribed,
+			Subscription: &WebSocketSubscription{
+				Topics:  []string{},
+				HeadSeq: c.lastSeq,
+			},
+		})
+	default:
+		return c.sendError(fmt.Sprintf("Invalid op %q", req.Op))
+	}
+}
+
+func (c *webSocketClient) subscribe(req WebSocketRequest) error {
+	topics := make(map[string]struct{}, len(req.Topics))
+	for _, t := range req.Topics {
+		switch t {
+		case WebSocketTopicBlocks, WebSocketTopicTransactions, WebSocketTopicAddresses:
+			topics[t] = struct{}{}
+		default:
+			return c.sendError(fmt.Sprintf("Invalid topic %q", t))
+		}
+	}
+
+	if len(topics) == 0 {
+		return c.sendError("topics is required")
+	}
+
+	_, wantsAddresses := topics[WebSocketTopicAddresses]
+	if wantsAddresses != (len(req.Addresses) != 0 || len(req.Wallets) != 0) {
+		return c.sendError("The addresses topic requires addresses or wallets, and addresses or wallets require the addresses topic")
+	}
+
+	addresses := make(map[string]struct{}, len(req.Addresses))
+	for _, a := range req.Addresses {
+		addr, err := cipher.DecodeBase58Address(a)
+		if err != nil {
+			return c.sendError(fmt.Sprintf("Invalid address %q: %v", a, err))
+		}
+		addresses[addr.String()] = struct{}{}
+	}
+
+	for _, id := range req.Wallets {
+		if !c.walletAPIEnabled {
+			return c.sendError("Subscribing to wallets requires the WALLET API set")
+		}
+
+		w, err := c.gateway.GetWallet(id)
+		if err != nil {
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				return c.sendError("Subscribing to wallets requires the WALLET API set")
+			default:
+				return c.sendError(fmt.Sprintf("Wallet %q: %v", id, err))
+			}
+		}
+
+		for _, a := range w.GetAddresses() {
+			addresses[a.String()] = struct{}{}
+		}
+	}
+
+	if len(addresses) > maxWebSocketAddresses {
+		return c.sendError(fmt.Sprintf("Too many addresses, at most %d addresses can be subscribed", maxWebSocketAddresses))
+	}
+
+	headSeq, _, err := c.gateway.HeadBkSeq()
+	if err != nil {
+		return err
+	}
+
+	if req.SinceSeq != nil {
+		if *req.SinceSeq > headSeq {
+			return c.sendError(fmt.Sprintf("since_seq %d is greater than the head block seq %d", *req.SinceSeq, headSeq))
+		}
+		if headSeq-*req.SinceSeq > maxWebSocketReplayBlocks {
+			return c.sendError(fmt.Sprintf("Too many blocks to replay, since_seq must be at most %d blocks behind the head block seq %d", maxWebSocketReplayBlocks, headSeq))
+		}
+	}
+
+	head, err := c.gateway.GetSignedBlockBySeq(headSeq)
+	if err != nil {
+		return err
+	}
+
+	prev := *c
+	c.topics = topics
+	c.addresses = addresses
+	c.lastSeq = headSeq
+	c.lastHash = cipher.SHA256{}
+	if head != nil {
+		c.lastHash = head.HashHeader()
+	}
+
+	var replayed uint64
+	if req.SinceSeq != nil {
+		for start := *req.SinceSeq + 1; start <= headSeq; start += webSocketReplayBatchSize {
+			end := start + webSocketReplayBatchSize - 1
+			if end > headSeq {
+				end = headSeq
+			}
+
+			blocks, inputs, err := c.gateway.GetBlocksInRangeVerbose(start, end)
+			if err != nil {
+				if isHistoryUnavailable(err) {
+					// Nothing was replayed, because the history does not become unavailable while the node runs
+					*c = prev
+					return c.sendError(fmt.Sprintf("since_seq can't be replayed: %v", err))
+				}
+				return err
+			}
+
+			for i, b := range blocks {
+				if err := c.sendBlock(b, inputs[i]); err != nil {
+					return err
+				}
+				replayed++
+			}
+		}
+	}
+
+	subscribedTopics := make([]string, 0, len(topics))
+	for _, t := range []string{WebSocketTopicBlocks, WebSocketTopicTransactions, WebSocketTopicAddresses} {
+		if _, ok := topics[t]; ok {
+			subscribedTopics = append(subscribedTopics, t)
+		}
+	}
+
+	return c.send(WebSocketMessage{
+		Type: WebSocketMessageSubscribed,
+		Subscription: &WebSocketSubscription{
+			Topics:    subscribedTopics,
+			Addresses: len(addresses),
+			HeadSeq:   headSeq,
+			Replayed:  replayed,
+		},
+	})
+}
+
+// handleEvent sends the messages for an event from the event bus
+func (c *webSocketClient) handleEvent(e eventbus.Event) error {
+	switch e := e.(type) {
+	case eventbus.BlockExecuted:
+		seq := e.Block.Head.BkSeq
+		if len(c.topics) == 0 || seq <= c.lastSeq {
+			// Not subscribed, or the block was already sent by a replay.
+			// A block that replaces a sent block follows the rollback of the sent block
+			return nil
+		}
+
+		if seq == c.lastSeq+1 && c.lastHash != (cipher.SHA256{}) && e.Block.Head.PrevHash != c.lastHash {
+			// The blocks sent to the client were replaced without a rollback that could be sent,
+			// for example by a reorganization during a replay
+			c.close(websocket.CloseTryAgainLater, "The blockchain was reorganized, reconnect and subscribe with since_seq")
+			return errWebSocketClosed
+		}
+
+		c.lastSeq = seq
+		c.lastHash = e.Block.HashHeader()
+
+		if !c.wants(WebSocketTopicBlocks) && !c.wants(WebSocketTopicAddresses) {
+			return nil
+		}
+
+		b, inputs, err := c.gateway.GetSignedBlockBySeqVerbose(seq)
+		if err != nil {
+			if isHistoryUnavailable(err) {
+				return c.sendError(fmt.Sprintf("Block %d can't be sent: %v", seq, err))
+			}
+			return err
+		}
+		if b == nil {
+			return fmt.Errorf("Block %d not found", seq)
+		}
+
+		return c.sendBlock(*b, inputs)
+
+	case eventbus.BlockRolledBack:
+		hash := e.Block.HashHeader()
+		if len(c.topics) == 0 || e.Block.Head.BkSeq != c.lastSeq || hash != c.lastHash {
+			// Not subscribed, or the block is not the last block sent to the client
+			return nil
+		}
+		c.lastSeq = e.Block.Head.BkSeq - 1
+		c.lastHash = e.Block.Head.PrevHash
+
+		if !c.wants(WebSocketTopicBlocks) && !c.wants(WebSocketTopicAddresses) {
+			return nil
+		}
+
+		return c.send(WebSocketMessage{
+			Type: WebSocketMessageRollback,
+			Rollback: &BlockRollback{
+				Seq:  e.Block.Head.BkSeq,
+				Hash: hash.Hex(),
+			},
+		})
+
+	case eventbus.TransactionAdded:
+		if !c.wants(WebSocketTopicTransactions) && !c.wants(WebSocketTopicAddresses) {
+			return nil
+		}
+
+		txn, inputs, err := c.gateway.GetTransactionWithInputs(e.Hash)
+		if err != nil {
+			if isHistoryUnavailable(err) {
+				return c.sendError(fmt.Sprintf("Transaction %s can't be sent: %v", e.Hash.Hex(), err))
+			}
+			return err
+		}
+		if txn == nil || txn.Status.Confirmed {
+			// The transaction has already left the pool. If it was confirmed, it is sent with its block.
+			return nil
+		}
+
+		return c.sendTransaction(*txn, inputs)
+
+	default:
+		return nil
+	}
+}
+
+func (c *webSocketClient) sendBlock(b coin.SignedBlock, inputs [][]visor.TransactionInput) error {
+	rb, err := readable.NewBlockVerbose(b.Block, inputs)
+	if err != nil {
+		return err
+	}
+
+	if c.wants(WebSocketTopicBlocks) {
+		if err := c.send(WebSocketMessage{
+			Type:  WebSocketMessageBlock,
+			Block: rb,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, txn := range rb.Body.Transactions {
+		if err := c.sendAddressActivity(txn, true, b.Head.BkSeq); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *webSocketClient) sendTransaction(txn visor.Transaction, inputs []visor.TransactionInput) error {
+	rt, err := readable.NewTransactionVerbose(txn, inputs)
+	if err != nil {
+		return err
+	}
+
+	if c.wants(WebSocketTopicTransactions) {
+		if err := c.send(WebSocketMessage{
+			Type:        WebSocketMessageTransaction,
+			Transaction: &rt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return c.sendAddressActivity(rt.BlockTransactionVerbose, false, 0)
+}
+
+// sendAddressActivity sends an address message for each subscribed address that the transaction spends from or sends to
+func (c *webSocketClient) sendAddressActivity(txn readable.BlockTransactionVerbose, confirmed bool, seq uint64) error {
+	if !c.wants(WebSocketTopicAddresses) {
+		return nil
+	}
+
+	addrs := make([]string, 0, len(txn.In)+len(txn.Out))
+	for _, in := range txn.In {
+		addrs = append(addrs, in.Address)
+	}
+	for _, o := range txn.Out {
+		addrs = append(addrs, o.Address)
+	}
+
+	sent := make(map[string]struct{})
+	for _, a := range addrs {
+		if _, ok := c.addresses[a]; !ok {
+			continue
+		}
+		if _, ok := sent[a]; ok {
+			continue
+		}
+		sent[a] = struct{}{}
+
+		if err := c.send(WebSocketMessage{
+			Type: WebSocketMessageAddress,
+			Activity: &AddressActivity{
+				Address:   a,
+				Txid:      txn.Hash,
+				Confirmed: confirmed,
+				BlockSeq:  seq,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isHistoryUnavailable returns true if the error is caused by the transaction history being disabled or rebuilt
+func isHistoryUnavailable(err error) bool {
+	return err == visor.ErrHistoryDisabled || err == visor.ErrHistoryRebuilding
+}
+
+func (c *webSocketClient) wants(topic string) bool {
+	_, ok := c.topics[topic]
+	return ok
+}
+
+func (c *webSocketClient) send(msg WebSocketMessage) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(webSocketWriteWait)); err != nil {
+		return err
+	}
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *webSocketClient) sendError(msg string) error {
+	return c.send(WebSocketMessage{
+		Type:  WebSocketMessageError,
+		Error: msg,
+	})
+}
+
+// closeWithError closes the connection after an error that the connection can't recover from
+func (c *webSocketClient) closeWithError(err error) {
+	logger.WithError(err).Warning("Closing websocket connection")
+	c.close(websocket.CloseInternalServerErr, err.Error())
+}
+
+// close sends a close message to the client. The connection itself is closed by run.
+func (c *webSocketClient) close(code int, text string) {
+	// Control frames are limited to 125 bytes, including the 2 byte close code
+	if len(text) > 123 {
+		text = text[:123]
+	}
+
+	msg := websocket.FormatCloseMessage(code, text)
+	if err := c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(webSocketWriteWait)); err != nil && err != websocket.ErrCloseSent {
+		logger.WithError(err).Debug("Websocket close failed")
+	}
+}
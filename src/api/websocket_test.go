@@ -0,0 +1,478 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+func makeWebSocketTestTxn(t *testing.T, from, to cipher.Address) (coin.Transaction, []visor.TransactionInput) {
+	ux := coin.UxOut{
+		Body: coin.UxBody{
+			SrcTransaction: testutil.RandSHA256(t),
+			Address:        from,
+			Coins:          2e6,
+			Hours:          10,
+		},
+	}
+
+	txn := coin.Transaction{
+		In: []cipher.SHA256{ux.Hash()},
+		Out: []coin.TransactionOutput{
+			{
+				Address: to,
+				Coins:   2e6,
+				Hours:   5,
+			},
+		},
+	}
+
+	return txn, []visor.TransactionInput{
+		{
+			UxOut:           ux,
+			CalculatedHours: 10,
+		},
+	}
+}
+
+func makeWebSocketTestBlock(t *testing.T, seq uint64, prevHash cipher.SHA256, from, to cipher.Address) (coin.SignedBlock, [][]visor.TransactionInput) {
+	txn, inputs := makeWebSocketTestTxn(t, from, to)
+	return coin.SignedBlock{
+		Block: coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq:    seq,
+				PrevHash: prevHash,
+			},
+			Body: coin.BlockBody{
+				Transactions: coin.Transactions{txn},
+			},
+		},
+	}, [][]visor.TransactionInput{inputs}
+}
+
+func dialWebSocketTestServer(t *testing.T, cfg muxConfig, gateway Gatewayer) (*websocket.Conn, func()) {
+	cfg.disableHeaderCheck = true
+	srv := httptest.NewServer(newServerMux(cfg, gateway))
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v2/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+
+	return conn, func() {
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func readWebSocketMessage(t *testing.T, conn *websocket.Conn) WebSocketMessage {
+	err := conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	require.NoError(t, err)
+
+	var msg WebSocketMessage
+	err = conn.ReadJSON(&msg)
+	require.NoError(t, err)
+	return msg
+}
+
+func TestWebSocketUnavailable(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/v2/ws", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := newServerMux(defaultMuxConfig(), &MockGatewayer{})
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "{\n    \"error\": {\n        \"message\": \"Event streaming is not available\",\n        \"code\": 503\n    }\n}", rr.Body.String())
+}
+
+func TestWebSocketInvalidRequests(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+
+	gateway := &MockGatewayer{}
+	gateway.On("HeadBkSeq").Return(uint64(2000), true, nil)
+
+	cfg := defaultMuxConfig()
+	cfg.eventBus = bus
+	cfg.enabledAPISets = map[string]struct{}{
+		EndpointsRead: struct{}{},
+	}
+
+	conn, cleanup := dialWebSocketTestServer(t, cfg, gateway)
+	defer cleanup()
+
+	since := uint64(10)
+	tt := []struct {
+		name string
+		req  interface{}
+		err  string
+	}{
+		{
+			name: "invalid json",
+			req:  "foo",
+			err:  "Invalid request: json: cannot unmarshal string into Go value of type api.WebSocketRequest",
+		},
+		{
+			name: "invalid op",
+			req:  WebSocketRequest{Op: "foo"},
+			err:  `Invalid op "foo"`,
+		},
+		{
+			name: "no topics",
+			req:  WebSocketRequest{Op: WebSocketOpSubscribe},
+			err:  "topics is required",
+		},
+		{
+			name: "invalid topic",
+			req:  WebSocketRequest{Op: WebSocketOpSubscribe, Topics: []string{"foo"}},
+			err:  `Invalid topic "foo"`,
+		},
+		{
+			name: "addresses topic without addresses",
+			req:  WebSocketRequest{Op: WebSocketOpSubscribe, Topics: []string{WebSocketTopicAddresses}},
+			err:  "The addresses topic requires addresses or wallets, and addresses or wallets require the addresses topic",
+		},
+		{
+			name: "invalid address",
+			req: WebSocketRequest{
+				Op:        WebSocketOpSubscribe,
+				Topics:    []string{WebSocketTopicAddresses},
+				Addresses: []string{"foo"},
+			},
+			err: `Invalid address "foo": Invalid address length`,
+		},
+		{
+			name: "wallet api disabled",
+			req: WebSocketRequest{
+				Op:      WebSocketOpSubscribe,
+				Topics:  []string{WebSocketTopicAddresses},
+				Wallets: []string{"foo.wlt"},
+			},
+			err: "Subscribing to wallets requires the WALLET API set",
+		},
+		{
+			name: "too many blocks to replay",
+			req: WebSocketRequest{
+				Op:       WebSocketOpSubscribe,
+				Topics:   []string{WebSocketTopicBlocks},
+				SinceSeq: &since,
+			},
+			err: "Too many blocks to replay, since_seq must be at most 1000 blocks behind the head block seq 2000",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := conn.WriteJSON(tc.req)
+			require.NoError(t, err)
+
+			msg := readWebSocketMessage(t, conn)
+			require.Equal(t, WebSocketMessage{
+				Type:  WebSocketMessageError,
+				Error: tc.err,
+			}, msg)
+		})
+	}
+}
+
+func TestWebSocketStream(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+
+	addr := testutil.MakeAddress()
+	wltAddr := testutil.MakeAddress()
+	otherAddr := testutil.MakeAddress()
+
+	b4, inputs4 := makeWebSocketTestBlock(t, 4, testutil.RandSHA256(t), addr, otherAddr)
+	b5, inputs5 := makeWebSocketTestBlock(t, 5, b4.HashHeader(), otherAddr, otherAddr)
+	b6, inputs6 := makeWebSocketTestBlock(t, 6, b5.HashHeader(), otherAddr, wltAddr)
+	txn, txnInputs := makeWebSocketTestTxn(t, wltAddr, addr)
+
+	gateway := &MockGatewayer{}
+	gateway.On("HeadBkSeq").Return(uint64(5), true, nil)
+	gateway.On("GetSignedBlockBySeq", uint64(5)).Return(&b5, nil)
+	gateway.On("GetWallet", "foo.wlt").Return(&wallet.Wallet{
+		Entries: []wallet.Entry{
+			{
+				Address: wltAddr,
+			},
+		},
+	}, nil)
+	gateway.On("GetBlocksInRangeVerbose", uint64(4), uint64(5)).Return([]coin.SignedBlock{b4, b5}, [][][]visor.TransactionInput{inputs4, inputs5}, nil)
+	gateway.On("GetSignedBlockBySeqVerbose", uint64(6)).Return(&b6, inputs6, nil)
+	gateway.On("GetTransactionWithInputs", txn.Hash()).Return(&visor.Transaction{
+		Transaction: txn,
+	}, txnInputs, nil)
+
+	cfg := defaultMuxConfig()
+	cfg.eventBus = bus
+
+	conn, cleanup := dialWebSocketTestServer(t, cfg, gateway)
+	defer cleanup()
+
+	// Subscribe with a replay of the blocks after block 3
+	since := uint64(3)
+	err := conn.WriteJSON(WebSocketRequest{
+		Op:        WebSocketOpSubscribe,
+		Topics:    []string{WebSocketTopicAddresses, WebSocketTopicBlocks},
+		Addresses: []string{addr.String()},
+		Wallets:   []string{"foo.wlt"},
+		SinceSeq:  &since,
+	})
+	require.NoError(t, err)
+
+	msg := readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageBlock, msg.Type)
+	require.Equal(t, uint64(4), msg.Block.Head.BkSeq)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageAddress,
+		Activity: &AddressActivity{
+			Address:   addr.String(),
+			Txid:      b4.Body.Transactions[0].Hash().Hex(),
+			Confirmed: true,
+			BlockSeq:  4,
+		},
+	}, msg)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageBlock, msg.Type)
+	require.Equal(t, uint64(5), msg.Block.Head.BkSeq)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageSubscribed,
+		Subscription: &WebSocketSubscription{
+			Topics:    []string{WebSocketTopicBlocks, WebSocketTopicAddresses},
+			Addresses: 2,
+			HeadSeq:   5,
+			Replayed:  2,
+		},
+	}, msg)
+
+	// A block that was already replayed is not sent again
+	bus.Publish(eventbus.BlockExecuted{Block: b5})
+	bus.Publish(eventbus.BlockExecuted{Block: b6})
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageBlock, msg.Type)
+	require.Equal(t, uint64(6), msg.Block.Head.BkSeq)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageAddress,
+		Activity: &AddressActivity{
+			Address:   wltAddr.String(),
+			Txid:      b6.Body.Transactions[0].Hash().Hex(),
+			Confirmed: true,
+			BlockSeq:  6,
+		},
+	}, msg)
+
+	// An unconfirmed transaction is only sent as address activity, because the transactions topic is not subscribed
+	bus.Publish(eventbus.TransactionAdded{Transaction: txn, Hash: txn.Hash()})
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageAddress,
+		Activity: &AddressActivity{
+			Address: wltAddr.String(),
+			Txid:    txn.Hash().Hex(),
+		},
+	}, msg)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageAddress,
+		Activity: &AddressActivity{
+			Address: addr.String(),
+			Txid:    txn.Hash().Hex(),
+		},
+	}, msg)
+
+	// Resubscribing replaces the subscription
+	err = conn.WriteJSON(WebSocketRequest{
+		Op:     WebSocketOpSubscribe,
+		Topics: []string{WebSocketTopicTransactions},
+	})
+	require.NoError(t, err)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageSubscribed,
+		Subscription: &WebSocketSubscription{
+			Topics:  []string{WebSocketTopicTransactions},
+			HeadSeq: 5,
+		},
+	}, msg)
+
+	bus.Publish(eventbus.TransactionAdded{Transaction: txn, Hash: txn.Hash()})
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageTransaction, msg.Type)
+	require.Equal(t, txn.Hash().Hex(), msg.Transaction.Hash)
+
+	// Closing the event bus closes the connection
+	bus.Close()
+
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
+
+func TestWebSocketReorganization(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+
+	addr := testutil.MakeAddress()
+
+	b4, _ := makeWebSocketTestBlock(t, 4, testutil.RandSHA256(t), addr, addr)
+	b5, _ := makeWebSocketTestBlock(t, 5, b4.HashHeader(), addr, addr)
+	forkB5, forkInputs5 := makeWebSocketTestBlock(t, 5, b4.HashHeader(), addr, addr)
+	forkB6, forkInputs6 := makeWebSocketTestBlock(t, 6, forkB5.HashHeader(), addr, addr)
+	otherB7, _ := makeWebSocketTestBlock(t, 7, testutil.RandSHA256(t), addr, addr)
+
+	gateway := &MockGatewayer{}
+	gateway.On("HeadBkSeq").Return(uint64(5), true, nil)
+	gateway.On("GetSignedBlockBySeq", uint64(5)).Return(&b5, nil)
+	gateway.On("GetSignedBlockBySeqVerbose", uint64(5)).Return(&forkB5, forkInputs5, nil)
+	gateway.On("GetSignedBlockBySeqVerbose", uint64(6)).Return(&forkB6, forkInputs6, nil)
+
+	cfg := defaultMuxConfig()
+	cfg.eventBus = bus
+
+	conn, cleanup := dialWebSocketTestServer(t, cfg, gateway)
+	defer cleanup()
+
+	err := conn.WriteJSON(WebSocketRequest{
+		Op:     WebSocketOpSubscribe,
+		Topics: []string{WebSocketTopicBlocks},
+	})
+	require.NoError(t, err)
+
+	msg := readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageSubscribed, msg.Type)
+
+	// A rolled back block that the client did not receive is not sent
+	bus.Publish(eventbus.BlockRolledBack{Block: forkB5})
+
+	// The rolled back block is sent as a rollback, followed by the blocks that replace it
+	bus.Publish(eventbus.BlockRolledBack{Block: b5})
+	bus.Publish(eventbus.BlockExecuted{Block: forkB5})
+	bus.Publish(eventbus.BlockExecuted{Block: forkB6})
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageRollback,
+		Rollback: &BlockRollback{
+			Seq:  5,
+			Hash: b5.HashHeader().Hex(),
+		},
+	}, msg)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageBlock, msg.Type)
+	require.Equal(t, forkB5.HashHeader().Hex(), msg.Block.Head.Hash)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageBlock, msg.Type)
+	require.Equal(t, forkB6.HashHeader().Hex(), msg.Block.Head.Hash)
+
+	// A block that does not follow the last sent block closes the connection
+	bus.Publish(eventbus.BlockExecuted{Block: otherB7})
+
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater))
+}
+
+func TestWebSocketHistoryUnavailable(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+
+	addr := testutil.MakeAddress()
+
+	b5, _ := makeWebSocketTestBlock(t, 5, testutil.RandSHA256(t), addr, addr)
+	b6, _ := makeWebSocketTestBlock(t, 6, b5.HashHeader(), addr, addr)
+	txn, _ := makeWebSocketTestTxn(t, addr, addr)
+
+	gateway := &MockGatewayer{}
+	gateway.On("HeadBkSeq").Return(uint64(5), true, nil)
+	gateway.On("GetSignedBlockBySeq", uint64(5)).Return(&b5, nil)
+	gateway.On("GetBlocksInRangeVerbose", uint64(5), uint64(5)).Return(nil, nil, visor.ErrHistoryDisabled)
+	gateway.On("GetSignedBlockBySeqVerbose", uint64(6)).Return(nil, nil, visor.ErrHistoryRebuilding)
+	gateway.On("GetTransactionWithInputs", txn.Hash()).Return(nil, nil, visor.ErrHistoryRebuilding)
+
+	cfg := defaultMuxConfig()
+	cfg.eventBus = bus
+
+	conn, cleanup := dialWebSocketTestServer(t, cfg, gateway)
+	defer cleanup()
+
+	// A replay is rejected
+	since := uint64(4)
+	err := conn.WriteJSON(WebSocketRequest{
+		Op:       WebSocketOpSubscribe,
+		Topics:   []string{WebSocketTopicBlocks},
+		SinceSeq: &since,
+	})
+	require.NoError(t, err)
+
+	msg := readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type:  WebSocketMessageError,
+		Error: "since_seq can't be replayed: history is disabled",
+	}, msg)
+
+	err = conn.WriteJSON(WebSocketRequest{
+		Op:     WebSocketOpSubscribe,
+		Topics: []string{WebSocketTopicBlocks, WebSocketTopicTransactions},
+	})
+	require.NoError(t, err)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessageSubscribed, msg.Type)
+
+	// Blocks and transactions that can't be sent are reported without closing the connection
+	bus.Publish(eventbus.BlockExecuted{Block: b6})
+	bus.Publish(eventbus.TransactionAdded{Transaction: txn, Hash: txn.Hash()})
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type:  WebSocketMessageError,
+		Error: "Block 6 can't be sent: history is being rebuilt",
+	}, msg)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type:  WebSocketMessageError,
+		Error: fmt.Sprintf("Transaction %s can't be sent: history is being rebuilt", txn.Hash().Hex()),
+	}, msg)
+
+	err = conn.WriteJSON(WebSocketRequest{
+		Op: WebSocketOpUnsubscribe,
+	})
+	require.NoError(t, err)
+
+	msg = readWebSocketMessage(t, conn)
+	require.Equal(t, WebSocketMessage{
+		Type: WebSocketMessageSubscribed,
+		Subscription: &WebSocketSubscription{
+			Topics:  []string{},
+			HeadSeq: 6,
+		},
+	}, msg)
+}
@@ -24,6 +24,10 @@ var (
 	ErrInvalidHexLength = errors.New("Invalid hex length")
 	// ErrInvalidBytesLength     Invalid bytes length
 	ErrInvalidBytesLength = errors.New("Invalid bytes length")
+	// ErrMerkleIndexOutOfRange  Merkle index is out of range
+	ErrMerkleIndexOutOfRange = errors.New("Merkle index out of range")
+	// ErrMerkleProofInvalid     Merkle proof does not lead to the merkle root
+	ErrMerkleProofInvalid = errors.New("Merkle proof does not match merkle root")
 )
 
 // Ripemd160 ripemd160
@@ -206,3 +210,61 @@ func Merkle(h0 []SHA256) SHA256 {
 	}
 	return h1[0]
 }
+
+// MerkleProof returns the hashes needed to recompute the merkle root of h0 from h0[i].
+// The proof lists the sibling of each node on the path from h0[i] to the root, leaf level first.
+// The padding applied by Merkle is taken into account.
+func MerkleProof(h0 []SHA256, i uint64) ([]SHA256, error) {
+	lh := uint64(len(h0))
+	if i >= lh {
+		return nil, ErrMerkleIndexOutOfRange
+	}
+
+	np := nextPowerOfTwo(lh)
+	h1 := make([]SHA256, np)
+	copy(h1, h0)
+
+	var proof []SHA256
+	for len(h1) != 1 {
+		proof = append(proof, h1[i^1])
+		h2 := make([]SHA256, len(h1)/2)
+		for j := 0; j < len(h2); j++ {
+			h2[j] = AddSHA256(h1[2*j], h1[2*j+1])
+		}
+		h1 = h2
+		i /= 2
+	}
+	return proof, nil
+}
+
+// MerkleProofRoot computes the merkle root from the i-th hash of the tree and its MerkleProof
+func MerkleProofRoot(h SHA256, i uint64, proof []SHA256) (SHA256, error) {
+	// The index must fit in the tree height given by the proof, otherwise
+	// the same proof would be accepted for several indexes
+	if len(proof) < 64 && i>>uint(len(proof)) != 0 {
+		return SHA256{}, ErrMerkleIndexOutOfRange
+	}
+
+	for _, p := range proof {
+		if i%2 == 0 {
+			h = AddSHA256(h, p)
+		} else {
+			h = AddSHA256(p, h)
+		}
+		i /= 2
+	}
+	return h, nil
+}
+
+// VerifyMerkleProof checks that h is the i-th hash of the merkle tree with the given root
+func VerifyMerkleProof(root, h SHA256, i uint64, proof []SHA256) error {
+	r, err := MerkleProofRoot(h, i, proof)
+	if err != nil {
+		return err
+	}
+
+	if r != root {
+		return ErrMerkleProofInvalid
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"math/bits"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -371,3 +372,37 @@ func TestMerkle(t *testing.T) {
 		AddSHA256(SHA256{}, SHA256{})))
 	require.Equal(t, Merkle([]SHA256{h, h2, h3, h4, h5}), out)
 }
+
+func TestMerkleProof(t *testing.T) {
+	_, err := MerkleProof(nil, 0)
+	require.Equal(t, ErrMerkleIndexOutOfRange, err)
+
+	for n := 1; n <= 9; n++ {
+		hashes := make([]SHA256, n)
+		for i := range hashes {
+			hashes[i] = SumSHA256(randBytes(t, 128))
+		}
+		root := Merkle(append([]SHA256{}, hashes...))
+
+		for i, h := range hashes {
+			proof, err := MerkleProof(hashes, uint64(i))
+			require.NoError(t, err)
+			require.Len(t, proof, bits.Len64(nextPowerOfTwo(uint64(n))-1))
+
+			require.NoError(t, VerifyMerkleProof(root, h, uint64(i), proof))
+
+			// Another hash or another index is rejected
+			require.Equal(t, ErrMerkleProofInvalid, VerifyMerkleProof(root, SumSHA256(h[:]), uint64(i), proof))
+			if n > 1 {
+				j := (i + 1) % n
+				require.Equal(t, ErrMerkleProofInvalid, VerifyMerkleProof(root, h, uint64(j), proof))
+			}
+
+			// An index beyond the tree height is rejected
+			require.Equal(t, ErrMerkleIndexOutOfRange, VerifyMerkleProof(root, h, uint64(i)+(1<<uint(len(proof))), proof))
+		}
+
+		_, err := MerkleProof(hashes, uint64(n))
+		require.Equal(t, ErrMerkleIndexOutOfRange, err)
+	}
+}
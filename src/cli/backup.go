@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/apputil"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func backupDBCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Backup the database of a running node",
+		Use:   "backupDB [backup file]",
+		Long: `Downloads a consistent copy of the node's database while the node keeps running,
+    and writes a manifest with its checksum to [backup file].manifest.json.
+    The node must have the DATABASE API set enabled.
+    Use verifyBackup to check the backup.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return backupDB(args[0])
+		},
+	}
+}
+
+func backupDB(backupPath string) error {
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup file: %v already exists", backupPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	removeBackup := func() {
+		f.Close()
+		if err := os.Remove(backupPath); err != nil {
+			fmt.Fprintf(os.Stderr, "remove backup file failed: %v\n", err)
+		}
+	}
+
+	m, err := apiClient.DatabaseBackup(f)
+	if err != nil {
+		removeBackup()
+		return fmt.Errorf("backup failed: %v", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		removeBackup()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := visor.SaveBackupManifest(backupPath, *m); err != nil {
+		return fmt.Errorf("save backup manifest failed: %v", err)
+	}
+
+	return printJSON(m)
+}
+
+func verifyBackupCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Verify a database backup",
+		Use:   "verifyBackup [backup file]",
+		Long: `Checks a backup written by backupDB against the checksum in its manifest,
+    then opens it read-only and verifies the blockchain data like checkdb.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return verifyBackup(args[0])
+		},
+	}
+}
+
+func verifyBackup(backupPath string) error {
+	m, err := visor.VerifyBackupChecksum(backupPath)
+	if err != nil {
+		return fmt.Errorf("verify backup checksum failed: %v", err)
+	}
+
+	db, err := bolt.Open(backupPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open backup failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	if err := visor.CheckDatabase(wrapDB(db), pubkey, quitChan); err != nil {
+		if err == visor.ErrVerifyStopped {
+			return nil
+		}
+		return fmt.Errorf("verify backup failed: %v", err)
+	}
+
+	fmt.Printf("verify backup of block %d success\n", m.HeadSeq)
+	return nil
+}
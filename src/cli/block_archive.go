@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func exportBlockArchiveCmd() *cobra.Command {
+	exportBlockArchiveCmd := &cobra.Command{
+		Short: "Export signed blocks to a block archive file",
+		Use:   "exportBlockArchive [archive file] [db path]",
+		Long: `Writes the signed blocks of a range of blocks to a block archive file, followed by an index of the blocks.
+    The archive can be imported by a node started with -import-block-archive, which executes and
+    verifies each block after its head block. The database is opened read-only, so the node must not be running.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be used.`,
+		Args:         cobra.RangeArgs(1, 2),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			startSeq, err := c.Flags().GetUint64("start-seq")
+			if err != nil {
+				return err
+			}
+
+			endSeq := uint64(math.MaxUint64)
+			if c.Flags().Changed("end-seq") {
+				endSeq, err = c.Flags().GetUint64("end-seq")
+				if err != nil {
+					return err
+				}
+			}
+
+			if startSeq > endSeq {
+				return errors.New("--start-seq must not be greater than --end-seq")
+			}
+
+			dbPath := ""
+			if len(args) > 1 {
+				dbPath = args[1]
+			}
+
+			return exportBlockArchive(args[0], dbPath, startSeq, endSeq)
+		},
+	}
+
+	exportBlockArchiveCmd.Flags().Uint64("start-seq", 0, "First block to export")
+	exportBlockArchiveCmd.Flags().Uint64("end-seq", 0, "Last block to export. Defaults to the head block")
+
+	return exportBlockArchiveCmd
+}
+
+func exportBlockArchive(path, dbPath string, startSeq, endSeq uint64) error {
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := visor.ExportBlockArchive(wrapDB(db), pubkey, startSeq, endSeq, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		// Don't leave an incomplete archive behind
+		f.Close()
+		if rmErr := os.Remove(path); rmErr != nil {
+			fmt.Fprintf(os.Stderr, "remove archive file failed: %v\n", rmErr)
+		}
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported blocks %d to %d to %s, %d bytes\n", info.StartSeq, info.EndSeq, path, info.Size)
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBlockArchive(t *testing.T) {
+	dbPath, err := filepath.Abs("../visor/testdata/data.db.ok")
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "export-block-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A failed export does not leave an incomplete archive behind
+	path := filepath.Join(dir, "failed.archive")
+	err = exportBlockArchive(path, dbPath, 1e9, 1e9)
+	require.Error(t, err)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	path = filepath.Join(dir, "blocks.archive")
+	err = exportBlockArchive(path, dbPath, 0, 10)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NotEqual(t, int64(0), info.Size())
+
+	// An existing file is not overwritten or removed
+	err = exportBlockArchive(path, dbPath, 0, 10)
+	require.True(t, os.IsExist(err))
+	info2, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), info2.Size())
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/apputil"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func buildHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Build the transaction history of a database from its blocks",
+		Use:   "buildHistory [db path]",
+		Long: `Erases the transaction history of the database and builds it again from the blocks,
+    without syncing the blocks again. Use it to enable the history of a node that was run
+    with -disable-history. The node must not be running, and must be started without -disable-history
+    afterwards, otherwise the history is erased again.
+    If interrupted, the node finishes building the history in the background when it starts.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be used.`,
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			dbPath := ""
+			if len(args) > 0 {
+				dbPath = args[0]
+			}
+
+			return buildHistory(dbPath)
+		},
+	}
+}
+
+func buildHistory(dbPath string) error {
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	r, err := visor.RebuildHistory(wrapDB(db), pubkey, quitChan)
+	switch err {
+	case nil:
+	case visor.ErrVerifyStopped:
+		return errors.New("build history interrupted, the node finishes building it in the background when it starts")
+	default:
+		return fmt.Errorf("build history failed: %v", err)
+	}
+
+	fmt.Println("build history:")
+	if r.PreviousParsedExists {
+		fmt.Printf("  previous parsed block: %d\n", r.PreviousParsedSeq)
+	} else {
+		fmt.Println("  previous parsed block: none")
+	}
+	fmt.Printf("  blocks: %d\n", r.Blocks)
+	fmt.Printf("  transactions: %d\n", r.Transactions)
+
+	fmt.Println("build history success")
+	return nil
+}
@@ -16,6 +16,8 @@ import (
 
 const (
 	blockchainPubkey = "0328c576d3f420e7682058a981173a4b374c7cc5ff55bf394d3cf57059bbe6456a"
+	// blockchainGenesisHash is the header hash of the genesis block
+	blockchainGenesisHash = "0551a1e5af999fe8fff529f6f2ab341e1e33db95135eef1b2be44fe6981349f3"
 )
 
 // wrapDB calls dbutil.WrapDB and disables all logging
@@ -224,17 +224,26 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 	commands := []*cobra.Command{
 		addPrivateKeyCmd(),
 		addressBalanceCmd(),
+		addressBalanceAtCmd(),
 		addressGenCmd(),
 		fiberAddressGenCmd(),
 		addressOutputsCmd(),
+		backupDBCmd(),
 		blocksCmd(),
 		broadcastTxCmd(),
+		buildHistoryCmd(),
 		checkDBCmd(),
 		checkDBEncodingCmd(),
+		coinSupplyStatsCmd(),
+		compactDBCmd(),
 		createRawTxnCmd(),
 		decodeRawTxnCmd(),
 		decryptWalletCmd(),
 		encryptWalletCmd(),
+		exportBlockArchiveCmd(),
+		exportChainCmd(),
+		exportSnapshotCmd(),
+		importSnapshotCmd(),
 		lastBlocksCmd(),
 		listAddressesCmd(),
 		listWalletsCmd(),
@@ -245,6 +254,7 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		transactionCmd(),
 		verifyTransactionCmd(),
 		verifyAddressCmd(),
+		verifyBackupCmd(),
 		versionCmd(),
 		walletCreateCmd(),
 		walletAddAddressesCmd(),
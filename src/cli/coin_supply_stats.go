@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/api"
+)
+
+func coinSupplyStatsCmd() *gcli.Command {
+	coinSupplyStatsCmd := &gcli.Command{
+		Short: "Show coin supply and distribution statistics over time",
+		Use:   "coinSupplyStats [flags]",
+		Long: `Show the total and circulating coin supply, total coin hours, active addresses,
+    number of transactions and burned coin hours of each block, or of periods of time with --interval.
+    The supply, coin hours and active addresses of a period are as of its last block.
+    The blocks are selected by seq with --start-seq and --end-seq, or by time with --start-time and --end-time,
+    which accept a unix timestamp, an RFC3339 time or a YYYY-MM-DD date.
+    Use --csv to print the statistics as CSV. Requires the node's historydb.
+    example: coinSupplyStats --start-time 2018-01-01 --interval 24h --limit 365 --csv`,
+		Args:                  gcli.NoArgs,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  coinSupplyStats,
+	}
+
+	coinSupplyStatsCmd.Flags().Uint64("start-seq", 0, "Only include blocks with this seq or higher")
+	coinSupplyStatsCmd.Flags().Uint64("end-seq", 0, "Only include blocks with this seq or lower")
+	coinSupplyStatsCmd.Flags().String("start-time", "", "Only include blocks created at or after this time. A date means the start of that day in UTC.")
+	coinSupplyStatsCmd.Flags().String("end-time", "", "Only include blocks created at or before this time. A date means the end of that day in UTC.")
+	coinSupplyStatsCmd.Flags().Duration("interval", 0, "Group the blocks into periods of this duration, e.g. 1h or 24h. By default each block is shown.")
+	coinSupplyStatsCmd.Flags().Uint64("limit", 0, "Maximum number of periods to show. Defaults to the node's limit.")
+	coinSupplyStatsCmd.Flags().Bool("csv", false, "Print the statistics as CSV")
+
+	return coinSupplyStatsCmd
+}
+
+func coinSupplyStats(c *gcli.Command, _ []string) error {
+	var params api.SupplyStatsParams
+
+	for _, f := range []struct {
+		name string
+		v    **uint64
+	}{
+		{"start-seq", &params.StartSeq},
+		{"end-seq", &params.EndSeq},
+	} {
+		if !c.Flags().Changed(f.name) {
+			continue
+		}
+
+		seq, err := c.Flags().GetUint64(f.name)
+		if err != nil {
+			return err
+		}
+		*f.v = &seq
+	}
+
+	for _, f := range []struct {
+		name     string
+		v        **uint64
+		endOfDay bool
+	}{
+		{"start-time", &params.StartTime, false},
+		{"end-time", &params.EndTime, true},
+	} {
+		s, err := c.Flags().GetString(f.name)
+		if err != nil {
+			return err
+		}
+
+		if s == "" {
+			continue
+		}
+
+		t, err := parseTimeArg(s, f.endOfDay)
+		if err != nil {
+			return err
+		}
+		*f.v = &t
+	}
+
+	interval, err := c.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+
+	if interval < 0 || interval%time.Second != 0 {
+		return errors.New("--interval must be a whole number of seconds and must not be negative")
+	}
+	params.Interval = uint64(interval / time.Second)
+
+	params.Limit, err = c.Flags().GetUint64("limit")
+	if err != nil {
+		return err
+	}
+
+	stats, err := apiClient.CoinSupplyStats(params)
+	if err != nil {
+		return err
+	}
+
+	asCSV, err := c.Flags().GetBool("csv")
+	if err != nil {
+		return err
+	}
+
+	if asCSV {
+		return api.WriteSupplyStatsCSV(os.Stdout, stats)
+	}
+
+	return printJSON(stats)
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/apputil"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+const (
+	// compactTxMaxSize is the size of the write transactions used to copy the database
+	compactTxMaxSize = 64 * 1024 * 1024
+)
+
+func compactDBCmd() *cobra.Command {
+	compactDBCmd := &cobra.Command{
+		Short: "Compact the database into a new file and optionally repair it",
+		Use:   "compactdb [compacted db path] [db path]",
+		Long: `Copies the database into a new file, which only takes the space needed by its data.
+    The original database is opened read-only and is not modified. The node must not be running.
+    The repair flags run on the compacted database, and a report is printed for each step.
+    The compacted database is verified like checkdb afterwards.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be compacted.`,
+		Args:         cobra.RangeArgs(1, 2),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			var opts compactDBOptions
+
+			all, err := c.Flags().GetBool("repair-all")
+			if err != nil {
+				return err
+			}
+
+			opts.rebuildHistory, err = c.Flags().GetBool("rebuild-history")
+			if err != nil {
+				return err
+			}
+
+			opts.rebuildAddrIndex, err = c.Flags().GetBool("rebuild-addr-index")
+			if err != nil {
+				return err
+			}
+
+			opts.recomputeUxHash, err = c.Flags().GetBool("recompute-uxhash")
+			if err != nil {
+				return err
+			}
+
+			opts.removeInvalidUnconfirmed, err = c.Flags().GetBool("remove-invalid-unconfirmed")
+			if err != nil {
+				return err
+			}
+
+			if all {
+				opts = compactDBOptions{
+					rebuildHistory:           true,
+					rebuildAddrIndex:         true,
+					recomputeUxHash:          true,
+					removeInvalidUnconfirmed: true,
+				}
+			}
+
+			dbPath := ""
+			if len(args) > 1 {
+				dbPath = args[1]
+			}
+
+			return compactDB(args[0], dbPath, opts)
+		},
+	}
+
+	compactDBCmd.Flags().Bool("rebuild-history", false, "rebuild the transaction history from the blocks")
+	compactDBCmd.Flags().Bool("rebuild-addr-index", false, "rebuild the unspent output address index")
+	compactDBCmd.Flags().Bool("recompute-uxhash", false, "recompute the xor hash of the unspent outputs")
+	compactDBCmd.Flags().Bool("remove-invalid-unconfirmed", false, "remove unconfirmed transactions that can never become valid")
+	compactDBCmd.Flags().BoolP("repair-all", "a", false, "run all repair steps")
+
+	return compactDBCmd
+}
+
+type compactDBOptions struct {
+	rebuildHistory           bool
+	rebuildAddrIndex         bool
+	recomputeUxHash          bool
+	removeInvalidUnconfirmed bool
+}
+
+func compactDB(dstPath, dbPath string, opts compactDBOptions) error {
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	if _, err := os.Stat(dstPath); err == nil {
+		return fmt.Errorf("compacted db file: %v already exists", dstPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	src, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := bolt.Open(dstPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open compacted db failed: %v", err)
+	}
+
+	if err := compactAndRepairDB(wrapDB(dst), wrapDB(src), pubkey, opts); err != nil {
+		dst.Close()
+		if rmErr := os.Remove(dstPath); rmErr != nil {
+			fmt.Fprintf(os.Stderr, "remove compacted db file failed: %v\n", rmErr)
+		}
+		return err
+	}
+
+	return dst.Close()
+}
+
+func compactAndRepairDB(dst, src *dbutil.DB, pubkey cipher.PubKey, opts compactDBOptions) error {
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	compact, err := visor.CompactDB(dst, src, compactTxMaxSize)
+	if err != nil {
+		return fmt.Errorf("compact db failed: %v", err)
+	}
+
+	fmt.Println("compact:")
+	fmt.Printf("  buckets: %d\n", compact.Buckets)
+	fmt.Printf("  keys: %d\n", compact.Keys)
+	fmt.Printf("  size: %d bytes -> %d bytes\n", compact.SrcSize, compact.DstSize)
+
+	if opts.recomputeUxHash {
+		r, err := visor.RecomputeUxHash(dst)
+		if err != nil {
+			return fmt.Errorf("recompute unspent hash failed: %v", err)
+		}
+
+		fmt.Println("recompute unspent hash:")
+		fmt.Printf("  previous: %s\n", r.Previous.Hex())
+		fmt.Printf("  recomputed: %s\n", r.Recomputed.Hex())
+		fmt.Printf("  repaired: %v\n", r.Changed())
+	}
+
+	if opts.rebuildAddrIndex {
+		r, err := visor.RebuildUnspentAddrIndex(dst, pubkey)
+		if err != nil {
+			return fmt.Errorf("rebuild unspent address index failed: %v", err)
+		}
+
+		fmt.Println("rebuild unspent address index:")
+		if r.PreviousHeightExists {
+			fmt.Printf("  previous height: %d\n", r.PreviousHeight)
+		} else {
+			fmt.Println("  previous height: none")
+		}
+		fmt.Printf("  addresses: %d\n", r.Addresses)
+		fmt.Printf("  outputs: %d\n", r.Outputs)
+		fmt.Printf("  corrupt entries: %d\n", r.Corrupt)
+		printAddresses("missing addresses", r.Missing)
+		printAddresses("stale addresses", r.Stale)
+		printAddresses("mismatched addresses", r.Mismatched)
+	}
+
+	if opts.rebuildHistory {
+		r, err := visor.RebuildHistory(dst, pubkey, quitChan)
+		switch err {
+		case nil:
+			fmt.Println("rebuild history:")
+			if r.PreviousParsedExists {
+				fmt.Printf("  previous parsed block: %d\n", r.PreviousParsedSeq)
+			} else {
+				fmt.Println("  previous parsed block: none")
+			}
+			fmt.Printf("  blocks: %d\n", r.Blocks)
+			fmt.Printf("  transactions: %d\n", r.Transactions)
+		case visor.ErrRepairPrunedHistory:
+			// A pruned database has no history to repair
+			fmt.Println("rebuild history:")
+			fmt.Printf("  skipped: %v\n", err)
+		default:
+			return fmt.Errorf("rebuild history failed: %v", err)
+		}
+	}
+
+	if opts.removeInvalidUnconfirmed {
+		r, err := visor.RemoveInvalidUnconfirmedTxns(dst, pubkey)
+		if err != nil {
+			return fmt.Errorf("remove invalid unconfirmed transactions failed: %v", err)
+		}
+
+		fmt.Println("remove invalid unconfirmed transactions:")
+		fmt.Printf("  checked: %d\n", r.Checked)
+		fmt.Printf("  removed: %d\n", len(r.Removed))
+		for _, h := range r.Removed {
+			fmt.Printf("    %s\n", h.Hex())
+		}
+	}
+
+	if err := visor.CheckDatabase(dst, pubkey, quitChan); err != nil {
+		return fmt.Errorf("checkdb of the compacted db failed: %v", err)
+	}
+
+	if opts != (compactDBOptions{}) {
+		info, err := os.Stat(dst.Path())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("size after repair: %d bytes\n", info.Size())
+	}
+
+	fmt.Println("compact db success")
+	return nil
+}
+
+func printAddresses(name string, addrs []cipher.Address) {
+	fmt.Printf("  %s: %d\n", name, len(addrs))
+	for _, a := range addrs {
+		fmt.Printf("    %s\n", a.String())
+	}
+}
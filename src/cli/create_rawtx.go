@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/skycoin/skycoin/src/params"
@@ -33,11 +34,14 @@ var (
 type SendAmount struct {
 	Addr  string
 	Coins uint64
+	// Hours are the target hours for the output, used with an hours mode
+	Hours uint64
 }
 
 type sendAmountJSON struct {
 	Addr  string `json:"addr"`
 	Coins string `json:"coins"`
+	Hours string `json:"hours,omitempty"`
 }
 
 func createRawTxnCmd() *cobra.Command {
@@ -100,7 +104,10 @@ By default the from address or a wallets coinbase address will be used.`)
 example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20"}]'`)
 	createRawTxnCmd.Flags().StringP("password", "p", "", "Wallet password")
 	createRawTxnCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
-	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
+	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send, with optional target hours")
+	createRawTxnCmd.Flags().String("hours-mode", "", `Send target hours to each address, specified as "hours" in -m or as the third column in --csv.
+"exact" sends exactly the target hours, "minimum" sends at least the target hours.
+The remaining hours go to the change address.`)
 
 	return createRawTxnCmd
 }
@@ -251,9 +258,20 @@ func parseSendAmountsFromCSV(fields [][]string) ([]SendAmount, error) {
 			continue
 		}
 
+		var hours uint64
+		if len(f) > 2 {
+			hours, err = strconv.ParseUint(strings.TrimSpace(f[2]), 10, 64)
+			if err != nil {
+				err = fmt.Errorf("[row %d] Invalid hours %s: %v", i, f[2], err)
+				errs = append(errs, err)
+				continue
+			}
+		}
+
 		sends = append(sends, SendAmount{
 			Addr:  addr,
 			Coins: coins,
+			Hours: hours,
 		})
 	}
 
@@ -286,9 +304,18 @@ func parseSendAmountsFromJSON(m string) ([]SendAmount, error) {
 			return nil, fmt.Errorf("invalid coins value in -m flag string: %v", err)
 		}
 
+		var hours uint64
+		if sa.Hours != "" {
+			hours, err = strconv.ParseUint(sa.Hours, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hours value in -m flag string: %v", err)
+			}
+		}
+
 		sendAmts = append(sendAmts, SendAmount{
 			Addr:  sa.Addr,
 			Coins: amt,
+			Hours: hours,
 		})
 	}
 
@@ -311,6 +338,7 @@ type createRawTxnArgs struct {
 	Address       string
 	ChangeAddress string
 	SendAmounts   []SendAmount
+	HoursMode     string
 	Password      PasswordReader
 }
 
@@ -329,11 +357,16 @@ func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs,
 		return nil, err
 	}
 
+	hoursMode, err := c.Flags().GetString("hours-mode")
+	if err != nil {
+		return nil, err
+	}
+
 	toAddrs, err := getToAddresses(c, args)
 	if err != nil {
 		return nil, err
 	}
-	if err := validateSendAmounts(toAddrs); err != nil {
+	if err := validateSendAmounts(toAddrs, hoursMode); err != nil {
 		return nil, err
 	}
 
@@ -348,6 +381,7 @@ func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs,
 		Address:       wltAddr.Address,
 		ChangeAddress: chgAddr,
 		SendAmounts:   toAddrs,
+		HoursMode:     hoursMode,
 		Password:      pr,
 	}, nil
 }
@@ -364,16 +398,23 @@ func createRawTxnCmdHandler(c *cobra.Command, args []string) (*coin.Transaction,
 	// but this wouldn't work for new fiber coins that hadn't been hardcoded yet.
 	if parsedArgs.Address == "" {
 		return CreateRawTxnFromWallet(apiClient, parsedArgs.WalletID,
-			parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
+			parsedArgs.ChangeAddress, parsedArgs.SendAmounts, parsedArgs.HoursMode,
 			parsedArgs.Password, params.MainNetDistribution)
 	}
 
 	return CreateRawTxnFromAddress(apiClient, parsedArgs.Address,
-		parsedArgs.WalletID, parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
+		parsedArgs.WalletID, parsedArgs.ChangeAddress, parsedArgs.SendAmounts, parsedArgs.HoursMode,
 		parsedArgs.Password, params.MainNetDistribution)
 }
 
-func validateSendAmounts(toAddrs []SendAmount) error {
+func validateSendAmounts(toAddrs []SendAmount, hoursMode string) error {
+	switch hoursMode {
+	case "", transaction.HoursSelectionModeExact, transaction.HoursSelectionModeMinimum:
+	default:
+		return fmt.Errorf("invalid hours mode %q, must be %q or %q", hoursMode,
+			transaction.HoursSelectionModeExact, transaction.HoursSelectionModeMinimum)
+	}
+
 	for _, arg := range toAddrs {
 		// validate to address
 		_, err := cipher.DecodeBase58Address(arg.Addr)
@@ -384,6 +425,14 @@ func validateSendAmounts(toAddrs []SendAmount) error {
 		if arg.Coins == 0 {
 			return errors.New("Cannot send 0 coins")
 		}
+
+		if hoursMode == "" && arg.Hours != 0 {
+			return errors.New("Hours can only be specified with an hours mode")
+		}
+
+		if hoursMode != "" && arg.Hours == 0 {
+			return errors.New("Hours must be specified for every address with an hours mode")
+		}
 	}
 
 	if len(toAddrs) == 0 {
@@ -396,7 +445,7 @@ func validateSendAmounts(toAddrs []SendAmount) error {
 // PUBLIC
 
 // CreateRawTxnFromWallet creates a transaction from any address or combination of addresses in a wallet
-func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
+func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, hoursMode string, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
 	// check change address
 	cAddr, err := cipher.DecodeBase58Address(chgAddr)
 	if err != nil {
@@ -446,11 +495,11 @@ func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs
 		addrStrArray[i] = a.String()
 	}
 
-	return CreateRawTxn(c, wlt, addrStrArray, chgAddr, toAddrs, password, distParams)
+	return CreateRawTxn(c, wlt, addrStrArray, chgAddr, toAddrs, hoursMode, password, distParams)
 }
 
 // CreateRawTxnFromAddress creates a transaction from a specific address in a wallet
-func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
+func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, hoursMode string, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
 	// check if the address is in the default wallet.
 	wlt, err := wallet.Load(walletFile)
 	if err != nil {
@@ -503,7 +552,7 @@ func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, t
 		}
 	}
 
-	return CreateRawTxn(c, wlt, []string{addr}, chgAddr, toAddrs, password, distParams)
+	return CreateRawTxn(c, wlt, []string{addr}, chgAddr, toAddrs, hoursMode, password, distParams)
 }
 
 // GetOutputser implements unspent output querying
@@ -511,9 +560,10 @@ type GetOutputser interface {
 	OutputsForAddresses([]string) (*readable.UnspentOutputsSummary, error)
 }
 
-// CreateRawTxn creates a transaction from a set of addresses contained in a loaded *wallet.Wallet
-func CreateRawTxn(c GetOutputser, wlt *wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution) (*coin.Transaction, error) {
-	if err := validateSendAmounts(toAddrs); err != nil {
+// CreateRawTxn creates a transaction from a set of addresses contained in a loaded *wallet.Wallet.
+// If hoursMode is set, the hours of each SendAmount are sent as target hours according to the mode.
+func CreateRawTxn(c GetOutputser, wlt *wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, hoursMode string, password []byte, distParams params.Distribution) (*coin.Transaction, error) {
+	if err := validateSendAmounts(toAddrs, hoursMode); err != nil {
 		return nil, err
 	}
 
@@ -528,7 +578,7 @@ func CreateRawTxn(c GetOutputser, wlt *wallet.Wallet, inAddrs []string, chgAddr
 		return nil, err
 	}
 
-	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, password)
+	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, hoursMode, password)
 	if err != nil {
 		return nil, err
 	}
@@ -561,23 +611,33 @@ func CreateRawTxn(c GetOutputser, wlt *wallet.Wallet, inAddrs []string, chgAddr
 	return txn, nil
 }
 
-func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt *wallet.Wallet, chgAddr string, toAddrs []SendAmount, password []byte) (*coin.Transaction, error) {
-	// Calculate total required coins
-	var totalCoins uint64
+func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt *wallet.Wallet, chgAddr string, toAddrs []SendAmount, hoursMode string, password []byte) (*coin.Transaction, error) {
+	// Calculate total required coins and target hours
+	var totalCoins, totalHours uint64
 	for _, arg := range toAddrs {
 		var err error
 		totalCoins, err = mathutil.AddUint64(totalCoins, arg.Coins)
 		if err != nil {
 			return nil, err
 		}
+
+		totalHours, err = mathutil.AddUint64(totalHours, arg.Hours)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	spendOutputs, err := chooseSpends(uxouts, totalCoins)
+	spendOutputs, err := chooseSpends(uxouts, totalCoins, totalHours)
 	if err != nil {
 		return nil, err
 	}
 
-	txOuts, err := makeChangeOut(spendOutputs, chgAddr, toAddrs)
+	var txOuts []coin.TransactionOutput
+	if hoursMode == "" {
+		txOuts, err = makeChangeOut(spendOutputs, chgAddr, toAddrs)
+	} else {
+		txOuts, err = makeTargetHoursOut(spendOutputs, chgAddr, toAddrs, hoursMode)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -613,7 +673,7 @@ func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt *wallet.Wallet, ch
 	return makeTxn()
 }
 
-func chooseSpends(uxouts *readable.UnspentOutputsSummary, coins uint64) ([]transaction.UxBalance, error) {
+func chooseSpends(uxouts *readable.UnspentOutputsSummary, coins, hours uint64) ([]transaction.UxBalance, error) {
 	// Convert spendable unspent outputs to []transaction.UxBalance
 	spendableOutputs, err := readable.OutputsToUxBalances(uxouts.SpendableOutputs())
 	if err != nil {
@@ -625,7 +685,7 @@ func chooseSpends(uxouts *readable.UnspentOutputsSummary, coins uint64) ([]trans
 	// application that may need to send frequently.
 	// Using fewer UxOuts will leave more available for other transactions,
 	// instead of waiting for confirmation.
-	outs, err := transaction.ChooseSpendsMinimizeUxOuts(spendableOutputs, coins, 0)
+	outs, err := transaction.ChooseSpendsMinimizeUxOuts(spendableOutputs, coins, hours)
 	if err != nil {
 		// If there is not enough balance in the spendable outputs,
 		// see if there is enough balance when including incoming outputs
@@ -635,7 +695,7 @@ func chooseSpends(uxouts *readable.UnspentOutputsSummary, coins uint64) ([]trans
 				return nil, otherErr
 			}
 
-			if _, otherErr := transaction.ChooseSpendsMinimizeUxOuts(expectedOutputs, coins, 0); otherErr != nil {
+			if _, otherErr := transaction.ChooseSpendsMinimizeUxOuts(expectedOutputs, coins, hours); otherErr != nil {
 				return nil, err
 			}
 
@@ -711,6 +771,92 @@ func makeChangeOut(outs []transaction.UxBalance, chgAddr string, toAddrs []SendA
 	return outAddrs, nil
 }
 
+// makeTargetHoursOut creates the outputs for target hours sends.
+// In "exact" mode every destination gets its target hours and the remaining hours after the fee go to change.
+// In "minimum" mode every destination gets its target hours, and the remaining hours after the fee and the targets
+// are split between the change and the destinations in the same way as makeChangeOut.
+// If there is no change output, the remaining hours of "exact" mode are burned and those of "minimum" mode go to the destinations.
+func makeTargetHoursOut(outs []transaction.UxBalance, chgAddr string, toAddrs []SendAmount, hoursMode string) ([]coin.TransactionOutput, error) {
+	var totalInCoins, totalInHours, totalOutCoins, totalTargetHours uint64
+
+	for _, o := range outs {
+		totalInCoins += o.Coins
+		totalInHours += o.Hours
+	}
+
+	if totalInHours == 0 {
+		return nil, fee.ErrTxnNoFee
+	}
+
+	for _, to := range toAddrs {
+		totalOutCoins += to.Coins
+		totalTargetHours += to.Hours
+	}
+
+	if totalInCoins < totalOutCoins {
+		return nil, transaction.ErrInsufficientBalance
+	}
+
+	remainingHours := fee.RemainingHours(totalInHours, params.UserVerifyTxn.BurnFactor)
+	if totalTargetHours > remainingHours {
+		return nil, transaction.ErrInsufficientHours
+	}
+	extraHours := remainingHours - totalTargetHours
+
+	changeAmount := totalInCoins - totalOutCoins
+	haveChange := changeAmount > 0
+
+	addrHours := make([]uint64, len(toAddrs))
+	for i, to := range toAddrs {
+		addrHours[i] = to.Hours
+	}
+
+	var changeHours uint64
+	switch hoursMode {
+	case transaction.HoursSelectionModeExact:
+		if haveChange {
+			changeHours = extraHours
+		}
+
+	case transaction.HoursSelectionModeMinimum:
+		// Split the extra hours between the change output and the other outputs,
+		// giving the extra hour of an odd split to the change output
+		if haveChange {
+			changeHours = extraHours/2 + extraHours%2
+		}
+
+		addrExtraHours := extraHours - changeHours
+		nAddrs := uint64(len(toAddrs))
+		for i := range addrHours {
+			addrHours[i] += addrExtraHours / nAddrs
+			if uint64(i) < addrExtraHours%nAddrs {
+				addrHours[i]++
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid hours mode %q", hoursMode)
+	}
+
+	var totalOutHours uint64
+	outAddrs := make([]coin.TransactionOutput, 0, len(toAddrs)+1)
+	for i, to := range toAddrs {
+		totalOutHours += addrHours[i]
+		outAddrs = append(outAddrs, mustMakeUtxoOutput(to.Addr, to.Coins, addrHours[i]))
+	}
+
+	if haveChange {
+		totalOutHours += changeHours
+		outAddrs = append(outAddrs, mustMakeUtxoOutput(chgAddr, changeAmount, changeHours))
+	}
+
+	if err := fee.VerifyTransactionFeeForHours(totalOutHours, totalInHours-totalOutHours, params.UserVerifyTxn.BurnFactor); err != nil {
+		return nil, err
+	}
+
+	return outAddrs, nil
+}
+
 func mustMakeUtxoOutput(addr string, coins, hours uint64) coin.TransactionOutput {
 	uo := coin.TransactionOutput{}
 	uo.Address = cipher.MustDecodeBase58Address(addr)
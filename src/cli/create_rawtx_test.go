@@ -288,6 +288,118 @@ func TestMakeChangeOutInsufficientCoinHours(t *testing.T) {
 	testutil.RequireError(t, err, fee.ErrTxnNoFee.Error())
 }
 
+func TestMakeTargetHoursOut(t *testing.T) {
+	uxOuts := []transaction.UxBalance{
+		{
+			Hash:    cipher.MustSHA256FromHex("f569461182b0efe9a5c666e9a35c6602b351021c1803cc740aca548cf6db4cb2"),
+			Address: cipher.MustDecodeBase58Address("k3rmz3PGbTxd7KL8AL5CeHrWy35C1UcWND"),
+			BkSeq:   10,
+			Coins:   400e6,
+			Hours:   200,
+		},
+		{
+			Hash:    cipher.MustSHA256FromHex("bddf0aaf80f96c144f33ac8a27764a868d37e1c11e568063ebeb1367de859566"),
+			Address: cipher.MustDecodeBase58Address("A2h4iWC1SDGmS6UPezatFzEUwirLJtjFUe"),
+			BkSeq:   11,
+			Coins:   300e6,
+			Hours:   100,
+		},
+	}
+
+	chgAddr := "2konv5no3DZvSMxf2GPVtAfZinfwqCGhfVQ"
+	toAddrs := []SendAmount{
+		{
+			Addr:  "2PBmUva7J8WFsyWg979cREZkU3z2pkYjNkE",
+			Coins: 300e6,
+			Hours: 50,
+		},
+		{
+			Addr:  "2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd",
+			Coins: 300e6,
+			Hours: 25,
+		},
+	}
+
+	cases := []struct {
+		name        string
+		mode        string
+		toAddrs     []SendAmount
+		addrHours   []uint64
+		changeHours uint64
+		noChange    bool
+		err         error
+	}{
+		{
+			// 300 input hours, 30 burned, 75 targeted, 195 remaining
+			name:        "exact",
+			mode:        transaction.HoursSelectionModeExact,
+			toAddrs:     toAddrs,
+			addrHours:   []uint64{50, 25},
+			changeHours: 195,
+		},
+		{
+			name:        "minimum",
+			mode:        transaction.HoursSelectionModeMinimum,
+			toAddrs:     toAddrs,
+			addrHours:   []uint64{99, 73},
+			changeHours: 98,
+		},
+		{
+			name: "minimum no change",
+			mode: transaction.HoursSelectionModeMinimum,
+			toAddrs: []SendAmount{
+				toAddrs[0],
+				{
+					Addr:  "2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd",
+					Coins: 400e6,
+					Hours: 25,
+				},
+			},
+			addrHours: []uint64{148, 122},
+			noChange:  true,
+		},
+		{
+			name: "insufficient hours",
+			mode: transaction.HoursSelectionModeExact,
+			toAddrs: []SendAmount{
+				{
+					Addr:  "2PBmUva7J8WFsyWg979cREZkU3z2pkYjNkE",
+					Coins: 300e6,
+					Hours: 271,
+				},
+			},
+			err: transaction.ErrInsufficientHours,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txOuts, err := makeTargetHoursOut(uxOuts, chgAddr, tc.toAddrs, tc.mode)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.noChange {
+				require.Len(t, txOuts, len(tc.toAddrs))
+			} else {
+				require.Len(t, txOuts, len(tc.toAddrs)+1)
+				chgOut := txOuts[len(tc.toAddrs)]
+				require.Equal(t, chgAddr, chgOut.Address.String())
+				require.Equal(t, tc.changeHours, chgOut.Hours)
+			}
+
+			for i, to := range tc.toAddrs {
+				require.Equal(t, to.Addr, txOuts[i].Address.String())
+				require.Equal(t, to.Coins, txOuts[i].Coins)
+				require.Equal(t, tc.addrHours[i], txOuts[i].Hours)
+				require.True(t, txOuts[i].Hours >= to.Hours)
+			}
+		})
+	}
+}
+
 func TestChooseSpends(t *testing.T) {
 	// Start with readable.UnspentOutputsSummary
 	// Spends should be minimized
@@ -512,7 +624,7 @@ func TestChooseSpends(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			spends, err := chooseSpends(&tc.ros, coins)
+			spends, err := chooseSpends(&tc.ros, coins, 0)
 
 			if tc.err != nil {
 				testutil.RequireError(t, err, tc.err.Error())
@@ -566,6 +678,34 @@ func TestParseSendAmountsFromCSV(t *testing.T) {
 			},
 		},
 
+		{
+			name: "valid target hours",
+			fields: [][]string{
+				{"2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP", "123", "10"},
+				{"2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd", "123.456", " 20 "},
+			},
+			amts: []SendAmount{
+				{
+					Addr:  "2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP",
+					Coins: 123e6,
+					Hours: 10,
+				},
+				{
+					Addr:  "2UDzBKnxZf4d9pdrBJAqbtoeH641RFLYKxd",
+					Coins: 123456e3,
+					Hours: 20,
+				},
+			},
+		},
+
+		{
+			name: "invalid hours value",
+			fields: [][]string{
+				{"7KU683yzoPE9rVuuFRQMZVhGwBBtwqTKT2", "1", "1.5"},
+			},
+			err: errors.New(`[row 0] Invalid hours 1.5: strconv.ParseUint: parsing "1.5": invalid syntax`),
+		},
+
 		{
 			name: "invalid coins value",
 			fields: [][]string{
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func exportChainCmd() *cobra.Command {
+	exportChainCmd := &cobra.Command{
+		Short: "Export blocks, transactions, inputs and outputs to JSONL or CSV files",
+		Use:   "exportChain [output dir] [db path]",
+		Long: `Writes the blocks, transactions, inputs and outputs of a range of blocks to one file per table,
+    named [table].jsonl or [table].csv in [output dir]. Each table has a fixed set of columns.
+    Coins are in droplets. Exporting inputs requires the historydb, to look up the outputs they spend.
+    The database is opened read-only, so the node must not be running.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be used.`,
+		Args:         cobra.RangeArgs(1, 2),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			startSeq, err := c.Flags().GetUint64("start-seq")
+			if err != nil {
+				return err
+			}
+
+			endSeq := uint64(math.MaxUint64)
+			if c.Flags().Changed("end-seq") {
+				endSeq, err = c.Flags().GetUint64("end-seq")
+				if err != nil {
+					return err
+				}
+			}
+
+			if startSeq > endSeq {
+				return errors.New("--start-seq must not be greater than --end-seq")
+			}
+
+			format, err := c.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			tables, err := c.Flags().GetString("tables")
+			if err != nil {
+				return err
+			}
+
+			dbPath := ""
+			if len(args) > 1 {
+				dbPath = args[1]
+			}
+
+			return exportChain(args[0], dbPath, tables, format, startSeq, endSeq)
+		},
+	}
+
+	exportChainCmd.Flags().Uint64("start-seq", 0, "First block to export")
+	exportChainCmd.Flags().Uint64("end-seq", 0, "Last block to export. Defaults to the head block")
+	exportChainCmd.Flags().StringP("format", "f", string(visor.ExportFormatJSONL), `File format, "jsonl" or "csv"`)
+	exportChainCmd.Flags().StringP("tables", "t", "blocks,transactions,inputs,outputs", "Comma separated tables to export")
+
+	return exportChainCmd
+}
+
+func exportChain(outDir, dbPath, tablesStr, formatStr string, startSeq, endSeq uint64) error {
+	format, err := visor.ParseExportFormat(formatStr)
+	if err != nil {
+		return err
+	}
+
+	var tables []visor.ExportTable
+	for _, s := range strings.Split(tablesStr, ",") {
+		t, err := visor.ParseExportTable(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t)
+	}
+
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0750); err != nil {
+		return err
+	}
+
+	wdb := wrapDB(db)
+
+	for _, t := range tables {
+		path := filepath.Join(outDir, fmt.Sprintf("%s.%s", t, format))
+
+		rows, err := exportChainTable(wdb, pubkey, path, visor.ExportParams{
+			Table:    t,
+			Format:   format,
+			StartSeq: startSeq,
+			EndSeq:   endSeq,
+		})
+		if err != nil {
+			return fmt.Errorf("export %s failed: %v", t, err)
+		}
+
+		fmt.Printf("exported %d %s to %s\n", rows, t, path)
+	}
+
+	return nil
+}
+
+func exportChainTable(db *dbutil.DB, pubkey cipher.PubKey, path string, p visor.ExportParams) (uint64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	rows, err := visor.ExportChain(db, pubkey, p, w)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/api"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/util/droplet"
+)
+
+// HistoricalAddressBalance represents an address's confirmed balance as of a block
+type HistoricalAddressBalance struct {
+	Confirmed Balance `json:"confirmed"`
+	Address   string  `json:"address"`
+}
+
+// HistoricalBalanceResult represents a set of addresses' confirmed balances as of a block
+type HistoricalBalanceResult struct {
+	BlockSeq  uint64                     `json:"block_seq"`
+	BlockHash string                     `json:"block_hash"`
+	BlockTime string                     `json:"block_time"`
+	Confirmed Balance                    `json:"confirmed"`
+	Addresses []HistoricalAddressBalance `json:"addresses"`
+}
+
+func addressBalanceAtCmd() *gcli.Command {
+	addressBalanceAtCmd := &gcli.Command{
+		Short: "Check the balance of specific addresses at a block in the past",
+		Use:   "addressBalanceAt [flags] [addresses]",
+		Long: `Check the confirmed balance of specific addresses after a block was executed,
+    join multiple addresses with space. The block is given by its seq with --seq,
+    or by a time with --time, in which case the last block at or before the time is used.
+    Coin hours are calculated at the time of the block. Requires the node's historydb.
+    example: addressBalanceAt --time 2018-01-31 "$addr1 $addr2 $addr3"`,
+		Args:                  gcli.MinimumNArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  addrBalanceAt,
+	}
+
+	addressBalanceAtCmd.Flags().Uint64("seq", 0, "Block seq")
+	addressBalanceAtCmd.Flags().String("time", "", "Unix timestamp, RFC3339 time, or YYYY-MM-DD date. A date means the end of that day in UTC.")
+
+	return addressBalanceAtCmd
+}
+
+func addrBalanceAt(c *gcli.Command, args []string) error {
+	for _, a := range args {
+		if _, err := cipher.DecodeBase58Address(a); err != nil {
+			return fmt.Errorf("invalid address: %v, err: %v", a, err)
+		}
+	}
+
+	req := api.AddressBalanceRequest{
+		Addresses: args,
+	}
+
+	timeStr, err := c.Flags().GetString("time")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c.Flags().Changed("seq") && timeStr != "":
+		return errors.New("--seq and --time cannot be combined")
+	case c.Flags().Changed("seq"):
+		seq, err := c.Flags().GetUint64("seq")
+		if err != nil {
+			return err
+		}
+		req.Seq = &seq
+	case timeStr != "":
+		t, err := parseTimeArg(timeStr, true)
+		if err != nil {
+			return err
+		}
+		req.Time = &t
+	default:
+		return errors.New("one of --seq or --time is required")
+	}
+
+	balance, err := apiClient.AddressBalance(req)
+	if err != nil {
+		return err
+	}
+
+	result, err := newHistoricalBalanceResult(args, balance)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(result)
+}
+
+// parseTimeArg parses a unix timestamp, an RFC3339 time, or a YYYY-MM-DD date.
+// A date is parsed as the start of that day in UTC, or as the last second of that day if endOfDay is true.
+func parseTimeArg(s string, endOfDay bool) (uint64, error) {
+	if ts, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return ts, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if t.Unix() < 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		return uint64(t.Unix()), nil
+	}
+
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil || t.Unix() < 0 {
+		return 0, fmt.Errorf("invalid time %q, must be a unix timestamp, an RFC3339 time or a YYYY-MM-DD date", s)
+	}
+
+	if endOfDay {
+		return uint64(t.AddDate(0, 0, 1).Unix() - 1), nil
+	}
+
+	return uint64(t.Unix()), nil
+}
+
+func newHistoricalBalanceResult(addrs []string, b *api.AddressBalance) (*HistoricalBalanceResult, error) {
+	toBalance := func(b readable.Balance) (Balance, error) {
+		coins, err := droplet.ToString(b.Coins)
+		if err != nil {
+			return Balance{}, err
+		}
+
+		return Balance{
+			Coins: coins,
+			Hours: strconv.FormatUint(b.Hours, 10),
+		}, nil
+	}
+
+	confirmed, err := toBalance(b.Confirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HistoricalBalanceResult{
+		BlockSeq:  b.Head.BkSeq,
+		BlockHash: b.Head.Hash,
+		BlockTime: time.Unix(int64(b.Head.Time), 0).UTC().Format(time.RFC3339),
+		Confirmed: confirmed,
+		Addresses: make([]HistoricalAddressBalance, len(addrs)),
+	}
+
+	for i, a := range addrs {
+		ab, ok := b.Addresses[a]
+		if !ok {
+			return nil, fmt.Errorf("address %s is missing from the response", a)
+		}
+
+		result.Addresses[i].Address = a
+		result.Addresses[i].Confirmed, err = toBalance(ab)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeArg(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		endOfDay bool
+		result   uint64
+		err      string
+	}{
+		{
+			name:   "unix timestamp",
+			input:  "1517400000",
+			result: 1517400000,
+		},
+		{
+			name:   "rfc3339",
+			input:  "2018-01-31T12:00:00Z",
+			result: 1517400000,
+		},
+		{
+			name:   "rfc3339 with offset",
+			input:  "2018-01-31T14:00:00+02:00",
+			result: 1517400000,
+		},
+		{
+			name:   "date",
+			input:  "2018-01-31",
+			result: 1517356800,
+		},
+		{
+			name:     "date is the end of the day",
+			input:    "2018-01-31",
+			endOfDay: true,
+			result:   1517443199,
+		},
+		{
+			name:  "invalid",
+			input: "31/01/2018",
+			err:   `invalid time "31/01/2018", must be a unix timestamp, an RFC3339 time or a YYYY-MM-DD date`,
+		},
+		{
+			name:  "before epoch",
+			input: "1969-12-31",
+			err:   `invalid time "1969-12-31", must be a unix timestamp, an RFC3339 time or a YYYY-MM-DD date`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, err := parseTimeArg(tc.input, tc.endOfDay)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.result, ts)
+		})
+	}
+}
@@ -57,7 +57,10 @@ By default the from address or a wallets coinbase address will be used.`)
 example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20"}]'`)
 	sendCmd.Flags().StringP("password", "p", "", "Wallet password")
 	sendCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
-	sendCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
+	sendCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send, with optional target hours")
+	sendCmd.Flags().String("hours-mode", "", `Send target hours to each address, specified as "hours" in -m or as the third column in --csv.
+"exact" sends exactly the target hours, "minimum" sends at least the target hours.
+The remaining hours go to the change address.`)
 
 	return sendCmd
 }
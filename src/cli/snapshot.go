@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func exportSnapshotCmd() *cobra.Command {
+	exportSnapshotCmd := &cobra.Command{
+		Short: "Export the unspent outputs to a snapshot file",
+		Use:   "exportSnapshot [snapshot file] [db path]",
+		Long: `Writes the unspent outputs at a block height to a snapshot file, which can be
+    imported with importSnapshot to bootstrap a new node. Prints the checkpoint of the snapshot,
+    which must be given to importSnapshot. The node must not be running.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be used.`,
+		Args:         cobra.RangeArgs(1, 2),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			seq, err := c.Flags().GetUint64("seq")
+			if err != nil {
+				return err
+			}
+
+			dbPath := ""
+			if len(args) > 1 {
+				dbPath = args[1]
+			}
+
+			return exportSnapshot(args[0], dbPath, seq)
+		},
+	}
+
+	exportSnapshotCmd.Flags().Uint64P("seq", "s", 0, "block height of the snapshot. Defaults to the head block")
+
+	return exportSnapshotCmd
+}
+
+func exportSnapshot(snapshotPath, dbPath string, seq uint64) error {
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	wdb := wrapDB(db)
+
+	if seq == 0 {
+		seq, err = headSeq(wdb, pubkey)
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	checkpoint, err := visor.ExportSnapshot(wdb, pubkey, seq, f)
+	if err != nil {
+		return fmt.Errorf("export snapshot failed: %v", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported snapshot of block %d\n", checkpoint.Seq)
+	fmt.Printf("checkpoint: %s\n", checkpoint)
+	return nil
+}
+
+func headSeq(db *dbutil.DB, pubkey cipher.PubKey) (uint64, error) {
+	bc, err := visor.NewBlockchain(db, visor.BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return 0, err
+	}
+
+	var seq uint64
+	if err := db.View("headSeq", func(tx *dbutil.Tx) error {
+		var ok bool
+		var err error
+		seq, ok, err = bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.New("the blockchain is empty")
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+func importSnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Create a new database from a snapshot file",
+		Use:   "importSnapshot [snapshot file] [checkpoint] [db path]",
+		Long: `Creates a new database from a snapshot file written by exportSnapshot.
+    The snapshot is verified against the trusted checkpoint, formatted as seq:blockhash:uxhash,
+    and its genesis block must be the genesis block of the blockchain.
+    The node syncs the blocks after the snapshot from its peers. The blocks before the snapshot
+    are not available, so the node must be run with -prune-blocks.
+    If no db path is specified, the default data.db in $HOME/.$COIN/ will be created.`,
+		Args:                  cobra.RangeArgs(2, 3),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			dbPath := ""
+			if len(args) > 2 {
+				dbPath = args[2]
+			}
+
+			return importSnapshot(args[0], args[1], dbPath)
+		},
+	}
+}
+
+func importSnapshot(snapshotPath, checkpointStr, dbPath string) error {
+	checkpoint, err := visor.ParseSnapshotCheckpoint(checkpointStr)
+	if err != nil {
+		return err
+	}
+
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("db file: %v already exists", dbPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	genesisHash, err := cipher.SHA256FromHex(blockchainGenesisHash)
+	if err != nil {
+		return fmt.Errorf("decode blockchain genesis hash failed: %v", err)
+	}
+
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+
+	if err := visor.ImportSnapshot(wrapDB(db), pubkey, genesisHash, checkpoint, f); err != nil {
+		db.Close()
+		if rmErr := os.Remove(dbPath); rmErr != nil {
+			fmt.Fprintf(os.Stderr, "remove db file failed: %v\n", rmErr)
+		}
+		return fmt.Errorf("import snapshot failed: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported snapshot of block %d\n", checkpoint.Seq)
+	return nil
+}
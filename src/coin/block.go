@@ -60,6 +60,31 @@ func (b SignedBlock) VerifySignature(pubkey cipher.PubKey) error {
 	return cipher.VerifyPubKeySignedHash(pubkey, b.Sig, b.HashHeader())
 }
 
+// SignedHeader returns the header of the block with its signature
+func (b SignedBlock) SignedHeader() SignedBlockHeader {
+	return SignedBlockHeader{
+		Head: b.Head,
+		Sig:  b.Sig,
+	}
+}
+
+// SignedBlockHeader is a block header with the signature of its hash.
+// It can be verified without the block body.
+type SignedBlockHeader struct {
+	Head BlockHeader
+	Sig  cipher.Sig
+}
+
+// VerifySignature verifies that the block header is signed by pubkey
+func (h SignedBlockHeader) VerifySignature(pubkey cipher.PubKey) error {
+	return cipher.VerifyPubKeySignedHash(pubkey, h.Sig, h.Head.Hash())
+}
+
+// Seq returns the block seq
+func (h SignedBlockHeader) Seq() uint64 {
+	return h.Head.BkSeq
+}
+
 // NewBlock creates new block.
 func NewBlock(prev Block, currentTime uint64, uxHash cipher.SHA256, txns Transactions, calc FeeCalculator) (*Block, error) {
 	if len(txns) == 0 {
@@ -168,6 +193,11 @@ func (bb BlockBody) Hash() cipher.SHA256 {
 	return cipher.Merkle(hashes)
 }
 
+// TransactionProof returns the merkle proof that the i-th transaction is committed to by the body hash
+func (bb BlockBody) TransactionProof(i uint64) ([]cipher.SHA256, error) {
+	return cipher.MerkleProof(bb.Transactions.Hashes(), i)
+}
+
 // Size returns the size of Transactions, in bytes
 func (bb BlockBody) Size() (uint32, error) {
 	// We can't use length of self.Bytes() because it has a length prefix
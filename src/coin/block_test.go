@@ -108,6 +108,45 @@ func TestBlockBodyHash(t *testing.T) {
 	require.Equal(t, b.Body.Hash(), cipher.Merkle(hashes))
 }
 
+func TestBlockBodyTransactionProof(t *testing.T) {
+	uxHash := testutil.RandSHA256(t)
+	b := makeNewBlock(t, uxHash)
+	for i := 0; i < 4; i++ {
+		addTransactionToBlock(t, b)
+	}
+
+	for i, txn := range b.Body.Transactions {
+		proof, err := b.Body.TransactionProof(uint64(i))
+		require.NoError(t, err)
+		require.NoError(t, cipher.VerifyMerkleProof(b.Body.Hash(), txn.Hash(), uint64(i), proof))
+	}
+
+	_, err := b.Body.TransactionProof(uint64(len(b.Body.Transactions)))
+	require.Equal(t, cipher.ErrMerkleIndexOutOfRange, err)
+}
+
+func TestSignedBlockHeader(t *testing.T) {
+	pubkey, seckey := cipher.GenerateKeyPair()
+	b := makeNewBlock(t, testutil.RandSHA256(t))
+	sb := SignedBlock{
+		Block: *b,
+		Sig:   cipher.MustSignHash(b.HashHeader(), seckey),
+	}
+	require.NoError(t, sb.VerifySignature(pubkey))
+
+	h := sb.SignedHeader()
+	require.Equal(t, sb.Head, h.Head)
+	require.Equal(t, sb.Sig, h.Sig)
+	require.Equal(t, sb.Seq(), h.Seq())
+	require.NoError(t, h.VerifySignature(pubkey))
+
+	otherPubkey, _ := cipher.GenerateKeyPair()
+	require.Error(t, h.VerifySignature(otherPubkey))
+
+	h.Head.Fee++
+	require.Error(t, h.VerifySignature(pubkey))
+}
+
 func TestNewGenesisBlock(t *testing.T) {
 	gb, err := NewGenesisBlock(genAddress, _genCoins, _genTime)
 	require.NoError(t, err)
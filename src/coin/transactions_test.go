@@ -230,6 +230,29 @@ func TestTransactionVerifyUnsigned(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTransactionVerifyAssumeSigned(t *testing.T) {
+	// The signatures are not verified
+	badSig := "9a0f86874a4d9541f58a1de4db1c1b58765a868dc6f027445d0a2a8a7bddd1c45ea559fcd7bef45e1b76ccdaf8e50bbebd952acbbea87d1cb3f7a964bc89bf1ed5"
+	txn := makeTransaction(t)
+	txn.Sigs[0] = cipher.MustSigFromHex(badSig)
+	testutil.RequireError(t, txn.Verify(), "Failed to recover pubkey from signature")
+	require.NoError(t, txn.VerifyAssumeSigned())
+
+	// Null signatures are not allowed
+	txn = makeTransaction(t)
+	txn.Sigs[0] = cipher.Sig{}
+	testutil.RequireError(t, txn.VerifyAssumeSigned(), "Unsigned input in transaction")
+
+	// The inner hash is verified
+	txn = makeTransaction(t)
+	txn.InnerHash = cipher.SHA256{}
+	testutil.RequireError(t, txn.VerifyAssumeSigned(), "InnerHash does not match computed hash")
+
+	// Valid
+	txn = makeTransaction(t)
+	require.NoError(t, txn.VerifyAssumeSigned())
+}
+
 func TestTransactionVerifyInput(t *testing.T) {
 	// Invalid uxIn args
 	txn := makeTransaction(t)
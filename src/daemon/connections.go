@@ -65,6 +65,8 @@ type ConnectionDetails struct {
 	UserAgent            useragent.Data
 	UnconfirmedVerifyTxn params.VerifyTxn
 	GenesisHash          cipher.SHA256
+	PruneBlocks          uint64
+	LightClient          bool
 }
 
 // HasIntroduced returns true if the connection has introduced
@@ -77,6 +79,31 @@ func (c ConnectionDetails) HasIntroduced() bool {
 	}
 }
 
+// canServeBlocksAfter returns false if the peer is known to have pruned the body of the block after seq,
+// or if the peer is a light client that has no blocks
+func (c ConnectionDetails) canServeBlocksAfter(seq uint64) bool {
+	if c.LightClient {
+		return false
+	}
+
+	if c.PruneBlocks == 0 || c.Height <= c.PruneBlocks {
+		return true
+	}
+
+	return seq >= c.Height-c.PruneBlocks
+}
+
+// canServeHeaders returns true if the peer can respond to a GetHeadersMessage
+func (c ConnectionDetails) canServeHeaders() bool {
+	return !c.LightClient && c.ProtocolVersion >= lightClientProtocolVersion
+}
+
+// canServeUxOuts returns true if the peer can respond to a GetUxOutsMessage.
+// Pruned peers are skipped, they can't prove the outputs created in pruned blocks.
+func (c ConnectionDetails) canServeUxOuts() bool {
+	return c.canServeHeaders() && c.PruneBlocks == 0
+}
+
 type connection struct {
 	Addr string
 	ConnectionDetails
@@ -300,6 +327,8 @@ func (c *Connections) introduced(addr string, gnetID uint64, m *IntroductionMess
 	conn.UserAgent = m.UserAgent
 	conn.UnconfirmedVerifyTxn = m.UnconfirmedVerifyTxn
 	conn.GenesisHash = m.GenesisHash
+	conn.PruneBlocks = m.PruneBlocks
+	conn.LightClient = m.LightClient
 
 	if !conn.Outgoing {
 		listenAddr := conn.ListenAddr()
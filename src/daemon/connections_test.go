@@ -448,6 +448,64 @@ func TestConnectionsSetHeight(t *testing.T) {
 	require.Equal(t, height, c.Height)
 }
 
+func TestConnectionDetailsCanServeBlocksAfter(t *testing.T) {
+	cases := []struct {
+		name        string
+		height      uint64
+		pruneBlocks uint64
+		lightClient bool
+		seq         uint64
+		ok          bool
+	}{
+		{name: "not pruned", height: 1000, pruneBlocks: 0, seq: 0, ok: true},
+		{name: "pruned peer below keep count", height: 100, pruneBlocks: 200, seq: 0, ok: true},
+		{name: "pruned peer has blocks", height: 1000, pruneBlocks: 200, seq: 800, ok: true},
+		{name: "pruned peer has later blocks", height: 1000, pruneBlocks: 200, seq: 900, ok: true},
+		{name: "pruned peer discarded blocks", height: 1000, pruneBlocks: 200, seq: 799, ok: false},
+		{name: "pruned peer discarded all blocks after genesis", height: 1000, pruneBlocks: 200, seq: 0, ok: false},
+		{name: "light client peer", height: 1000, lightClient: true, seq: 900, ok: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ConnectionDetails{
+				Height:      tc.height,
+				PruneBlocks: tc.pruneBlocks,
+				LightClient: tc.lightClient,
+			}
+			require.Equal(t, tc.ok, c.canServeBlocksAfter(tc.seq))
+		})
+	}
+}
+
+func TestConnectionDetailsCanServeLightClient(t *testing.T) {
+	cases := []struct {
+		name            string
+		protocolVersion int32
+		pruneBlocks     uint64
+		lightClient     bool
+		headers         bool
+		uxOuts          bool
+	}{
+		{name: "full peer", protocolVersion: 3, headers: true, uxOuts: true},
+		{name: "old protocol version", protocolVersion: 2, headers: false, uxOuts: false},
+		{name: "pruned peer", protocolVersion: 3, pruneBlocks: 1000, headers: true, uxOuts: false},
+		{name: "light client peer", protocolVersion: 3, lightClient: true, headers: false, uxOuts: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := ConnectionDetails{
+				ProtocolVersion: tc.protocolVersion,
+				PruneBlocks:     tc.pruneBlocks,
+				LightClient:     tc.lightClient,
+			}
+			require.Equal(t, tc.headers, c.canServeHeaders())
+			require.Equal(t, tc.uxOuts, c.canServeUxOuts())
+		})
+	}
+}
+
 func TestConnectionsModifyMirrorPanics(t *testing.T) {
 	conns := NewConnections()
 	addr := "127.0.0.1:6060"
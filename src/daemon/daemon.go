@@ -19,6 +19,7 @@ import (
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/daemon/gnet"
 	"github.com/skycoin/skycoin/src/daemon/pex"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/elapse"
 	"github.com/skycoin/skycoin/src/util/fee"
@@ -54,6 +55,9 @@ func IsBroadcastFailure(err error) bool {
 
 const (
 	daemonRunDurationThreshold = time.Millisecond * 200
+
+	// lightClientProtocolVersion is the first protocol version that serves light clients
+	lightClientProtocolVersion = 3
 )
 
 // Config subsystem configurations
@@ -163,6 +167,10 @@ type DaemonConfig struct { // nolint: golint
 	BlockchainPubkey cipher.PubKey
 	// GenesisHash genesis block hash
 	GenesisHash cipher.SHA256
+	// Number of recent blocks whose bodies are kept by this node, 0 if all blocks are kept
+	PruneBlocks uint64
+	// Run as a header-only light client
+	LightClient bool
 	// TCP/UDP port for connections
 	Port int
 	// Directory where application data is stored
@@ -205,6 +213,10 @@ type DaemonConfig struct { // nolint: golint
 	GetBlocksRequestCount uint64
 	// Maximum number of blocks to respond with to a GetBlocksMessage
 	MaxGetBlocksResponseCount uint64
+	// How many headers to request in a GetHeadersMessage
+	GetHeadersRequestCount uint64
+	// Maximum number of headers to respond with to a GetHeadersMessage
+	MaxGetHeadersResponseCount uint64
 	// Max announce txns hash number
 	MaxTxnAnnounceNum int
 	// How often new blocks are created by the signing node, in seconds
@@ -228,12 +240,14 @@ type DaemonConfig struct { // nolint: golint
 	MaxOutgoingMessageLength uint64
 	// Maximum total size of transactions in a block
 	MaxBlockTransactionsSize uint32
+	// Peer connection events are published to EventBus, if set
+	EventBus *eventbus.Bus
 }
 
 // NewDaemonConfig creates daemon config
 func NewDaemonConfig() DaemonConfig {
 	return DaemonConfig{
-		ProtocolVersion:              2,
+		ProtocolVersion:              3,
 		MinProtocolVersion:           2,
 		Address:                      "",
 		Port:                         6677,
@@ -256,6 +270,8 @@ func NewDaemonConfig() DaemonConfig {
 		BlocksAnnounceRate:           time.Second * 60,
 		GetBlocksRequestCount:        20,
 		MaxGetBlocksResponseCount:    20,
+		GetHeadersRequestCount:       500,
+		MaxGetHeadersResponseCount:   1000,
 		MaxTxnAnnounceNum:            16,
 		BlockCreationInterval:        10,
 		UnconfirmedRefreshRate:       time.Minute,
@@ -282,9 +298,15 @@ type daemoner interface {
 	getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, error)
 	headBkSeq() (uint64, bool, error)
 	executeSignedBlock(b coin.SignedBlock) error
+	verifyPendingBlockSignatures(blocks []coin.SignedBlock) error
 	filterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error)
 	getKnownUnconfirmed(txns []cipher.SHA256) (coin.Transactions, error)
 	requestBlocksFromAddr(addr string) error
+	requestUxOutsFromAddr(addr string) error
+	getSignedBlockHeadersSince(seq, count uint64) ([]coin.SignedBlockHeader, error)
+	executeSignedBlockHeaders(headers []coin.SignedBlockHeader) (int, error)
+	getUxOutProofs(addrs []cipher.Address) ([]visor.UxOutProof, error)
+	setWatchedUxOuts(addrs []cipher.Address, proofs []visor.UxOutProof) error
 	announceAllValidTxns() error
 	pexConfig() pex.Config
 	injectTransaction(txn coin.Transaction) (bool, *visor.ErrTxnViolatesSoftConstraint, error)
@@ -626,7 +648,10 @@ loop:
 			elapser.Register("blockCreationTicker.C")
 			if dm.visor.Config.IsBlockPublisher {
 				sb, err := dm.createAndPublishBlock()
-				if err != nil {
+				if err == visor.ErrPartialBlockHeld {
+					logger.WithError(err).Debug("Block not created")
+					continue
+				} else if err != nil {
 					logger.WithError(err).Error("Failed to create and publish block")
 					continue
 				}
@@ -655,6 +680,11 @@ loop:
 
 		case <-unconfirmedRemoveInvalidTicker.C:
 			elapser.Register("unconfirmedRemoveInvalidTicker")
+			// Remove expired transactions, and evict the lowest fee transactions if the pool exceeds its limits
+			if _, err := dm.visor.EvictUnconfirmed(); err != nil {
+				logger.WithError(err).Error("dm.Visor.EvictUnconfirmed failed")
+			}
+
 			// Remove transactions that become invalid (violating hard constraints)
 			removedTxns, err := dm.visor.RemoveInvalidUnconfirmed()
 			if err != nil {
@@ -969,6 +999,8 @@ func (dm *Daemon) onConnectEvent(e ConnectEvent) {
 		dm.config.userAgent,
 		dm.config.UnconfirmedVerifyTxn,
 		dm.config.GenesisHash,
+		dm.config.PruneBlocks,
+		dm.config.LightClient,
 	)); err != nil {
 		logger.WithFields(fields).WithError(err).Error("Send IntroductionMessage failed")
 		return
@@ -983,11 +1015,21 @@ func (dm *Daemon) onDisconnectEvent(e DisconnectEvent) {
 	}
 	logger.WithFields(fields).Info("onDisconnectEvent")
 
+	c := dm.connections.get(e.Addr)
+	introduced := c != nil && c.gnetID == e.GnetID && c.HasIntroduced()
+
 	if err := dm.connections.remove(e.Addr, e.GnetID); err != nil {
 		logger.WithError(err).WithFields(fields).Error("connections.Remove failed")
 		return
 	}
 
+	if introduced {
+		dm.config.EventBus.Publish(eventbus.PeerDisconnected{
+			Addr:   e.Addr,
+			Reason: e.Reason.Error(),
+		})
+	}
+
 	// TODO -- blacklist peer for certain reasons, not just remove
 	switch e.Reason {
 	case ErrDisconnectIntroductionTimeout,
@@ -1096,12 +1138,17 @@ func (dm *Daemon) handleMessageSendResult(r gnet.SendResult) {
 	}
 }
 
-// requestBlocks sends a GetBlocksMessage to all connections
+// requestBlocks sends a GetBlocksMessage to all connections.
+// A light client sends a GetHeadersMessage instead.
 func (dm *Daemon) requestBlocks() error {
 	if dm.config.DisableNetworking {
 		return ErrNetworkingDisabled
 	}
 
+	if dm.config.LightClient {
+		return dm.requestHeaders()
+	}
+
 	headSeq, ok, err := dm.visor.HeadBkSeq()
 	if err != nil {
 		return err
@@ -1112,7 +1159,16 @@ func (dm *Daemon) requestBlocks() error {
 
 	m := NewGetBlocksMessage(headSeq, dm.config.GetBlocksRequestCount)
 
-	if _, err := dm.broadcastMessage(m); err != nil {
+	// Don't request blocks from pruned peers that no longer have the bodies of the blocks we need
+	conns := dm.connections.all()
+	var addrs []string
+	for _, c := range conns {
+		if c.HasIntroduced() && c.canServeBlocksAfter(headSeq) {
+			addrs = append(addrs, c.Addr)
+		}
+	}
+
+	if _, err := dm.pool.Pool.BroadcastMessage(m, addrs); err != nil {
 		logger.WithError(err).Debug("Broadcast GetBlocksMessage failed")
 		return err
 	}
@@ -1126,6 +1182,11 @@ func (dm *Daemon) announceBlocks() error {
 		return ErrNetworkingDisabled
 	}
 
+	// A light client has no blocks to give to peers
+	if dm.config.LightClient {
+		return nil
+	}
+
 	headSeq, ok, err := dm.visor.HeadBkSeq()
 	if err != nil {
 		return err
@@ -1330,12 +1391,17 @@ func (dm *Daemon) Disconnect(addr string, r gnet.DisconnectReason) error {
 
 // Implements private daemoner interface methods:
 
-// requestBlocksFromAddr sends a GetBlocksMessage to one connected address
+// requestBlocksFromAddr sends a GetBlocksMessage to one connected address.
+// A light client sends a GetHeadersMessage and a GetUxOutsMessage instead, if the peer can serve them.
 func (dm *Daemon) requestBlocksFromAddr(addr string) error {
 	if dm.config.DisableNetworking {
 		return ErrNetworkingDisabled
 	}
 
+	if dm.config.LightClient {
+		return dm.requestHeadersFromAddr(addr)
+	}
+
 	headSeq, ok, err := dm.visor.HeadBkSeq()
 	if err != nil {
 		return err
@@ -1348,6 +1414,98 @@ func (dm *Daemon) requestBlocksFromAddr(addr string) error {
 	return dm.sendMessage(addr, m)
 }
 
+// requestHeaders sends a GetHeadersMessage to all connections that can serve headers
+func (dm *Daemon) requestHeaders() error {
+	headSeq, ok, err := dm.visor.LightHeadBkSeq()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Cannot request headers, there is no head header")
+	}
+
+	m := NewGetHeadersMessage(headSeq, dm.config.GetHeadersRequestCount)
+
+	conns := dm.connections.all()
+	var addrs []string
+	for _, c := range conns {
+		if c.HasIntroduced() && c.canServeHeaders() {
+			addrs = append(addrs, c.Addr)
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	if _, err := dm.pool.Pool.BroadcastMessage(m, addrs); err != nil {
+		logger.WithError(err).Debug("Broadcast GetHeadersMessage failed")
+		return err
+	}
+
+	return nil
+}
+
+// requestHeadersFromAddr sends a GetHeadersMessage and a GetUxOutsMessage to one connected address,
+// if it can serve them
+func (dm *Daemon) requestHeadersFromAddr(addr string) error {
+	c := dm.connections.get(addr)
+	if c == nil {
+		return ErrConnectionNotExist
+	}
+
+	if !c.canServeHeaders() {
+		return nil
+	}
+
+	headSeq, ok, err := dm.visor.LightHeadBkSeq()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Cannot request headers from addr, there is no head header")
+	}
+
+	if err := dm.sendMessage(addr, NewGetHeadersMessage(headSeq, dm.config.GetHeadersRequestCount)); err != nil {
+		return err
+	}
+
+	return dm.requestUxOutsFromAddr(addr)
+}
+
+// requestUxOutsFromAddr sends GetUxOutsMessages for the watched addresses of the light client to one connected address,
+// if it can serve them
+func (dm *Daemon) requestUxOutsFromAddr(addr string) error {
+	if dm.config.DisableNetworking {
+		return ErrNetworkingDisabled
+	}
+
+	c := dm.connections.get(addr)
+	if c == nil {
+		return ErrConnectionNotExist
+	}
+
+	if !c.canServeUxOuts() {
+		return nil
+	}
+
+	addrs := dm.visor.LightWatchedAddresses()
+	for len(addrs) > 0 {
+		n := len(addrs)
+		if n > 256 {
+			n = 256
+		}
+
+		if err := dm.sendMessage(addr, NewGetUxOutsMessage(addrs[:n])); err != nil {
+			return err
+		}
+
+		addrs = addrs[n:]
+	}
+
+	return nil
+}
+
 // broadcastBlock sends a signed block to all connections
 func (dm *Daemon) broadcastBlock(sb coin.SignedBlock) error {
 	if dm.config.DisableNetworking {
@@ -1408,6 +1566,12 @@ func (dm *Daemon) connectionIntroduced(addr string, gnetID uint64, m *Introducti
 
 	dm.pex.ResetRetryTimes(listenAddr)
 
+	dm.config.EventBus.Publish(eventbus.PeerConnected{
+		Addr:      addr,
+		Outgoing:  c.Outgoing,
+		UserAgent: c.UserAgent,
+	})
+
 	return c, nil
 }
 
@@ -1540,16 +1704,45 @@ func (dm *Daemon) getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, e
 	return dm.visor.GetSignedBlocksSince(seq, count)
 }
 
-// headBkSeq returns the head block sequence
+// headBkSeq returns the head block sequence, or the sequence of the most recent synced header of a light client
 func (dm *Daemon) headBkSeq() (uint64, bool, error) {
+	if dm.config.LightClient {
+		return dm.visor.LightHeadBkSeq()
+	}
 	return dm.visor.HeadBkSeq()
 }
 
+// getSignedBlockHeadersSince returns N signed block headers since given seq
+func (dm *Daemon) getSignedBlockHeadersSince(seq, count uint64) ([]coin.SignedBlockHeader, error) {
+	return dm.visor.GetSignedBlockHeadersSince(seq, count)
+}
+
+// executeSignedBlockHeaders adds the signed block headers to the light client
+func (dm *Daemon) executeSignedBlockHeaders(headers []coin.SignedBlockHeader) (int, error) {
+	return dm.visor.ExecuteSignedBlockHeaders(headers)
+}
+
+// getUxOutProofs returns the proofs of the unspent outputs of addrs
+func (dm *Daemon) getUxOutProofs(addrs []cipher.Address) ([]visor.UxOutProof, error) {
+	proofs, _, err := dm.visor.GetUxOutProofs(addrs)
+	return proofs, err
+}
+
+// setWatchedUxOuts replaces the outputs of watched addresses of the light client
+func (dm *Daemon) setWatchedUxOuts(addrs []cipher.Address, proofs []visor.UxOutProof) error {
+	return dm.visor.SetWatchedUxOuts(addrs, proofs)
+}
+
 // executeSignedBlock executes the signed block
 func (dm *Daemon) executeSignedBlock(b coin.SignedBlock) error {
 	return dm.visor.ExecuteSignedBlock(b)
 }
 
+// verifyPendingBlockSignatures verifies the transaction signatures of blocks that are about to be executed
+func (dm *Daemon) verifyPendingBlockSignatures(blocks []coin.SignedBlock) error {
+	return dm.visor.VerifyPendingBlockSignatures(blocks)
+}
+
 // filterKnownUnconfirmed returns unconfirmed txn hashes with known ones removed
 func (dm *Daemon) filterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	return dm.visor.FilterKnownUnconfirmed(txns)
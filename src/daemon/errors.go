@@ -47,6 +47,11 @@ var (
 	ErrDisconnectInvalidMaxTransactionSize gnet.DisconnectReason = errors.New("Invalid max transaction size in introduction message")
 	// ErrDisconnectInvalidMaxDropletPrecision invalid max droplet precision in introduction message
 	ErrDisconnectInvalidMaxDropletPrecision gnet.DisconnectReason = errors.New("Invalid max droplet precision in introduction message")
+	// ErrDisconnectCheckpointMismatch the peer sent a block that conflicts with a checkpoint
+	ErrDisconnectCheckpointMismatch gnet.DisconnectReason = errors.New("Block conflicts with a checkpoint")
+	// ErrDisconnectLightHeaderFork the peer sent a header that forks from the headers synced by a light client
+	// more than blockdb.MaxRollbackDepth headers behind the head header
+	ErrDisconnectLightHeaderFork gnet.DisconnectReason = errors.New("Header conflicts with the synced headers")
 
 	// ErrDisconnectUnknownReason used when mapping an unknown reason code to an error. Is not sent over the network.
 	ErrDisconnectUnknownReason gnet.DisconnectReason = errors.New("Unknown DisconnectReason")
@@ -73,6 +78,8 @@ var (
 		ErrDisconnectInvalidBurnFactor:             17,
 		ErrDisconnectInvalidMaxTransactionSize:     18,
 		ErrDisconnectInvalidMaxDropletPrecision:    19,
+		ErrDisconnectCheckpointMismatch:            20,
+		ErrDisconnectLightHeaderFork:               21,
 
 		// gnet codes are registered here, but they are not sent in a DISC
 		// message by gnet. Only daemon sends a DISC packet.
@@ -17,6 +17,7 @@ import (
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/iputil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor"
 )
 
 // Message represent a packet to be serialized over the network by
@@ -52,10 +53,16 @@ func NewMessageConfig(prefix string, m interface{}) MessageConfig {
 //go:generate skyencoder -unexported -struct GetTxnsMessage
 //go:generate skyencoder -unexported -struct GiveTxnsMessage
 //go:generate skyencoder -unexported -struct AnnounceTxnsMessage
+//go:generate skyencoder -unexported -struct GetHeadersMessage
+//go:generate skyencoder -unexported -struct GiveHeadersMessage
+//go:generate skyencoder -unexported -struct GetUxOutsMessage
+//go:generate skyencoder -unexported -struct GiveUxOutsMessage
 //go:generate skyencoder -unexported -struct DisconnectMessage
 //go:generate skyencoder -unexported -struct IPAddr
 //go:generate skyencoder -unexported -output-path . -package daemon -struct SignedBlock github.com/skycoin/skycoin/src/coin
 //go:generate skyencoder -unexported -output-path . -package daemon -struct Transaction github.com/skycoin/skycoin/src/coin
+//go:generate skyencoder -unexported -output-path . -package daemon -struct SignedBlockHeader github.com/skycoin/skycoin/src/coin
+//go:generate skyencoder -unexported -output-path . -package daemon -struct UxOutProof github.com/skycoin/skycoin/src/visor
 
 // Creates and populates the message configs
 func getMessageConfigs() []MessageConfig {
@@ -72,6 +79,10 @@ func getMessageConfigs() []MessageConfig {
 		NewMessageConfig("GIVT", GiveTxnsMessage{}),
 		NewMessageConfig("ANNT", AnnounceTxnsMessage{}),
 		NewMessageConfig("DISC", DisconnectMessage{}),
+		NewMessageConfig("GETH", GetHeadersMessage{}),
+		NewMessageConfig("GIVH", GiveHeadersMessage{}),
+		NewMessageConfig("GETU", GetUxOutsMessage{}),
+		NewMessageConfig("GIVU", GiveUxOutsMessage{}),
 	}
 }
 
@@ -329,6 +340,8 @@ type IntroductionMessage struct {
 	UserAgent            useragent.Data       `enc:"-"`
 	UnconfirmedVerifyTxn params.VerifyTxn     `enc:"-"`
 	GenesisHash          cipher.SHA256        `enc:"-"`
+	PruneBlocks          uint64               `enc:"-"`
+	LightClient          bool                 `enc:"-"`
 
 	// Mirror is a random value generated on client startup that is used to identify self-connections
 	Mirror uint32
@@ -348,20 +361,22 @@ type IntroductionMessage struct {
 	// MaxDropletPrecision uint8 // maximum number of decimal places for announced txns
 	// UserAgent           string `enc:",maxlen=256"`
 	// GenesisHash         cipher.SHA256 // genesis block hash
+	// PruneBlocks         uint64 // number of recent blocks whose bodies are kept, 0 if the peer keeps all blocks
+	// LightClient         bool // true if the peer is a header-only light client that has no blocks
 	Extra []byte `enc:",omitempty"`
 }
 
 // NewIntroductionMessage creates introduction message
-func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256) *IntroductionMessage {
+func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256, pruneBlocks uint64, lightClient bool) *IntroductionMessage {
 	return &IntroductionMessage{
 		Mirror:          mirror,
 		ProtocolVersion: version,
 		ListenPort:      port,
-		Extra:           newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash),
+		Extra:           newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash, pruneBlocks, lightClient),
 	}
 }
 
-func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256) []byte {
+func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256, pruneBlocks uint64, lightClient bool) []byte {
 	if len(userAgent) > useragent.MaxLen {
 		logger.WithFields(logrus.Fields{
 			"userAgent": userAgent,
@@ -380,7 +395,10 @@ func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyP
 	userAgentSerialized := encoder.SerializeString(userAgent)
 	verifyParamsSerialized := encoder.Serialize(verifyParams)
 
-	extra := make([]byte, len(pubkey)+len(userAgentSerialized)+len(verifyParamsSerialized)+len(genesisHash))
+	pruneBlocksSerialized := encoder.SerializeAtomic(pruneBlocks)
+	lightClientSerialized := encoder.SerializeAtomic(lightClient)
+
+	extra := make([]byte, len(pubkey)+len(userAgentSerialized)+len(verifyParamsSerialized)+len(genesisHash)+len(pruneBlocksSerialized)+len(lightClientSerialized))
 
 	copy(extra[:len(pubkey)], pubkey[:])
 	i := len(pubkey)
@@ -389,6 +407,10 @@ func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyP
 	copy(extra[i:], userAgentSerialized)
 	i += len(userAgentSerialized)
 	copy(extra[i:i+len(genesisHash)], genesisHash[:])
+	i += len(genesisHash)
+	copy(extra[i:], pruneBlocksSerialized)
+	i += len(pruneBlocksSerialized)
+	copy(extra[i:], lightClientSerialized)
 
 	return extra
 }
@@ -508,6 +530,8 @@ func (intro *IntroductionMessage) Verify(dc DaemonConfig, logFields logrus.Field
 	// v26 would check the blockchain pubkey and reject if not matched or not provided, and parses a user agent
 	// v26 adds genesis hash
 	// v27 would require and check the genesis hash
+	// v27 adds the number of block bodies kept by a pruned peer
+	// v28 adds the light client flag
 	extraLen := len(intro.Extra)
 	if extraLen == 0 {
 		logger.WithFields(logFields).Warning("Blockchain pubkey is not provided")
@@ -579,6 +603,31 @@ func (intro *IntroductionMessage) Verify(dc DaemonConfig, logFields logrus.Field
 		return ErrDisconnectInvalidExtraData
 	}
 	copy(intro.GenesisHash[:], intro.Extra[i:])
+	i += len(intro.GenesisHash)
+
+	remainingLen = extraLen - i
+	if remainingLen > 0 {
+		if remainingLen < 8 {
+			logger.WithFields(logFields).Warning("Extra data prune blocks could not be deserialized: not enough data")
+			return ErrDisconnectInvalidExtraData
+		}
+
+		if _, err := encoder.DeserializeAtomic(intro.Extra[i:i+8], &intro.PruneBlocks); err != nil {
+			// This should not occur due to the previous length check
+			logger.Critical().WithError(err).WithFields(logFields).Warning("Extra data prune blocks could not be deserialized")
+			return ErrDisconnectInvalidExtraData
+		}
+		i += 8
+	}
+
+	remainingLen = extraLen - i
+	if remainingLen > 0 {
+		if _, err := encoder.DeserializeAtomic(intro.Extra[i:i+1], &intro.LightClient); err != nil {
+			// This should not occur due to the previous length check
+			logger.Critical().WithError(err).WithFields(logFields).Warning("Extra data light client flag could not be deserialized")
+			return ErrDisconnectInvalidExtraData
+		}
+	}
 
 	return nil
 }
@@ -881,6 +930,11 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	// A light client only syncs the block headers
+	if d.DaemonConfig().LightClient {
+		return
+	}
+
 	// These DB queries are not performed in a transaction for performance reasons.
 	// It is not necessary that the blocks be executed together in a single transaction.
 
@@ -895,6 +949,20 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	// Verify the transaction signatures of the pending blocks together, before executing them one by one.
+	// Only the blocks signed by the block publisher that chain from the head block are verified
+	var pending []coin.SignedBlock
+	for _, b := range m.Blocks {
+		if b.Seq() > maxSeq {
+			pending = append(pending, b)
+		}
+	}
+	if len(pending) != 0 {
+		if err := d.verifyPendingBlockSignatures(pending); err != nil {
+			logger.WithError(err).Error("d.verifyPendingBlockSignatures failed")
+		}
+	}
+
 	for _, b := range m.Blocks {
 		// To minimize waste when receiving multiple responses from peers
 		// we only break out of the loop if the block itself is invalid.
@@ -912,6 +980,15 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 			processed++
 		} else {
 			logger.Critical().WithError(err).WithField("seq", b.Block.Head.BkSeq).Error("Failed to execute received block")
+
+			// The peer is on a chain that conflicts with a checkpoint
+			if _, ok := err.(visor.ErrCheckpointMismatch); ok {
+				if err := d.Disconnect(m.c.Addr, ErrDisconnectCheckpointMismatch); err != nil {
+					logger.WithError(err).WithField("addr", m.c.Addr).Warning("Disconnect")
+				}
+				return
+			}
+
 			// Blocks must be received in order, so if one fails its assumed
 			// the rest are failing
 			break
@@ -1010,6 +1087,14 @@ func (abm *AnnounceBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	if d.DaemonConfig().LightClient {
+		m := NewGetHeadersMessage(headBkSeq, d.DaemonConfig().GetHeadersRequestCount)
+		if err := d.sendMessage(abm.c.Addr, m); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Send GetHeadersMessage")
+		}
+		return
+	}
+
 	// TODO: Should this be block get request for current sequence?
 	// If client is not caught up, won't attempt to get block
 	m := NewGetBlocksMessage(headBkSeq, d.DaemonConfig().GetBlocksRequestCount)
@@ -1127,6 +1212,11 @@ func (atm *AnnounceTxnsMessage) process(d daemoner) {
 		return
 	}
 
+	// A light client has no unspent pool to verify transactions with
+	if dc.LightClient {
+		return
+	}
+
 	fields := logrus.Fields{
 		"addr":   atm.c.Addr,
 		"gnetID": atm.c.ConnID,
@@ -1351,6 +1441,11 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		return
 	}
 
+	// A light client has no unspent pool to verify transactions with
+	if dc.LightClient {
+		return
+	}
+
 	hashes := make([]cipher.SHA256, 0, len(gtm.Transactions))
 	// Update unconfirmed pool with these transactions
 	for _, txn := range gtm.Transactions {
@@ -1388,3 +1483,448 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		logger.Debugf("Announced %d transactions to %d peers", len(hashes), len(ids))
 	}
 }
+
+// GetHeadersMessage is sent by a light client to request the signed block headers since LastBlock
+type GetHeadersMessage struct {
+	LastBlock        uint64
+	RequestedHeaders uint64
+	c                *gnet.MessageContext `enc:"-"`
+}
+
+// NewGetHeadersMessage creates GetHeadersMessage
+func NewGetHeadersMessage(lastBlock, requestedHeaders uint64) *GetHeadersMessage {
+	return &GetHeadersMessage{
+		LastBlock:        lastBlock,
+		RequestedHeaders: requestedHeaders,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GetHeadersMessage) EncodeSize() uint64 {
+	return encodeSizeGetHeadersMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GetHeadersMessage) Encode(buf []byte) error {
+	return encodeGetHeadersMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GetHeadersMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGetHeadersMessage(buf, m)
+}
+
+// Handle handles message
+func (m *GetHeadersMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process replies with the signed block headers since LastBlock
+func (m *GetHeadersMessage) process(d daemoner) {
+	dc := d.DaemonConfig()
+	if dc.DisableNetworking || dc.LightClient {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":   m.c.Addr,
+		"gnetID": m.c.ConnID,
+	}
+
+	// Record this as this peer's highest block
+	d.recordPeerHeight(m.c.Addr, m.c.ConnID, m.LastBlock)
+
+	requestedHeaders := m.RequestedHeaders
+	if requestedHeaders > dc.MaxGetHeadersResponseCount {
+		logger.WithFields(logrus.Fields{
+			"requestedHeaders":    requestedHeaders,
+			"maxRequestedHeaders": dc.MaxGetHeadersResponseCount,
+		}).WithFields(fields).Debug("GetHeadersMessage.RequestedHeaders value exceeds configured limit, reducing")
+		requestedHeaders = dc.MaxGetHeadersResponseCount
+	}
+
+	headers, err := d.getSignedBlockHeadersSince(m.LastBlock, requestedHeaders)
+	if err != nil {
+		logger.WithFields(fields).WithError(err).Error("getSignedBlockHeadersSince failed")
+		return
+	}
+
+	if len(headers) == 0 {
+		return
+	}
+
+	logger.WithFields(fields).Debugf("GetHeadersMessage: replying with %d headers after block %d", len(headers), m.LastBlock)
+
+	gm := NewGiveHeadersMessage(headers, dc.MaxOutgoingMessageLength)
+	if len(gm.Headers) != len(headers) {
+		logger.WithField("startBlockSeq", headers[0].Seq()).WithFields(fields).Warningf("NewGiveHeadersMessage truncated %d headers to %d headers", len(headers), len(gm.Headers))
+	}
+
+	if err := d.sendMessage(m.c.Addr, gm); err != nil {
+		logger.WithFields(fields).WithError(err).Error("Send GiveHeadersMessage failed")
+	}
+}
+
+// GiveHeadersMessage is sent in response to GetHeadersMessage
+type GiveHeadersMessage struct {
+	Headers []coin.SignedBlockHeader `enc:",maxlen=1024"`
+	c       *gnet.MessageContext     `enc:"-"`
+}
+
+// NewGiveHeadersMessage creates GiveHeadersMessage.
+// If the size of message would exceed maxMsgLength, the header slice is truncated.
+func NewGiveHeadersMessage(headers []coin.SignedBlockHeader, maxMsgLength uint64) *GiveHeadersMessage {
+	if len(headers) > 1024 {
+		headers = headers[:1024]
+	}
+	m := &GiveHeadersMessage{
+		Headers: headers,
+	}
+	truncateGiveHeadersMessage(m, maxMsgLength)
+	return m
+}
+
+// truncateGiveHeadersMessage truncates the headers in GiveHeadersMessage to fit inside of MaxOutgoingMessageLength
+func truncateGiveHeadersMessage(m *GiveHeadersMessage, maxMsgLength uint64) {
+	// The message length will include a 4 byte message type prefix.
+	// Panic if the prefix can't fit, otherwise we can't adjust the uint64 safely
+	if maxMsgLength < 4 {
+		logger.Panic("maxMsgLength must be >= 4")
+	}
+
+	maxMsgLength -= 4
+
+	// Measure the current message size, if it fits, return
+	n := m.EncodeSize()
+	if n <= maxMsgLength {
+		return
+	}
+
+	// Measure the size of an empty message
+	var mm GiveHeadersMessage
+	size := mm.EncodeSize()
+
+	// Measure the size of the headers, advancing the slice index until it reaches capacity
+	index := -1
+	for i, h := range m.Headers {
+		x := encodeSizeSignedBlockHeader(&h)
+		if size+x > maxMsgLength {
+			break
+		}
+		size += x
+		index = i
+	}
+
+	m.Headers = m.Headers[:index+1]
+
+	if len(m.Headers) == 0 {
+		logger.Critical().Error("truncateGiveHeadersMessage truncated headers to an empty slice")
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GiveHeadersMessage) EncodeSize() uint64 {
+	return encodeSizeGiveHeadersMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GiveHeadersMessage) Encode(buf []byte) error {
+	return encodeGiveHeadersMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GiveHeadersMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGiveHeadersMessage(buf, m)
+}
+
+// Handle handles message
+func (m *GiveHeadersMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process verifies and adds the headers to a light client
+func (m *GiveHeadersMessage) process(d daemoner) {
+	dc := d.DaemonConfig()
+	if dc.DisableNetworking || !dc.LightClient {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":   m.c.Addr,
+		"gnetID": m.c.ConnID,
+	}
+
+	processed, err := d.executeSignedBlockHeaders(m.Headers)
+	if err != nil {
+		logger.Critical().WithError(err).WithFields(fields).Error("Failed to execute received headers")
+
+		var reason gnet.DisconnectReason
+		switch e := err.(type) {
+		case visor.ErrCheckpointMismatch:
+			// The peer is on a chain that conflicts with a checkpoint
+			reason = ErrDisconnectCheckpointMismatch
+		case visor.ErrLightHeaderFork:
+			// The peer is on a chain that forks from the synced headers further back than a reorganization can reach
+			reason = ErrDisconnectLightHeaderFork
+		case visor.ErrLightHeaderForkPointUnknown:
+			// The peer is on a competing chain. Request the older headers that include the fork point,
+			// the light client follows the chain if it is longer
+			gm := NewGetHeadersMessage(e.RequestSeq, dc.GetHeadersRequestCount)
+			if err := d.sendMessage(m.c.Addr, gm); err != nil {
+				logger.WithError(err).WithFields(fields).Warning("Send GetHeadersMessage failed")
+			}
+			return
+		}
+
+		if reason != nil {
+			if err := d.Disconnect(m.c.Addr, reason); err != nil {
+				logger.WithError(err).WithFields(fields).Warning("Disconnect")
+			}
+			return
+		}
+	}
+	if processed == 0 {
+		return
+	}
+
+	headBkSeq, ok, err := d.headBkSeq()
+	if err != nil {
+		logger.WithError(err).Error("d.headBkSeq failed")
+		return
+	}
+	if !ok {
+		logger.Error("No HeadBkSeq found after executing headers")
+		return
+	}
+
+	logger.Critical().WithFields(fields).WithField("seq", headBkSeq).Infof("Added %d new block headers", processed)
+
+	// Request more headers
+	gm := NewGetHeadersMessage(headBkSeq, dc.GetHeadersRequestCount)
+	if err := d.sendMessage(m.c.Addr, gm); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Send GetHeadersMessage failed")
+	}
+
+	// The peer sent fewer headers than requested, so it has no more headers.
+	// Refresh the outputs of the watched addresses against the new headers.
+	if uint64(len(m.Headers)) < dc.GetHeadersRequestCount {
+		if err := d.requestUxOutsFromAddr(m.c.Addr); err != nil {
+			logger.WithError(err).WithFields(fields).Warning("requestUxOutsFromAddr failed")
+		}
+	}
+}
+
+// GetUxOutsMessage is sent by a light client to request the unspent outputs of its watched addresses
+type GetUxOutsMessage struct {
+	Addresses []cipher.Address     `enc:",maxlen=256"`
+	c         *gnet.MessageContext `enc:"-"`
+}
+
+// NewGetUxOutsMessage creates GetUxOutsMessage
+func NewGetUxOutsMessage(addrs []cipher.Address) *GetUxOutsMessage {
+	return &GetUxOutsMessage{
+		Addresses: addrs,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GetUxOutsMessage) EncodeSize() uint64 {
+	return encodeSizeGetUxOutsMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GetUxOutsMessage) Encode(buf []byte) error {
+	return encodeGetUxOutsMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GetUxOutsMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGetUxOutsMessage(buf, m)
+}
+
+// Handle handles message
+func (m *GetUxOutsMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process replies with the unspent outputs of the addresses and their proofs
+func (m *GetUxOutsMessage) process(d daemoner) {
+	dc := d.DaemonConfig()
+	if dc.DisableNetworking || dc.LightClient || dc.PruneBlocks != 0 {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":   m.c.Addr,
+		"gnetID": m.c.ConnID,
+	}
+
+	if len(m.Addresses) == 0 {
+		return
+	}
+
+	proofs, err := d.getUxOutProofs(m.Addresses)
+	if err != nil {
+		logger.WithFields(fields).WithError(err).Error("getUxOutProofs failed")
+		return
+	}
+
+	gm := NewGiveUxOutsMessage(m.Addresses, proofs, dc.MaxOutgoingMessageLength)
+	if len(gm.Addresses) != len(m.Addresses) {
+		logger.WithFields(fields).Warningf("NewGiveUxOutsMessage truncated %d addresses to %d addresses", len(m.Addresses), len(gm.Addresses))
+	}
+
+	if err := d.sendMessage(m.c.Addr, gm); err != nil {
+		logger.WithFields(fields).WithError(err).Error("Send GiveUxOutsMessage failed")
+	}
+}
+
+// GiveUxOutsMessage is sent in response to GetUxOutsMessage.
+// It has the unspent outputs of Addresses, each with a proof of the transaction that created it.
+type GiveUxOutsMessage struct {
+	Addresses []cipher.Address `enc:",maxlen=256"`
+	UxOuts    []visor.UxOutProof
+	c         *gnet.MessageContext `enc:"-"`
+}
+
+// NewGiveUxOutsMessage creates GiveUxOutsMessage.
+// If the size of message would exceed maxMsgLength, trailing addresses are removed with their outputs.
+func NewGiveUxOutsMessage(addrs []cipher.Address, proofs []visor.UxOutProof, maxMsgLength uint64) *GiveUxOutsMessage {
+	m := &GiveUxOutsMessage{
+		Addresses: addrs,
+		UxOuts:    proofs,
+	}
+	if len(addrs) > 256 {
+		m.Addresses = addrs[:256]
+		m.UxOuts = filterUxOutProofs(m.Addresses, proofs)
+	}
+	truncateGiveUxOutsMessage(m, maxMsgLength)
+	return m
+}
+
+// uxOutProofAddress returns the address of the output proven by a UxOutProof
+func uxOutProofAddress(p visor.UxOutProof) (cipher.Address, bool) {
+	if p.OutIndex >= uint64(len(p.Transaction.Out)) {
+		return cipher.Address{}, false
+	}
+	return p.Transaction.Out[p.OutIndex].Address, true
+}
+
+// filterUxOutProofs returns the proofs of outputs owned by addrs
+func filterUxOutProofs(addrs []cipher.Address, proofs []visor.UxOutProof) []visor.UxOutProof {
+	keep := make(map[cipher.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		keep[a] = struct{}{}
+	}
+
+	var filtered []visor.UxOutProof
+	for _, p := range proofs {
+		a, ok := uxOutProofAddress(p)
+		if !ok {
+			continue
+		}
+		if _, ok := keep[a]; ok {
+			filtered = append(filtered, p)
+		}
+	}
+
+	return filtered
+}
+
+// truncateGiveUxOutsMessage truncates the addresses in GiveUxOutsMessage, together with their outputs,
+// to fit inside of MaxOutgoingMessageLength. An address is never sent with only some of its outputs.
+func truncateGiveUxOutsMessage(m *GiveUxOutsMessage, maxMsgLength uint64) {
+	// The message length will include a 4 byte message type prefix.
+	// Panic if the prefix can't fit, otherwise we can't adjust the uint64 safely
+	if maxMsgLength < 4 {
+		logger.Panic("maxMsgLength must be >= 4")
+	}
+
+	maxMsgLength -= 4
+
+	// Measure the current message size, if it fits, return
+	n := m.EncodeSize()
+	if n <= maxMsgLength {
+		return
+	}
+
+	// Measure the size of the outputs of each address
+	sizes := make(map[cipher.Address]uint64, len(m.Addresses))
+	for _, p := range m.UxOuts {
+		a, ok := uxOutProofAddress(p)
+		if !ok {
+			continue
+		}
+		sizes[a] += encodeSizeUxOutProof(&p)
+	}
+
+	// Measure the size of an empty message
+	var mm GiveUxOutsMessage
+	size := mm.EncodeSize()
+
+	// Measure the size of the addresses and their outputs, advancing the slice index until it reaches capacity
+	index := -1
+	for i, a := range m.Addresses {
+		x := uint64(len(a.Key)+1) + sizes[a]
+		if size+x > maxMsgLength {
+			break
+		}
+		size += x
+		index = i
+	}
+
+	m.Addresses = m.Addresses[:index+1]
+	m.UxOuts = filterUxOutProofs(m.Addresses, m.UxOuts)
+
+	if len(m.Addresses) == 0 {
+		logger.Critical().Error("truncateGiveUxOutsMessage truncated addresses to an empty slice")
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GiveUxOutsMessage) EncodeSize() uint64 {
+	return encodeSizeGiveUxOutsMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GiveUxOutsMessage) Encode(buf []byte) error {
+	return encodeGiveUxOutsMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GiveUxOutsMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGiveUxOutsMessage(buf, m)
+}
+
+// Handle handles message
+func (m *GiveUxOutsMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process verifies the proofs of the outputs and replaces the outputs of the watched addresses of a light client
+func (m *GiveUxOutsMessage) process(d daemoner) {
+	dc := d.DaemonConfig()
+	if dc.DisableNetworking || !dc.LightClient {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":   m.c.Addr,
+		"gnetID": m.c.ConnID,
+	}
+
+	if len(m.Addresses) == 0 {
+		return
+	}
+
+	if err := d.setWatchedUxOuts(m.Addresses, m.UxOuts); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Failed to set the outputs of watched addresses")
+		return
+	}
+
+	logger.WithFields(fields).Debugf("Updated %d outputs of %d watched addresses", len(m.UxOuts), len(m.Addresses))
+}
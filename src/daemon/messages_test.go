@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor"
 )
 
 func TestIntroductionMessage(t *testing.T) {
@@ -31,8 +33,22 @@ func TestIntroductionMessage(t *testing.T) {
 		BurnFactor:          4,
 		MaxTransactionSize:  32768,
 		MaxDropletPrecision: 3,
-	}, genesisHash)
-	invalidGenesisHashExtra = invalidGenesisHashExtra[:len(invalidGenesisHashExtra)-2]
+	}, genesisHash, 0, false)
+	invalidGenesisHashExtra = invalidGenesisHashExtra[:len(invalidGenesisHashExtra)-10]
+
+	invalidPruneBlocksExtra := newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
+		BurnFactor:          4,
+		MaxTransactionSize:  32768,
+		MaxDropletPrecision: 3,
+	}, genesisHash, 1000, false)
+	invalidPruneBlocksExtra = invalidPruneBlocksExtra[:len(invalidPruneBlocksExtra)-2]
+
+	withoutPruneBlocksExtra := newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
+		BurnFactor:          4,
+		MaxTransactionSize:  32768,
+		MaxDropletPrecision: 3,
+	}, genesisHash, 0, false)
+	withoutPruneBlocksExtra = withoutPruneBlocksExtra[:len(withoutPruneBlocksExtra)-9]
 
 	type daemonMockValue struct {
 		protocolVersion          uint32
@@ -56,6 +72,8 @@ func TestIntroductionMessage(t *testing.T) {
 		mockValue            daemonMockValue
 		userAgent            useragent.Data
 		unconfirmedVerifyTxn params.VerifyTxn
+		pruneBlocks          uint64
+		lightClient          bool
 		intro                *IntroductionMessage
 	}{
 		{
@@ -144,7 +162,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, 0, false),
 			},
 		},
 		{
@@ -187,7 +205,161 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash), []byte("additional data")...),
+				}, genesisHash, 0, false), []byte("additional data")...),
+			},
+		},
+		{
+			name: "INTR message with all extra fields and prune blocks",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:          10000,
+				protocolVersion: 1,
+				pubkey:          pubkey,
+				connectionIntroduced: &connection{
+					Addr: "121.121.121.121:6000",
+					ConnectionDetails: ConnectionDetails{
+						ListenPort: 6000,
+						UserAgent: useragent.Data{
+							Coin:    "skycoin",
+							Version: "0.26.0",
+						},
+						UnconfirmedVerifyTxn: params.VerifyTxn{
+							BurnFactor:          4,
+							MaxTransactionSize:  32768,
+							MaxDropletPrecision: 3,
+						},
+						PruneBlocks: 1000,
+					},
+				},
+			},
+			userAgent: useragent.Data{
+				Coin:    "skycoin",
+				Version: "0.26.0",
+			},
+			unconfirmedVerifyTxn: params.VerifyTxn{
+				BurnFactor:          4,
+				MaxTransactionSize:  32768,
+				MaxDropletPrecision: 3,
+			},
+			pruneBlocks: 1000,
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 1,
+				Extra: newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
+					BurnFactor:          4,
+					MaxTransactionSize:  32768,
+					MaxDropletPrecision: 3,
+				}, genesisHash, 1000, false),
+			},
+		},
+		{
+			name: "INTR message with light client flag",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:          10000,
+				protocolVersion: 1,
+				pubkey:          pubkey,
+				connectionIntroduced: &connection{
+					Addr: "121.121.121.121:6000",
+					ConnectionDetails: ConnectionDetails{
+						ListenPort: 6000,
+						UserAgent: useragent.Data{
+							Coin:    "skycoin",
+							Version: "0.26.0",
+						},
+						UnconfirmedVerifyTxn: params.VerifyTxn{
+							BurnFactor:          4,
+							MaxTransactionSize:  32768,
+							MaxDropletPrecision: 3,
+						},
+						LightClient: true,
+					},
+				},
+			},
+			userAgent: useragent.Data{
+				Coin:    "skycoin",
+				Version: "0.26.0",
+			},
+			unconfirmedVerifyTxn: params.VerifyTxn{
+				BurnFactor:          4,
+				MaxTransactionSize:  32768,
+				MaxDropletPrecision: 3,
+			},
+			lightClient: true,
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 1,
+				Extra: newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", params.VerifyTxn{
+					BurnFactor:          4,
+					MaxTransactionSize:  32768,
+					MaxDropletPrecision: 3,
+				}, genesisHash, 0, true),
+			},
+		},
+		{
+			name: "INTR message without prune blocks",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:          10000,
+				protocolVersion: 1,
+				pubkey:          pubkey,
+				connectionIntroduced: &connection{
+					Addr: "121.121.121.121:6000",
+					ConnectionDetails: ConnectionDetails{
+						ListenPort: 6000,
+						UserAgent: useragent.Data{
+							Coin:    "skycoin",
+							Version: "0.26.0",
+						},
+						UnconfirmedVerifyTxn: params.VerifyTxn{
+							BurnFactor:          4,
+							MaxTransactionSize:  32768,
+							MaxDropletPrecision: 3,
+						},
+					},
+				},
+			},
+			userAgent: useragent.Data{
+				Coin:    "skycoin",
+				Version: "0.26.0",
+			},
+			unconfirmedVerifyTxn: params.VerifyTxn{
+				BurnFactor:          4,
+				MaxTransactionSize:  32768,
+				MaxDropletPrecision: 3,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 1,
+				Extra:           withoutPruneBlocksExtra,
+			},
+		},
+		{
+			name: "INTR message with extra fields but invalid prune blocks data",
+			addr: "121.121.121.121:6000",
+			mockValue: daemonMockValue{
+				mirror:           10000,
+				protocolVersion:  1,
+				pubkey:           pubkey,
+				disconnectReason: ErrDisconnectInvalidExtraData,
+			},
+			userAgent: useragent.Data{
+				Coin:    "skycoin",
+				Version: "0.26.0",
+			},
+			unconfirmedVerifyTxn: params.VerifyTxn{
+				BurnFactor:          4,
+				MaxTransactionSize:  32768,
+				MaxDropletPrecision: 3,
+			},
+			intro: &IntroductionMessage{
+				Mirror:          10001,
+				ListenPort:      6000,
+				ProtocolVersion: 1,
+				Extra:           invalidPruneBlocksExtra,
 			},
 		},
 		{
@@ -232,7 +404,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, 0, false),
 			},
 		},
 		{
@@ -392,7 +564,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, 0, false),
 			},
 		},
 		{
@@ -435,7 +607,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, 0, false),
 			},
 		},
 		{
@@ -477,7 +649,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, 0, false),
 			},
 		},
 	}
@@ -515,6 +687,12 @@ func TestIntroductionMessage(t *testing.T) {
 				if tc.unconfirmedVerifyTxn != m.UnconfirmedVerifyTxn {
 					return false
 				}
+				if tc.pruneBlocks != m.PruneBlocks {
+					return false
+				}
+				if tc.lightClient != m.LightClient {
+					return false
+				}
 
 				return true
 			})).Return(tc.mockValue.connectionIntroduced, tc.mockValue.connectionIntroducedErr)
@@ -568,7 +746,7 @@ func TestMessageEncodeDecode(t *testing.T) {
 					BurnFactor:          2,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, introGenesisHash),
+				}, introGenesisHash, 0, false),
 			},
 		},
 		{
@@ -839,6 +1017,82 @@ func TestMessageEncodeDecode(t *testing.T) {
 				},
 			},
 		},
+		{
+			goldenFile: "get-headers-msg.golden",
+			obj:        &GetHeadersMessage{},
+			msg: &GetHeadersMessage{
+				LastBlock:        999988887777,
+				RequestedHeaders: 888899997777,
+			},
+		},
+		{
+			goldenFile: "give-headers-msg.golden",
+			obj:        &GiveHeadersMessage{},
+			msg: &GiveHeadersMessage{
+				Headers: []coin.SignedBlockHeader{
+					{
+						Sig: cipher.MustSigFromHex("8cf145e9ef4a4a5254bc57798a7a61dfed238768f94edc5635175c6b91bccd8ec1555da603c5e31b018e135b82b1525be8a92973c468a74b5b40b8da189cb465eb"),
+						Head: coin.BlockHeader{
+							Version:  1,
+							Time:     1538036613,
+							BkSeq:    9999999999,
+							Fee:      1234123412341234,
+							PrevHash: cipher.MustSHA256FromHex("59cb7d0e2ce8a03d1054afcc28a22fe864a8813460d241db38c59d10e7c29132"),
+							BodyHash: cipher.MustSHA256FromHex("6d421469409591f0c3112884c8cf10f8bca5d8ab87c9c30dea2ea73b6751bbf9"),
+							UxHash:   cipher.MustSHA256FromHex("6ea6a972cf06d25908b29953aeddb68c3b6f3a9903e8f964dc89b0abc0645dea"),
+						},
+					},
+				},
+			},
+		},
+		{
+			goldenFile: "get-uxouts-msg.golden",
+			obj:        &GetUxOutsMessage{},
+			msg: &GetUxOutsMessage{
+				Addresses: []cipher.Address{
+					cipher.MustDecodeBase58Address("24iFsYHzVfYXo8cvWg1jhetpTMNvHH7j6AX"),
+					cipher.MustDecodeBase58Address("JV5xJ33po1Bj3dXZT3SYA3ZmnTibREFxxd"),
+				},
+			},
+		},
+		{
+			goldenFile: "give-uxouts-msg.golden",
+			obj:        &GiveUxOutsMessage{},
+			msg: &GiveUxOutsMessage{
+				Addresses: []cipher.Address{
+					cipher.MustDecodeBase58Address("24iFsYHzVfYXo8cvWg1jhetpTMNvHH7j6AX"),
+				},
+				UxOuts: []visor.UxOutProof{
+					{
+						BkSeq: 9999999999,
+						Transaction: coin.Transaction{
+							Length:    43214321,
+							Type:      1,
+							InnerHash: cipher.MustSHA256FromHex("cbedf8ef0bda91afc6a582f3e18e8ba6cbd8a6e2cd7ae9a4fe8e1fba6b1e5e2b"),
+							Sigs: []cipher.Sig{
+								cipher.MustSigFromHex("1cfd7a4db3a52a85d2a86708695112b6520acc8dc8c6d61fc6f2aa9bf51e4ceb0c1fd11f85b2c6bdaac0c6ab8dde4fbb0f30c7a8f1c0e7d6d1c5d1ae2e70e3a700"),
+							},
+							In: []cipher.SHA256{
+								cipher.MustSHA256FromHex("536f0a1a915fadfa3a2720a0615641827ff67394d2b2149f6106bbf5e2a8b0e2"),
+							},
+							Out: []coin.TransactionOutput{
+								{
+									Address: cipher.MustDecodeBase58Address("24iFsYHzVfYXo8cvWg1jhetpTMNvHH7j6AX"),
+									Coins:   1123103123,
+									Hours:   123000,
+								},
+							},
+						},
+						TxnIndex: 3,
+						OutIndex: 0,
+						Proof: []cipher.SHA256{
+							cipher.MustSHA256FromHex("766d6f6ed56599a91759c75466e3f09b9d6d5995b58dd5bbfba5af10b1a8cdea"),
+							cipher.MustSHA256FromHex("2c7989f47524721bb2c7a7f967208c9b1c01829c9a55addf22d066e5c55ab3ac"),
+						},
+					},
+				},
+			},
+		},
 	}
 
 	if update {
@@ -1086,6 +1340,264 @@ func TestGetBlocksMessageProcess(t *testing.T) {
 	d.AssertExpectations(t)
 }
 
+func TestTruncateGiveHeadersMessage(t *testing.T) {
+	maxLen := uint64(1024)
+	m := &GiveHeadersMessage{}
+
+	// Empty message, no truncation
+	prevLen := len(m.Headers)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Headers))
+
+	n := encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen)
+
+	// One header, no truncation
+	m.Headers = append(m.Headers, coin.SignedBlockHeader{})
+	prevLen = len(m.Headers)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Headers))
+
+	n = encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen)
+
+	// Too many headers, truncated
+	n = encodeSizeSignedBlockHeader(&coin.SignedBlockHeader{})
+	m.Headers = make([]coin.SignedBlockHeader, (maxLen/n)*2)
+	prevLen = len(m.Headers)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.True(t, len(m.Headers) < prevLen)
+	require.NotEmpty(t, m.Headers)
+
+	n = encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen)
+}
+
+func TestTruncateGiveUxOutsMessage(t *testing.T) {
+	maxLen := uint64(1024)
+
+	addrs := make([]cipher.Address, 4)
+	var proofs []visor.UxOutProof
+	for i := range addrs {
+		addrs[i] = testutil.MakeAddress()
+		// Each address has 3 outputs
+		for j := 0; j < 3; j++ {
+			proofs = append(proofs, visor.UxOutProof{
+				Transaction: coin.Transaction{
+					Out: []coin.TransactionOutput{
+						{
+							Address: addrs[i],
+						},
+					},
+				},
+			})
+		}
+	}
+
+	// No truncation
+	m := NewGiveUxOutsMessage(addrs[:1], proofs[:3], maxLen)
+	require.Equal(t, addrs[:1], m.Addresses)
+	require.Equal(t, proofs[:3], m.UxOuts)
+
+	// Too many outputs, the trailing addresses are truncated with their outputs
+	m = NewGiveUxOutsMessage(addrs, proofs, maxLen)
+	require.NotEmpty(t, m.Addresses)
+	require.True(t, len(m.Addresses) < len(addrs))
+	require.Equal(t, addrs[:len(m.Addresses)], m.Addresses)
+	require.Equal(t, proofs[:len(m.Addresses)*3], m.UxOuts)
+
+	n := encodeSizeGiveUxOutsMessage(m)
+	require.True(t, n <= maxLen-4)
+}
+
+func TestGetHeadersMessageProcess(t *testing.T) {
+	d := &mockDaemoner{}
+
+	m := &GetHeadersMessage{
+		LastBlock: 7,
+		// request more headers than MaxGetHeadersResponseCount to verify capping
+		RequestedHeaders: 100,
+		c: &gnet.MessageContext{
+			ConnID: 10,
+			Addr:   "127.0.0.1:1234",
+		},
+	}
+
+	config := DaemonConfig{
+		DisableNetworking:          false,
+		MaxGetHeadersResponseCount: 20,
+		MaxOutgoingMessageLength:   1024,
+	}
+
+	// Have getSignedBlockHeadersSince return a lot of headers to verify truncation
+	headers := make([]coin.SignedBlockHeader, 20)
+
+	ghm := NewGiveHeadersMessage(headers, config.MaxOutgoingMessageLength)
+	require.True(t, len(ghm.Headers) < len(headers), "headers should be truncated")
+	require.NotEmpty(t, ghm.Headers)
+
+	d.On("DaemonConfig").Return(config)
+	d.On("recordPeerHeight", "127.0.0.1:1234", uint64(10), uint64(7)).Return()
+	d.On("getSignedBlockHeadersSince", uint64(7), uint64(20)).Return(headers, nil)
+	d.On("sendMessage", "127.0.0.1:1234", ghm).Return(nil)
+
+	m.process(d)
+
+	d.AssertExpectations(t)
+
+	// A light client does not serve headers
+	d = &mockDaemoner{}
+	config.LightClient = true
+	d.On("DaemonConfig").Return(config)
+
+	m.process(d)
+
+	d.AssertExpectations(t)
+	d.AssertNotCalled(t, "getSignedBlockHeadersSince", mock.Anything, mock.Anything)
+}
+
+func TestGiveHeadersMessageProcess(t *testing.T) {
+	addr := "127.0.0.1:1234"
+	headers := make([]coin.SignedBlockHeader, 3)
+
+	cases := []struct {
+		name             string
+		lightClient      bool
+		processed        int
+		err              error
+		disconnectReason gnet.DisconnectReason
+		requestCount     uint64
+		requestSeq       uint64
+		requestUxOuts    bool
+	}{
+		{
+			name:        "not a light client",
+			lightClient: false,
+		},
+		{
+			name:         "no headers processed",
+			lightClient:  true,
+			processed:    0,
+			requestCount: 3,
+		},
+		{
+			name:         "peer may have more headers",
+			lightClient:  true,
+			processed:    3,
+			requestCount: 3,
+		},
+		{
+			name:          "caught up with the peer",
+			lightClient:   true,
+			processed:     3,
+			requestCount:  10,
+			requestUxOuts: true,
+		},
+		{
+			name:         "some headers processed before an invalid header",
+			lightClient:  true,
+			processed:    2,
+			err:          errors.New("invalid signature"),
+			requestCount: 3,
+		},
+		{
+			name:        "header conflicts with a checkpoint",
+			lightClient: true,
+			processed:   2,
+			err: visor.ErrCheckpointMismatch{
+				Seq:  3,
+				Hash: testutil.RandSHA256(t),
+			},
+			disconnectReason: ErrDisconnectCheckpointMismatch,
+			requestCount:     3,
+		},
+		{
+			name:        "header forks too far behind the head header",
+			lightClient: true,
+			processed:   0,
+			err: visor.ErrLightHeaderFork{
+				Seq:  2,
+				Hash: testutil.RandSHA256(t),
+			},
+			disconnectReason: ErrDisconnectLightHeaderFork,
+			requestCount:     3,
+		},
+		{
+			name:        "header forks from the synced headers at an unknown fork point",
+			lightClient: true,
+			processed:   0,
+			err: visor.ErrLightHeaderForkPointUnknown{
+				Seq:        4,
+				Hash:       testutil.RandSHA256(t),
+				RequestSeq: 1,
+			},
+			requestCount: 3,
+			requestSeq:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &GiveHeadersMessage{
+				Headers: headers,
+				c: &gnet.MessageContext{
+					ConnID: 10,
+					Addr:   addr,
+				},
+			}
+
+			config := DaemonConfig{
+				LightClient:            tc.lightClient,
+				GetHeadersRequestCount: tc.requestCount,
+			}
+
+			d := &mockDaemoner{}
+			d.On("DaemonConfig").Return(config)
+			d.On("executeSignedBlockHeaders", headers).Return(tc.processed, tc.err)
+			d.On("headBkSeq").Return(uint64(3), true, nil)
+			d.On("sendMessage", addr, NewGetHeadersMessage(3, tc.requestCount)).Return(nil)
+			d.On("sendMessage", addr, NewGetHeadersMessage(tc.requestSeq, tc.requestCount)).Return(nil)
+			d.On("requestUxOutsFromAddr", addr).Return(nil)
+			d.On("Disconnect", addr, tc.disconnectReason).Return(nil)
+
+			m.process(d)
+
+			if !tc.lightClient {
+				d.AssertNotCalled(t, "executeSignedBlockHeaders", mock.Anything)
+				return
+			}
+
+			d.AssertCalled(t, "executeSignedBlockHeaders", headers)
+
+			if tc.disconnectReason != nil {
+				d.AssertCalled(t, "Disconnect", addr, tc.disconnectReason)
+				d.AssertNotCalled(t, "sendMessage", mock.Anything, mock.Anything)
+				return
+			}
+			d.AssertNotCalled(t, "Disconnect", mock.Anything, mock.Anything)
+
+			if _, ok := tc.err.(visor.ErrLightHeaderForkPointUnknown); ok {
+				d.AssertCalled(t, "sendMessage", addr, NewGetHeadersMessage(tc.requestSeq, tc.requestCount))
+				d.AssertNumberOfCalls(t, "sendMessage", 1)
+				d.AssertNotCalled(t, "requestUxOutsFromAddr", mock.Anything)
+				return
+			}
+
+			if tc.processed == 0 {
+				d.AssertNotCalled(t, "sendMessage", mock.Anything, mock.Anything)
+			} else {
+				d.AssertCalled(t, "sendMessage", addr, NewGetHeadersMessage(3, tc.requestCount))
+			}
+
+			if tc.requestUxOuts {
+				d.AssertCalled(t, "requestUxOutsFromAddr", addr)
+			} else {
+				d.AssertNotCalled(t, "requestUxOutsFromAddr", mock.Anything)
+			}
+		})
+	}
+}
+
 func setupMsgEncoding() {
 	gnet.EraseMessages()
 	var messagesConfig = NewMessagesConfig()
@@ -144,6 +144,27 @@ func (_m *mockDaemoner) executeSignedBlock(b coin.SignedBlock) error {
 	return r0
 }
 
+// executeSignedBlockHeaders provides a mock function with given fields: headers
+func (_m *mockDaemoner) executeSignedBlockHeaders(headers []coin.SignedBlockHeader) (int, error) {
+	ret := _m.Called(headers)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]coin.SignedBlockHeader) int); ok {
+		r0 = rf(headers)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]coin.SignedBlockHeader) error); ok {
+		r1 = rf(headers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // filterKnownUnconfirmed provides a mock function with given fields: txns
 func (_m *mockDaemoner) filterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	ret := _m.Called(txns)
@@ -190,6 +211,29 @@ func (_m *mockDaemoner) getKnownUnconfirmed(txns []cipher.SHA256) (coin.Transact
 	return r0, r1
 }
 
+// getSignedBlockHeadersSince provides a mock function with given fields: seq, count
+func (_m *mockDaemoner) getSignedBlockHeadersSince(seq uint64, count uint64) ([]coin.SignedBlockHeader, error) {
+	ret := _m.Called(seq, count)
+
+	var r0 []coin.SignedBlockHeader
+	if rf, ok := ret.Get(0).(func(uint64, uint64) []coin.SignedBlockHeader); ok {
+		r0 = rf(seq, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]coin.SignedBlockHeader)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, uint64) error); ok {
+		r1 = rf(seq, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // getSignedBlocksSince provides a mock function with given fields: seq, count
 func (_m *mockDaemoner) getSignedBlocksSince(seq uint64, count uint64) ([]coin.SignedBlock, error) {
 	ret := _m.Called(seq, count)
@@ -213,6 +257,29 @@ func (_m *mockDaemoner) getSignedBlocksSince(seq uint64, count uint64) ([]coin.S
 	return r0, r1
 }
 
+// getUxOutProofs provides a mock function with given fields: addrs
+func (_m *mockDaemoner) getUxOutProofs(addrs []cipher.Address) ([]visor.UxOutProof, error) {
+	ret := _m.Called(addrs)
+
+	var r0 []visor.UxOutProof
+	if rf, ok := ret.Get(0).(func([]cipher.Address) []visor.UxOutProof); ok {
+		r0 = rf(addrs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.UxOutProof)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]cipher.Address) error); ok {
+		r1 = rf(addrs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // headBkSeq provides a mock function with given fields:
 func (_m *mockDaemoner) headBkSeq() (uint64, bool, error) {
 	ret := _m.Called()
@@ -318,6 +385,20 @@ func (_m *mockDaemoner) requestBlocksFromAddr(addr string) error {
 	return r0
 }
 
+// requestUxOutsFromAddr provides a mock function with given fields: addr
+func (_m *mockDaemoner) requestUxOutsFromAddr(addr string) error {
+	ret := _m.Called(addr)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(addr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // sendMessage provides a mock function with given fields: addr, msg
 func (_m *mockDaemoner) sendMessage(addr string, msg gnet.Message) error {
 	ret := _m.Called(addr, msg)
@@ -345,3 +426,31 @@ func (_m *mockDaemoner) sendRandomPeers(addr string) error {
 
 	return r0
 }
+
+// setWatchedUxOuts provides a mock function with given fields: addrs, proofs
+func (_m *mockDaemoner) setWatchedUxOuts(addrs []cipher.Address, proofs []visor.UxOutProof) error {
+	ret := _m.Called(addrs, proofs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]cipher.Address, []visor.UxOutProof) error); ok {
+		r0 = rf(addrs, proofs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// verifyPendingBlockSignatures provides a mock function with given fields: blocks
+func (_m *mockDaemoner) verifyPendingBlockSignatures(blocks []coin.SignedBlock) error {
+	ret := _m.Called(blocks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]coin.SignedBlock) error); ok {
+		r0 = rf(blocks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
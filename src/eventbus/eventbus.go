@@ -0,0 +1,158 @@
+/*
+Package eventbus implements an in-process publish/subscribe bus for blockchain,
+unconfirmed pool and peer connection events.
+
+Back-pressure policy: publishing never blocks the publisher. Each subscriber
+has a bounded buffer. If a subscriber's buffer is full when an event is published,
+the event is dropped for that subscriber only, and the subscriber's dropped event
+counter is incremented. A subscriber that finds Dropped() has increased has missed
+events and should resynchronize its state by querying the node.
+*/
+package eventbus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the buffer size of a subscription if none is specified
+const DefaultBufferSize = 256
+
+// Bus publishes events to subscribers
+type Bus struct {
+	sync.RWMutex
+	subs   map[uint64]*Subscription
+	nextID uint64
+	closed bool
+}
+
+// New creates a Bus
+func New() *Bus {
+	return &Bus{
+		subs: make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe creates a Subscription to events of the given topics, or to all topics if none are given.
+// bufferSize is the number of events buffered for the subscriber. DefaultBufferSize is used if bufferSize is <= 0.
+// If the bus is closed, the returned subscription's channel is already closed.
+func (b *Bus) Subscribe(bufferSize int, topics ...Topic) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	s := &Subscription{
+		bus:    b,
+		events: make(chan Event, bufferSize),
+	}
+
+	if len(topics) != 0 {
+		s.topics = make(map[Topic]struct{}, len(topics))
+		for _, t := range topics {
+			s.topics[t] = struct{}{}
+		}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		close(s.events)
+		return s
+	}
+
+	s.id = b.nextID
+	b.nextID++
+	b.subs[s.id] = s
+
+	return s
+}
+
+// Publish sends an event to the subscribers of its topic. It does not block; see the package's back-pressure policy.
+// Publishing to a nil or closed Bus does nothing.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	topic := e.Topic()
+	for _, s := range b.subs {
+		if !s.wants(topic) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// Close closes the channels of all subscriptions. Events published after Close are discarded.
+func (b *Bus) Close() {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for id, s := range b.subs {
+		close(s.events)
+		delete(b.subs, id)
+	}
+}
+
+func (b *Bus) unsubscribe(s *Subscription) {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.subs[s.id]; !ok {
+		return
+	}
+
+	delete(b.subs, s.id)
+	close(s.events)
+}
+
+// Subscription receives the events published to a Bus
+type Subscription struct {
+	// dropped is accessed atomically and must be the first field for 64-bit alignment
+	dropped uint64
+	id      uint64
+	bus     *Bus
+	topics  map[Topic]struct{}
+	events  chan Event
+}
+
+// Events returns the channel of events. The channel is closed by Unsubscribe or when the Bus is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events that were dropped because the subscription's buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe stops the subscription and closes its channel
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+func (s *Subscription) wants(t Topic) bool {
+	if s.topics == nil {
+		return true
+	}
+	_, ok := s.topics[t]
+	return ok
+}
@@ -0,0 +1,134 @@
+package eventbus
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func drain(s *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestBusTopics(t *testing.T) {
+	b := New()
+	defer b.Close()
+
+	all := b.Subscribe(0)
+	txns := b.Subscribe(10, TopicTransactionAdded, TopicTransactionRemoved)
+	peers := b.Subscribe(10, TopicPeerConnected)
+
+	h := testutil.RandSHA256(t)
+	events := []Event{
+		TransactionAdded{Hash: h},
+		PeerConnected{Addr: "127.0.0.1:6000", Outgoing: true},
+		TransactionRemoved{Hash: h, Reason: RemovedConfirmed},
+		PeerDisconnected{Addr: "127.0.0.1:6000"},
+	}
+	for _, e := range events {
+		b.Publish(e)
+	}
+
+	require.Equal(t, events, drain(all))
+	require.Equal(t, []Event{events[0], events[2]}, drain(txns))
+	require.Equal(t, []Event{events[1]}, drain(peers))
+	require.Equal(t, uint64(0), all.Dropped())
+}
+
+func TestBusDropsWhenBufferFull(t *testing.T) {
+	b := New()
+	defer b.Close()
+
+	slow := b.Subscribe(2)
+	fast := b.Subscribe(10)
+
+	for i := 0; i < 5; i++ {
+		b.Publish(PeerConnected{Addr: "127.0.0.1:6000"})
+	}
+
+	// The oldest events are kept, and the newer events are dropped for the slow subscriber only
+	require.Len(t, drain(slow), 2)
+	require.Equal(t, uint64(3), slow.Dropped())
+	require.Len(t, drain(fast), 5)
+	require.Equal(t, uint64(0), fast.Dropped())
+
+	// After draining, events are delivered again
+	b.Publish(PeerConnected{Addr: "127.0.0.1:6000"})
+	require.Len(t, drain(slow), 1)
+	require.Equal(t, uint64(3), slow.Dropped())
+}
+
+func TestBusUnsubscribeAndClose(t *testing.T) {
+	b := New()
+
+	s1 := b.Subscribe(1)
+	s2 := b.Subscribe(1)
+
+	s1.Unsubscribe()
+	_, ok := <-s1.Events()
+	require.False(t, ok)
+
+	// Unsubscribing twice does nothing
+	s1.Unsubscribe()
+
+	b.Publish(PeerConnected{})
+	require.Len(t, drain(s2), 1)
+
+	b.Close()
+	_, ok = <-s2.Events()
+	require.False(t, ok)
+
+	// Publishing and subscribing after Close does not panic
+	b.Publish(PeerConnected{})
+	b.Close()
+	s3 := b.Subscribe(1)
+	_, ok = <-s3.Events()
+	require.False(t, ok)
+	s3.Unsubscribe()
+
+	// Publishing to a nil bus does nothing
+	var nb *Bus
+	nb.Publish(PeerConnected{})
+}
+
+func TestBusConcurrent(t *testing.T) {
+	b := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Publish(PeerConnected{})
+			}
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := b.Subscribe(10)
+			drain(s)
+			s.Unsubscribe()
+		}()
+	}
+
+	wg.Wait()
+	b.Close()
+}
@@ -0,0 +1,112 @@
+package eventbus
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/useragent"
+)
+
+// Topic identifies a type of event
+type Topic string
+
+const (
+	// TopicBlockExecuted is the topic of BlockExecuted events
+	TopicBlockExecuted Topic = "block_executed"
+	// TopicBlockRolledBack is the topic of BlockRolledBack events
+	TopicBlockRolledBack Topic = "block_rolled_back"
+	// TopicTransactionAdded is the topic of TransactionAdded events
+	TopicTransactionAdded Topic = "transaction_added"
+	// TopicTransactionRemoved is the topic of TransactionRemoved events
+	TopicTransactionRemoved Topic = "transaction_removed"
+	// TopicPeerConnected is the topic of PeerConnected events
+	TopicPeerConnected Topic = "peer_connected"
+	// TopicPeerDisconnected is the topic of PeerDisconnected events
+	TopicPeerDisconnected Topic = "peer_disconnected"
+)
+
+// Event is an event published to a Bus. Subscribers can switch on the concrete type of the event.
+type Event interface {
+	Topic() Topic
+}
+
+// BlockExecuted is published after a block is added to the main chain.
+// When the blockchain is reorganized, it is published for each block applied to the new main chain.
+type BlockExecuted struct {
+	Block coin.SignedBlock
+}
+
+// Topic returns TopicBlockExecuted
+func (e BlockExecuted) Topic() Topic {
+	return TopicBlockExecuted
+}
+
+// BlockRolledBack is published after a block is removed from the main chain when the blockchain is reorganized.
+// It is published for each block rolled back, starting from the old head, before the BlockExecuted
+// events of the blocks applied to the new main chain.
+type BlockRolledBack struct {
+	Block coin.SignedBlock
+}
+
+// Topic returns TopicBlockRolledBack
+func (e BlockRolledBack) Topic() Topic {
+	return TopicBlockRolledBack
+}
+
+// TransactionAdded is published after a new transaction is added to the unconfirmed pool
+type TransactionAdded struct {
+	Transaction coin.Transaction
+	Hash        cipher.SHA256
+}
+
+// Topic returns TopicTransactionAdded
+func (e TransactionAdded) Topic() Topic {
+	return TopicTransactionAdded
+}
+
+// RemovalReason is the reason a transaction was removed from the unconfirmed pool
+type RemovalReason string
+
+const (
+	// RemovedConfirmed the transaction was included in a block
+	RemovedConfirmed RemovalReason = "confirmed"
+	// RemovedInvalid the transaction became permanently invalid
+	RemovedInvalid RemovalReason = "invalid"
+	// RemovedPoolFull the transaction was evicted because the pool was full and it had the lowest fee
+	RemovedPoolFull RemovalReason = "pool full"
+	// RemovedExpired the transaction was evicted because it was not received again within the pool's TTL
+	RemovedExpired RemovalReason = "expired"
+)
+
+// TransactionRemoved is published after a transaction is removed from the unconfirmed pool
+type TransactionRemoved struct {
+	Hash   cipher.SHA256
+	Reason RemovalReason
+}
+
+// Topic returns TopicTransactionRemoved
+func (e TransactionRemoved) Topic() Topic {
+	return TopicTransactionRemoved
+}
+
+// PeerConnected is published after a peer completes the introduction handshake
+type PeerConnected struct {
+	Addr      string
+	Outgoing  bool
+	UserAgent useragent.Data
+}
+
+// Topic returns TopicPeerConnected
+func (e PeerConnected) Topic() Topic {
+	return TopicPeerConnected
+}
+
+// PeerDisconnected is published after an introduced peer disconnects
+type PeerDisconnected struct {
+	Addr   string
+	Reason string
+}
+
+// Topic returns TopicPeerDisconnected
+func (e PeerDisconnected) Topic() Topic {
+	return TopicPeerDisconnected
+}
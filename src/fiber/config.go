@@ -40,6 +40,10 @@ type NodeConfig struct {
 	DefaultConnections []string `mapstructure:"default_connections"`
 	// PeerlistURL is a URL pointing to a newline-separated list of ip:ports that are used for bootstrapping (but they are not "trusted")
 	PeerListURL string `mapstructure:"peer_list_url"`
+	// Checkpoints are main chain blocks known to be valid, in the format "seq:hash". Chains that conflict with a checkpoint are rejected
+	Checkpoints []string `mapstructure:"checkpoints"`
+	// AssumeValid is a main chain block in the format "seq:hash". The transaction signatures of the block and its main chain ancestors are not verified once it is reached
+	AssumeValid string `mapstructure:"assume_valid"`
 
 	// UnconfirmedBurnFactor is the burn factor to apply when verifying unconfirmed transactions
 	UnconfirmedBurnFactor uint32 `mapstructure:"unconfirmed_burn_factor"`
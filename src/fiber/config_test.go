@@ -26,8 +26,13 @@ func TestNewConfig(t *testing.T) {
 				"172.104.85.6:6000",
 				"139.162.7.132:6000",
 			},
-			Port:                           6000,
-			PeerListURL:                    "https://downloads.skycoin.net/blockchain/peers.txt",
+			Port:        6000,
+			PeerListURL: "https://downloads.skycoin.net/blockchain/peers.txt",
+			Checkpoints: []string{
+				"1000:6ab6b5ed2ef3dcd2e2c5a5ca3dcc3d2f24b7b2b2b9d4a9dbd64dd3d0cf7a3cf0",
+				"2000:15b7e6f25c0e2ddc4d4e07b50e83d2ac66b7e6e4a9b2d1e9b1b0ba9e0c2e9d1b",
+			},
+			AssumeValid:                    "2000:15b7e6f25c0e2ddc4d4e07b50e83d2ac66b7e6e4a9b2d1e9b1b0ba9e0c2e9d1b",
 			WebInterfacePort:               6420,
 			UnconfirmedBurnFactor:          10,
 			UnconfirmedMaxTransactionSize:  777,
@@ -30,3 +30,36 @@ func NewRichlistBalances(visorRichlist visor.Richlist) ([]RichlistBalance, error
 
 	return richlist, nil
 }
+
+// RichlistHolders counts the addresses holding coins
+type RichlistHolders struct {
+	Addresses  uint64                     `json:"addresses"`
+	Thresholds []RichlistHoldersThreshold `json:"thresholds"`
+}
+
+// RichlistHoldersThreshold is the number of addresses holding at least a number of coins
+type RichlistHoldersThreshold struct {
+	Coins     string `json:"coins"`
+	Addresses uint64 `json:"addresses"`
+}
+
+// NewRichlistHolders copies from visor.RichlistHolders
+func NewRichlistHolders(h *visor.RichlistHolders) (*RichlistHolders, error) {
+	thresholds := make([]RichlistHoldersThreshold, len(h.Thresholds))
+	for i, t := range h.Thresholds {
+		coins, err := droplet.ToString(t.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		thresholds[i] = RichlistHoldersThreshold{
+			Coins:     coins,
+			Addresses: t.Addresses,
+		}
+	}
+
+	return &RichlistHolders{
+		Addresses:  h.Addresses,
+		Thresholds: thresholds,
+	}, nil
+}
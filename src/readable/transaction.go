@@ -162,6 +162,7 @@ type UnconfirmedTransactions struct {
 	Checked     time.Time   `json:"checked"`
 	Announced   time.Time   `json:"announced"`
 	IsValid     bool        `json:"is_valid"`
+	Note        string      `json:"note,omitempty"`
 }
 
 // NewUnconfirmedTransaction creates a readable unconfirmed transaction
@@ -198,6 +199,7 @@ type TransactionWithStatus struct {
 	Status      TransactionStatus `json:"status"`
 	Time        uint64            `json:"time"`
 	Transaction Transaction       `json:"txn"`
+	Note        string            `json:"note,omitempty"`
 }
 
 // NewTransactionWithStatus converts visor.Transaction to TransactionWithStatus
@@ -224,6 +226,7 @@ type TransactionWithStatusVerbose struct {
 	Status      TransactionStatus  `json:"status"`
 	Time        uint64             `json:"time"`
 	Transaction TransactionVerbose `json:"txn"`
+	Note        string             `json:"note,omitempty"`
 }
 
 // NewTransactionWithStatusVerbose converts visor.Transaction to TransactionWithStatusVerbose
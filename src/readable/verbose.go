@@ -219,6 +219,7 @@ type UnconfirmedTransactionVerbose struct {
 	Checked     time.Time               `json:"checked"`
 	Announced   time.Time               `json:"announced"`
 	IsValid     bool                    `json:"is_valid"`
+	Note        string                  `json:"note,omitempty"`
 }
 
 // NewUnconfirmedTransactionVerbose creates a verbose readable unconfirmed transaction
@@ -24,6 +24,8 @@ import (
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/file"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 	"github.com/skycoin/skycoin/src/wallet"
 )
 
@@ -143,6 +145,20 @@ type NodeConfig struct {
 	VerifyDB bool
 	// Reset the database if integrity checks fail, and continue running
 	ResetCorruptDB bool
+	// Number of recent blocks whose bodies are kept, older block bodies are discarded.
+	// Pruning is disabled if 0.
+	PruneBlocks uint64
+	// Disable the transaction history, which is erased from the database
+	DisableHistory bool
+	// Run as a header-only light client, which verifies the block headers and tracks the balances of WatchAddresses only
+	LightClient bool
+	// Comma separated list of addresses watched by the light client
+	WatchAddresses string
+	watchAddresses []cipher.Address
+	// Block archive file whose blocks are executed after the head block on startup, before exiting
+	ImportBlockArchive string
+	// Number of workers verifying transaction signatures in parallel when executing blocks. If 0, the number of CPUs is used
+	SigVerifyWorkers int
 
 	// Transaction verification parameters for unconfirmed transactions
 	UnconfirmedVerifyTxn params.VerifyTxn
@@ -150,6 +166,12 @@ type NodeConfig struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum total size of transactions in a block
 	MaxBlockTransactionsSize uint32
+	// Maximum number of transactions in the unconfirmed pool. The transactions with the lowest fee are evicted when exceeded
+	MaxUnconfirmedTxns uint64
+	// Maximum total size of the transactions in the unconfirmed pool. The transactions with the lowest fee are evicted when exceeded
+	MaxUnconfirmedBytes uint64
+	// Unconfirmed transactions that were not received again within this duration are removed from the pool
+	UnconfirmedTxnTTL time.Duration
 
 	unconfirmedBurnFactor          uint64
 	maxUnconfirmedTransactionSize  uint64
@@ -176,6 +198,15 @@ type NodeConfig struct {
 	CustomPeersFile string
 
 	RunBlockPublisher bool
+	// Minimum fee in coin hours per kB of the transactions included in a block by a block publisher
+	BlockMinFeePerKB uint64
+	// Comma separated list of addresses whose transactions are included first by a block publisher
+	BlockPriorityAddresses string
+	// Maximum number of transactions spending outputs of the same address in a block created by a block publisher
+	BlockMaxTxnsPerSender int
+	// A block publisher does not publish a block that is not full until the head block is this old
+	BlockMaxAge    time.Duration
+	blockAssembler visor.BlockAssembler
 
 	/* Developer options */
 
@@ -200,6 +231,13 @@ type NodeConfig struct {
 	GenesisTimestamp    uint64
 	GenesisCoinVolume   uint64
 	DefaultConnections  []string
+	// Main chain blocks known to be valid, in the format "seq:hash"
+	Checkpoints []string
+	// Block in the format "seq:hash", whose transaction signatures and those of its main chain ancestors are not verified once it is reached. Disabled if empty
+	AssumeValid string
+
+	checkpoints []visor.Checkpoint
+	assumeValid visor.Checkpoint
 
 	genesisSignature cipher.Sig
 	genesisAddress   cipher.Address
@@ -222,6 +260,8 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		BlockchainPubkeyStr: node.BlockchainPubkeyStr,
 		BlockchainSeckeyStr: node.BlockchainSeckeyStr,
 		DefaultConnections:  node.DefaultConnections,
+		Checkpoints:         node.Checkpoints,
+		AssumeValid:         node.AssumeValid,
 		// Disable peer exchange
 		DisablePEX: false,
 		// Don't make any outgoing connections
@@ -300,6 +340,9 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 			MaxDropletPrecision: node.CreateBlockMaxDropletPrecision,
 		},
 		MaxBlockTransactionsSize: node.MaxBlockTransactionsSize,
+		MaxUnconfirmedTxns:       50000,
+		MaxUnconfirmedBytes:      32 * 1024 * 1024,
+		UnconfirmedTxnTTL:        time.Hour * 72,
 
 		// Wallets
 		WalletDirectory:  "",
@@ -452,6 +495,73 @@ func (c *Config) postProcess() error {
 		c.Node.GUIDirectory = file.ResolveResourceDirectory(c.Node.GUIDirectory)
 	}
 
+	if c.Node.PruneBlocks != 0 {
+		if c.Node.PruneBlocks < blockdb.MinPruneKeepBlocks {
+			return fmt.Errorf("-prune-blocks must be 0 or >= %d", blockdb.MinPruneKeepBlocks)
+		}
+		if c.Node.EnableGUI {
+			return errors.New("-prune-blocks cannot be used with -enable-gui, the wallet requires the transaction history")
+		}
+	}
+
+	if c.Node.LightClient {
+		if c.Node.RunBlockPublisher {
+			return errors.New("-light-client cannot be used with -block-publisher")
+		}
+		if c.Node.PruneBlocks != 0 {
+			return errors.New("-light-client cannot be used with -prune-blocks")
+		}
+		if c.Node.EnableGUI {
+			return errors.New("-light-client cannot be used with -enable-gui, the wallet requires the transaction history")
+		}
+	}
+
+	if c.Node.WatchAddresses != "" {
+		if !c.Node.LightClient {
+			return errors.New("-watch-addresses requires -light-client")
+		}
+
+		for _, s := range strings.Split(c.Node.WatchAddresses, ",") {
+			a, err := cipher.DecodeBase58Address(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("-watch-addresses has an invalid address %q: %v", s, err)
+			}
+			c.Node.watchAddresses = append(c.Node.watchAddresses, a)
+		}
+	}
+
+	if c.Node.ImportBlockArchive != "" {
+		if c.Node.DBReadOnly {
+			return errors.New("-import-block-archive cannot be used with -db-read-only")
+		}
+		if c.Node.LightClient {
+			return errors.New("-import-block-archive cannot be used with -light-client")
+		}
+	}
+
+	if c.Node.SigVerifyWorkers < 0 {
+		return errors.New("-sig-verify-workers must be >= 0")
+	}
+
+	for _, s := range c.Node.Checkpoints {
+		cp, err := visor.ParseCheckpoint(s)
+		if err != nil {
+			return err
+		}
+		c.Node.checkpoints = append(c.Node.checkpoints, cp)
+	}
+
+	if c.Node.AssumeValid != "" {
+		c.Node.assumeValid, err = visor.ParseCheckpoint(c.Node.AssumeValid)
+		if err != nil {
+			return fmt.Errorf("-assume-valid is invalid: %v", err)
+		}
+	}
+
+	if err := c.Node.postProcessBlockAssembly(); err != nil {
+		return err
+	}
+
 	if c.Node.DisableDefaultPeers {
 		c.Node.DefaultConnections = nil
 	}
@@ -558,6 +668,13 @@ func (c *Config) postProcess() error {
 		return fmt.Errorf("-max-decimals-create-block must be >= params.UserVerifyTxn.MaxDropletPrecision (%d)", params.UserVerifyTxn.MaxDropletPrecision)
 	}
 
+	if c.Node.MaxUnconfirmedBytes != 0 && c.Node.MaxUnconfirmedBytes < uint64(c.Node.UnconfirmedVerifyTxn.MaxTransactionSize) {
+		return errors.New("-max-unconfirmed-bytes must be 0 or >= -max-txn-size-unconfirmed")
+	}
+	if c.Node.UnconfirmedTxnTTL < 0 {
+		return errors.New("-unconfirmed-txn-ttl must be >= 0")
+	}
+
 	return nil
 }
 
@@ -586,6 +703,7 @@ func buildAPISets(c NodeConfig) (map[string]struct{}, error) {
 		api.EndpointsPrometheus,
 		api.EndpointsNetCtrl,
 		api.EndpointsStorage,
+		api.EndpointsDatabase,
 		// Do not include insecure or deprecated API sets, they must always
 		// be explicitly enabled through -enable-api-sets
 	}
@@ -620,7 +738,8 @@ func validateAPISets(opt string, apiSets []string) error {
 			api.EndpointsInsecureWalletSeed,
 			api.EndpointsPrometheus,
 			api.EndpointsNetCtrl,
-			api.EndpointsStorage:
+			api.EndpointsStorage,
+			api.EndpointsDatabase:
 		case "":
 			continue
 		default:
@@ -630,6 +749,49 @@ func validateAPISets(opt string, apiSets []string) error {
 	return nil
 }
 
+// postProcessBlockAssembly parses the block assembly options and creates the block assembler of a block publisher
+func (c *NodeConfig) postProcessBlockAssembly() error {
+	if c.BlockMinFeePerKB == 0 && c.BlockPriorityAddresses == "" && c.BlockMaxTxnsPerSender == 0 && c.BlockMaxAge == 0 {
+		return nil
+	}
+
+	if !c.RunBlockPublisher {
+		return errors.New("-block-min-fee-per-kb, -block-priority-addresses, -block-max-txns-per-sender and -block-max-age require -block-publisher")
+	}
+
+	if c.BlockMaxTxnsPerSender < 0 {
+		return errors.New("-block-max-txns-per-sender must be >= 0")
+	}
+
+	if c.BlockMaxAge < 0 {
+		return errors.New("-block-max-age must be >= 0")
+	}
+
+	policy := visor.BlockAssemblyPolicy{
+		MinFeePerKB:              c.BlockMinFeePerKB,
+		MaxTransactionsPerSender: c.BlockMaxTxnsPerSender,
+		MaxBlockAge:              c.BlockMaxAge,
+	}
+
+	if c.BlockPriorityAddresses != "" {
+		for _, s := range strings.Split(c.BlockPriorityAddresses, ",") {
+			a, err := cipher.DecodeBase58Address(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("-block-priority-addresses has an invalid address %q: %v", s, err)
+			}
+			policy.PriorityAddresses = append(policy.PriorityAddresses, a)
+		}
+	}
+
+	assembler, err := visor.NewPolicyBlockAssembler(policy)
+	if err != nil {
+		return err
+	}
+	c.blockAssembler = assembler
+
+	return nil
+}
+
 // RegisterFlags binds CLI flags to config values
 func (c *NodeConfig) RegisterFlags() {
 	flag.BoolVar(&help, "help", false, "Show help")
@@ -663,6 +825,7 @@ func (c *NodeConfig) RegisterFlags() {
 		api.EndpointsNetCtrl,
 		api.EndpointsInsecureWalletSeed,
 		api.EndpointsStorage,
+		api.EndpointsDatabase,
 	}
 	flag.StringVar(&c.EnabledAPISets, "enable-api-sets", c.EnabledAPISets, fmt.Sprintf("enable API set. Options are %s. Multiple values should be separated by comma", strings.Join(allAPISets, ", ")))
 	flag.StringVar(&c.DisabledAPISets, "disable-api-sets", c.DisabledAPISets, fmt.Sprintf("disable API set. Options are %s. Multiple values should be separated by comma", strings.Join(allAPISets, ", ")))
@@ -688,6 +851,13 @@ func (c *NodeConfig) RegisterFlags() {
 
 	flag.BoolVar(&c.VerifyDB, "verify-db", c.VerifyDB, "check the database for corruption")
 	flag.BoolVar(&c.ResetCorruptDB, "reset-corrupt-db", c.ResetCorruptDB, "reset the database if corrupted, and continue running instead of exiting")
+	flag.Uint64Var(&c.PruneBlocks, "prune-blocks", c.PruneBlocks, fmt.Sprintf("keep the bodies of only this many recent blocks, discarding older ones and disabling the transaction history. 0 keeps all blocks. Must be 0 or >= %d", blockdb.MinPruneKeepBlocks))
+	flag.BoolVar(&c.DisableHistory, "disable-history", c.DisableHistory, "disable the transaction history and erase it from the database, to save disk space. History dependent API endpoints return 503 errors. The history is rebuilt from the blocks if enabled again, or with the cli buildHistory command")
+	flag.BoolVar(&c.LightClient, "light-client", c.LightClient, "run as a header-only light client, which syncs and verifies the block headers without executing blocks, and answers balance queries for -watch-addresses only")
+	flag.StringVar(&c.WatchAddresses, "watch-addresses", c.WatchAddresses, "comma separated list of addresses watched by the light client")
+	flag.StringVar(&c.AssumeValid, "assume-valid", c.AssumeValid, `block in the format "seq:hash", whose transaction signatures and those of its main chain ancestors are not verified once it is reached. The block is also a checkpoint. An empty value verifies all signatures`)
+	flag.IntVar(&c.SigVerifyWorkers, "sig-verify-workers", c.SigVerifyWorkers, "number of workers verifying transaction signatures in parallel when executing blocks. 0 uses the number of CPUs")
+	flag.StringVar(&c.ImportBlockArchive, "import-block-archive", c.ImportBlockArchive, "execute and verify the blocks of a block archive created by the cli exportBlockArchive command that follow the head block, then exit")
 
 	flag.BoolVar(&c.DisableDefaultPeers, "disable-default-peers", c.DisableDefaultPeers, "disable the hardcoded default peers")
 	flag.StringVar(&c.CustomPeersFile, "custom-peers-file", c.CustomPeersFile, "load custom peers from a newline separate list of ip:port in a file. Note that this is different from the peers.json file in the data directory")
@@ -701,8 +871,15 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.Uint64Var(&c.createBlockMaxTransactionSize, "max-txn-size-create-block", uint64(c.CreateBlockVerifyTxn.MaxTransactionSize), "maximum size of a transaction applied when creating blocks")
 	flag.Uint64Var(&c.createBlockMaxDropletPrecision, "max-decimals-create-block", uint64(c.CreateBlockVerifyTxn.MaxDropletPrecision), "max number of decimal places applied when creating blocks")
 	flag.Uint64Var(&c.maxBlockSize, "max-block-size", uint64(c.MaxBlockTransactionsSize), "maximum total size of transactions in a block")
+	flag.Uint64Var(&c.MaxUnconfirmedTxns, "max-unconfirmed-txns", c.MaxUnconfirmedTxns, "maximum number of transactions in the unconfirmed pool, evicting the transactions with the lowest fee when exceeded. 0 is unlimited")
+	flag.Uint64Var(&c.MaxUnconfirmedBytes, "max-unconfirmed-bytes", c.MaxUnconfirmedBytes, "maximum total size in bytes of the transactions in the unconfirmed pool, evicting the transactions with the lowest fee when exceeded. 0 is unlimited")
+	flag.DurationVar(&c.UnconfirmedTxnTTL, "unconfirmed-txn-ttl", c.UnconfirmedTxnTTL, "remove unconfirmed transactions that were first received longer than this duration ago. 0 disables expiry")
 
 	flag.BoolVar(&c.RunBlockPublisher, "block-publisher", c.RunBlockPublisher, "run the daemon as a block publisher")
+	flag.Uint64Var(&c.BlockMinFeePerKB, "block-min-fee-per-kb", c.BlockMinFeePerKB, "minimum fee in coin hours per kB of the transactions included in a block. Requires -block-publisher")
+	flag.StringVar(&c.BlockPriorityAddresses, "block-priority-addresses", c.BlockPriorityAddresses, "comma separated list of addresses whose transactions are included in a block first, regardless of -block-min-fee-per-kb. Requires -block-publisher")
+	flag.IntVar(&c.BlockMaxTxnsPerSender, "block-max-txns-per-sender", c.BlockMaxTxnsPerSender, "maximum number of transactions spending outputs of the same address in a block. 0 is unlimited. Requires -block-publisher")
+	flag.DurationVar(&c.BlockMaxAge, "block-max-age", c.BlockMaxAge, "wait until the head block is this old before publishing a block that is not full. Requires -block-publisher")
 	flag.StringVar(&c.BlockchainPubkeyStr, "blockchain-public-key", c.BlockchainPubkeyStr, "public key of the blockchain")
 	flag.StringVar(&c.BlockchainSeckeyStr, "blockchain-secret-key", c.BlockchainSeckeyStr, "secret key of the blockchain")
 
@@ -23,6 +23,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/daemon"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/readable"
@@ -45,8 +46,9 @@ var (
 
 // Coin represents a fiber coin instance
 type Coin struct {
-	config Config
-	logger *logging.Logger
+	config   Config
+	logger   *logging.Logger
+	eventBus *eventbus.Bus
 }
 
 // Run starts the node
@@ -61,6 +63,8 @@ func (c *Coin) Run() error {
 	var retErr error
 	errC := make(chan error, 10)
 
+	defer c.eventBus.Close()
+
 	if c.config.Node.Version {
 		fmt.Println(c.config.Build.Version)
 		return nil
@@ -124,6 +128,9 @@ func (c *Coin) Run() error {
 
 	quit := make(chan struct{})
 
+	// Stops the background historydb rebuild on shutdown
+	historyQuit := make(chan struct{})
+
 	// Catch SIGINT (CTRL-C) (closes the quit channel)
 	go apputil.CatchInterrupt(quit)
 
@@ -266,6 +273,24 @@ func (c *Coin) Run() error {
 		goto earlyShutdown
 	}
 
+	if c.config.Node.ImportBlockArchive != "" {
+		if err := c.importBlockArchive(v, quit); err != nil && err != visor.ErrBlockArchiveImportStopped {
+			c.logger.WithError(err).Error("Import block archive failed")
+			retErr = err
+		}
+		goto earlyShutdown
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if err := v.RunHistoryRebuild(historyQuit); err != nil {
+			c.logger.WithError(err).Error("visor.RunHistoryRebuild failed")
+			errC <- err
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -323,6 +348,8 @@ func (c *Coin) Run() error {
 	c.logger.Info("Closing daemon")
 	d.Shutdown()
 
+	close(historyQuit)
+
 	c.logger.Info("Waiting for goroutines to finish")
 	wg.Wait()
 
@@ -345,14 +372,52 @@ earlyShutdown:
 	return retErr
 }
 
+// importBlockArchive executes the blocks of the block archive file configured by -import-block-archive
+func (c *Coin) importBlockArchive(v *visor.Visor, quit <-chan struct{}) error {
+	f, err := os.Open(c.config.Node.ImportBlockArchive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	a, err := visor.OpenBlockArchive(f, fi.Size())
+	if err != nil {
+		return err
+	}
+
+	info := a.Info()
+	c.logger.Infof("Importing blocks %d to %d from block archive %s", info.StartSeq, info.EndSeq, c.config.Node.ImportBlockArchive)
+
+	p, err := v.ImportBlockArchive(a, quit, func(p visor.BlockArchiveImportProgress) {
+		c.logger.Infof("Imported block %d/%d, %.1f blocks/s", p.Seq, p.EndSeq, p.BlocksPerSecond())
+	})
+	if p != nil {
+		c.logger.Infof("Imported %d blocks with %d transactions in %s, %.1f blocks/s", p.Blocks, p.Transactions, p.Elapsed, p.BlocksPerSecond())
+	}
+
+	return err
+}
+
 // NewCoin returns a new fiber coin instance
 func NewCoin(config Config, logger *logging.Logger) *Coin {
 	return &Coin{
-		config: config,
-		logger: logger,
+		config:   config,
+		logger:   logger,
+		eventBus: eventbus.New(),
 	}
 }
 
+// EventBus returns the bus that blockchain, unconfirmed pool and peer connection events are published to.
+// Subscribe before calling Run to receive all events. The bus is closed when Run returns.
+func (c *Coin) EventBus() *eventbus.Bus {
+	return c.eventBus
+}
+
 func (c *Coin) initLogFile() (*os.File, error) {
 	logDir := filepath.Join(c.config.Node.DataDirectory, "logs")
 	if err := createDirIfNotExist(logDir); err != nil {
@@ -389,8 +454,22 @@ func (c *Coin) ConfigureVisor() visor.Config {
 	vc.BlockchainSeckey = c.config.Node.blockchainSeckey
 
 	vc.UnconfirmedVerifyTxn = c.config.Node.UnconfirmedVerifyTxn
+	vc.EventBus = c.eventBus
+	vc.UnconfirmedPoolLimits = visor.UnconfirmedPoolLimits{
+		MaxTransactions: c.config.Node.MaxUnconfirmedTxns,
+		MaxBytes:        c.config.Node.MaxUnconfirmedBytes,
+		TTL:             c.config.Node.UnconfirmedTxnTTL,
+	}
 	vc.CreateBlockVerifyTxn = c.config.Node.CreateBlockVerifyTxn
 	vc.MaxBlockTransactionsSize = c.config.Node.MaxBlockTransactionsSize
+	vc.BlockAssembler = c.config.Node.blockAssembler
+	vc.PruneBlocks = c.config.Node.PruneBlocks
+	vc.DisableHistory = c.config.Node.DisableHistory
+	vc.LightClient = c.config.Node.LightClient
+	vc.WatchAddresses = c.config.Node.watchAddresses
+	vc.Checkpoints = c.config.Node.checkpoints
+	vc.AssumeValid = c.config.Node.assumeValid
+	vc.SigVerifyWorkers = c.config.Node.SigVerifyWorkers
 
 	vc.GenesisAddress = c.config.Node.genesisAddress
 	vc.GenesisSignature = c.config.Node.genesisSignature
@@ -465,8 +544,11 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Daemon.LogPings = !c.config.Node.DisablePingPong
 	dc.Daemon.BlockchainPubkey = c.config.Node.blockchainPubkey
 	dc.Daemon.GenesisHash = c.config.Node.genesisHash
+	dc.Daemon.PruneBlocks = c.config.Node.PruneBlocks
+	dc.Daemon.LightClient = c.config.Node.LightClient
 	dc.Daemon.UserAgent = c.config.Node.userAgent
 	dc.Daemon.UnconfirmedVerifyTxn = c.config.Node.UnconfirmedVerifyTxn
+	dc.Daemon.EventBus = c.eventBus
 
 	if c.config.Node.OutgoingConnectionsRate == 0 {
 		c.config.Node.OutgoingConnectionsRate = time.Millisecond
@@ -499,6 +581,7 @@ func (c *Coin) createGUI(gw *api.Gateway, host string) (*api.Server, error) {
 		},
 		Username: c.config.Node.WebInterfaceUsername,
 		Password: c.config.Node.WebInterfacePassword,
+		EventBus: c.eventBus,
 	}
 
 	var s *api.Server
@@ -16,8 +16,35 @@ import (
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
+// DBEngineEnv is the environment variable which selects the engine of the databases created by PrepareDB.
+// It is "bolt" by default, or "memory" to run the tests on the in-memory engine.
+const DBEngineEnv = "SKYCOIN_TEST_DB_ENGINE"
+
+// IsMemoryDB returns true if PrepareDB creates in-memory databases
+func IsMemoryDB() bool {
+	return os.Getenv(DBEngineEnv) == "memory"
+}
+
+// RequireBoltDB skips the test if PrepareDB does not create bolt databases,
+// for tests of features that need a database file
+func RequireBoltDB(t *testing.T) {
+	t.Helper()
+	if IsMemoryDB() {
+		t.Skipf("%s=memory, the test requires a bolt database", DBEngineEnv)
+	}
+}
+
 // PrepareDB creates and opens a temporary test DB and returns it with a cleanup callback
-func PrepareDB(t *testing.T) (*dbutil.DB, func()) {
+func PrepareDB(t testing.TB) (*dbutil.DB, func()) {
+	if IsMemoryDB() {
+		db := dbutil.WrapMemoryDB()
+		return db, func() {
+			if err := db.Close(); err != nil {
+				t.Logf("Failed to close database: %v", err)
+			}
+		}
+	}
+
 	f, err := ioutil.TempFile("", "testdb")
 	require.NoError(t, err)
 
@@ -36,6 +36,8 @@ var (
 //     such that there would be no change output but hours remain as change, another output will be chosen to create change,
 //     if the coinhour cost of adding that output is less than the coinhours that would be lost as change
 // If receiving hours are not explicitly specified, hours are allocated amongst the receiving outputs proportional to the number of coins being sent to them.
+// If target hours are specified, each receiving output gets exactly its target hours ("exact" mode),
+// or its target hours plus a share of the surplus hours ("minimum" mode); the remaining hours go to change.
 // If the change address is not specified, the address whose bytes are lexically sorted first is chosen from the owners of the outputs being spent.
 func Create(p Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []UxBalance, error) {
 	return create(p, auxs, headTime, 0)
@@ -127,36 +129,52 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 
 		switch p.HoursSelection.Mode {
 		case HoursSelectionModeShare:
-			// multiply remaining hours after fee burn with share factor
-			hours, err := mathutil.Uint64ToInt64(remainingHours)
+			addrHours, err = distributeShareHours(p, remainingHours)
 			if err != nil {
 				return nil, nil, err
 			}
+		default:
+			// This should have been caught by params.Validate()
+			logger.Panic("Invalid HoursSelection.Mode")
+			return nil, nil, errors.New("Invalid HoursSelection.Type")
+		}
+
+		for i, out := range p.To {
+			out.Hours = addrHours[i]
+			txn.Out = append(txn.Out, out)
+		}
 
-			allocatedHoursInt := p.HoursSelection.ShareFactor.Mul(decimal.New(hours, 0)).IntPart()
-			allocatedHours, err := mathutil.Int64ToUint64(allocatedHoursInt)
+	case HoursSelectionTypeTarget:
+		// ChooseSpends guarantees that the remaining hours after the fee can satisfy
+		// the requested hours, but check again before distributing the surplus
+		if requestedHours > remainingHours {
+			logger.Critical().WithError(ErrInsufficientHours).Error("Insufficient hours for target hours after choosing spends, this should not occur")
+			return nil, nil, ErrInsufficientHours
+		}
+
+		switch p.HoursSelection.Mode {
+		case HoursSelectionModeExact:
+			txn.Out = append(txn.Out, p.To...)
+
+		case HoursSelectionModeMinimum:
+			// Share the surplus hours over the targets, leaving the targets untouched
+			addrHours, err := distributeShareHours(p, remainingHours-requestedHours)
 			if err != nil {
 				return nil, nil, err
 			}
 
-			toCoins := make([]uint64, len(p.To))
-			for i, to := range p.To {
-				toCoins[i] = to.Coins
+			for i, out := range p.To {
+				out.Hours, err = mathutil.AddUint64(out.Hours, addrHours[i])
+				if err != nil {
+					return nil, nil, err
+				}
+				txn.Out = append(txn.Out, out)
 			}
 
-			addrHours, err = DistributeCoinHoursProportional(toCoins, allocatedHours)
-			if err != nil {
-				return nil, nil, err
-			}
 		default:
 			// This should have been caught by params.Validate()
 			logger.Panic("Invalid HoursSelection.Mode")
-			return nil, nil, errors.New("Invalid HoursSelection.Type")
-		}
-
-		for i, out := range p.To {
-			out.Hours = addrHours[i]
-			txn.Out = append(txn.Out, out)
+			return nil, nil, errors.New("Invalid HoursSelection.Mode")
 		}
 
 	default:
@@ -249,9 +267,9 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		}
 	}
 
-	// With auto share mode, if there are leftover hours and change couldn't be force-added,
+	// With auto share mode or target minimum mode, if there are leftover hours and change couldn't be force-added,
 	// recalculate that share ratio at 100%
-	if changeCoins == 0 && changeHours > 0 && p.HoursSelection.Type == HoursSelectionTypeAuto && p.HoursSelection.Mode == HoursSelectionModeShare {
+	if changeCoins == 0 && changeHours > 0 && p.HoursSelection.UsesShareFactor() {
 		logger.Debug("Recalculating share factor at 1.0 to avoid burning change hours")
 		oneDecimal := decimal.New(1, 0)
 
@@ -335,6 +353,28 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 	return txn, inputs, nil
 }
 
+// distributeShareHours multiplies hours by the share factor and distributes the result
+// amongst the destination outputs, proportional to their coins
+func distributeShareHours(p Params, hours uint64) ([]uint64, error) {
+	hoursInt64, err := mathutil.Uint64ToInt64(hours)
+	if err != nil {
+		return nil, err
+	}
+
+	allocatedHoursInt := p.HoursSelection.ShareFactor.Mul(decimal.New(hoursInt64, 0)).IntPart()
+	allocatedHours, err := mathutil.Int64ToUint64(allocatedHoursInt)
+	if err != nil {
+		return nil, err
+	}
+
+	toCoins := make([]uint64, len(p.To))
+	for i, to := range p.To {
+		toCoins[i] = to.Coins
+	}
+
+	return DistributeCoinHoursProportional(toCoins, allocatedHours)
+}
+
 func verifyCreatedUnignedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance) error {
 	if !txn.IsFullyUnsigned() {
 		return errors.New("Transaction is not fully unsigned")
@@ -377,7 +417,11 @@ func VerifyCreatedInvariants(p Params, txn *coin.Transaction, inputs []UxBalance
 			return errors.New("Output coins does not match requested coins")
 		}
 
-		if p.To[i].Hours != 0 && o.Hours != p.To[i].Hours {
+		if p.HoursSelection.Type == HoursSelectionTypeTarget && p.HoursSelection.Mode == HoursSelectionModeMinimum {
+			if o.Hours < p.To[i].Hours {
+				return errors.New("Output hours is less than the requested minimum hours")
+			}
+		} else if p.To[i].Hours != 0 && o.Hours != p.To[i].Hours {
 			return errors.New("Output hours does not match requested hours")
 		}
 	}
@@ -620,6 +620,125 @@ func TestCreate(t *testing.T) {
 			toExpectedHours: []uint64{55, 108, 108, 1},
 		},
 
+		{
+			name: "target exact, multiple outputs",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+					Mode: HoursSelectionModeExact,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   1e6,
+						Hours:   10,
+					},
+					{
+						Address: addrs[1],
+						Coins:   2e6,
+						Hours:   20,
+					},
+					{
+						Address: addrs[4],
+						Coins:   1e3,
+						Hours:   5,
+					},
+				},
+			},
+			unspents:       uxouts,
+			chosenUnspents: []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			changeOutput: &coin.TransactionOutput{
+				Address: changeAddress,
+				Hours:   145,
+				Coins:   4e6 - (3e6 + 1e3),
+			},
+		},
+
+		{
+			name: "target minimum, multiple outputs, share factor 0.5",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeTarget,
+					Mode:        HoursSelectionModeMinimum,
+					ShareFactor: newShareFactor("0.5"),
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   1e6,
+						Hours:   10,
+					},
+					{
+						Address: addrs[1],
+						Coins:   2e6,
+						Hours:   20,
+					},
+					{
+						Address: addrs[4],
+						Coins:   1e3,
+						Hours:   5,
+					},
+				},
+			},
+			unspents:       uxouts,
+			chosenUnspents: []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			changeOutput: &coin.TransactionOutput{
+				Address: changeAddress,
+				Hours:   73,
+				Coins:   4e6 - (3e6 + 1e3),
+			},
+			toExpectedHours: []uint64{34, 67, 6},
+		},
+
+		{
+			name: "target minimum, share factor 0.5, switch to 1.0 because no change could be made",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeTarget,
+					Mode:        HoursSelectionModeMinimum,
+					ShareFactor: newShareFactor("0.5"),
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   2e6,
+						Hours:   10,
+					},
+					{
+						Address: addrs[1],
+						Coins:   2e6,
+						Hours:   20,
+					},
+				},
+			},
+			unspents:        []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			chosenUnspents:  []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			toExpectedHours: []uint64{85, 95},
+		},
+
+		{
+			name: "target exact, insufficient hours after fee",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+					Mode: HoursSelectionModeExact,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   1e6,
+						Hours:   1000,
+					},
+				},
+			},
+			unspents: uxouts,
+			err:      ErrInsufficientHours,
+		},
+
 		{
 			name:     "no coin hours in inputs",
 			unspents: uxoutsNoHours[:],
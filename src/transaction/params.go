@@ -28,9 +28,17 @@ const (
 	HoursSelectionTypeManual = "manual"
 	// HoursSelectionTypeAuto is used to specify automatic hours selection in advanced spend
 	HoursSelectionTypeAuto = "auto"
+	// HoursSelectionTypeTarget is used to specify per-destination target hours in advanced spend
+	HoursSelectionTypeTarget = "target"
 
 	// HoursSelectionModeShare will distribute coin hours equally amongst destinations
 	HoursSelectionModeShare = "share"
+	// HoursSelectionModeExact will send exactly the requested hours to each destination,
+	// with the remaining hours sent to the change output
+	HoursSelectionModeExact = "exact"
+	// HoursSelectionModeMinimum will send at least the requested hours to each destination,
+	// with the remaining hours shared between the destinations and the change output
+	HoursSelectionModeMinimum = "minimum"
 )
 
 var (
@@ -54,10 +62,16 @@ var (
 	ErrInvalidHoursSelectionModeManual = NewError(errors.New("HoursSelection.Mode cannot be used for manual type hours selection"))
 	// ErrInvalidHoursSelectionType Invalid HoursSelection.Type
 	ErrInvalidHoursSelectionType = NewError(errors.New("Invalid HoursSelection.Type"))
+	// ErrZeroHoursReceiverTarget To.Hours must not be zero for target type hours selection
+	ErrZeroHoursReceiverTarget = NewError(errors.New("To.Hours must not be zero for target type hours selection"))
+	// ErrMissingHoursSelectionModeTarget HoursSelection.Mode is required for target type hours selection
+	ErrMissingHoursSelectionModeTarget = NewError(errors.New("HoursSelection.Mode is required for target type hours selection"))
 	// ErrMissingShareFactor HoursSelection.ShareFactor must be set for share mode
 	ErrMissingShareFactor = NewError(errors.New("HoursSelection.ShareFactor must be set for share mode"))
-	// ErrInvalidShareFactor HoursSelection.ShareFactor can only be used for share mode
-	ErrInvalidShareFactor = NewError(errors.New("HoursSelection.ShareFactor can only be used for share mode"))
+	// ErrMissingShareFactorMinimum HoursSelection.ShareFactor must be set for minimum mode
+	ErrMissingShareFactorMinimum = NewError(errors.New("HoursSelection.ShareFactor must be set for minimum mode"))
+	// ErrInvalidShareFactor HoursSelection.ShareFactor can only be used for share or minimum mode
+	ErrInvalidShareFactor = NewError(errors.New("HoursSelection.ShareFactor can only be used for share or minimum mode"))
 	// ErrShareFactorOutOfRange HoursSelection.ShareFactor must be >= 0 and <= 1
 	ErrShareFactorOutOfRange = NewError(errors.New("HoursSelection.ShareFactor must be >= 0 and <= 1"))
 )
@@ -69,6 +83,16 @@ type HoursSelection struct {
 	ShareFactor *decimal.Decimal
 }
 
+// UsesShareFactor returns true if the hours selection mode distributes a share of the remaining hours
+func (h HoursSelection) UsesShareFactor() bool {
+	switch h.Mode {
+	case HoursSelectionModeShare, HoursSelectionModeMinimum:
+		return true
+	default:
+		return false
+	}
+}
+
 // Params defines control parameters for transaction construction
 type Params struct {
 	HoursSelection HoursSelection
@@ -131,16 +155,34 @@ func (c Params) Validate() error {
 			return ErrInvalidHoursSelectionModeManual
 		}
 
+	case HoursSelectionTypeTarget:
+		for _, to := range c.To {
+			if to.Hours == 0 {
+				return ErrZeroHoursReceiverTarget
+			}
+		}
+
+		switch c.HoursSelection.Mode {
+		case HoursSelectionModeExact, HoursSelectionModeMinimum:
+		case "":
+			return ErrMissingHoursSelectionModeTarget
+		default:
+			return ErrInvalidHoursSelelectionMode
+		}
+
 	default:
 		return ErrInvalidHoursSelectionType
 	}
 
 	if c.HoursSelection.ShareFactor == nil {
-		if c.HoursSelection.Mode == HoursSelectionModeShare {
+		switch c.HoursSelection.Mode {
+		case HoursSelectionModeShare:
 			return ErrMissingShareFactor
+		case HoursSelectionModeMinimum:
+			return ErrMissingShareFactorMinimum
 		}
 	} else {
-		if c.HoursSelection.Mode != HoursSelectionModeShare {
+		if !c.HoursSelection.UsesShareFactor() {
 			return ErrInvalidShareFactor
 		}
 
@@ -39,6 +39,19 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 		},
 	}
 
+	toTarget := []coin.TransactionOutput{
+		{
+			Address: testutil.MakeAddress(),
+			Coins:   1e6,
+			Hours:   10,
+		},
+		{
+			Address: testutil.MakeAddress(),
+			Coins:   5e6,
+			Hours:   20,
+		},
+	}
+
 	one := decimal.New(1, 0)
 	negativeOne := decimal.New(-1, 0)
 	onePointOne := decimal.New(11, -1)
@@ -190,7 +203,87 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 					ShareFactor: &one,
 				},
 			},
-			err: "HoursSelection.ShareFactor can only be used for share mode",
+			err: "HoursSelection.ShareFactor can only be used for share or minimum mode",
+		},
+
+		{
+			name: "share factor set for target exact mode",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeTarget,
+					Mode:        HoursSelectionModeExact,
+					ShareFactor: &one,
+				},
+			},
+			err: "HoursSelection.ShareFactor can only be used for share or minimum mode",
+		},
+
+		{
+			name: "missing share factor for target minimum mode",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+					Mode: HoursSelectionModeMinimum,
+				},
+			},
+			err: "HoursSelection.ShareFactor must be set for minimum mode",
+		},
+
+		{
+			name: "zero hours for target type",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toManual,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+					Mode: HoursSelectionModeExact,
+				},
+			},
+			err: "To.Hours must not be zero for target type hours selection",
+		},
+
+		{
+			name: "missing mode for target type",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+				},
+			},
+			err: "HoursSelection.Mode is required for target type hours selection",
+		},
+
+		{
+			name: "share mode for target type",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeTarget,
+					Mode:        HoursSelectionModeShare,
+					ShareFactor: &one,
+				},
+			},
+			err: "Invalid HoursSelection.Mode",
+		},
+
+		{
+			name: "minimum mode for auto type",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeMinimum,
+					ShareFactor: &one,
+				},
+			},
+			err: "Invalid HoursSelection.Mode",
 		},
 
 		{
@@ -270,6 +363,31 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			name: "valid target exact",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeTarget,
+					Mode: HoursSelectionModeExact,
+				},
+			},
+		},
+
+		{
+			name: "valid target minimum",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toTarget,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeTarget,
+					Mode:        HoursSelectionModeMinimum,
+					ShareFactor: &pointOneOne,
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1,7 +1,10 @@
 package httphelper
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -51,3 +54,17 @@ func (lrw *wrappedResponseWriter) Write(buff []byte) (int, error) {
 	}
 	return retVal, err
 }
+
+// Hijack implements http.Hijacker, so that connections can be upgraded to websockets
+func (lrw *wrappedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker interface is not supported")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		lrw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
@@ -0,0 +1,399 @@
+package visor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// BlockArchiveVersion is the version of the block archive format
+const BlockArchiveVersion = 1
+
+const (
+	// blockArchiveHeaderSize is the size of the archive header: magic, version and start seq
+	blockArchiveHeaderSize = 8 + 4 + 8
+	// blockArchiveFooterSize is the size of the archive footer: index offset, block count and magic
+	blockArchiveFooterSize = 8 + 8 + 8
+	// blockArchiveProgressInterval is the number of blocks imported between progress reports
+	blockArchiveProgressInterval = 1000
+	// blockArchiveVerifyWindow is the number of blocks whose transaction signatures are verified together
+	blockArchiveVerifyWindow = 100
+)
+
+// blockArchiveMagic starts and ends a block archive file
+var blockArchiveMagic = []byte("SKYBLKAR")
+
+var (
+	// ErrBlockArchiveInvalid is returned if a file is not a block archive or is truncated
+	ErrBlockArchiveInvalid = errors.New("invalid block archive")
+	// ErrBlockArchiveChainMismatch is returned if an archive does not contain the blocks of the node's blockchain
+	ErrBlockArchiveChainMismatch = errors.New("block archive does not match the blockchain")
+	// ErrBlockArchiveImportStopped is returned when an archive import is interrupted
+	ErrBlockArchiveImportStopped = errors.New("block archive import stopped")
+)
+
+// A block archive is a sequential file of signed blocks, followed by an index of their offsets:
+//
+//     header: magic [8]byte | version uint32 | start seq uint64
+//     blocks: for each block, length uint32 | skyencoder encoded coin.SignedBlock
+//     index:  for each block, offset of the block from the start of the file uint64
+//     footer: offset of the index uint64 | block count uint64 | magic [8]byte
+//
+// Integers are little endian, like the skyencoder format.
+
+// BlockArchiveInfo describes the blocks in a block archive
+type BlockArchiveInfo struct {
+	// StartSeq is the seq of the first block in the archive
+	StartSeq uint64 `json:"start_seq"`
+	// EndSeq is the seq of the last block in the archive
+	EndSeq uint64 `json:"end_seq"`
+	// Size is the size of the archive in bytes
+	Size int64 `json:"size"`
+}
+
+// countingWriter counts the bytes written to a writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// ExportBlockArchive writes the main chain blocks from startSeq to endSeq to a block archive.
+// endSeq is lowered to the head block. It reads the database directly,
+// so it can be used on the database of a stopped node.
+func ExportBlockArchive(db *dbutil.DB, pubkey cipher.PubKey, startSeq, endSeq uint64, w io.Writer) (*BlockArchiveInfo, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return nil, err
+	}
+
+	var info *BlockArchiveInfo
+	if err := db.View("ExportBlockArchive", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		if endSeq > headSeq {
+			endSeq = headSeq
+		}
+		if startSeq > endSeq {
+			return fmt.Errorf("block %d does not exist, the head block is %d", startSeq, headSeq)
+		}
+
+		// The bodies of pruned blocks are not available. The genesis block is never pruned.
+		prunedSeq, ok, err := bc.PrunedSeq(tx)
+		if err != nil {
+			return err
+		}
+		if ok && endSeq > 0 && startSeq <= prunedSeq {
+			return fmt.Errorf("the bodies of blocks 1 to %d have been pruned", prunedSeq)
+		}
+
+		info, err = writeBlockArchive(tx, bc, startSeq, endSeq, w)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func writeBlockArchive(tx *dbutil.Tx, bc *Blockchain, startSeq, endSeq uint64, w io.Writer) (*BlockArchiveInfo, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	header := make([]byte, blockArchiveHeaderSize)
+	copy(header, blockArchiveMagic)
+	binary.LittleEndian.PutUint32(header[8:], BlockArchiveVersion)
+	binary.LittleEndian.PutUint64(header[12:], startSeq)
+	if _, err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	index := make([]byte, 0, (endSeq-startSeq+1)*8)
+	length := make([]byte, 4)
+	for seq := startSeq; seq <= endSeq; seq++ {
+		b, err := bc.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return nil, err
+		} else if b == nil {
+			return nil, NewErrBlockNotExist(seq)
+		}
+
+		buf, err := blockdb.EncodeSignedBlock(b)
+		if err != nil {
+			return nil, err
+		}
+
+		index = appendUint64(index, uint64(cw.n))
+
+		binary.LittleEndian.PutUint32(length, uint32(len(buf)))
+		if _, err := cw.Write(length); err != nil {
+			return nil, err
+		}
+		if _, err := cw.Write(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	indexOffset := uint64(cw.n)
+	if _, err := cw.Write(index); err != nil {
+		return nil, err
+	}
+
+	footer := make([]byte, 0, blockArchiveFooterSize)
+	footer = appendUint64(footer, indexOffset)
+	footer = appendUint64(footer, endSeq-startSeq+1)
+	footer = append(footer, blockArchiveMagic...)
+	if _, err := cw.Write(footer); err != nil {
+		return nil, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return &BlockArchiveInfo{
+		StartSeq: startSeq,
+		EndSeq:   endSeq,
+		Size:     cw.n,
+	}, nil
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// BlockArchive reads the blocks of a block archive
+type BlockArchive struct {
+	r           io.ReaderAt
+	info        BlockArchiveInfo
+	index       []uint64
+	indexOffset uint64
+}
+
+// OpenBlockArchive reads the header, footer and index of a block archive of the given size
+func OpenBlockArchive(r io.ReaderAt, size int64) (*BlockArchive, error) {
+	if size < blockArchiveHeaderSize+blockArchiveFooterSize {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	header := make([]byte, blockArchiveHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(header[:8], blockArchiveMagic) {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	if v := binary.LittleEndian.Uint32(header[8:]); v != BlockArchiveVersion {
+		return nil, fmt.Errorf("unsupported block archive version %d", v)
+	}
+
+	footer := make([]byte, blockArchiveFooterSize)
+	if _, err := r.ReadAt(footer, size-blockArchiveFooterSize); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(footer[16:], blockArchiveMagic) {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	indexOffset := binary.LittleEndian.Uint64(footer)
+	count := binary.LittleEndian.Uint64(footer[8:])
+	if count == 0 || indexOffset < blockArchiveHeaderSize || indexOffset+count*8 != uint64(size-blockArchiveFooterSize) {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	indexBuf := make([]byte, count*8)
+	if _, err := r.ReadAt(indexBuf, int64(indexOffset)); err != nil {
+		return nil, err
+	}
+
+	index := make([]uint64, count)
+	for i := range index {
+		index[i] = binary.LittleEndian.Uint64(indexBuf[i*8:])
+	}
+
+	startSeq := binary.LittleEndian.Uint64(header[12:])
+
+	return &BlockArchive{
+		r: r,
+		info: BlockArchiveInfo{
+			StartSeq: startSeq,
+			EndSeq:   startSeq + count - 1,
+			Size:     size,
+		},
+		index:       index,
+		indexOffset: indexOffset,
+	}, nil
+}
+
+// Info returns the block range and size of the archive
+func (a *BlockArchive) Info() BlockArchiveInfo {
+	return a.info
+}
+
+// GetBlock reads the block with the given seq from the archive
+func (a *BlockArchive) GetBlock(seq uint64) (*coin.SignedBlock, error) {
+	if seq < a.info.StartSeq || seq > a.info.EndSeq {
+		return nil, NewErrBlockNotExist(seq)
+	}
+
+	i := seq - a.info.StartSeq
+
+	// A block ends where the next block or the index starts
+	start := a.index[i]
+	end := a.indexOffset
+	if i+1 < uint64(len(a.index)) {
+		end = a.index[i+1]
+	}
+	if start+4 > end || end > a.indexOffset {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	buf := make([]byte, end-start)
+	if _, err := a.r.ReadAt(buf, int64(start)); err != nil {
+		return nil, err
+	}
+
+	if uint64(binary.LittleEndian.Uint32(buf)) != uint64(len(buf)-4) {
+		return nil, ErrBlockArchiveInvalid
+	}
+
+	b, err := blockdb.DecodeSignedBlockExact(buf[4:])
+	if err != nil {
+		return nil, fmt.Errorf("decode block %d failed: %v", seq, err)
+	}
+
+	if b.Seq() != seq {
+		return nil, fmt.Errorf("block archive has block %d in place of block %d", b.Seq(), seq)
+	}
+
+	return b, nil
+}
+
+// BlockArchiveImportProgress reports the progress of a block archive import
+type BlockArchiveImportProgress struct {
+	// Seq is the seq of the last block executed
+	Seq uint64
+	// EndSeq is the seq of the last block in the archive
+	EndSeq uint64
+	// Blocks is the number of blocks executed
+	Blocks uint64
+	// Transactions is the number of transactions in the executed blocks
+	Transactions uint64
+	// Elapsed is the time spent executing the blocks
+	Elapsed time.Duration
+}
+
+// BlocksPerSecond returns the rate the blocks were executed at
+func (p BlockArchiveImportProgress) BlocksPerSecond() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Blocks) / p.Elapsed.Seconds()
+}
+
+// ImportBlockArchive executes the blocks of an archive that follow the head block.
+// Each block is executed with ExecuteSignedBlock, so it is fully verified like a block received from a peer.
+// If the archive contains the head block, it must match the head block of the blockchain.
+// progress is called every blockArchiveProgressInterval blocks and after the last block, if not nil.
+// The quit channel is optional and if closed, the import stops after the current block.
+func (vs *Visor) ImportBlockArchive(a *BlockArchive, quit <-chan struct{}, progress func(BlockArchiveImportProgress)) (*BlockArchiveImportProgress, error) {
+	info := a.Info()
+
+	headSeq, ok, err := vs.HeadBkSeq()
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, blockdb.ErrNoHeadBlock
+	}
+
+	if info.StartSeq > headSeq+1 {
+		return nil, fmt.Errorf("block archive starts at block %d, which does not follow the head block %d", info.StartSeq, headSeq)
+	}
+
+	// Check that the archive continues the node's blockchain
+	if headSeq >= info.StartSeq && headSeq <= info.EndSeq {
+		b, err := a.GetBlock(headSeq)
+		if err != nil {
+			return nil, err
+		}
+
+		head, err := vs.GetSignedBlockBySeq(headSeq)
+		if err != nil {
+			return nil, err
+		}
+
+		if head == nil || b.HashHeader() != head.HashHeader() {
+			return nil, ErrBlockArchiveChainMismatch
+		}
+	}
+
+	p := BlockArchiveImportProgress{
+		Seq:    headSeq,
+		EndSeq: info.EndSeq,
+	}
+
+	start := time.Now()
+	window := make([]coin.SignedBlock, 0, blockArchiveVerifyWindow)
+	for seq := headSeq + 1; seq <= info.EndSeq; {
+		// Read a window of blocks and verify their transaction signatures together
+		window = window[:0]
+		for ; seq <= info.EndSeq && len(window) < blockArchiveVerifyWindow; seq++ {
+			b, err := a.GetBlock(seq)
+			if err != nil {
+				return &p, err
+			}
+			window = append(window, *b)
+		}
+
+		if err := vs.VerifyPendingBlockSignatures(window); err != nil {
+			return &p, err
+		}
+
+		for _, b := range window {
+			select {
+			case <-quit:
+				return &p, ErrBlockArchiveImportStopped
+			default:
+			}
+
+			if err := vs.ExecuteSignedBlock(b); err != nil {
+				return &p, fmt.Errorf("execute block %d failed: %v", b.Seq(), err)
+			}
+
+			p.Seq = b.Seq()
+			p.Blocks++
+			p.Transactions += uint64(len(b.Body.Transactions))
+			p.Elapsed = time.Since(start)
+
+			if progress != nil && (p.Blocks%blockArchiveProgressInterval == 0 || p.Seq == info.EndSeq) {
+				progress(p)
+			}
+		}
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,299 @@
+package visor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// blockArchiveFixture is a block archive of the blocks of src/api/integration/testdata/blockchain-180.db,
+// exported with the exportBlockArchive command. The blocks are signed by blockchainPubkeyStr.
+const blockArchiveFixture = "./testdata/blockchain-180.archive"
+
+// openBlockArchiveFixture opens the recorded block archive fixture
+func openBlockArchiveFixture(t testing.TB) *BlockArchive {
+	data, err := ioutil.ReadFile(blockArchiveFixture)
+	require.NoError(t, err)
+
+	a, err := OpenBlockArchive(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	return a
+}
+
+// newBlockArchiveFixtureChain creates a testChain with the genesis block of the recorded block archive fixture
+func newBlockArchiveFixtureChain(t testing.TB, a *BlockArchive) *testChain {
+	db, shutdown := prepareDB(t)
+
+	pubkey := cipher.MustPubKeyFromHex(blockchainPubkeyStr)
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey: pubkey,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	gb, err := a.GetBlock(0)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.BlockchainPubkey = pubkey
+	cfg.GenesisSignature = gb.Sig
+	cfg.GenesisAddress = gb.Body.Transactions[0].Out[0].Address
+	cfg.Distribution = params.MainNetDistribution
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return v.executeSignedBlock(tx, *gb)
+	})
+	require.NoError(t, err)
+
+	return &testChain{
+		t:        t,
+		v:        v,
+		shutdown: shutdown,
+		now:      gb.Time(),
+	}
+}
+
+// addSpendBlocks adds n blocks to the chain, each with a transaction that sends coins from genAddress.
+// The change outputs of the head block are split in two, and each block spends the output
+// that was not created by the previous block, so that it has earned coin hours for the fee.
+func addSpendBlocks(t testing.TB, c *testChain, n int) {
+	head := c.head()
+	txn := head.Body.Transactions[len(head.Body.Transactions)-1]
+	uxs := coin.CreateUnspents(head.Head, txn)
+	ux := uxs[len(uxs)-1]
+
+	makeTxn := func(ux coin.UxOut, outputs ...coin.TransactionOutput) coin.Transaction {
+		var txn coin.Transaction
+		err := txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		for _, o := range outputs {
+			err = txn.PushOutput(o.Address, o.Coins, o.Hours)
+			require.NoError(t, err)
+		}
+		txn.SignInputs([]cipher.SecKey{genSecret})
+		err = txn.UpdateHeader()
+		require.NoError(t, err)
+		return txn
+	}
+
+	hours, err := ux.CoinHours(head.Time())
+	require.NoError(t, err)
+	half := ux.Body.Coins / 2
+	txn = makeTxn(ux, coin.TransactionOutput{
+		Address: genAddress,
+		Coins:   half,
+		Hours:   hours / 4,
+	}, coin.TransactionOutput{
+		Address: genAddress,
+		Coins:   ux.Body.Coins - half,
+		Hours:   hours / 8,
+	})
+	b := c.addBlock(txn)
+	pending := coin.CreateUnspents(b.Head, txn)
+
+	toAddr := testutil.MakeAddress()
+	for i := 1; i < n; i++ {
+		ux := pending[0]
+		hours, err := ux.CoinHours(c.head().Time())
+		require.NoError(t, err)
+
+		txn := makeTxn(ux, coin.TransactionOutput{
+			Address: toAddr,
+			Coins:   1e6,
+			Hours:   hours / 4,
+		}, coin.TransactionOutput{
+			Address: genAddress,
+			Coins:   ux.Body.Coins - 1e6,
+			Hours:   hours / 4,
+		})
+		b := c.addBlock(txn)
+		pending = coin.UxArray{pending[1], coin.CreateUnspents(b.Head, txn)[1]}
+	}
+}
+
+func exportTestBlockArchive(t testing.TB, c *testChain, startSeq, endSeq uint64) *BlockArchive {
+	var buf bytes.Buffer
+	info, err := ExportBlockArchive(c.v.db, genPublic, startSeq, endSeq, &buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), info.Size)
+
+	a, err := OpenBlockArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, *info, a.Info())
+
+	return a
+}
+
+func TestBlockArchive(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 5)
+
+	// The end seq is lowered to the head block
+	a := exportTestBlockArchive(t, c, 0, math.MaxUint64)
+	require.Equal(t, uint64(0), a.Info().StartSeq)
+	require.Equal(t, uint64(5), a.Info().EndSeq)
+
+	for i := uint64(0); i <= 5; i++ {
+		b, err := a.GetBlock(i)
+		require.NoError(t, err)
+		require.Equal(t, c.block(i), *b)
+	}
+
+	_, err := a.GetBlock(6)
+	require.Equal(t, NewErrBlockNotExist(6), err)
+
+	a = exportTestBlockArchive(t, c, 2, 3)
+	require.Equal(t, uint64(2), a.Info().StartSeq)
+	require.Equal(t, uint64(3), a.Info().EndSeq)
+
+	b, err := a.GetBlock(3)
+	require.NoError(t, err)
+	require.Equal(t, c.block(3), *b)
+
+	_, err = a.GetBlock(1)
+	require.Equal(t, NewErrBlockNotExist(1), err)
+
+	var buf bytes.Buffer
+	_, err = ExportBlockArchive(c.v.db, genPublic, 6, 10, &buf)
+	require.Error(t, err)
+
+	// Invalid or truncated archives can't be opened
+	_, err = ExportBlockArchive(c.v.db, genPublic, 0, 5, &buf)
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	_, err = OpenBlockArchive(bytes.NewReader(data[:len(data)-1]), int64(len(data)-1))
+	require.Equal(t, ErrBlockArchiveInvalid, err)
+
+	_, err = OpenBlockArchive(bytes.NewReader(data[:10]), 10)
+	require.Equal(t, ErrBlockArchiveInvalid, err)
+
+	badMagic := append([]byte{}, data...)
+	badMagic[0] = 'X'
+	_, err = OpenBlockArchive(bytes.NewReader(badMagic), int64(len(badMagic)))
+	require.Equal(t, ErrBlockArchiveInvalid, err)
+}
+
+func TestImportBlockArchive(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 5)
+
+	a := exportTestBlockArchive(t, c, 0, 5)
+
+	// Import into a chain with only the genesis block
+	f := newTestChain(t)
+	defer f.shutdown()
+
+	var reports []BlockArchiveImportProgress
+	p, err := f.v.ImportBlockArchive(a, nil, func(p BlockArchiveImportProgress) {
+		reports = append(reports, p)
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), p.Seq)
+	require.Equal(t, uint64(5), p.EndSeq)
+	require.Equal(t, uint64(5), p.Blocks)
+	require.Equal(t, uint64(5), p.Transactions)
+	require.Len(t, reports, 1)
+	require.Equal(t, *p, reports[0])
+
+	f.requireSameState(c)
+
+	// Importing again does nothing
+	p, err = f.v.ImportBlockArchive(a, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), p.Blocks)
+
+	// An archive that starts after the head block can't be imported
+	addSpendBlocks(t, c, 2)
+	g := newTestChain(t)
+	defer g.shutdown()
+
+	_, err = g.v.ImportBlockArchive(exportTestBlockArchive(t, c, 2, 7), nil, nil)
+	require.Error(t, err)
+
+	// An archive that starts before the head block must contain the head block
+	f.now = c.now
+	addSpendBlocks(t, f, 1)
+	_, err = f.v.ImportBlockArchive(exportTestBlockArchive(t, c, 2, 7), nil, nil)
+	require.Equal(t, ErrBlockArchiveChainMismatch, err)
+
+	h := c.fork(3)
+	defer h.shutdown()
+
+	p, err = h.v.ImportBlockArchive(exportTestBlockArchive(t, c, 2, 7), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), p.Blocks)
+	h.requireSameState(c)
+
+	// The import can be stopped
+	quit := make(chan struct{})
+	close(quit)
+	_, err = g.v.ImportBlockArchive(a, quit, nil)
+	require.Equal(t, ErrBlockArchiveImportStopped, err)
+}
+
+func TestImportBlockArchiveFixture(t *testing.T) {
+	a := openBlockArchiveFixture(t)
+	require.Equal(t, uint64(0), a.Info().StartSeq)
+	require.Equal(t, uint64(180), a.Info().EndSeq)
+
+	c := newBlockArchiveFixtureChain(t, a)
+	defer c.shutdown()
+
+	p, err := c.v.ImportBlockArchive(a, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(180), p.Blocks)
+
+	last, err := a.GetBlock(180)
+	require.NoError(t, err)
+	require.Equal(t, last.HashHeader(), c.head().HashHeader())
+}
+
+// BenchmarkImportBlockArchive measures the execution and verification of blocks imported from an archive
+func BenchmarkImportBlockArchive(b *testing.B) {
+	c := newTestChain(b)
+	defer c.shutdown()
+
+	addSpendBlocks(b, c, 100)
+
+	a := exportTestBlockArchive(b, c, 0, math.MaxUint64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		f := newTestChain(b)
+		b.StartTimer()
+
+		_, err := f.v.ImportBlockArchive(a, nil, nil)
+		require.NoError(b, err)
+
+		b.StopTimer()
+		f.shutdown()
+		b.StartTimer()
+	}
+}
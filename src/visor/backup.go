@@ -0,0 +1,119 @@
+package visor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/skycoin/skycoin/src/util/file"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// ErrBackupChecksumMismatch is returned if a backup does not match its SHA256 checksum
+var ErrBackupChecksumMismatch = errors.New("backup does not match its checksum")
+
+// BackupManifest describes a database backup, so that the copy can be checked for corruption
+type BackupManifest struct {
+	// HeadSeq is the head block of the backup
+	HeadSeq uint64 `json:"head_seq"`
+	// Size is the size of the backup file in bytes
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA256 checksum of the backup file
+	SHA256 string `json:"sha256"`
+	// CreatedAt is when the backup was made
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupManifestPath returns the path of the manifest of a backup file
+func BackupManifestPath(backupPath string) string {
+	return backupPath + ".manifest.json"
+}
+
+// SaveBackupManifest writes the manifest of a backup file next to it
+func SaveBackupManifest(backupPath string, m BackupManifest) error {
+	return file.SaveJSON(BackupManifestPath(backupPath), m, 0600)
+}
+
+// LoadBackupManifest reads the manifest of a backup file
+func LoadBackupManifest(backupPath string) (*BackupManifest, error) {
+	var m BackupManifest
+	if err := file.LoadJSON(BackupManifestPath(backupPath), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Backup writes a consistent copy of the database to w while the node keeps running.
+// The copy is made inside a read transaction, so blocks can still be executed while it is written.
+// If begin is not nil, it is called with the manifest before any data is written. The checksum is not set yet.
+func (vs *Visor) Backup(w io.Writer, begin func(BackupManifest)) (*BackupManifest, error) {
+	var m BackupManifest
+	if err := vs.db.View("Backup", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		m.HeadSeq = headSeq
+		m.Size = tx.Size()
+		m.CreatedAt = time.Now().UTC()
+
+		if begin != nil {
+			begin(m)
+		}
+
+		h := sha256.New()
+		n, err := tx.WriteTo(io.MultiWriter(w, h))
+		if err != nil {
+			return err
+		}
+		if n != m.Size {
+			return fmt.Errorf("backup wrote %d bytes, expected %d", n, m.Size)
+		}
+
+		m.SHA256 = hex.EncodeToString(h.Sum(nil))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// VerifyBackupChecksum checks that a backup file matches the size and checksum in its manifest.
+// It does not verify the blockchain data in the backup, which is done by CheckDatabase.
+func VerifyBackupChecksum(backupPath string) (*BackupManifest, error) {
+	m, err := LoadBackupManifest(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("load backup manifest failed: %v", err)
+	}
+
+	f, err := os.Open(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if n != m.Size {
+		return nil, fmt.Errorf("backup file is %d bytes, the manifest size is %d bytes", n, m.Size)
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != m.SHA256 {
+		return nil, ErrBackupChecksumMismatch
+	}
+
+	return m, nil
+}
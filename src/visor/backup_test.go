@@ -0,0 +1,91 @@
+package visor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestVisorBackup(t *testing.T) {
+	testutil.RequireBoltDB(t)
+
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	var begun *BackupManifest
+	var buf bytes.Buffer
+	m, err := c.v.Backup(&buf, func(m BackupManifest) {
+		require.Equal(t, 0, buf.Len())
+		begun = &m
+	})
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(buf.Bytes())
+	require.Equal(t, c.head().Seq(), m.HeadSeq)
+	require.Equal(t, int64(buf.Len()), m.Size)
+	require.Equal(t, hex.EncodeToString(sum[:]), m.SHA256)
+
+	require.NotNil(t, begun)
+	require.Empty(t, begun.SHA256)
+	begun.SHA256 = m.SHA256
+	require.Equal(t, *m, *begun)
+
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backupPath := filepath.Join(dir, "data.db")
+	err = ioutil.WriteFile(backupPath, buf.Bytes(), 0600)
+	require.NoError(t, err)
+
+	// A backup without a manifest can't be verified
+	_, err = VerifyBackupChecksum(backupPath)
+	require.Error(t, err)
+
+	err = SaveBackupManifest(backupPath, *m)
+	require.NoError(t, err)
+
+	verified, err := VerifyBackupChecksum(backupPath)
+	require.NoError(t, err)
+	require.Equal(t, m.HeadSeq, verified.HeadSeq)
+	require.Equal(t, m.SHA256, verified.SHA256)
+	require.True(t, m.CreatedAt.Equal(verified.CreatedAt))
+
+	// The backup is a complete, valid database
+	db, err := bolt.Open(backupPath, 0600, &bolt.Options{
+		Timeout:  time.Second,
+		ReadOnly: true,
+	})
+	require.NoError(t, err)
+	err = CheckDatabase(dbutil.WrapDB(db), genPublic, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// A corrupted backup does not match the manifest
+	corrupted := append([]byte{}, buf.Bytes()...)
+	corrupted[len(corrupted)/2] ^= 0xFF
+	err = ioutil.WriteFile(backupPath, corrupted, 0600)
+	require.NoError(t, err)
+
+	_, err = VerifyBackupChecksum(backupPath)
+	require.Equal(t, ErrBackupChecksumMismatch, err)
+
+	// A truncated backup does not match the manifest
+	err = ioutil.WriteFile(backupPath, buf.Bytes()[:buf.Len()-1], 0600)
+	require.NoError(t, err)
+
+	_, err = VerifyBackupChecksum(backupPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the manifest size is")
+}
@@ -0,0 +1,305 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+)
+
+var (
+	// ErrPartialBlockHeld is returned when creating a block if the block assembler holds back a partial block
+	ErrPartialBlockHeld = errors.New("Partial block is held until the head block reaches the maximum block age")
+)
+
+// SkipReason is the reason a pending transaction was not included in a new block
+type SkipReason string
+
+const (
+	// SkipViolatesConstraints the transaction violates the soft or hard constraints for creating a block
+	SkipViolatesConstraints SkipReason = "violates constraints"
+	// SkipInvalidFee the fee of the transaction could not be computed
+	SkipInvalidFee SkipReason = "invalid fee"
+	// SkipBlockFull the transaction does not fit in the block
+	SkipBlockFull SkipReason = "block is full"
+	// SkipLowFee the fee per kB of the transaction is below the minimum
+	SkipLowFee SkipReason = "fee below minimum"
+	// SkipSenderLimit a sender of the transaction reached the maximum number of transactions per sender
+	SkipSenderLimit SkipReason = "sender transaction limit reached"
+)
+
+// PendingTransaction is an unconfirmed transaction considered for a new block
+type PendingTransaction struct {
+	Transaction coin.Transaction
+	Hash        cipher.SHA256
+	Size        uint32
+	// Fee in coin hours
+	Fee uint64
+	// Unique owners of the transaction's inputs
+	Senders []cipher.Address
+}
+
+// FeePerKB returns the fee of the transaction per kB, as used to sort transactions by fee
+func (p PendingTransaction) FeePerKB() uint64 {
+	// If the fee * 1024 would exceed math.MaxUint64, set it to math.MaxUint64 so that
+	// this transaction can still be processed
+	feeKB, err := mathutil.MultUint64(p.Fee, 1024)
+	if err != nil {
+		feeKB = math.MaxUint64
+	}
+
+	if p.Size == 0 {
+		return feeKB
+	}
+
+	return feeKB / uint64(p.Size)
+}
+
+// BlockAssemblyParams are the parameters of the block being assembled
+type BlockAssemblyParams struct {
+	// Time of the head block
+	HeadTime uint64
+	// Time of the new block
+	Time uint64
+	// Maximum total size of the transactions in the block
+	MaxBlockSize uint32
+	// Maximum number of transactions in the block
+	MaxBlockTransactions int
+}
+
+// SkippedTransaction is a pending transaction that was not included in a new block
+type SkippedTransaction struct {
+	Hash   cipher.SHA256
+	Reason SkipReason
+}
+
+// BlockAssembly is the result of assembling a block
+type BlockAssembly struct {
+	// Transactions to include in the block
+	Transactions coin.Transactions
+	// Pending transactions that were not included, with the reason
+	Skipped []SkippedTransaction
+	// Hold is true if the block should not be published yet
+	Hold bool
+}
+
+// BlockAssemblyReport is the result of the most recent block assembly
+type BlockAssemblyReport struct {
+	// Time of the block that was assembled
+	Time uint64
+	// Hashes of the transactions included in the block
+	Included []cipher.SHA256
+	// Pending transactions that were not included, with the reason
+	Skipped []SkippedTransaction
+	// Held is true if a partial block was held back
+	Held bool
+}
+
+// lastBlockAssembly keeps the result of the most recent block assembly
+type lastBlockAssembly struct {
+	sync.Mutex
+	report *BlockAssemblyReport
+}
+
+func (l *lastBlockAssembly) set(r *BlockAssemblyReport) {
+	l.Lock()
+	defer l.Unlock()
+	l.report = r
+}
+
+func (l *lastBlockAssembly) get() *BlockAssemblyReport {
+	l.Lock()
+	defer l.Unlock()
+	return l.report
+}
+
+// BlockAssembler selects the pending transactions to include in a new block.
+// The pending transactions passed to AssembleBlock satisfy the constraints for creating a block.
+type BlockAssembler interface {
+	AssembleBlock(txns []PendingTransaction, p BlockAssemblyParams) (*BlockAssembly, error)
+}
+
+// DefaultBlockAssembler includes the transactions with the highest fee per kB,
+// up to the maximum block size and number of transactions
+type DefaultBlockAssembler struct{}
+
+// AssembleBlock assembles a block from pending transactions
+func (a DefaultBlockAssembler) AssembleBlock(txns []PendingTransaction, p BlockAssemblyParams) (*BlockAssembly, error) {
+	txns = sortPendingTransactions(txns)
+
+	var ba BlockAssembly
+	fillBlock(&ba, txns, p)
+
+	return &ba, nil
+}
+
+// BlockAssemblyPolicy configures a PolicyBlockAssembler
+type BlockAssemblyPolicy struct {
+	// Minimum fee in coin hours per kB (1024 bytes) of transaction size. Disabled if 0
+	MinFeePerKB uint64
+	// Transactions spending outputs owned by these addresses are included before other transactions,
+	// and are not subject to MinFeePerKB
+	PriorityAddresses []cipher.Address
+	// Maximum number of transactions spending outputs owned by the same address in a block. Disabled if 0
+	MaxTransactionsPerSender int
+	// A block that is not full is not published until the head block is at least this old. Disabled if 0
+	MaxBlockAge time.Duration
+}
+
+// PolicyBlockAssembler assembles blocks according to a BlockAssemblyPolicy.
+// Within the priority and normal lanes, transactions are ordered by the highest fee per kB.
+type PolicyBlockAssembler struct {
+	policy   BlockAssemblyPolicy
+	priority map[cipher.Address]struct{}
+}
+
+// NewPolicyBlockAssembler creates a PolicyBlockAssembler
+func NewPolicyBlockAssembler(policy BlockAssemblyPolicy) (*PolicyBlockAssembler, error) {
+	if policy.MaxTransactionsPerSender < 0 {
+		return nil, errors.New("MaxTransactionsPerSender must be >= 0")
+	}
+
+	if policy.MaxBlockAge < 0 {
+		return nil, errors.New("MaxBlockAge must be >= 0")
+	}
+
+	priority := make(map[cipher.Address]struct{}, len(policy.PriorityAddresses))
+	for _, a := range policy.PriorityAddresses {
+		priority[a] = struct{}{}
+	}
+
+	return &PolicyBlockAssembler{
+		policy:   policy,
+		priority: priority,
+	}, nil
+}
+
+// Policy returns the block assembly policy
+func (a *PolicyBlockAssembler) Policy() BlockAssemblyPolicy {
+	return a.policy
+}
+
+// AssembleBlock assembles a block from pending transactions
+func (a *PolicyBlockAssembler) AssembleBlock(txns []PendingTransaction, p BlockAssemblyParams) (*BlockAssembly, error) {
+	var ba BlockAssembly
+
+	var priority, normal []PendingTransaction
+	for _, txn := range txns {
+		if a.isPriority(txn) {
+			priority = append(priority, txn)
+			continue
+		}
+
+		if a.policy.MinFeePerKB != 0 && txn.FeePerKB() < a.policy.MinFeePerKB {
+			ba.Skipped = append(ba.Skipped, SkippedTransaction{
+				Hash:   txn.Hash,
+				Reason: SkipLowFee,
+			})
+			continue
+		}
+
+		normal = append(normal, txn)
+	}
+
+	ordered := append(sortPendingTransactions(priority), sortPendingTransactions(normal)...)
+
+	if a.policy.MaxTransactionsPerSender != 0 {
+		counts := make(map[cipher.Address]int)
+		var allowed []PendingTransaction
+		for _, txn := range ordered {
+			if !a.senderAllowed(counts, txn) {
+				ba.Skipped = append(ba.Skipped, SkippedTransaction{
+					Hash:   txn.Hash,
+					Reason: SkipSenderLimit,
+				})
+				continue
+			}
+
+			for _, s := range txn.Senders {
+				counts[s]++
+			}
+			allowed = append(allowed, txn)
+		}
+		ordered = allowed
+	}
+
+	full := fillBlock(&ba, ordered, p)
+
+	if !full && a.policy.MaxBlockAge != 0 && len(ba.Transactions) != 0 {
+		age := time.Duration(0)
+		if p.Time > p.HeadTime {
+			age = time.Duration(p.Time-p.HeadTime) * time.Second
+		}
+		ba.Hold = age < a.policy.MaxBlockAge
+	}
+
+	return &ba, nil
+}
+
+func (a *PolicyBlockAssembler) isPriority(txn PendingTransaction) bool {
+	for _, s := range txn.Senders {
+		if _, ok := a.priority[s]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *PolicyBlockAssembler) senderAllowed(counts map[cipher.Address]int, txn PendingTransaction) bool {
+	for _, s := range txn.Senders {
+		if counts[s] >= a.policy.MaxTransactionsPerSender {
+			return false
+		}
+	}
+	return true
+}
+
+// fillBlock adds transactions to the block in order until the maximum block size or number of transactions is reached.
+// The remaining transactions are skipped. Returns true if the block is full.
+func fillBlock(ba *BlockAssembly, txns []PendingTransaction, p BlockAssemblyParams) bool {
+	var total uint32
+	full := false
+	for _, txn := range txns {
+		if !full {
+			pendingTotal, err := mathutil.AddUint32(total, txn.Size)
+			if err != nil || pendingTotal > p.MaxBlockSize || len(ba.Transactions) >= p.MaxBlockTransactions {
+				full = true
+			} else {
+				total = pendingTotal
+				ba.Transactions = append(ba.Transactions, txn.Transaction)
+				continue
+			}
+		}
+
+		ba.Skipped = append(ba.Skipped, SkippedTransaction{
+			Hash:   txn.Hash,
+			Reason: SkipBlockFull,
+		})
+	}
+
+	return full || len(ba.Transactions) >= p.MaxBlockTransactions
+}
+
+// sortPendingTransactions returns a copy of txns sorted by fee per kB, and by lowest hash if tied.
+// This is the same order as coin.SortTransactions.
+func sortPendingTransactions(txns []PendingTransaction) []PendingTransaction {
+	sorted := make([]PendingTransaction, len(txns))
+	copy(sorted, txns)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		fi := sorted[i].FeePerKB()
+		fj := sorted[j].FeePerKB()
+		if fi == fj {
+			return bytes.Compare(sorted[i].Hash[:], sorted[j].Hash[:]) < 0
+		}
+		return fi > fj
+	})
+
+	return sorted
+}
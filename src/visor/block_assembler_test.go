@@ -0,0 +1,314 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func makePendingTxn(t *testing.T, size uint32, fee uint64, senders ...cipher.Address) PendingTransaction {
+	hash := testutil.RandSHA256(t)
+	return PendingTransaction{
+		Transaction: coin.Transaction{InnerHash: hash},
+		Hash:        hash,
+		Size:        size,
+		Fee:         fee,
+		Senders:     senders,
+	}
+}
+
+func assembledTxns(txns ...PendingTransaction) coin.Transactions {
+	var out coin.Transactions
+	for _, txn := range txns {
+		out = append(out, txn.Transaction)
+	}
+	return out
+}
+
+func skippedTxns(reason SkipReason, txns ...PendingTransaction) []SkippedTransaction {
+	var out []SkippedTransaction
+	for _, txn := range txns {
+		out = append(out, SkippedTransaction{
+			Hash:   txn.Hash,
+			Reason: reason,
+		})
+	}
+	return out
+}
+
+func TestDefaultBlockAssembler(t *testing.T) {
+	a := makePendingTxn(t, 1024, 10)
+	b := makePendingTxn(t, 1024, 30)
+	c := makePendingTxn(t, 2048, 40)
+	d := makePendingTxn(t, 512, 100)
+
+	cases := []struct {
+		name     string
+		params   BlockAssemblyParams
+		txns     []PendingTransaction
+		included coin.Transactions
+		skipped  []SkippedTransaction
+	}{
+		{
+			name: "sorted by fee per kB",
+			params: BlockAssemblyParams{
+				MaxBlockSize:         1024 * 10,
+				MaxBlockTransactions: 10,
+			},
+			txns:     []PendingTransaction{a, b, c, d},
+			included: assembledTxns(d, b, c, a),
+		},
+		{
+			name: "truncated by size",
+			params: BlockAssemblyParams{
+				MaxBlockSize:         2048,
+				MaxBlockTransactions: 10,
+			},
+			txns:     []PendingTransaction{a, b, c, d},
+			included: assembledTxns(d, b),
+			skipped:  skippedTxns(SkipBlockFull, c, a),
+		},
+		{
+			name: "truncated by number of transactions",
+			params: BlockAssemblyParams{
+				MaxBlockSize:         1024 * 10,
+				MaxBlockTransactions: 1,
+			},
+			txns:     []PendingTransaction{a, b, c, d},
+			included: assembledTxns(d),
+			skipped:  skippedTxns(SkipBlockFull, b, c, a),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ba, err := DefaultBlockAssembler{}.AssembleBlock(tc.txns, tc.params)
+			require.NoError(t, err)
+			require.Equal(t, tc.included, ba.Transactions)
+			require.Equal(t, tc.skipped, ba.Skipped)
+			require.False(t, ba.Hold)
+		})
+	}
+}
+
+func TestPolicyBlockAssembler(t *testing.T) {
+	alice := testutil.MakeAddress()
+	bob := testutil.MakeAddress()
+	carol := testutil.MakeAddress()
+
+	a := makePendingTxn(t, 1024, 10, alice)
+	b := makePendingTxn(t, 1024, 30, alice)
+	c := makePendingTxn(t, 1024, 40, bob)
+	d := makePendingTxn(t, 1024, 100, alice, bob)
+	e := makePendingTxn(t, 1024, 1, carol)
+
+	now := uint64(1e9)
+	params := BlockAssemblyParams{
+		HeadTime:             now - 60,
+		Time:                 now,
+		MaxBlockSize:         1024 * 10,
+		MaxBlockTransactions: 10,
+	}
+
+	cases := []struct {
+		name     string
+		policy   BlockAssemblyPolicy
+		params   BlockAssemblyParams
+		txns     []PendingTransaction
+		included coin.Transactions
+		skipped  []SkippedTransaction
+		hold     bool
+		err      string
+	}{
+		{
+			name:     "no policy",
+			params:   params,
+			txns:     []PendingTransaction{a, b, c, d, e},
+			included: assembledTxns(d, c, b, a, e),
+		},
+		{
+			name: "minimum fee",
+			policy: BlockAssemblyPolicy{
+				MinFeePerKB: 30,
+			},
+			params:   params,
+			txns:     []PendingTransaction{a, b, c, d, e},
+			included: assembledTxns(d, c, b),
+			skipped:  skippedTxns(SkipLowFee, a, e),
+		},
+		{
+			name: "priority addresses",
+			policy: BlockAssemblyPolicy{
+				MinFeePerKB:       30,
+				PriorityAddresses: []cipher.Address{carol},
+			},
+			params:   params,
+			txns:     []PendingTransaction{a, b, c, d, e},
+			included: assembledTxns(e, d, c, b),
+			skipped:  skippedTxns(SkipLowFee, a),
+		},
+		{
+			name: "priority addresses when the block is full",
+			policy: BlockAssemblyPolicy{
+				PriorityAddresses: []cipher.Address{carol},
+			},
+			params: BlockAssemblyParams{
+				HeadTime:             params.HeadTime,
+				Time:                 params.Time,
+				MaxBlockSize:         1024 * 2,
+				MaxBlockTransactions: 10,
+			},
+			txns:     []PendingTransaction{a, b, c, d, e},
+			included: assembledTxns(e, d),
+			skipped:  skippedTxns(SkipBlockFull, c, b, a),
+		},
+		{
+			name: "transactions per sender",
+			policy: BlockAssemblyPolicy{
+				MaxTransactionsPerSender: 1,
+			},
+			params:   params,
+			txns:     []PendingTransaction{a, b, c, d, e},
+			included: assembledTxns(d, e),
+			skipped:  skippedTxns(SkipSenderLimit, c, b, a),
+		},
+		{
+			name: "partial block held",
+			policy: BlockAssemblyPolicy{
+				MaxBlockAge: time.Minute * 2,
+			},
+			params:   params,
+			txns:     []PendingTransaction{a, c},
+			included: assembledTxns(c, a),
+			hold:     true,
+		},
+		{
+			name: "partial block published after the maximum block age",
+			policy: BlockAssemblyPolicy{
+				MaxBlockAge: time.Minute,
+			},
+			params:   params,
+			txns:     []PendingTransaction{a, c},
+			included: assembledTxns(c, a),
+		},
+		{
+			name: "full block is not held",
+			policy: BlockAssemblyPolicy{
+				MaxBlockAge: time.Minute * 2,
+			},
+			params: BlockAssemblyParams{
+				HeadTime:             params.HeadTime,
+				Time:                 params.Time,
+				MaxBlockSize:         1024 * 10,
+				MaxBlockTransactions: 2,
+			},
+			txns:     []PendingTransaction{a, c},
+			included: assembledTxns(c, a),
+		},
+		{
+			name: "invalid transactions per sender",
+			policy: BlockAssemblyPolicy{
+				MaxTransactionsPerSender: -1,
+			},
+			err: "MaxTransactionsPerSender must be >= 0",
+		},
+		{
+			name: "invalid maximum block age",
+			policy: BlockAssemblyPolicy{
+				MaxBlockAge: -time.Second,
+			},
+			err: "MaxBlockAge must be >= 0",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assembler, err := NewPolicyBlockAssembler(tc.policy)
+			if tc.err != "" {
+				testutil.RequireError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			ba, err := assembler.AssembleBlock(tc.txns, tc.params)
+			require.NoError(t, err)
+			require.Equal(t, tc.included, ba.Transactions)
+			require.Equal(t, tc.skipped, ba.Skipped)
+			require.Equal(t, tc.hold, ba.Hold)
+		})
+	}
+}
+
+func TestVisorCreateBlockAssembler(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+	c.v.blockAssembly = &lastBlockAssembly{}
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, 5, params.UserVerifyTxn.MaxDropletPrecision)
+	b1 := c.addBlock(splitTxn)
+	uxs := coin.CreateUnspents(b1.Head, splitTxn)
+
+	toAddr := testutil.MakeAddress()
+	txn1 := makeSpendTxWithFee(t, coin.UxArray{uxs[0]}, []cipher.SecKey{genSecret}, toAddr, 9e6, 10)
+	txn2 := makeSpendTxWithFee(t, coin.UxArray{uxs[1]}, []cipher.SecKey{genSecret}, toAddr, 9e6, 20)
+
+	for _, txn := range []coin.Transaction{txn1, txn2} {
+		err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+			_, _, err := c.v.unconfirmed.InjectTransaction(tx, c.v.blockchain, txn, c.v.Config.Distribution, c.v.Config.UnconfirmedVerifyTxn)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	createBlock := func(when uint64) (coin.SignedBlock, error) {
+		var sb coin.SignedBlock
+		err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+			var err error
+			sb, err = c.v.createBlock(tx, when)
+			return err
+		})
+		return sb, err
+	}
+
+	// A partial block is held until the head block is old enough
+	assembler, err := NewPolicyBlockAssembler(BlockAssemblyPolicy{
+		MaxTransactionsPerSender: 1,
+		MaxBlockAge:              time.Hour,
+	})
+	require.NoError(t, err)
+	c.v.Config.BlockAssembler = assembler
+
+	_, err = createBlock(b1.Head.Time + 100)
+	require.Equal(t, ErrPartialBlockHeld, err)
+
+	report := c.v.LastBlockAssembly()
+	require.NotNil(t, report)
+	require.True(t, report.Held)
+	require.Equal(t, b1.Head.Time+100, report.Time)
+
+	// Only one transaction of the sender is included, the one with the highest fee
+	sb, err := createBlock(b1.Head.Time + 3600)
+	require.NoError(t, err)
+	require.Equal(t, coin.Transactions{txn2}, sb.Body.Transactions)
+
+	report = c.v.LastBlockAssembly()
+	require.Equal(t, &BlockAssemblyReport{
+		Time:     b1.Head.Time + 3600,
+		Included: []cipher.SHA256{txn2.Hash()},
+		Skipped: []SkippedTransaction{
+			{
+				Hash:   txn1.Hash(),
+				Reason: SkipSenderLimit,
+			},
+		},
+	}, report)
+}
@@ -43,6 +43,22 @@ func (e ErrBlockNotExist) Error() string {
 	return fmt.Sprintf("block does not exist seq=%d", e.Seq)
 }
 
+// ErrBlockPruned is returned if the body of a requested block has been pruned
+type ErrBlockPruned struct {
+	Seq uint64
+}
+
+// NewErrBlockPruned creates an ErrBlockPruned for a block whose body was pruned
+func NewErrBlockPruned(seq uint64) ErrBlockPruned {
+	return ErrBlockPruned{
+		Seq: seq,
+	}
+}
+
+func (e ErrBlockPruned) Error() string {
+	return fmt.Sprintf("block body pruned seq=%d", e.Seq)
+}
+
 //Warning: 10e6 is 10 million, 1e6 is 1 million
 
 // Note: DebugLevel1 adds additional checks for hash collisions that
@@ -67,6 +83,9 @@ type chainStore interface {
 	HeadSeq(*dbutil.Tx) (uint64, bool, error)
 	Len(*dbutil.Tx) (uint64, error)
 	AddBlock(*dbutil.Tx, *coin.SignedBlock) error
+	AddSideBlock(*dbutil.Tx, *coin.SignedBlock) error
+	ApplySideBlock(*dbutil.Tx, *coin.SignedBlock) error
+	RollbackHead(*dbutil.Tx) (*coin.SignedBlock, error)
 	GetBlockByHash(*dbutil.Tx, cipher.SHA256) (*coin.Block, error)
 	GetSignedBlockByHash(*dbutil.Tx, cipher.SHA256) (*coin.SignedBlock, error)
 	GetSignedBlockBySeq(*dbutil.Tx, uint64) (*coin.SignedBlock, error)
@@ -74,6 +93,9 @@ type chainStore interface {
 	GetGenesisBlock(*dbutil.Tx) (*coin.SignedBlock, error)
 	GetBlockSignature(*dbutil.Tx, *coin.Block) (cipher.Sig, bool, error)
 	ForEachBlock(*dbutil.Tx, func(*coin.Block) error) error
+	PrunedSeq(*dbutil.Tx) (uint64, bool, error)
+	PruneBlocks(*dbutil.Tx, uint64) error
+	LoadSnapshot(*dbutil.Tx, *coin.SignedBlock, *coin.SignedBlock, coin.UxArray) error
 }
 
 // DefaultWalker default blockchain walker
@@ -98,6 +120,8 @@ func CreateBuckets(db *dbutil.DB) error {
 		return dbutil.CreateBuckets(tx, [][]byte{
 			UnconfirmedTxnsBkt,
 			UnconfirmedUnspentsBkt,
+			UnconfirmedFirstSeenBkt,
+			UnconfirmedMetaBkt,
 		})
 	})
 }
@@ -109,13 +133,22 @@ type BlockchainConfig struct {
 	// node will throw the error and return.
 	Arbitrating bool
 	Pubkey      cipher.PubKey
+	// Checkpoints are main chain blocks known to be valid. Blocks that conflict with a checkpoint are rejected.
+	Checkpoints []Checkpoint
+	// AssumeValid is a checkpoint whose ancestors' transaction input signatures are not verified,
+	// once the blocks are known to lead to it. Disabled if the hash is null.
+	AssumeValid Checkpoint
+	// SigVerifyWorkers is the number of workers verifying the input signatures of transactions in parallel.
+	// If 0, the number of CPUs is used
+	SigVerifyWorkers int
 }
 
 // Blockchain maintains blockchain and provides apis for accessing the chain.
 type Blockchain struct {
-	db    *dbutil.DB
-	cfg   BlockchainConfig
-	store chainStore
+	db          *dbutil.DB
+	cfg         BlockchainConfig
+	store       chainStore
+	sigVerifier *txnSigVerifier
 }
 
 // NewBlockchain creates a Blockchain
@@ -126,9 +159,10 @@ func NewBlockchain(db *dbutil.DB, cfg BlockchainConfig) (*Blockchain, error) {
 	}
 
 	return &Blockchain{
-		cfg:   cfg,
-		db:    db,
-		store: chainstore,
+		cfg:         cfg,
+		db:          db,
+		store:       chainstore,
+		sigVerifier: newTxnSigVerifier(cfg.SigVerifyWorkers),
 	}, nil
 }
 
@@ -147,6 +181,23 @@ func (bc *Blockchain) GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.Sign
 	return bc.store.GetSignedBlockBySeq(tx, seq)
 }
 
+// PrunedSeq returns the sequence of the most recent block whose body was pruned.
+// Returns false if no block has been pruned.
+func (bc *Blockchain) PrunedSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	return bc.store.PrunedSeq(tx)
+}
+
+// PruneBlocks removes the bodies of the main chain blocks up to and including seq.
+// The block headers and signatures are kept.
+func (bc *Blockchain) PruneBlocks(tx *dbutil.Tx, seq uint64) error {
+	return bc.store.PruneBlocks(tx, seq)
+}
+
+// LoadSnapshot seeds an empty blockchain with an unspent output snapshot taken at the head block
+func (bc *Blockchain) LoadSnapshot(tx *dbutil.Tx, genesis, head *coin.SignedBlock, uxs coin.UxArray) error {
+	return bc.store.LoadSnapshot(tx, genesis, head, uxs)
+}
+
 // Head returns the most recent confirmed block
 func (bc Blockchain) Head(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 	return bc.store.Head(tx)
@@ -200,7 +251,7 @@ func (bc Blockchain) NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime
 		return nil, errors.New("Time can only move forward")
 	}
 
-	txns, err = bc.processTransactions(tx, txns)
+	txns, err = bc.processTransactions(tx, txns, TxnSigned)
 	if err != nil {
 		return nil, err
 	}
@@ -222,7 +273,7 @@ func (bc Blockchain) NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime
 		if err := bc.verifyBlockHeader(tx, *b); err != nil {
 			return nil, err
 		}
-		txns, err := bc.processTransactions(tx, b.Body.Transactions)
+		txns, err := bc.processTransactions(tx, b.Body.Transactions, TxnSigned)
 		if err != nil {
 			logger.Panicf("bc.processTransactions second verification call failed: %v", err)
 		}
@@ -249,7 +300,16 @@ func (bc *Blockchain) processBlock(tx *dbutil.Tx, b coin.SignedBlock) (coin.Sign
 				return coin.SignedBlock{}, err
 			}
 
-			txns, err := bc.processTransactions(tx, b.Body.Transactions)
+			if err := verifyCheckpoint(bc.cfg.Checkpoints, b.Seq(), b.HashHeader()); err != nil {
+				return coin.SignedBlock{}, err
+			}
+
+			signed, err := bc.txnSignedFlag(tx, b.Block)
+			if err != nil {
+				return coin.SignedBlock{}, err
+			}
+
+			txns, err := bc.processTransactions(tx, b.Body.Transactions, signed)
 			if err != nil {
 				return coin.SignedBlock{}, err
 			}
@@ -295,6 +355,217 @@ func (bc *Blockchain) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
 	return nil
 }
 
+// Reorganization describes a change of the main chain to a competing chain
+type Reorganization struct {
+	// RolledBack are the blocks removed from the main chain, starting from the old head
+	RolledBack []coin.SignedBlock
+	// Applied are the blocks of the competing chain added to the main chain, starting from the fork point
+	Applied []coin.SignedBlock
+}
+
+// IsForkBlock returns true if the block does not extend the head of the main chain,
+// but extends another block known to the blockchain.
+func (bc *Blockchain) IsForkBlock(tx *dbutil.Tx, b coin.Block) (bool, error) {
+	length, err := bc.Len(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if length == 0 {
+		return false, nil
+	}
+
+	head, err := bc.Head(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if b.Head.PrevHash == head.HashHeader() {
+		return false, nil
+	}
+
+	parent, err := bc.store.GetBlockByHash(tx, b.Head.PrevHash)
+	if err != nil {
+		return false, err
+	}
+
+	return parent != nil, nil
+}
+
+// isMainChainBlock returns true if the block is part of the main chain
+func (bc *Blockchain) isMainChainBlock(tx *dbutil.Tx, b coin.Block) (bool, error) {
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return false, err
+	} else if !ok || b.Seq() > headSeq {
+		return false, nil
+	}
+
+	mb, err := bc.store.GetSignedBlockBySeq(tx, b.Seq())
+	if err != nil {
+		return false, err
+	} else if mb == nil {
+		return false, nil
+	}
+
+	return mb.HashHeader() == b.HashHeader(), nil
+}
+
+// ExecuteForkBlock adds a block that extends a chain competing with the main chain.
+// If the competing chain becomes longer than the main chain, the main chain is rolled back
+// to the fork point and the competing chain is executed in its place.
+// If the competing chain is not longer, the block is only stored and nil is returned.
+// If any block of the competing chain is invalid, an error is returned and the caller must
+// discard the db transaction.
+func (bc *Blockchain) ExecuteForkBlock(tx *dbutil.Tx, sb *coin.SignedBlock) (*Reorganization, error) {
+	parent, err := bc.store.GetSignedBlockByHash(tx, sb.Head.PrevHash)
+	if err != nil {
+		return nil, err
+	} else if parent == nil {
+		return nil, errors.New("Fork block parent does not exist")
+	}
+
+	if err := verifyBlockHeaderParent(parent.Block, sb.Block); err != nil {
+		return nil, err
+	}
+
+	if err := bc.verifyForkCheckpoints(tx, sb.Block); err != nil {
+		return nil, err
+	}
+
+	if err := bc.store.AddSideBlock(tx, sb); err != nil {
+		return nil, err
+	}
+
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The longest chain is the main chain. If the chains have the same length, the main chain is kept.
+	if sb.Seq() <= head.Seq() {
+		logger.Infof("Stored fork block seq=%d hash=%s, main chain head is seq=%d", sb.Seq(), sb.HashHeader().Hex(), head.Seq())
+		return nil, nil
+	}
+
+	// Walk back the competing chain to the fork point
+	chain := []coin.SignedBlock{*sb}
+	b := parent
+	for {
+		if isMain, err := bc.isMainChainBlock(tx, b.Block); err != nil {
+			return nil, err
+		} else if isMain {
+			break
+		}
+
+		chain = append(chain, *b)
+
+		b, err = bc.store.GetSignedBlockByHash(tx, b.Head.PrevHash)
+		if err != nil {
+			return nil, err
+		} else if b == nil {
+			return nil, errors.New("Fork block ancestor does not exist")
+		}
+	}
+
+	forkSeq := b.Seq()
+	if head.Seq()-forkSeq > blockdb.MaxRollbackDepth {
+		return nil, fmt.Errorf("Fork point seq=%d is more than %d blocks behind the head", forkSeq, blockdb.MaxRollbackDepth)
+	}
+
+	logger.Infof("Reorganizing blockchain, fork point seq=%d, rolling back %d blocks, applying %d blocks", forkSeq, head.Seq()-forkSeq, len(chain))
+
+	var reorg Reorganization
+	for i := head.Seq(); i > forkSeq; i-- {
+		rb, err := bc.store.RollbackHead(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		reorg.RolledBack = append(reorg.RolledBack, *rb)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		b := chain[i]
+
+		nb, err := bc.processBlock(tx, b)
+		if err != nil {
+			return nil, err
+		}
+
+		// The block is already stored, it can't be modified by arbitrating
+		if len(nb.Body.Transactions) != len(b.Body.Transactions) {
+			return nil, fmt.Errorf("Fork block seq=%d contains invalid transactions", b.Seq())
+		}
+
+		if err := bc.store.ApplySideBlock(tx, &b); err != nil {
+			return nil, err
+		}
+
+		reorg.Applied = append(reorg.Applied, b)
+	}
+
+	return &reorg, nil
+}
+
+// verifyForkCheckpoints returns ErrCheckpointMismatch if a fork block conflicts with a checkpoint.
+// A competing chain can't include a block at or below a checkpoint that the main chain has reached,
+// because it would replace the checkpoint block.
+func (bc *Blockchain) verifyForkCheckpoints(tx *dbutil.Tx, b coin.Block) error {
+	headSeq, _, err := bc.HeadSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	hash := b.HashHeader()
+	for _, c := range bc.cfg.Checkpoints {
+		if c.Hash != hash && (c.Seq == b.Seq() || (c.Seq > b.Seq() && c.Seq <= headSeq)) {
+			return ErrCheckpointMismatch{
+				Checkpoint: c,
+				Seq:        b.Seq(),
+				Hash:       hash,
+			}
+		}
+	}
+
+	return nil
+}
+
+// txnSignedFlag returns TxnAssumeSigned for blocks known to lead to the assume-valid checkpoint,
+// whose transaction input signatures are not verified. Otherwise returns TxnSigned.
+// A block is known to lead to the assume-valid block if it is the assume-valid block, or if it is
+// below the assume-valid block and both are in the main chain. Until the assume-valid block is reached,
+// a block at or below its seq could belong to a chain that does not lead to it, so its signatures are verified.
+func (bc *Blockchain) txnSignedFlag(tx *dbutil.Tx, b coin.Block) (TxnSignedFlag, error) {
+	av := bc.cfg.AssumeValid
+	if av.Hash.Null() || b.Seq() > av.Seq {
+		return TxnSigned, nil
+	}
+
+	if b.Seq() == av.Seq {
+		if b.HashHeader() == av.Hash {
+			return TxnAssumeSigned, nil
+		}
+		return TxnSigned, nil
+	}
+
+	mb, err := bc.store.GetSignedBlockBySeq(tx, av.Seq)
+	if err != nil {
+		return TxnSigned, err
+	} else if mb == nil || mb.HashHeader() != av.Hash {
+		return TxnSigned, nil
+	}
+
+	isMain, err := bc.isMainChainBlock(tx, b)
+	if err != nil {
+		return TxnSigned, err
+	} else if !isMain {
+		return TxnSigned, nil
+	}
+
+	return TxnAssumeSigned, nil
+}
+
 // isGenesisBlock checks if the block is genesis block
 func (bc Blockchain) isGenesisBlock(tx *dbutil.Tx, b coin.Block) (bool, error) {
 	gb, err := bc.store.GetGenesisBlock(tx)
@@ -326,6 +597,10 @@ func (bc Blockchain) verifyUxHash(tx *dbutil.Tx, b coin.Block) error {
 // VerifyBlockTxnConstraints checks that the transaction does not violate hard constraints,
 // for transactions that are already included in a block.
 func (bc Blockchain) VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction) error {
+	return bc.verifyBlockTxnConstraints(tx, txn, TxnSigned)
+}
+
+func (bc Blockchain) verifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction, signed TxnSignedFlag) error {
 	// NOTE: Unspent().GetArray() returns an error if not all txn.In can be found
 	// This prevents double spends
 	uxIn, err := bc.Unspent().GetArray(tx, txn.In)
@@ -343,12 +618,12 @@ func (bc Blockchain) VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transacti
 		return err
 	}
 
-	return bc.verifyBlockTxnHardConstraints(tx, txn, head, uxIn)
+	return bc.verifyBlockTxnHardConstraints(tx, txn, head, uxIn, signed)
 }
 
-func (bc Blockchain) verifyBlockTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, head *coin.SignedBlock, uxIn coin.UxArray) error {
-	if err := VerifyBlockTxnConstraints(txn, head.Head, uxIn); err != nil {
-		return err
+func (bc Blockchain) verifyBlockTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, head *coin.SignedBlock, uxIn coin.UxArray, signed TxnSignedFlag) error {
+	if err := verifyTxnHardConstraints(txn, head.Head, uxIn, signed); err != nil {
+		return NewErrTxnViolatesHardConstraint(err)
 	}
 
 	if DebugLevel1 {
@@ -524,10 +799,11 @@ func (bc Blockchain) GetLastBlocks(tx *dbutil.Tx, num uint64) ([]coin.SignedBloc
 // firstFalse is false, if there is no way to filter the txns into a valid
 // array, i.e. processTransactions(processTransactions(txn, false), true)
 // should not result in an error, unless all txns are invalid.
+// The input signatures are not verified if signed is TxnAssumeSigned.
 // TODO:
 //  - move arbitration to visor
 //  - blockchain should have strict checking
-func (bc Blockchain) processTransactions(tx *dbutil.Tx, txs coin.Transactions) (coin.Transactions, error) {
+func (bc Blockchain) processTransactions(tx *dbutil.Tx, txs coin.Transactions, signed TxnSignedFlag) (coin.Transactions, error) {
 	// copy txs so that the following code won't modify the original txns
 	txns := make(coin.Transactions, len(txs))
 	copy(txns, txs)
@@ -556,12 +832,27 @@ func (bc Blockchain) processTransactions(tx *dbutil.Tx, txs coin.Transactions) (
 		return nil, errors.New("No transactions")
 	}
 
+	// Verify the input signatures of all transactions in parallel. Transactions with valid
+	// signatures are then verified sequentially without verifying their signatures again
+	var sigsValid []bool
+	if signed == TxnSigned {
+		sigsValid, err = bc.verifyTxnSignatures(tx, txns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	skip := make(map[int]struct{})
 	uxHashes := make(coin.UxHashSet, len(txns))
 	for i, txn := range txns {
+		txnSigned := signed
+		if sigsValid != nil && sigsValid[i] {
+			txnSigned = TxnAssumeSigned
+		}
+
 		// Check the transaction against itself.  This covers the hash,
 		// signature indices and duplicate spends within itself
-		if err := bc.VerifyBlockTxnConstraints(tx, txn); err != nil {
+		if err := bc.verifyBlockTxnConstraints(tx, txn, txnSigned); err != nil {
 			switch err.(type) {
 			case ErrTxnViolatesSoftConstraint:
 				logger.Critical().WithError(err).Panic("bc.VerifyBlockTxnConstraints should not return a ErrTxnViolatesSoftConstraint error")
@@ -693,7 +984,12 @@ func (bc Blockchain) TransactionFee(tx *dbutil.Tx, headTime uint64) coin.FeeCalc
 
 // VerifySignature checks that BlockSigs state correspond with coin.Blockchain state
 // and that all signatures are valid.
+// The signatures of pending blocks verified by VerifyPendingBlockSignatures are not verified again.
 func (bc *Blockchain) VerifySignature(block *coin.SignedBlock) error {
+	if bc.sigVerifier.isBlockSigVerified(block.HashHeader(), block.Sig) {
+		return nil
+	}
+
 	err := block.VerifySignature(bc.cfg.Pubkey)
 	if err != nil {
 		logger.Errorf("Blockchain signature verification failed for block %d: %v", block.Head.BkSeq, err)
@@ -826,16 +1122,21 @@ func (bc Blockchain) verifyBlockHeader(tx *dbutil.Tx, b coin.Block) error {
 		return err
 	}
 
+	return verifyBlockHeaderParent(head.Block, b)
+}
+
+// verifyBlockHeaderParent returns error if the BlockHeader is not valid as a child of parent
+func verifyBlockHeaderParent(parent, b coin.Block) error {
 	//check BkSeq
-	if b.Head.BkSeq != head.Head.BkSeq+1 {
+	if b.Head.BkSeq != parent.Head.BkSeq+1 {
 		return errors.New("BkSeq invalid")
 	}
 	//check Time, only requirement is that its monotonely increasing
-	if b.Head.Time <= head.Head.Time {
+	if b.Head.Time <= parent.Head.Time {
 		return errors.New("Block time must be > head time")
 	}
 	// Check block hash against previous head
-	if b.Head.PrevHash != head.HashHeader() {
+	if b.Head.PrevHash != parent.HashHeader() {
 		return errors.New("PrevHash does not match current head")
 	}
 
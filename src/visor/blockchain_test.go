@@ -106,6 +106,30 @@ func (fcs *fakeChainStore) AddBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	return nil
 }
 
+func (fcs *fakeChainStore) AddSideBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	return nil
+}
+
+func (fcs *fakeChainStore) ApplySideBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	return nil
+}
+
+func (fcs *fakeChainStore) RollbackHead(tx *dbutil.Tx) (*coin.SignedBlock, error) {
+	return nil, nil
+}
+
+func (fcs *fakeChainStore) PrunedSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	return 0, false, nil
+}
+
+func (fcs *fakeChainStore) PruneBlocks(tx *dbutil.Tx, seq uint64) error {
+	return nil
+}
+
+func (fcs *fakeChainStore) LoadSnapshot(tx *dbutil.Tx, genesis, head *coin.SignedBlock, uxs coin.UxArray) error {
+	return nil
+}
+
 func (fcs *fakeChainStore) GetBlockSignature(tx *dbutil.Tx, b *coin.Block) (cipher.Sig, bool, error) {
 	return cipher.Sig{}, false, nil
 }
@@ -663,8 +687,9 @@ func TestProcessTransactions(t *testing.T) {
 				cfg: BlockchainConfig{
 					Arbitrating: tc.arbitrating,
 				},
-				db:    db,
-				store: store,
+				db:          db,
+				store:       store,
+				sigVerifier: newTxnSigVerifier(0),
 			}
 
 			// init chain
@@ -696,7 +721,7 @@ func TestProcessTransactions(t *testing.T) {
 			}
 
 			err = db.View("", func(tx *dbutil.Tx) error {
-				_, err := bc.processTransactions(tx, txns)
+				_, err := bc.processTransactions(tx, txns, TxnSigned)
 				require.EqualValues(t, tc.err, err)
 				return nil
 			})
@@ -728,8 +753,9 @@ func TestVerifyUxHash(t *testing.T) {
 	require.NoError(t, err)
 
 	bc := &Blockchain{
-		db:    db,
-		store: store,
+		db:          db,
+		store:       store,
+		sigVerifier: newTxnSigVerifier(0),
 	}
 
 	gb := addGenesisBlockToBlockchain(t, bc)
@@ -767,8 +793,9 @@ func TestProcessBlock(t *testing.T) {
 	require.NoError(t, err)
 
 	bc := &Blockchain{
-		db:    db,
-		store: store,
+		db:          db,
+		store:       store,
+		sigVerifier: newTxnSigVerifier(0),
 	}
 
 	gb, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
@@ -825,8 +852,9 @@ func TestExecuteBlock(t *testing.T) {
 	require.NoError(t, err)
 
 	bc := &Blockchain{
-		db:    db,
-		store: store,
+		db:          db,
+		store:       store,
+		sigVerifier: newTxnSigVerifier(0),
 	}
 
 	gb, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
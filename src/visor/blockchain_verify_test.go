@@ -368,8 +368,9 @@ func testVerifyTransactionSoftHardConstraints(t *testing.T, signed TxnSignedFlag
 	require.NoError(t, err)
 
 	bc := &Blockchain{
-		db:    db,
-		store: store,
+		db:          db,
+		store:       store,
+		sigVerifier: newTxnSigVerifier(0),
 	}
 
 	gb := addGenesisBlockToBlockchain(t, bc)
@@ -545,8 +546,9 @@ func TestVerifyTxnFeeCoinHoursAdditionFails(t *testing.T) {
 	require.NoError(t, err)
 
 	bc := &Blockchain{
-		db:    db,
-		store: store,
+		db:          db,
+		store:       store,
+		sigVerifier: newTxnSigVerifier(0),
 	}
 
 	gb := addGenesisBlockToBlockchain(t, bc)
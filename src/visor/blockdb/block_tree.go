@@ -14,6 +14,7 @@ var (
 	errNoParent    = errors.New("block is not genesis and has no parent")
 	errWrongParent = errors.New("wrong parent")
 	errHasChild    = errors.New("remove block failed, it has children")
+	errNoSuchBlock = errors.New("block is not in the block tree")
 
 	// BlocksBkt holds coin.Blocks
 	BlocksBkt = []byte("blocks")
@@ -29,6 +30,16 @@ type blockTree struct{}
 
 // AddBlock adds block with *dbutil.Tx
 func (bt *blockTree) AddBlock(tx *dbutil.Tx, b *coin.Block) error {
+	return bt.addBlock(tx, b, true)
+}
+
+// AddDetachedBlock adds a block whose parent is not in the tree.
+// It is used to store the head block of an unspent output snapshot.
+func (bt *blockTree) AddDetachedBlock(tx *dbutil.Tx, b *coin.Block) error {
+	return bt.addBlock(tx, b, false)
+}
+
+func (bt *blockTree) addBlock(tx *dbutil.Tx, b *coin.Block, requireParent bool) error {
 	// can't store block if it's not genesis block and has no parent.
 	if b.Seq() > 0 && b.Head.PrevHash.Null() {
 		return errNoParent
@@ -53,7 +64,7 @@ func (bt *blockTree) AddBlock(tx *dbutil.Tx, b *coin.Block) error {
 	}
 
 	// the pre hash must be in depth - 1.
-	if b.Seq() > 0 {
+	if b.Seq() > 0 && requireParent {
 		parentHashPair, err := getHashPairInDepth(tx, b.Seq()-1, func(hp coin.HashPair) bool {
 			return hp.Hash == b.Head.PrevHash
 		})
@@ -127,6 +138,50 @@ func (bt *blockTree) RemoveBlock(tx *dbutil.Tx, b *coin.Block) error {
 	return setHashPairInDepth(tx, b.Seq(), ps)
 }
 
+// SetMainChainBlock moves the block's hash pair to the front of its depth.
+// The first hash pair in a depth is the block chosen by the default walker,
+// so this makes the block part of the main chain.
+func (bt *blockTree) SetMainChainBlock(tx *dbutil.Tx, b *coin.Block) error {
+	hashPairs, err := getHashPairInDepth(tx, b.Seq(), allPairs)
+	if err != nil {
+		return err
+	}
+
+	hp := coin.HashPair{
+		Hash:     b.HashHeader(),
+		PrevHash: b.Head.PrevHash,
+	}
+
+	if !containHash(hashPairs, hp) {
+		return errNoSuchBlock
+	}
+
+	ps := append([]coin.HashPair{hp}, removePairs(hashPairs, hp)...)
+	return setHashPairInDepth(tx, b.Seq(), ps)
+}
+
+// PruneBlock replaces the stored block with its header and an empty body.
+// The block's hash is unchanged, since it only depends on the header.
+func (bt *blockTree) PruneBlock(tx *dbutil.Tx, b *coin.Block) error {
+	hash := b.HashHeader()
+	if ok, err := dbutil.BucketHasKey(tx, BlocksBkt, hash[:]); err != nil {
+		return err
+	} else if !ok {
+		return errNoSuchBlock
+	}
+
+	pruned := coin.Block{
+		Head: b.Head,
+	}
+
+	buf, err := encodeBlock(&pruned)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, BlocksBkt, hash[:], buf)
+}
+
 // GetBlock get block by hash, return nil on not found
 func (bt *blockTree) GetBlock(tx *dbutil.Tx, hash cipher.SHA256) (*coin.Block, error) {
 	var b coin.Block
@@ -7,6 +7,7 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
@@ -226,3 +227,133 @@ func TestGetBlockInDepth(t *testing.T) {
 	require.NotNil(t, block)
 	require.Equal(t, blocks[2], *block)
 }
+
+func TestSetMainChainBlock(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+
+	bc := &blockTree{}
+	blocks := []coin.Block{
+		coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq: 0,
+				Time:  0,
+			},
+		},
+		coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq: 1,
+				Time:  1,
+			},
+		},
+		coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq: 1,
+				Time:  2,
+			},
+		},
+	}
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		err := bc.AddBlock(tx, &blocks[0])
+		require.NoError(t, err)
+
+		blocks[1].Head.PrevHash = blocks[0].HashHeader()
+		err = bc.AddBlock(tx, &blocks[1])
+		require.NoError(t, err)
+
+		blocks[2].Head.PrevHash = blocks[0].HashHeader()
+		err = bc.AddBlock(tx, &blocks[2])
+		require.NoError(t, err)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	requireMainChainBlock := func(b coin.Block) {
+		err := db.View("", func(tx *dbutil.Tx) error {
+			block, err := bc.GetBlockInDepth(tx, 1, DefaultWalker)
+			require.NoError(t, err)
+			require.NotNil(t, block)
+			require.Equal(t, b, *block)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	requireMainChainBlock(blocks[1])
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.SetMainChainBlock(tx, &blocks[2])
+	})
+	require.NoError(t, err)
+	requireMainChainBlock(blocks[2])
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.SetMainChainBlock(tx, &blocks[1])
+	})
+	require.NoError(t, err)
+	requireMainChainBlock(blocks[1])
+
+	// unknown block
+	b := blocks[2]
+	b.Head.Time = 3
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.SetMainChainBlock(tx, &b)
+	})
+	require.Equal(t, errNoSuchBlock, err)
+}
+
+func TestPruneBlock(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+
+	bc := &blockTree{}
+	b := coin.Block{
+		Head: coin.BlockHeader{
+			BkSeq: 0,
+			Time:  0,
+		},
+		Body: coin.BlockBody{
+			Transactions: coin.Transactions{
+				{
+					Length:    1,
+					InnerHash: testutil.RandSHA256(t),
+				},
+			},
+		},
+	}
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return bc.AddBlock(tx, &b)
+	})
+	require.NoError(t, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.PruneBlock(tx, &b)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		block, err := bc.GetBlock(tx, b.HashHeader())
+		require.NoError(t, err)
+		require.NotNil(t, block)
+		require.Equal(t, b.Head, block.Head)
+		require.Empty(t, block.Body.Transactions)
+
+		block, err = bc.GetBlockInDepth(tx, 0, DefaultWalker)
+		require.NoError(t, err)
+		require.NotNil(t, block)
+		require.Equal(t, b.HashHeader(), block.HashHeader())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// unknown block
+	unknown := b
+	unknown.Head.Time = 1
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.PruneBlock(tx, &unknown)
+	})
+	require.Equal(t, errNoSuchBlock, err)
+}
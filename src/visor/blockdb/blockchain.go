@@ -20,6 +20,14 @@ var (
 	ErrNoHeadBlock = fmt.Errorf("found no head block")
 )
 
+// MaxRollbackDepth is the number of blocks from the head that can be rolled back.
+// The data needed to roll back a block is removed once the block is deeper than this.
+const MaxRollbackDepth = 100
+
+// MinPruneKeepBlocks is the minimum number of recent blocks whose bodies must be kept when pruning.
+// Rolling back a block and discarding its rollback data both require the block body.
+const MinPruneKeepBlocks = MaxRollbackDepth + 1
+
 //go:generate skyencoder -unexported -struct Block -output-path . -package blockdb github.com/skycoin/skycoin/src/coin
 //go:generate skyencoder -unexported -struct UxOut -output-path . -package blockdb github.com/skycoin/skycoin/src/coin
 //go:generate skyencoder -unexported -struct hashPairsWrapper
@@ -67,12 +75,18 @@ func CreateBuckets(tx *dbutil.Tx) error {
 		UnspentPoolBkt,
 		UnspentPoolAddrIndexBkt,
 		UnspentMetaBkt,
+		UnspentSpentBkt,
+		UnspentAddrBalanceBkt,
+		UnspentRichlistBkt,
 	})
 }
 
 // BlockTree block storage
 type BlockTree interface {
 	AddBlock(*dbutil.Tx, *coin.Block) error
+	AddDetachedBlock(*dbutil.Tx, *coin.Block) error
+	SetMainChainBlock(*dbutil.Tx, *coin.Block) error
+	PruneBlock(*dbutil.Tx, *coin.Block) error
 	GetBlock(*dbutil.Tx, cipher.SHA256) (*coin.Block, error)
 	GetBlockInDepth(*dbutil.Tx, uint64, Walker) (*coin.Block, error)
 	ForEachBlock(*dbutil.Tx, func(*coin.Block) error) error
@@ -96,16 +110,24 @@ type UnspentPooler interface {
 	GetAll(*dbutil.Tx) (coin.UxArray, error)
 	GetArray(*dbutil.Tx, []cipher.SHA256) (coin.UxArray, error)
 	GetUxHash(*dbutil.Tx) (cipher.SHA256, error)
+	Load(*dbutil.Tx, coin.UxArray, uint64) error
 	GetUnspentsOfAddrs(*dbutil.Tx, []cipher.Address) (coin.AddressUxOuts, error)
 	GetUnspentHashesOfAddrs(*dbutil.Tx, []cipher.Address) (AddressHashes, error)
 	ProcessBlock(*dbutil.Tx, *coin.SignedBlock) error
+	RollbackBlock(*dbutil.Tx, *coin.SignedBlock) error
+	PruneSpent(*dbutil.Tx, *coin.SignedBlock) error
 	AddressCount(*dbutil.Tx) (uint64, error)
+	GetRichlist(*dbutil.Tx, uint64, map[cipher.Address]struct{}) ([]AddressBalance, error)
+	GetAddressBalances(*dbutil.Tx, []cipher.Address) (map[cipher.Address]uint64, error)
+	GetBalanceHistogram(*dbutil.Tx) (BalanceHistogram, error)
 }
 
 // ChainMeta blockchain metadata
 type ChainMeta interface {
 	GetHeadSeq(*dbutil.Tx) (uint64, bool, error)
 	SetHeadSeq(*dbutil.Tx, uint64) error
+	GetPrunedSeq(*dbutil.Tx) (uint64, bool, error)
+	SetPrunedSeq(*dbutil.Tx, uint64) error
 }
 
 // Blockchain maintain the buckets for blockchain
@@ -161,13 +183,72 @@ func (bc *Blockchain) AddBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
 	return nil
 }
 
+// AddSideBlock adds a signed block that is not part of the main chain.
+// The block is stored, but the head and the unspent pool are not changed.
+func (bc *Blockchain) AddSideBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	if err := bc.sigs.Add(tx, sb.HashHeader(), sb.Sig); err != nil {
+		return fmt.Errorf("save signature failed: %v", err)
+	}
+
+	if err := bc.tree.AddBlock(tx, &sb.Block); err != nil {
+		return fmt.Errorf("save block failed: %v", err)
+	}
+
+	return nil
+}
+
+// ApplySideBlock makes a block previously added with AddSideBlock the new head of the main chain.
+// The block's parent must be the current head.
+func (bc *Blockchain) ApplySideBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	if err := bc.tree.SetMainChainBlock(tx, &sb.Block); err != nil {
+		return fmt.Errorf("set main chain block failed: %v", err)
+	}
+
+	return bc.processBlock(tx, sb)
+}
+
+// RollbackHead removes the head block from the main chain, reverting its changes to the unspent pool.
+// The block stays in the block tree as a side block. Returns the removed block.
+func (bc *Blockchain) RollbackHead(tx *dbutil.Tx) (*coin.SignedBlock, error) {
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bc.unspent.RollbackBlock(tx, head); err != nil {
+		return nil, err
+	}
+
+	if err := bc.meta.SetHeadSeq(tx, head.Seq()-1); err != nil {
+		return nil, err
+	}
+
+	return head, nil
+}
+
 // processBlock processes a block and updates the db
 func (bc *Blockchain) processBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	if err := bc.unspent.ProcessBlock(tx, b); err != nil {
 		return err
 	}
 
-	return bc.meta.SetHeadSeq(tx, b.Seq())
+	if err := bc.meta.SetHeadSeq(tx, b.Seq()); err != nil {
+		return err
+	}
+
+	// Discard the rollback data of the block that is now beyond the rollback depth
+	if b.Seq() < MaxRollbackDepth {
+		return nil
+	}
+
+	old, err := bc.GetSignedBlockBySeq(tx, b.Seq()-MaxRollbackDepth)
+	if err != nil {
+		return err
+	} else if old == nil {
+		return nil
+	}
+
+	return bc.unspent.PruneSpent(tx, old)
 }
 
 // Head returns head block, returns error if no head block exists
@@ -208,6 +289,94 @@ func (bc *Blockchain) Len(tx *dbutil.Tx) (uint64, error) {
 	return seq + 1, nil
 }
 
+// LoadSnapshot seeds an empty blockchain with an unspent output snapshot taken at the head block.
+// Only the genesis block and the head block are stored. The blocks between them are
+// marked as pruned, so the database can only be used with block pruning enabled.
+func (bc *Blockchain) LoadSnapshot(tx *dbutil.Tx, genesis, head *coin.SignedBlock, uxs coin.UxArray) error {
+	if _, ok, err := bc.meta.GetHeadSeq(tx); err != nil {
+		return err
+	} else if ok {
+		return errors.New("cannot load a snapshot into a blockchain that is not empty")
+	}
+
+	if genesis.Seq() != 0 {
+		return errors.New("snapshot genesis block is not a genesis block")
+	}
+	if head.Seq() == 0 {
+		return errors.New("snapshot head block must not be the genesis block")
+	}
+
+	if err := bc.sigs.Add(tx, genesis.HashHeader(), genesis.Sig); err != nil {
+		return fmt.Errorf("save signature failed: %v", err)
+	}
+	if err := bc.tree.AddBlock(tx, &genesis.Block); err != nil {
+		return fmt.Errorf("save block failed: %v", err)
+	}
+
+	if err := bc.sigs.Add(tx, head.HashHeader(), head.Sig); err != nil {
+		return fmt.Errorf("save signature failed: %v", err)
+	}
+	if err := bc.tree.AddDetachedBlock(tx, &head.Block); err != nil {
+		return fmt.Errorf("save block failed: %v", err)
+	}
+
+	if err := bc.unspent.Load(tx, uxs, head.Seq()); err != nil {
+		return err
+	}
+
+	if err := bc.meta.SetHeadSeq(tx, head.Seq()); err != nil {
+		return err
+	}
+
+	return bc.meta.SetPrunedSeq(tx, head.Seq()-1)
+}
+
+// PrunedSeq returns the sequence of the most recent block whose body was pruned.
+// Returns false if no block has been pruned.
+func (bc *Blockchain) PrunedSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	return bc.meta.GetPrunedSeq(tx)
+}
+
+// PruneBlocks removes the bodies of the main chain blocks up to and including seq.
+// The headers and signatures are kept. The genesis block is never pruned.
+// Blocks that are already pruned are skipped.
+func (bc *Blockchain) PruneBlocks(tx *dbutil.Tx, seq uint64) error {
+	headSeq, ok, err := bc.meta.GetHeadSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok {
+		return ErrNoHeadBlock
+	}
+
+	if headSeq < MinPruneKeepBlocks || seq > headSeq-MinPruneKeepBlocks {
+		return fmt.Errorf("cannot prune block %d, the bodies of the last %d blocks must be kept", seq, MinPruneKeepBlocks)
+	}
+
+	prunedSeq, _, err := bc.meta.GetPrunedSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if seq <= prunedSeq {
+		return nil
+	}
+
+	for i := prunedSeq + 1; i <= seq; i++ {
+		b, err := bc.tree.GetBlockInDepth(tx, i, bc.walker)
+		if err != nil {
+			return err
+		} else if b == nil {
+			return fmt.Errorf("no block exists in depth: %d", i)
+		}
+
+		if err := bc.tree.PruneBlock(tx, b); err != nil {
+			return err
+		}
+	}
+
+	return bc.meta.SetPrunedSeq(tx, seq)
+}
+
 // GetBlockSignature returns the signature of a block
 func (bc *Blockchain) GetBlockSignature(tx *dbutil.Tx, b *coin.Block) (cipher.Sig, bool, error) {
 	return bc.sigs.Get(tx, b.HashHeader())
@@ -1,8 +1,10 @@
 package blockdb
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -83,6 +85,18 @@ func (bt *fakeBlockTree) AddBlock(tx *dbutil.Tx, b *coin.Block) error {
 	return nil
 }
 
+func (bt *fakeBlockTree) AddDetachedBlock(tx *dbutil.Tx, b *coin.Block) error {
+	return bt.AddBlock(tx, b)
+}
+
+func (bt *fakeBlockTree) SetMainChainBlock(tx *dbutil.Tx, b *coin.Block) error {
+	return nil
+}
+
+func (bt *fakeBlockTree) PruneBlock(tx *dbutil.Tx, b *coin.Block) error {
+	return nil
+}
+
 func (bt *fakeBlockTree) GetBlock(tx *dbutil.Tx, hash cipher.SHA256) (*coin.Block, error) {
 	if bt.failedWhenSaved != nil && *bt.failedWhenSaved {
 		return nil, nil
@@ -243,6 +257,13 @@ func (fup *fakeUnspentPool) GetUnspentsOfAddrs(tx *dbutil.Tx, addrs []cipher.Add
 	return addrOutMap, nil
 }
 
+func (fup *fakeUnspentPool) Load(tx *dbutil.Tx, uxs coin.UxArray, headSeq uint64) error {
+	for _, ux := range uxs {
+		fup.outs[ux.Hash()] = ux
+	}
+	return nil
+}
+
 func (fup *fakeUnspentPool) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	if fup.saveFailed {
 		if fup.failedWhenSaved != nil {
@@ -253,6 +274,14 @@ func (fup *fakeUnspentPool) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) err
 	return nil
 }
 
+func (fup *fakeUnspentPool) RollbackBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	return nil
+}
+
+func (fup *fakeUnspentPool) PruneSpent(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	return nil
+}
+
 func (fup *fakeUnspentPool) Contains(tx *dbutil.Tx, h cipher.SHA256) (bool, error) {
 	_, ok := fup.outs[h]
 	return ok, nil
@@ -267,6 +296,61 @@ func (fup *fakeUnspentPool) AddressCount(tx *dbutil.Tx) (uint64, error) {
 	return uint64(len(addrs)), nil
 }
 
+func (fup *fakeUnspentPool) balances() map[cipher.Address]uint64 {
+	balances := make(map[cipher.Address]uint64)
+	for _, out := range fup.outs {
+		balances[out.Body.Address] += out.Body.Coins
+	}
+
+	return balances
+}
+
+func (fup *fakeUnspentPool) GetRichlist(tx *dbutil.Tx, n uint64, exclude map[cipher.Address]struct{}) ([]AddressBalance, error) {
+	var balances []AddressBalance
+	for addr, coins := range fup.balances() {
+		if _, ok := exclude[addr]; !ok && coins > 0 {
+			balances = append(balances, AddressBalance{
+				Address: addr,
+				Coins:   coins,
+			})
+		}
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Coins == balances[j].Coins {
+			return bytes.Compare(balances[i].Address.Bytes(), balances[j].Address.Bytes()) < 0
+		}
+		return balances[i].Coins > balances[j].Coins
+	})
+
+	if n > 0 && uint64(len(balances)) > n {
+		balances = balances[:n]
+	}
+
+	return balances, nil
+}
+
+func (fup *fakeUnspentPool) GetAddressBalances(tx *dbutil.Tx, addrs []cipher.Address) (map[cipher.Address]uint64, error) {
+	all := fup.balances()
+	balances := make(map[cipher.Address]uint64, len(addrs))
+	for _, addr := range addrs {
+		if coins := all[addr]; coins > 0 {
+			balances[addr] = coins
+		}
+	}
+
+	return balances, nil
+}
+
+func (fup *fakeUnspentPool) GetBalanceHistogram(tx *dbutil.Tx) (BalanceHistogram, error) {
+	var h BalanceHistogram
+	for _, coins := range fup.balances() {
+		h.Add(coins)
+	}
+
+	return h, nil
+}
+
 type fakeChainMeta struct {
 	headSeq   uint64
 	didSetSeq bool
@@ -290,6 +374,14 @@ func (fcm *fakeChainMeta) SetHeadSeq(tx *dbutil.Tx, seq uint64) error {
 	return nil
 }
 
+func (fcm *fakeChainMeta) GetPrunedSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	return 0, false, nil
+}
+
+func (fcm *fakeChainMeta) SetPrunedSeq(tx *dbutil.Tx, seq uint64) error {
+	return nil
+}
+
 func DefaultWalker(tx *dbutil.Tx, hps []coin.HashPair) (cipher.SHA256, bool) {
 	return hps[0].Hash, true
 }
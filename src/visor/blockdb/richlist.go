@@ -0,0 +1,384 @@
+package blockdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+var (
+	richlistIndexHeightKey = []byte("richlist_index_height")
+	balanceHistogramKey    = []byte("balance_histogram")
+
+	// UnspentAddrBalanceBkt maps addresses to the total coins of their unspent outputs
+	UnspentAddrBalanceBkt = []byte("unspent_addr_balance")
+	// UnspentRichlistBkt orders the addresses with a nonzero balance by balance, highest first.
+	// The keys are the bitwise inverted balance followed by the address, the values are empty.
+	UnspentRichlistBkt = []byte("unspent_richlist")
+)
+
+// BalanceHistogramSize is the number of magnitudes counted by a BalanceHistogram,
+// enough for any uint64 balance
+const BalanceHistogramSize = 20
+
+// BalanceHistogram counts the addresses with a nonzero balance by the magnitude of their balance.
+// BalanceHistogram[i] is the number of addresses holding at least 10^i and less than 10^(i+1) droplets.
+type BalanceHistogram [BalanceHistogramSize]uint64
+
+// AtLeast returns the number of addresses holding at least 10^exp droplets
+func (h BalanceHistogram) AtLeast(exp int) uint64 {
+	if exp < 0 {
+		exp = 0
+	}
+
+	var n uint64
+	for i := exp; i < len(h); i++ {
+		n += h[i]
+	}
+	return n
+}
+
+// Add counts an address holding coins
+func (h *BalanceHistogram) Add(coins uint64) {
+	if coins == 0 {
+		return
+	}
+	h[balanceMagnitude(coins)]++
+}
+
+// Remove uncounts an address holding coins
+func (h *BalanceHistogram) Remove(coins uint64) error {
+	if coins == 0 {
+		return nil
+	}
+
+	i := balanceMagnitude(coins)
+	if h[i] == 0 {
+		return fmt.Errorf("BalanceHistogram.Remove: no address holding %d droplets is counted", coins)
+	}
+	h[i]--
+	return nil
+}
+
+// balanceMagnitude returns the number of decimal digits of coins minus one
+func balanceMagnitude(coins uint64) int {
+	n := 0
+	for coins >= 10 {
+		coins /= 10
+		n++
+	}
+	return n
+}
+
+// AddressBalance is the total coins of the unspent outputs of an address
+type AddressBalance struct {
+	Address cipher.Address
+	Coins   uint64
+}
+
+// balanceDelta is the change of an address balance caused by a block
+type balanceDelta struct {
+	add uint64
+	sub uint64
+}
+
+// addBalanceDelta adds coins received and spent by an address to deltas
+func addBalanceDelta(deltas map[cipher.Address]balanceDelta, addr cipher.Address, add, sub uint64) error {
+	d := deltas[addr]
+
+	var err error
+	d.add, err = mathutil.AddUint64(d.add, add)
+	if err != nil {
+		return err
+	}
+
+	d.sub, err = mathutil.AddUint64(d.sub, sub)
+	if err != nil {
+		return err
+	}
+
+	deltas[addr] = d
+	return nil
+}
+
+// richlistKey returns the UnspentRichlistBkt key of an address balance.
+// Inverting the balance orders the keys by balance, highest first, and then by address.
+func richlistKey(addr cipher.Address, coins uint64) []byte {
+	addrBytes := addr.Bytes()
+	k := make([]byte, 8, 8+len(addrBytes))
+	binary.BigEndian.PutUint64(k, ^coins)
+	return append(k, addrBytes...)
+}
+
+// parseRichlistKey parses an UnspentRichlistBkt key
+func parseRichlistKey(k []byte) (AddressBalance, error) {
+	if len(k) < 8 {
+		return AddressBalance{}, errors.New("invalid richlist index key length")
+	}
+
+	addr, err := cipher.AddressFromBytes(k[8:])
+	if err != nil {
+		return AddressBalance{}, err
+	}
+
+	return AddressBalance{
+		Address: addr,
+		Coins:   ^binary.BigEndian.Uint64(k[:8]),
+	}, nil
+}
+
+// richlistIndex maintains the address balances, the balance ordered index and the balance histogram
+type richlistIndex struct{}
+
+func (ri richlistIndex) getBalance(tx *dbutil.Tx, addr cipher.Address) (uint64, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnspentAddrBalanceBkt, addr.Bytes())
+	if err != nil {
+		return 0, err
+	} else if v == nil {
+		return 0, nil
+	}
+
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid balance length %d for address %s", len(v), addr.String())
+	}
+
+	return dbutil.Btoi(v), nil
+}
+
+func (ri richlistIndex) getHistogram(tx *dbutil.Tx) (BalanceHistogram, error) {
+	var h BalanceHistogram
+
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnspentMetaBkt, balanceHistogramKey)
+	if err != nil {
+		return h, err
+	} else if v == nil {
+		return h, nil
+	}
+
+	if len(v) != 8*len(h) {
+		return h, errors.New("invalid balance histogram length")
+	}
+
+	for i := range h {
+		h[i] = dbutil.Btoi(v[i*8 : (i+1)*8])
+	}
+
+	return h, nil
+}
+
+func (ri richlistIndex) setHistogram(tx *dbutil.Tx, h BalanceHistogram) error {
+	v := make([]byte, 0, 8*len(h))
+	for _, n := range h {
+		v = append(v, dbutil.Itob(n)...)
+	}
+
+	return dbutil.PutBucketValue(tx, UnspentMetaBkt, balanceHistogramKey, v)
+}
+
+// setBalance moves an address in the index from its old balance to its new balance
+func (ri richlistIndex) setBalance(tx *dbutil.Tx, h *BalanceHistogram, addr cipher.Address, oldCoins, newCoins uint64) error {
+	if oldCoins == newCoins {
+		return nil
+	}
+
+	if oldCoins > 0 {
+		if err := dbutil.Delete(tx, UnspentRichlistBkt, richlistKey(addr, oldCoins)); err != nil {
+			return err
+		}
+
+		if err := h.Remove(oldCoins); err != nil {
+			return err
+		}
+	}
+
+	// Delete the row if the balance is zero, so that only holders are indexed
+	if newCoins == 0 {
+		return dbutil.Delete(tx, UnspentAddrBalanceBkt, addr.Bytes())
+	}
+
+	if err := dbutil.PutBucketValue(tx, UnspentRichlistBkt, richlistKey(addr, newCoins), []byte{}); err != nil {
+		return err
+	}
+
+	h.Add(newCoins)
+
+	return dbutil.PutBucketValue(tx, UnspentAddrBalanceBkt, addr.Bytes(), dbutil.Itob(newCoins))
+}
+
+// adjust applies balance changes to the index
+func (ri richlistIndex) adjust(tx *dbutil.Tx, deltas map[cipher.Address]balanceDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	h, err := ri.getHistogram(tx)
+	if err != nil {
+		return err
+	}
+
+	addrs := make([]cipher.Address, 0, len(deltas))
+	for addr := range deltas {
+		addrs = append(addrs, addr)
+	}
+	sortAddresses(addrs)
+
+	for _, addr := range addrs {
+		d := deltas[addr]
+
+		oldCoins, err := ri.getBalance(tx, addr)
+		if err != nil {
+			return err
+		}
+
+		newCoins, err := mathutil.AddUint64(oldCoins, d.add)
+		if err != nil {
+			return err
+		}
+
+		if newCoins < d.sub {
+			return fmt.Errorf("richlistIndex.adjust: balance of address %s would be negative", addr.String())
+		}
+		newCoins -= d.sub
+
+		if err := ri.setBalance(tx, &h, addr, oldCoins, newCoins); err != nil {
+			return err
+		}
+	}
+
+	return ri.setHistogram(tx, h)
+}
+
+// build rebuilds the index from the unspent pool
+func (ri richlistIndex) build(tx *dbutil.Tx) error {
+	for _, bkt := range [][]byte{UnspentAddrBalanceBkt, UnspentRichlistBkt} {
+		if dbutil.Exists(tx, bkt) {
+			if err := dbutil.Reset(tx, bkt); err != nil {
+				return err
+			}
+		} else if err := dbutil.CreateBuckets(tx, [][]byte{bkt}); err != nil {
+			return err
+		}
+	}
+
+	balances := make(map[cipher.Address]uint64)
+	if err := dbutil.ForEach(tx, UnspentPoolBkt, func(_, v []byte) error {
+		var ux coin.UxOut
+		if err := decodeUxOutExact(v, &ux); err != nil {
+			return err
+		}
+
+		coins, err := mathutil.AddUint64(balances[ux.Body.Address], ux.Body.Coins)
+		if err != nil {
+			return err
+		}
+
+		balances[ux.Body.Address] = coins
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var h BalanceHistogram
+	for addr, coins := range balances {
+		if err := ri.setBalance(tx, &h, addr, 0, coins); err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("Indexed the balances of %d addresses", h.AtLeast(0))
+
+	return ri.setHistogram(tx, h)
+}
+
+func (m *unspentMeta) getRichlistIndexHeight(tx *dbutil.Tx) (uint64, bool, error) {
+	v, err := dbutil.GetBucketValue(tx, UnspentMetaBkt, richlistIndexHeightKey)
+	if err != nil {
+		return 0, false, err
+	} else if v == nil {
+		return 0, false, nil
+	}
+
+	return dbutil.Btoi(v), true, nil
+}
+
+func (m *unspentMeta) setRichlistIndexHeight(tx *dbutil.Tx, height uint64) error {
+	return dbutil.PutBucketValue(tx, UnspentMetaBkt, richlistIndexHeightKey, dbutil.Itob(height))
+}
+
+// maybeBuildRichlistIndex builds the richlist index if it was not maintained up to block headSeq
+func (up *Unspents) maybeBuildRichlistIndex(tx *dbutil.Tx, headSeq uint64) error {
+	height, ok, err := up.meta.getRichlistIndexHeight(tx)
+	if err != nil {
+		return err
+	}
+
+	if ok && height == headSeq {
+		return nil
+	}
+
+	logger.Infof("Rebuilding unspent_richlist (richlistIndexHeightExists=%v, richlistIndexHeight=%d, headSeq=%d)", ok, height, headSeq)
+
+	if err := up.richlist.build(tx); err != nil {
+		return err
+	}
+
+	return up.meta.setRichlistIndexHeight(tx, headSeq)
+}
+
+// GetRichlist returns the balances of the top n addresses, highest first and then ordered by address bytes.
+// Addresses in exclude are skipped. If n is 0, all addresses with a nonzero balance are returned.
+func (up *Unspents) GetRichlist(tx *dbutil.Tx, n uint64, exclude map[cipher.Address]struct{}) ([]AddressBalance, error) {
+	b := tx.Bucket(UnspentRichlistBkt)
+	if b == nil {
+		return nil, dbutil.NewErrBucketNotExist(UnspentRichlistBkt)
+	}
+
+	var balances []AddressBalance
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		ab, err := parseRichlistKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := exclude[ab.Address]; ok {
+			continue
+		}
+
+		balances = append(balances, ab)
+
+		if n > 0 && uint64(len(balances)) == n {
+			break
+		}
+	}
+
+	return balances, nil
+}
+
+// GetAddressBalances returns the total coins of the unspent outputs of addresses.
+// Addresses without unspent outputs are omitted.
+func (up *Unspents) GetAddressBalances(tx *dbutil.Tx, addrs []cipher.Address) (map[cipher.Address]uint64, error) {
+	balances := make(map[cipher.Address]uint64, len(addrs))
+	for _, addr := range addrs {
+		coins, err := up.richlist.getBalance(tx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if coins > 0 {
+			balances[addr] = coins
+		}
+	}
+
+	return balances, nil
+}
+
+// GetBalanceHistogram returns the number of addresses by the magnitude of their balance
+func (up *Unspents) GetBalanceHistogram(tx *dbutil.Tx) (BalanceHistogram, error) {
+	return up.richlist.getHistogram(tx)
+}
@@ -0,0 +1,200 @@
+package blockdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestBalanceHistogram(t *testing.T) {
+	var h BalanceHistogram
+	h.Add(0)
+	h.Add(1)
+	h.Add(9)
+	h.Add(10)
+	h.Add(999e6)
+	h.Add(1000e6)
+	h.Add(^uint64(0))
+
+	require.Equal(t, uint64(2), h[0])
+	require.Equal(t, uint64(1), h[1])
+	require.Equal(t, uint64(1), h[8])
+	require.Equal(t, uint64(1), h[9])
+	require.Equal(t, uint64(1), h[19])
+
+	require.Equal(t, uint64(6), h.AtLeast(0))
+	require.Equal(t, uint64(4), h.AtLeast(1))
+	require.Equal(t, uint64(3), h.AtLeast(6))
+	require.Equal(t, uint64(2), h.AtLeast(9))
+	require.Equal(t, uint64(1), h.AtLeast(19))
+	require.Equal(t, uint64(0), h.AtLeast(20))
+
+	require.NoError(t, h.Remove(1000e6))
+	require.Equal(t, uint64(0), h[9])
+	require.Error(t, h.Remove(1000e6))
+}
+
+func TestUnspentRichlist(t *testing.T) {
+	db, closedb := prepareDB(t)
+	defer closedb()
+
+	up := NewUnspentPool()
+
+	var uxs coin.UxArray
+	for _, coins := range []uint64{5e6, 20e6, 3e6, 20e6, 1e6} {
+		ux := makeUxOut(t)
+		ux.Body.Coins = coins
+		uxs = append(uxs, ux)
+	}
+
+	// Two outputs of the same address
+	uxs[4].Body.Address = uxs[2].Body.Address
+
+	for _, ux := range uxs {
+		err := addUxOut(db, up, ux)
+		require.NoError(t, err)
+	}
+
+	// Equal balances are ordered by address
+	top, tie := uxs[1].Body.Address, uxs[3].Body.Address
+	if string(tie.Bytes()) < string(top.Bytes()) {
+		top, tie = tie, top
+	}
+
+	richlist := func(n uint64, exclude map[cipher.Address]struct{}) []AddressBalance {
+		var balances []AddressBalance
+		err := db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			balances, err = up.GetRichlist(tx, n, exclude)
+			return err
+		})
+		require.NoError(t, err)
+		return balances
+	}
+
+	histogram := func() BalanceHistogram {
+		var h BalanceHistogram
+		err := db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			h, err = up.GetBalanceHistogram(tx)
+			return err
+		})
+		require.NoError(t, err)
+		return h
+	}
+
+	initRichlist := []AddressBalance{
+		{Address: top, Coins: 20e6},
+		{Address: tie, Coins: 20e6},
+		{Address: uxs[0].Body.Address, Coins: 5e6},
+		{Address: uxs[2].Body.Address, Coins: 4e6},
+	}
+	require.Equal(t, initRichlist, richlist(0, nil))
+	require.Equal(t, initRichlist[:2], richlist(2, nil))
+	require.Equal(t, initRichlist[:1], richlist(0, map[cipher.Address]struct{}{
+		tie:                 {},
+		uxs[0].Body.Address: {},
+		uxs[2].Body.Address: {},
+	}))
+
+	initHistogram := histogram()
+	require.Equal(t, uint64(4), initHistogram.AtLeast(6))
+	require.Equal(t, uint64(2), initHistogram.AtLeast(7))
+
+	err := db.View("", func(tx *dbutil.Tx) error {
+		balances, err := up.GetAddressBalances(tx, []cipher.Address{uxs[2].Body.Address, testutil.MakeAddress()})
+		require.NoError(t, err)
+		require.Equal(t, map[cipher.Address]uint64{
+			uxs[2].Body.Address: 4e6,
+		}, balances)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Spend the outputs of uxs[2]'s address and one of the 20 coin outputs, to a new address and to uxs[0]'s address
+	newAddr := testutil.MakeAddress()
+	txn := coin.Transaction{}
+	for _, in := range []coin.UxOut{uxs[2], uxs[4], uxs[3]} {
+		err := txn.PushInput(in.Hash())
+		require.NoError(t, err)
+	}
+	err = txn.PushOutput(newAddr, 21e6, 10)
+	require.NoError(t, err)
+	err = txn.PushOutput(uxs[0].Body.Address, 3e6, 10)
+	require.NoError(t, err)
+
+	var sb *coin.SignedBlock
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		uxHash, err := up.GetUxHash(tx)
+		require.NoError(t, err)
+
+		block, err := coin.NewBlock(coin.Block{}, uint64(time.Now().Unix()), uxHash, coin.Transactions{txn}, feeCalc)
+		require.NoError(t, err)
+
+		sb = &coin.SignedBlock{
+			Block: *block,
+		}
+
+		return up.ProcessBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []AddressBalance{
+		{Address: newAddr, Coins: 21e6},
+		{Address: uxs[1].Body.Address, Coins: 20e6},
+		{Address: uxs[0].Body.Address, Coins: 8e6},
+	}, richlist(0, nil))
+
+	h := histogram()
+	require.Equal(t, uint64(3), h.AtLeast(0))
+	require.Equal(t, uint64(2), h.AtLeast(7))
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, UnspentAddrBalanceBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), n)
+
+		height, ok, err := up.meta.getRichlistIndexHeight(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(1), height)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Rolling back the block restores the index
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return up.RollbackBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, initRichlist, richlist(0, nil))
+	require.Equal(t, initHistogram, histogram())
+
+	// The index is rebuilt if it was not maintained up to the head block
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := dbutil.Reset(tx, UnspentRichlistBkt); err != nil {
+			return err
+		}
+
+		if err := up.MaybeBuildIndexes(tx, 0); err != nil {
+			return err
+		}
+
+		n, err := dbutil.Len(tx, UnspentRichlistBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), n)
+
+		return up.MaybeBuildIndexes(tx, 1)
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, initRichlist, richlist(0, nil))
+	require.Equal(t, initHistogram, histogram())
+}
@@ -0,0 +1,44 @@
+package blockdb
+
+import (
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// EncodeSignedBlock encodes a signed block with skyencoder, as the block followed by its signature.
+// This is the same encoding as encoder.Serialize of a coin.SignedBlock.
+func EncodeSignedBlock(b *coin.SignedBlock) ([]byte, error) {
+	sig := &sigWrapper{
+		Sig: b.Sig,
+	}
+
+	n := encodeSizeBlock(&b.Block)
+	buf := make([]byte, n+encodeSizeSigWrapper(sig))
+
+	if err := encodeBlockToBuffer(buf[:n], &b.Block); err != nil {
+		return nil, err
+	}
+
+	if err := encodeSigWrapperToBuffer(buf[n:], sig); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// DecodeSignedBlockExact decodes a signed block encoded by EncodeSignedBlock.
+// If the buffer is longer than required to decode the block, returns encoder.ErrRemainingBytes.
+func DecodeSignedBlockExact(buf []byte) (*coin.SignedBlock, error) {
+	var b coin.SignedBlock
+	n, err := decodeBlock(buf, &b.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig sigWrapper
+	if err := decodeSigWrapperExact(buf[n:], &sig); err != nil {
+		return nil, err
+	}
+	b.Sig = sig.Sig
+
+	return &b, nil
+}
@@ -0,0 +1,42 @@
+package blockdb
+
+import (
+	mathrand "math/rand"
+	"testing"
+	"time"
+
+	"github.com/skycoin/encodertest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func TestEncodeSignedBlock(t *testing.T) {
+	rand := mathrand.New(mathrand.NewSource(time.Now().Unix()))
+
+	for i := 0; i < 10; i++ {
+		var b coin.SignedBlock
+		err := encodertest.PopulateRandom(&b, rand, encodertest.PopulateRandomOptions{
+			MaxRandLen: 4,
+			MinRandLen: 1,
+		})
+		require.NoError(t, err)
+
+		buf, err := EncodeSignedBlock(&b)
+		require.NoError(t, err)
+
+		// The encoding matches the reflection based encoder
+		require.Equal(t, encoder.Serialize(b), buf)
+
+		b2, err := DecodeSignedBlockExact(buf)
+		require.NoError(t, err)
+		require.Equal(t, b, *b2)
+
+		_, err = DecodeSignedBlockExact(buf[:len(buf)-1])
+		require.Equal(t, encoder.ErrBufferUnderflow, err)
+
+		_, err = DecodeSignedBlockExact(append(buf, 0))
+		require.Equal(t, encoder.ErrRemainingBytes, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
@@ -20,6 +21,9 @@ var (
 	UnspentPoolAddrIndexBkt = []byte("unspent_pool_addr_index")
 	// UnspentMetaBkt holds unspent output metadata
 	UnspentMetaBkt = []byte("unspent_meta")
+	// UnspentSpentBkt holds recently spent outputs, indexed by unspent output hash,
+	// so that the blocks which spent them can be rolled back
+	UnspentSpentBkt = []byte("unspent_spent")
 )
 
 // ErrUnspentNotExist is returned if an unspent is not found in the pool
@@ -141,6 +145,39 @@ func (pl *pool) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
 	return dbutil.Delete(tx, UnspentPoolBkt, hash[:])
 }
 
+// spentPool holds the outputs spent by recent blocks
+type spentPool struct{}
+
+func (sp spentPool) get(tx *dbutil.Tx, hash cipher.SHA256) (*coin.UxOut, error) {
+	var out coin.UxOut
+
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnspentSpentBkt, hash[:])
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, nil
+	}
+
+	if err := decodeUxOutExact(v, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (sp spentPool) put(tx *dbutil.Tx, hash cipher.SHA256, ux coin.UxOut) error {
+	buf, err := encodeUxOut(&ux)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, UnspentSpentBkt, hash[:], buf)
+}
+
+func (sp *spentPool) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnspentSpentBkt, hash[:])
+}
+
 type poolAddrIndex struct{}
 
 func (p poolAddrIndex) get(tx *dbutil.Tx, addr cipher.Address) ([]cipher.SHA256, error) {
@@ -253,7 +290,9 @@ func (p poolAddrIndex) adjust(tx *dbutil.Tx, addr cipher.Address, addHashes, rmH
 // Unspents unspent outputs pool
 type Unspents struct {
 	pool          *pool
+	spent         *spentPool
 	poolAddrIndex *poolAddrIndex
+	richlist      *richlistIndex
 	meta          *unspentMeta
 }
 
@@ -261,7 +300,9 @@ type Unspents struct {
 func NewUnspentPool() *Unspents {
 	return &Unspents{
 		pool:          &pool{},
+		spent:         &spentPool{},
 		poolAddrIndex: &poolAddrIndex{},
+		richlist:      &richlistIndex{},
 		meta:          &unspentMeta{},
 	}
 }
@@ -277,17 +318,19 @@ func (up *Unspents) MaybeBuildIndexes(tx *dbutil.Tx, headSeq uint64) error {
 		return err
 	}
 
-	if ok && addrIndexHeight == headSeq {
-		return nil
-	}
+	if !ok || addrIndexHeight != headSeq {
+		if addrIndexHeight > headSeq {
+			logger.Critical().Warningf("addrIndexHeight > headSeq (%d > %d)", addrIndexHeight, headSeq)
+		}
 
-	if addrIndexHeight > headSeq {
-		logger.Critical().Warningf("addrIndexHeight > headSeq (%d > %d)", addrIndexHeight, headSeq)
-	}
+		logger.Infof("Rebuilding unspent_pool_addr_index (addrHeightIndexExists=%v, addrIndexHeight=%d, headSeq=%d)", ok, addrIndexHeight, headSeq)
 
-	logger.Infof("Rebuilding unspent_pool_addr_index (addrHeightIndexExists=%v, addrIndexHeight=%d, headSeq=%d)", ok, addrIndexHeight, headSeq)
+		if err := up.buildAddrIndex(tx); err != nil {
+			return err
+		}
+	}
 
-	return up.buildAddrIndex(tx)
+	return up.maybeBuildRichlistIndex(tx, headSeq)
 }
 
 func (up *Unspents) buildAddrIndex(tx *dbutil.Tx) error {
@@ -343,6 +386,207 @@ func (up *Unspents) buildAddrIndex(tx *dbutil.Tx) error {
 	return nil
 }
 
+// AddrIndexRebuild describes the differences between the previous and the rebuilt unspent address index
+type AddrIndexRebuild struct {
+	// PreviousHeight is the block height of the previous index, if it had one
+	PreviousHeight       uint64
+	PreviousHeightExists bool
+	// Addresses is the number of addresses in the rebuilt index
+	Addresses uint64
+	// Outputs is the number of unspent outputs in the rebuilt index
+	Outputs uint64
+	// Missing are addresses with unspent outputs that were not indexed
+	Missing []cipher.Address
+	// Stale are indexed addresses that have no unspent outputs
+	Stale []cipher.Address
+	// Mismatched are addresses that were indexed with the wrong unspent outputs
+	Mismatched []cipher.Address
+	// Corrupt is the number of previous index entries that could not be decoded
+	Corrupt uint64
+}
+
+// RebuildAddrIndex rebuilds the unspent address index from the unspent pool at block headSeq,
+// and returns the differences to the previous index
+func (up *Unspents) RebuildAddrIndex(tx *dbutil.Tx, headSeq uint64) (*AddrIndexRebuild, error) {
+	var r AddrIndexRebuild
+
+	var err error
+	r.PreviousHeight, r.PreviousHeightExists, err = up.meta.getAddrIndexHeight(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[cipher.Address]map[cipher.SHA256]struct{})
+	if err := dbutil.ForEach(tx, UnspentPoolAddrIndexBkt, func(k, v []byte) error {
+		addr, err := cipher.AddressFromBytes(k)
+		if err != nil {
+			r.Corrupt++
+			return nil
+		}
+
+		var hashes hashesWrapper
+		if err := decodeHashesWrapperExact(v, &hashes); err != nil {
+			r.Corrupt++
+			r.Mismatched = append(r.Mismatched, addr)
+			previous[addr] = nil
+			return nil
+		}
+
+		set := make(map[cipher.SHA256]struct{}, len(hashes.Hashes))
+		for _, h := range hashes.Hashes {
+			set[h] = struct{}{}
+		}
+		previous[addr] = set
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := up.buildAddrIndex(tx); err != nil {
+		return nil, err
+	}
+
+	if err := up.meta.setAddrIndexHeight(tx, headSeq); err != nil {
+		return nil, err
+	}
+
+	// The richlist index is derived from the unspent pool too, rebuild it along with the address index
+	if err := up.richlist.build(tx); err != nil {
+		return nil, err
+	}
+
+	if err := up.meta.setRichlistIndexHeight(tx, headSeq); err != nil {
+		return nil, err
+	}
+
+	if err := dbutil.ForEach(tx, UnspentPoolAddrIndexBkt, func(k, v []byte) error {
+		addr, err := cipher.AddressFromBytes(k)
+		if err != nil {
+			return err
+		}
+
+		var hashes hashesWrapper
+		if err := decodeHashesWrapperExact(v, &hashes); err != nil {
+			return err
+		}
+
+		r.Addresses++
+		r.Outputs += uint64(len(hashes.Hashes))
+
+		prev, ok := previous[addr]
+		delete(previous, addr)
+
+		switch {
+		case !ok:
+			r.Missing = append(r.Missing, addr)
+		case prev == nil:
+			// Already reported as mismatched because it could not be decoded
+		case len(prev) != len(hashes.Hashes):
+			r.Mismatched = append(r.Mismatched, addr)
+		default:
+			for _, h := range hashes.Hashes {
+				if _, ok := prev[h]; !ok {
+					r.Mismatched = append(r.Mismatched, addr)
+					break
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for addr := range previous {
+		r.Stale = append(r.Stale, addr)
+	}
+	sortAddresses(r.Stale)
+	sortAddresses(r.Mismatched)
+
+	return &r, nil
+}
+
+// RecomputeUxHash recomputes the xor hash of the unspent pool and stores it.
+// Returns the previous and the recomputed hash.
+func (up *Unspents) RecomputeUxHash(tx *dbutil.Tx) (cipher.SHA256, cipher.SHA256, error) {
+	previous, err := up.meta.getXorHash(tx)
+	if err != nil {
+		return cipher.SHA256{}, cipher.SHA256{}, err
+	}
+
+	var xorHash cipher.SHA256
+	if err := dbutil.ForEach(tx, UnspentPoolBkt, func(_, v []byte) error {
+		var ux coin.UxOut
+		if err := decodeUxOutExact(v, &ux); err != nil {
+			return err
+		}
+
+		xorHash = xorHash.Xor(ux.SnapshotHash())
+		return nil
+	}); err != nil {
+		return cipher.SHA256{}, cipher.SHA256{}, err
+	}
+
+	if err := up.meta.setXorHash(tx, xorHash); err != nil {
+		return cipher.SHA256{}, cipher.SHA256{}, err
+	}
+
+	return previous, xorHash, nil
+}
+
+func sortAddresses(addrs []cipher.Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+}
+
+// Load fills an empty unspent pool with the unspent outputs of a snapshot taken at block headSeq
+func (up *Unspents) Load(tx *dbutil.Tx, uxs coin.UxArray, headSeq uint64) error {
+	if n, err := up.Len(tx); err != nil {
+		return err
+	} else if n != 0 {
+		return errors.New("cannot load unspents into an unspent pool that is not empty")
+	}
+
+	var xorHash cipher.SHA256
+	for _, ux := range uxs {
+		if ux.Head.BkSeq > headSeq {
+			return fmt.Errorf("uxout %s was created after block %d", ux.Hash().Hex(), headSeq)
+		}
+
+		h := ux.Hash()
+		if hasKey, err := up.Contains(tx, h); err != nil {
+			return err
+		} else if hasKey {
+			return fmt.Errorf("attempted to insert uxout:%v twice into the unspent pool", h.Hex())
+		}
+
+		if err := up.pool.put(tx, h, ux); err != nil {
+			return err
+		}
+
+		xorHash = xorHash.Xor(ux.SnapshotHash())
+	}
+
+	if err := up.meta.setXorHash(tx, xorHash); err != nil {
+		return err
+	}
+
+	if err := up.buildAddrIndex(tx); err != nil {
+		return err
+	}
+
+	if err := up.meta.setAddrIndexHeight(tx, headSeq); err != nil {
+		return err
+	}
+
+	if err := up.richlist.build(tx); err != nil {
+		return err
+	}
+
+	return up.meta.setRichlistIndexHeight(tx, headSeq)
+}
+
 // ProcessBlock adds unspents from a block to the unspent pool
 func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	// Gather all transaction inputs
@@ -365,15 +609,25 @@ func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 
 	// Remove spent outputs
 	rmAddrHashes := make(map[cipher.Address][]cipher.SHA256)
+	deltas := make(map[cipher.Address]balanceDelta)
 	for _, ux := range uxs {
 		xorHash = xorHash.Xor(ux.SnapshotHash())
 
+		if err := addBalanceDelta(deltas, ux.Body.Address, 0, ux.Body.Coins); err != nil {
+			return err
+		}
+
 		h := ux.Hash()
 
 		if err := up.pool.delete(tx, h); err != nil {
 			return err
 		}
 
+		// Keep the spent output so that the block can be rolled back
+		if err := up.spent.put(tx, h, ux); err != nil {
+			return err
+		}
+
 		rmAddrHashes[ux.Body.Address] = append(rmAddrHashes[ux.Body.Address], h)
 	}
 
@@ -403,6 +657,10 @@ func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 
 		// Recalculate xorHash
 		xorHash = xorHash.Xor(ux.SnapshotHash())
+
+		if err := addBalanceDelta(deltas, ux.Body.Address, ux.Body.Coins, 0); err != nil {
+			return err
+		}
 	}
 
 	// Set xorHash
@@ -446,7 +704,143 @@ func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	}
 
 	// Update the addrIndexHeight
-	return up.meta.setAddrIndexHeight(tx, b.Block.Head.BkSeq)
+	if err := up.meta.setAddrIndexHeight(tx, b.Block.Head.BkSeq); err != nil {
+		return err
+	}
+
+	// Update the richlist index
+	if err := up.richlist.adjust(tx, deltas); err != nil {
+		return err
+	}
+
+	return up.meta.setRichlistIndexHeight(tx, b.Block.Head.BkSeq)
+}
+
+// RollbackBlock reverts the changes made to the unspent pool by ProcessBlock.
+// The block must be the last block processed.
+// Outputs spent by the block are restored from the outputs kept by ProcessBlock,
+// which are removed once the block is older than the rollback depth (see PruneSpent).
+func (up *Unspents) RollbackBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	if b.Block.Head.BkSeq == 0 {
+		return errors.New("cannot roll back the genesis block")
+	}
+
+	addrIndexHeight, ok, err := up.meta.getAddrIndexHeight(tx)
+	if err != nil {
+		return err
+	}
+
+	if !ok || addrIndexHeight != b.Block.Head.BkSeq {
+		err := errors.New("unspent pool rolling back a block that is not the last processed block")
+		logger.Critical().Error(err.Error())
+		return err
+	}
+
+	xorHash, err := up.meta.getXorHash(tx)
+	if err != nil {
+		return err
+	}
+
+	// Remove the outputs created by the block
+	rmAddrHashes := make(map[cipher.Address][]cipher.SHA256)
+	deltas := make(map[cipher.Address]balanceDelta)
+	for _, txn := range b.Body.Transactions {
+		for _, ux := range coin.CreateUnspents(b.Head, txn) {
+			h := ux.Hash()
+
+			if hasKey, err := up.Contains(tx, h); err != nil {
+				return err
+			} else if !hasKey {
+				return NewErrUnspentNotExist(h.Hex())
+			}
+
+			if err := up.pool.delete(tx, h); err != nil {
+				return err
+			}
+
+			xorHash = xorHash.Xor(ux.SnapshotHash())
+
+			if err := addBalanceDelta(deltas, ux.Body.Address, 0, ux.Body.Coins); err != nil {
+				return err
+			}
+
+			rmAddrHashes[ux.Body.Address] = append(rmAddrHashes[ux.Body.Address], h)
+		}
+	}
+
+	// Restore the outputs spent by the block
+	addAddrHashes := make(map[cipher.Address][]cipher.SHA256)
+	for _, txn := range b.Body.Transactions {
+		for _, h := range txn.In {
+			ux, err := up.spent.get(tx, h)
+			if err != nil {
+				return err
+			} else if ux == nil {
+				return fmt.Errorf("spent output %s is not available, block %d can't be rolled back", h.Hex(), b.Block.Head.BkSeq)
+			}
+
+			if err := up.pool.put(tx, h, *ux); err != nil {
+				return err
+			}
+
+			if err := up.spent.delete(tx, h); err != nil {
+				return err
+			}
+
+			xorHash = xorHash.Xor(ux.SnapshotHash())
+
+			if err := addBalanceDelta(deltas, ux.Body.Address, ux.Body.Coins, 0); err != nil {
+				return err
+			}
+
+			addAddrHashes[ux.Body.Address] = append(addAddrHashes[ux.Body.Address], h)
+		}
+	}
+
+	if err := up.meta.setXorHash(tx, xorHash); err != nil {
+		return err
+	}
+
+	// Update indexes
+	for addr, rmHashes := range rmAddrHashes {
+		addHashes := addAddrHashes[addr]
+
+		if err := up.poolAddrIndex.adjust(tx, addr, addHashes, rmHashes); err != nil {
+			return err
+		}
+
+		delete(addAddrHashes, addr)
+	}
+
+	for addr, addHashes := range addAddrHashes {
+		if err := up.poolAddrIndex.adjust(tx, addr, addHashes, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := up.meta.setAddrIndexHeight(tx, b.Block.Head.BkSeq-1); err != nil {
+		return err
+	}
+
+	if err := up.richlist.adjust(tx, deltas); err != nil {
+		return err
+	}
+
+	return up.meta.setRichlistIndexHeight(tx, b.Block.Head.BkSeq-1)
+}
+
+// PruneSpent removes the outputs spent by a block that were kept for rolling it back.
+// The block can no longer be rolled back afterwards.
+func (up *Unspents) PruneSpent(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	for _, txn := range b.Body.Transactions {
+		for _, h := range txn.In {
+			if err := up.spent.delete(tx, h); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // GetArray returns UxOut for a set of hashes, will return error if any of the hashes do not exist in the pool.
@@ -68,7 +68,15 @@ func addUxOut(db *dbutil.DB, up *Unspents, ux coin.UxOut) error {
 			return err
 		}
 
-		return up.poolAddrIndex.adjust(tx, ux.Body.Address, []cipher.SHA256{ux.Hash()}, nil)
+		if err := up.poolAddrIndex.adjust(tx, ux.Body.Address, []cipher.SHA256{ux.Hash()}, nil); err != nil {
+			return err
+		}
+
+		return up.richlist.adjust(tx, map[cipher.Address]balanceDelta{
+			ux.Body.Address: {
+				add: ux.Body.Coins,
+			},
+		})
 	})
 }
 
@@ -635,6 +643,208 @@ func TestUnspentProcessBlock(t *testing.T) {
 	}
 }
 
+func TestUnspentRollbackBlock(t *testing.T) {
+	var uxs coin.UxArray
+	for i := 0; i < 5; i++ {
+		ux := makeUxOut(t)
+		uxs = append(uxs, ux)
+	}
+
+	db, closedb := prepareDB(t)
+	defer closedb()
+
+	up := NewUnspentPool()
+
+	for _, ux := range uxs {
+		err := addUxOut(db, up, ux)
+		require.NoError(t, err)
+	}
+
+	// Spend two outputs of one address to a new address and the address of another spent output
+	txn := coin.Transaction{}
+	for _, in := range uxs[:2] {
+		err := txn.PushInput(in.Hash())
+		require.NoError(t, err)
+	}
+	err := txn.PushOutput(testutil.MakeAddress(), 1e6, 10)
+	require.NoError(t, err)
+	err = txn.PushOutput(uxs[1].Body.Address, 1e6, 10)
+	require.NoError(t, err)
+
+	getState := func() (coin.UxArray, cipher.SHA256, map[cipher.Address][]cipher.SHA256) {
+		var all coin.UxArray
+		var uxHash cipher.SHA256
+		index := make(map[cipher.Address][]cipher.SHA256)
+
+		err := db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			all, err = up.GetAll(tx)
+			require.NoError(t, err)
+
+			uxHash, err = up.GetUxHash(tx)
+			require.NoError(t, err)
+
+			return dbutil.ForEach(tx, UnspentPoolAddrIndexBkt, func(k, v []byte) error {
+				addr, err := cipher.AddressFromBytes(k)
+				require.NoError(t, err)
+
+				var uxHashes []cipher.SHA256
+				err = encoder.DeserializeRawExact(v, &uxHashes)
+				require.NoError(t, err)
+
+				sort.Slice(uxHashes, func(i, j int) bool {
+					return bytes.Compare(uxHashes[i][:], uxHashes[j][:]) < 0
+				})
+
+				index[addr] = uxHashes
+				return nil
+			})
+		})
+		require.NoError(t, err)
+
+		sort.Slice(all, func(i, j int) bool {
+			return all[i].Body.Hours+all[i].Body.Coins < all[j].Body.Hours+all[j].Body.Coins
+		})
+
+		return all, uxHash, index
+	}
+
+	initUxs, initUxHash, initIndex := getState()
+
+	var sb *coin.SignedBlock
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		uxHash, err := up.GetUxHash(tx)
+		require.NoError(t, err)
+
+		block, err := coin.NewBlock(coin.Block{}, uint64(time.Now().Unix()), uxHash, coin.Transactions{txn}, feeCalc)
+		require.NoError(t, err)
+
+		sb = &coin.SignedBlock{
+			Block: *block,
+		}
+
+		return up.ProcessBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	// The spent outputs are kept for rolling back
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, UnspentSpentBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), n)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Only the last processed block can be rolled back
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		b := *sb
+		b.Block.Head.BkSeq++
+		return up.RollbackBlock(tx, &b)
+	})
+	require.Equal(t, errors.New("unspent pool rolling back a block that is not the last processed block"), err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return up.RollbackBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	rollbackUxs, rollbackUxHash, rollbackIndex := getState()
+	require.Equal(t, initUxHash, rollbackUxHash)
+	require.Equal(t, initIndex, rollbackIndex)
+	require.Equal(t, len(initUxs), len(rollbackUxs))
+	for _, ux := range initUxs {
+		require.Contains(t, rollbackUxs, ux)
+	}
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, UnspentSpentBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), n)
+
+		height, ok, err := up.meta.getAddrIndexHeight(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(0), height)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// A block can't be rolled back once its spent outputs are pruned
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := up.ProcessBlock(tx, sb); err != nil {
+			return err
+		}
+
+		return up.PruneSpent(tx, sb)
+	})
+	require.NoError(t, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return up.RollbackBlock(tx, sb)
+	})
+	require.Equal(t, fmt.Errorf("spent output %s is not available, block 1 can't be rolled back", uxs[0].Hash().Hex()), err)
+}
+
+func TestUnspentLoad(t *testing.T) {
+	var uxs coin.UxArray
+	for i := 0; i < 5; i++ {
+		uxs = append(uxs, makeUxOut(t))
+	}
+
+	// Two outputs of the same address
+	uxs[1].Body.Address = uxs[0].Body.Address
+
+	var xorHash cipher.SHA256
+	for _, ux := range uxs {
+		xorHash = xorHash.Xor(ux.SnapshotHash())
+	}
+
+	db, closedb := prepareDB(t)
+	defer closedb()
+
+	up := NewUnspentPool()
+
+	// Outputs created after the head block are rejected
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return up.Load(tx, uxs, 1)
+	})
+	testutil.RequireError(t, err, fmt.Sprintf("uxout %s was created after block 1", uxs[0].Hash().Hex()))
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return up.Load(tx, uxs, 10)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := up.Len(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(uxs)), n)
+
+		uxHash, err := up.GetUxHash(tx)
+		require.NoError(t, err)
+		require.Equal(t, xorHash, uxHash)
+
+		height, ok, err := up.meta.getAddrIndexHeight(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(10), height)
+
+		addrHashes, err := up.GetUnspentHashesOfAddrs(tx, []cipher.Address{uxs[0].Body.Address, uxs[2].Body.Address})
+		require.NoError(t, err)
+		require.Len(t, addrHashes[uxs[0].Body.Address], 2)
+		require.Equal(t, []cipher.SHA256{uxs[2].Hash()}, addrHashes[uxs[2].Body.Address])
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The pool must be empty
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return up.Load(tx, coin.UxArray{makeUxOut(t)}, 10)
+	})
+	testutil.RequireError(t, err, "cannot load unspents into an unspent pool that is not empty")
+}
+
 func TestUnspentPoolAddrIndex(t *testing.T) {
 	addrs := make([]cipher.Address, 10)
 	for i := range addrs {
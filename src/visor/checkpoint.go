@@ -0,0 +1,119 @@
+package visor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// Checkpoint is a main chain block that is known to be valid.
+// A blockchain whose block at the checkpoint's seq has a different hash is rejected.
+type Checkpoint struct {
+	Seq  uint64
+	Hash cipher.SHA256
+}
+
+// ParseCheckpoint parses a checkpoint in the format "seq:hash", with a hex encoded block hash
+func ParseCheckpoint(s string) (Checkpoint, error) {
+	pts := strings.Split(s, ":")
+	if len(pts) != 2 {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint %q, must be in the format seq:hash", s)
+	}
+
+	seq, err := strconv.ParseUint(pts[0], 10, 64)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint %q seq: %v", s, err)
+	}
+
+	hash, err := cipher.SHA256FromHex(pts[1])
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint %q hash: %v", s, err)
+	}
+
+	return Checkpoint{
+		Seq:  seq,
+		Hash: hash,
+	}, nil
+}
+
+// String returns the checkpoint in the format "seq:hash"
+func (c Checkpoint) String() string {
+	return fmt.Sprintf("%d:%s", c.Seq, c.Hash.Hex())
+}
+
+// ErrCheckpointMismatch is returned if a block conflicts with a checkpoint
+type ErrCheckpointMismatch struct {
+	Checkpoint Checkpoint
+	Seq        uint64
+	Hash       cipher.SHA256
+}
+
+func (e ErrCheckpointMismatch) Error() string {
+	return fmt.Sprintf("block seq=%d hash=%s conflicts with checkpoint seq=%d hash=%s", e.Seq, e.Hash.Hex(), e.Checkpoint.Seq, e.Checkpoint.Hash.Hex())
+}
+
+// verifyCheckpoint returns ErrCheckpointMismatch if there is a checkpoint at seq with a different hash
+func verifyCheckpoint(checkpoints []Checkpoint, seq uint64, hash cipher.SHA256) error {
+	for _, c := range checkpoints {
+		if c.Seq == seq && c.Hash != hash {
+			return ErrCheckpointMismatch{
+				Checkpoint: c,
+				Seq:        seq,
+				Hash:       hash,
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyStoredCheckpoints returns ErrCheckpointMismatch if a block already in the db conflicts with a checkpoint,
+// since the checkpoints may have been added to the config after the blocks were executed.
+// In light client mode, the synced headers are checked instead.
+func verifyStoredCheckpoints(tx *dbutil.Tx, checkpoints []Checkpoint, bc *Blockchain, light *LightClient) error {
+	for _, c := range checkpoints {
+		var hash cipher.SHA256
+		if light != nil {
+			if !dbutil.Exists(tx, LightHeadersBkt) {
+				return nil
+			}
+
+			h, err := light.GetHeader(tx, c.Seq)
+			if err != nil {
+				return err
+			} else if h == nil {
+				continue
+			}
+			hash = h.Head.Hash()
+		} else {
+			if !dbutil.Exists(tx, blockdb.BlocksBkt) {
+				return nil
+			}
+
+			headSeq, ok, err := bc.HeadSeq(tx)
+			if err != nil {
+				return err
+			} else if !ok || c.Seq > headSeq {
+				continue
+			}
+
+			b, err := bc.GetSignedBlockBySeq(tx, c.Seq)
+			if err != nil {
+				return err
+			} else if b == nil {
+				continue
+			}
+			hash = b.HashHeader()
+		}
+
+		if err := verifyCheckpoint([]Checkpoint{c}, c.Seq, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
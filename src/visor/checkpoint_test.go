@@ -0,0 +1,231 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestParseCheckpoint(t *testing.T) {
+	hash := testutil.RandSHA256(t)
+
+	c, err := ParseCheckpoint("100:" + hash.Hex())
+	require.NoError(t, err)
+	require.Equal(t, Checkpoint{
+		Seq:  100,
+		Hash: hash,
+	}, c)
+	require.Equal(t, "100:"+hash.Hex(), c.String())
+
+	for _, s := range []string{
+		"",
+		"100",
+		hash.Hex(),
+		"foo:" + hash.Hex(),
+		"100:foo",
+		"100:" + hash.Hex() + ":1",
+	} {
+		_, err := ParseCheckpoint(s)
+		require.Error(t, err, s)
+	}
+}
+
+func setTestCheckpoints(c *testChain, checkpoints []Checkpoint, assumeValid Checkpoint) {
+	c.v.Config.Checkpoints = checkpoints
+	c.v.Config.AssumeValid = assumeValid
+
+	bc := c.v.blockchain.(*Blockchain)
+	bc.cfg.Checkpoints = c.v.Config.checkpoints()
+	bc.cfg.AssumeValid = assumeValid
+}
+
+func TestCheckpoints(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 3)
+
+	// A chain that matches the checkpoint
+	f := newTestChain(t)
+	defer f.shutdown()
+	setTestCheckpoints(f, []Checkpoint{{Seq: 2, Hash: c.block(2).HashHeader()}}, Checkpoint{})
+
+	for i := uint64(1); i <= 3; i++ {
+		err := f.v.ExecuteSignedBlock(c.block(i))
+		require.NoError(t, err)
+	}
+	f.requireSameState(c)
+
+	// A chain that conflicts with the checkpoint
+	g := newTestChain(t)
+	defer g.shutdown()
+	checkpoint := Checkpoint{Seq: 2, Hash: testutil.RandSHA256(t)}
+	setTestCheckpoints(g, []Checkpoint{checkpoint}, Checkpoint{})
+
+	err := g.v.ExecuteSignedBlock(c.block(1))
+	require.NoError(t, err)
+	err = g.v.ExecuteSignedBlock(c.block(2))
+	require.Equal(t, ErrCheckpointMismatch{
+		Checkpoint: checkpoint,
+		Seq:        2,
+		Hash:       c.block(2).HashHeader(),
+	}, err)
+
+	// A fork that branches below a checkpoint reached by the main chain is rejected
+	fork := c.fork(1)
+	defer fork.shutdown()
+	addSpendBlocks(t, fork, 2)
+
+	err = f.v.ExecuteSignedBlock(fork.block(2))
+	require.Equal(t, ErrCheckpointMismatch{
+		Checkpoint: Checkpoint{Seq: 2, Hash: c.block(2).HashHeader()},
+		Seq:        2,
+		Hash:       fork.block(2).HashHeader(),
+	}, err)
+
+	// A fork that branches at the checkpoint is allowed
+	fork2 := c.fork(2)
+	defer fork2.shutdown()
+	addSpendBlocks(t, fork2, 1)
+
+	err = f.v.ExecuteSignedBlock(fork2.block(3))
+	require.NoError(t, err)
+	f.requireSameState(c)
+}
+
+// makeBadSigBlock creates a block on top of the head block, with a transaction whose input is signed with the wrong key
+func makeBadSigBlock(t *testing.T, c *testChain) coin.SignedBlock {
+	head := c.head()
+	txn := head.Body.Transactions[len(head.Body.Transactions)-1]
+	ux := coin.CreateUnspents(head.Head, txn)[len(txn.Out)-1]
+
+	_, badSecret := cipher.GenerateKeyPair()
+
+	var badTxn coin.Transaction
+	err := badTxn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = badTxn.PushOutput(testutil.MakeAddress(), 1e6, 0)
+	require.NoError(t, err)
+	err = badTxn.PushOutput(genAddress, ux.Body.Coins-1e6, 0)
+	require.NoError(t, err)
+	badTxn.SignInputs([]cipher.SecKey{badSecret})
+	err = badTxn.UpdateHeader()
+	require.NoError(t, err)
+
+	bc := c.v.blockchain.(*Blockchain)
+
+	var b *coin.Block
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		uxHash, err := bc.Unspent().GetUxHash(tx)
+		if err != nil {
+			return err
+		}
+
+		b, err = coin.NewBlock(head.Block, head.Time()+100, uxHash, coin.Transactions{badTxn}, bc.TransactionFee(tx, head.Time()))
+		return err
+	})
+	require.NoError(t, err)
+
+	return coin.SignedBlock{
+		Block: *b,
+		Sig:   cipher.MustSignHash(b.HashHeader(), genSecret),
+	}
+}
+
+func TestAssumeValid(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 2)
+
+	b := makeBadSigBlock(t, c)
+	require.Equal(t, uint64(3), b.Seq())
+
+	// The input signatures are verified by default
+	err := c.v.ExecuteSignedBlock(b)
+	testutil.RequireError(t, err, "Transaction violates hard constraint: Signature not valid for output being spent")
+
+	// The assume-valid block is below the block
+	setTestCheckpoints(c, nil, Checkpoint{Seq: 2, Hash: c.block(2).HashHeader()})
+	err = c.v.ExecuteSignedBlock(b)
+	testutil.RequireError(t, err, "Transaction violates hard constraint: Signature not valid for output being spent")
+
+	// The block is below the assume-valid block, but it is not known to lead to it until it is reached
+	setTestCheckpoints(c, nil, Checkpoint{Seq: 4, Hash: testutil.RandSHA256(t)})
+	err = c.v.ExecuteSignedBlock(b)
+	testutil.RequireError(t, err, "Transaction violates hard constraint: Signature not valid for output being spent")
+
+	// The input signatures of the assume-valid block are not verified
+	assumeValid := Checkpoint{Seq: 3, Hash: b.HashHeader()}
+	setTestCheckpoints(c, nil, assumeValid)
+	err = c.v.ExecuteSignedBlock(b)
+	require.NoError(t, err)
+	require.Equal(t, b.HashHeader(), c.head().HashHeader())
+
+	// Once the assume-valid block is reached, its main chain ancestors are known to lead to it
+	fork := c.fork(1)
+	defer fork.shutdown()
+	addSpendBlocks(t, fork, 1)
+
+	bc := c.v.blockchain.(*Blockchain)
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		for _, tc := range []struct {
+			b      coin.SignedBlock
+			signed TxnSignedFlag
+		}{
+			{c.block(2), TxnAssumeSigned},
+			{b, TxnAssumeSigned},
+			{fork.block(2), TxnSigned},
+			{makeBadSigBlock(t, c), TxnSigned},
+		} {
+			signed, err := bc.txnSignedFlag(tx, tc.b.Block)
+			require.NoError(t, err)
+			require.Equal(t, tc.signed, signed, "seq=%d", tc.b.Seq())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestVerifyStoredCheckpoints(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 3)
+
+	cfg := c.v.Config
+	cfg.IsBlockPublisher = false
+
+	// Checkpoints above the head block are not checked
+	cfg.Checkpoints = []Checkpoint{{Seq: 2, Hash: c.block(2).HashHeader()}}
+	cfg.AssumeValid = Checkpoint{Seq: 10, Hash: testutil.RandSHA256(t)}
+	_, err := New(cfg, c.v.db, nil)
+	require.NoError(t, err)
+
+	// A block in the db conflicts with a checkpoint
+	checkpoint := Checkpoint{Seq: 1, Hash: testutil.RandSHA256(t)}
+	cfg.Checkpoints = []Checkpoint{checkpoint}
+	cfg.AssumeValid = Checkpoint{}
+	_, err = New(cfg, c.v.db, nil)
+	require.Equal(t, ErrCheckpointMismatch{
+		Checkpoint: checkpoint,
+		Seq:        1,
+		Hash:       c.block(1).HashHeader(),
+	}, err)
+
+	// A block in the db conflicts with the assume-valid block
+	assumeValid := Checkpoint{Seq: 3, Hash: testutil.RandSHA256(t)}
+	cfg.Checkpoints = nil
+	cfg.AssumeValid = assumeValid
+	_, err = New(cfg, c.v.db, nil)
+	require.Equal(t, ErrCheckpointMismatch{
+		Checkpoint: assumeValid,
+		Seq:        3,
+		Hash:       c.block(3).HashHeader(),
+	}, err)
+}
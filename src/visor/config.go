@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 )
 
 // Config configuration parameters for the Visor
@@ -25,6 +27,15 @@ type Config struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum size of a block, in bytes for creating blocks
 	MaxBlockTransactionsSize uint32
+	// Limits of the unconfirmed transaction pool
+	UnconfirmedPoolLimits UnconfirmedPoolLimits
+
+	// Selects the pending transactions to include when creating blocks.
+	// DefaultBlockAssembler is used if not set
+	BlockAssembler BlockAssembler
+
+	// Blockchain and unconfirmed pool events are published to EventBus, if set
+	EventBus *eventbus.Bus
 
 	// Coin distribution parameters (necessary for txn verification)
 	Distribution params.Distribution
@@ -44,6 +55,30 @@ type Config struct {
 	GenesisCoinVolume uint64
 	// enable arbitrating mode
 	Arbitrating bool
+
+	// Disable the historydb, which records the transactions and spent outputs of each address.
+	// The historydb is erased, and the history queries return ErrHistoryDisabled.
+	// If enabled again, the historydb is rebuilt from the blocks in the background.
+	DisableHistory bool
+
+	// Number of recent blocks whose bodies are kept, older block bodies are pruned.
+	// Pruning is disabled if 0. The historydb is disabled when pruning.
+	PruneBlocks uint64
+
+	// Run as a header-only light client, which syncs and verifies the signed block headers without
+	// executing blocks, and answers balance queries for WatchAddresses only. The historydb is disabled.
+	LightClient bool
+	// Addresses watched by the light client
+	WatchAddresses []cipher.Address
+
+	// Main chain blocks known to be valid. Blocks that conflict with a checkpoint are rejected
+	Checkpoints []Checkpoint
+	// The input signatures of transactions in this block and its main chain ancestors are not verified,
+	// once the block is reached. The block is also a checkpoint. Disabled if the hash is empty
+	AssumeValid Checkpoint
+	// Number of workers verifying the input signatures of transactions in parallel when executing blocks.
+	// If 0, the number of CPUs is used
+	SigVerifyWorkers int
 }
 
 // NewConfig creates Config
@@ -115,5 +150,52 @@ func (c Config) Verify() error {
 		return err
 	}
 
+	if c.UnconfirmedPoolLimits.TTL < 0 {
+		return errors.New("UnconfirmedPoolLimits.TTL must be >= 0")
+	}
+
+	if c.PruneBlocks != 0 && c.PruneBlocks < blockdb.MinPruneKeepBlocks {
+		return fmt.Errorf("PruneBlocks must be 0 or >= %d", blockdb.MinPruneKeepBlocks)
+	}
+
+	if c.LightClient {
+		if c.IsBlockPublisher {
+			return errors.New("Cannot run as block publisher in light client mode")
+		}
+
+		if c.PruneBlocks != 0 {
+			return errors.New("PruneBlocks can't be used in light client mode")
+		}
+	} else if len(c.WatchAddresses) != 0 {
+		return errors.New("WatchAddresses requires light client mode")
+	}
+
+	if c.SigVerifyWorkers < 0 {
+		return errors.New("SigVerifyWorkers must be >= 0")
+	}
+
+	if !c.AssumeValid.Hash.Null() && c.AssumeValid.Seq == 0 {
+		return errors.New("AssumeValid can't be the genesis block")
+	}
+
+	checkpoints := make(map[uint64]cipher.SHA256)
+	for _, cp := range c.checkpoints() {
+		if hash, ok := checkpoints[cp.Seq]; ok && hash != cp.Hash {
+			return fmt.Errorf("Conflicting checkpoints for block %d", cp.Seq)
+		}
+		checkpoints[cp.Seq] = cp.Hash
+	}
+
 	return nil
 }
+
+// checkpoints returns the checkpoints, including the assume-valid block
+func (c Config) checkpoints() []Checkpoint {
+	if c.AssumeValid.Hash.Null() {
+		return c.Checkpoints
+	}
+
+	checkpoints := make([]Checkpoint, 0, len(c.Checkpoints)+1)
+	checkpoints = append(checkpoints, c.Checkpoints...)
+	return append(checkpoints, c.AssumeValid)
+}
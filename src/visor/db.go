@@ -61,6 +61,20 @@ func CheckDatabase(db *dbutil.DB, pubkey cipher.PubKey, quit chan struct{}) erro
 	history := historydb.New()
 	indexesMap := historydb.NewIndexesMap()
 
+	// An empty historydb is not verified. It is either disabled, or is rebuilt when the visor starts
+	var historyExists bool
+	if err := db.View("CheckDatabase", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, historydb.HistoryMetaBkt) {
+			return nil
+		}
+
+		var err error
+		_, historyExists, err = history.ParsedBlockSeq(tx)
+		return err
+	}); err != nil {
+		return err
+	}
+
 	var historyVerifyErr error
 	var lock sync.Mutex
 	verifyFunc := func(tx *dbutil.Tx, b *coin.SignedBlock) error {
@@ -69,6 +83,17 @@ func CheckDatabase(db *dbutil.DB, pubkey cipher.PubKey, quit chan struct{}) erro
 			return err
 		}
 
+		if !historyExists {
+			return nil
+		}
+
+		// Blocks of competing chains are not in the historydb
+		if isMain, err := bc.isMainChainBlock(tx, b.Block); err != nil {
+			return err
+		} else if !isMain {
+			return nil
+		}
+
 		// Verify historydb, we don't return the error of history.Verify here,
 		// as we have to check all signature, if we return error early here, the
 		// potential bad signature won't be detected.
@@ -0,0 +1,146 @@
+package dbutil
+
+import (
+	"errors"
+)
+
+// CompactStats describes the data copied by Compact
+type CompactStats struct {
+	Buckets uint64 `json:"buckets"`
+	Keys    uint64 `json:"keys"`
+}
+
+// Compact copies all buckets and keys of src into the empty database dst.
+// Bolt never shrinks a database file, but keys inserted in order into a new file fill their pages
+// completely, so dst only takes the space needed by the data.
+// src and dst can use different engines.
+// The write transaction on dst is committed every txMaxSize bytes, to limit memory use.
+func Compact(dst, src *DB, txMaxSize int64) (*CompactStats, error) {
+	if dst.IsReadOnly() {
+		return nil, errors.New("cannot compact into a read-only database")
+	}
+
+	tx, err := dst.Store.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compactor{
+		dst:       dst,
+		tx:        tx,
+		txMaxSize: txMaxSize,
+	}
+
+	if err := src.View("Compact", func(srcTx *Tx) error {
+		return srcTx.ForEach(func(name []byte, b Bucket) error {
+			return c.copyBucket([][]byte{name}, b)
+		})
+	}); err != nil {
+		_ = c.tx.Rollback() // nolint: errcheck
+		return nil, err
+	}
+
+	if err := c.tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &c.stats, nil
+}
+
+// compactor holds the write transaction of Compact, which is replaced every txMaxSize bytes
+type compactor struct {
+	dst       *DB
+	tx        StoreTx
+	size      int64
+	txMaxSize int64
+	stats     CompactStats
+}
+
+// copyBucket copies the bucket src and its nested buckets to the bucket at path in dst
+func (c *compactor) copyBucket(path [][]byte, src Bucket) error {
+	b, err := c.createBucket(path)
+	if err != nil {
+		return err
+	}
+
+	if err := b.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+
+	c.stats.Buckets++
+
+	return src.ForEach(func(k, v []byte) error {
+		// A nil value is a nested bucket
+		if v == nil {
+			nestedPath := make([][]byte, len(path)+1)
+			copy(nestedPath, path)
+			nestedPath[len(path)] = k
+			return c.copyBucket(nestedPath, src.Bucket(k))
+		}
+
+		if err := c.maybeCommit(int64(len(k) + len(v))); err != nil {
+			return err
+		}
+
+		b := c.bucket(path)
+		if err := b.Put(k, v); err != nil {
+			return err
+		}
+
+		c.stats.Keys++
+		return nil
+	})
+}
+
+// maybeCommit commits the write transaction and begins a new one if it would grow larger than txMaxSize
+func (c *compactor) maybeCommit(size int64) error {
+	if c.txMaxSize == 0 || c.size+size <= c.txMaxSize {
+		c.size += size
+		return nil
+	}
+
+	if err := c.tx.Commit(); err != nil {
+		return err
+	}
+
+	tx, err := c.dst.Store.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	c.tx = tx
+	c.size = size
+	return nil
+}
+
+// createBucket creates the bucket at path in the write transaction
+func (c *compactor) createBucket(path [][]byte) (Bucket, error) {
+	var b Bucket
+	var err error
+	if len(path) == 1 {
+		b, err = c.tx.CreateBucket(path[0])
+	} else {
+		b, err = c.bucket(path[:len(path)-1]).CreateBucket(path[len(path)-1])
+	}
+	if err != nil {
+		return nil, NewErrCreateBucketFailed(path[len(path)-1], err)
+	}
+
+	return b, nil
+}
+
+// bucket returns the bucket at path in the write transaction.
+// The bucket must be looked up again after each commit, because a bucket is only valid in its transaction.
+// The pages of bolt buckets are filled completely, because keys are inserted in order.
+func (c *compactor) bucket(path [][]byte) Bucket {
+	b := c.tx.Bucket(path[0])
+	for _, name := range path[1:] {
+		b = b.Bucket(name)
+	}
+
+	if bb, ok := b.(boltBucket); ok {
+		bb.b.FillPercent = 1.0
+	}
+
+	return b
+}
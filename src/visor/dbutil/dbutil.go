@@ -1,5 +1,6 @@
 /*
-Package dbutil provides boltdb utility methods
+Package dbutil provides utility methods for the key-value store of the node.
+The store is bolt by default, see Store for the engines.
 */
 package dbutil
 
@@ -28,19 +29,26 @@ var (
 	txDurationReportingThreshold = time.Millisecond * 100
 )
 
-// Tx wraps a Tx
+// Tx wraps a StoreTx
 type Tx struct {
-	*bolt.Tx
+	StoreTx
+	onCommit []func()
+}
+
+// OnCommit registers f to be called after the Update transaction is committed.
+// f is not called if the transaction is rolled back.
+func (tx *Tx) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
 }
 
 // String is implemented to prevent a panic when mocking methods with *Tx arguments.
 // The mock library forces arguments to be printed with %s which causes Tx to panic.
 // See https://github.com/stretchr/testify/pull/596
 func (tx *Tx) String() string {
-	return fmt.Sprintf("%v", tx.Tx)
+	return fmt.Sprintf("%v", tx.StoreTx)
 }
 
-// DB wraps a bolt.DB to add logging
+// DB wraps a Store to add logging
 type DB struct {
 	ViewLog                    bool
 	ViewTrace                  bool
@@ -49,7 +57,7 @@ type DB struct {
 	DurationLog                bool
 	DurationReportingThreshold time.Duration
 
-	*bolt.DB
+	Store
 
 	// shutdownLock is added to prevent closing the database while a View transaction is in progress
 	// bolt.DB will block for Update transactions but not for View transactions, and if
@@ -60,8 +68,13 @@ type DB struct {
 	shutdownLock sync.RWMutex
 }
 
-// WrapDB returns WrapDB
+// WrapDB returns a *DB backed by a *bolt.DB
 func WrapDB(db *bolt.DB) *DB {
+	return WrapStore(NewBoltStore(db))
+}
+
+// WrapStore returns a *DB backed by a Store
+func WrapStore(s Store) *DB {
 	return &DB{
 		ViewLog:                    txViewLog,
 		UpdateLog:                  txUpdateLog,
@@ -69,11 +82,11 @@ func WrapDB(db *bolt.DB) *DB {
 		UpdateTrace:                txUpdateTrace,
 		DurationLog:                txDurationLog,
 		DurationReportingThreshold: txDurationReportingThreshold,
-		DB:                         db,
+		Store:                      s,
 	}
 }
 
-// View wraps *bolt.DB.View to add logging
+// View wraps Store.View to add logging
 func (db *DB) View(name string, f func(*Tx) error) error {
 	db.shutdownLock.RLock()
 	defer db.shutdownLock.RUnlock()
@@ -88,8 +101,8 @@ func (db *DB) View(name string, f func(*Tx) error) error {
 
 	t0 := time.Now()
 
-	err := db.DB.View(func(tx *bolt.Tx) error {
-		return f(&Tx{tx})
+	err := db.Store.View(func(tx StoreTx) error {
+		return f(&Tx{StoreTx: tx})
 	})
 
 	t1 := time.Now()
@@ -101,7 +114,7 @@ func (db *DB) View(name string, f func(*Tx) error) error {
 	return err
 }
 
-// Update wraps *bolt.DB.Update to add logging
+// Update wraps Store.Update to add logging
 func (db *DB) Update(name string, f func(*Tx) error) error {
 	db.shutdownLock.RLock()
 	defer db.shutdownLock.RUnlock()
@@ -116,8 +129,14 @@ func (db *DB) Update(name string, f func(*Tx) error) error {
 
 	t0 := time.Now()
 
-	err := db.DB.Update(func(tx *bolt.Tx) error {
-		return f(&Tx{tx})
+	var onCommit []func()
+	err := db.Store.Update(func(tx StoreTx) error {
+		t := &Tx{StoreTx: tx}
+		if err := f(t); err != nil {
+			return err
+		}
+		onCommit = t.onCommit
+		return nil
 	})
 
 	t1 := time.Now()
@@ -126,15 +145,21 @@ func (db *DB) Update(name string, f func(*Tx) error) error {
 		logger.Debugf("db.Update [%s] elapsed %s", name, delta)
 	}
 
+	if err == nil {
+		for _, f := range onCommit {
+			f()
+		}
+	}
+
 	return err
 }
 
-// Close closes the underlying *bolt.DB
+// Close closes the underlying Store
 func (db *DB) Close() error {
 	db.shutdownLock.Lock()
 	defer db.shutdownLock.Unlock()
 
-	return db.DB.Close()
+	return db.Store.Close()
 }
 
 // ErrCreateBucketFailed is returned if creating a bolt.DB bucket fails
@@ -314,13 +339,13 @@ func Len(tx *Tx, bktName []byte) (uint64, error) {
 		return 0, NewErrBucketNotExist(bktName)
 	}
 
-	bstats := bkt.Stats()
+	keyN := bkt.KeyN()
 
-	if bstats.KeyN < 0 {
+	if keyN < 0 {
 		return 0, errors.New("Negative length queried from db stats")
 	}
 
-	return uint64(bstats.KeyN), nil
+	return uint64(keyN), nil
 }
 
 // IsEmpty returns true if the bucket is empty
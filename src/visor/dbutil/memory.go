@@ -0,0 +1,631 @@
+package dbutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// ErrMemoryStoreNoFile is returned when writing a memory store as a database file
+var ErrMemoryStoreNoFile = errors.New("the memory store has no database file")
+
+// memoryStore is a Store which keeps its data in memory.
+// Each bucket is a sorted slice of items. A write transaction copies a bucket the first time it modifies it,
+// and commits by replacing the root of the store, so read transactions keep seeing the snapshot they began with.
+type memoryStore struct {
+	// writeLock is held by the open write transaction
+	writeLock sync.Mutex
+	// lock protects the fields below
+	lock   sync.RWMutex
+	root   *memoryNode
+	lastID uint64
+	closed bool
+}
+
+// memoryNode is the content of a bucket
+type memoryNode struct {
+	// txID is the write transaction which created this copy of the bucket, and can modify it
+	txID  uint64
+	items []memoryItem
+	seq   uint64
+}
+
+// memoryItem is a key-value pair, or a nested bucket if bucket is not nil
+type memoryItem struct {
+	key    []byte
+	value  []byte
+	bucket *memoryNode
+}
+
+// NewMemoryStore returns an empty Store which keeps its data in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		root: &memoryNode{},
+	}
+}
+
+// WrapMemoryDB returns a *DB backed by a new, empty memory store
+func WrapMemoryDB() *DB {
+	return WrapStore(NewMemoryStore())
+}
+
+func (s *memoryStore) Begin(writable bool) (StoreTx, error) {
+	if writable {
+		s.writeLock.Lock()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		if writable {
+			s.writeLock.Unlock()
+		}
+		return nil, bolt.ErrDatabaseNotOpen
+	}
+
+	tx := &memoryTx{
+		store:    s,
+		root:     s.root,
+		writable: writable,
+	}
+
+	if writable {
+		s.lastID++
+		tx.id = s.lastID
+	}
+
+	return tx, nil
+}
+
+func (s *memoryStore) View(f func(StoreTx) error) error {
+	tx, err := s.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	return f(tx)
+}
+
+func (s *memoryStore) Update(f func(StoreTx) error) error {
+	tx, err := s.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback() // nolint: errcheck
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	committed = true
+	return tx.Commit()
+}
+
+func (s *memoryStore) Path() string {
+	return ""
+}
+
+func (s *memoryStore) IsReadOnly() bool {
+	return false
+}
+
+func (s *memoryStore) Close() error {
+	// Wait for the open write transaction
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	s.root = &memoryNode{}
+	return nil
+}
+
+// memoryTx is a transaction of a memoryStore
+type memoryTx struct {
+	store    *memoryStore
+	root     *memoryNode
+	id       uint64
+	writable bool
+	closed   bool
+}
+
+func (tx *memoryTx) String() string {
+	return fmt.Sprintf("memoryTx{id: %d, writable: %v}", tx.id, tx.writable)
+}
+
+// rootBucket returns the root bucket, whose nested buckets are the top-level buckets
+func (tx *memoryTx) rootBucket() *memoryBucket {
+	return &memoryBucket{tx: tx}
+}
+
+func (tx *memoryTx) Bucket(name []byte) Bucket {
+	return tx.rootBucket().Bucket(name)
+}
+
+func (tx *memoryTx) CreateBucket(name []byte) (Bucket, error) {
+	return tx.rootBucket().CreateBucket(name)
+}
+
+func (tx *memoryTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	return tx.rootBucket().CreateBucketIfNotExists(name)
+}
+
+func (tx *memoryTx) DeleteBucket(name []byte) error {
+	return tx.rootBucket().DeleteBucket(name)
+}
+
+func (tx *memoryTx) ForEach(f func(name []byte, b Bucket) error) error {
+	root := tx.rootBucket()
+	return root.ForEach(func(k, _ []byte) error {
+		return f(k, root.Bucket(k))
+	})
+}
+
+func (tx *memoryTx) Writable() bool {
+	return tx.writable
+}
+
+// Size returns the total size of the keys and values in the store
+func (tx *memoryTx) Size() int64 {
+	var size func(n *memoryNode) int64
+	size = func(n *memoryNode) int64 {
+		var s int64
+		for _, it := range n.items {
+			s += int64(len(it.key) + len(it.value))
+			if it.bucket != nil {
+				s += size(it.bucket)
+			}
+		}
+		return s
+	}
+
+	return size(tx.root)
+}
+
+// WriteTo is not supported, a memory store has no database file
+func (tx *memoryTx) WriteTo(w io.Writer) (int64, error) {
+	return 0, ErrMemoryStoreNoFile
+}
+
+func (tx *memoryTx) Commit() error {
+	if tx.closed {
+		return bolt.ErrTxClosed
+	}
+	if !tx.writable {
+		return bolt.ErrTxNotWritable
+	}
+
+	tx.store.lock.Lock()
+	tx.store.root = tx.root
+	tx.store.lock.Unlock()
+
+	tx.close()
+	return nil
+}
+
+func (tx *memoryTx) Rollback() error {
+	if tx.closed {
+		return bolt.ErrTxClosed
+	}
+
+	tx.close()
+	return nil
+}
+
+func (tx *memoryTx) close() {
+	tx.closed = true
+	tx.root = nil
+	if tx.writable {
+		tx.store.writeLock.Unlock()
+	}
+}
+
+// memoryBucket is a Bucket of a memoryTx. It is identified by its path from the root of the transaction,
+// because a write to the bucket or to one of its nested buckets replaces the nodes on that path.
+type memoryBucket struct {
+	tx     *memoryTx
+	parent *memoryBucket
+	name   []byte
+}
+
+// node returns the current node of the bucket, or nil if it has been deleted
+func (b *memoryBucket) node() *memoryNode {
+	if b.tx.closed {
+		return nil
+	}
+
+	if b.parent == nil {
+		return b.tx.root
+	}
+
+	p := b.parent.node()
+	if p == nil {
+		return nil
+	}
+
+	i, ok := p.search(b.name)
+	if !ok || p.items[i].bucket == nil {
+		return nil
+	}
+
+	return p.items[i].bucket
+}
+
+// writableNode returns the node of the bucket, after copying the nodes on its path which
+// were not created by this transaction
+func (b *memoryBucket) writableNode() (*memoryNode, error) {
+	if b.tx.closed {
+		return nil, bolt.ErrTxClosed
+	}
+	if !b.tx.writable {
+		return nil, bolt.ErrTxNotWritable
+	}
+
+	if b.parent == nil {
+		if b.tx.root.txID != b.tx.id {
+			b.tx.root = b.tx.root.copy(b.tx.id)
+		}
+		return b.tx.root, nil
+	}
+
+	p, err := b.parent.writableNode()
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := p.search(b.name)
+	if !ok || p.items[i].bucket == nil {
+		return nil, bolt.ErrBucketNotFound
+	}
+
+	n := p.items[i].bucket
+	if n.txID != b.tx.id {
+		n = n.copy(b.tx.id)
+		p.items[i].bucket = n
+	}
+
+	return n, nil
+}
+
+func (b *memoryBucket) Get(key []byte) []byte {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+
+	i, ok := n.search(key)
+	if !ok || n.items[i].bucket != nil {
+		return nil
+	}
+
+	return n.items[i].value
+}
+
+func (b *memoryBucket) Put(key, value []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+
+	if len(key) == 0 {
+		return bolt.ErrKeyRequired
+	} else if len(key) > bolt.MaxKeySize {
+		return bolt.ErrKeyTooLarge
+	} else if int64(len(value)) > bolt.MaxValueSize {
+		return bolt.ErrValueTooLarge
+	}
+
+	// The caller may reuse key and value after Put returns
+	it := memoryItem{
+		key:   append([]byte{}, key...),
+		value: append([]byte{}, value...),
+	}
+
+	i, ok := n.search(key)
+	if ok {
+		if n.items[i].bucket != nil {
+			return bolt.ErrIncompatibleValue
+		}
+		n.items[i] = it
+		return nil
+	}
+
+	n.insert(i, it)
+	return nil
+}
+
+func (b *memoryBucket) Delete(key []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+
+	i, ok := n.search(key)
+	if !ok {
+		return nil
+	}
+
+	if n.items[i].bucket != nil {
+		return bolt.ErrIncompatibleValue
+	}
+
+	n.remove(i)
+	return nil
+}
+
+func (b *memoryBucket) ForEach(f func(k, v []byte) error) error {
+	if b.tx.closed {
+		return bolt.ErrTxClosed
+	}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := f(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *memoryBucket) Cursor() Cursor {
+	return &memoryCursor{bucket: b}
+}
+
+func (b *memoryBucket) KeyN() int {
+	var keyN func(n *memoryNode) int
+	keyN = func(n *memoryNode) int {
+		k := len(n.items)
+		for _, it := range n.items {
+			if it.bucket != nil {
+				k += keyN(it.bucket)
+			}
+		}
+		return k
+	}
+
+	n := b.node()
+	if n == nil {
+		return 0
+	}
+
+	return keyN(n)
+}
+
+func (b *memoryBucket) Bucket(name []byte) Bucket {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+
+	i, ok := n.search(name)
+	if !ok || n.items[i].bucket == nil {
+		return nil
+	}
+
+	return &memoryBucket{
+		tx:     b.tx,
+		parent: b,
+		name:   append([]byte{}, name...),
+	}
+}
+
+func (b *memoryBucket) CreateBucket(name []byte) (Bucket, error) {
+	if len(name) == 0 {
+		return nil, bolt.ErrBucketNameRequired
+	}
+
+	n, err := b.writableNode()
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := n.search(name)
+	if ok {
+		if n.items[i].bucket != nil {
+			return nil, bolt.ErrBucketExists
+		}
+		return nil, bolt.ErrIncompatibleValue
+	}
+
+	n.insert(i, memoryItem{
+		key:    append([]byte{}, name...),
+		bucket: &memoryNode{txID: b.tx.id},
+	})
+
+	return b.Bucket(name), nil
+}
+
+func (b *memoryBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	nb, err := b.CreateBucket(name)
+	if err == bolt.ErrBucketExists {
+		return b.Bucket(name), nil
+	}
+	return nb, err
+}
+
+func (b *memoryBucket) DeleteBucket(name []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+
+	i, ok := n.search(name)
+	if !ok {
+		return bolt.ErrBucketNotFound
+	}
+
+	if n.items[i].bucket == nil {
+		return bolt.ErrIncompatibleValue
+	}
+
+	n.remove(i)
+	return nil
+}
+
+func (b *memoryBucket) Sequence() uint64 {
+	n := b.node()
+	if n == nil {
+		return 0
+	}
+	return n.seq
+}
+
+func (b *memoryBucket) SetSequence(v uint64) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+
+	n.seq = v
+	return nil
+}
+
+func (b *memoryBucket) NextSequence() (uint64, error) {
+	n, err := b.writableNode()
+	if err != nil {
+		return 0, err
+	}
+
+	n.seq++
+	return n.seq, nil
+}
+
+func (b *memoryBucket) Writable() bool {
+	return b.tx.writable
+}
+
+// memoryCursor is a Cursor of a memoryBucket. It remembers its current key rather than a position,
+// so that it stays valid when the bucket is modified.
+type memoryCursor struct {
+	bucket *memoryBucket
+	key    []byte
+}
+
+// at moves the cursor to the item i of n, or past the end of the bucket if there is no such item
+func (c *memoryCursor) at(n *memoryNode, i int) ([]byte, []byte) {
+	if n == nil || i < 0 || i >= len(n.items) {
+		c.key = nil
+		return nil, nil
+	}
+
+	c.key = n.items[i].key
+	return n.items[i].key, n.items[i].value
+}
+
+func (c *memoryCursor) First() ([]byte, []byte) {
+	return c.at(c.bucket.node(), 0)
+}
+
+func (c *memoryCursor) Last() ([]byte, []byte) {
+	n := c.bucket.node()
+	if n == nil {
+		return c.at(nil, 0)
+	}
+	return c.at(n, len(n.items)-1)
+}
+
+func (c *memoryCursor) Next() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+
+	n := c.bucket.node()
+	if n == nil {
+		return c.at(nil, 0)
+	}
+
+	i, ok := n.search(c.key)
+	if ok {
+		i++
+	}
+	return c.at(n, i)
+}
+
+func (c *memoryCursor) Prev() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+
+	n := c.bucket.node()
+	if n == nil {
+		return c.at(nil, 0)
+	}
+
+	i, _ := n.search(c.key)
+	return c.at(n, i-1)
+}
+
+func (c *memoryCursor) Seek(seek []byte) ([]byte, []byte) {
+	n := c.bucket.node()
+	if n == nil {
+		return c.at(nil, 0)
+	}
+
+	i, _ := n.search(seek)
+	return c.at(n, i)
+}
+
+func (c *memoryCursor) Delete() error {
+	if c.key == nil {
+		return nil
+	}
+
+	n := c.bucket.node()
+	if n != nil {
+		if i, ok := n.search(c.key); ok && n.items[i].bucket != nil {
+			return bolt.ErrIncompatibleValue
+		}
+	}
+
+	return c.bucket.Delete(c.key)
+}
+
+// search returns the index of key in the node, or the index where it would be inserted
+func (n *memoryNode) search(key []byte) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool {
+		return bytes.Compare(n.items[i].key, key) >= 0
+	})
+	return i, i < len(n.items) && bytes.Equal(n.items[i].key, key)
+}
+
+// insert inserts an item at index i
+func (n *memoryNode) insert(i int, it memoryItem) {
+	n.items = append(n.items, memoryItem{})
+	copy(n.items[i+1:], n.items[i:])
+	n.items[i] = it
+}
+
+// remove removes the item at index i
+func (n *memoryNode) remove(i int) {
+	copy(n.items[i:], n.items[i+1:])
+	n.items[len(n.items)-1] = memoryItem{}
+	n.items = n.items[:len(n.items)-1]
+}
+
+// copy returns a copy of the node which can be modified by the write transaction txID.
+// Keys, values and nested nodes are never modified in place, so they are shared with the original.
+func (n *memoryNode) copy(txID uint64) *memoryNode {
+	items := make([]memoryItem, len(n.items))
+	copy(items, n.items)
+	return &memoryNode{
+		txID:  txID,
+		items: items,
+		seq:   n.seq,
+	}
+}
@@ -0,0 +1,280 @@
+package dbutil
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/boltdb/bolt"
+)
+
+// Store is a transactional key-value store of nested buckets.
+// Read transactions see a consistent snapshot of the store and only one write transaction can be open at a time.
+// Errors are reported with the bolt error values (bolt.ErrBucketNotFound, bolt.ErrTxNotWritable etc.)
+// regardless of the engine.
+type Store interface {
+	// Begin starts a transaction, which must be closed with Commit or Rollback
+	Begin(writable bool) (StoreTx, error)
+	// View executes f in a read-only transaction
+	View(f func(StoreTx) error) error
+	// Update executes f in a write transaction, which is committed if f returns nil
+	Update(f func(StoreTx) error) error
+	// Path returns the path of the database file, or an empty string if the store has no file
+	Path() string
+	// IsReadOnly returns true if the store was opened read-only
+	IsReadOnly() bool
+	// Close closes the store
+	Close() error
+}
+
+// StoreTx is a transaction of a Store
+type StoreTx interface {
+	// Bucket returns the top-level bucket name, or nil if it does not exist
+	Bucket(name []byte) Bucket
+	// CreateBucket creates the top-level bucket name
+	CreateBucket(name []byte) (Bucket, error)
+	// CreateBucketIfNotExists creates the top-level bucket name if it does not exist
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	// DeleteBucket deletes the top-level bucket name and its contents
+	DeleteBucket(name []byte) error
+	// ForEach calls f for each top-level bucket, in key order
+	ForEach(f func(name []byte, b Bucket) error) error
+	// Writable returns true if the transaction can modify the store
+	Writable() bool
+	// Size returns the size of the store in bytes, as seen by the transaction
+	Size() int64
+	// WriteTo writes a copy of the database file, as seen by the transaction
+	WriteTo(w io.Writer) (int64, error)
+	// Commit writes the changes of a write transaction and closes it
+	Commit() error
+	// Rollback discards the changes of the transaction and closes it
+	Rollback() error
+}
+
+// Bucket is a sorted collection of key-value pairs and nested buckets.
+// Values returned by a Bucket are only valid inside its transaction and must not be modified.
+type Bucket interface {
+	// Get returns the value of key, or nil if key does not exist or is a nested bucket
+	Get(key []byte) []byte
+	// Put sets the value of key
+	Put(key, value []byte) error
+	// Delete removes key. Deleting a key that does not exist is not an error
+	Delete(key []byte) error
+	// ForEach calls f for each key, in key order. The value of a nested bucket is nil
+	ForEach(f func(k, v []byte) error) error
+	// Cursor returns a cursor over the keys of the bucket
+	Cursor() Cursor
+	// KeyN returns the number of keys in the bucket and its nested buckets
+	KeyN() int
+	// Bucket returns the nested bucket name, or nil if it does not exist
+	Bucket(name []byte) Bucket
+	// CreateBucket creates the nested bucket name
+	CreateBucket(name []byte) (Bucket, error)
+	// CreateBucketIfNotExists creates the nested bucket name if it does not exist
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	// DeleteBucket deletes the nested bucket name and its contents
+	DeleteBucket(name []byte) error
+	// Sequence returns the current sequence number of the bucket
+	Sequence() uint64
+	// SetSequence sets the sequence number of the bucket
+	SetSequence(v uint64) error
+	// NextSequence increments and returns the sequence number of the bucket
+	NextSequence() (uint64, error)
+	// Writable returns true if the bucket can be modified
+	Writable() bool
+}
+
+// Cursor iterates over the keys of a bucket in order.
+// A nil key is returned when the cursor moves past the first or last key.
+type Cursor interface {
+	// First moves to the first key
+	First() (key, value []byte)
+	// Last moves to the last key
+	Last() (key, value []byte)
+	// Next moves to the next key
+	Next() (key, value []byte)
+	// Prev moves to the previous key
+	Prev() (key, value []byte)
+	// Seek moves to seek, or to the key after it if seek does not exist
+	Seek(seek []byte) (key, value []byte)
+	// Delete removes the current key
+	Delete() error
+}
+
+// boltStore is a Store backed by a *bolt.DB
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore returns a Store backed by a *bolt.DB
+func NewBoltStore(db *bolt.DB) Store {
+	return boltStore{db}
+}
+
+func (s boltStore) Begin(writable bool) (StoreTx, error) {
+	tx, err := s.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+	return boltTx{tx}, nil
+}
+
+func (s boltStore) View(f func(StoreTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return f(boltTx{tx})
+	})
+}
+
+func (s boltStore) Update(f func(StoreTx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return f(boltTx{tx})
+	})
+}
+
+func (s boltStore) Path() string {
+	return s.db.Path()
+}
+
+func (s boltStore) IsReadOnly() bool {
+	return s.db.IsReadOnly()
+}
+
+func (s boltStore) Close() error {
+	return s.db.Close()
+}
+
+// boltTx is a StoreTx backed by a *bolt.Tx
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// String returns the bolt transaction's representation, see Tx.String
+func (tx boltTx) String() string {
+	return fmt.Sprintf("%v", tx.tx)
+}
+
+func (tx boltTx) Bucket(name []byte) Bucket {
+	return wrapBoltBucket(tx.tx.Bucket(name))
+}
+
+func (tx boltTx) CreateBucket(name []byte) (Bucket, error) {
+	b, err := tx.tx.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapBoltBucket(b), nil
+}
+
+func (tx boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapBoltBucket(b), nil
+}
+
+func (tx boltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+func (tx boltTx) ForEach(f func(name []byte, b Bucket) error) error {
+	return tx.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		return f(name, wrapBoltBucket(b))
+	})
+}
+
+func (tx boltTx) Writable() bool {
+	return tx.tx.Writable()
+}
+
+func (tx boltTx) Size() int64 {
+	return tx.tx.Size()
+}
+
+func (tx boltTx) WriteTo(w io.Writer) (int64, error) {
+	return tx.tx.WriteTo(w)
+}
+
+func (tx boltTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx boltTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// boltBucket is a Bucket backed by a *bolt.Bucket
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+// wrapBoltBucket wraps a *bolt.Bucket, a nil *bolt.Bucket is returned as a nil Bucket
+func wrapBoltBucket(b *bolt.Bucket) Bucket {
+	if b == nil {
+		return nil
+	}
+	return boltBucket{b}
+}
+
+func (b boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b boltBucket) Put(key, value []byte) error {
+	return b.b.Put(key, value)
+}
+
+func (b boltBucket) Delete(key []byte) error {
+	return b.b.Delete(key)
+}
+
+func (b boltBucket) ForEach(f func(k, v []byte) error) error {
+	return b.b.ForEach(f)
+}
+
+func (b boltBucket) Cursor() Cursor {
+	return b.b.Cursor()
+}
+
+func (b boltBucket) KeyN() int {
+	return b.b.Stats().KeyN
+}
+
+func (b boltBucket) Bucket(name []byte) Bucket {
+	return wrapBoltBucket(b.b.Bucket(name))
+}
+
+func (b boltBucket) CreateBucket(name []byte) (Bucket, error) {
+	nb, err := b.b.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapBoltBucket(nb), nil
+}
+
+func (b boltBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	nb, err := b.b.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapBoltBucket(nb), nil
+}
+
+func (b boltBucket) DeleteBucket(name []byte) error {
+	return b.b.DeleteBucket(name)
+}
+
+func (b boltBucket) Sequence() uint64 {
+	return b.b.Sequence()
+}
+
+func (b boltBucket) SetSequence(v uint64) error {
+	return b.b.SetSequence(v)
+}
+
+func (b boltBucket) NextSequence() (uint64, error) {
+	return b.b.NextSequence()
+}
+
+func (b boltBucket) Writable() bool {
+	return b.b.Writable()
+}
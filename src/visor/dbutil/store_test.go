@@ -0,0 +1,435 @@
+package dbutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testBkt       = []byte("test")
+	testNestedBkt = []byte("nested")
+)
+
+// testEngines returns constructors for a new, empty *DB of each engine
+func testEngines(t *testing.T) map[string]func() (*DB, func()) {
+	return map[string]func() (*DB, func()){
+		"bolt": func() (*DB, func()) {
+			f, err := ioutil.TempFile("", "testdb")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			db, err := bolt.Open(f.Name(), 0600, nil)
+			require.NoError(t, err)
+
+			return WrapDB(db), func() {
+				require.NoError(t, db.Close())
+				require.NoError(t, os.Remove(f.Name()))
+			}
+		},
+		"memory": func() (*DB, func()) {
+			db := WrapMemoryDB()
+			return db, func() {
+				require.NoError(t, db.Close())
+			}
+		},
+	}
+}
+
+// forEachEngine runs f on a new database of each engine, with the bucket testBkt created
+func forEachEngine(t *testing.T, f func(t *testing.T, db *DB)) {
+	for name, newDB := range testEngines(t) {
+		t.Run(name, func(t *testing.T) {
+			db, shutdown := newDB()
+			defer shutdown()
+
+			err := db.Update("", func(tx *Tx) error {
+				return CreateBuckets(tx, [][]byte{testBkt})
+			})
+			require.NoError(t, err)
+
+			f(t, db)
+		})
+	}
+}
+
+func TestStoreBuckets(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		err := db.Update("", func(tx *Tx) error {
+			require.True(t, Exists(tx, testBkt))
+			require.False(t, Exists(tx, testNestedBkt))
+
+			_, err := tx.CreateBucket(testBkt)
+			require.Equal(t, bolt.ErrBucketExists, err)
+			_, err = tx.CreateBucket(nil)
+			require.Equal(t, bolt.ErrBucketNameRequired, err)
+			require.Equal(t, bolt.ErrBucketNotFound, tx.DeleteBucket(testNestedBkt))
+
+			b := tx.Bucket(testBkt)
+			require.NoError(t, b.Put([]byte("a"), []byte("1")))
+
+			// Nested buckets and keys share the key space
+			nb, err := b.CreateBucket(testNestedBkt)
+			require.NoError(t, err)
+			require.NoError(t, nb.Put([]byte("b"), []byte("2")))
+			require.NoError(t, nb.Put([]byte("c"), []byte("3")))
+
+			require.Nil(t, b.Get(testNestedBkt))
+			require.Equal(t, bolt.ErrIncompatibleValue, b.Put(testNestedBkt, []byte("x")))
+			require.Equal(t, bolt.ErrIncompatibleValue, b.Delete(testNestedBkt))
+			_, err = b.CreateBucket([]byte("a"))
+			require.Equal(t, bolt.ErrIncompatibleValue, err)
+
+			nb, err = b.CreateBucketIfNotExists(testNestedBkt)
+			require.NoError(t, err)
+			require.Equal(t, []byte("2"), nb.Get([]byte("b")))
+
+			var names []string
+			require.NoError(t, tx.ForEach(func(name []byte, b Bucket) error {
+				require.NotNil(t, b)
+				names = append(names, string(name))
+				return nil
+			}))
+			require.Equal(t, []string{string(testBkt)}, names)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = db.Update("", func(tx *Tx) error {
+			// The keys of nested buckets are counted
+			n, err := Len(tx, testBkt)
+			require.NoError(t, err)
+			require.Equal(t, uint64(4), n)
+
+			b := tx.Bucket(testBkt)
+			require.Equal(t, []byte("3"), b.Bucket(testNestedBkt).Get([]byte("c")))
+			require.NoError(t, b.DeleteBucket(testNestedBkt))
+			require.Nil(t, b.Bucket(testNestedBkt))
+
+			require.NoError(t, Reset(tx, testBkt))
+			empty, err := IsEmpty(tx, testBkt)
+			require.NoError(t, err)
+			require.True(t, empty)
+
+			require.NoError(t, tx.DeleteBucket(testBkt))
+			require.False(t, Exists(tx, testBkt))
+
+			_, err = GetBucketValue(tx, testBkt, []byte("a"))
+			require.Equal(t, NewErrBucketNotExist(testBkt), err)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestStoreKeys(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		keys := []string{"d", "b", "e", "a", "c"}
+
+		err := db.Update("", func(tx *Tx) error {
+			for _, k := range keys {
+				require.NoError(t, PutBucketValue(tx, testBkt, []byte(k), []byte("v"+k)))
+			}
+
+			require.Equal(t, bolt.ErrKeyRequired, PutBucketValue(tx, testBkt, nil, []byte("v")))
+
+			// A value can be empty
+			require.NoError(t, PutBucketValue(tx, testBkt, []byte("f"), nil))
+			v, err := GetBucketValueNoCopy(tx, testBkt, []byte("f"))
+			require.NoError(t, err)
+			require.Empty(t, v)
+			require.NoError(t, Delete(tx, testBkt, []byte("f")))
+
+			// Deleting a key which does not exist is not an error
+			require.NoError(t, Delete(tx, testBkt, []byte("x")))
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = db.View("", func(tx *Tx) error {
+			var visited []string
+			require.NoError(t, ForEach(tx, testBkt, func(k, v []byte) error {
+				require.Equal(t, "v"+string(k), string(v))
+				visited = append(visited, string(k))
+				return nil
+			}))
+			require.Equal(t, []string{"a", "b", "c", "d", "e"}, visited)
+
+			v, err := GetBucketValue(tx, testBkt, []byte("c"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("vc"), v)
+
+			v, err = GetBucketValue(tx, testBkt, []byte("x"))
+			require.NoError(t, err)
+			require.Nil(t, v)
+
+			s, ok, err := GetBucketString(tx, testBkt, []byte("e"))
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "ve", s)
+
+			n, err := Len(tx, testBkt)
+			require.NoError(t, err)
+			require.Equal(t, uint64(len(keys)), n)
+
+			// A read transaction can't write
+			require.Equal(t, bolt.ErrTxNotWritable, PutBucketValue(tx, testBkt, []byte("x"), []byte("vx")))
+			require.Equal(t, bolt.ErrTxNotWritable, Delete(tx, testBkt, []byte("a")))
+			_, err = NextSequence(tx, testBkt)
+			require.Equal(t, bolt.ErrTxNotWritable, err)
+			_, err = tx.CreateBucketIfNotExists(testNestedBkt)
+			require.Equal(t, bolt.ErrTxNotWritable, err)
+			require.False(t, tx.Writable())
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestStoreCursor(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		err := db.Update("", func(tx *Tx) error {
+			b := tx.Bucket(testBkt)
+			for _, k := range []string{"b", "d", "f", "h"} {
+				require.NoError(t, b.Put([]byte(k), []byte("v"+k)))
+			}
+
+			c := b.Cursor()
+			k, v := c.First()
+			require.Equal(t, "b", string(k))
+			require.Equal(t, "vb", string(v))
+			k, _ = c.Next()
+			require.Equal(t, "d", string(k))
+			k, _ = c.Prev()
+			require.Equal(t, "b", string(k))
+			k, _ = c.Prev()
+			require.Nil(t, k)
+
+			k, _ = c.Last()
+			require.Equal(t, "h", string(k))
+			k, _ = c.Next()
+			require.Nil(t, k)
+
+			k, _ = c.Seek([]byte("d"))
+			require.Equal(t, "d", string(k))
+			k, _ = c.Seek([]byte("e"))
+			require.Equal(t, "f", string(k))
+			k, _ = c.Seek([]byte("i"))
+			require.Nil(t, k)
+
+			// Delete every other key while iterating
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if string(k) == "d" || string(k) == "h" {
+					require.NoError(t, c.Delete())
+				}
+			}
+
+			var keys []string
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				keys = append(keys, string(k))
+			}
+			require.Equal(t, []string{"b", "f"}, keys)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestStoreSequence(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		err := db.Update("", func(tx *Tx) error {
+			for i := uint64(1); i <= 3; i++ {
+				n, err := NextSequence(tx, testBkt)
+				require.NoError(t, err)
+				require.Equal(t, i, n)
+			}
+
+			b := tx.Bucket(testBkt)
+			require.NoError(t, b.SetSequence(10))
+			require.Equal(t, uint64(10), b.Sequence())
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = db.View("", func(tx *Tx) error {
+			require.Equal(t, uint64(10), tx.Bucket(testBkt).Sequence())
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestStoreTransactions(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		key := []byte("k")
+
+		err := db.Update("", func(tx *Tx) error {
+			return PutBucketValue(tx, testBkt, key, []byte("1"))
+		})
+		require.NoError(t, err)
+
+		// A failed update is rolled back
+		errFail := fmt.Errorf("fail")
+		err = db.Update("", func(tx *Tx) error {
+			require.NoError(t, PutBucketValue(tx, testBkt, key, []byte("2")))
+			require.NoError(t, Delete(tx, testBkt, key))
+			_, err := tx.CreateBucket(testNestedBkt)
+			require.NoError(t, err)
+			return errFail
+		})
+		require.Equal(t, errFail, err)
+
+		// A read transaction sees the data as it was when it began
+		rtx, err := db.Begin(false)
+		require.NoError(t, err)
+
+		err = db.Update("", func(tx *Tx) error {
+			v, err := GetBucketValue(tx, testBkt, key)
+			require.NoError(t, err)
+			require.Equal(t, []byte("1"), v)
+			require.False(t, Exists(tx, testNestedBkt))
+
+			return PutBucketValue(tx, testBkt, key, []byte("3"))
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, []byte("1"), rtx.Bucket(testBkt).Get(key))
+		require.NoError(t, rtx.Rollback())
+		require.Equal(t, bolt.ErrTxClosed, rtx.Rollback())
+
+		err = db.View("", func(tx *Tx) error {
+			v, err := GetBucketValue(tx, testBkt, key)
+			require.NoError(t, err)
+			require.Equal(t, []byte("3"), v)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestTxOnCommit(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		var called []int
+
+		err := db.Update("", func(tx *Tx) error {
+			tx.OnCommit(func() {
+				called = append(called, 1)
+			})
+			tx.OnCommit(func() {
+				called = append(called, 2)
+			})
+			require.Empty(t, called)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2}, called)
+
+		// The functions are not called if the update is rolled back
+		called = nil
+		errFail := fmt.Errorf("fail")
+		err = db.Update("", func(tx *Tx) error {
+			tx.OnCommit(func() {
+				called = append(called, 1)
+			})
+			return errFail
+		})
+		require.Equal(t, errFail, err)
+		require.Empty(t, called)
+	})
+}
+
+func TestStoreObjects(t *testing.T) {
+	type object struct {
+		A uint64
+		B string
+	}
+
+	forEachEngine(t, func(t *testing.T, db *DB) {
+		obj := object{A: 7, B: "seven"}
+
+		err := db.Update("", func(tx *Tx) error {
+			require.NoError(t, PutBucketValue(tx, testBkt, []byte("json"), []byte(`{"A":7,"B":"seven"}`)))
+			return PutBucketValue(tx, testBkt, []byte("encoded"), []byte{7, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 's', 'e', 'v', 'e', 'n'})
+		})
+		require.NoError(t, err)
+
+		err = db.View("", func(tx *Tx) error {
+			var jsonObj, encodedObj object
+			ok, err := GetBucketObjectJSON(tx, testBkt, []byte("json"), &jsonObj)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, obj, jsonObj)
+
+			ok, err = GetBucketObjectDecoded(tx, testBkt, []byte("encoded"), &encodedObj)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, obj, encodedObj)
+
+			ok, err = GetBucketObjectDecoded(tx, testBkt, []byte("missing"), &encodedObj)
+			require.NoError(t, err)
+			require.False(t, ok)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestCompactEngines(t *testing.T) {
+	engines := testEngines(t)
+
+	// Copy a bolt database into a memory database and back
+	src, shutdown := engines["bolt"]()
+	defer shutdown()
+
+	err := src.Update("", func(tx *Tx) error {
+		b, err := tx.CreateBucket(testBkt)
+		require.NoError(t, err)
+		require.NoError(t, b.SetSequence(5))
+		for i := 0; i < 100; i++ {
+			require.NoError(t, b.Put(Itob(uint64(i)), []byte(fmt.Sprint(i))))
+		}
+
+		nb, err := b.CreateBucket(testNestedBkt)
+		require.NoError(t, err)
+		return nb.Put([]byte("a"), []byte("b"))
+	})
+	require.NoError(t, err)
+
+	mem, shutdown := engines["memory"]()
+	defer shutdown()
+
+	stats, err := Compact(mem, src, 256)
+	require.NoError(t, err)
+	require.Equal(t, CompactStats{Buckets: 2, Keys: 101}, *stats)
+
+	dst, shutdown := engines["bolt"]()
+	defer shutdown()
+
+	stats, err = Compact(dst, mem, 0)
+	require.NoError(t, err)
+	require.Equal(t, CompactStats{Buckets: 2, Keys: 101}, *stats)
+
+	err = dst.View("", func(tx *Tx) error {
+		b := tx.Bucket(testBkt)
+		require.Equal(t, uint64(5), b.Sequence())
+		require.Equal(t, []byte("42"), b.Get(Itob(42)))
+		require.Equal(t, []byte("b"), b.Bucket(testNestedBkt).Get([]byte("a")))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The memory engine has no database file
+	err = mem.View("", func(tx *Tx) error {
+		require.NotZero(t, tx.Size())
+		_, err := tx.WriteTo(ioutil.Discard)
+		require.Equal(t, ErrMemoryStoreNoFile, err)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, mem.Path())
+}
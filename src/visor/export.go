@@ -0,0 +1,483 @@
+package visor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// ExportTable is a table of blockchain data that can be exported
+type ExportTable string
+
+const (
+	// ExportTableBlocks exports a row for each block
+	ExportTableBlocks ExportTable = "blocks"
+	// ExportTableTransactions exports a row for each transaction
+	ExportTableTransactions ExportTable = "transactions"
+	// ExportTableInputs exports a row for each transaction input, with the output it spends.
+	// The spent outputs are read from the historydb.
+	ExportTableInputs ExportTable = "inputs"
+	// ExportTableOutputs exports a row for each transaction output
+	ExportTableOutputs ExportTable = "outputs"
+)
+
+// ExportTables are the tables that can be exported
+var ExportTables = []ExportTable{
+	ExportTableBlocks,
+	ExportTableTransactions,
+	ExportTableInputs,
+	ExportTableOutputs,
+}
+
+// ExportFormat is the file format of exported blockchain data
+type ExportFormat string
+
+const (
+	// ExportFormatJSONL writes a JSON object on each line
+	ExportFormatJSONL ExportFormat = "jsonl"
+	// ExportFormatCSV writes a CSV file with a header row
+	ExportFormatCSV ExportFormat = "csv"
+)
+
+var (
+	// ErrExportTableInvalid is returned if an unknown table is exported
+	ErrExportTableInvalid = errors.New(`invalid export table, must be "blocks", "transactions", "inputs" or "outputs"`)
+	// ErrExportFormatInvalid is returned if an unknown format is exported
+	ErrExportFormatInvalid = errors.New(`invalid export format, must be "jsonl" or "csv"`)
+)
+
+// ParseExportTable parses the name of an export table
+func ParseExportTable(s string) (ExportTable, error) {
+	for _, t := range ExportTables {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", ErrExportTableInvalid
+}
+
+// ParseExportFormat parses the name of an export format
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(s); f {
+	case ExportFormatJSONL, ExportFormatCSV:
+		return f, nil
+	default:
+		return "", ErrExportFormatInvalid
+	}
+}
+
+// ExportParams selects the table, format and blocks of a blockchain export
+type ExportParams struct {
+	Table  ExportTable
+	Format ExportFormat
+	// StartSeq and EndSeq are the first and last blocks exported, inclusive.
+	// EndSeq is lowered to the head block.
+	StartSeq uint64
+	EndSeq   uint64
+}
+
+// ExportBlock is a row of the blocks table
+type ExportBlock struct {
+	Seq          uint64 `json:"seq"`
+	Hash         string `json:"hash"`
+	ParentHash   string `json:"parent_hash"`
+	Time         uint64 `json:"time"`
+	Version      uint32 `json:"version"`
+	Fee          uint64 `json:"fee"`
+	BodyHash     string `json:"body_hash"`
+	UxHash       string `json:"ux_hash"`
+	Transactions int    `json:"transactions"`
+}
+
+var exportBlockHeader = []string{"seq", "hash", "parent_hash", "time", "version", "fee", "body_hash", "ux_hash", "transactions"}
+
+func (b ExportBlock) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(b.Seq, 10),
+		b.Hash,
+		b.ParentHash,
+		strconv.FormatUint(b.Time, 10),
+		strconv.FormatUint(uint64(b.Version), 10),
+		strconv.FormatUint(b.Fee, 10),
+		b.BodyHash,
+		b.UxHash,
+		strconv.Itoa(b.Transactions),
+	}
+}
+
+// ExportTransaction is a row of the transactions table
+type ExportTransaction struct {
+	BlockSeq  uint64 `json:"block_seq"`
+	BlockTime uint64 `json:"block_time"`
+	Index     int    `json:"index"`
+	TxID      string `json:"txid"`
+	InnerHash string `json:"inner_hash"`
+	Type      uint8  `json:"type"`
+	Length    uint32 `json:"length"`
+	Inputs    int    `json:"inputs"`
+	Outputs   int    `json:"outputs"`
+}
+
+var exportTransactionHeader = []string{"block_seq", "block_time", "index", "txid", "inner_hash", "type", "length", "inputs", "outputs"}
+
+func (t ExportTransaction) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(t.BlockSeq, 10),
+		strconv.FormatUint(t.BlockTime, 10),
+		strconv.Itoa(t.Index),
+		t.TxID,
+		t.InnerHash,
+		strconv.FormatUint(uint64(t.Type), 10),
+		strconv.FormatUint(uint64(t.Length), 10),
+		strconv.Itoa(t.Inputs),
+		strconv.Itoa(t.Outputs),
+	}
+}
+
+// ExportInput is a row of the inputs table. Coins are in droplets.
+type ExportInput struct {
+	BlockSeq  uint64 `json:"block_seq"`
+	BlockTime uint64 `json:"block_time"`
+	TxID      string `json:"txid"`
+	Index     int    `json:"index"`
+	UxID      string `json:"uxid"`
+	SrcTxID   string `json:"src_txid"`
+	SrcSeq    uint64 `json:"src_block_seq"`
+	Address   string `json:"address"`
+	Coins     uint64 `json:"coins"`
+	Hours     uint64 `json:"hours"`
+}
+
+var exportInputHeader = []string{"block_seq", "block_time", "txid", "index", "uxid", "src_txid", "src_block_seq", "address", "coins", "hours"}
+
+func (in ExportInput) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(in.BlockSeq, 10),
+		strconv.FormatUint(in.BlockTime, 10),
+		in.TxID,
+		strconv.Itoa(in.Index),
+		in.UxID,
+		in.SrcTxID,
+		strconv.FormatUint(in.SrcSeq, 10),
+		in.Address,
+		strconv.FormatUint(in.Coins, 10),
+		strconv.FormatUint(in.Hours, 10),
+	}
+}
+
+// ExportOutput is a row of the outputs table. Coins are in droplets.
+type ExportOutput struct {
+	BlockSeq  uint64 `json:"block_seq"`
+	BlockTime uint64 `json:"block_time"`
+	TxID      string `json:"txid"`
+	Index     int    `json:"index"`
+	UxID      string `json:"uxid"`
+	Address   string `json:"address"`
+	Coins     uint64 `json:"coins"`
+	Hours     uint64 `json:"hours"`
+}
+
+var exportOutputHeader = []string{"block_seq", "block_time", "txid", "index", "uxid", "address", "coins", "hours"}
+
+func (o ExportOutput) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(o.BlockSeq, 10),
+		strconv.FormatUint(o.BlockTime, 10),
+		o.TxID,
+		strconv.Itoa(o.Index),
+		o.UxID,
+		o.Address,
+		strconv.FormatUint(o.Coins, 10),
+		strconv.FormatUint(o.Hours, 10),
+	}
+}
+
+// ExportHeader returns the CSV header row of a table
+func ExportHeader(t ExportTable) ([]string, error) {
+	switch t {
+	case ExportTableBlocks:
+		return exportBlockHeader, nil
+	case ExportTableTransactions:
+		return exportTransactionHeader, nil
+	case ExportTableInputs:
+		return exportInputHeader, nil
+	case ExportTableOutputs:
+		return exportOutputHeader, nil
+	default:
+		return nil, ErrExportTableInvalid
+	}
+}
+
+type exportRow interface {
+	csvRecord() []string
+}
+
+// exportWriter writes the rows of a table in the export format
+type exportWriter struct {
+	format ExportFormat
+	json   *json.Encoder
+	csv    *csv.Writer
+	rows   uint64
+}
+
+func newExportWriter(w io.Writer, p ExportParams) (*exportWriter, error) {
+	header, err := ExportHeader(p.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	ew := &exportWriter{
+		format: p.Format,
+	}
+
+	switch p.Format {
+	case ExportFormatJSONL:
+		ew.json = json.NewEncoder(w)
+	case ExportFormatCSV:
+		ew.csv = csv.NewWriter(w)
+		if err := ew.csv.Write(header); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrExportFormatInvalid
+	}
+
+	return ew, nil
+}
+
+func (ew *exportWriter) write(r exportRow) error {
+	var err error
+	if ew.format == ExportFormatJSONL {
+		err = ew.json.Encode(r)
+	} else {
+		err = ew.csv.Write(r.csvRecord())
+	}
+	if err != nil {
+		return err
+	}
+
+	ew.rows++
+	return nil
+}
+
+// flush writes the buffered CSV rows, so that the export is streamed block by block
+func (ew *exportWriter) flush() error {
+	if ew.csv == nil {
+		return nil
+	}
+	ew.csv.Flush()
+	return ew.csv.Error()
+}
+
+// ExportChain writes the rows of a table for a range of blocks to w.
+// It reads the database directly, so it can be used on the database of a stopped node.
+// Returns the number of rows written.
+func ExportChain(db *dbutil.DB, pubkey cipher.PubKey, p ExportParams, w io.Writer) (uint64, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return 0, err
+	}
+
+	var rows uint64
+	if err := db.View("ExportChain", func(tx *dbutil.Tx) error {
+		var err error
+		rows, err = exportChain(tx, bc, historydb.New(), p, w, nil)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// ExportChain writes the rows of a table for a range of blocks to w while the node keeps running.
+// The blocks are read inside a read transaction, so blocks can still be executed while it is written.
+// If begin is not nil, it is called with the resolved block range before any data is written.
+// Returns the number of rows written.
+func (vs *Visor) ExportChain(p ExportParams, w io.Writer, begin func(ExportParams)) (uint64, error) {
+	var rows uint64
+	if err := vs.db.View("ExportChain", func(tx *dbutil.Tx) error {
+		var err error
+		rows, err = exportChain(tx, vs.blockchain, vs.history, p, w, begin)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+func exportChain(tx *dbutil.Tx, bc Blockchainer, history Historyer, p ExportParams, w io.Writer, begin func(ExportParams)) (uint64, error) {
+	if _, err := ExportHeader(p.Table); err != nil {
+		return 0, err
+	}
+	if _, err := ParseExportFormat(string(p.Format)); err != nil {
+		return 0, err
+	}
+
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return 0, err
+	} else if !ok {
+		return 0, errors.New("the blockchain is empty")
+	}
+
+	if p.EndSeq > headSeq {
+		p.EndSeq = headSeq
+	}
+
+	if p.StartSeq <= p.EndSeq {
+		// The bodies of pruned blocks are not available. The genesis block is never pruned.
+		prunedSeq, ok, err := bc.PrunedSeq(tx)
+		if err != nil {
+			return 0, err
+		}
+		if ok && p.EndSeq > 0 && p.StartSeq <= prunedSeq {
+			return 0, fmt.Errorf("the bodies of blocks 1 to %d have been pruned", prunedSeq)
+		}
+
+		if p.Table == ExportTableInputs {
+			if err := checkExportHistory(tx, history, p.EndSeq); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	ew, err := newExportWriter(w, p)
+	if err != nil {
+		return 0, err
+	}
+
+	if begin != nil {
+		begin(p)
+	}
+
+	for seq := p.StartSeq; seq <= p.EndSeq; seq++ {
+		b, err := bc.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return ew.rows, err
+		} else if b == nil {
+			return ew.rows, NewErrBlockNotExist(seq)
+		}
+
+		if err := exportBlock(tx, history, p.Table, &b.Block, ew); err != nil {
+			return ew.rows, err
+		}
+
+		if err := ew.flush(); err != nil {
+			return ew.rows, err
+		}
+	}
+
+	return ew.rows, ew.flush()
+}
+
+// checkExportHistory checks that the historydb has the outputs spent by the blocks up to seq
+func checkExportHistory(tx *dbutil.Tx, history Historyer, seq uint64) error {
+	// A disabled or rebuilding historydb returns its error for any lookup
+	if _, err := history.GetUxOuts(tx, nil); err != nil {
+		return err
+	}
+
+	parsedSeq, ok, err := history.ParsedBlockSeq(tx)
+	if err != nil {
+		return fmt.Errorf("exporting inputs requires the historydb: %v", err)
+	}
+	if !ok || parsedSeq < seq {
+		return fmt.Errorf("exporting inputs requires the historydb, which has not indexed block %d", seq)
+	}
+
+	return nil
+}
+
+// exportBlock writes the rows of a table for block b
+func exportBlock(tx *dbutil.Tx, history Historyer, table ExportTable, b *coin.Block, ew *exportWriter) error {
+	if table == ExportTableBlocks {
+		return ew.write(ExportBlock{
+			Seq:          b.Head.BkSeq,
+			Hash:         b.HashHeader().Hex(),
+			ParentHash:   b.Head.PrevHash.Hex(),
+			Time:         b.Head.Time,
+			Version:      b.Head.Version,
+			Fee:          b.Head.Fee,
+			BodyHash:     b.Head.BodyHash.Hex(),
+			UxHash:       b.Head.UxHash.Hex(),
+			Transactions: len(b.Body.Transactions),
+		})
+	}
+
+	for i, txn := range b.Body.Transactions {
+		txid := txn.Hash()
+
+		switch table {
+		case ExportTableTransactions:
+			if err := ew.write(ExportTransaction{
+				BlockSeq:  b.Head.BkSeq,
+				BlockTime: b.Head.Time,
+				Index:     i,
+				TxID:      txid.Hex(),
+				InnerHash: txn.InnerHash.Hex(),
+				Type:      txn.Type,
+				Length:    txn.Length,
+				Inputs:    len(txn.In),
+				Outputs:   len(txn.Out),
+			}); err != nil {
+				return err
+			}
+
+		case ExportTableInputs:
+			// The genesis transaction has no inputs
+			if len(txn.In) == 0 {
+				continue
+			}
+
+			uxs, err := history.GetUxOuts(tx, txn.In)
+			if err != nil {
+				return err
+			}
+
+			for j, ux := range uxs {
+				if err := ew.write(ExportInput{
+					BlockSeq:  b.Head.BkSeq,
+					BlockTime: b.Head.Time,
+					TxID:      txid.Hex(),
+					Index:     j,
+					UxID:      txn.In[j].Hex(),
+					SrcTxID:   ux.Out.Body.SrcTransaction.Hex(),
+					SrcSeq:    ux.Out.Head.BkSeq,
+					Address:   ux.Out.Body.Address.String(),
+					Coins:     ux.Out.Body.Coins,
+					Hours:     ux.Out.Body.Hours,
+				}); err != nil {
+					return err
+				}
+			}
+
+		case ExportTableOutputs:
+			for j, ux := range coin.CreateUnspents(b.Head, txn) {
+				if err := ew.write(ExportOutput{
+					BlockSeq:  b.Head.BkSeq,
+					BlockTime: b.Head.Time,
+					TxID:      txid.Hex(),
+					Index:     j,
+					UxID:      ux.Hash().Hex(),
+					Address:   ux.Body.Address.String(),
+					Coins:     ux.Body.Coins,
+					Hours:     ux.Body.Hours,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package visor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestExportChain(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	toAddr := testutil.MakeAddress()
+	// The change output keeps coin hours, so that it can be spent by the next block
+	var txn1 coin.Transaction
+	err := txn1.PushInput(genUxs[0].Hash())
+	require.NoError(t, err)
+	err = txn1.PushOutput(toAddr, 10e6, genUxs[0].Body.Hours/4)
+	require.NoError(t, err)
+	err = txn1.PushOutput(genAddress, genUxs[0].Body.Coins-10e6, genUxs[0].Body.Hours/4)
+	require.NoError(t, err)
+	txn1.SignInputs([]cipher.SecKey{genSecret})
+	err = txn1.UpdateHeader()
+	require.NoError(t, err)
+	b1 := c.addBlock(txn1)
+
+	uxs1 := coin.CreateUnspents(b1.Head, txn1)
+	txn2 := makeSpendTxWithFee(t, coin.UxArray{uxs1[1]}, []cipher.SecKey{genSecret}, toAddr, 20e6, 1)
+	b2 := c.addBlock(txn2)
+	uxs2 := coin.CreateUnspents(b2.Head, txn2)
+
+	blocks := []coin.SignedBlock{gb, b1, b2}
+
+	expectBlocks := make([]ExportBlock, len(blocks))
+	for i, b := range blocks {
+		expectBlocks[i] = ExportBlock{
+			Seq:          b.Seq(),
+			Hash:         b.HashHeader().Hex(),
+			ParentHash:   b.Head.PrevHash.Hex(),
+			Time:         b.Time(),
+			Version:      b.Head.Version,
+			Fee:          b.Head.Fee,
+			BodyHash:     b.Head.BodyHash.Hex(),
+			UxHash:       b.Head.UxHash.Hex(),
+			Transactions: 1,
+		}
+	}
+
+	expectInputs := []ExportInput{
+		{
+			BlockSeq:  1,
+			BlockTime: b1.Time(),
+			TxID:      txn1.Hash().Hex(),
+			Index:     0,
+			UxID:      genUxs[0].Hash().Hex(),
+			SrcTxID:   genUxs[0].Body.SrcTransaction.Hex(),
+			SrcSeq:    0,
+			Address:   genAddress.String(),
+			Coins:     genUxs[0].Body.Coins,
+			Hours:     genUxs[0].Body.Hours,
+		},
+		{
+			BlockSeq:  2,
+			BlockTime: b2.Time(),
+			TxID:      txn2.Hash().Hex(),
+			Index:     0,
+			UxID:      uxs1[1].Hash().Hex(),
+			SrcTxID:   txn1.Hash().Hex(),
+			SrcSeq:    1,
+			Address:   genAddress.String(),
+			Coins:     uxs1[1].Body.Coins,
+			Hours:     uxs1[1].Body.Hours,
+		},
+	}
+
+	var expectOutputs [][]string
+	for i, uxs := range []coin.UxArray{genUxs, uxs1, uxs2} {
+		for j, ux := range uxs {
+			expectOutputs = append(expectOutputs, ExportOutput{
+				BlockSeq:  blocks[i].Seq(),
+				BlockTime: blocks[i].Time(),
+				TxID:      blocks[i].Body.Transactions[0].Hash().Hex(),
+				Index:     j,
+				UxID:      ux.Hash().Hex(),
+				Address:   ux.Body.Address.String(),
+				Coins:     ux.Body.Coins,
+				Hours:     ux.Body.Hours,
+			}.csvRecord())
+		}
+	}
+
+	decodeJSONL := func(t *testing.T, buf *bytes.Buffer, v func() interface{}) []interface{} {
+		var rows []interface{}
+		d := json.NewDecoder(buf)
+		for d.More() {
+			r := v()
+			require.NoError(t, d.Decode(r))
+			rows = append(rows, r)
+		}
+		return rows
+	}
+
+	t.Run("blocks jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		var begun *ExportParams
+		rows, err := c.v.ExportChain(ExportParams{
+			Table:    ExportTableBlocks,
+			Format:   ExportFormatJSONL,
+			StartSeq: 1,
+			EndSeq:   math.MaxUint64,
+		}, &buf, func(p ExportParams) {
+			begun = &p
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), rows)
+
+		// The end of the range is lowered to the head block
+		require.NotNil(t, begun)
+		require.Equal(t, uint64(2), begun.EndSeq)
+
+		require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+		got := decodeJSONL(t, &buf, func() interface{} { return &ExportBlock{} })
+		require.Equal(t, []interface{}{&expectBlocks[1], &expectBlocks[2]}, got)
+	})
+
+	t.Run("outputs csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := c.v.ExportChain(ExportParams{
+			Table:  ExportTableOutputs,
+			Format: ExportFormatCSV,
+			EndSeq: 2,
+		}, &buf, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(expectOutputs)), rows)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, exportOutputHeader, records[0])
+		require.Equal(t, expectOutputs, records[1:])
+	})
+
+	t.Run("inputs jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := c.v.ExportChain(ExportParams{
+			Table:  ExportTableInputs,
+			Format: ExportFormatJSONL,
+			EndSeq: 2,
+		}, &buf, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), rows)
+
+		got := decodeJSONL(t, &buf, func() interface{} { return &ExportInput{} })
+		require.Equal(t, []interface{}{&expectInputs[0], &expectInputs[1]}, got)
+	})
+
+	t.Run("empty range csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := c.v.ExportChain(ExportParams{
+			Table:    ExportTableTransactions,
+			Format:   ExportFormatCSV,
+			StartSeq: 3,
+			EndSeq:   10,
+		}, &buf, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), rows)
+		require.Equal(t, strings.Join(exportTransactionHeader, ",")+"\n", buf.String())
+	})
+
+	t.Run("stopped node db", func(t *testing.T) {
+		p := ExportParams{
+			Table:  ExportTableTransactions,
+			Format: ExportFormatCSV,
+			EndSeq: 2,
+		}
+
+		var expect bytes.Buffer
+		_, err := c.v.ExportChain(p, &expect, nil)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		rows, err := ExportChain(c.v.db, genPublic, p, &buf)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), rows)
+		require.Equal(t, expect.String(), buf.String())
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := c.v.ExportChain(ExportParams{
+			Table:  "foo",
+			Format: ExportFormatCSV,
+		}, &bytes.Buffer{}, nil)
+		require.Equal(t, ErrExportTableInvalid, err)
+
+		_, err = c.v.ExportChain(ExportParams{
+			Table:  ExportTableBlocks,
+			Format: "xml",
+		}, &bytes.Buffer{}, nil)
+		require.Equal(t, ErrExportFormatInvalid, err)
+	})
+
+	t.Run("inputs require the historydb", func(t *testing.T) {
+		history := c.v.history
+		defer func() {
+			c.v.history = history
+		}()
+		c.v.history = disabledHistory{}
+
+		var buf bytes.Buffer
+		_, err := c.v.ExportChain(ExportParams{
+			Table:  ExportTableInputs,
+			Format: ExportFormatJSONL,
+			EndSeq: 2,
+		}, &buf, nil)
+		require.Equal(t, ErrHistoryDisabled, err)
+		require.Empty(t, buf.String())
+
+		rows, err := c.v.ExportChain(ExportParams{
+			Table:  ExportTableBlocks,
+			Format: ExportFormatJSONL,
+			EndSeq: 2,
+		}, &buf, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), rows)
+	})
+}
+
+func TestParseExportTable(t *testing.T) {
+	for _, table := range ExportTables {
+		got, err := ParseExportTable(string(table))
+		require.NoError(t, err)
+		require.Equal(t, table, got)
+	}
+
+	_, err := ParseExportTable("unspents")
+	require.Equal(t, ErrExportTableInvalid, err)
+
+	_, err = ParseExportFormat("json")
+	require.Equal(t, ErrExportFormatInvalid, err)
+}
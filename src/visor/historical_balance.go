@@ -0,0 +1,154 @@
+package visor
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+var (
+	// ErrTimeBeforeGenesis is returned when a historical balance is requested at a time before the genesis block
+	ErrTimeBeforeGenesis = errors.New("time is before the genesis block")
+)
+
+// HistoricalBalances are the confirmed balances of a set of addresses after a block was executed
+type HistoricalBalances struct {
+	// Block is the header of the block that the balances were calculated at.
+	// Coin hours are calculated at the time of this block.
+	Block coin.BlockHeader
+	// Balances are the balances of the addresses, in the order the addresses were requested
+	Balances []wallet.Balance
+}
+
+// GetBalanceOfAddrsAtSeq returns the confirmed balances of addrs after the block with the given seq was executed.
+// The balances are rebuilt from the outputs recorded in the historydb.
+func (vs Visor) GetBalanceOfAddrsAtSeq(addrs []cipher.Address, seq uint64) (*HistoricalBalances, error) {
+	var hb *HistoricalBalances
+
+	if err := vs.db.View("GetBalanceOfAddrsAtSeq", func(tx *dbutil.Tx) error {
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return NewErrBlockNotExist(seq)
+		}
+
+		hb, err = vs.getBalanceOfAddrsAtBlock(tx, addrs, b.Head)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return hb, nil
+}
+
+// GetBalanceOfAddrsAtTime returns the confirmed balances of addrs after the most recent block
+// with a time at or before t was executed. t is a unix timestamp.
+// The balances are rebuilt from the outputs recorded in the historydb.
+func (vs Visor) GetBalanceOfAddrsAtTime(addrs []cipher.Address, t uint64) (*HistoricalBalances, error) {
+	var hb *HistoricalBalances
+
+	if err := vs.db.View("GetBalanceOfAddrsAtTime", func(tx *dbutil.Tx) error {
+		bh, err := vs.blockHeaderAtTime(tx, t)
+		if err != nil {
+			return err
+		}
+
+		hb, err = vs.getBalanceOfAddrsAtBlock(tx, addrs, *bh)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return hb, nil
+}
+
+// blockHeaderAtTime returns the header of the most recent block with a time at or before t.
+// Block times always increase, so the block is found with a binary search.
+func (vs Visor) blockHeaderAtTime(tx *dbutil.Tx, t uint64) (*coin.BlockHeader, error) {
+	headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrTimeBeforeGenesis
+	}
+
+	// Search for the first block with a time after t. The block before it is the last block at or before t.
+	var found *coin.BlockHeader
+	lo, hi := uint64(0), headSeq+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, mid)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return nil, NewErrBlockNotExist(mid)
+		}
+
+		if b.Head.Time <= t {
+			found = &b.Head
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	if found == nil {
+		return nil, ErrTimeBeforeGenesis
+	}
+
+	return found, nil
+}
+
+// getBalanceOfAddrsAtBlock returns the balances of addrs from the outputs that were unspent after block bh was executed
+func (vs Visor) getBalanceOfAddrsAtBlock(tx *dbutil.Tx, addrs []cipher.Address, bh coin.BlockHeader) (*HistoricalBalances, error) {
+	balances := make([]wallet.Balance, len(addrs))
+
+	for i, addr := range addrs {
+		uxs, err := vs.history.GetOutputsForAddress(tx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ux := range uxs {
+			if !isUnspentAtSeq(ux, bh.BkSeq) {
+				continue
+			}
+
+			b, err := wallet.NewBalanceFromUxOut(bh.Time, &ux.Out)
+			if err != nil {
+				return nil, err
+			}
+
+			balances[i], err = balances[i].Add(b)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &HistoricalBalances{
+		Block:    bh,
+		Balances: balances,
+	}, nil
+}
+
+// isUnspentAtSeq returns true if the output was created by block seq or earlier, and not spent by block seq or earlier
+func isUnspentAtSeq(ux historydb.UxOut, seq uint64) bool {
+	if ux.Out.Head.BkSeq > seq {
+		return false
+	}
+
+	if ux.SpentTxnID.Null() {
+		return true
+	}
+
+	return ux.SpentBlockSeq > seq
+}
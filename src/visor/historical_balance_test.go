@@ -0,0 +1,89 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+func TestGetBalanceOfAddrsAtSeq(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	toPub, toSec := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(toPub)
+	addrs := []cipher.Address{genAddress, toAddr}
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	// Block 1 sends coins from the genesis address to toAddr, with change back to the genesis address
+	txn1 := makeSpendTxWithFee(t, genUxs, []cipher.SecKey{genSecret}, toAddr, 10e6, 0)
+	b1 := c.addBlock(txn1)
+	uxs1 := coin.CreateUnspents(b1.Head, txn1)
+
+	// Block 2 sends all of toAddr's coins back to the genesis address
+	txn2 := makeSpendTxWithFee(t, coin.UxArray{uxs1[0]}, []cipher.SecKey{toSec}, genAddress, 10e6, 0)
+	b2 := c.addBlock(txn2)
+	uxs2 := coin.CreateUnspents(b2.Head, txn2)
+
+	balance := func(bh coin.BlockHeader, uxs ...coin.UxOut) wallet.Balance {
+		var total wallet.Balance
+		for i := range uxs {
+			b, err := wallet.NewBalanceFromUxOut(bh.Time, &uxs[i])
+			require.NoError(t, err)
+			total, err = total.Add(b)
+			require.NoError(t, err)
+		}
+		return total
+	}
+
+	expected := []HistoricalBalances{
+		{
+			Block:    gb.Head,
+			Balances: []wallet.Balance{balance(gb.Head, genUxs[0]), {}},
+		},
+		{
+			Block:    b1.Head,
+			Balances: []wallet.Balance{balance(b1.Head, uxs1[1]), balance(b1.Head, uxs1[0])},
+		},
+		{
+			Block:    b2.Head,
+			Balances: []wallet.Balance{balance(b2.Head, uxs1[1], uxs2[0]), {}},
+		},
+	}
+
+	for i, e := range expected {
+		hb, err := c.v.GetBalanceOfAddrsAtSeq(addrs, uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, e, *hb)
+	}
+
+	_, err := c.v.GetBalanceOfAddrsAtSeq(addrs, 3)
+	require.Equal(t, NewErrBlockNotExist(3), err)
+
+	// A time between blocks returns the balances at the earlier block
+	hb, err := c.v.GetBalanceOfAddrsAtTime(addrs, b1.Time()+50)
+	require.NoError(t, err)
+	require.Equal(t, expected[1], *hb)
+
+	hb, err = c.v.GetBalanceOfAddrsAtTime(addrs, gb.Time())
+	require.NoError(t, err)
+	require.Equal(t, expected[0], *hb)
+
+	hb, err = c.v.GetBalanceOfAddrsAtTime(addrs, b2.Time()+1e6)
+	require.NoError(t, err)
+	require.Equal(t, expected[2], *hb)
+
+	_, err = c.v.GetBalanceOfAddrsAtTime(addrs, gb.Time()-1)
+	require.Equal(t, ErrTimeBeforeGenesis, err)
+
+	// Historical balances require the historydb
+	c.v.history = disabledHistory{}
+	_, err = c.v.GetBalanceOfAddrsAtSeq(addrs, 1)
+	require.Equal(t, ErrHistoryDisabled, err)
+}
@@ -0,0 +1,98 @@
+package visor
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+var (
+	// ErrHistoryDisabled is returned when history data is requested but the historydb is disabled
+	ErrHistoryDisabled = errors.New("history is disabled")
+)
+
+// disabledHistory is the Historyer used when the historydb is disabled.
+// Blocks are not recorded, and queries return ErrHistoryDisabled.
+type disabledHistory struct{}
+
+// GetUxOuts returns ErrHistoryDisabled
+func (h disabledHistory) GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]historydb.UxOut, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// ParseBlock does nothing
+func (h disabledHistory) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
+	return nil
+}
+
+// RollbackBlock does nothing
+func (h disabledHistory) RollbackBlock(tx *dbutil.Tx, b coin.Block) error {
+	return nil
+}
+
+// GetTransaction returns ErrHistoryDisabled
+func (h disabledHistory) GetTransaction(tx *dbutil.Tx, hash cipher.SHA256) (*historydb.Transaction, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// GetOutputsForAddress returns ErrHistoryDisabled
+func (h disabledHistory) GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.UxOut, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// GetTransactionsForAddress returns ErrHistoryDisabled
+func (h disabledHistory) GetTransactionsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.Transaction, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// GetTransactionsPageForAddress returns ErrHistoryDisabled
+func (h disabledHistory) GetTransactionsPageForAddress(tx *dbutil.Tx, address cipher.Address, p historydb.AddressTxnsPageParams) (*historydb.AddressTxnsPage, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// GetAddressSummary returns ErrHistoryDisabled
+func (h disabledHistory) GetAddressSummary(tx *dbutil.Tx, addr cipher.Address) (*historydb.AddressSummary, error) {
+	return nil, ErrHistoryDisabled
+}
+
+// ForEachSupplyStats returns ErrHistoryDisabled
+func (h disabledHistory) ForEachSupplyStats(tx *dbutil.Tx, start, end uint64, f func(historydb.SupplyStats) error) error {
+	return ErrHistoryDisabled
+}
+
+// NeedsReset returns false, there is nothing to reset
+func (h disabledHistory) NeedsReset(tx *dbutil.Tx) (bool, error) {
+	return false, nil
+}
+
+// Erase does nothing
+func (h disabledHistory) Erase(tx *dbutil.Tx) error {
+	return nil
+}
+
+// ParsedBlockSeq returns false, no block is parsed
+func (h disabledHistory) ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	return 0, false, nil
+}
+
+// ForEachTxn returns ErrHistoryDisabled
+func (h disabledHistory) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *historydb.Transaction) error) error {
+	return ErrHistoryDisabled
+}
+
+// eraseHistory erases the historydb if it has any data
+func eraseHistory(tx *dbutil.Tx, history *historydb.HistoryDB) error {
+	_, ok, err := history.ParsedBlockSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	logger.Info("Erasing historyDB")
+
+	return history.Erase(tx)
+}
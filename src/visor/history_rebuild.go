@@ -0,0 +1,539 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+const (
+	// historyParseBatchSize is the number of blocks parsed into the historydb by each database transaction
+	historyParseBatchSize = 1000
+	// historyParseChunkSize is the number of blocks a worker reads and prepares at a time
+	historyParseChunkSize = 100
+)
+
+var (
+	// ErrHistoryRebuilding is returned when history data is requested while the historydb is being rebuilt
+	ErrHistoryRebuilding = errors.New("history is being rebuilt")
+
+	errHistoryParseStopped = errors.New("history parse stopped")
+)
+
+// HistoryRebuildProgress is the progress of a historydb rebuild
+type HistoryRebuildProgress struct {
+	// ParsedBlocks is the number of blocks parsed into the historydb
+	ParsedBlocks uint64
+	// Blocks is the number of blocks in the blockchain
+	Blocks uint64
+	// Done is true once the historydb has caught up with the blockchain head
+	Done       bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// historyParser parses the blocks following the last parsed block into the historydb.
+// Worker goroutines read and prepare chunks of blocks in parallel, then the prepared blocks are parsed in order,
+// in batches of at least batchSize blocks. Each batch commits the parsed block seq,
+// so that an interrupted parse resumes after the last committed batch.
+type historyParser struct {
+	db      *dbutil.DB
+	bc      Blockchainer
+	history *historydb.HistoryDB
+	workers int
+	// chunkSize is the number of blocks a worker reads and prepares at a time
+	chunkSize uint64
+	// batchSize is the minimum number of blocks parsed by each database transaction, except for the last one
+	batchSize int
+	// rollbacks returns the number of blocks rolled back while parsing. Prepared blocks are discarded if it changes.
+	// It is nil if blocks can't be rolled back while parsing.
+	rollbacks func() uint64
+	// committed is called after each batch is committed, with the parsed blocks and the head block seq
+	committed func(blocks []*historydb.PreparedBlock, headSeq uint64)
+}
+
+func newHistoryParser(db *dbutil.DB, bc Blockchainer, history *historydb.HistoryDB) *historyParser {
+	return &historyParser{
+		db:        db,
+		bc:        bc,
+		history:   history,
+		workers:   runtime.NumCPU(),
+		chunkSize: historyParseChunkSize,
+		batchSize: historyParseBatchSize,
+	}
+}
+
+// preparedChunk is the result of preparing a chunk of blocks
+type preparedChunk struct {
+	blocks []*historydb.PreparedBlock
+	err    error
+}
+
+// prepareJob is a chunk of blocks to prepare
+type prepareJob struct {
+	start  uint64
+	end    uint64
+	result chan preparedChunk
+}
+
+// run parses blocks until the historydb has caught up with the blockchain head.
+// It returns errHistoryParseStopped if quit is closed.
+func (p *historyParser) run(quit <-chan struct{}) error {
+	for {
+		caughtUp, err := p.parse(quit)
+		if err != nil {
+			return err
+		}
+
+		if caughtUp {
+			return nil
+		}
+	}
+}
+
+// parse parses the blocks following the last parsed block up to the head block.
+// It returns true if the historydb caught up with the head block, or false if it has to parse again,
+// because blocks were executed or rolled back meanwhile.
+func (p *historyParser) parse(quit <-chan struct{}) (bool, error) {
+	var rollbacks uint64
+	if p.rollbacks != nil {
+		rollbacks = p.rollbacks()
+	}
+
+	var start, headSeq uint64
+	var hasHead bool
+	if err := p.db.View("historyParser.parse", func(tx *dbutil.Tx) error {
+		parsedSeq, ok, err := p.history.ParsedBlockSeq(tx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			start = parsedSeq + 1
+		}
+
+		headSeq, hasHead, err = p.bc.HeadSeq(tx)
+		return err
+	}); err != nil {
+		return false, err
+	}
+
+	if !hasHead || start > headSeq {
+		return true, nil
+	}
+
+	logger.Infof("Parsing history of blocks %d to %d with %d workers", start, headSeq, p.workers)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	// pending holds the results of the jobs in block order, and bounds how far the workers read ahead
+	pending := make(chan chan preparedChunk, p.workers*2)
+	jobs := make(chan prepareJob)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(pending)
+
+		for s := start; s <= headSeq; s += p.chunkSize {
+			end := s + p.chunkSize - 1
+			if end > headSeq || end < s {
+				end = headSeq
+			}
+
+			j := prepareJob{
+				start:  s,
+				end:    end,
+				result: make(chan preparedChunk, 1),
+			}
+
+			select {
+			case pending <- j.result:
+			case <-stop:
+				return
+			}
+
+			select {
+			case jobs <- j:
+			case <-stop:
+				return
+			}
+
+			if end == headSeq {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				blocks, err := p.prepare(j.start, j.end)
+				j.result <- preparedChunk{
+					blocks: blocks,
+					err:    err,
+				}
+			}
+		}()
+	}
+
+	var batch []*historydb.PreparedBlock
+	for result := range pending {
+		var c preparedChunk
+		select {
+		case c = <-result:
+		case <-quit:
+			return false, errHistoryParseStopped
+		}
+
+		if c.err != nil {
+			// Blocks may be missing because they were rolled back after the head block seq was read
+			if p.rollbacks != nil && p.rollbacks() != rollbacks {
+				return false, nil
+			}
+			return false, c.err
+		}
+
+		batch = append(batch, c.blocks...)
+		if len(batch) < p.batchSize && batch[len(batch)-1].Block.Seq() != headSeq {
+			continue
+		}
+
+		select {
+		case <-quit:
+			return false, errHistoryParseStopped
+		default:
+		}
+
+		caughtUp, ok, err := p.commit(batch, rollbacks)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		if caughtUp {
+			return true, nil
+		}
+
+		batch = nil
+	}
+
+	return false, nil
+}
+
+// prepare reads and prepares blocks start to end.
+// The blocks are prepared after the read transaction is closed, so that it is not held while hashing.
+func (p *historyParser) prepare(start, end uint64) ([]*historydb.PreparedBlock, error) {
+	blocks := make([]coin.Block, 0, end-start+1)
+	if err := p.db.View("historyParser.prepare", func(tx *dbutil.Tx) error {
+		for seq := start; seq <= end; seq++ {
+			b, err := p.bc.GetSignedBlockBySeq(tx, seq)
+			if err != nil {
+				return err
+			}
+
+			if b == nil {
+				return fmt.Errorf("no block exists in depth: %d", seq)
+			}
+
+			blocks = append(blocks, b.Block)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	prepared := make([]*historydb.PreparedBlock, len(blocks))
+	for i, b := range blocks {
+		pb, err := historydb.PrepareBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		prepared[i] = pb
+	}
+
+	return prepared, nil
+}
+
+// commit parses a batch of prepared blocks in a database transaction.
+// It returns ok false without parsing the batch if blocks were rolled back since they were prepared,
+// or if the batch doesn't follow the last parsed block.
+// caughtUp is true if the last block of the batch is the head block.
+func (p *historyParser) commit(batch []*historydb.PreparedBlock, rollbacks uint64) (caughtUp, ok bool, err error) {
+	var headSeq uint64
+	if err := p.db.Update("historyParser.commit", func(tx *dbutil.Tx) error {
+		if p.rollbacks != nil && p.rollbacks() != rollbacks {
+			return nil
+		}
+
+		parsedSeq, parsed, err := p.history.ParsedBlockSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		var next uint64
+		if parsed {
+			next = parsedSeq + 1
+		}
+
+		if batch[0].Block.Seq() != next {
+			return nil
+		}
+
+		for _, pb := range batch {
+			if err := p.history.ParsePreparedBlock(tx, pb); err != nil {
+				return err
+			}
+		}
+
+		headSeq, _, err = p.bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		ok = true
+		return nil
+	}); err != nil {
+		return false, false, err
+	}
+
+	if !ok {
+		logger.Info("Blocks were rolled back while parsing history, parsing again from the last parsed block")
+		return false, false, nil
+	}
+
+	lastSeq := batch[len(batch)-1].Block.Seq()
+	logger.Infof("Parsed history up to block %d of %d", lastSeq, headSeq)
+
+	if p.committed != nil {
+		p.committed(batch, headSeq)
+	}
+
+	return lastSeq == headSeq, true, nil
+}
+
+// rebuildingHistory is the Historyer used while the historydb is rebuilt in the background.
+// Queries return ErrHistoryRebuilding until the historydb has caught up with the blockchain head.
+// Blocks executed or rolled back meanwhile are only applied to the historydb if they follow its last parsed block,
+// the rebuild parses the others later.
+type rebuildingHistory struct {
+	*historydb.HistoryDB
+
+	lock      sync.RWMutex
+	progress  HistoryRebuildProgress
+	rollbacks uint64
+}
+
+func newRebuildingHistory(history *historydb.HistoryDB) *rebuildingHistory {
+	return &rebuildingHistory{
+		HistoryDB: history,
+		progress: HistoryRebuildProgress{
+			StartedAt: time.Now(),
+		},
+	}
+}
+
+func (h *rebuildingHistory) done() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.progress.Done
+}
+
+func (h *rebuildingHistory) getProgress() HistoryRebuildProgress {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.progress
+}
+
+func (h *rebuildingHistory) getRollbacks() uint64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.rollbacks
+}
+
+func (h *rebuildingHistory) setProgress(parsedBlocks, blocks uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.progress.ParsedBlocks = parsedBlocks
+	h.progress.Blocks = blocks
+}
+
+func (h *rebuildingHistory) setDone() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.progress.Done = true
+	h.progress.ParsedBlocks = h.progress.Blocks
+	h.progress.FinishedAt = time.Now()
+}
+
+// nextSeq returns the seq of the block following the last parsed block
+func (h *rebuildingHistory) nextSeq(tx *dbutil.Tx) (uint64, error) {
+	parsedSeq, ok, err := h.HistoryDB.ParsedBlockSeq(tx)
+	if err != nil {
+		return 0, err
+	} else if !ok {
+		return 0, nil
+	}
+	return parsedSeq + 1, nil
+}
+
+// ParseBlock parses the block if it follows the last parsed block
+func (h *rebuildingHistory) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
+	if h.done() {
+		return h.HistoryDB.ParseBlock(tx, b)
+	}
+
+	next, err := h.nextSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if b.Seq() != next {
+		return nil
+	}
+
+	return h.HistoryDB.ParseBlock(tx, b)
+}
+
+// RollbackBlock rolls back the block if it is the last parsed block
+func (h *rebuildingHistory) RollbackBlock(tx *dbutil.Tx, b coin.Block) error {
+	if h.done() {
+		return h.HistoryDB.RollbackBlock(tx, b)
+	}
+
+	h.lock.Lock()
+	h.rollbacks++
+	h.lock.Unlock()
+
+	next, err := h.nextSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if b.Seq() >= next {
+		return nil
+	}
+
+	return h.HistoryDB.RollbackBlock(tx, b)
+}
+
+// GetUxOuts returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]historydb.UxOut, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetUxOuts(tx, uxids)
+}
+
+// GetTransaction returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetTransaction(tx *dbutil.Tx, hash cipher.SHA256) (*historydb.Transaction, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetTransaction(tx, hash)
+}
+
+// GetOutputsForAddress returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.UxOut, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetOutputsForAddress(tx, address)
+}
+
+// GetTransactionsForAddress returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetTransactionsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.Transaction, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetTransactionsForAddress(tx, address)
+}
+
+// GetTransactionsPageForAddress returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetTransactionsPageForAddress(tx *dbutil.Tx, address cipher.Address, p historydb.AddressTxnsPageParams) (*historydb.AddressTxnsPage, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetTransactionsPageForAddress(tx, address, p)
+}
+
+// GetAddressSummary returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) GetAddressSummary(tx *dbutil.Tx, addr cipher.Address) (*historydb.AddressSummary, error) {
+	if !h.done() {
+		return nil, ErrHistoryRebuilding
+	}
+	return h.HistoryDB.GetAddressSummary(tx, addr)
+}
+
+// ForEachSupplyStats returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) ForEachSupplyStats(tx *dbutil.Tx, start, end uint64, f func(historydb.SupplyStats) error) error {
+	if !h.done() {
+		return ErrHistoryRebuilding
+	}
+	return h.HistoryDB.ForEachSupplyStats(tx, start, end, f)
+}
+
+// ForEachTxn returns ErrHistoryRebuilding until the rebuild is done
+func (h *rebuildingHistory) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *historydb.Transaction) error) error {
+	if !h.done() {
+		return ErrHistoryRebuilding
+	}
+	return h.HistoryDB.ForEachTxn(tx, f)
+}
+
+// RunHistoryRebuild parses the blocks missing from the historydb, if New found it erased or behind the blockchain head.
+// Until it is done, history queries return ErrHistoryRebuilding and GetHistoryRebuildProgress reports the progress.
+// The parsed blocks are committed in batches, so if quit is closed the next start resumes from the last batch.
+func (vs *Visor) RunHistoryRebuild(quit <-chan struct{}) error {
+	h := vs.historyRebuild
+	if h == nil || h.done() {
+		return nil
+	}
+
+	logger.Info("Rebuilding historyDB")
+
+	p := newHistoryParser(vs.db, vs.blockchain, h.HistoryDB)
+	p.rollbacks = h.getRollbacks
+	p.committed = func(blocks []*historydb.PreparedBlock, headSeq uint64) {
+		h.setProgress(blocks[len(blocks)-1].Block.Seq()+1, headSeq+1)
+	}
+
+	if err := p.run(quit); err != nil {
+		if err == errHistoryParseStopped {
+			logger.Info("HistoryDB rebuild stopped, it will resume on the next start")
+			return nil
+		}
+		return err
+	}
+
+	h.setDone()
+
+	progress := h.getProgress()
+	logger.Infof("Rebuilt historyDB of %d blocks in %s", progress.Blocks, progress.FinishedAt.Sub(progress.StartedAt))
+
+	return nil
+}
+
+// GetHistoryRebuildProgress returns the progress of the historydb rebuild started by New,
+// or nil if the historydb did not need to be rebuilt
+func (vs *Visor) GetHistoryRebuildProgress() *HistoryRebuildProgress {
+	if vs.historyRebuild == nil {
+		return nil
+	}
+
+	progress := vs.historyRebuild.getProgress()
+	return &progress
+}
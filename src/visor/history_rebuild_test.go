@@ -0,0 +1,205 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// dumpHistoryDB returns the keys and values of the historydb buckets
+func dumpHistoryDB(t *testing.T, db *dbutil.DB) map[string]map[string]string {
+	dump := dumpDB(t, db)
+	history := make(map[string]map[string]string)
+	for _, bkt := range [][]byte{
+		historydb.AddressTxnsBkt,
+		historydb.AddressUxBkt,
+		historydb.AddressSummaryBkt,
+		historydb.HistoryMetaBkt,
+		historydb.UxOutsBkt,
+		historydb.TransactionsBkt,
+	} {
+		history[string(bkt)] = dump[string(bkt)]
+	}
+	return history
+}
+
+func TestRunHistoryRebuild(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	headSeq := c.head().Seq()
+	historyDB := c.v.history.(*historydb.HistoryDB)
+
+	// Erase the history, the visor finds it needs to be rebuilt
+	var h *rebuildingHistory
+	err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+		if err := historyDB.Erase(tx); err != nil {
+			return err
+		}
+
+		var err error
+		h, err = initHistory(tx, c.v.blockchain.(*Blockchain), historyDB)
+		return err
+	})
+	require.NoError(t, err)
+	require.NotNil(t, h)
+	require.Equal(t, uint64(0), h.getProgress().ParsedBlocks)
+	require.Equal(t, headSeq+1, h.getProgress().Blocks)
+
+	c.v.history = h
+	c.v.historyRebuild = h
+
+	txid := c.block(1).Body.Transactions[0].Hash()
+	_, err = c.v.GetTransaction(txid)
+	require.Equal(t, ErrHistoryRebuilding, err)
+
+	// Parse two blocks per batch and stop after the first batch
+	quit := make(chan struct{})
+	p := newHistoryParser(c.v.db, c.v.blockchain, historyDB)
+	p.workers = 4
+	p.chunkSize = 1
+	p.batchSize = 2
+	p.committed = func(blocks []*historydb.PreparedBlock, headSeq uint64) {
+		close(quit)
+	}
+	err = p.run(quit)
+	require.Equal(t, errHistoryParseStopped, err)
+
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		parsedSeq, ok, err := historyDB.ParsedBlockSeq(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(1), parsedSeq)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The checkpoint is resumed instead of erased on the next start
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		var err error
+		h, err = initHistory(tx, c.v.blockchain.(*Blockchain), historyDB)
+		return err
+	})
+	require.NoError(t, err)
+	require.NotNil(t, h)
+	require.Equal(t, uint64(2), h.getProgress().ParsedBlocks)
+
+	c.v.history = h
+	c.v.historyRebuild = h
+
+	// A rollback of a block that is not parsed yet is skipped, and counted so that prepared blocks are discarded
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return h.RollbackBlock(tx, c.head().Block)
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), h.getRollbacks())
+
+	p = newHistoryParser(c.v.db, c.v.blockchain, historyDB)
+	p.rollbacks = h.getRollbacks
+	_, ok, err := p.commit([]*historydb.PreparedBlock{{Block: c.block(2).Block}}, 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// A block executed during the rebuild is parsed by the rebuild
+	var genUx coin.UxOut
+	uxs, err := c.v.GetAllUnspentOutputs()
+	require.NoError(t, err)
+	for _, ux := range uxs {
+		if ux.Body.Address == genAddress {
+			genUx = ux
+			break
+		}
+	}
+	require.Equal(t, genAddress, genUx.Body.Address)
+
+	addr := testutil.MakeAddress()
+	sb := c.addBlock(makeSpendTxn(t, coin.UxArray{genUx}, []cipher.SecKey{genSecret}, addr, 1e6))
+	require.Equal(t, headSeq+1, sb.Seq())
+
+	err = c.v.RunHistoryRebuild(nil)
+	require.NoError(t, err)
+
+	progress := c.v.GetHistoryRebuildProgress()
+	require.NotNil(t, progress)
+	require.True(t, progress.Done)
+	require.Equal(t, headSeq+2, progress.Blocks)
+	require.Equal(t, headSeq+2, progress.ParsedBlocks)
+
+	txn, err := c.v.GetTransaction(txid)
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+
+	// The rebuilt history matches the history of a chain that parsed the blocks as they were executed
+	f := c.fork(sb.Seq())
+	defer f.shutdown()
+	require.Equal(t, dumpHistoryDB(t, f.v.db), dumpHistoryDB(t, c.v.db))
+
+	// Blocks executed after the rebuild is done are parsed as they are executed
+	uxs, err = c.v.GetUnspentOutputs([]cipher.SHA256{coin.CreateUnspents(sb.Head, sb.Body.Transactions[0])[1].Hash()})
+	require.NoError(t, err)
+	sb = c.addBlock(makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, addr, 1e6))
+	err = f.v.ExecuteSignedBlock(sb)
+	require.NoError(t, err)
+	require.Equal(t, dumpHistoryDB(t, f.v.db), dumpHistoryDB(t, c.v.db))
+
+	// Nothing is rebuilt if the history is complete
+	c.v.historyRebuild = nil
+	require.Nil(t, c.v.GetHistoryRebuildProgress())
+	require.NoError(t, c.v.RunHistoryRebuild(nil))
+}
+
+func TestDisableHistory(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addSpendBlocks(t, c, 3)
+
+	txid := c.block(2).Body.Transactions[0].Hash()
+	txn, err := c.v.GetTransaction(txid)
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+
+	history := dumpHistoryDB(t, c.v.db)
+
+	// Disabling the history erases the historydb
+	cfg := c.v.Config
+	cfg.DisableHistory = true
+	v, err := New(cfg, c.v.db, nil)
+	require.NoError(t, err)
+	require.Equal(t, disabledHistory{}, v.history)
+	require.Nil(t, v.GetHistoryRebuildProgress())
+
+	_, err = v.GetTransaction(txid)
+	require.Equal(t, ErrHistoryDisabled, err)
+
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		_, ok, err := historydb.New().ParsedBlockSeq(tx)
+		require.NoError(t, err)
+		require.False(t, ok)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Enabling the history again rebuilds the historydb from the blocks
+	cfg.DisableHistory = false
+	v, err = New(cfg, c.v.db, nil)
+	require.NoError(t, err)
+	require.NotNil(t, v.GetHistoryRebuildProgress())
+
+	_, err = v.GetTransaction(txid)
+	require.Equal(t, ErrHistoryRebuilding, err)
+
+	err = v.RunHistoryRebuild(nil)
+	require.NoError(t, err)
+
+	txn, err = v.GetTransaction(txid)
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+	require.Equal(t, history, dumpHistoryDB(t, c.v.db))
+}
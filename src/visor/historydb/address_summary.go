@@ -0,0 +1,147 @@
+package historydb
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// AddressSummaryBkt maps addresses to the aggregates of their history
+var AddressSummaryBkt = []byte("address_summary")
+
+// AddressSummary aggregates the history of an address
+type AddressSummary struct {
+	// Received is the total of coins in the outputs received by the address, in droplets
+	Received uint64
+	// Sent is the total of coins in the outputs spent by the address, in droplets
+	Sent uint64
+	// TxnCount is the number of transactions that spend from or send to the address
+	TxnCount uint64
+	// FirstSeq is the seq of the first block with a transaction of the address
+	FirstSeq uint64
+	// LastSeq is the seq of the last block with a transaction of the address
+	LastSeq uint64
+}
+
+// addressSummaryDelta is the change of an address summary caused by a single transaction
+type addressSummaryDelta struct {
+	received uint64
+	sent     uint64
+}
+
+// addressSummaries bucket stores the summary of each address, address as key, AddressSummary as value
+type addressSummaries struct{}
+
+// get returns the summary of an address, or nil if the address has no history
+func (as *addressSummaries) get(tx *dbutil.Tx, addr cipher.Address) (*AddressSummary, error) {
+	var s AddressSummary
+	if ok, err := dbutil.GetBucketObjectDecoded(tx, AddressSummaryBkt, addr.Bytes(), &s); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// put saves the summary of an address
+func (as *addressSummaries) put(tx *dbutil.Tx, addr cipher.Address, s AddressSummary) error {
+	return dbutil.PutBucketValue(tx, AddressSummaryBkt, addr.Bytes(), encoder.Serialize(s))
+}
+
+// apply adds the deltas of a transaction in block seq to the summaries of its addresses
+func (as *addressSummaries) apply(tx *dbutil.Tx, seq uint64, deltas map[cipher.Address]addressSummaryDelta) error {
+	for _, addr := range sortedDeltaAddresses(deltas) {
+		d := deltas[addr]
+
+		s, err := as.get(tx, addr)
+		if err != nil {
+			return err
+		}
+
+		if s == nil {
+			s = &AddressSummary{
+				FirstSeq: seq,
+			}
+		}
+
+		s.Received += d.received
+		s.Sent += d.sent
+		s.TxnCount++
+		s.LastSeq = seq
+
+		if err := as.put(tx, addr, *s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revert subtracts the deltas of a transaction from the summaries of its addresses.
+// lastSeq returns the seq of the last block with a transaction of the address, after the transaction was removed.
+func (as *addressSummaries) revert(tx *dbutil.Tx, deltas map[cipher.Address]addressSummaryDelta, lastSeq func(cipher.Address) (uint64, error)) error {
+	for _, addr := range sortedDeltaAddresses(deltas) {
+		d := deltas[addr]
+
+		s, err := as.get(tx, addr)
+		if err != nil {
+			return err
+		}
+
+		if s == nil || s.TxnCount == 0 || s.Received < d.received || s.Sent < d.sent {
+			return errors.New("addressSummaries.revert: address summary does not include the transaction")
+		}
+
+		s.Received -= d.received
+		s.Sent -= d.sent
+		s.TxnCount--
+
+		// Delete the row if the address has no transactions left
+		if s.TxnCount == 0 {
+			if err := dbutil.Delete(tx, AddressSummaryBkt, addr.Bytes()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.LastSeq, err = lastSeq(addr)
+		if err != nil {
+			return err
+		}
+
+		if err := as.put(tx, addr, *s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isEmpty checks if the address summary bucket is empty
+func (as *addressSummaries) isEmpty(tx *dbutil.Tx) (bool, error) {
+	return dbutil.IsEmpty(tx, AddressSummaryBkt)
+}
+
+// reset resets the bucket
+func (as *addressSummaries) reset(tx *dbutil.Tx) error {
+	return dbutil.Reset(tx, AddressSummaryBkt)
+}
+
+// sortedDeltaAddresses returns the addresses of deltas in a deterministic order
+func sortedDeltaAddresses(deltas map[cipher.Address]addressSummaryDelta) []cipher.Address {
+	addrs := make([]cipher.Address, 0, len(deltas))
+	for a := range deltas {
+		addrs = append(addrs, a)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+
+	return addrs
+}
@@ -0,0 +1,184 @@
+package historydb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestAddressSummary(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+	bc := newBlockchain()
+	gb := bc.CreateGenesisBlock(genAddress, genCoins, genTime)
+
+	hisDB := New()
+
+	requireSummaries := func(expect map[cipher.Address]*AddressSummary) {
+		t.Helper()
+		err := db.View("", func(tx *dbutil.Tx) error {
+			for addr, s := range expect {
+				summary, err := hisDB.GetAddressSummary(tx, addr)
+				require.NoError(t, err)
+				require.Equal(t, s, summary, addr.String())
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	parseBlock := func(b coin.Block) {
+		t.Helper()
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return hisDB.ParseBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+
+	rollbackBlock := func(b coin.Block) {
+		t.Helper()
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return hisDB.RollbackBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+
+	toPubKey, toSecKey := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(toPubKey)
+	otherAddr := makeAddress()
+
+	parseBlock(gb)
+
+	genSummary := &AddressSummary{
+		Received: genCoins,
+		TxnCount: 1,
+	}
+	requireSummaries(map[cipher.Address]*AddressSummary{
+		genAddress: genSummary,
+		toAddr:     nil,
+	})
+
+	// The genesis address sends coins to toAddr, with change to itself
+	b1, txn1, err := addBlock(bc, testData{
+		PreBlockHash: gb.HashHeader(),
+		Vin: txIn{
+			SigKey:   genSecret.Hex(),
+			Addr:     genAddress.String(),
+			TxID:     gb.Body.Transactions[0].Hash(),
+			BlockSeq: 0,
+		},
+		Vouts: []txOut{
+			{
+				ToAddr: toAddr.String(),
+				Coins:  10e6,
+				Hours:  100,
+			},
+			{
+				ToAddr: genAddress.String(),
+				Coins:  genCoins - 10e6,
+				Hours:  400,
+			},
+		},
+	}, incTime)
+	require.NoError(t, err)
+	parseBlock(*b1)
+
+	genSummary1 := &AddressSummary{
+		Received: genCoins + genCoins - 10e6,
+		Sent:     genCoins,
+		TxnCount: 2,
+		FirstSeq: 0,
+		LastSeq:  1,
+	}
+	toSummary1 := &AddressSummary{
+		Received: 10e6,
+		TxnCount: 1,
+		FirstSeq: 1,
+		LastSeq:  1,
+	}
+	requireSummaries(map[cipher.Address]*AddressSummary{
+		genAddress: genSummary1,
+		toAddr:     toSummary1,
+	})
+
+	// toAddr sends coins to otherAddr
+	b2, _, err := addBlock(bc, testData{
+		PreBlockHash: b1.HashHeader(),
+		Vin: txIn{
+			SigKey:   toSecKey.Hex(),
+			Addr:     toAddr.String(),
+			TxID:     txn1.Hash(),
+			BlockSeq: 1,
+		},
+		Vouts: []txOut{
+			{
+				ToAddr: otherAddr.String(),
+				Coins:  4e6,
+				Hours:  10,
+			},
+			{
+				ToAddr: toAddr.String(),
+				Coins:  6e6,
+				Hours:  10,
+			},
+		},
+	}, incTime)
+	require.NoError(t, err)
+	parseBlock(*b2)
+
+	requireSummaries(map[cipher.Address]*AddressSummary{
+		genAddress: genSummary1,
+		toAddr: {
+			Received: 16e6,
+			Sent:     10e6,
+			TxnCount: 2,
+			FirstSeq: 1,
+			LastSeq:  2,
+		},
+		otherAddr: {
+			Received: 4e6,
+			TxnCount: 1,
+			FirstSeq: 2,
+			LastSeq:  2,
+		},
+	})
+
+	// Rolling back the blocks restores the previous summaries
+	rollbackBlock(*b2)
+	requireSummaries(map[cipher.Address]*AddressSummary{
+		genAddress: genSummary1,
+		toAddr:     toSummary1,
+		otherAddr:  nil,
+	})
+
+	rollbackBlock(*b1)
+	requireSummaries(map[cipher.Address]*AddressSummary{
+		genAddress: genSummary,
+		toAddr:     nil,
+	})
+
+	// The summaries are rebuilt by a reset
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		needsReset, err := hisDB.NeedsReset(tx)
+		require.NoError(t, err)
+		require.False(t, needsReset)
+
+		require.NoError(t, hisDB.addrSums.reset(tx))
+
+		needsReset, err = hisDB.NeedsReset(tx)
+		require.NoError(t, err)
+		require.True(t, needsReset)
+
+		require.NoError(t, hisDB.Erase(tx))
+
+		s, err := hisDB.GetAddressSummary(tx, genAddress)
+		require.NoError(t, err)
+		require.Nil(t, s)
+		return nil
+	})
+	require.NoError(t, err)
+}
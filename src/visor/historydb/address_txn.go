@@ -1,6 +1,11 @@
 package historydb
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
@@ -12,55 +17,212 @@ type hashesWrapper struct {
 	Hashes []cipher.SHA256
 }
 
-// AddressTxnsBkt maps addresses to transaction hashes
-var AddressTxnsBkt = []byte("address_txns")
+// AddressTxnsBkt indexes the transactions of each address.
+// The keys are the address, the seq of the block that executed the transaction and the transaction's
+// index in the block, so that the transactions of an address are ordered and can be paged with a cursor.
+// The values are the transaction hashes.
+var AddressTxnsBkt = []byte("address_txn_index")
+
+// legacyAddressTxnsBkt mapped each address to a list of all of its transaction hashes.
+// It is replaced by AddressTxnsBkt and removed when the history is erased.
+var legacyAddressTxnsBkt = []byte("address_txns")
+
+// ErrAddressTxnsCursorNotFound is returned if the cursor of a page of address transactions
+// is not a transaction of the address, e.g. because its block was rolled back
+var ErrAddressTxnsCursorNotFound = errors.New("cursor is not a transaction of the address")
+
+// AddressTxnsPageParams selects a page of the transactions of an address.
+// The transactions of an address are ordered by the seq of the block that executed them,
+// then by their position in the block.
+type AddressTxnsPageParams struct {
+	// MinSeq and MaxSeq restrict the page to transactions of blocks MinSeq to MaxSeq, inclusive
+	MinSeq uint64
+	MaxSeq uint64
+	// Reverse orders the transactions newest first
+	Reverse bool
+	// After is the hash of the last transaction of the previous page, nil for the first page
+	After *cipher.SHA256
+	// Limit is the maximum number of transactions in the page, 0 for no limit
+	Limit uint64
+}
+
+// AddressTxnsPage is a page of the transactions of an address
+type AddressTxnsPage struct {
+	Transactions []Transaction
+	// Next is the hash of the last transaction of the page if more transactions follow,
+	// to be used as After for the next page. It stays valid when new blocks are executed.
+	Next *cipher.SHA256
+}
 
-// addressTxn buckets for storing address related transactions
-// address as key, transaction id slice as value
+// addressTxns bucket for storing address related transactions
 type addressTxns struct{}
 
-// get returns the transaction hashes of given address
+// addressTxnKey returns the AddressTxnsBkt key of the transaction at index in the block seq
+func addressTxnKey(addr cipher.Address, seq uint64, index uint32) []byte {
+	k := addr.Bytes()
+	k = append(k, dbutil.Itob(seq)...)
+	return append(k, uint32Bytes(index)...)
+}
+
+// addressTxnKeySeq returns the block seq of an AddressTxnsBkt key
+func addressTxnKeySeq(k []byte) uint64 {
+	return dbutil.Btoi(k[len(k)-12 : len(k)-4])
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// get returns the transaction hashes of given address, in the order they were executed
 func (atx *addressTxns) get(tx *dbutil.Tx, addr cipher.Address) ([]cipher.SHA256, error) {
-	var txnHashes hashesWrapper
+	b := tx.Bucket(AddressTxnsBkt)
+	if b == nil {
+		return nil, dbutil.NewErrBucketNotExist(AddressTxnsBkt)
+	}
+
+	prefix := addr.Bytes()
+
+	var hashes []cipher.SHA256
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		h, err := cipher.SHA256FromBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+
+	return hashes, nil
+}
+
+// add adds the transaction at index in the block seq to the address's transactions.
+// Adding a transaction again has no effect.
+func (atx *addressTxns) add(tx *dbutil.Tx, addr cipher.Address, seq uint64, index uint32, hash cipher.SHA256) error {
+	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addressTxnKey(addr, seq, index), hash[:])
+}
+
+// remove removes the transaction at index in the block seq from the address's transactions
+func (atx *addressTxns) remove(tx *dbutil.Tx, addr cipher.Address, seq uint64, index uint32) error {
+	return dbutil.Delete(tx, AddressTxnsBkt, addressTxnKey(addr, seq, index))
+}
+
+// lastSeq returns the block seq of the address's last transaction.
+// Returns false if the address has no transactions.
+func (atx *addressTxns) lastSeq(tx *dbutil.Tx, addr cipher.Address) (uint64, bool, error) {
+	b := tx.Bucket(AddressTxnsBkt)
+	if b == nil {
+		return 0, false, dbutil.NewErrBucketNotExist(AddressTxnsBkt)
+	}
 
-	v, err := dbutil.GetBucketValueNoCopy(tx, AddressTxnsBkt, addr.Bytes())
-	if err != nil {
-		return nil, err
-	} else if v == nil {
-		return nil, nil
+	k, _ := seekLast(b.Cursor(), addressTxnKey(addr, math.MaxUint64, math.MaxUint32))
+	if k == nil || !bytes.HasPrefix(k, addr.Bytes()) {
+		return 0, false, nil
 	}
 
-	if err := decodeHashesWrapperExact(v, &txnHashes); err != nil {
-		return nil, err
+	return addressTxnKeySeq(k), true, nil
+}
+
+// seekLast moves the cursor to the last key that is less than or equal to key
+func seekLast(c dbutil.Cursor, key []byte) ([]byte, []byte) {
+	k, v := c.Seek(key)
+	switch {
+	case k == nil:
+		return c.Last()
+	case bytes.Equal(k, key):
+		return k, v
+	default:
+		return c.Prev()
+	}
+}
+
+// cursorKey returns the key of the transaction hash in the block seq of the address's transactions
+func (atx *addressTxns) cursorKey(c dbutil.Cursor, addr cipher.Address, seq uint64, hash cipher.SHA256) []byte {
+	prefix := addressTxnKey(addr, seq, 0)
+	prefix = prefix[:len(prefix)-4]
+
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if bytes.Equal(v, hash[:]) {
+			return append([]byte{}, k...)
+		}
 	}
 
-	return txnHashes.Hashes, nil
+	return nil
 }
 
-// add adds a hash to an address's hash list
-func (atx *addressTxns) add(tx *dbutil.Tx, addr cipher.Address, hash cipher.SHA256) error {
-	hashes, err := atx.get(tx, addr)
-	if err != nil {
-		return err
+// page returns the transaction hashes of a page of the address's transactions,
+// and whether more transactions follow the page.
+// afterSeq is the block seq of the p.After cursor transaction, if any.
+// The page is read with a cursor seeked to the start of the page, so its cost does not depend
+// on the number of transactions of the address.
+func (atx *addressTxns) page(tx *dbutil.Tx, addr cipher.Address, p AddressTxnsPageParams, afterSeq uint64) ([]cipher.SHA256, bool, error) {
+	if p.MinSeq > p.MaxSeq {
+		return nil, false, errors.New("addressTxns.page: MinSeq is greater than MaxSeq")
+	}
+
+	b := tx.Bucket(AddressTxnsBkt)
+	if b == nil {
+		return nil, false, dbutil.NewErrBucketNotExist(AddressTxnsBkt)
 	}
 
-	// check for duplicates
-	for _, u := range hashes {
-		if u == hash {
-			return nil
+	c := b.Cursor()
+
+	var afterKey []byte
+	if p.After != nil {
+		afterKey = atx.cursorKey(c, addr, afterSeq, *p.After)
+		if afterKey == nil {
+			return nil, false, ErrAddressTxnsCursorNotFound
 		}
 	}
 
-	hashes = append(hashes, hash)
+	prefix := addr.Bytes()
+	inRange := func(k []byte) bool {
+		if k == nil || !bytes.HasPrefix(k, prefix) {
+			return false
+		}
 
-	buf, err := encodeHashesWrapper(&hashesWrapper{
-		Hashes: hashes,
-	})
-	if err != nil {
-		return err
+		seq := addressTxnKeySeq(k)
+		return seq >= p.MinSeq && seq <= p.MaxSeq
 	}
 
-	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addr.Bytes(), buf)
+	// Move to the first transaction of the page
+	var k, v []byte
+	next := c.Next
+	if p.Reverse {
+		next = c.Prev
+
+		maxKey := addressTxnKey(addr, p.MaxSeq, math.MaxUint32)
+		if afterKey != nil && bytes.Compare(afterKey, maxKey) <= 0 {
+			c.Seek(afterKey)
+			k, v = c.Prev()
+		} else {
+			k, v = seekLast(c, maxKey)
+		}
+	} else {
+		minKey := addressTxnKey(addr, p.MinSeq, 0)
+		if afterKey != nil && bytes.Compare(afterKey, minKey) >= 0 {
+			c.Seek(afterKey)
+			k, v = c.Next()
+		} else {
+			k, v = c.Seek(minKey)
+		}
+	}
+
+	var hashes []cipher.SHA256
+	for ; inRange(k); k, v = next() {
+		if p.Limit > 0 && uint64(len(hashes)) == p.Limit {
+			return hashes, true, nil
+		}
+
+		h, err := cipher.SHA256FromBytes(v)
+		if err != nil {
+			return nil, false, err
+		}
+		hashes = append(hashes, h)
+	}
+
+	return hashes, false, nil
 }
 
 // isEmpty checks if address transactions bucket is empty
@@ -68,7 +230,13 @@ func (atx *addressTxns) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, AddressTxnsBkt)
 }
 
-// reset resets the bucket
+// reset resets the bucket and removes the legacy address transactions bucket
 func (atx *addressTxns) reset(tx *dbutil.Tx) error {
+	if dbutil.Exists(tx, legacyAddressTxnsBkt) {
+		if err := tx.DeleteBucket(legacyAddressTxnsBkt); err != nil {
+			return err
+		}
+	}
+
 	return dbutil.Reset(tx, AddressTxnsBkt)
 }
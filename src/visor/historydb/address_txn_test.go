@@ -1,12 +1,14 @@
 package historydb
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
@@ -16,6 +18,8 @@ func TestAddAddressTxns(t *testing.T) {
 
 	type pair struct {
 		addr   cipher.Address
+		seq    uint64
+		index  uint32
 		txHash cipher.SHA256
 	}
 
@@ -62,10 +66,13 @@ func TestAddAddressTxns(t *testing.T) {
 			[]pair{
 				{
 					addr:   preAddrs[0],
+					seq:    1,
 					txHash: preTxHashes[0],
 				},
 				{
 					addr:   preAddrs[0],
+					seq:    1,
+					index:  1,
 					txHash: preTxHashes[1],
 				},
 			},
@@ -110,7 +117,7 @@ func TestAddAddressTxns(t *testing.T) {
 
 			err := db.Update("", func(tx *dbutil.Tx) error {
 				for _, pr := range tc.addPairs {
-					err := addrTxns.add(tx, pr.addr, pr.txHash)
+					err := addrTxns.add(tx, pr.addr, pr.seq, pr.index, pr.txHash)
 					require.NoError(t, err)
 				}
 				return nil
@@ -137,6 +144,8 @@ func TestGetAddressTxns(t *testing.T) {
 
 	type pair struct {
 		addr   cipher.Address
+		seq    uint64
+		index  uint32
 		txHash cipher.SHA256
 	}
 
@@ -183,10 +192,13 @@ func TestGetAddressTxns(t *testing.T) {
 			[]pair{
 				{
 					addr:   preAddrs[0],
+					seq:    1,
 					txHash: preTxHashes[0],
 				},
 				{
 					addr:   preAddrs[0],
+					seq:    1,
+					index:  1,
 					txHash: preTxHashes[1],
 				},
 			},
@@ -220,6 +232,33 @@ func TestGetAddressTxns(t *testing.T) {
 				},
 			},
 		},
+		{
+			"transactions are ordered by block seq and index",
+			[]pair{
+				{
+					addr:   preAddrs[0],
+					seq:    2,
+					txHash: preTxHashes[2],
+				},
+				{
+					addr:   preAddrs[0],
+					seq:    1,
+					index:  1,
+					txHash: preTxHashes[1],
+				},
+				{
+					addr:   preAddrs[0],
+					seq:    1,
+					txHash: preTxHashes[0],
+				},
+			},
+			[]expectPair{
+				{
+					preAddrs[0],
+					preTxHashes,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -231,7 +270,7 @@ func TestGetAddressTxns(t *testing.T) {
 
 			err := db.Update("", func(tx *dbutil.Tx) error {
 				for _, pr := range tc.addPairs {
-					err := addrTxns.add(tx, pr.addr, pr.txHash)
+					err := addrTxns.add(tx, pr.addr, pr.seq, pr.index, pr.txHash)
 					require.NoError(t, err)
 				}
 
@@ -252,3 +291,280 @@ func TestGetAddressTxns(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionsPageForAddress(t *testing.T) {
+	db, td := prepareDB(t)
+	defer td()
+
+	hisDB := New()
+	addr := makeAddress()
+
+	// Transactions of addr in blocks 1, 1, 2, 4, 4 and 7
+	seqs := []uint64{1, 1, 2, 4, 4, 7}
+	txns := make([]Transaction, len(seqs))
+	hashes := make([]cipher.SHA256, len(seqs))
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		for i, seq := range seqs {
+			txns[i] = Transaction{
+				Txn: coin.Transaction{
+					In: []cipher.SHA256{cipher.SumSHA256([]byte(fmt.Sprintf("in%d", i)))},
+				},
+				BlockSeq: seq,
+			}
+			hashes[i] = txns[i].Hash()
+
+			if err := hisDB.txns.put(tx, &txns[i]); err != nil {
+				return err
+			}
+
+			if err := hisDB.addrTxns.add(tx, addr, seq, uint32(i), hashes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	reversed := func(txns []Transaction) []Transaction {
+		r := make([]Transaction, len(txns))
+		for i, txn := range txns {
+			r[len(txns)-1-i] = txn
+		}
+		return r
+	}
+
+	unknownHash := cipher.SumSHA256([]byte("unknown"))
+
+	// A transaction of another address in the same block as a transaction of addr
+	otherTxn := Transaction{
+		Txn: coin.Transaction{
+			In: []cipher.SHA256{cipher.SumSHA256([]byte("other"))},
+		},
+		BlockSeq: 4,
+	}
+	otherHash := otherTxn.Hash()
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := hisDB.txns.put(tx, &otherTxn); err != nil {
+			return err
+		}
+
+		return hisDB.addrTxns.add(tx, makeAddress(), 4, 5, otherHash)
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		addr   cipher.Address
+		params AddressTxnsPageParams
+		txns   []Transaction
+		next   *cipher.SHA256
+		err    error
+	}{
+		{
+			name: "all",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+			},
+			txns: txns,
+		},
+		{
+			name: "first page",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+				Limit:  2,
+			},
+			txns: txns[:2],
+			next: &hashes[1],
+		},
+		{
+			name: "middle page",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+				Limit:  2,
+				After:  &hashes[1],
+			},
+			txns: txns[2:4],
+			next: &hashes[3],
+		},
+		{
+			name: "last page",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+				Limit:  2,
+				After:  &hashes[3],
+			},
+			txns: txns[4:],
+		},
+		{
+			name: "newest first",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq:  10,
+				Limit:   4,
+				Reverse: true,
+			},
+			txns: reversed(txns[2:]),
+			next: &hashes[2],
+		},
+		{
+			name: "newest first last page",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq:  10,
+				Limit:   4,
+				Reverse: true,
+				After:   &hashes[2],
+			},
+			txns: reversed(txns[:2]),
+		},
+		{
+			name: "block range",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq: 2,
+				MaxSeq: 4,
+			},
+			txns: txns[2:5],
+		},
+		{
+			name: "block range newest first with cursor",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq:  2,
+				MaxSeq:  4,
+				Reverse: true,
+				Limit:   1,
+				After:   &hashes[4],
+			},
+			txns: []Transaction{txns[3]},
+			next: &hashes[3],
+		},
+		{
+			name: "block range without transactions",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq: 5,
+				MaxSeq: 6,
+			},
+		},
+		{
+			name: "cursor outside of the block range",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq: 2,
+				MaxSeq: 4,
+				After:  &hashes[5],
+			},
+		},
+		{
+			name: "unknown address",
+			addr: makeAddress(),
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+			},
+		},
+		{
+			name: "unknown cursor",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+				After:  &unknownHash,
+			},
+			err: ErrAddressTxnsCursorNotFound,
+		},
+		{
+			name: "cursor of another address",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MaxSeq: 10,
+				After:  &otherHash,
+			},
+			err: ErrAddressTxnsCursorNotFound,
+		},
+		{
+			name: "cursor below the block range",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq: 4,
+				MaxSeq: 10,
+				After:  &hashes[0],
+			},
+			txns: txns[3:],
+		},
+		{
+			name: "newest first cursor above the block range",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq:  1,
+				MaxSeq:  2,
+				Reverse: true,
+				After:   &hashes[5],
+			},
+			txns: reversed(txns[:3]),
+		},
+		{
+			name: "invalid block range",
+			addr: addr,
+			params: AddressTxnsPageParams{
+				MinSeq: 4,
+				MaxSeq: 2,
+			},
+			err: errors.New("addressTxns.page: MinSeq is greater than MaxSeq"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := db.View("", func(tx *dbutil.Tx) error {
+				page, err := hisDB.GetTransactionsPageForAddress(tx, tc.addr, tc.params)
+				if tc.err != nil {
+					require.Equal(t, tc.err, err)
+					return nil
+				}
+
+				require.NoError(t, err)
+				require.Len(t, page.Transactions, len(tc.txns))
+				if len(tc.txns) > 0 {
+					require.Equal(t, tc.txns, page.Transactions)
+				}
+				require.Equal(t, tc.next, page.Next)
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAddressTxnsResetRemovesLegacyBucket(t *testing.T) {
+	db, td := prepareDB(t)
+	defer td()
+
+	addrTxns := &addressTxns{}
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		if _, err := tx.CreateBucket(legacyAddressTxnsBkt); err != nil {
+			return err
+		}
+
+		return addrTxns.add(tx, makeAddress(), 1, 0, cipher.SumSHA256([]byte("tx")))
+	})
+	require.NoError(t, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return addrTxns.reset(tx)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		require.False(t, dbutil.Exists(tx, legacyAddressTxnsBkt))
+
+		empty, err := addrTxns.isEmpty(tx)
+		require.NoError(t, err)
+		require.True(t, empty)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -55,6 +55,30 @@ func (au *addressUx) add(tx *dbutil.Tx, address cipher.Address, uxHash cipher.SH
 	return dbutil.PutBucketValue(tx, AddressUxBkt, address.Bytes(), buf)
 }
 
+// remove removes a hash from an address's hash list
+func (au *addressUx) remove(tx *dbutil.Tx, address cipher.Address, uxHash cipher.SHA256) error {
+	hashes, err := au.get(tx, address)
+	if err != nil {
+		return err
+	}
+
+	hashes = removeHash(hashes, uxHash)
+
+	// Delete the row if no hashes remain
+	if len(hashes) == 0 {
+		return dbutil.Delete(tx, AddressUxBkt, address.Bytes())
+	}
+
+	buf, err := encodeHashesWrapper(&hashesWrapper{
+		Hashes: hashes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, AddressUxBkt, address.Bytes(), buf)
+}
+
 // isEmpty checks if the addressUx bucket is empty
 func (au *addressUx) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, AddressUxBkt)
@@ -64,3 +88,14 @@ func (au *addressUx) isEmpty(tx *dbutil.Tx) (bool, error) {
 func (au *addressUx) reset(tx *dbutil.Tx) error {
 	return dbutil.Reset(tx, AddressUxBkt)
 }
+
+// removeHash returns hashes without hash
+func removeHash(hashes []cipher.SHA256, hash cipher.SHA256) []cipher.SHA256 {
+	newHashes := make([]cipher.SHA256, 0, len(hashes))
+	for _, h := range hashes {
+		if h != hash {
+			newHashes = append(newHashes, h)
+		}
+	}
+	return newHashes
+}
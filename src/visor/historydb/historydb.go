@@ -21,6 +21,8 @@ func CreateBuckets(tx *dbutil.Tx) error {
 	return dbutil.CreateBuckets(tx, [][]byte{
 		AddressTxnsBkt,
 		AddressUxBkt,
+		AddressSummaryBkt,
+		SupplyStatsBkt,
 		HistoryMetaBkt,
 		UxOutsBkt,
 		TransactionsBkt,
@@ -29,11 +31,13 @@ func CreateBuckets(tx *dbutil.Tx) error {
 
 // HistoryDB provides APIs for blockchain explorer
 type HistoryDB struct {
-	outputs  *uxOuts       // outputs bucket
-	txns     *transactions // transactions bucket
-	addrUx   *addressUx    // bucket which stores all UxOuts that address received
-	addrTxns *addressTxns  // address related transaction bucket
-	meta     *historyMeta  // stores history meta info
+	outputs  *uxOuts           // outputs bucket
+	txns     *transactions     // transactions bucket
+	addrUx   *addressUx        // bucket which stores all UxOuts that address received
+	addrTxns *addressTxns      // address related transaction bucket
+	addrSums *addressSummaries // aggregates of each address's history
+	supply   *supplyStats      // supply statistics of each block
+	meta     *historyMeta      // stores history meta info
 }
 
 // New create HistoryDB instance
@@ -43,6 +47,8 @@ func New() *HistoryDB {
 		txns:     &transactions{},
 		addrUx:   &addressUx{},
 		addrTxns: &addressTxns{},
+		addrSums: &addressSummaries{},
+		supply:   &supplyStats{},
 		meta:     &historyMeta{},
 	}
 }
@@ -79,7 +85,17 @@ func (hd *HistoryDB) NeedsReset(tx *dbutil.Tx) (bool, error) {
 		return false, err
 	}
 
-	if addrTxnsEmpty || addrUxEmpty || txnsEmpty || outputsEmpty {
+	addrSumsEmpty, err := hd.addrSums.isEmpty(tx)
+	if err != nil {
+		return false, err
+	}
+
+	supplyEmpty, err := hd.supply.isEmpty(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if addrTxnsEmpty || addrUxEmpty || txnsEmpty || outputsEmpty || addrSumsEmpty || supplyEmpty {
 		return true, nil
 	}
 
@@ -97,6 +113,14 @@ func (hd *HistoryDB) Erase(tx *dbutil.Tx) error {
 		return err
 	}
 
+	if err := hd.addrSums.reset(tx); err != nil {
+		return err
+	}
+
+	if err := hd.supply.reset(tx); err != nil {
+		return err
+	}
+
 	if err := hd.outputs.reset(tx); err != nil {
 		return err
 	}
@@ -123,20 +147,100 @@ func (hd *HistoryDB) GetUxOuts(tx *dbutil.Tx, uxIDs []cipher.SHA256) ([]UxOut, e
 	return hd.outputs.getArray(tx, uxIDs)
 }
 
-// ParseBlock builds indexes out of the block data
-func (hd *HistoryDB) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
-	for _, t := range b.Body.Transactions {
+// PreparedBlock is a block with the hashes and encoded values that parsing it writes to the historydb.
+// Preparing a block does not read the db, so blocks can be prepared concurrently and then parsed in order.
+type PreparedBlock struct {
+	Block coin.Block
+	txns  []preparedTxn
+}
+
+type preparedTxn struct {
+	hash    cipher.SHA256
+	encoded []byte
+	outputs []preparedUxOut
+}
+
+type preparedUxOut struct {
+	out     coin.UxOut
+	hash    cipher.SHA256
+	encoded []byte
+}
+
+// PrepareBlock computes the transaction and output hashes of a block and encodes them for the historydb
+func PrepareBlock(b coin.Block) (*PreparedBlock, error) {
+	pb := &PreparedBlock{
+		Block: b,
+		txns:  make([]preparedTxn, len(b.Body.Transactions)),
+	}
+
+	for i, t := range b.Body.Transactions {
 		txn := Transaction{
 			Txn:      t,
 			BlockSeq: b.Seq(),
 		}
 
-		spentTxnID := t.Hash()
+		encoded, err := encodeTransaction(&txn)
+		if err != nil {
+			return nil, err
+		}
+
+		uxArray := coin.CreateUnspents(b.Head, t)
+		outputs := make([]preparedUxOut, len(uxArray))
+		for j, ux := range uxArray {
+			encodedUx, err := encodeUxOut(&UxOut{
+				Out: ux,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			outputs[j] = preparedUxOut{
+				out:     ux,
+				hash:    ux.Hash(),
+				encoded: encodedUx,
+			}
+		}
+
+		pb.txns[i] = preparedTxn{
+			hash:    t.Hash(),
+			encoded: encoded,
+			outputs: outputs,
+		}
+	}
+
+	return pb, nil
+}
+
+// ParseBlock builds indexes out of the block data
+func (hd *HistoryDB) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
+	pb, err := PrepareBlock(b)
+	if err != nil {
+		return err
+	}
 
-		if err := hd.txns.put(tx, &txn); err != nil {
+	return hd.ParsePreparedBlock(tx, pb)
+}
+
+// ParsePreparedBlock builds indexes out of the data of a prepared block.
+// Blocks must be parsed in order.
+func (hd *HistoryDB) ParsePreparedBlock(tx *dbutil.Tx, pb *PreparedBlock) error {
+	b := pb.Block
+
+	stats, err := hd.newSupplyStatsBuilder(tx, b)
+	if err != nil {
+		return err
+	}
+
+	for i, pt := range pb.txns {
+		t := b.Body.Transactions[i]
+		spentTxnID := pt.hash
+
+		if err := hd.txns.putEncoded(tx, spentTxnID, pt.encoded); err != nil {
 			return err
 		}
 
+		deltas := make(map[cipher.Address]addressSummaryDelta)
+
 		for _, in := range t.In {
 			o, err := hd.outputs.get(tx, in)
 			if err != nil {
@@ -155,31 +259,152 @@ func (hd *HistoryDB) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
 			}
 
 			// store the IN address with txid
-			if err := hd.addrTxns.add(tx, o.Out.Body.Address, spentTxnID); err != nil {
+			if err := hd.addrTxns.add(tx, o.Out.Body.Address, b.Seq(), uint32(i), spentTxnID); err != nil {
+				return err
+			}
+
+			d := deltas[o.Out.Body.Address]
+			d.sent += o.Out.Body.Coins
+			deltas[o.Out.Body.Address] = d
+
+			if err := stats.stats.removeOutput(o.Out); err != nil {
 				return err
 			}
 		}
 
 		// handle the tx out
+		for _, po := range pt.outputs {
+			ux := po.out
+
+			if err := hd.outputs.putEncoded(tx, po.hash, po.encoded); err != nil {
+				return err
+			}
+
+			if err := hd.addrUx.add(tx, ux.Body.Address, po.hash); err != nil {
+				return err
+			}
+
+			if err := hd.addrTxns.add(tx, ux.Body.Address, b.Seq(), uint32(i), spentTxnID); err != nil {
+				return err
+			}
+
+			d := deltas[ux.Body.Address]
+			d.received += ux.Body.Coins
+			deltas[ux.Body.Address] = d
+
+			if err := stats.stats.addOutput(ux); err != nil {
+				return err
+			}
+		}
+
+		if err := stats.touch(tx, hd, deltas); err != nil {
+			return err
+		}
+
+		if err := hd.addrSums.apply(tx, b.Seq(), deltas); err != nil {
+			return err
+		}
+	}
+
+	s, err := stats.finish(tx, hd)
+	if err != nil {
+		return err
+	}
+
+	if err := hd.supply.put(tx, s); err != nil {
+		return err
+	}
+
+	return hd.SetParsedBlockSeq(tx, b.Seq())
+}
+
+// RollbackBlock reverts the indexes built by ParseBlock.
+// The block must be the last parsed block.
+func (hd *HistoryDB) RollbackBlock(tx *dbutil.Tx, b coin.Block) error {
+	if b.Seq() == 0 {
+		return errors.New("HistoryDB.RollbackBlock: cannot roll back the genesis block")
+	}
+
+	parsedBlockSeq, ok, err := hd.meta.parsedBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if !ok || parsedBlockSeq != b.Seq() {
+		return fmt.Errorf("HistoryDB.RollbackBlock: block %d is not the last parsed block", b.Seq())
+	}
+
+	// Undo the transactions in reverse order
+	for i := len(b.Body.Transactions) - 1; i >= 0; i-- {
+		t := b.Body.Transactions[i]
+		txnHash := t.Hash()
+
+		deltas := make(map[cipher.Address]addressSummaryDelta)
+
+		// remove the outputs created by the transaction
 		uxArray := coin.CreateUnspents(b.Head, t)
 		for _, ux := range uxArray {
-			if err := hd.outputs.put(tx, UxOut{
-				Out: ux,
-			}); err != nil {
+			uxHash := ux.Hash()
+
+			if err := hd.outputs.delete(tx, uxHash); err != nil {
+				return err
+			}
+
+			if err := hd.addrUx.remove(tx, ux.Body.Address, uxHash); err != nil {
+				return err
+			}
+
+			if err := hd.addrTxns.remove(tx, ux.Body.Address, b.Seq(), uint32(i)); err != nil {
+				return err
+			}
+
+			d := deltas[ux.Body.Address]
+			d.received += ux.Body.Coins
+			deltas[ux.Body.Address] = d
+		}
+
+		// mark the inputs as unspent
+		for _, in := range t.In {
+			o, err := hd.outputs.get(tx, in)
+			if err != nil {
 				return err
 			}
 
-			if err := hd.addrUx.add(tx, ux.Body.Address, ux.Hash()); err != nil {
+			if o == nil {
+				return errors.New("HistoryDB.RollbackBlock: transaction input not found in outputs bucket")
+			}
+
+			o.SpentBlockSeq = 0
+			o.SpentTxnID = cipher.SHA256{}
+			if err := hd.outputs.put(tx, *o); err != nil {
 				return err
 			}
 
-			if err := hd.addrTxns.add(tx, ux.Body.Address, spentTxnID); err != nil {
+			if err := hd.addrTxns.remove(tx, o.Out.Body.Address, b.Seq(), uint32(i)); err != nil {
 				return err
 			}
+
+			d := deltas[o.Out.Body.Address]
+			d.sent += o.Out.Body.Coins
+			deltas[o.Out.Body.Address] = d
+		}
+
+		if err := hd.txns.delete(tx, txnHash); err != nil {
+			return err
+		}
+
+		if err := hd.addrSums.revert(tx, deltas, func(addr cipher.Address) (uint64, error) {
+			return hd.lastTxnSeq(tx, addr)
+		}); err != nil {
+			return err
 		}
 	}
 
-	return hd.SetParsedBlockSeq(tx, b.Seq())
+	if err := hd.supply.delete(tx, b.Seq()); err != nil {
+		return err
+	}
+
+	return hd.SetParsedBlockSeq(tx, b.Seq()-1)
 }
 
 // GetTransaction get transaction by hash.
@@ -197,6 +422,34 @@ func (hd HistoryDB) GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address)
 	return hd.outputs.getArray(tx, hashes)
 }
 
+// lastTxnSeq returns the block seq of the last transaction of an address
+func (hd HistoryDB) lastTxnSeq(tx *dbutil.Tx, addr cipher.Address) (uint64, error) {
+	seq, ok, err := hd.addrTxns.lastSeq(tx, addr)
+	if err != nil {
+		return 0, err
+	} else if !ok {
+		return 0, fmt.Errorf("HistoryDB.lastTxnSeq: address %s has no transactions", addr)
+	}
+
+	return seq, nil
+}
+
+// GetAddressSummary returns the aggregates of an address's history, or nil if the address has no history
+func (hd HistoryDB) GetAddressSummary(tx *dbutil.Tx, addr cipher.Address) (*AddressSummary, error) {
+	return hd.addrSums.get(tx, addr)
+}
+
+// GetSupplyStats returns the supply statistics of the parsed blocks from start to end seq, inclusive
+func (hd HistoryDB) GetSupplyStats(tx *dbutil.Tx, start, end uint64) ([]SupplyStats, error) {
+	return hd.supply.getRange(tx, start, end)
+}
+
+// ForEachSupplyStats calls f with the supply statistics of the parsed blocks from start to end seq, inclusive, in seq order.
+// The scan stops at the first error returned by f, which is returned.
+func (hd HistoryDB) ForEachSupplyStats(tx *dbutil.Tx, start, end uint64, f func(SupplyStats) error) error {
+	return hd.supply.forEachInRange(tx, start, end, f)
+}
+
 // GetTransactionsForAddress returns all the address related transactions
 func (hd HistoryDB) GetTransactionsForAddress(tx *dbutil.Tx, address cipher.Address) ([]Transaction, error) {
 	hashes, err := hd.addrTxns.get(tx, address)
@@ -207,6 +460,42 @@ func (hd HistoryDB) GetTransactionsForAddress(tx *dbutil.Tx, address cipher.Addr
 	return hd.txns.getArray(tx, hashes)
 }
 
+// GetTransactionsPageForAddress returns a page of the address related transactions
+func (hd HistoryDB) GetTransactionsPageForAddress(tx *dbutil.Tx, address cipher.Address, p AddressTxnsPageParams) (*AddressTxnsPage, error) {
+	// The cursor is found among the address's transactions in the block of the cursor transaction
+	var afterSeq uint64
+	if p.After != nil {
+		txn, err := hd.txns.get(tx, *p.After)
+		if err != nil {
+			return nil, err
+		} else if txn == nil {
+			return nil, ErrAddressTxnsCursorNotFound
+		}
+		afterSeq = txn.BlockSeq
+	}
+
+	hashes, more, err := hd.addrTxns.page(tx, address, p, afterSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	txns, err := hd.txns.getArray(tx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AddressTxnsPage{
+		Transactions: txns,
+	}
+
+	if more {
+		next := hashes[len(hashes)-1]
+		page.Next = &next
+	}
+
+	return page, nil
+}
+
 // ForEachTxn traverses the transactions bucket
 func (hd HistoryDB) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *Transaction) error) error {
 	return hd.txns.forEach(tx, f)
@@ -482,3 +482,112 @@ func newBlockHeader(prev coin.BlockHeader, uxHash cipher.SHA256, currentTime, fe
 		UxHash:   uxHash,
 	}
 }
+
+func TestRollbackBlock(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+	bc := newBlockchain()
+	gb := bc.CreateGenesisBlock(genAddress, genCoins, genTime)
+
+	hisDB := New()
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return hisDB.ParseBlock(tx, gb)
+	})
+	require.NoError(t, err)
+
+	td := testData{
+		PreBlockHash: gb.HashHeader(),
+		Vin: txIn{
+			SigKey:   genSecret.Hex(),
+			Addr:     genAddress.String(),
+			TxID:     gb.Body.Transactions[0].Hash(),
+			BlockSeq: 0,
+		},
+		Vouts: []txOut{
+			{
+				ToAddr: "2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS",
+				Coins:  10e6,
+				Hours:  100,
+			},
+			{
+				ToAddr: genAddress.String(),
+				Coins:  genCoins - 10e6,
+				Hours:  400,
+			},
+		},
+	}
+
+	b, txn, err := addBlock(bc, td, incTime)
+	require.NoError(t, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return hisDB.ParseBlock(tx, *b)
+	})
+	require.NoError(t, err)
+
+	genUxID := gb.Body.Transactions[0].Hash()
+	genUxHash := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])[0].Hash()
+	toAddr := cipher.MustDecodeBase58Address("2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS")
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		// The genesis block can't be rolled back
+		err := hisDB.RollbackBlock(tx, gb)
+		require.Equal(t, errors.New("HistoryDB.RollbackBlock: cannot roll back the genesis block"), err)
+
+		// Only the last parsed block can be rolled back
+		b2 := *b
+		b2.Head.BkSeq = 2
+		err = hisDB.RollbackBlock(tx, b2)
+		require.Equal(t, errors.New("HistoryDB.RollbackBlock: block 2 is not the last parsed block"), err)
+
+		return hisDB.RollbackBlock(tx, *b)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		seq, ok, err := hisDB.ParsedBlockSeq(tx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(0), seq)
+
+		// The block's transaction and outputs are removed
+		txnInDB, err := hisDB.GetTransaction(tx, txn.Hash())
+		require.NoError(t, err)
+		require.Nil(t, txnInDB)
+
+		for _, ux := range coin.CreateUnspents(b.Head, *txn) {
+			_, err := hisDB.GetUxOuts(tx, []cipher.SHA256{ux.Hash()})
+			require.Equal(t, NewErrUxOutNotExist(ux.Hash().Hex()), err)
+		}
+
+		txns, err := hisDB.GetTransactionsForAddress(tx, toAddr)
+		require.NoError(t, err)
+		require.Empty(t, txns)
+
+		outs, err := hisDB.GetOutputsForAddress(tx, toAddr)
+		require.NoError(t, err)
+		require.Empty(t, outs)
+
+		// The genesis address keeps the genesis transaction and output
+		txns, err = hisDB.GetTransactionsForAddress(tx, genAddress)
+		require.NoError(t, err)
+		require.Len(t, txns, 1)
+		require.Equal(t, genUxID, txns[0].Hash())
+
+		outs, err = hisDB.GetOutputsForAddress(tx, genAddress)
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		require.Equal(t, genUxHash, outs[0].Hash())
+
+		// The spent input is unspent again
+		uxs, err := hisDB.GetUxOuts(tx, []cipher.SHA256{genUxHash})
+		require.NoError(t, err)
+		require.Len(t, uxs, 1)
+		require.Equal(t, uint64(0), uxs[0].SpentBlockSeq)
+		require.Equal(t, cipher.SHA256{}, uxs[0].SpentTxnID)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
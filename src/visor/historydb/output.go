@@ -53,6 +53,11 @@ func (ux *uxOuts) put(tx *dbutil.Tx, out UxOut) error {
 		return err
 	}
 
+	return ux.putEncoded(tx, hash, buf)
+}
+
+// putEncoded sets an encoded out value
+func (ux *uxOuts) putEncoded(tx *dbutil.Tx, hash cipher.SHA256, buf []byte) error {
 	return dbutil.PutBucketValue(tx, UxOutsBkt, hash[:], buf)
 }
 
@@ -91,6 +96,11 @@ func (ux *uxOuts) getArray(tx *dbutil.Tx, uxIDs []cipher.SHA256) ([]UxOut, error
 	return outs, nil
 }
 
+// delete removes the UxOut of given id
+func (ux *uxOuts) delete(tx *dbutil.Tx, uxID cipher.SHA256) error {
+	return dbutil.Delete(tx, UxOutsBkt, uxID[:])
+}
+
 // isEmpty checks if the uxout bucekt is empty
 func (ux *uxOuts) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, UxOutsBkt)
@@ -0,0 +1,268 @@
+package historydb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// SupplyStatsBkt maps block seqs to the supply statistics after the block was executed
+var SupplyStatsBkt = []byte("supply_stats")
+
+// SupplyStats are the statistics of the blockchain and its unspent outputs after a block was executed.
+// The stats of a block are built from the stats of the previous block, so they are kept without scanning the unspent outputs.
+type SupplyStats struct {
+	// BlockSeq is the seq of the block
+	BlockSeq uint64
+	// BlockTime is the time of the block
+	BlockTime uint64
+	// TxnCount is the number of transactions in the block
+	TxnCount uint64
+	// BurnedHours is the number of coin hours burned by the transactions of the block, which is the fee of the block
+	BurnedHours uint64
+	// TotalTxnCount is the number of transactions in the blocks up to and including the block
+	TotalTxnCount uint64
+	// TotalBurnedHours is the number of coin hours burned by the blocks up to and including the block
+	TotalBurnedHours uint64
+	// ActiveAddresses is the number of addresses with a nonzero balance
+	ActiveAddresses uint64
+	// UnspentCoins is the total of the coins of the unspent outputs, in droplets
+	UnspentCoins uint64
+	// UnspentHours is the total of the hours of the unspent outputs when they were created
+	UnspentHours uint64
+	// UnspentWholeCoins is the total of the whole coins of the unspent outputs
+	UnspentWholeCoins uint64
+	// UnspentWholeCoinSeconds is the total of the whole coins of each unspent output
+	// multiplied by the time of the block that created it
+	UnspentWholeCoinSeconds uint64
+}
+
+// CoinHours returns the coin hours of the unspent outputs at time t.
+// They are calculated from the totals of the unspent outputs, so the coin hours earned by
+// droplets and the rounding of the coin hours of each output are not included.
+func (s SupplyStats) CoinHours(t uint64) (uint64, error) {
+	if t < s.BlockTime {
+		return s.UnspentHours, nil
+	}
+
+	coinSeconds, err := mathutil.MultUint64(s.UnspentWholeCoins, t)
+	if err != nil {
+		return 0, err
+	}
+
+	if coinSeconds < s.UnspentWholeCoinSeconds {
+		return 0, errors.New("SupplyStats.CoinHours: unspent coin seconds underflow")
+	}
+
+	return mathutil.AddUint64(s.UnspentHours, (coinSeconds-s.UnspentWholeCoinSeconds)/3600)
+}
+
+// addOutput adds a new unspent output to the totals
+func (s *SupplyStats) addOutput(ux coin.UxOut) error {
+	wholeCoins := ux.Body.Coins / droplet.Multiplier
+	wholeCoinSeconds, err := mathutil.MultUint64(wholeCoins, ux.Head.Time)
+	if err != nil {
+		return err
+	}
+
+	if s.UnspentCoins, err = mathutil.AddUint64(s.UnspentCoins, ux.Body.Coins); err != nil {
+		return err
+	}
+
+	if s.UnspentHours, err = mathutil.AddUint64(s.UnspentHours, ux.Body.Hours); err != nil {
+		return err
+	}
+
+	if s.UnspentWholeCoins, err = mathutil.AddUint64(s.UnspentWholeCoins, wholeCoins); err != nil {
+		return err
+	}
+
+	s.UnspentWholeCoinSeconds, err = mathutil.AddUint64(s.UnspentWholeCoinSeconds, wholeCoinSeconds)
+	return err
+}
+
+// removeOutput removes a spent output from the totals
+func (s *SupplyStats) removeOutput(ux coin.UxOut) error {
+	wholeCoins := ux.Body.Coins / droplet.Multiplier
+	wholeCoinSeconds := wholeCoins * ux.Head.Time
+
+	if s.UnspentCoins < ux.Body.Coins ||
+		s.UnspentHours < ux.Body.Hours ||
+		s.UnspentWholeCoins < wholeCoins ||
+		s.UnspentWholeCoinSeconds < wholeCoinSeconds {
+		return fmt.Errorf("SupplyStats.removeOutput: output %s is not included in the unspent totals", ux.Hash().Hex())
+	}
+
+	s.UnspentCoins -= ux.Body.Coins
+	s.UnspentHours -= ux.Body.Hours
+	s.UnspentWholeCoins -= wholeCoins
+	s.UnspentWholeCoinSeconds -= wholeCoinSeconds
+
+	return nil
+}
+
+// supplyStats bucket stores the supply statistics of each block, block seq as key, SupplyStats as value
+type supplyStats struct{}
+
+// get returns the supply statistics of block seq, or nil if the block has not been parsed
+func (ss *supplyStats) get(tx *dbutil.Tx, seq uint64) (*SupplyStats, error) {
+	var s SupplyStats
+	if ok, err := dbutil.GetBucketObjectDecoded(tx, SupplyStatsBkt, dbutil.Itob(seq), &s); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// getRange returns the supply statistics of the blocks from start to end seq, inclusive
+func (ss *supplyStats) getRange(tx *dbutil.Tx, start, end uint64) ([]SupplyStats, error) {
+	var stats []SupplyStats
+	if err := ss.forEachInRange(tx, start, end, func(s SupplyStats) error {
+		stats = append(stats, s)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// forEachInRange calls f with the supply statistics of the blocks from start to end seq, inclusive, in seq order.
+// The scan stops at the first error returned by f.
+func (ss *supplyStats) forEachInRange(tx *dbutil.Tx, start, end uint64, f func(SupplyStats) error) error {
+	b := tx.Bucket(SupplyStatsBkt)
+	if b == nil {
+		return dbutil.NewErrBucketNotExist(SupplyStatsBkt)
+	}
+
+	c := b.Cursor()
+	for k, v := c.Seek(dbutil.Itob(start)); k != nil && dbutil.Btoi(k) <= end; k, v = c.Next() {
+		var s SupplyStats
+		if err := encoder.DeserializeRawExact(v, &s); err != nil {
+			return err
+		}
+
+		if err := f(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// put saves the supply statistics of a block
+func (ss *supplyStats) put(tx *dbutil.Tx, s SupplyStats) error {
+	return dbutil.PutBucketValue(tx, SupplyStatsBkt, dbutil.Itob(s.BlockSeq), encoder.Serialize(s))
+}
+
+// delete removes the supply statistics of block seq
+func (ss *supplyStats) delete(tx *dbutil.Tx, seq uint64) error {
+	return dbutil.Delete(tx, SupplyStatsBkt, dbutil.Itob(seq))
+}
+
+// isEmpty checks if the supply stats bucket is empty
+func (ss *supplyStats) isEmpty(tx *dbutil.Tx) (bool, error) {
+	return dbutil.IsEmpty(tx, SupplyStatsBkt)
+}
+
+// reset resets the bucket
+func (ss *supplyStats) reset(tx *dbutil.Tx) error {
+	return dbutil.Reset(tx, SupplyStatsBkt)
+}
+
+// supplyStatsBuilder builds the supply statistics of a block while it is parsed
+type supplyStatsBuilder struct {
+	stats SupplyStats
+	// wasActive records if each address touched by the block had a nonzero balance before the block
+	wasActive map[cipher.Address]bool
+}
+
+// newSupplyStatsBuilder starts the supply statistics of block b from the statistics of the previous block
+func (hd *HistoryDB) newSupplyStatsBuilder(tx *dbutil.Tx, b coin.Block) (*supplyStatsBuilder, error) {
+	var stats SupplyStats
+	if b.Seq() > 0 {
+		prev, err := hd.supply.get(tx, b.Seq()-1)
+		if err != nil {
+			return nil, err
+		} else if prev == nil {
+			return nil, fmt.Errorf("HistoryDB.ParseBlock: supply stats of block %d not found", b.Seq()-1)
+		}
+		stats = *prev
+	}
+
+	stats.BlockSeq = b.Seq()
+	stats.BlockTime = b.Time()
+	stats.TxnCount = uint64(len(b.Body.Transactions))
+	stats.BurnedHours = b.Head.Fee
+	stats.TotalTxnCount += stats.TxnCount
+
+	var err error
+	stats.TotalBurnedHours, err = mathutil.AddUint64(stats.TotalBurnedHours, b.Head.Fee)
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplyStatsBuilder{
+		stats:     stats,
+		wasActive: make(map[cipher.Address]bool),
+	}, nil
+}
+
+// touch records if the addresses of a transaction had a nonzero balance before the block.
+// It must be called before the deltas are applied to the address summaries.
+func (sb *supplyStatsBuilder) touch(tx *dbutil.Tx, hd *HistoryDB, deltas map[cipher.Address]addressSummaryDelta) error {
+	for addr := range deltas {
+		if _, ok := sb.wasActive[addr]; ok {
+			continue
+		}
+
+		active, err := hd.isActiveAddress(tx, addr)
+		if err != nil {
+			return err
+		}
+
+		sb.wasActive[addr] = active
+	}
+
+	return nil
+}
+
+// finish updates the number of active addresses from the address summaries after the block was applied
+func (sb *supplyStatsBuilder) finish(tx *dbutil.Tx, hd *HistoryDB) (SupplyStats, error) {
+	for addr, wasActive := range sb.wasActive {
+		active, err := hd.isActiveAddress(tx, addr)
+		if err != nil {
+			return SupplyStats{}, err
+		}
+
+		switch {
+		case active && !wasActive:
+			sb.stats.ActiveAddresses++
+		case !active && wasActive:
+			if sb.stats.ActiveAddresses == 0 {
+				return SupplyStats{}, errors.New("supplyStatsBuilder.finish: active addresses underflow")
+			}
+			sb.stats.ActiveAddresses--
+		}
+	}
+
+	return sb.stats, nil
+}
+
+// isActiveAddress returns true if the address has a nonzero balance
+func (hd *HistoryDB) isActiveAddress(tx *dbutil.Tx, addr cipher.Address) (bool, error) {
+	s, err := hd.addrSums.get(tx, addr)
+	if err != nil {
+		return false, err
+	}
+
+	return s != nil && s.Received > s.Sent, nil
+}
@@ -0,0 +1,163 @@
+package historydb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestSupplyStats(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+	bc := newBlockchain()
+	gb := bc.CreateGenesisBlock(genAddress, genCoins, genTime)
+
+	hisDB := New()
+
+	requireStats := func(start, end uint64, expect []SupplyStats) {
+		t.Helper()
+		err := db.View("", func(tx *dbutil.Tx) error {
+			stats, err := hisDB.GetSupplyStats(tx, start, end)
+			require.NoError(t, err)
+			require.Equal(t, expect, stats)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	parseBlock := func(b coin.Block) {
+		t.Helper()
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return hisDB.ParseBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+
+	rollbackBlock := func(b coin.Block) {
+		t.Helper()
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return hisDB.RollbackBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+
+	toPubKey, toSecKey := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(toPubKey)
+	otherAddr := makeAddress()
+
+	parseBlock(gb)
+
+	gbStats := SupplyStats{
+		BlockSeq:                0,
+		BlockTime:               genTime,
+		TxnCount:                1,
+		TotalTxnCount:           1,
+		ActiveAddresses:         1,
+		UnspentCoins:            genCoins,
+		UnspentHours:            genCoins,
+		UnspentWholeCoins:       1000,
+		UnspentWholeCoinSeconds: 1000 * genTime,
+	}
+	requireStats(0, 10, []SupplyStats{gbStats})
+
+	// The genesis address sends coins to toAddr, with change to itself
+	b1, txn1, err := addBlock(bc, testData{
+		PreBlockHash: gb.HashHeader(),
+		Vin: txIn{
+			SigKey:   genSecret.Hex(),
+			Addr:     genAddress.String(),
+			TxID:     gb.Body.Transactions[0].Hash(),
+			BlockSeq: 0,
+		},
+		Vouts: []txOut{
+			{
+				ToAddr: toAddr.String(),
+				Coins:  10e6,
+				Hours:  100,
+			},
+			{
+				ToAddr: genAddress.String(),
+				Coins:  genCoins - 10e6,
+				Hours:  400,
+			},
+		},
+	}, incTime)
+	require.NoError(t, err)
+	parseBlock(*b1)
+
+	b1Stats := SupplyStats{
+		BlockSeq:                1,
+		BlockTime:               incTime,
+		TxnCount:                1,
+		BurnedHours:             b1.Head.Fee,
+		TotalTxnCount:           2,
+		TotalBurnedHours:        b1.Head.Fee,
+		ActiveAddresses:         2,
+		UnspentCoins:            genCoins,
+		UnspentHours:            500,
+		UnspentWholeCoins:       1000,
+		UnspentWholeCoinSeconds: 1000 * incTime,
+	}
+	requireStats(0, 10, []SupplyStats{gbStats, b1Stats})
+
+	// toAddr sends all of its coins to otherAddr
+	b2, _, err := addBlock(bc, testData{
+		PreBlockHash: b1.HashHeader(),
+		Vin: txIn{
+			SigKey:   toSecKey.Hex(),
+			Addr:     toAddr.String(),
+			TxID:     txn1.Hash(),
+			BlockSeq: 1,
+		},
+		Vouts: []txOut{
+			{
+				ToAddr: otherAddr.String(),
+				Coins:  10e6,
+				Hours:  20,
+			},
+		},
+	}, incTime*2)
+	require.NoError(t, err)
+	parseBlock(*b2)
+
+	b2Stats := SupplyStats{
+		BlockSeq:                2,
+		BlockTime:               incTime * 2,
+		TxnCount:                1,
+		BurnedHours:             b2.Head.Fee,
+		TotalTxnCount:           3,
+		TotalBurnedHours:        b1.Head.Fee + b2.Head.Fee,
+		ActiveAddresses:         2,
+		UnspentCoins:            genCoins,
+		UnspentHours:            420,
+		UnspentWholeCoins:       1000,
+		UnspentWholeCoinSeconds: 990*incTime + 10*incTime*2,
+	}
+	requireStats(0, 10, []SupplyStats{gbStats, b1Stats, b2Stats})
+	requireStats(1, 1, []SupplyStats{b1Stats})
+	requireStats(3, 10, nil)
+
+	// The coin hours are earned by the whole coins of the unspent outputs
+	hours, err := b2Stats.CoinHours(incTime*2 + 3600)
+	require.NoError(t, err)
+	require.Equal(t, uint64(420+(990*(incTime+3600)+10*3600)/3600), hours)
+
+	// Rolling back a block removes its stats
+	rollbackBlock(*b2)
+	requireStats(0, 10, []SupplyStats{gbStats, b1Stats})
+
+	// The stats are rebuilt by a reset
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		require.NoError(t, hisDB.supply.reset(tx))
+
+		needsReset, err := hisDB.NeedsReset(tx)
+		require.NoError(t, err)
+		require.True(t, needsReset)
+		return nil
+	})
+	require.NoError(t, err)
+}
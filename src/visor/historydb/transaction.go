@@ -40,6 +40,11 @@ func (txs *transactions) put(tx *dbutil.Tx, txn *Transaction) error {
 		return err
 	}
 
+	return txs.putEncoded(tx, hash, buf)
+}
+
+// putEncoded puts an encoded transaction in the db
+func (txs *transactions) putEncoded(tx *dbutil.Tx, hash cipher.SHA256, buf []byte) error {
 	return dbutil.PutBucketValue(tx, TransactionsBkt, hash[:], buf)
 }
 
@@ -79,6 +84,11 @@ func (txs *transactions) getArray(tx *dbutil.Tx, hashes []cipher.SHA256) ([]Tran
 	return txns, nil
 }
 
+// delete removes the transaction of given hash
+func (txs *transactions) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, TransactionsBkt, hash[:])
+}
+
 // isEmpty checks if transaction bucket is empty
 func (txs *transactions) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, TransactionsBkt)
@@ -21,32 +21,6 @@ func VerifyDBSkyencoderSafe(tx *dbutil.Tx, quit <-chan struct{}) error {
 		quit = make(chan struct{})
 	}
 
-	if err := dbutil.ForEach(tx, AddressTxnsBkt, func(_, v []byte) error {
-		select {
-		case <-quit:
-			return ErrVerifyStopped
-		default:
-		}
-
-		var b1 hashesWrapper
-		if err := decodeHashesWrapperExact(v, &b1); err != nil {
-			return err
-		}
-
-		var b2 []cipher.SHA256
-		if err := encoder.DeserializeRawExact(v, &b2); err != nil {
-			return err
-		}
-
-		if !reflect.DeepEqual(b1.Hashes, b2) {
-			return errors.New("AddressTxnsBkt sha256 hashes mismatch")
-		}
-
-		return nil
-	}); err != nil {
-		return err
-	}
-
 	if err := dbutil.ForEach(tx, AddressUxBkt, func(_, v []byte) error {
 		select {
 		case <-quit:
@@ -1,6 +1,8 @@
 package visor
 
 import (
+	"time"
+
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/params"
@@ -17,9 +19,13 @@ import (
 type Historyer interface {
 	GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]historydb.UxOut, error)
 	ParseBlock(tx *dbutil.Tx, b coin.Block) error
+	RollbackBlock(tx *dbutil.Tx, b coin.Block) error
 	GetTransaction(tx *dbutil.Tx, hash cipher.SHA256) (*historydb.Transaction, error)
 	GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.UxOut, error)
 	GetTransactionsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.Transaction, error)
+	GetTransactionsPageForAddress(tx *dbutil.Tx, address cipher.Address, p historydb.AddressTxnsPageParams) (*historydb.AddressTxnsPage, error)
+	GetAddressSummary(tx *dbutil.Tx, addr cipher.Address) (*historydb.AddressSummary, error)
+	ForEachSupplyStats(tx *dbutil.Tx, start, end uint64, f func(historydb.SupplyStats) error) error
 	NeedsReset(tx *dbutil.Tx) (bool, error)
 	Erase(tx *dbutil.Tx) error
 	ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error)
@@ -41,7 +47,13 @@ type Blockchainer interface {
 	Time(tx *dbutil.Tx) (uint64, error)
 	NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime uint64) (*coin.Block, error)
 	ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
+	IsForkBlock(tx *dbutil.Tx, b coin.Block) (bool, error)
+	ExecuteForkBlock(tx *dbutil.Tx, sb *coin.SignedBlock) (*Reorganization, error)
+	PrunedSeq(tx *dbutil.Tx) (uint64, bool, error)
+	PruneBlocks(tx *dbutil.Tx, seq uint64) error
 	VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction) error
+	VerifyPendingBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error
+	VerifySignature(block *coin.SignedBlock) error
 	VerifySingleTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, signed TxnSignedFlag) error
 	VerifySingleTxnSoftHardConstraints(tx *dbutil.Tx, txn coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn, signed TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error)
 	TransactionFee(tx *dbutil.Tx, hours uint64) coin.FeeCalculator
@@ -66,4 +78,7 @@ type UnconfirmedTransactionPooler interface {
 	ForEach(tx *dbutil.Tx, f func(cipher.SHA256, UnconfirmedTransaction) error) error
 	GetUnspentsOfAddr(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error)
 	Len(tx *dbutil.Tx) (uint64, error)
+	Size(tx *dbutil.Tx) (uint64, uint64, error)
+	RemoveExpired(tx *dbutil.Tx, ttl time.Duration, now time.Time) ([]cipher.SHA256, error)
+	RemoveCheapest(tx *dbutil.Tx, bc Blockchainer, maxTxns, maxBytes uint64) ([]cipher.SHA256, error)
 }
@@ -0,0 +1,730 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+var (
+	// LightHeadersBkt holds the signed block headers synced by a light client, keyed by block seq
+	LightHeadersBkt = []byte("light_headers")
+	// LightUxOutsBkt holds the unspent outputs of the addresses watched by a light client, keyed by address
+	LightUxOutsBkt = []byte("light_uxouts")
+	// LightMetaBkt holds the light client metadata
+	LightMetaBkt = []byte("light_meta")
+
+	lightHeadSeqKey = []byte("head_seq")
+
+	// ErrAddressNotWatched is returned by a light client for addresses that it does not watch
+	ErrAddressNotWatched = errors.New("address is not watched by the light client")
+	// ErrLightClient is returned for operations that a light client can't perform
+	ErrLightClient = errors.New("not available in light client mode")
+)
+
+// ErrLightHeaderFork is returned if a header conflicts with the headers synced by a light client,
+// and forks from them more than blockdb.MaxRollbackDepth headers behind the head header.
+// Like a full node, a light client does not follow reorganizations deeper than that.
+type ErrLightHeaderFork struct {
+	Seq  uint64
+	Hash cipher.SHA256
+}
+
+func (e ErrLightHeaderFork) Error() string {
+	return fmt.Sprintf("header seq=%d hash=%s forks from the synced headers more than %d headers behind the head header", e.Seq, e.Hash.Hex(), blockdb.MaxRollbackDepth)
+}
+
+// ErrLightHeaderForkPointUnknown is returned if the first header sent to a light client conflicts with the synced headers,
+// and the header it forks from is not known. The headers after RequestSeq must be requested to find the fork point.
+type ErrLightHeaderForkPointUnknown struct {
+	Seq        uint64
+	Hash       cipher.SHA256
+	RequestSeq uint64
+}
+
+func (e ErrLightHeaderForkPointUnknown) Error() string {
+	return fmt.Sprintf("header seq=%d hash=%s forks from the synced headers before its previous header, the headers after seq=%d are needed", e.Seq, e.Hash.Hex(), e.RequestSeq)
+}
+
+// UxOutProof proves that an output was created by a transaction included in a block.
+// The output is rebuilt from the transaction, so only the signed block header is needed to check the proof.
+// It does not prove that the output is unspent.
+type UxOutProof struct {
+	// BkSeq is the seq of the block that includes the transaction
+	BkSeq uint64
+	// Transaction is the transaction that created the output
+	Transaction coin.Transaction
+	// TxnIndex is the position of the transaction in the block
+	TxnIndex uint64
+	// OutIndex is the position of the output in the transaction
+	OutIndex uint64
+	// Proof is the merkle proof of the transaction hash against the header's BodyHash
+	Proof []cipher.SHA256 `enc:",maxlen=64"`
+}
+
+// Verify checks the proof against the header of the block that includes the transaction and returns the output
+func (p UxOutProof) Verify(head coin.BlockHeader) (coin.UxOut, error) {
+	if head.BkSeq != p.BkSeq {
+		return coin.UxOut{}, fmt.Errorf("UxOutProof block seq %d does not match header seq %d", p.BkSeq, head.BkSeq)
+	}
+
+	if err := cipher.VerifyMerkleProof(head.BodyHash, p.Transaction.Hash(), p.TxnIndex, p.Proof); err != nil {
+		return coin.UxOut{}, err
+	}
+
+	if p.OutIndex >= uint64(len(p.Transaction.Out)) {
+		return coin.UxOut{}, errors.New("UxOutProof output index out of range")
+	}
+
+	return coin.CreateUnspent(head, p.Transaction, int(p.OutIndex))
+}
+
+// LightClient syncs and verifies the signed block headers of the blockchain, without executing blocks
+// or keeping the unspent pool. It keeps the outputs of a set of watched addresses, which are
+// fetched from full peers with proofs against the synced headers.
+type LightClient struct {
+	pubkey  cipher.PubKey
+	watched []cipher.Address
+	watch   map[cipher.Address]struct{}
+}
+
+// NewLightClient creates a LightClient watching addrs
+func NewLightClient(pubkey cipher.PubKey, addrs []cipher.Address) *LightClient {
+	watch := make(map[cipher.Address]struct{}, len(addrs))
+	var watched []cipher.Address
+	for _, a := range addrs {
+		if _, ok := watch[a]; ok {
+			continue
+		}
+		watch[a] = struct{}{}
+		watched = append(watched, a)
+	}
+
+	return &LightClient{
+		pubkey:  pubkey,
+		watched: watched,
+		watch:   watch,
+	}
+}
+
+// CreateLightBuckets creates the light client buckets
+func CreateLightBuckets(tx *dbutil.Tx) error {
+	return dbutil.CreateBuckets(tx, [][]byte{
+		LightHeadersBkt,
+		LightUxOutsBkt,
+		LightMetaBkt,
+	})
+}
+
+// Init adds the genesis block header if no header was synced, otherwise it checks that
+// the synced genesis header matches
+func (lc *LightClient) Init(tx *dbutil.Tx, genesis coin.SignedBlockHeader) error {
+	if err := CreateLightBuckets(tx); err != nil {
+		return err
+	}
+
+	h, err := lc.GetHeader(tx, 0)
+	if err != nil {
+		return err
+	}
+
+	if h == nil {
+		return lc.putHeader(tx, genesis)
+	}
+
+	if h.Head.Hash() != genesis.Head.Hash() {
+		return errors.New("light client genesis header does not match the genesis block")
+	}
+
+	return nil
+}
+
+// WatchedAddresses returns the addresses watched by the light client
+func (lc *LightClient) WatchedAddresses() []cipher.Address {
+	return lc.watched
+}
+
+// IsWatched returns true if the address is watched by the light client
+func (lc *LightClient) IsWatched(addr cipher.Address) bool {
+	_, ok := lc.watch[addr]
+	return ok
+}
+
+// HeadSeq returns the seq of the most recent synced header
+func (lc *LightClient) HeadSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	v, err := dbutil.GetBucketValue(tx, LightMetaBkt, lightHeadSeqKey)
+	if err != nil {
+		return 0, false, err
+	} else if v == nil {
+		return 0, false, nil
+	}
+
+	return dbutil.Btoi(v), true, nil
+}
+
+// Head returns the most recent synced header
+func (lc *LightClient) Head(tx *dbutil.Tx) (*coin.SignedBlockHeader, error) {
+	seq, ok, err := lc.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("light client has no headers")
+	}
+
+	h, err := lc.GetHeader(tx, seq)
+	if err != nil {
+		return nil, err
+	} else if h == nil {
+		return nil, fmt.Errorf("light client header %d not found", seq)
+	}
+
+	return h, nil
+}
+
+// GetHeader returns the synced header of the block seq, or nil if it was not synced
+func (lc *LightClient) GetHeader(tx *dbutil.Tx, seq uint64) (*coin.SignedBlockHeader, error) {
+	var h coin.SignedBlockHeader
+	if ok, err := dbutil.GetBucketObjectDecoded(tx, LightHeadersBkt, dbutil.Itob(seq), &h); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &h, nil
+}
+
+// AddHeader verifies a header as the child of the head header and adds it.
+// Synced headers are only replaced after a Rollback.
+func (lc *LightClient) AddHeader(tx *dbutil.Tx, h coin.SignedBlockHeader) error {
+	head, err := lc.Head(tx)
+	if err != nil {
+		return err
+	}
+
+	if h.Head.BkSeq != head.Head.BkSeq+1 {
+		return errors.New("BkSeq invalid")
+	}
+	if h.Head.Time <= head.Head.Time {
+		return errors.New("Block time must be > head time")
+	}
+	if h.Head.PrevHash != head.Head.Hash() {
+		return errors.New("PrevHash does not match current head")
+	}
+
+	if err := h.VerifySignature(lc.pubkey); err != nil {
+		return err
+	}
+
+	return lc.putHeader(tx, h)
+}
+
+// Rollback removes the synced headers after seq, and the outputs of watched addresses created in the removed blocks.
+// The outputs spent by the removed blocks are not known, they are restored when the outputs are fetched again from a peer.
+func (lc *LightClient) Rollback(tx *dbutil.Tx, seq uint64) error {
+	headSeq, ok, err := lc.HeadSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok || seq >= headSeq {
+		return nil
+	}
+
+	for i := headSeq; i > seq; i-- {
+		if err := dbutil.Delete(tx, LightHeadersBkt, dbutil.Itob(i)); err != nil {
+			return err
+		}
+	}
+
+	if err := dbutil.PutBucketValue(tx, LightMetaBkt, lightHeadSeqKey, dbutil.Itob(seq)); err != nil {
+		return err
+	}
+
+	for _, a := range lc.watched {
+		uxs, err := lc.GetUxOuts(tx, a)
+		if err != nil {
+			return err
+		}
+
+		kept := make(coin.UxArray, 0, len(uxs))
+		for _, ux := range uxs {
+			if ux.Head.BkSeq <= seq {
+				kept = append(kept, ux)
+			}
+		}
+
+		if len(kept) == len(uxs) {
+			continue
+		}
+
+		if err := dbutil.PutBucketValue(tx, LightUxOutsBkt, a.Bytes(), encoder.Serialize(kept)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (lc *LightClient) putHeader(tx *dbutil.Tx, h coin.SignedBlockHeader) error {
+	if err := dbutil.PutBucketValue(tx, LightHeadersBkt, dbutil.Itob(h.Seq()), encoder.Serialize(h)); err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, LightMetaBkt, lightHeadSeqKey, dbutil.Itob(h.Seq()))
+}
+
+// SetUxOuts replaces the outputs of watched addresses with the outputs proven by proofs.
+// Every proof must be for an output of one of the addresses, in a block whose header is synced.
+func (lc *LightClient) SetUxOuts(tx *dbutil.Tx, addrs []cipher.Address, proofs []UxOutProof) error {
+	uxs := make(coin.AddressUxOuts, len(addrs))
+	for _, a := range addrs {
+		if !lc.IsWatched(a) {
+			return ErrAddressNotWatched
+		}
+		uxs[a] = nil
+	}
+
+	for _, p := range proofs {
+		h, err := lc.GetHeader(tx, p.BkSeq)
+		if err != nil {
+			return err
+		} else if h == nil {
+			return fmt.Errorf("UxOutProof block %d header is not synced", p.BkSeq)
+		}
+
+		ux, err := p.Verify(h.Head)
+		if err != nil {
+			return err
+		}
+
+		a := ux.Body.Address
+		if _, ok := uxs[a]; !ok {
+			return fmt.Errorf("UxOutProof output address %s was not requested", a)
+		}
+
+		uxs[a] = append(uxs[a], ux)
+	}
+
+	for a, ux := range uxs {
+		if err := dbutil.PutBucketValue(tx, LightUxOutsBkt, a.Bytes(), encoder.Serialize(ux)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUxOuts returns the known outputs of a watched address
+func (lc *LightClient) GetUxOuts(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error) {
+	if !lc.IsWatched(addr) {
+		return nil, ErrAddressNotWatched
+	}
+
+	var uxs coin.UxArray
+	if _, err := dbutil.GetBucketObjectDecoded(tx, LightUxOutsBkt, addr.Bytes(), &uxs); err != nil {
+		return nil, err
+	}
+
+	return uxs, nil
+}
+
+// LightClientStatus is the sync status of a light client
+type LightClientStatus struct {
+	// HeadSeq is the seq of the most recent synced header
+	HeadSeq uint64
+	// WatchedAddresses is the number of watched addresses
+	WatchedAddresses int
+}
+
+// IsLightClient returns true if the visor runs in light client mode
+func (vs *Visor) IsLightClient() bool {
+	return vs.light != nil
+}
+
+// GetLightClientStatus returns the sync status of the light client, or nil if the visor does not run in light client mode
+func (vs *Visor) GetLightClientStatus() (*LightClientStatus, error) {
+	if vs.light == nil {
+		return nil, nil
+	}
+
+	var status *LightClientStatus
+	if err := vs.db.View("GetLightClientStatus", func(tx *dbutil.Tx) error {
+		headSeq, _, err := vs.light.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		status = &LightClientStatus{
+			HeadSeq:          headSeq,
+			WatchedAddresses: len(vs.light.WatchedAddresses()),
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// LightHeadBkSeq returns the seq of the most recent header synced by the light client
+func (vs *Visor) LightHeadBkSeq() (uint64, bool, error) {
+	if vs.light == nil {
+		return 0, false, errors.New("visor is not in light client mode")
+	}
+
+	var headSeq uint64
+	var ok bool
+
+	if err := vs.db.View("LightHeadBkSeq", func(tx *dbutil.Tx) error {
+		var err error
+		headSeq, ok, err = vs.light.HeadSeq(tx)
+		return err
+	}); err != nil {
+		return 0, false, err
+	}
+
+	return headSeq, ok, nil
+}
+
+// LightWatchedAddresses returns the addresses watched by the light client
+func (vs *Visor) LightWatchedAddresses() []cipher.Address {
+	if vs.light == nil {
+		return nil
+	}
+	return vs.light.WatchedAddresses()
+}
+
+// ExecuteSignedBlockHeaders verifies and adds headers to the light client, in order.
+// It stops at the first header that is not valid, and returns the number of headers added with the error.
+// Headers that were already synced are skipped if they match the synced headers.
+// Headers that conflict with the synced headers are a competing chain. Like a full node, the light client follows
+// the longest chain: if the headers end after the head header, the synced headers are rolled back to the fork point
+// and replaced. A fork more than blockdb.MaxRollbackDepth headers behind the head header returns ErrLightHeaderFork.
+// If the first header conflicts and the fork point is not in the headers, ErrLightHeaderForkPointUnknown is returned.
+func (vs *Visor) ExecuteSignedBlockHeaders(headers []coin.SignedBlockHeader) (int, error) {
+	if vs.light == nil {
+		return 0, errors.New("visor is not in light client mode")
+	}
+
+	added := 0
+	failed := -1
+	err := vs.db.Update("ExecuteSignedBlockHeaders", func(tx *dbutil.Tx) error {
+		headSeq, _, err := vs.light.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		for i, h := range headers {
+			failed = i
+
+			fork := false
+			if h.Seq() <= headSeq {
+				synced, err := vs.light.GetHeader(tx, h.Seq())
+				if err != nil {
+					return err
+				}
+
+				if synced == nil || synced.Head.Hash() == h.Head.Hash() {
+					continue
+				}
+
+				fork = true
+			} else if i == 0 && h.Seq() == headSeq+1 {
+				head, err := vs.light.Head(tx)
+				if err != nil {
+					return err
+				}
+
+				fork = h.Head.PrevHash != head.Head.Hash()
+			}
+
+			if fork {
+				follow, err := vs.followLightHeaderFork(tx, headSeq, h, i == 0, headers[len(headers)-1].Seq())
+				if err != nil {
+					return err
+				}
+
+				if !follow {
+					return nil
+				}
+
+				headSeq = h.Seq() - 1
+			}
+
+			if err := verifyCheckpoint(vs.Config.checkpoints(), h.Seq(), h.Head.Hash()); err != nil {
+				return err
+			}
+
+			if err := vs.light.AddHeader(tx, h); err != nil {
+				return err
+			}
+
+			headSeq = h.Seq()
+			added++
+		}
+
+		return nil
+	})
+
+	// Keep the headers that were added before the invalid header
+	if err != nil && added > 0 {
+		logger.WithError(err).Warningf("ExecuteSignedBlockHeaders: header %d is not valid", headers[failed].Seq())
+
+		n, retryErr := vs.ExecuteSignedBlockHeaders(headers[:failed])
+		if retryErr != nil {
+			return n, retryErr
+		}
+		return n, err
+	}
+
+	return added, err
+}
+
+// followLightHeaderFork rolls back the synced headers to the parent of h, a header that conflicts with the synced headers,
+// if the competing chain ends at endSeq after the head header. first is true if h is the first header received.
+// Returns false if the competing chain is not longer than the synced headers, which are kept.
+func (vs *Visor) followLightHeaderFork(tx *dbutil.Tx, headSeq uint64, h coin.SignedBlockHeader, first bool, endSeq uint64) (bool, error) {
+	errFork := ErrLightHeaderFork{
+		Seq:  h.Seq(),
+		Hash: h.Head.Hash(),
+	}
+
+	if h.Seq() == 0 {
+		return false, errFork
+	}
+
+	forkSeq := h.Seq() - 1
+	parent, err := vs.light.GetHeader(tx, forkSeq)
+	if err != nil {
+		return false, err
+	} else if parent == nil {
+		return false, fmt.Errorf("light client header %d not found", forkSeq)
+	}
+
+	if h.Head.PrevHash != parent.Head.Hash() {
+		if !first {
+			return false, errors.New("PrevHash does not match the previous header")
+		}
+
+		// The chain forks before the parent. The fork point can only be found from older headers,
+		// as long as it is not too far behind the head header.
+		if forkSeq == 0 || headSeq-forkSeq >= blockdb.MaxRollbackDepth {
+			return false, errFork
+		}
+
+		var requestSeq uint64
+		if headSeq > blockdb.MaxRollbackDepth {
+			requestSeq = headSeq - blockdb.MaxRollbackDepth
+		}
+
+		return false, ErrLightHeaderForkPointUnknown{
+			Seq:        h.Seq(),
+			Hash:       h.Head.Hash(),
+			RequestSeq: requestSeq,
+		}
+	}
+
+	if headSeq-forkSeq > blockdb.MaxRollbackDepth {
+		return false, errFork
+	}
+
+	// The longest chain is followed. If the chains have the same length, the synced headers are kept.
+	if endSeq <= headSeq {
+		logger.Infof("Light client ignored competing headers ending at seq=%d, fork point seq=%d, head header is seq=%d", endSeq, forkSeq, headSeq)
+		return false, nil
+	}
+
+	logger.Infof("Light client reorganizing headers, fork point seq=%d, rolling back %d headers", forkSeq, headSeq-forkSeq)
+
+	if err := vs.light.Rollback(tx, forkSeq); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetWatchedUxOuts replaces the outputs of watched addresses of the light client with the outputs proven by proofs
+func (vs *Visor) SetWatchedUxOuts(addrs []cipher.Address, proofs []UxOutProof) error {
+	if vs.light == nil {
+		return errors.New("visor is not in light client mode")
+	}
+
+	return vs.db.Update("SetWatchedUxOuts", func(tx *dbutil.Tx) error {
+		return vs.light.SetUxOuts(tx, addrs, proofs)
+	})
+}
+
+// GetSignedBlockHeadersSince returns up to ct signed block headers more recent than seq
+func (vs *Visor) GetSignedBlockHeadersSince(seq, ct uint64) ([]coin.SignedBlockHeader, error) {
+	var headers []coin.SignedBlockHeader
+
+	if err := vs.db.View("GetSignedBlockHeadersSince", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok || headSeq <= seq {
+			return nil
+		}
+
+		if avail := headSeq - seq; avail < ct {
+			ct = avail
+		}
+
+		for i := seq + 1; i <= seq+ct; i++ {
+			b, err := vs.blockchain.GetSignedBlockBySeq(tx, i)
+			if err != nil {
+				return err
+			}
+
+			// Blocks before the checkpoint of a snapshot are not available
+			if b == nil {
+				break
+			}
+
+			headers = append(headers, b.SignedHeader())
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
+
+// GetUxOutProofs returns the proofs of the unspent outputs of addrs, and the seq of the head block.
+// Returns ErrLightClient in light client mode.
+func (vs *Visor) GetUxOutProofs(addrs []cipher.Address) ([]UxOutProof, uint64, error) {
+	if vs.light != nil {
+		return nil, 0, ErrLightClient
+	}
+
+	var proofs []UxOutProof
+	var headSeq uint64
+
+	if err := vs.db.View("GetUxOutProofs", func(tx *dbutil.Tx) error {
+		var err error
+		headSeq, _, err = vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		auxs, err := vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, addrs)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range addrs {
+			for _, ux := range auxs[a] {
+				p, err := vs.getUxOutProof(tx, ux)
+				if err != nil {
+					return err
+				}
+				proofs = append(proofs, *p)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	return proofs, headSeq, nil
+}
+
+func (vs *Visor) getUxOutProof(tx *dbutil.Tx, ux coin.UxOut) (*UxOutProof, error) {
+	b, err := vs.blockchain.GetSignedBlockBySeq(tx, ux.Head.BkSeq)
+	if err != nil {
+		return nil, err
+	} else if b == nil {
+		return nil, fmt.Errorf("found no block in seq %d", ux.Head.BkSeq)
+	}
+
+	uxHash := ux.Hash()
+	for i, txn := range b.Body.Transactions {
+		// The genesis block uses the null hash as the SrcTransaction
+		if ux.Head.BkSeq != 0 && txn.Hash() != ux.Body.SrcTransaction {
+			continue
+		}
+
+		for j := range txn.Out {
+			o, err := coin.CreateUnspent(b.Head, txn, j)
+			if err != nil {
+				return nil, err
+			}
+			if o.Hash() != uxHash {
+				continue
+			}
+
+			proof, err := b.Body.TransactionProof(uint64(i))
+			if err != nil {
+				return nil, err
+			}
+
+			return &UxOutProof{
+				BkSeq:       ux.Head.BkSeq,
+				Transaction: txn,
+				TxnIndex:    uint64(i),
+				OutIndex:    uint64(j),
+				Proof:       proof,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unspent output %s not found in block %d, the block body may be pruned", uxHash.Hex(), ux.Head.BkSeq)
+}
+
+// getLightBalanceOfAddrs returns the balances of watched addresses, from the outputs known by the light client.
+// Coin hours are computed at the time of the most recent synced header.
+// Unconfirmed transactions are not known by the light client, so the predicted balance is the confirmed balance.
+func (vs *Visor) getLightBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair, error) {
+	var bps []wallet.BalancePair
+
+	if err := vs.db.View("getLightBalanceOfAddrs", func(tx *dbutil.Tx) error {
+		head, err := vs.light.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range addrs {
+			uxs, err := vs.light.GetUxOuts(tx, a)
+			if err != nil {
+				return err
+			}
+
+			coins, err := uxs.Coins()
+			if err != nil {
+				return fmt.Errorf("uxs.Coins failed: %v", err)
+			}
+
+			hours, err := uxs.CoinHours(head.Head.Time)
+			if err != nil {
+				switch err {
+				case coin.ErrAddEarnedCoinHoursAdditionOverflow:
+					hours = 0
+				default:
+					return fmt.Errorf("uxs.CoinHours failed: %v", err)
+				}
+			}
+
+			b := wallet.Balance{
+				Coins: coins,
+				Hours: hours,
+			}
+			bps = append(bps, wallet.BalancePair{
+				Confirmed: b,
+				Predicted: b,
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return bps, nil
+}
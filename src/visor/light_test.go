@@ -0,0 +1,489 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func newLightTestVisor(t *testing.T, c *testChain, watched []cipher.Address) (*Visor, func()) {
+	db, shutdown := prepareDB(t)
+
+	cfg := c.v.Config
+	cfg.IsBlockPublisher = false
+	cfg.Arbitrating = false
+	cfg.BlockchainSeckey = cipher.SecKey{}
+	cfg.GenesisCoinVolume = genCoins
+	cfg.GenesisTimestamp = genTime
+	cfg.GenesisSignature = c.block(0).Sig
+	cfg.LightClient = true
+	cfg.WatchAddresses = watched
+
+	v, err := New(cfg, db, nil)
+	require.NoError(t, err)
+	require.NoError(t, v.Init())
+	require.True(t, v.IsLightClient())
+
+	return v, shutdown
+}
+
+func TestGetSignedBlockHeadersSince(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	headers, err := c.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 5)
+	for i, h := range headers {
+		b := c.block(uint64(i + 1))
+		require.Equal(t, b.SignedHeader(), h)
+		require.NoError(t, h.VerifySignature(genPublic))
+	}
+
+	headers, err = c.v.GetSignedBlockHeadersSince(3, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+	require.Equal(t, uint64(4), headers[0].Seq())
+
+	headers, err = c.v.GetSignedBlockHeadersSince(1, 2)
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+	require.Equal(t, uint64(3), headers[1].Seq())
+
+	headers, err = c.v.GetSignedBlockHeadersSince(5, 10)
+	require.NoError(t, err)
+	require.Empty(t, headers)
+}
+
+func TestLightClientSync(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	otherAddr := testutil.MakeAddress()
+	lv, shutdown := newLightTestVisor(t, c, []cipher.Address{genAddress, otherAddr, genAddress})
+	defer shutdown()
+
+	require.Equal(t, []cipher.Address{genAddress, otherAddr}, lv.LightWatchedAddresses())
+
+	seq, ok, err := lv.LightHeadBkSeq()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), seq)
+
+	// The light client does not serve outputs
+	_, _, err = lv.GetUxOutProofs([]cipher.Address{genAddress})
+	require.Equal(t, ErrLightClient, err)
+
+	headers, err := c.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+
+	n, err := lv.ExecuteSignedBlockHeaders(headers)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	// Headers that were already synced are skipped
+	n, err = lv.ExecuteSignedBlockHeaders(headers)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	status, err := lv.GetLightClientStatus()
+	require.NoError(t, err)
+	require.Equal(t, &LightClientStatus{
+		HeadSeq:          5,
+		WatchedAddresses: 2,
+	}, status)
+
+	// No outputs are known yet
+	bals, err := lv.GetBalanceOfAddrs([]cipher.Address{genAddress})
+	require.NoError(t, err)
+	require.Len(t, bals, 1)
+	require.Equal(t, uint64(0), bals[0].Confirmed.Coins)
+
+	// Balance queries are answered for watched addresses only
+	_, err = lv.GetBalanceOfAddrs([]cipher.Address{testutil.MakeAddress()})
+	require.Equal(t, ErrAddressNotWatched, err)
+
+	addrs := []cipher.Address{genAddress, otherAddr}
+	proofs, headSeq, err := c.v.GetUxOutProofs(addrs)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), headSeq)
+	require.NotEmpty(t, proofs)
+
+	err = lv.SetWatchedUxOuts(addrs, proofs)
+	require.NoError(t, err)
+
+	expected, err := c.v.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	bals, err = lv.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.Len(t, bals, 2)
+	for i := range bals {
+		require.Equal(t, expected[i].Confirmed, bals[i].Confirmed)
+		require.Equal(t, bals[i].Confirmed, bals[i].Predicted)
+	}
+	require.NotEqual(t, uint64(0), bals[0].Confirmed.Coins)
+	require.Equal(t, uint64(0), bals[1].Confirmed.Coins)
+
+	// Outputs of addresses that were not requested are rejected
+	err = lv.SetWatchedUxOuts([]cipher.Address{otherAddr}, proofs)
+	require.Error(t, err)
+
+	// Addresses that are not watched are rejected
+	err = lv.SetWatchedUxOuts([]cipher.Address{testutil.MakeAddress()}, nil)
+	require.Equal(t, ErrAddressNotWatched, err)
+
+	// A proof that does not match the header is rejected
+	badProofs := append([]UxOutProof{}, proofs...)
+	badProofs[0].TxnIndex++
+	err = lv.SetWatchedUxOuts(addrs, badProofs)
+	require.Error(t, err)
+
+	badProofs = append([]UxOutProof{}, proofs...)
+	badProofs[0].Transaction.Out = append([]coin.TransactionOutput{}, badProofs[0].Transaction.Out...)
+	badProofs[0].Transaction.Out[badProofs[0].OutIndex].Coins++
+	err = lv.SetWatchedUxOuts(addrs, badProofs)
+	require.Error(t, err)
+
+	// The rejected proofs did not change the known outputs
+	bals, err = lv.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.Equal(t, expected[0].Confirmed, bals[0].Confirmed)
+
+	// Replacing the outputs of an address with no proofs clears them
+	err = lv.SetWatchedUxOuts([]cipher.Address{genAddress}, nil)
+	require.NoError(t, err)
+	bals, err = lv.GetBalanceOfAddrs([]cipher.Address{genAddress})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), bals[0].Confirmed.Coins)
+}
+
+func TestLightClientInvalidHeaders(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	headers, err := c.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+
+	_, otherSecret := cipher.GenerateKeyPair()
+
+	cases := []struct {
+		name    string
+		synced  int
+		headers func() []coin.SignedBlockHeader
+		added   int
+		err     bool
+		fork    bool
+	}{
+		{
+			name: "first header has an invalid signature",
+			headers: func() []coin.SignedBlockHeader {
+				hs := append([]coin.SignedBlockHeader{}, headers...)
+				hs[0].Sig = cipher.MustSignHash(hs[0].Head.Hash(), otherSecret)
+				return hs
+			},
+			added: 0,
+			err:   true,
+		},
+		{
+			name: "later header has an invalid signature",
+			headers: func() []coin.SignedBlockHeader {
+				hs := append([]coin.SignedBlockHeader{}, headers...)
+				hs[2].Sig = cipher.MustSignHash(hs[2].Head.Hash(), otherSecret)
+				return hs
+			},
+			added: 2,
+			err:   true,
+		},
+		{
+			name:   "later header has an invalid signature after synced headers",
+			synced: 2,
+			headers: func() []coin.SignedBlockHeader {
+				hs := append([]coin.SignedBlockHeader{}, headers...)
+				hs[3].Sig = cipher.MustSignHash(hs[3].Head.Hash(), otherSecret)
+				return hs
+			},
+			added: 1,
+			err:   true,
+		},
+		{
+			name: "header does not follow the head",
+			headers: func() []coin.SignedBlockHeader {
+				return headers[1:]
+			},
+			added: 0,
+			err:   true,
+		},
+		{
+			name: "header has a different previous hash",
+			headers: func() []coin.SignedBlockHeader {
+				hs := append([]coin.SignedBlockHeader{}, headers...)
+				hs[0].Head.PrevHash = testutil.RandSHA256(t)
+				hs[0].Sig = cipher.MustSignHash(hs[0].Head.Hash(), genSecret)
+				return hs
+			},
+			added: 0,
+			err:   true,
+			fork:  true,
+		},
+		{
+			name:   "synced header is replaced by a header that does not follow the previous header",
+			synced: 2,
+			headers: func() []coin.SignedBlockHeader {
+				hs := append([]coin.SignedBlockHeader{}, headers...)
+				hs[1].Head.PrevHash = testutil.RandSHA256(t)
+				hs[1].Sig = cipher.MustSignHash(hs[1].Head.Hash(), genSecret)
+				return hs
+			},
+			added: 0,
+			err:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lv, shutdown := newLightTestVisor(t, c, []cipher.Address{genAddress})
+			defer shutdown()
+
+			n, err := lv.ExecuteSignedBlockHeaders(headers[:tc.synced])
+			require.NoError(t, err)
+			require.Equal(t, tc.synced, n)
+
+			n, err = lv.ExecuteSignedBlockHeaders(tc.headers())
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.fork {
+				require.IsType(t, ErrLightHeaderFork{}, err)
+			}
+			require.Equal(t, tc.added, n)
+
+			seq, ok, err := lv.LightHeadBkSeq()
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, uint64(tc.synced+tc.added), seq)
+		})
+	}
+}
+
+func TestLightClientReorganization(t *testing.T) {
+	// Branch A forks from branch B after block 2
+	a := makeSnapshotChain(t)
+	defer a.shutdown()
+
+	b := a.fork(2)
+	defer b.shutdown()
+
+	sb := a.block(1)
+	splitUxs := coin.CreateUnspents(sb.Head, sb.Body.Transactions[0])
+	for _, i := range []int{4, 1, 2, 3} {
+		b.addBlock(makeSpendTxn(t, coin.UxArray{splitUxs[i]}, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6))
+	}
+	require.Equal(t, uint64(5), a.head().Seq())
+	require.Equal(t, uint64(6), b.head().Seq())
+	require.NotEqual(t, a.block(3).HashHeader(), b.block(3).HashHeader())
+
+	// The recipient of the last block of branch A
+	recipient := a.head().Body.Transactions[0].Out[0].Address
+	addrs := []cipher.Address{genAddress, recipient}
+
+	lv, shutdown := newLightTestVisor(t, a, addrs)
+	defer shutdown()
+
+	// Sync branch A and its outputs
+	headersA, err := a.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+	n, err := lv.ExecuteSignedBlockHeaders(headersA)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	proofs, _, err := a.v.GetUxOutProofs(addrs)
+	require.NoError(t, err)
+	err = lv.SetWatchedUxOuts(addrs, proofs)
+	require.NoError(t, err)
+
+	lightUxOuts := func(addr cipher.Address) coin.UxArray {
+		var uxs coin.UxArray
+		err := lv.db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			uxs, err = lv.light.GetUxOuts(tx, addr)
+			return err
+		})
+		require.NoError(t, err)
+		return uxs
+	}
+
+	require.Len(t, lightUxOuts(recipient), 1)
+
+	// The headers of branch B after the head header do not include the fork point
+	headersB, err := b.v.GetSignedBlockHeadersSince(5, 10)
+	require.NoError(t, err)
+	require.Len(t, headersB, 1)
+	n, err = lv.ExecuteSignedBlockHeaders(headersB)
+	require.Equal(t, ErrLightHeaderForkPointUnknown{
+		Seq:        6,
+		Hash:       headersB[0].Head.Hash(),
+		RequestSeq: 0,
+	}, err)
+	require.Equal(t, 0, n)
+
+	// The headers of branch B from the requested seq include the fork point, and branch B is longer
+	headersB, err = b.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+	n, err = lv.ExecuteSignedBlockHeaders(headersB)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	requireLightHeaders := func(c *testChain) {
+		seq, ok, err := lv.LightHeadBkSeq()
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, c.head().Seq(), seq)
+
+		err = lv.db.View("", func(tx *dbutil.Tx) error {
+			for i := uint64(0); i <= seq; i++ {
+				h, err := lv.light.GetHeader(tx, i)
+				require.NoError(t, err)
+				require.NotNil(t, h)
+				require.Equal(t, c.block(i).HashHeader(), h.Head.Hash())
+			}
+
+			h, err := lv.light.GetHeader(tx, seq+1)
+			require.NoError(t, err)
+			require.Nil(t, h)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	requireLightHeaders(b)
+
+	// The outputs created by the rolled back blocks are dropped
+	require.Empty(t, lightUxOuts(recipient))
+	uxs := lightUxOuts(genAddress)
+	require.NotEmpty(t, uxs)
+	for _, ux := range uxs {
+		require.True(t, ux.Head.BkSeq <= 2)
+	}
+
+	// The outputs can be fetched again from a peer on branch B
+	proofs, _, err = b.v.GetUxOutProofs(addrs)
+	require.NoError(t, err)
+	err = lv.SetWatchedUxOuts(addrs, proofs)
+	require.NoError(t, err)
+	expected, err := b.v.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	bals, err := lv.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	for i := range bals {
+		require.Equal(t, expected[i].Confirmed, bals[i].Confirmed)
+	}
+
+	// The shorter branch A is not followed
+	n, err = lv.ExecuteSignedBlockHeaders(headersA)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	requireLightHeaders(b)
+
+	// The light client follows branch A back once it is longer
+	ux := splitUxs[4]
+	for i := 0; i < 2; i++ {
+		txn := makeSpendTxn(t, coin.UxArray{ux}, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6)
+		nb := a.addBlock(txn)
+		// Spend the change output next
+		ux = coin.CreateUnspents(nb.Head, txn)[1]
+	}
+	headersA, err = a.v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+	n, err = lv.ExecuteSignedBlockHeaders(headersA)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	requireLightHeaders(a)
+}
+
+// makeLightHeaders creates n signed headers following parent
+func makeLightHeaders(t *testing.T, parent coin.BlockHeader, n int) []coin.SignedBlockHeader {
+	headers := make([]coin.SignedBlockHeader, n)
+	for i := range headers {
+		h := coin.BlockHeader{
+			Version:  parent.Version,
+			Time:     parent.Time + 100,
+			BkSeq:    parent.BkSeq + 1,
+			PrevHash: parent.Hash(),
+			BodyHash: testutil.RandSHA256(t),
+		}
+		headers[i] = coin.SignedBlockHeader{
+			Head: h,
+			Sig:  cipher.MustSignHash(h.Hash(), genSecret),
+		}
+		parent = h
+	}
+	return headers
+}
+
+func TestLightClientDeepFork(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	lv, shutdown := newLightTestVisor(t, c, []cipher.Address{genAddress})
+	defer shutdown()
+
+	depth := int(blockdb.MaxRollbackDepth)
+
+	// The synced headers end depth+5 headers after the genesis header
+	synced := makeLightHeaders(t, c.block(0).Head, depth+5)
+	n, err := lv.ExecuteSignedBlockHeaders(synced)
+	require.NoError(t, err)
+	require.Equal(t, depth+5, n)
+	headSeq := uint64(depth + 5)
+
+	// A longer chain forking depth+1 headers behind the head header
+	deep := makeLightHeaders(t, synced[3].Head, depth+10)
+	require.Equal(t, uint64(5), deep[0].Seq())
+
+	// The headers after the head header do not include the fork point
+	headers := deep[headSeq-4:]
+	n, err = lv.ExecuteSignedBlockHeaders(headers)
+	require.Equal(t, ErrLightHeaderForkPointUnknown{
+		Seq:        headSeq + 1,
+		Hash:       headers[0].Head.Hash(),
+		RequestSeq: 5,
+	}, err)
+	require.Equal(t, 0, n)
+
+	// The headers after the requested seq show that the fork is too deep
+	headers = deep[1:]
+	require.Equal(t, uint64(6), headers[0].Seq())
+	n, err = lv.ExecuteSignedBlockHeaders(headers)
+	require.Equal(t, ErrLightHeaderFork{
+		Seq:  6,
+		Hash: headers[0].Head.Hash(),
+	}, err)
+	require.Equal(t, 0, n)
+
+	// The fork point is in the headers, but too deep
+	n, err = lv.ExecuteSignedBlockHeaders(deep)
+	require.Equal(t, ErrLightHeaderFork{
+		Seq:  5,
+		Hash: deep[0].Head.Hash(),
+	}, err)
+	require.Equal(t, 0, n)
+
+	// A longer chain forking depth headers behind the head header is followed
+	shallow := makeLightHeaders(t, synced[4].Head, depth+10)
+	n, err = lv.ExecuteSignedBlockHeaders(shallow)
+	require.NoError(t, err)
+	require.Equal(t, depth+10, n)
+
+	seq, ok, err := lv.LightHeadBkSeq()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, shallow[len(shallow)-1].Seq(), seq)
+}
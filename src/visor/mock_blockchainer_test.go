@@ -328,6 +328,34 @@ func (_m *MockBlockchainer) VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Tr
 	return r0
 }
 
+// VerifyPendingBlockSignatures provides a mock function with given fields: tx, blocks
+func (_m *MockBlockchainer) VerifyPendingBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error {
+	ret := _m.Called(tx, blocks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, []coin.SignedBlock) error); ok {
+		r0 = rf(tx, blocks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifySignature provides a mock function with given fields: block
+func (_m *MockBlockchainer) VerifySignature(block *coin.SignedBlock) error {
+	ret := _m.Called(block)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*coin.SignedBlock) error); ok {
+		r0 = rf(block)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // VerifySingleTxnHardConstraints provides a mock function with given fields: tx, txn, signed
 func (_m *MockBlockchainer) VerifySingleTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, signed TxnSignedFlag) error {
 	ret := _m.Called(tx, txn, signed)
@@ -373,3 +401,89 @@ func (_m *MockBlockchainer) VerifySingleTxnSoftHardConstraints(tx *dbutil.Tx, tx
 
 	return r0, r1, r2
 }
+
+// ExecuteForkBlock provides a mock function with given fields: tx, sb
+func (_m *MockBlockchainer) ExecuteForkBlock(tx *dbutil.Tx, sb *coin.SignedBlock) (*Reorganization, error) {
+	ret := _m.Called(tx, sb)
+
+	var r0 *Reorganization
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock) *Reorganization); ok {
+		r0 = rf(tx, sb)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Reorganization)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, *coin.SignedBlock) error); ok {
+		r1 = rf(tx, sb)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsForkBlock provides a mock function with given fields: tx, b
+func (_m *MockBlockchainer) IsForkBlock(tx *dbutil.Tx, b coin.Block) (bool, error) {
+	ret := _m.Called(tx, b)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Block) bool); ok {
+		r0 = rf(tx, b)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, coin.Block) error); ok {
+		r1 = rf(tx, b)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PruneBlocks provides a mock function with given fields: tx, seq
+func (_m *MockBlockchainer) PruneBlocks(tx *dbutil.Tx, seq uint64) error {
+	ret := _m.Called(tx, seq)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64) error); ok {
+		r0 = rf(tx, seq)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PrunedSeq provides a mock function with given fields: tx
+func (_m *MockBlockchainer) PrunedSeq(tx *dbutil.Tx) (uint64, bool, error) {
+	ret := _m.Called(tx)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) uint64); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) bool); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*dbutil.Tx) error); ok {
+		r2 = rf(tx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -27,6 +27,20 @@ func (_m *MockHistoryer) Erase(tx *dbutil.Tx) error {
 	return r0
 }
 
+// ForEachSupplyStats provides a mock function with given fields: tx, start, end, f
+func (_m *MockHistoryer) ForEachSupplyStats(tx *dbutil.Tx, start uint64, end uint64, f func(historydb.SupplyStats) error) error {
+	ret := _m.Called(tx, start, end, f)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64, uint64, func(historydb.SupplyStats) error) error); ok {
+		r0 = rf(tx, start, end, f)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ForEachTxn provides a mock function with given fields: tx, f
 func (_m *MockHistoryer) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *historydb.Transaction) error) error {
 	ret := _m.Called(tx, f)
@@ -41,6 +55,29 @@ func (_m *MockHistoryer) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *histor
 	return r0
 }
 
+// GetAddressSummary provides a mock function with given fields: tx, addr
+func (_m *MockHistoryer) GetAddressSummary(tx *dbutil.Tx, addr cipher.Address) (*historydb.AddressSummary, error) {
+	ret := _m.Called(tx, addr)
+
+	var r0 *historydb.AddressSummary
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.Address) *historydb.AddressSummary); ok {
+		r0 = rf(tx, addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*historydb.AddressSummary)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.Address) error); ok {
+		r1 = rf(tx, addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOutputsForAddress provides a mock function with given fields: tx, address
 func (_m *MockHistoryer) GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.UxOut, error) {
 	ret := _m.Called(tx, address)
@@ -110,6 +147,29 @@ func (_m *MockHistoryer) GetTransactionsForAddress(tx *dbutil.Tx, address cipher
 	return r0, r1
 }
 
+// GetTransactionsPageForAddress provides a mock function with given fields: tx, address, p
+func (_m *MockHistoryer) GetTransactionsPageForAddress(tx *dbutil.Tx, address cipher.Address, p historydb.AddressTxnsPageParams) (*historydb.AddressTxnsPage, error) {
+	ret := _m.Called(tx, address, p)
+
+	var r0 *historydb.AddressTxnsPage
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.Address, historydb.AddressTxnsPageParams) *historydb.AddressTxnsPage); ok {
+		r0 = rf(tx, address, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*historydb.AddressTxnsPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, cipher.Address, historydb.AddressTxnsPageParams) error); ok {
+		r1 = rf(tx, address, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUxOuts provides a mock function with given fields: tx, uxids
 func (_m *MockHistoryer) GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]historydb.UxOut, error) {
 	ret := _m.Called(tx, uxids)
@@ -195,3 +255,17 @@ func (_m *MockHistoryer) ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error) {
 
 	return r0, r1, r2
 }
+
+// RollbackBlock provides a mock function with given fields: tx, b
+func (_m *MockHistoryer) RollbackBlock(tx *dbutil.Tx, b coin.Block) error {
+	ret := _m.Called(tx, b)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Block) error); ok {
+		r0 = rf(tx, b)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -7,6 +7,7 @@ import coin "github.com/skycoin/skycoin/src/coin"
 import dbutil "github.com/skycoin/skycoin/src/visor/dbutil"
 import mock "github.com/stretchr/testify/mock"
 import params "github.com/skycoin/skycoin/src/params"
+import time "time"
 
 // MockUnconfirmedTransactionPooler is an autogenerated mock type for the UnconfirmedTransactionPooler type
 type MockUnconfirmedTransactionPooler struct {
@@ -308,6 +309,52 @@ func (_m *MockUnconfirmedTransactionPooler) Refresh(tx *dbutil.Tx, bc Blockchain
 	return r0, r1
 }
 
+// RemoveCheapest provides a mock function with given fields: tx, bc, maxTxns, maxBytes
+func (_m *MockUnconfirmedTransactionPooler) RemoveCheapest(tx *dbutil.Tx, bc Blockchainer, maxTxns uint64, maxBytes uint64) ([]cipher.SHA256, error) {
+	ret := _m.Called(tx, bc, maxTxns, maxBytes)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, uint64, uint64) []cipher.SHA256); ok {
+		r0 = rf(tx, bc, maxTxns, maxBytes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, uint64, uint64) error); ok {
+		r1 = rf(tx, bc, maxTxns, maxBytes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveExpired provides a mock function with given fields: tx, ttl, now
+func (_m *MockUnconfirmedTransactionPooler) RemoveExpired(tx *dbutil.Tx, ttl time.Duration, now time.Time) ([]cipher.SHA256, error) {
+	ret := _m.Called(tx, ttl, now)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, time.Duration, time.Time) []cipher.SHA256); ok {
+		r0 = rf(tx, ttl, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, time.Duration, time.Time) error); ok {
+		r1 = rf(tx, ttl, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveInvalid provides a mock function with given fields: tx, bc
 func (_m *MockUnconfirmedTransactionPooler) RemoveInvalid(tx *dbutil.Tx, bc Blockchainer) ([]cipher.SHA256, error) {
 	ret := _m.Called(tx, bc)
@@ -358,3 +405,31 @@ func (_m *MockUnconfirmedTransactionPooler) SetTransactionsAnnounced(tx *dbutil.
 
 	return r0
 }
+
+// Size provides a mock function with given fields: tx
+func (_m *MockUnconfirmedTransactionPooler) Size(tx *dbutil.Tx) (uint64, uint64, error) {
+	ret := _m.Called(tx)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) uint64); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 uint64
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) uint64); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*dbutil.Tx) error); ok {
+		r2 = rf(tx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
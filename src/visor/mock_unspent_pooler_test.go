@@ -82,6 +82,29 @@ func (_m *MockUnspentPooler) Get(_a0 *dbutil.Tx, _a1 cipher.SHA256) (*coin.UxOut
 	return r0, r1
 }
 
+// GetAddressBalances provides a mock function with given fields: _a0, _a1
+func (_m *MockUnspentPooler) GetAddressBalances(_a0 *dbutil.Tx, _a1 []cipher.Address) (map[cipher.Address]uint64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[cipher.Address]uint64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, []cipher.Address) map[cipher.Address]uint64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.Address]uint64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, []cipher.Address) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAll provides a mock function with given fields: _a0
 func (_m *MockUnspentPooler) GetAll(_a0 *dbutil.Tx) (coin.UxArray, error) {
 	ret := _m.Called(_a0)
@@ -128,6 +151,52 @@ func (_m *MockUnspentPooler) GetArray(_a0 *dbutil.Tx, _a1 []cipher.SHA256) (coin
 	return r0, r1
 }
 
+// GetBalanceHistogram provides a mock function with given fields: _a0
+func (_m *MockUnspentPooler) GetBalanceHistogram(_a0 *dbutil.Tx) (blockdb.BalanceHistogram, error) {
+	ret := _m.Called(_a0)
+
+	var r0 blockdb.BalanceHistogram
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) blockdb.BalanceHistogram); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(blockdb.BalanceHistogram)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRichlist provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockUnspentPooler) GetRichlist(_a0 *dbutil.Tx, _a1 uint64, _a2 map[cipher.Address]struct{}) ([]blockdb.AddressBalance, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []blockdb.AddressBalance
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64, map[cipher.Address]struct{}) []blockdb.AddressBalance); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]blockdb.AddressBalance)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, uint64, map[cipher.Address]struct{}) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUnspentHashesOfAddrs provides a mock function with given fields: _a0, _a1
 func (_m *MockUnspentPooler) GetUnspentHashesOfAddrs(_a0 *dbutil.Tx, _a1 []cipher.Address) (blockdb.AddressHashes, error) {
 	ret := _m.Called(_a0, _a1)
@@ -218,6 +287,20 @@ func (_m *MockUnspentPooler) Len(_a0 *dbutil.Tx) (uint64, error) {
 	return r0, r1
 }
 
+// Load provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockUnspentPooler) Load(_a0 *dbutil.Tx, _a1 coin.UxArray, _a2 uint64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.UxArray, uint64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // MaybeBuildIndexes provides a mock function with given fields: _a0, _a1
 func (_m *MockUnspentPooler) MaybeBuildIndexes(_a0 *dbutil.Tx, _a1 uint64) error {
 	ret := _m.Called(_a0, _a1)
@@ -245,3 +328,31 @@ func (_m *MockUnspentPooler) ProcessBlock(_a0 *dbutil.Tx, _a1 *coin.SignedBlock)
 
 	return r0
 }
+
+// PruneSpent provides a mock function with given fields: _a0, _a1
+func (_m *MockUnspentPooler) PruneSpent(_a0 *dbutil.Tx, _a1 *coin.SignedBlock) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollbackBlock provides a mock function with given fields: _a0, _a1
+func (_m *MockUnspentPooler) RollbackBlock(_a0 *dbutil.Tx, _a1 *coin.SignedBlock) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -3,6 +3,7 @@ package visor
 import (
 	"time"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/transaction"
 )
@@ -120,6 +121,26 @@ func NewTransactionInputsFromUxBalance(uxb []transaction.UxBalance) []Transactio
 	return inputs
 }
 
+// TransactionProof proves that a transaction is included in a signed block.
+// Hashes is the merkle proof of the transaction hash against the header's BodyHash,
+// so the proof can be checked without the rest of the block
+type TransactionProof struct {
+	Txid   cipher.SHA256
+	Header coin.BlockHeader
+	Sig    cipher.Sig
+	Index  uint64
+	Hashes []cipher.SHA256
+}
+
+// Verify checks that the header is signed by pubkey and that the transaction is committed to by the header's BodyHash
+func (p TransactionProof) Verify(pubkey cipher.PubKey) error {
+	if err := cipher.VerifyPubKeySignedHash(pubkey, p.Sig, p.Header.Hash()); err != nil {
+		return err
+	}
+
+	return cipher.VerifyMerkleProof(p.Header.BodyHash, p.Txid, p.Index, p.Hashes)
+}
+
 // BlockchainMetadata encapsulates useful information from the coin.Blockchain
 type BlockchainMetadata struct {
 	// Most recent block
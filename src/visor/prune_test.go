@@ -0,0 +1,156 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestVisorPruneBlocks(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	c.v.Config.PruneBlocks = blockdb.MinPruneKeepBlocks
+	c.v.history = disabledHistory{}
+	err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return eraseHistory(tx, historydb.New())
+	})
+	require.NoError(t, err)
+
+	// Split the genesis output so that each block can spend an output with coin hours,
+	// then add blocks until some blocks are old enough to be pruned
+	nBlocks := uint64(blockdb.MinPruneKeepBlocks + 5)
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, int(nBlocks), params.UserVerifyTxn.MaxDropletPrecision)
+	sb := c.addBlock(splitTxn)
+	splitUxs := coin.CreateUnspents(sb.Head, splitTxn)
+
+	txns := []coin.Transaction{splitTxn}
+	toAddr := testutil.MakeAddress()
+	for i := uint64(1); i < nBlocks; i++ {
+		txn := makeSpendTxn(t, coin.UxArray{splitUxs[i]}, []cipher.SecKey{genSecret}, toAddr, 1e6)
+		txns = append(txns, txn)
+		c.addBlock(txn)
+	}
+
+	require.Equal(t, nBlocks, c.head().Seq())
+
+	var prunedSeq uint64
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		var ok bool
+		var err error
+		prunedSeq, ok, err = c.v.blockchain.PrunedSeq(tx)
+		require.True(t, ok)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, nBlocks-blockdb.MinPruneKeepBlocks, prunedSeq)
+
+	// The genesis block is never pruned
+	require.NotEmpty(t, c.block(0).Body.Transactions)
+
+	// Old blocks keep their headers and signatures, but not their bodies
+	for i := uint64(1); i <= nBlocks; i++ {
+		var b *coin.SignedBlock
+		err := c.v.db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			b, err = c.v.blockchain.GetSignedBlockBySeq(tx, i)
+			return err
+		})
+		require.NoError(t, err)
+
+		if i <= prunedSeq {
+			require.Empty(t, b.Body.Transactions)
+		} else {
+			require.Len(t, b.Body.Transactions, 1)
+			require.Equal(t, txns[i-1].Hash(), b.Body.Transactions[0].Hash())
+		}
+	}
+
+	// Pruned blocks are not returned by the block queries
+	_, err = c.v.GetSignedBlockBySeq(prunedSeq)
+	require.Equal(t, NewErrBlockPruned(prunedSeq), err)
+
+	_, _, err = c.v.GetSignedBlockBySeqVerbose(1)
+	require.Equal(t, NewErrBlockPruned(1), err)
+
+	prunedHash := c.head().HashHeader()
+	for i := c.head().Seq(); i > prunedSeq; i-- {
+		prunedHash = c.block(i).Head.PrevHash
+	}
+	_, err = c.v.GetSignedBlockByHash(prunedHash)
+	require.Equal(t, NewErrBlockPruned(prunedSeq), err)
+
+	_, err = c.v.GetBlock(1)
+	require.Equal(t, NewErrBlockPruned(1), err)
+
+	_, err = c.v.GetBlocks([]uint64{0, 2})
+	require.Equal(t, NewErrBlockPruned(2), err)
+
+	_, err = c.v.GetBlocksInRange(prunedSeq, prunedSeq+1)
+	require.Equal(t, NewErrBlockPruned(prunedSeq), err)
+
+	_, err = c.v.GetLastBlocks(blockdb.MinPruneKeepBlocks + 1)
+	require.Equal(t, NewErrBlockPruned(prunedSeq), err)
+
+	blocks, err := c.v.GetLastBlocks(blockdb.MinPruneKeepBlocks)
+	require.NoError(t, err)
+	require.Len(t, blocks, blockdb.MinPruneKeepBlocks)
+
+	// The genesis block is never pruned
+	b, err := c.v.GetSignedBlockBySeq(0)
+	require.NoError(t, err)
+	require.NotEmpty(t, b.Body.Transactions)
+
+	// Pruned blocks cannot be served to peers
+	blocks, err = c.v.GetSignedBlocksSince(prunedSeq-1, 10)
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+
+	blocks, err = c.v.GetSignedBlocksSince(prunedSeq, 10)
+	require.NoError(t, err)
+	require.Len(t, blocks, 10)
+	require.Equal(t, prunedSeq+1, blocks[0].Seq())
+
+	// The history is disabled
+	_, err = c.v.GetTransaction(txns[0].Hash())
+	require.Equal(t, ErrHistoryDisabled, err)
+
+	// The pruned database passes verification
+	err = CheckDatabase(c.v.db, genPublic, nil)
+	require.NoError(t, err)
+
+	// A pruned database can't be opened without pruning enabled
+	cfg := c.v.Config
+	cfg.PruneBlocks = 0
+	_, err = New(cfg, c.v.db, nil)
+	require.Equal(t, ErrPruningRequired, err)
+
+	cfg.PruneBlocks = blockdb.MinPruneKeepBlocks
+	v, err := New(cfg, c.v.db, nil)
+	require.NoError(t, err)
+	require.Equal(t, disabledHistory{}, v.history)
+}
+
+func TestConfigVerifyPruneBlocks(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Distribution = params.MainNetDistribution
+
+	cfg.PruneBlocks = 0
+	require.NoError(t, cfg.Verify())
+
+	cfg.PruneBlocks = blockdb.MinPruneKeepBlocks
+	require.NoError(t, cfg.Verify())
+
+	cfg.PruneBlocks = blockdb.MinPruneKeepBlocks - 1
+	require.Error(t, cfg.Verify())
+}
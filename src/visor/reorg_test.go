@@ -0,0 +1,469 @@
+package visor
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// testChain builds a blockchain with its own database, for creating competing chains.
+// All testChains share the same genesis block, so the blocks of one chain can be
+// executed by the visor of another chain to trigger a reorganization.
+type testChain struct {
+	t        testing.TB
+	v        *Visor
+	shutdown func()
+	now      uint64
+}
+
+func newTestChain(t testing.TB) *testChain {
+	db, shutdown := prepareDB(t)
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey: genPublic,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.BlockchainSeckey = genSecret
+	cfg.GenesisAddress = genAddress
+	cfg.Distribution = params.MainNetDistribution
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		distOutputs: &distributionOutputsCache{},
+	}
+
+	addGenesisBlockToVisor(t, v)
+
+	return &testChain{
+		t:        t,
+		v:        v,
+		shutdown: shutdown,
+		now:      genTime + 3600*24*365,
+	}
+}
+
+// fork creates a new testChain that copies this chain's main chain up to and including seq
+func (c *testChain) fork(seq uint64) *testChain {
+	f := newTestChain(c.t)
+	f.now = c.now
+
+	for i := uint64(1); i <= seq; i++ {
+		b := c.block(i)
+		err := f.v.ExecuteSignedBlock(b)
+		require.NoError(c.t, err)
+	}
+
+	return f
+}
+
+// addBlock creates and executes a block with the given transactions
+func (c *testChain) addBlock(txns ...coin.Transaction) coin.SignedBlock {
+	for _, txn := range txns {
+		err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+			_, softErr, err := c.v.unconfirmed.InjectTransaction(tx, c.v.blockchain, txn, c.v.Config.Distribution, c.v.Config.UnconfirmedVerifyTxn)
+			require.Nil(c.t, softErr)
+			return err
+		})
+		require.NoError(c.t, err)
+	}
+
+	c.now += 100
+
+	var sb coin.SignedBlock
+	err := c.v.db.Update("", func(tx *dbutil.Tx) error {
+		var err error
+		sb, err = c.v.createBlock(tx, c.now)
+		if err != nil {
+			return err
+		}
+
+		return c.v.executeSignedBlock(tx, sb)
+	})
+	require.NoError(c.t, err)
+	require.Len(c.t, sb.Body.Transactions, len(txns))
+
+	return sb
+}
+
+// block returns the main chain block at seq
+func (c *testChain) block(seq uint64) coin.SignedBlock {
+	b, err := c.v.GetSignedBlockBySeq(seq)
+	require.NoError(c.t, err)
+	require.NotNil(c.t, b)
+	return *b
+}
+
+// head returns the head block
+func (c *testChain) head() coin.SignedBlock {
+	b, err := c.v.GetHeadBlock()
+	require.NoError(c.t, err)
+	return *b
+}
+
+// requireSameState checks that the main chain, unspent pool and history of both chains match
+func (c *testChain) requireSameState(other *testChain) {
+	t := c.t
+
+	require.Equal(t, other.head().HashHeader(), c.head().HashHeader())
+
+	for i := uint64(0); i <= c.head().Seq(); i++ {
+		require.Equal(t, other.block(i).HashHeader(), c.block(i).HashHeader())
+	}
+
+	expectedUxs, err := other.v.GetAllUnspentOutputs()
+	require.NoError(t, err)
+	uxs, err := c.v.GetAllUnspentOutputs()
+	require.NoError(t, err)
+
+	sortUxs := func(uxs coin.UxArray) {
+		sort.Slice(uxs, func(i, j int) bool {
+			return uxs[i].Hash().Hex() < uxs[j].Hash().Hex()
+		})
+	}
+	sortUxs(expectedUxs)
+	sortUxs(uxs)
+	require.Equal(t, expectedUxs, uxs)
+
+	var addrs []cipher.Address
+	for _, ux := range uxs {
+		addrs = append(addrs, ux.Body.Address)
+	}
+
+	getState := func(v *Visor) (cipher.SHA256, uint64, blockdb.AddressHashes, uint64) {
+		var uxHash cipher.SHA256
+		var addrCount uint64
+		var addrHashes blockdb.AddressHashes
+		var parsedSeq uint64
+		err := v.db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			uxHash, err = v.blockchain.Unspent().GetUxHash(tx)
+			if err != nil {
+				return err
+			}
+
+			addrCount, err = v.blockchain.Unspent().AddressCount(tx)
+			if err != nil {
+				return err
+			}
+
+			addrHashes, err = v.blockchain.Unspent().GetUnspentHashesOfAddrs(tx, addrs)
+			if err != nil {
+				return err
+			}
+
+			parsedSeq, _, err = v.history.ParsedBlockSeq(tx)
+			return err
+		})
+		require.NoError(t, err)
+
+		for _, hashes := range addrHashes {
+			sort.Slice(hashes, func(i, j int) bool {
+				return hashes[i].Hex() < hashes[j].Hex()
+			})
+		}
+
+		return uxHash, addrCount, addrHashes, parsedSeq
+	}
+
+	expectedUxHash, expectedAddrCount, expectedAddrHashes, expectedParsedSeq := getState(other.v)
+	uxHash, addrCount, addrHashes, parsedSeq := getState(c.v)
+	require.Equal(t, expectedUxHash, uxHash)
+	require.Equal(t, expectedAddrCount, addrCount)
+	require.Equal(t, expectedAddrHashes, addrHashes)
+	require.Equal(t, expectedParsedSeq, parsedSeq)
+
+	for _, addr := range addrs {
+		expectedTxns := c.confirmedTransactionsForAddress(other.v, addr)
+		txns := c.confirmedTransactionsForAddress(c.v, addr)
+		require.Equal(t, len(expectedTxns), len(txns))
+		for i := range txns {
+			require.Equal(t, expectedTxns[i].Transaction.Hash(), txns[i].Transaction.Hash())
+			require.Equal(t, expectedTxns[i].Status, txns[i].Status)
+		}
+	}
+
+	// The database must pass verification, including the historydb
+	err = CheckDatabase(c.v.db, genPublic, nil)
+	require.NoError(t, err)
+}
+
+// confirmedTransactionsForAddress returns the confirmed transactions of an address,
+// the unconfirmed pools of the chains being compared may differ
+func (c *testChain) confirmedTransactionsForAddress(v *Visor, addr cipher.Address) []Transaction {
+	txns, err := v.GetTransactionsForAddress(addr)
+	require.NoError(c.t, err)
+
+	var confirmed []Transaction
+	for _, txn := range txns {
+		if txn.Status.Confirmed {
+			confirmed = append(confirmed, txn)
+		}
+	}
+
+	return confirmed
+}
+
+func (c *testChain) requireUnconfirmed(txns ...coin.Transaction) {
+	utxns, err := c.v.GetAllUnconfirmedTransactions()
+	require.NoError(c.t, err)
+
+	var hashes []cipher.SHA256
+	for _, utxn := range utxns {
+		hashes = append(hashes, utxn.Transaction.Hash())
+	}
+
+	var expectedHashes []cipher.SHA256
+	for _, txn := range txns {
+		expectedHashes = append(expectedHashes, txn.Hash())
+	}
+
+	sortHashes := func(hashes []cipher.SHA256) {
+		sort.Slice(hashes, func(i, j int) bool {
+			return hashes[i].Hex() < hashes[j].Hex()
+		})
+	}
+	sortHashes(expectedHashes)
+	sortHashes(hashes)
+
+	require.Equal(c.t, expectedHashes, hashes)
+}
+
+func (c *testChain) requireTransaction(txn coin.Transaction, seq uint64) {
+	t, err := c.v.GetTransaction(txn.Hash())
+	require.NoError(c.t, err)
+	require.NotNil(c.t, t)
+	require.True(c.t, t.Status.Confirmed)
+	require.Equal(c.t, seq, t.Status.BlockSeq)
+}
+
+func (c *testChain) requireNoConfirmedTransaction(txn coin.Transaction) {
+	t, err := c.v.GetTransaction(txn.Hash())
+	require.NoError(c.t, err)
+	if t != nil {
+		require.False(c.t, t.Status.Confirmed)
+	}
+}
+
+func TestExecuteForkBlockReorganize(t *testing.T) {
+	main := newTestChain(t)
+	defer main.shutdown()
+
+	// Split the genesis output so that the competing chains can spend different outputs
+	gb := main.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, 10, params.UserVerifyTxn.MaxDropletPrecision)
+	b1 := main.addBlock(splitTxn)
+	uxs := coin.CreateUnspents(b1.Head, splitTxn)
+
+	toAddr := testutil.MakeAddress()
+	var coins uint64 = 9e6
+	spend := func(ux coin.UxOut, fee uint64) coin.Transaction {
+		return makeSpendTxWithFee(t, coin.UxArray{ux}, []cipher.SecKey{genSecret}, toAddr, coins, fee)
+	}
+
+	// Competing chain forks after block 1
+	other := main.fork(1)
+	defer other.shutdown()
+
+	// Main chain: blocks 2 and 3
+	tA1 := spend(uxs[0], 10)
+	tA2 := spend(uxs[1], 10)
+	tA3 := spend(uxs[3], 10)
+	main.addBlock(tA1)
+	a3 := main.addBlock(tA2, tA3)
+
+	// Competing chain: blocks 2', 3' and 4'.
+	// tB1 double spends the input of tA2, tA1 is included in both chains.
+	tB1 := spend(uxs[1], 20)
+	tB2 := spend(uxs[2], 10)
+	b2 := other.addBlock(tB1)
+	b3 := other.addBlock(tA1)
+	b4 := other.addBlock(tB2)
+
+	// A shorter competing chain is stored without changing the main chain
+	err := main.v.ExecuteSignedBlock(b2)
+	require.NoError(t, err)
+	require.Equal(t, a3.HashHeader(), main.head().HashHeader())
+
+	sb, err := main.v.GetSignedBlockByHash(b2.HashHeader())
+	require.NoError(t, err)
+	require.NotNil(t, sb)
+
+	// A competing chain of the same length does not replace the main chain
+	err = main.v.ExecuteSignedBlock(b3)
+	require.NoError(t, err)
+	require.Equal(t, a3.HashHeader(), main.head().HashHeader())
+	main.requireTransaction(tA2, 3)
+
+	// An invalid block that would make the competing chain longer is rejected,
+	// and the main chain is not changed
+	badB4 := b4
+	badB4.Head.UxHash = testutil.RandSHA256(t)
+	badB4.Sig = cipher.MustSignHash(badB4.HashHeader(), genSecret)
+	err = main.v.ExecuteSignedBlock(badB4)
+	testutil.RequireError(t, err, "UxHash does not match")
+	require.Equal(t, a3.HashHeader(), main.head().HashHeader())
+	sb, err = main.v.GetSignedBlockByHash(badB4.HashHeader())
+	require.NoError(t, err)
+	require.Nil(t, sb)
+	main.requireTransaction(tA2, 3)
+
+	// The competing chain becomes longer and replaces the main chain
+	err = main.v.ExecuteSignedBlock(b4)
+	require.NoError(t, err)
+	main.requireSameState(other)
+
+	main.requireTransaction(tA1, 3)
+	main.requireTransaction(tB1, 2)
+	main.requireTransaction(tB2, 4)
+	main.requireNoConfirmedTransaction(tA2)
+	main.requireNoConfirmedTransaction(tA3)
+
+	// tA2 conflicts with tB1 and is dropped, tA3 returns to the unconfirmed pool
+	main.requireUnconfirmed(tA3)
+
+	// The old chain becomes longer again and is restored
+	err = main.v.db.Update("", func(tx *dbutil.Tx) error {
+		return main.v.unconfirmed.RemoveTransactions(tx, []cipher.SHA256{tA3.Hash()})
+	})
+	require.NoError(t, err)
+
+	original := main.fork(1)
+	defer original.shutdown()
+	original.now = main.now
+	err = original.v.ExecuteSignedBlock(main.mustGetBlockByHash(a3.Head.PrevHash))
+	require.NoError(t, err)
+	err = original.v.ExecuteSignedBlock(a3)
+	require.NoError(t, err)
+	tA4 := spend(uxs[4], 10)
+	a4 := original.addBlock(tA4)
+	a5 := original.addBlock(spend(uxs[5], 10))
+
+	err = main.v.ExecuteSignedBlock(a4)
+	require.NoError(t, err)
+	require.Equal(t, b4.HashHeader(), main.head().HashHeader())
+
+	err = main.v.ExecuteSignedBlock(a5)
+	require.NoError(t, err)
+	main.requireSameState(original)
+
+	main.requireTransaction(tA1, 2)
+	main.requireTransaction(tA2, 3)
+	main.requireTransaction(tA3, 3)
+	main.requireNoConfirmedTransaction(tB1)
+	main.requireNoConfirmedTransaction(tB2)
+
+	// tB1 conflicts with tA2 and is dropped, tB2 returns to the unconfirmed pool
+	main.requireUnconfirmed(tB2)
+}
+
+func (c *testChain) mustGetBlockByHash(hash cipher.SHA256) coin.SignedBlock {
+	b, err := c.v.GetSignedBlockByHash(hash)
+	require.NoError(c.t, err)
+	require.NotNil(c.t, b)
+	return *b
+}
+
+func TestExecuteForkBlockPublishesEvents(t *testing.T) {
+	main := newTestChain(t)
+	defer main.shutdown()
+
+	gb := main.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, 3, params.UserVerifyTxn.MaxDropletPrecision)
+	b1 := main.addBlock(splitTxn)
+	uxs := coin.CreateUnspents(b1.Head, splitTxn)
+
+	toAddr := testutil.MakeAddress()
+	spend := func(ux coin.UxOut) coin.Transaction {
+		return makeSpendTxWithFee(t, coin.UxArray{ux}, []cipher.SecKey{genSecret}, toAddr, 9e6, 10)
+	}
+
+	other := main.fork(1)
+	defer other.shutdown()
+
+	tA := spend(uxs[0])
+	a2 := main.addBlock(tA)
+	b2 := other.addBlock(spend(uxs[1]))
+	b3 := other.addBlock(spend(uxs[2]))
+
+	bus := eventbus.New()
+	defer bus.Close()
+	sub := bus.Subscribe(0)
+	main.v.Config.EventBus = bus
+
+	// A competing chain that does not replace the main chain is not published
+	err := main.v.ExecuteSignedBlock(b2)
+	require.NoError(t, err)
+	require.Empty(t, drainEvents(sub))
+
+	// The rolled back blocks are published before the applied blocks,
+	// and the orphaned transactions are published as added to the unconfirmed pool
+	err = main.v.ExecuteSignedBlock(b3)
+	require.NoError(t, err)
+	require.Equal(t, []eventbus.Event{
+		eventbus.BlockRolledBack{
+			Block: a2,
+		},
+		eventbus.BlockExecuted{
+			Block: b2,
+		},
+		eventbus.BlockExecuted{
+			Block: b3,
+		},
+		eventbus.TransactionAdded{
+			Transaction: tA,
+			Hash:        tA.Hash(),
+		},
+	}, drainEvents(sub))
+}
+
+func TestExecuteForkBlockUnknownParent(t *testing.T) {
+	main := newTestChain(t)
+	defer main.shutdown()
+
+	other := main.fork(0)
+	defer other.shutdown()
+
+	gb := main.block(0)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, 2, params.UserVerifyTxn.MaxDropletPrecision)
+
+	// The chains create different blocks at seq 1
+	main.now++
+	a1 := main.addBlock(splitTxn)
+	b1 := other.addBlock(splitTxn)
+	require.NotEqual(t, a1.HashHeader(), b1.HashHeader())
+	splitUxs := coin.CreateUnspents(b1.Head, splitTxn)
+	b2 := other.addBlock(makeSpendTxWithFee(t, splitUxs[:1], []cipher.SecKey{genSecret}, testutil.MakeAddress(), 9e6, 10))
+
+	// The parent of b2 is unknown, so the block is not treated as a fork block
+	err := main.v.db.View("", func(tx *dbutil.Tx) error {
+		isFork, err := main.v.blockchain.IsForkBlock(tx, b2.Block)
+		require.NoError(t, err)
+		require.False(t, isFork)
+		return nil
+	})
+	require.NoError(t, err)
+}
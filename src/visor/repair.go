@@ -0,0 +1,201 @@
+package visor
+
+import (
+	"errors"
+	"os"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// ErrRepairPrunedHistory is returned when rebuilding the historydb of a pruned database
+var ErrRepairPrunedHistory = errors.New("cannot rebuild the history of a pruned database, the bodies of old blocks are not available")
+
+// CompactReport describes a database compaction
+type CompactReport struct {
+	dbutil.CompactStats
+	// SrcSize is the size of the source database file in bytes
+	SrcSize int64
+	// DstSize is the size of the compacted database file in bytes
+	DstSize int64
+}
+
+// HistoryRebuild describes a historydb rebuild
+type HistoryRebuild struct {
+	// PreviousParsedSeq is the last block parsed by the previous historydb, if it had parsed any
+	PreviousParsedSeq    uint64
+	PreviousParsedExists bool
+	// Blocks is the number of blocks parsed
+	Blocks uint64
+	// Transactions is the number of transactions parsed
+	Transactions uint64
+}
+
+// UxHashRecompute describes a recomputed unspent pool xor hash
+type UxHashRecompute struct {
+	Previous   cipher.SHA256
+	Recomputed cipher.SHA256
+}
+
+// Changed returns true if the stored hash was wrong
+func (r UxHashRecompute) Changed() bool {
+	return r.Previous != r.Recomputed
+}
+
+// UnconfirmedCleanup describes the invalid unconfirmed transactions removed from the pool
+type UnconfirmedCleanup struct {
+	// Checked is the number of unconfirmed transactions that were checked
+	Checked uint64
+	// Removed are the hashes of the removed transactions
+	Removed []cipher.SHA256
+}
+
+// CompactDB copies the database src into dst, which must be a new, empty database.
+// txMaxSize limits the size of each write transaction on dst.
+func CompactDB(dst, src *dbutil.DB, txMaxSize int64) (*CompactReport, error) {
+	stats, err := dbutil.Compact(dst, src, txMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	srcInfo, err := os.Stat(src.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	dstInfo, err := os.Stat(dst.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompactReport{
+		CompactStats: *stats,
+		SrcSize:      srcInfo.Size(),
+		DstSize:      dstInfo.Size(),
+	}, nil
+}
+
+// RebuildHistory erases the historydb and parses the blocks of the main chain again.
+// The blocks are parsed in batches, if quit is closed the historydb is left partially parsed
+// and the node finishes parsing it in the background on startup.
+func RebuildHistory(db *dbutil.DB, pubkey cipher.PubKey, quit chan struct{}) (*HistoryRebuild, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return nil, err
+	}
+
+	history := historydb.New()
+
+	var r HistoryRebuild
+	if err := db.Update("RebuildHistory", func(tx *dbutil.Tx) error {
+		if _, ok, err := bc.PrunedSeq(tx); err != nil {
+			return err
+		} else if ok {
+			return ErrRepairPrunedHistory
+		}
+
+		if _, ok, err := bc.HeadSeq(tx); err != nil {
+			return err
+		} else if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		if dbutil.Exists(tx, historydb.HistoryMetaBkt) {
+			r.PreviousParsedSeq, r.PreviousParsedExists, err = history.ParsedBlockSeq(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return history.Erase(tx)
+	}); err != nil {
+		return nil, err
+	}
+
+	p := newHistoryParser(db, bc, history)
+	p.committed = func(blocks []*historydb.PreparedBlock, headSeq uint64) {
+		r.Blocks += uint64(len(blocks))
+		for _, b := range blocks {
+			r.Transactions += uint64(len(b.Block.Body.Transactions))
+		}
+	}
+
+	if err := p.run(quit); err != nil {
+		if err == errHistoryParseStopped {
+			return nil, ErrVerifyStopped
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RebuildUnspentAddrIndex rebuilds the unspent output address index from the unspent pool
+func RebuildUnspentAddrIndex(db *dbutil.DB, pubkey cipher.PubKey) (*blockdb.AddrIndexRebuild, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return nil, err
+	}
+
+	var r *blockdb.AddrIndexRebuild
+	if err := db.Update("RebuildUnspentAddrIndex", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		r, err = blockdb.NewUnspentPool().RebuildAddrIndex(tx, headSeq)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RecomputeUxHash recomputes the xor hash of the unspent pool
+func RecomputeUxHash(db *dbutil.DB) (*UxHashRecompute, error) {
+	var r UxHashRecompute
+	if err := db.Update("RecomputeUxHash", func(tx *dbutil.Tx) error {
+		var err error
+		r.Previous, r.Recomputed, err = blockdb.NewUnspentPool().RecomputeUxHash(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RemoveInvalidUnconfirmedTxns removes the unconfirmed transactions that violate hard constraints
+func RemoveInvalidUnconfirmedTxns(db *dbutil.DB, pubkey cipher.PubKey) (*UnconfirmedCleanup, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return nil, err
+	}
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var r UnconfirmedCleanup
+	if err := db.Update("RemoveInvalidUnconfirmedTxns", func(tx *dbutil.Tx) error {
+		var err error
+		r.Checked, err = unconfirmed.Len(tx)
+		if err != nil {
+			return err
+		}
+
+		r.Removed, err = unconfirmed.RemoveInvalid(tx, bc)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
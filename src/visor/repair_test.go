@@ -0,0 +1,194 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// dumpDB returns the keys and values of every bucket in the database
+func dumpDB(t *testing.T, db *dbutil.DB) map[string]map[string]string {
+	dump := make(map[string]map[string]string)
+	err := db.View("", func(tx *dbutil.Tx) error {
+		return tx.ForEach(func(name []byte, b dbutil.Bucket) error {
+			kvs := make(map[string]string)
+			dump[string(name)] = kvs
+			return b.ForEach(func(k, v []byte) error {
+				kvs[string(k)] = string(v)
+				return nil
+			})
+		})
+	})
+	require.NoError(t, err)
+	return dump
+}
+
+func TestCompactDB(t *testing.T) {
+	testutil.RequireBoltDB(t)
+
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	for _, txMaxSize := range []int64{0, 1024} {
+		db, shutdown := testutil.PrepareDB(t)
+		defer shutdown()
+
+		r, err := CompactDB(db, c.v.db, txMaxSize)
+		require.NoError(t, err)
+
+		src := dumpDB(t, c.v.db)
+		require.Equal(t, src, dumpDB(t, db))
+
+		var keys uint64
+		for _, kvs := range src {
+			keys += uint64(len(kvs))
+		}
+		require.Equal(t, uint64(len(src)), r.Buckets)
+		require.Equal(t, keys, r.Keys)
+		require.NotZero(t, r.SrcSize)
+		require.NotZero(t, r.DstSize)
+
+		err = CheckDatabase(db, genPublic, nil)
+		require.NoError(t, err)
+	}
+
+	// The destination must be writable
+	db, shutdown := testutil.PrepareDB(t)
+	defer shutdown()
+	dbPath := db.Path()
+	err := db.Close()
+	require.NoError(t, err)
+
+	ro, err := bolt.Open(dbPath, 0600, &bolt.Options{ReadOnly: true})
+	require.NoError(t, err)
+	defer ro.Close()
+
+	_, err = CompactDB(dbutil.WrapDB(ro), c.v.db, 0)
+	testutil.RequireError(t, err, "cannot compact into a read-only database")
+}
+
+func TestRepairDatabase(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	unspents := blockdb.NewUnspentPool()
+
+	var uxHash cipher.SHA256
+	var addrs []cipher.Address
+	err := c.v.db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		uxHash, err = unspents.GetUxHash(tx)
+		require.NoError(t, err)
+
+		return dbutil.ForEach(tx, blockdb.UnspentPoolAddrIndexBkt, func(k, _ []byte) error {
+			addr, err := cipher.AddressFromBytes(k)
+			require.NoError(t, err)
+			addrs = append(addrs, addr)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	require.True(t, len(addrs) >= 3)
+
+	// An unconfirmed transaction that spends an output which was spent by a block
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	invalidTxn := makeSpendTxn(t, genUxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6)
+
+	staleAddr := testutil.MakeAddress()
+
+	// Corrupt the database
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		badHash := testutil.RandSHA256(t)
+		require.NoError(t, dbutil.PutBucketValue(tx, blockdb.UnspentMetaBkt, []byte("xorhash"), badHash[:]))
+
+		// Delete, copy and corrupt address index entries
+		v, err := dbutil.GetBucketValue(tx, blockdb.UnspentPoolAddrIndexBkt, addrs[0].Bytes())
+		require.NoError(t, err)
+		require.NoError(t, dbutil.Delete(tx, blockdb.UnspentPoolAddrIndexBkt, addrs[0].Bytes()))
+		require.NoError(t, dbutil.PutBucketValue(tx, blockdb.UnspentPoolAddrIndexBkt, staleAddr.Bytes(), v))
+		require.NoError(t, dbutil.PutBucketValue(tx, blockdb.UnspentPoolAddrIndexBkt, addrs[1].Bytes(), v))
+		require.NoError(t, dbutil.PutBucketValue(tx, blockdb.UnspentPoolAddrIndexBkt, addrs[2].Bytes(), []byte{1, 2, 3}))
+
+		require.NoError(t, historydb.New().Erase(tx))
+
+		return c.v.unconfirmed.(*UnconfirmedTransactionPool).txns.put(tx, &UnconfirmedTransaction{
+			Transaction: invalidTxn,
+		})
+	})
+	require.NoError(t, err)
+
+	// Recompute the unspent hash
+	uxHashRepair, err := RecomputeUxHash(c.v.db)
+	require.NoError(t, err)
+	require.True(t, uxHashRepair.Changed())
+	require.Equal(t, uxHash, uxHashRepair.Recomputed)
+
+	uxHashRepair, err = RecomputeUxHash(c.v.db)
+	require.NoError(t, err)
+	require.False(t, uxHashRepair.Changed())
+
+	// Rebuild the address index
+	addrIndexRepair, err := RebuildUnspentAddrIndex(c.v.db, genPublic)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(addrs)), addrIndexRepair.Addresses)
+	require.Equal(t, []cipher.Address{addrs[0]}, addrIndexRepair.Missing)
+	require.Equal(t, []cipher.Address{staleAddr}, addrIndexRepair.Stale)
+	require.Len(t, addrIndexRepair.Mismatched, 2)
+	require.Contains(t, addrIndexRepair.Mismatched, addrs[1])
+	require.Contains(t, addrIndexRepair.Mismatched, addrs[2])
+	require.Equal(t, uint64(1), addrIndexRepair.Corrupt)
+
+	uxs, err := c.v.GetAllUnspentOutputs()
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(uxs)), addrIndexRepair.Outputs)
+
+	addrIndexRepair, err = RebuildUnspentAddrIndex(c.v.db, genPublic)
+	require.NoError(t, err)
+	require.Empty(t, addrIndexRepair.Missing)
+	require.Empty(t, addrIndexRepair.Stale)
+	require.Empty(t, addrIndexRepair.Mismatched)
+	require.True(t, addrIndexRepair.PreviousHeightExists)
+	require.Equal(t, c.head().Seq(), addrIndexRepair.PreviousHeight)
+
+	// Rebuild the history
+	historyRepair, err := RebuildHistory(c.v.db, genPublic, nil)
+	require.NoError(t, err)
+	require.False(t, historyRepair.PreviousParsedExists)
+	require.Equal(t, c.head().Seq()+1, historyRepair.Blocks)
+	require.Equal(t, c.head().Seq()+1, historyRepair.Transactions)
+
+	txn, err := c.v.GetTransaction(c.block(1).Body.Transactions[0].Hash())
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+
+	// Remove the invalid unconfirmed transaction
+	unconfirmedRepair, err := RemoveInvalidUnconfirmedTxns(c.v.db, genPublic)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), unconfirmedRepair.Checked)
+	require.Equal(t, []cipher.SHA256{invalidTxn.Hash()}, unconfirmedRepair.Removed)
+
+	utxns, err := c.v.GetAllUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Empty(t, utxns)
+
+	err = CheckDatabase(c.v.db, genPublic, nil)
+	require.NoError(t, err)
+
+	// The history of a pruned database can't be rebuilt
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return dbutil.PutBucketValue(tx, blockdb.BlockchainMetaBkt, []byte("pruned_seq"), dbutil.Itob(1))
+	})
+	require.NoError(t, err)
+
+	_, err = RebuildHistory(c.v.db, genPublic, nil)
+	require.Equal(t, ErrRepairPrunedHistory, err)
+}
@@ -5,6 +5,8 @@ import (
 	"sort"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 )
 
 // RichlistBalance holds info an address balance holder
@@ -62,3 +64,41 @@ func (r Richlist) FilterAddresses(addrs map[cipher.Address]struct{}) Richlist {
 	}
 	return s
 }
+
+// RichlistHolders counts the addresses holding coins
+type RichlistHolders struct {
+	// Addresses is the number of addresses with a nonzero balance
+	Addresses uint64
+	// Thresholds count the addresses holding at least each power of ten coins, from one coin up to the largest balance
+	Thresholds []RichlistHoldersThreshold
+}
+
+// RichlistHoldersThreshold is the number of addresses holding at least Coins droplets
+type RichlistHoldersThreshold struct {
+	Coins     uint64
+	Addresses uint64
+}
+
+// NewRichlistHolders creates RichlistHolders from a balance histogram
+func NewRichlistHolders(h blockdb.BalanceHistogram) *RichlistHolders {
+	holders := &RichlistHolders{
+		Addresses: h.AtLeast(0),
+	}
+
+	coins := uint64(droplet.Multiplier)
+	for exp := droplet.Exponent; exp < blockdb.BalanceHistogramSize; exp++ {
+		n := h.AtLeast(exp)
+		if n == 0 && exp > droplet.Exponent {
+			break
+		}
+
+		holders.Thresholds = append(holders.Thresholds, RichlistHoldersThreshold{
+			Coins:     coins,
+			Addresses: n,
+		})
+
+		coins *= 10
+	}
+
+	return holders
+}
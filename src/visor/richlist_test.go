@@ -1,11 +1,17 @@
 package visor
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
 func getLockedMap(distributionAddresses [4]cipher.Address) map[cipher.Address]struct{} {
@@ -122,3 +128,165 @@ func TestRichlist(t *testing.T) {
 		})
 	}
 }
+
+func TestVisorGetRichlist(t *testing.T) {
+	db, shutdown := testutil.PrepareDB(t)
+	defer shutdown()
+
+	var distAddrs []cipher.Address
+	var distAddrStrs []string
+	for i := 0; i < 4; i++ {
+		a := testutil.MakeAddress()
+		distAddrs = append(distAddrs, a)
+		distAddrStrs = append(distAddrStrs, a.String())
+	}
+
+	otherAddrs := []cipher.Address{
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+	}
+
+	// distAddrs[0] and distAddrs[1] are unlocked, distAddrs[2] and distAddrs[3] are locked
+	dist := params.Distribution{
+		MaxCoinSupply:        4e6,
+		InitialUnlockedCount: 2,
+		Addresses:            distAddrStrs,
+	}
+
+	balances := map[cipher.Address]uint64{
+		otherAddrs[0]: 50e6,
+		distAddrs[2]:  3e6,
+		distAddrs[0]:  3e6,
+		otherAddrs[1]: 3e6,
+		distAddrs[3]:  1e6,
+		otherAddrs[2]: 5e5,
+	}
+
+	var uxs coin.UxArray
+	for a, coins := range balances {
+		uxs = append(uxs, coin.UxOut{
+			Head: coin.UxHead{
+				BkSeq: 1,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        a,
+				Coins:          coins,
+				Hours:          10,
+			},
+		})
+	}
+
+	unspent := blockdb.NewUnspentPool()
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		if err := blockdb.CreateBuckets(tx); err != nil {
+			return err
+		}
+		return unspent.Load(tx, uxs, 1)
+	})
+	require.NoError(t, err)
+
+	bc := &MockBlockchainer{}
+	bc.On("Unspent").Return(unspent)
+
+	v := &Visor{
+		blockchain: bc,
+		db:         db,
+		Config: Config{
+			Distribution: dist,
+		},
+	}
+
+	// Locked addresses come first among equal balances, then the addresses are ordered by bytes
+	tie := []cipher.Address{distAddrs[0], otherAddrs[1]}
+	if bytes.Compare(tie[1].Bytes(), tie[0].Bytes()) < 0 {
+		tie[0], tie[1] = tie[1], tie[0]
+	}
+
+	full := Richlist{
+		{Address: otherAddrs[0], Coins: 50e6},
+		{Address: distAddrs[2], Coins: 3e6, Locked: true},
+		{Address: tie[0], Coins: 3e6},
+		{Address: tie[1], Coins: 3e6},
+		{Address: distAddrs[3], Coins: 1e6, Locked: true},
+		{Address: otherAddrs[2], Coins: 5e5},
+	}
+
+	noDist := Richlist{
+		{Address: otherAddrs[0], Coins: 50e6},
+		{Address: otherAddrs[1], Coins: 3e6},
+		{Address: otherAddrs[2], Coins: 5e5},
+	}
+
+	cases := []struct {
+		name                string
+		includeDistribution bool
+		offset              uint64
+		n                   uint64
+		result              Richlist
+	}{
+		{
+			name:                "all with distribution",
+			includeDistribution: true,
+			result:              full,
+		},
+		{
+			name:                "page with distribution",
+			includeDistribution: true,
+			offset:              1,
+			n:                   2,
+			result:              full[1:3],
+		},
+		{
+			name:                "locked address at the end of a page",
+			includeDistribution: true,
+			n:                   2,
+			result:              full[:2],
+		},
+		{
+			name:   "all without distribution",
+			result: noDist,
+		},
+		{
+			name:   "last page without distribution",
+			offset: 2,
+			n:      5,
+			result: noDist[2:],
+		},
+		{
+			name:   "offset past the end",
+			offset: 10,
+			n:      1,
+			result: Richlist{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			richlist, err := v.GetRichlist(tc.includeDistribution, tc.offset, tc.n)
+			require.NoError(t, err)
+			require.Equal(t, tc.result, richlist)
+		})
+	}
+
+	holders, err := v.GetRichlistHolders(true)
+	require.NoError(t, err)
+	require.Equal(t, &RichlistHolders{
+		Addresses: 6,
+		Thresholds: []RichlistHoldersThreshold{
+			{Coins: 1e6, Addresses: 5},
+			{Coins: 10e6, Addresses: 1},
+		},
+	}, holders)
+
+	holders, err = v.GetRichlistHolders(false)
+	require.NoError(t, err)
+	require.Equal(t, &RichlistHolders{
+		Addresses: 3,
+		Thresholds: []RichlistHoldersThreshold{
+			{Coins: 1e6, Addresses: 2},
+			{Coins: 10e6, Addresses: 1},
+		},
+	}, holders)
+}
@@ -0,0 +1,295 @@
+package visor
+
+/*
+
+sigverify.go: Parallel verification of transaction input signatures
+
+Recovering the public keys of input signatures is the most expensive part of executing a block.
+The input signatures of all transactions in a block are verified in a bounded pool of workers
+before the transactions are verified sequentially. Transactions whose signatures were verified
+are verified sequentially with TxnAssumeSigned, which keeps all of the other checks, including
+the unspent and double spend checks against the unspent output pool. Transactions whose signatures
+could not be verified are verified sequentially with their signatures, which returns the same
+error as when verifying without the worker pool.
+
+When syncing, the signatures of a window of pending blocks are verified ahead of their execution.
+Only the blocks that are signed by the block publisher and chain from the head block are verified,
+so that a peer can't make the node spend work on blocks that will not be executed.
+The block signatures verified for the window are remembered too, so they are not verified again
+when the blocks are executed.
+The addresses of outputs that are created by earlier blocks in the window are taken from those blocks.
+This is safe because an output's hash commits to its address, so a signature verified against the
+address of an output is valid for any unspent output with the same hash.
+
+*/
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// txnSigVerifier verifies the input signatures of transactions in a bounded pool of workers.
+// It remembers the transactions and signatures of pending blocks that were verified ahead of their execution.
+type txnSigVerifier struct {
+	workers int
+
+	sync.RWMutex
+	verified map[cipher.SHA256]struct{}
+	// blockSigs are the verified signatures of the pending blocks, by block hash
+	blockSigs map[cipher.SHA256]cipher.Sig
+}
+
+// newTxnSigVerifier creates a txnSigVerifier. If workers is 0, the number of CPUs is used
+func newTxnSigVerifier(workers int) *txnSigVerifier {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &txnSigVerifier{
+		workers: workers,
+	}
+}
+
+// sigJob is an input signature of a transaction to verify
+type sigJob struct {
+	txn int
+	in  int
+}
+
+// verify verifies the input signatures of txns. addrs[i] are the addresses of the outputs spent by txns[i],
+// or nil if they are not known. Returns whether all of the input signatures of each transaction are valid.
+// Transactions whose addresses are not known are not verified.
+func (v *txnSigVerifier) verify(txns coin.Transactions, addrs [][]cipher.Address) []bool {
+	valid := make([]bool, len(txns))
+
+	var jobs []sigJob
+	for i, txn := range txns {
+		if addrs[i] == nil || len(txn.In) == 0 || len(txn.Sigs) != len(txn.In) || len(addrs[i]) != len(txn.In) {
+			continue
+		}
+
+		valid[i] = true
+		for j := range txn.In {
+			jobs = append(jobs, sigJob{
+				txn: i,
+				in:  j,
+			})
+		}
+	}
+
+	failed := make([]bool, len(jobs))
+	verifyJob := func(k int) {
+		job := jobs[k]
+		txn := &txns[job.txn]
+		sig := txn.Sigs[job.in]
+		if sig.Null() {
+			failed[k] = true
+			return
+		}
+
+		hash := cipher.AddSHA256(txn.InnerHash, txn.In[job.in]) // use inner hash, not outer hash
+		failed[k] = cipher.VerifyAddressSignedHash(addrs[job.txn][job.in], sig, hash) != nil
+	}
+
+	workers := v.workers
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	if workers <= 1 {
+		for k := range jobs {
+			verifyJob(k)
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for k := range next {
+					verifyJob(k)
+				}
+			}()
+		}
+
+		for k := range jobs {
+			next <- k
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	for k, job := range jobs {
+		if failed[k] {
+			valid[job.txn] = false
+		}
+	}
+
+	return valid
+}
+
+// remember replaces the set of transactions and block signatures that were verified ahead of their execution
+func (v *txnSigVerifier) remember(hashes []cipher.SHA256, blockSigs map[cipher.SHA256]cipher.Sig) {
+	verified := make(map[cipher.SHA256]struct{}, len(hashes))
+	for _, h := range hashes {
+		verified[h] = struct{}{}
+	}
+
+	v.Lock()
+	defer v.Unlock()
+	v.verified = verified
+	v.blockSigs = blockSigs
+}
+
+// isBlockSigVerified returns true if sig was verified as the signature of the block ahead of its execution
+func (v *txnSigVerifier) isBlockSigVerified(hash cipher.SHA256, sig cipher.Sig) bool {
+	v.RLock()
+	defer v.RUnlock()
+	verifiedSig, ok := v.blockSigs[hash]
+	return ok && verifiedSig == sig
+}
+
+// isVerified returns true if the signatures of the transaction were verified ahead of its execution
+func (v *txnSigVerifier) isVerified(hash cipher.SHA256) bool {
+	v.RLock()
+	defer v.RUnlock()
+	_, ok := v.verified[hash]
+	return ok
+}
+
+// hasVerified returns true if the signatures of any transactions were verified ahead of their execution
+func (v *txnSigVerifier) hasVerified() bool {
+	v.RLock()
+	defer v.RUnlock()
+	return len(v.verified) != 0
+}
+
+// verifyTxnSignatures verifies the input signatures of txns in parallel, against the outputs they spend
+// in the unspent pool. Returns whether all of the input signatures of each transaction are valid.
+// Transactions whose inputs are not all in the unspent pool are not verified.
+func (bc Blockchain) verifyTxnSignatures(tx *dbutil.Tx, txns coin.Transactions) ([]bool, error) {
+	hasVerified := bc.sigVerifier.hasVerified()
+	verified := make([]bool, len(txns))
+	addrs := make([][]cipher.Address, len(txns))
+	for i, txn := range txns {
+		if hasVerified && bc.sigVerifier.isVerified(txn.Hash()) {
+			verified[i] = true
+			continue
+		}
+
+		uxIn, err := bc.Unspent().GetArray(tx, txn.In)
+		if err != nil {
+			switch err.(type) {
+			case blockdb.ErrUnspentNotExist:
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		addrs[i] = make([]cipher.Address, len(uxIn))
+		for j, ux := range uxIn {
+			addrs[i][j] = ux.Body.Address
+		}
+	}
+
+	valid := bc.sigVerifier.verify(txns, addrs)
+	for i := range valid {
+		valid[i] = valid[i] || verified[i]
+	}
+
+	return valid, nil
+}
+
+// VerifyPendingBlockSignatures verifies the input signatures of the transactions in a window of pending blocks
+// in parallel, ahead of their execution. The blocks are expected to be in sequence and follow the head block.
+// The window stops at the first block that is not signed by the block publisher, or does not follow
+// the head block or the previous block of the window.
+// The transactions with valid signatures and the block signatures are not verified again when the blocks are executed.
+// Invalid signatures are not reported, and transactions whose inputs are not known are not verified,
+// they are verified when the blocks are executed.
+func (bc *Blockchain) VerifyPendingBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error {
+	prev, err := bc.Head(tx)
+	if err != nil {
+		return err
+	}
+
+	// Addresses of the outputs created by the pending blocks
+	created := make(map[cipher.SHA256]cipher.Address)
+
+	blockSigs := make(map[cipher.SHA256]cipher.Sig)
+
+	var txns coin.Transactions
+	var addrs [][]cipher.Address
+	for i := range blocks {
+		b := &blocks[i]
+		if !bc.isPendingBlock(prev, b) {
+			break
+		}
+		prev = b
+		blockSigs[b.HashHeader()] = b.Sig
+
+		signed, err := bc.txnSignedFlag(tx, b.Block)
+		if err != nil {
+			return err
+		}
+		verifySigs := signed == TxnSigned
+
+		for _, txn := range b.Body.Transactions {
+			if verifySigs {
+				txnAddrs := make([]cipher.Address, len(txn.In))
+				for j, h := range txn.In {
+					if addr, ok := created[h]; ok {
+						txnAddrs[j] = addr
+						continue
+					}
+
+					ux, err := bc.Unspent().Get(tx, h)
+					if err != nil {
+						return err
+					} else if ux == nil {
+						txnAddrs = nil
+						break
+					}
+
+					txnAddrs[j] = ux.Body.Address
+				}
+
+				txns = append(txns, txn)
+				addrs = append(addrs, txnAddrs)
+			}
+
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				created[ux.Hash()] = ux.Body.Address
+			}
+		}
+	}
+
+	valid := bc.sigVerifier.verify(txns, addrs)
+
+	var hashes []cipher.SHA256
+	for i, txn := range txns {
+		if valid[i] {
+			hashes = append(hashes, txn.Hash())
+		}
+	}
+
+	bc.sigVerifier.remember(hashes, blockSigs)
+
+	return nil
+}
+
+// isPendingBlock returns true if b is signed by the block publisher and follows prev
+func (bc *Blockchain) isPendingBlock(prev, b *coin.SignedBlock) bool {
+	if b.Seq() != prev.Seq()+1 || b.Head.PrevHash != prev.HashHeader() {
+		return false
+	}
+
+	return b.VerifySignature(bc.cfg.Pubkey) == nil
+}
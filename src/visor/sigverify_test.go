@@ -0,0 +1,386 @@
+package visor
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func setTestSigVerifyWorkers(c *testChain, workers int) {
+	bc := c.v.blockchain.(*Blockchain)
+	bc.sigVerifier = newTxnSigVerifier(workers)
+}
+
+// addMultiInputBlocks adds n blocks to the chain, each with txnsPerBlock transactions that spend inputsPerTxn
+// outputs of genAddress, send coins to another address and return the change to genAddress in inputsPerTxn outputs.
+// The change output of the head block is first split into the outputs that are spent by the blocks,
+// and each block spends the outputs that were created two blocks before it.
+func addMultiInputBlocks(t testing.TB, c *testChain, n, txnsPerBlock, inputsPerTxn int) {
+	makeTxn := func(uxs coin.UxArray, headTime uint64, nChange int, toAddr cipher.Address) coin.Transaction {
+		var txn coin.Transaction
+		var coins, hours uint64
+		for _, ux := range uxs {
+			err := txn.PushInput(ux.Hash())
+			require.NoError(t, err)
+
+			coins += ux.Body.Coins
+			h, err := ux.CoinHours(headTime)
+			require.NoError(t, err)
+			hours += h
+		}
+
+		sent := uint64(0)
+		if !toAddr.Null() {
+			sent = 1e6
+			err := txn.PushOutput(toAddr, sent, 0)
+			require.NoError(t, err)
+		}
+
+		// The change outputs have different coins, so that they are not duplicates
+		change := (coins - sent) / uint64(nChange) / 1e6 * 1e6
+		changeHours := hours / 2 / uint64(nChange)
+		remaining := coins - sent
+		for i := 0; i < nChange; i++ {
+			c := remaining
+			if i != nChange-1 {
+				c = change - uint64(i)*1e3
+			}
+			remaining -= c
+
+			err := txn.PushOutput(genAddress, c, changeHours)
+			require.NoError(t, err)
+		}
+
+		keys := make([]cipher.SecKey, len(uxs))
+		for i := range keys {
+			keys[i] = genSecret
+		}
+		txn.SignInputs(keys)
+		err := txn.UpdateHeader()
+		require.NoError(t, err)
+		return txn
+	}
+
+	spendsPerBlock := txnsPerBlock * inputsPerTxn
+
+	head := c.head()
+	txn := head.Body.Transactions[len(head.Body.Transactions)-1]
+	uxs := coin.CreateUnspents(head.Head, txn)
+
+	// Split the change output into two blocks worth of outputs
+	txn = makeTxn(uxs[len(uxs)-1:], head.Time(), 2*spendsPerBlock, cipher.Address{})
+	b := c.addBlock(txn)
+	pending := coin.CreateUnspents(b.Head, txn)
+
+	toAddr := testutil.MakeAddress()
+	for i := 0; i < n; i++ {
+		// Let an hour pass, so that the small outputs earn coin hours for the fees
+		c.now += 3600
+
+		headTime := c.head().Time()
+		txns := make(coin.Transactions, txnsPerBlock)
+		for j := range txns {
+			txns[j] = makeTxn(pending[j*inputsPerTxn:(j+1)*inputsPerTxn], headTime, inputsPerTxn, toAddr)
+		}
+
+		b := c.addBlock(txns...)
+
+		pending = pending[spendsPerBlock:]
+		for _, txn := range b.Body.Transactions {
+			pending = append(pending, coin.CreateUnspents(b.Head, txn)[1:]...)
+		}
+	}
+}
+
+func TestTxnSigVerifierVerify(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addMultiInputBlocks(t, c, 1, 3, 3)
+
+	b := c.head()
+	txns := b.Body.Transactions
+	require.Len(t, txns, 3)
+
+	addrs := make([][]cipher.Address, len(txns))
+	for i, txn := range txns {
+		for range txn.In {
+			addrs[i] = append(addrs[i], genAddress)
+		}
+	}
+
+	// A transaction with an input signed by another key
+	badTxn := txns[1]
+	badTxn.Sigs = append([]cipher.Sig{}, badTxn.Sigs...)
+	_, badSecret := cipher.GenerateKeyPair()
+	badTxn.Sigs[2] = cipher.MustSignHash(cipher.AddSHA256(badTxn.InnerHash, badTxn.In[2]), badSecret)
+
+	// A transaction with a null signature
+	nullSigTxn := txns[2]
+	nullSigTxn.Sigs = append([]cipher.Sig{}, nullSigTxn.Sigs...)
+	nullSigTxn.Sigs[0] = cipher.Sig{}
+
+	for _, workers := range []int{1, 2, 4, 16} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			v := newTxnSigVerifier(workers)
+
+			valid := v.verify(txns, addrs)
+			require.Equal(t, []bool{true, true, true}, valid)
+
+			// The addresses of the second transaction are not known
+			valid = v.verify(txns, [][]cipher.Address{addrs[0], nil, addrs[2]})
+			require.Equal(t, []bool{true, false, true}, valid)
+
+			// The spent outputs belong to another address
+			otherAddrs := make([]cipher.Address, len(txns[0].In))
+			otherAddrs[0] = testutil.MakeAddress()
+			valid = v.verify(txns, [][]cipher.Address{otherAddrs, addrs[1], addrs[2]})
+			require.Equal(t, []bool{false, true, true}, valid)
+
+			valid = v.verify(coin.Transactions{txns[0], badTxn, nullSigTxn}, addrs)
+			require.Equal(t, []bool{true, false, false}, valid)
+
+			valid = v.verify(nil, nil)
+			require.Empty(t, valid)
+		})
+	}
+}
+
+func TestVerifyPendingBlockSignatures(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	addMultiInputBlocks(t, c, 4, 2, 3)
+
+	f := newTestChain(t)
+	defer f.shutdown()
+
+	var blocks []coin.SignedBlock
+	for i := uint64(1); i <= c.head().Seq(); i++ {
+		blocks = append(blocks, c.block(i))
+	}
+
+	// The blocks spend outputs created by earlier blocks of the window
+	err := f.v.VerifyPendingBlockSignatures(blocks)
+	require.NoError(t, err)
+
+	sigVerifier := f.v.blockchain.(*Blockchain).sigVerifier
+	for _, b := range blocks {
+		require.True(t, sigVerifier.isBlockSigVerified(b.HashHeader(), b.Sig))
+		for _, txn := range b.Body.Transactions {
+			require.True(t, sigVerifier.isVerified(txn.Hash()))
+		}
+	}
+
+	// A block with a verified header but a different signature is verified again and rejected
+	_, otherSecret := cipher.GenerateKeyPair()
+	otherSig := blocks[0]
+	otherSig.Sig = cipher.MustSignHash(otherSig.HashHeader(), otherSecret)
+	require.False(t, sigVerifier.isBlockSigVerified(otherSig.HashHeader(), otherSig.Sig))
+	err = f.v.ExecuteSignedBlock(otherSig)
+	require.Error(t, err)
+
+	for _, b := range blocks {
+		err := f.v.ExecuteSignedBlock(b)
+		require.NoError(t, err)
+	}
+	f.requireSameState(c)
+
+	// Transactions with invalid signatures are not remembered, and are rejected when the block is executed
+	b := makeBadSigBlock(t, c)
+	err = f.v.VerifyPendingBlockSignatures([]coin.SignedBlock{b})
+	require.NoError(t, err)
+	require.False(t, sigVerifier.isVerified(b.Body.Transactions[0].Hash()))
+	require.False(t, sigVerifier.isVerified(blocks[0].Body.Transactions[0].Hash()))
+
+	err = f.v.ExecuteSignedBlock(b)
+	testutil.RequireError(t, err, "Transaction violates hard constraint: Signature not valid for output being spent")
+
+	// Blocks that do not follow the head block are not verified
+	g := newTestChain(t)
+	defer g.shutdown()
+
+	err = g.v.VerifyPendingBlockSignatures(blocks[1:])
+	require.NoError(t, err)
+	gSigVerifier := g.v.blockchain.(*Blockchain).sigVerifier
+	require.False(t, gSigVerifier.hasVerified())
+
+	// The window stops at the first block that is not signed by the block publisher
+	badPublisher := append([]coin.SignedBlock{}, blocks...)
+	badPublisher[1].Sig = cipher.MustSignHash(badPublisher[1].HashHeader(), otherSecret)
+
+	err = g.v.VerifyPendingBlockSignatures(badPublisher)
+	require.NoError(t, err)
+	require.True(t, gSigVerifier.isVerified(blocks[0].Body.Transactions[0].Hash()))
+	require.True(t, gSigVerifier.isBlockSigVerified(blocks[0].HashHeader(), blocks[0].Sig))
+	for _, b := range blocks[1:] {
+		require.False(t, gSigVerifier.isBlockSigVerified(b.HashHeader(), b.Sig))
+		for _, txn := range b.Body.Transactions {
+			require.False(t, gSigVerifier.isVerified(txn.Hash()))
+		}
+	}
+
+	// The window stops at the first block that does not follow the previous block
+	badChain := append([]coin.SignedBlock{}, blocks...)
+	badChain[2] = blocks[3]
+
+	err = g.v.VerifyPendingBlockSignatures(badChain)
+	require.NoError(t, err)
+	require.True(t, gSigVerifier.isVerified(blocks[1].Body.Transactions[0].Hash()))
+	require.False(t, gSigVerifier.isVerified(blocks[3].Body.Transactions[0].Hash()))
+
+	// Transactions in the assume-valid block are not verified.
+	// The blocks below it are verified, since they are not known to lead to it until it is reached.
+	h := newTestChain(t)
+	defer h.shutdown()
+	setTestCheckpoints(h, nil, Checkpoint{Seq: 2, Hash: c.block(2).HashHeader()})
+
+	err = h.v.VerifyPendingBlockSignatures(blocks)
+	require.NoError(t, err)
+	hSigVerifier := h.v.blockchain.(*Blockchain).sigVerifier
+	require.True(t, hSigVerifier.isVerified(blocks[0].Body.Transactions[0].Hash()))
+	require.False(t, hSigVerifier.isVerified(blocks[1].Body.Transactions[0].Hash()))
+	require.True(t, hSigVerifier.isVerified(blocks[2].Body.Transactions[0].Hash()))
+}
+
+// benchmarkSigVerifyWorkers are the worker pool sizes compared by the signature verification benchmarks
+func benchmarkSigVerifyWorkers() []int {
+	workers := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workers = append(workers, n)
+	}
+	return workers
+}
+
+// BenchmarkTxnSigVerifier measures the verification of the input signatures of transactions:
+// a block with multiple multi-input transactions, and all of the transactions of the recorded block archive fixture
+func BenchmarkTxnSigVerifier(b *testing.B) {
+	c := newTestChain(b)
+	defer c.shutdown()
+
+	addMultiInputBlocks(b, c, 1, 8, 8)
+
+	synthetic := c.head().Body.Transactions
+	syntheticAddrs := make([][]cipher.Address, len(synthetic))
+	for i, txn := range synthetic {
+		for range txn.In {
+			syntheticAddrs[i] = append(syntheticAddrs[i], genAddress)
+		}
+	}
+
+	// The addresses of the outputs spent by the recorded transactions are taken from the blocks that created them
+	a := openBlockArchiveFixture(b)
+	created := make(map[cipher.SHA256]cipher.Address)
+	var recorded coin.Transactions
+	var recordedAddrs [][]cipher.Address
+	for seq := a.Info().StartSeq; seq <= a.Info().EndSeq; seq++ {
+		sb, err := a.GetBlock(seq)
+		require.NoError(b, err)
+
+		for _, txn := range sb.Body.Transactions {
+			if seq != 0 {
+				addrs := make([]cipher.Address, len(txn.In))
+				for j, h := range txn.In {
+					addr, ok := created[h]
+					require.True(b, ok)
+					addrs[j] = addr
+				}
+
+				recorded = append(recorded, txn)
+				recordedAddrs = append(recordedAddrs, addrs)
+			}
+
+			for _, ux := range coin.CreateUnspents(sb.Head, txn) {
+				created[ux.Hash()] = ux.Body.Address
+			}
+		}
+	}
+
+	cases := []struct {
+		name  string
+		txns  coin.Transactions
+		addrs [][]cipher.Address
+	}{
+		{
+			name:  "synthetic",
+			txns:  synthetic,
+			addrs: syntheticAddrs,
+		},
+		{
+			name:  "recorded",
+			txns:  recorded,
+			addrs: recordedAddrs,
+		},
+	}
+
+	for _, tc := range cases {
+		for _, workers := range benchmarkSigVerifyWorkers() {
+			b.Run(fmt.Sprintf("%s/workers=%d", tc.name, workers), func(b *testing.B) {
+				v := newTxnSigVerifier(workers)
+				for i := 0; i < b.N; i++ {
+					v.verify(tc.txns, tc.addrs)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkImportBlockArchiveSigVerifyWorkers measures the execution of archived blocks, for different numbers
+// of signature verification workers: blocks with multiple multi-input transactions, and the recorded block archive fixture
+func BenchmarkImportBlockArchiveSigVerifyWorkers(b *testing.B) {
+	c := newTestChain(b)
+	defer c.shutdown()
+
+	addMultiInputBlocks(b, c, 50, 4, 4)
+
+	synthetic := exportTestBlockArchive(b, c, 0, math.MaxUint64)
+	recorded := openBlockArchiveFixture(b)
+
+	cases := []struct {
+		name     string
+		archive  *BlockArchive
+		newChain func() *testChain
+	}{
+		{
+			name:    "synthetic",
+			archive: synthetic,
+			newChain: func() *testChain {
+				return newTestChain(b)
+			},
+		},
+		{
+			name:    "recorded",
+			archive: recorded,
+			newChain: func() *testChain {
+				return newBlockArchiveFixtureChain(b, recorded)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		for _, workers := range benchmarkSigVerifyWorkers() {
+			b.Run(fmt.Sprintf("%s/workers=%d", tc.name, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					f := tc.newChain()
+					setTestSigVerifyWorkers(f, workers)
+					b.StartTimer()
+
+					_, err := f.v.ImportBlockArchive(tc.archive, nil, nil)
+					require.NoError(b, err)
+
+					b.StopTimer()
+					f.shutdown()
+					b.StartTimer()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// SnapshotVersion is the version of the unspent output snapshot format
+const SnapshotVersion = 1
+
+var (
+	// ErrSnapshotCheckpointMismatch is returned if a snapshot does not match the trusted checkpoint
+	ErrSnapshotCheckpointMismatch = errors.New("snapshot does not match the trusted checkpoint")
+	// ErrSnapshotGenesisMismatch is returned if the genesis block of a snapshot is not the genesis block of the blockchain
+	ErrSnapshotGenesisMismatch = errors.New("snapshot genesis block does not match the blockchain genesis block")
+	// ErrSnapshotUxHashMismatch is returned if the unspent outputs of a snapshot do not match its unspent hash
+	ErrSnapshotUxHashMismatch = errors.New("snapshot unspent outputs do not match the unspent hash")
+)
+
+// Snapshot is the unspent output set after a block is executed.
+// A node can be bootstrapped from a snapshot instead of executing every block.
+type Snapshot struct {
+	Version uint32
+	// Genesis is the genesis block
+	Genesis coin.SignedBlock
+	// Head is the block the snapshot was taken at
+	Head coin.SignedBlock
+	// UxHash is the xor hash of the unspent outputs
+	UxHash cipher.SHA256
+	// Unspents are the unspent outputs, sorted by hash
+	Unspents coin.UxArray
+}
+
+// Checkpoint returns the checkpoint identifying the snapshot
+func (s Snapshot) Checkpoint() SnapshotCheckpoint {
+	return SnapshotCheckpoint{
+		Seq:       s.Head.Seq(),
+		BlockHash: s.Head.HashHeader(),
+		UxHash:    s.UxHash,
+	}
+}
+
+// SnapshotCheckpoint is a trusted block and unspent hash that a snapshot is verified against
+type SnapshotCheckpoint struct {
+	Seq       uint64
+	BlockHash cipher.SHA256
+	UxHash    cipher.SHA256
+}
+
+// String returns the checkpoint formatted as "seq:blockhash:uxhash"
+func (c SnapshotCheckpoint) String() string {
+	return fmt.Sprintf("%d:%s:%s", c.Seq, c.BlockHash.Hex(), c.UxHash.Hex())
+}
+
+// ParseSnapshotCheckpoint parses a checkpoint formatted as "seq:blockhash:uxhash"
+func ParseSnapshotCheckpoint(s string) (SnapshotCheckpoint, error) {
+	pts := strings.Split(s, ":")
+	if len(pts) != 3 {
+		return SnapshotCheckpoint{}, errors.New("invalid snapshot checkpoint, must be formatted as seq:blockhash:uxhash")
+	}
+
+	seq, err := strconv.ParseUint(pts[0], 10, 64)
+	if err != nil {
+		return SnapshotCheckpoint{}, fmt.Errorf("invalid snapshot checkpoint seq: %v", err)
+	}
+
+	blockHash, err := cipher.SHA256FromHex(pts[1])
+	if err != nil {
+		return SnapshotCheckpoint{}, fmt.Errorf("invalid snapshot checkpoint block hash: %v", err)
+	}
+
+	uxHash, err := cipher.SHA256FromHex(pts[2])
+	if err != nil {
+		return SnapshotCheckpoint{}, fmt.Errorf("invalid snapshot checkpoint unspent hash: %v", err)
+	}
+
+	return SnapshotCheckpoint{
+		Seq:       seq,
+		BlockHash: blockHash,
+		UxHash:    uxHash,
+	}, nil
+}
+
+// ExportSnapshot writes the unspent output set after block seq was executed to w.
+// If seq is older than the head block, the outputs spent by the newer blocks are
+// restored from the historydb, which must be enabled.
+func ExportSnapshot(db *dbutil.DB, pubkey cipher.PubKey, seq uint64, w io.Writer) (SnapshotCheckpoint, error) {
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return SnapshotCheckpoint{}, err
+	}
+
+	history := historydb.New()
+
+	var snapshot Snapshot
+	if err := db.View("ExportSnapshot", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		} else if !ok {
+			return blockdb.ErrNoHeadBlock
+		}
+
+		if seq == 0 {
+			return errors.New("cannot export a snapshot of the genesis block")
+		}
+		if seq > headSeq {
+			return fmt.Errorf("block %d does not exist, the head block is %d", seq, headSeq)
+		}
+
+		genesis, err := bc.GetGenesisBlock(tx)
+		if err != nil {
+			return err
+		} else if genesis == nil {
+			return errors.New("genesis block does not exist")
+		}
+
+		head, err := bc.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return err
+		} else if head == nil {
+			return fmt.Errorf("block %d does not exist", seq)
+		}
+
+		uxs, err := bc.Unspent().GetAll(tx)
+		if err != nil {
+			return err
+		}
+
+		// The unspent hash is stored in the unspent pool metadata for the head block,
+		// and in the header of the next block for older blocks
+		var uxHash cipher.SHA256
+		if seq == headSeq {
+			uxHash, err = bc.Unspent().GetUxHash(tx)
+			if err != nil {
+				return err
+			}
+		} else {
+			uxs, err = rollbackUnspents(tx, bc, history, uxs, seq, headSeq)
+			if err != nil {
+				return err
+			}
+
+			next, err := bc.GetSignedBlockBySeq(tx, seq+1)
+			if err != nil {
+				return err
+			}
+			uxHash = next.Head.UxHash
+		}
+
+		if snapshotUxHash(uxs) != uxHash {
+			return ErrSnapshotUxHashMismatch
+		}
+
+		sort.Slice(uxs, func(i, j int) bool {
+			hi := uxs[i].Hash()
+			hj := uxs[j].Hash()
+			return bytes.Compare(hi[:], hj[:]) < 0
+		})
+
+		snapshot = Snapshot{
+			Version:  SnapshotVersion,
+			Genesis:  *genesis,
+			Head:     *head,
+			UxHash:   uxHash,
+			Unspents: uxs,
+		}
+
+		return nil
+	}); err != nil {
+		return SnapshotCheckpoint{}, err
+	}
+
+	if _, err := w.Write(encoder.Serialize(snapshot)); err != nil {
+		return SnapshotCheckpoint{}, err
+	}
+
+	return snapshot.Checkpoint(), nil
+}
+
+// rollbackUnspents returns the unspent outputs after block seq was executed,
+// given the unspent outputs uxs after the head block was executed
+func rollbackUnspents(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB, uxs coin.UxArray, seq, headSeq uint64) (coin.UxArray, error) {
+	if !dbutil.Exists(tx, historydb.HistoryMetaBkt) {
+		return nil, ErrHistoryDisabled
+	}
+	if _, ok, err := history.ParsedBlockSeq(tx); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrHistoryDisabled
+	}
+
+	if prunedSeq, ok, err := bc.PrunedSeq(tx); err != nil {
+		return nil, err
+	} else if ok && seq < prunedSeq {
+		return nil, fmt.Errorf("the bodies of the blocks after block %d have been pruned", seq)
+	}
+
+	unspents := make(map[cipher.SHA256]coin.UxOut, len(uxs))
+	for _, ux := range uxs {
+		unspents[ux.Hash()] = ux
+	}
+
+	for i := headSeq; i > seq; i-- {
+		b, err := bc.GetSignedBlockBySeq(tx, i)
+		if err != nil {
+			return nil, err
+		} else if b == nil {
+			return nil, fmt.Errorf("block %d does not exist", i)
+		}
+
+		// Undo the transactions in reverse order, in case a transaction
+		// spends an output created earlier in the same block
+		for j := len(b.Body.Transactions) - 1; j >= 0; j-- {
+			txn := b.Body.Transactions[j]
+
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				delete(unspents, ux.Hash())
+			}
+
+			spent, err := history.GetUxOuts(tx, txn.In)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, ux := range spent {
+				unspents[ux.Out.Hash()] = ux.Out
+			}
+		}
+	}
+
+	rolledBack := make(coin.UxArray, 0, len(unspents))
+	for _, ux := range unspents {
+		rolledBack = append(rolledBack, ux)
+	}
+
+	return rolledBack, nil
+}
+
+// ImportSnapshot reads a snapshot from r and seeds an empty database with it.
+// The snapshot must match the trusted checkpoint, its genesis block must have the hash genesisHash
+// and its blocks must be signed by pubkey.
+// The first block synced after the snapshot verifies the unspent outputs again,
+// because its header includes their hash.
+// The blocks below the snapshot are not available, so the database can only be used with block pruning enabled.
+func ImportSnapshot(db *dbutil.DB, pubkey cipher.PubKey, genesisHash cipher.SHA256, checkpoint SnapshotCheckpoint, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var snapshot Snapshot
+	if err := encoder.DeserializeRawExact(b, &snapshot); err != nil {
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	if err := snapshot.Genesis.VerifySignature(pubkey); err != nil {
+		return fmt.Errorf("invalid snapshot genesis block signature: %v", err)
+	}
+	if err := snapshot.Head.VerifySignature(pubkey); err != nil {
+		return fmt.Errorf("invalid snapshot head block signature: %v", err)
+	}
+
+	// The genesis block is signed with the same key on other networks, such as a testnet
+	if snapshot.Genesis.HashHeader() != genesisHash {
+		return ErrSnapshotGenesisMismatch
+	}
+
+	if snapshot.Checkpoint() != checkpoint {
+		return ErrSnapshotCheckpointMismatch
+	}
+
+	if snapshotUxHash(snapshot.Unspents) != snapshot.UxHash {
+		return ErrSnapshotUxHashMismatch
+	}
+
+	if err := CreateBuckets(db); err != nil {
+		return err
+	}
+
+	bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+	if err != nil {
+		return err
+	}
+
+	return db.Update("ImportSnapshot", func(tx *dbutil.Tx) error {
+		return bc.LoadSnapshot(tx, &snapshot.Genesis, &snapshot.Head, snapshot.Unspents)
+	})
+}
+
+// snapshotUxHash returns the xor hash of the unspent outputs, as maintained by the unspent pool
+func snapshotUxHash(uxs coin.UxArray) cipher.SHA256 {
+	var h cipher.SHA256
+	for _, ux := range uxs {
+		h = h.Xor(ux.SnapshotHash())
+	}
+	return h
+}
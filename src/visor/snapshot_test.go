@@ -0,0 +1,247 @@
+package visor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// makeSnapshotChain creates a testChain with a few blocks that spend and create outputs
+func makeSnapshotChain(t *testing.T) *testChain {
+	c := newTestChain(t)
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, 5, params.UserVerifyTxn.MaxDropletPrecision)
+	sb := c.addBlock(splitTxn)
+	splitUxs := coin.CreateUnspents(sb.Head, splitTxn)
+
+	for i := 0; i < 4; i++ {
+		c.addBlock(makeSpendTxn(t, coin.UxArray{splitUxs[i]}, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6))
+	}
+
+	return c
+}
+
+func sortedUxHashes(uxs coin.UxArray) []cipher.SHA256 {
+	hashes := make([]cipher.SHA256, len(uxs))
+	for i, ux := range uxs {
+		hashes[i] = ux.Hash()
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	return hashes
+}
+
+func TestExportImportSnapshot(t *testing.T) {
+	for _, seq := range []uint64{5, 3} {
+		t.Run(fmt.Sprintf("seq %d", seq), func(t *testing.T) {
+			c := makeSnapshotChain(t)
+			defer c.shutdown()
+
+			var buf bytes.Buffer
+			checkpoint, err := ExportSnapshot(c.v.db, genPublic, seq, &buf)
+			require.NoError(t, err)
+			require.Equal(t, seq, checkpoint.Seq)
+			require.Equal(t, c.block(seq).HashHeader(), checkpoint.BlockHash)
+
+			parsed, err := ParseSnapshotCheckpoint(checkpoint.String())
+			require.NoError(t, err)
+			require.Equal(t, checkpoint, parsed)
+
+			// Import the snapshot into an empty database
+			db, shutdown := testutil.PrepareDB(t)
+			defer shutdown()
+
+			err = ImportSnapshot(db, genPublic, c.block(0).HashHeader(), checkpoint, bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+
+			// The imported database can only be used with pruning enabled
+			cfg := c.v.Config
+			_, err = New(cfg, db, nil)
+			require.Equal(t, ErrPruningRequired, err)
+
+			cfg.PruneBlocks = blockdb.MinPruneKeepBlocks
+			v, err := New(cfg, db, nil)
+			require.NoError(t, err)
+
+			head, err := v.GetHeadBlock()
+			require.NoError(t, err)
+			require.Equal(t, checkpoint.BlockHash, head.HashHeader())
+
+			err = v.db.View("", func(tx *dbutil.Tx) error {
+				uxHash, err := v.blockchain.Unspent().GetUxHash(tx)
+				require.NoError(t, err)
+				require.Equal(t, checkpoint.UxHash, uxHash)
+				return nil
+			})
+			require.NoError(t, err)
+
+			// Sync the remaining blocks
+			for i := seq + 1; i <= c.head().Seq(); i++ {
+				err := v.ExecuteSignedBlock(c.block(i))
+				require.NoError(t, err)
+			}
+
+			head, err = v.GetHeadBlock()
+			require.NoError(t, err)
+			require.Equal(t, c.head().HashHeader(), head.HashHeader())
+
+			expectedUxs, err := c.v.GetAllUnspentOutputs()
+			require.NoError(t, err)
+			uxs, err := v.GetAllUnspentOutputs()
+			require.NoError(t, err)
+			require.Equal(t, sortedUxHashes(expectedUxs), sortedUxHashes(uxs))
+
+			err = CheckDatabase(db, genPublic, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestExportSnapshotErrors(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	var buf bytes.Buffer
+	_, err := ExportSnapshot(c.v.db, genPublic, 0, &buf)
+	testutil.RequireError(t, err, "cannot export a snapshot of the genesis block")
+
+	_, err = ExportSnapshot(c.v.db, genPublic, 6, &buf)
+	testutil.RequireError(t, err, "block 6 does not exist, the head block is 5")
+
+	// Blocks older than the head block can't be exported without the historydb
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return historydb.New().Erase(tx)
+	})
+	require.NoError(t, err)
+
+	_, err = ExportSnapshot(c.v.db, genPublic, 3, &buf)
+	require.Equal(t, ErrHistoryDisabled, err)
+
+	_, err = ExportSnapshot(c.v.db, genPublic, 5, &buf)
+	require.NoError(t, err)
+}
+
+func TestImportSnapshotErrors(t *testing.T) {
+	c := makeSnapshotChain(t)
+	defer c.shutdown()
+
+	var buf bytes.Buffer
+	checkpoint, err := ExportSnapshot(c.v.db, genPublic, 5, &buf)
+	require.NoError(t, err)
+
+	var snapshot Snapshot
+	err = encoder.DeserializeRawExact(buf.Bytes(), &snapshot)
+	require.NoError(t, err)
+
+	encode := func(s Snapshot) *bytes.Reader {
+		return bytes.NewReader(encoder.Serialize(s))
+	}
+
+	badCheckpoint := checkpoint
+	badCheckpoint.Seq = 4
+
+	missingUnspent := snapshot
+	missingUnspent.Unspents = snapshot.Unspents[1:]
+
+	badVersion := snapshot
+	badVersion.Version = SnapshotVersion + 1
+
+	badSig := snapshot
+	badSig.Head.Sig = cipher.Sig{}
+
+	// A genesis block of another network, signed with the same key
+	otherGenesis, err := coin.NewGenesisBlock(genAddress, genCoins, genTime+1)
+	require.NoError(t, err)
+	otherNetwork := snapshot
+	otherNetwork.Genesis = coin.SignedBlock{
+		Block: *otherGenesis,
+		Sig:   cipher.MustSignHash(otherGenesis.HashHeader(), genSecret),
+	}
+
+	cases := []struct {
+		name       string
+		checkpoint SnapshotCheckpoint
+		pubkey     cipher.PubKey
+		snapshot   *bytes.Reader
+		err        string
+	}{
+		{
+			name:       "checkpoint mismatch",
+			checkpoint: badCheckpoint,
+			pubkey:     genPublic,
+			snapshot:   encode(snapshot),
+			err:        ErrSnapshotCheckpointMismatch.Error(),
+		},
+		{
+			name:       "genesis mismatch",
+			checkpoint: checkpoint,
+			pubkey:     genPublic,
+			snapshot:   encode(otherNetwork),
+			err:        ErrSnapshotGenesisMismatch.Error(),
+		},
+		{
+			name:       "unspent hash mismatch",
+			checkpoint: checkpoint,
+			pubkey:     genPublic,
+			snapshot:   encode(missingUnspent),
+			err:        ErrSnapshotUxHashMismatch.Error(),
+		},
+		{
+			name:       "bad version",
+			checkpoint: checkpoint,
+			pubkey:     genPublic,
+			snapshot:   encode(badVersion),
+			err:        "unsupported snapshot version 2",
+		},
+		{
+			name:       "bad signature",
+			checkpoint: checkpoint,
+			pubkey:     genPublic,
+			snapshot:   encode(badSig),
+			err:        "invalid snapshot head block signature: Failed to recover pubkey from signature",
+		},
+		{
+			name:       "wrong pubkey",
+			checkpoint: checkpoint,
+			pubkey:     testutil.MakePubKey(),
+			snapshot:   encode(snapshot),
+			err:        "invalid snapshot genesis block signature: Recovered pubkey does not match pubkey",
+		},
+		{
+			name:       "truncated",
+			checkpoint: checkpoint,
+			pubkey:     genPublic,
+			snapshot:   bytes.NewReader(buf.Bytes()[:buf.Len()-1]),
+			err:        "invalid snapshot: Not enough buffer data to deserialize",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, shutdown := testutil.PrepareDB(t)
+			defer shutdown()
+
+			err := ImportSnapshot(db, tc.pubkey, c.block(0).HashHeader(), tc.checkpoint, tc.snapshot)
+			testutil.RequireError(t, err, tc.err)
+		})
+	}
+
+	// A snapshot can't be imported into a database that is not empty
+	err = ImportSnapshot(c.v.db, genPublic, c.block(0).HashHeader(), checkpoint, bytes.NewReader(buf.Bytes()))
+	testutil.RequireError(t, err, "cannot load a snapshot into a blockchain that is not empty")
+}
@@ -0,0 +1,369 @@
+package visor
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/droplet"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// SupplyStatsParams selects the blocks of the supply statistics and groups them into periods
+type SupplyStatsParams struct {
+	// MinSeq and MaxSeq restrict the stats to blocks MinSeq to MaxSeq, inclusive
+	MinSeq uint64
+	MaxSeq uint64
+	// MinTime and MaxTime restrict the stats to blocks created from MinTime to MaxTime, inclusive
+	MinTime uint64
+	MaxTime uint64
+	// Interval groups the blocks into periods of Interval seconds, aligned to the unix epoch.
+	// If 0, each block is a period.
+	Interval uint64
+	// Limit is the maximum number of periods, 0 for no limit
+	Limit uint64
+}
+
+// SupplyStats are the coin supply and distribution statistics of a period of blocks
+type SupplyStats struct {
+	// PeriodStart is the start time of the period, or the time of the block if the blocks are not grouped
+	PeriodStart uint64
+	// Blocks is the number of blocks in the period
+	Blocks uint64
+	// BlockSeq is the seq of the last block of the period.
+	// The supply, coin hours and active addresses are as of this block.
+	BlockSeq uint64
+	// BlockTime is the time of the last block of the period
+	BlockTime uint64
+	// TotalSupply is the number of coins unlocked by the distribution, in droplets
+	TotalSupply uint64
+	// CirculatingSupply is the number of coins distributed from the unlocked distribution addresses, in droplets
+	CirculatingSupply uint64
+	// TotalCoinHours is the number of coin hours of the unspent outputs, excluding the locked distribution addresses
+	TotalCoinHours uint64
+	// ActiveAddresses is the number of addresses with a nonzero balance
+	ActiveAddresses uint64
+	// TxnCount is the number of transactions in the blocks of the period
+	TxnCount uint64
+	// BurnedHours is the number of coin hours burned by the blocks of the period
+	BurnedHours uint64
+}
+
+// GetSupplyStats returns the supply statistics of the blocks selected by p, oldest first.
+// The statistics are kept by the historydb as blocks are executed.
+// The distribution statistics are calculated from the outputs of the distribution addresses.
+func (vs Visor) GetSupplyStats(p SupplyStatsParams) ([]SupplyStats, error) {
+	var stats []SupplyStats
+
+	if err := vs.db.View("GetSupplyStats", func(tx *dbutil.Tx) error {
+		minSeq, maxSeq, ok, err := vs.supplyStatsSeqRange(tx, p)
+		if err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+
+		g := supplyStatsGrouper{
+			interval: p.Interval,
+			limit:    p.Limit,
+		}
+
+		if err := vs.history.ForEachSupplyStats(tx, minSeq, maxSeq, func(r historydb.SupplyStats) error {
+			if !g.add(r) {
+				return errSupplyStatsLimit
+			}
+			return nil
+		}); err != nil && err != errSupplyStatsLimit {
+			return err
+		}
+
+		periods := g.periods
+		if len(periods) == 0 {
+			return nil
+		}
+
+		dist, err := vs.distributionOutputs(tx)
+		if err != nil {
+			return err
+		}
+
+		stats = make([]SupplyStats, len(periods))
+		for i, period := range periods {
+			stats[i], err = dist.supplyStats(period)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// supplyStatsSeqRange returns the seqs of the first and last block selected by p.
+// Returns false if no block is selected.
+func (vs Visor) supplyStatsSeqRange(tx *dbutil.Tx, p SupplyStatsParams) (uint64, uint64, bool, error) {
+	headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+	if err != nil {
+		return 0, 0, false, err
+	} else if !ok {
+		return 0, 0, false, nil
+	}
+
+	minSeq, maxSeq := p.MinSeq, p.MaxSeq
+	if maxSeq > headSeq {
+		maxSeq = headSeq
+	}
+
+	if p.MinTime > 0 {
+		// The first block at or after MinTime follows the last block before it
+		bh, err := vs.blockHeaderAtTime(tx, p.MinTime-1)
+		switch err {
+		case nil:
+			if bh.BkSeq+1 > minSeq {
+				minSeq = bh.BkSeq + 1
+			}
+		case ErrTimeBeforeGenesis:
+		default:
+			return 0, 0, false, err
+		}
+	}
+
+	if p.MaxTime < math.MaxUint64 {
+		bh, err := vs.blockHeaderAtTime(tx, p.MaxTime)
+		switch err {
+		case nil:
+			if bh.BkSeq < maxSeq {
+				maxSeq = bh.BkSeq
+			}
+		case ErrTimeBeforeGenesis:
+			return 0, 0, false, nil
+		default:
+			return 0, 0, false, err
+		}
+	}
+
+	return minSeq, maxSeq, minSeq <= maxSeq, nil
+}
+
+// supplyStatsPeriod is a group of the historydb supply statistics of consecutive blocks
+type supplyStatsPeriod struct {
+	start       uint64
+	blocks      uint64
+	txnCount    uint64
+	burnedHours uint64
+	last        historydb.SupplyStats
+}
+
+// errSupplyStatsLimit stops the supply stats scan once the period limit is reached
+var errSupplyStatsLimit = errors.New("supply stats period limit reached")
+
+// supplyStatsGrouper groups the supply statistics of consecutive blocks into periods of interval seconds.
+// If interval is 0, each block is a period. At most limit periods are kept, 0 for no limit.
+type supplyStatsGrouper struct {
+	interval uint64
+	limit    uint64
+	periods  []supplyStatsPeriod
+}
+
+// add adds the supply statistics of the next block to its period.
+// Returns false if the block would start a period past the limit, so the remaining blocks can be skipped.
+func (g *supplyStatsGrouper) add(r historydb.SupplyStats) bool {
+	start := r.BlockTime
+	if g.interval > 0 {
+		start -= r.BlockTime % g.interval
+	}
+
+	n := len(g.periods)
+	if g.interval == 0 || n == 0 || g.periods[n-1].start != start {
+		if g.limit > 0 && uint64(n) == g.limit {
+			return false
+		}
+
+		g.periods = append(g.periods, supplyStatsPeriod{
+			start: start,
+		})
+		n++
+	}
+
+	p := &g.periods[n-1]
+	p.blocks++
+	p.txnCount += r.TxnCount
+	p.burnedHours += r.BurnedHours
+	p.last = r
+
+	return true
+}
+
+// distributionOutputs are the outputs of the distribution addresses recorded in the historydb
+type distributionOutputs struct {
+	totalSupply uint64
+	unlocked    []historydb.UxOut
+	locked      []historydb.UxOut
+}
+
+// distributionOutputsCache keeps the distribution outputs loaded at a head block,
+// so they are only reloaded from the historydb after the blockchain changes
+type distributionOutputsCache struct {
+	sync.Mutex
+	headHash cipher.SHA256
+	outputs  *distributionOutputs
+}
+
+func (c *distributionOutputsCache) get(headHash cipher.SHA256) *distributionOutputs {
+	c.Lock()
+	defer c.Unlock()
+	if c.outputs == nil || c.headHash != headHash {
+		return nil
+	}
+	return c.outputs
+}
+
+func (c *distributionOutputsCache) set(headHash cipher.SHA256, d *distributionOutputs) {
+	c.Lock()
+	defer c.Unlock()
+	c.headHash = headHash
+	c.outputs = d
+}
+
+// distributionOutputs returns the outputs of the distribution addresses,
+// loading them from the historydb if the head block changed since they were cached
+func (vs Visor) distributionOutputs(tx *dbutil.Tx) (*distributionOutputs, error) {
+	head, err := vs.blockchain.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+	headHash := head.HashHeader()
+
+	if d := vs.distOutputs.get(headHash); d != nil {
+		return d, nil
+	}
+
+	d, err := vs.loadDistributionOutputs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.distOutputs.set(headHash, d)
+	return d, nil
+}
+
+// loadDistributionOutputs loads the outputs of the distribution addresses from the historydb
+func (vs Visor) loadDistributionOutputs(tx *dbutil.Tx) (*distributionOutputs, error) {
+	dist := vs.Config.Distribution
+
+	unlockedAddrs := dist.UnlockedAddressesDecoded()
+
+	// "total supply" is the number of coins unlocked.
+	// Each distribution address was allocated distribution.AddressInitialBalance coins.
+	totalSupply, err := mathutil.MultUint64(uint64(len(unlockedAddrs)), dist.AddressInitialBalance())
+	if err != nil {
+		return nil, err
+	}
+
+	totalSupply, err = mathutil.MultUint64(totalSupply, droplet.Multiplier)
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := vs.getOutputsForAddresses(tx, unlockedAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := vs.getOutputsForAddresses(tx, dist.LockedAddressesDecoded())
+	if err != nil {
+		return nil, err
+	}
+
+	return &distributionOutputs{
+		totalSupply: totalSupply,
+		unlocked:    unlocked,
+		locked:      locked,
+	}, nil
+}
+
+func (vs Visor) getOutputsForAddresses(tx *dbutil.Tx, addrs []cipher.Address) ([]historydb.UxOut, error) {
+	var uxs []historydb.UxOut
+	for _, addr := range addrs {
+		addrUxs, err := vs.history.GetOutputsForAddress(tx, addr)
+		if err != nil {
+			return nil, err
+		}
+		uxs = append(uxs, addrUxs...)
+	}
+
+	return uxs, nil
+}
+
+// supplyStats calculates the supply statistics of a period from its last block
+func (d *distributionOutputs) supplyStats(p supplyStatsPeriod) (SupplyStats, error) {
+	seq := p.last.BlockSeq
+	t := p.last.BlockTime
+
+	var unlockedCoins uint64
+	for _, ux := range d.unlocked {
+		if !isUnspentAtSeq(ux, seq) {
+			continue
+		}
+
+		var err error
+		unlockedCoins, err = mathutil.AddUint64(unlockedCoins, ux.Out.Body.Coins)
+		if err != nil {
+			return SupplyStats{}, err
+		}
+	}
+
+	var lockedHours uint64
+	for _, ux := range d.locked {
+		if !isUnspentAtSeq(ux, seq) {
+			continue
+		}
+
+		hours, err := ux.Out.CoinHours(t)
+		if err != nil {
+			return SupplyStats{}, err
+		}
+
+		lockedHours, err = mathutil.AddUint64(lockedHours, hours)
+		if err != nil {
+			return SupplyStats{}, err
+		}
+	}
+
+	totalHours, err := p.last.CoinHours(t)
+	if err != nil {
+		return SupplyStats{}, err
+	}
+
+	// "circulating supply" is the number of coins distributed from the unlocked pool
+	var circulating uint64
+	if d.totalSupply > unlockedCoins {
+		circulating = d.totalSupply - unlockedCoins
+	}
+
+	if totalHours > lockedHours {
+		totalHours -= lockedHours
+	} else {
+		totalHours = 0
+	}
+
+	return SupplyStats{
+		PeriodStart:       p.start,
+		Blocks:            p.blocks,
+		BlockSeq:          seq,
+		BlockTime:         t,
+		TotalSupply:       d.totalSupply,
+		CirculatingSupply: circulating,
+		TotalCoinHours:    totalHours,
+		ActiveAddresses:   p.last.ActiveAddresses,
+		TxnCount:          p.txnCount,
+		BurnedHours:       p.burnedHours,
+	}, nil
+}
@@ -0,0 +1,282 @@
+package visor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// countingSupplyStatsHistory counts the supply statistics read by ForEachSupplyStats
+type countingSupplyStatsHistory struct {
+	Historyer
+	scanned int
+}
+
+func (h *countingSupplyStatsHistory) ForEachSupplyStats(tx *dbutil.Tx, start, end uint64, f func(historydb.SupplyStats) error) error {
+	return h.Historyer.ForEachSupplyStats(tx, start, end, func(r historydb.SupplyStats) error {
+		h.scanned++
+		return f(r)
+	})
+}
+
+func TestGetSupplyStats(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	unlockedPub, unlockedSec := cipher.GenerateKeyPair()
+	unlockedAddr := cipher.AddressFromPubKey(unlockedPub)
+	lockedAddr := testutil.MakeAddress()
+	toPub, toSec := cipher.GenerateKeyPair()
+	toAddr := cipher.AddressFromPubKey(toPub)
+
+	// Each distribution address is allocated 500 coins, and one of them is unlocked
+	c.v.Config.Distribution = params.Distribution{
+		MaxCoinSupply:        1000,
+		InitialUnlockedCount: 1,
+		Addresses:            []string{unlockedAddr.String(), lockedAddr.String()},
+	}
+	c.v.Config.Distribution.MustValidate()
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	// Block 1 sends half of the coins to the unlocked distribution address
+	var txn1 coin.Transaction
+	err := txn1.PushInput(genUxs[0].Hash())
+	require.NoError(t, err)
+	err = txn1.PushOutput(unlockedAddr, 500e6, genUxs[0].Body.Hours/4)
+	require.NoError(t, err)
+	err = txn1.PushOutput(genAddress, 500e6, genUxs[0].Body.Hours/4)
+	require.NoError(t, err)
+	txn1.SignInputs([]cipher.SecKey{genSecret})
+	err = txn1.UpdateHeader()
+	require.NoError(t, err)
+	b1 := c.addBlock(txn1)
+	uxs1 := coin.CreateUnspents(b1.Head, txn1)
+
+	// Block 2 sends the other half to the locked distribution address
+	txn2 := makeSpendTxWithFee(t, coin.UxArray{uxs1[1]}, []cipher.SecKey{genSecret}, lockedAddr, 500e6, 1)
+	b2 := c.addBlock(txn2)
+	uxs2 := coin.CreateUnspents(b2.Head, txn2)
+
+	// Block 3 distributes coins from the unlocked distribution address
+	txn3 := makeSpendTxWithFee(t, coin.UxArray{uxs1[0]}, []cipher.SecKey{unlockedSec}, toAddr, 100e6, 1)
+	b3 := c.addBlock(txn3)
+
+	blocks := []coin.SignedBlock{gb, b1, b2, b3}
+
+	var rows []SupplyStats
+	err = c.v.db.View("", func(tx *dbutil.Tx) error {
+		var hrows []historydb.SupplyStats
+		err := c.v.history.ForEachSupplyStats(tx, 0, 3, func(r historydb.SupplyStats) error {
+			hrows = append(hrows, r)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, hrows, 4)
+
+		for i, r := range hrows {
+			hours, err := r.CoinHours(r.BlockTime)
+			require.NoError(t, err)
+
+			// The locked distribution address's coin hours are excluded
+			if i >= 2 {
+				lockedHours, err := uxs2[0].CoinHours(r.BlockTime)
+				require.NoError(t, err)
+				hours -= lockedHours
+			}
+
+			rows = append(rows, SupplyStats{
+				PeriodStart:     r.BlockTime,
+				Blocks:          1,
+				BlockSeq:        r.BlockSeq,
+				BlockTime:       r.BlockTime,
+				TotalSupply:     500e6,
+				TotalCoinHours:  hours,
+				ActiveAddresses: r.ActiveAddresses,
+				TxnCount:        1,
+				BurnedHours:     blocks[i].Head.Fee,
+			})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Coins in the unlocked distribution address are not circulating
+	rows[0].CirculatingSupply = 500e6
+	rows[1].CirculatingSupply = 0
+	rows[2].CirculatingSupply = 0
+	rows[3].CirculatingSupply = 100e6
+
+	require.Equal(t, uint64(1), rows[0].ActiveAddresses)
+	require.Equal(t, uint64(2), rows[1].ActiveAddresses)
+	require.Equal(t, uint64(2), rows[2].ActiveAddresses)
+	require.Equal(t, uint64(3), rows[3].ActiveAddresses)
+
+	all := SupplyStatsParams{
+		MaxSeq:  math.MaxUint64,
+		MaxTime: math.MaxUint64,
+	}
+
+	hourStart := b1.Time() - b1.Time()%3600
+	require.Equal(t, hourStart, b3.Time()-b3.Time()%3600)
+
+	tt := []struct {
+		name   string
+		params func(p SupplyStatsParams) SupplyStatsParams
+		stats  []SupplyStats
+		// scanned is the number of blocks read from the historydb
+		scanned int
+	}{
+		{
+			name: "all blocks",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				return p
+			},
+			stats: rows,
+			scanned: 4,
+		},
+		{
+			name: "seq range",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.MinSeq = 1
+				p.MaxSeq = 2
+				return p
+			},
+			stats: rows[1:3],
+			scanned: 2,
+		},
+		{
+			name: "time range",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.MinTime = b1.Time() + 1
+				p.MaxTime = b3.Time() - 1
+				return p
+			},
+			stats: rows[2:3],
+			scanned: 1,
+		},
+		{
+			name: "time range before genesis",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.MaxTime = gb.Time() - 1
+				return p
+			},
+		},
+		{
+			name: "limit",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.Limit = 2
+				return p
+			},
+			stats: rows[:2],
+			scanned: 3,
+		},
+		{
+			name: "hourly periods",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.Interval = 3600
+				return p
+			},
+			stats: []SupplyStats{
+				{
+					PeriodStart:       gb.Time() - gb.Time()%3600,
+					Blocks:            1,
+					BlockSeq:          0,
+					BlockTime:         gb.Time(),
+					TotalSupply:       500e6,
+					CirculatingSupply: 500e6,
+					TotalCoinHours:    rows[0].TotalCoinHours,
+					ActiveAddresses:   1,
+					TxnCount:          1,
+				},
+				{
+					PeriodStart:       hourStart,
+					Blocks:            3,
+					BlockSeq:          3,
+					BlockTime:         b3.Time(),
+					TotalSupply:       500e6,
+					CirculatingSupply: 100e6,
+					TotalCoinHours:    rows[3].TotalCoinHours,
+					ActiveAddresses:   3,
+					TxnCount:          3,
+					BurnedHours:       b1.Head.Fee + b2.Head.Fee + b3.Head.Fee,
+				},
+			},
+			scanned: 4,
+		},
+		{
+			name: "hourly periods limit",
+			params: func(p SupplyStatsParams) SupplyStatsParams {
+				p.Interval = 3600
+				p.Limit = 1
+				return p
+			},
+			stats: []SupplyStats{
+				{
+					PeriodStart:       gb.Time() - gb.Time()%3600,
+					Blocks:            1,
+					BlockSeq:          0,
+					BlockTime:         gb.Time(),
+					TotalSupply:       500e6,
+					CirculatingSupply: 500e6,
+					TotalCoinHours:    rows[0].TotalCoinHours,
+					ActiveAddresses:   1,
+					TxnCount:          1,
+				},
+			},
+			// The scan stops at the first block of the next hour
+			scanned: 2,
+		},
+	}
+
+	history := &countingSupplyStatsHistory{
+		Historyer: c.v.history,
+	}
+	c.v.history = history
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			history.scanned = 0
+			stats, err := c.v.GetSupplyStats(tc.params(all))
+			require.NoError(t, err)
+			require.Equal(t, tc.stats, stats)
+			require.Equal(t, tc.scanned, history.scanned)
+		})
+	}
+
+	// The distribution outputs are cached until the head block changes
+	cached := c.v.distOutputs.get(b3.HashHeader())
+	require.NotNil(t, cached)
+
+	stats, err := c.v.GetSupplyStats(all)
+	require.NoError(t, err)
+	require.Equal(t, rows, stats)
+	require.True(t, cached == c.v.distOutputs.get(b3.HashHeader()))
+
+	// Block 4 spends the distributed coins
+	uxs3 := coin.CreateUnspents(b3.Head, txn3)
+	b4 := c.addBlock(makeSpendTxWithFee(t, coin.UxArray{uxs3[0]}, []cipher.SecKey{toSec}, testutil.MakeAddress(), 50e6, 1))
+	require.Nil(t, c.v.distOutputs.get(b4.HashHeader()))
+
+	stats, err = c.v.GetSupplyStats(all)
+	require.NoError(t, err)
+	require.Len(t, stats, 5)
+	require.Equal(t, uint64(4), stats[4].BlockSeq)
+	require.Equal(t, uint64(100e6), stats[4].CirculatingSupply)
+	require.NotNil(t, c.v.distOutputs.get(b4.HashHeader()))
+	require.Nil(t, c.v.distOutputs.get(b3.HashHeader()))
+
+	// Supply stats require the historydb
+	c.v.history = disabledHistory{}
+	_, err = c.v.GetSupplyStats(all)
+	require.Equal(t, ErrHistoryDisabled, err)
+}
@@ -1,12 +1,16 @@
 package visor
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
@@ -16,10 +20,64 @@ var (
 	UnconfirmedTxnsBkt = []byte("unconfirmed_txns")
 	// UnconfirmedUnspentsBkt holds unconfirmed unspent outputs
 	UnconfirmedUnspentsBkt = []byte("unconfirmed_unspents")
+	// UnconfirmedFirstSeenBkt holds the time each unconfirmed transaction was first received
+	UnconfirmedFirstSeenBkt = []byte("unconfirmed_first_seen")
+	// UnconfirmedMetaBkt holds the number of unconfirmed transactions and their total size
+	UnconfirmedMetaBkt = []byte("unconfirmed_meta")
+
+	// number of transactions in the unconfirmed pool
+	unconfirmedLenKey = []byte("len")
+	// total size of the transactions in the unconfirmed pool, in bytes
+	unconfirmedBytesKey = []byte("bytes")
 
 	errUpdateObjectDoesNotExist = errors.New("object does not exist in bucket")
+
+	// ErrUnconfirmedPoolFull is returned when injecting a transaction into a full unconfirmed pool,
+	// if the transaction's fee is too low for it to replace the transactions in the pool
+	ErrUnconfirmedPoolFull = errors.New("The unconfirmed transaction pool is full and the transaction's fee is too low to replace the transactions in it")
+)
+
+// UnconfirmedPoolLimits bounds the unconfirmed transaction pool
+type UnconfirmedPoolLimits struct {
+	// Maximum number of transactions in the pool. Unlimited if 0
+	MaxTransactions uint64
+	// Maximum total size of the transactions in the pool, in bytes. Unlimited if 0
+	MaxBytes uint64
+	// Transactions that were first received longer than TTL ago are dropped. Disabled if 0
+	TTL time.Duration
+}
+
+// EvictionReason is the reason a transaction was removed from the unconfirmed pool without being confirmed
+type EvictionReason string
+
+const (
+	// EvictPoolFull the pool exceeded its maximum number of transactions or size, and the transaction had the lowest fee per kB
+	EvictPoolFull EvictionReason = "pool full"
+	// EvictExpired the transaction was not received for longer than the TTL
+	EvictExpired EvictionReason = "expired"
+	// EvictInvalid the transaction began violating hard constraints
+	EvictInvalid EvictionReason = "invalid"
 )
 
+// removalReason returns the eventbus.RemovalReason of the eviction reason
+func (r EvictionReason) removalReason() eventbus.RemovalReason {
+	switch r {
+	case EvictPoolFull:
+		return eventbus.RemovedPoolFull
+	case EvictExpired:
+		return eventbus.RemovedExpired
+	default:
+		return eventbus.RemovedInvalid
+	}
+}
+
+// EvictedTransaction is a transaction that was removed from the unconfirmed pool without being confirmed
+type EvictedTransaction struct {
+	Hash   cipher.SHA256
+	Reason EvictionReason
+	Time   time.Time
+}
+
 //go:generate skyencoder -unexported -struct UnconfirmedTransaction
 //go:generate skyencoder -unexported -struct UxArray
 
@@ -166,6 +224,67 @@ func (txus *txnUnspents) getByAddr(tx *dbutil.Tx, a cipher.Address) (coin.UxArra
 	return uxo, nil
 }
 
+// first seen times of unconfirmed transactions bucket
+type unconfirmedFirstSeen struct{}
+
+func (fs *unconfirmedFirstSeen) put(tx *dbutil.Tx, hash cipher.SHA256, t int64) error {
+	return dbutil.PutBucketValue(tx, UnconfirmedFirstSeenBkt, []byte(hash.Hex()), dbutil.Itob(uint64(t)))
+}
+
+// get returns the time a transaction was first received, if it was recorded
+func (fs *unconfirmedFirstSeen) get(tx *dbutil.Tx, hash cipher.SHA256) (int64, bool, error) {
+	if !dbutil.Exists(tx, UnconfirmedFirstSeenBkt) {
+		return 0, false, nil
+	}
+
+	v, err := dbutil.GetBucketValue(tx, UnconfirmedFirstSeenBkt, []byte(hash.Hex()))
+	if err != nil {
+		return 0, false, err
+	} else if v == nil {
+		return 0, false, nil
+	}
+
+	return int64(dbutil.Btoi(v)), true, nil
+}
+
+func (fs *unconfirmedFirstSeen) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedFirstSeenBkt, []byte(hash.Hex()))
+}
+
+// unconfirmed pool size bucket
+type unconfirmedMeta struct{}
+
+func (m *unconfirmedMeta) setSize(tx *dbutil.Tx, n, size uint64) error {
+	if err := dbutil.PutBucketValue(tx, UnconfirmedMetaBkt, unconfirmedLenKey, dbutil.Itob(n)); err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, UnconfirmedMetaBkt, unconfirmedBytesKey, dbutil.Itob(size))
+}
+
+// getSize returns the number of transactions in the pool and their total size, if they were recorded
+func (m *unconfirmedMeta) getSize(tx *dbutil.Tx) (uint64, uint64, bool, error) {
+	if !dbutil.Exists(tx, UnconfirmedMetaBkt) {
+		return 0, 0, false, nil
+	}
+
+	n, err := dbutil.GetBucketValue(tx, UnconfirmedMetaBkt, unconfirmedLenKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	size, err := dbutil.GetBucketValue(tx, UnconfirmedMetaBkt, unconfirmedBytesKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if n == nil || size == nil {
+		return 0, 0, false, nil
+	}
+
+	return dbutil.Btoi(n), dbutil.Btoi(size), true, nil
+}
+
 // UnconfirmedTransactionPool manages unconfirmed transactions
 type UnconfirmedTransactionPool struct {
 	db   *dbutil.DB
@@ -174,6 +293,11 @@ type UnconfirmedTransactionPool struct {
 	// our future balance and avoid double spending our own coins
 	// Maps from Transaction.Hash() to UxArray.
 	unspent *txnUnspents
+	// Time each txn was first received, which is not updated when the txn is received again
+	firstSeen *unconfirmedFirstSeen
+	// Number of txns in the pool and their total size, so that the pool limits
+	// can be checked without scanning the pool
+	meta *unconfirmedMeta
 }
 
 // NewUnconfirmedTransactionPool creates an UnconfirmedTransactionPool instance
@@ -191,9 +315,11 @@ func NewUnconfirmedTransactionPool(db *dbutil.DB) (*UnconfirmedTransactionPool,
 	}
 
 	return &UnconfirmedTransactionPool{
-		db:      db,
-		txns:    &unconfirmedTxns{},
-		unspent: &txnUnspents{},
+		db:        db,
+		txns:      &unconfirmedTxns{},
+		unspent:   &txnUnspents{},
+		firstSeen: &unconfirmedFirstSeen{},
+		meta:      &unconfirmedMeta{},
 	}, nil
 }
 
@@ -273,12 +399,28 @@ func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Block
 	utx := NewUnconfirmedTransaction(txn)
 	utx.IsValid = isValid
 
+	n, size, err := utp.Size(tx)
+	if err != nil {
+		logger.Errorf("InjectTransaction get pool size failed: %v", err)
+		return false, nil, err
+	}
+
 	// add txn to index
 	if err := utp.txns.put(tx, &utx); err != nil {
 		logger.Errorf("InjectTransaction put new unconfirmed txn failed: %v", err)
 		return false, nil, err
 	}
 
+	if err := utp.firstSeen.put(tx, hash, utx.Received); err != nil {
+		logger.Errorf("InjectTransaction put first seen time failed: %v", err)
+		return false, nil, err
+	}
+
+	if err := utp.meta.setSize(tx, n+1, size+uint64(txn.Length)); err != nil {
+		logger.Errorf("InjectTransaction update pool size failed: %v", err)
+		return false, nil, err
+	}
+
 	head, err := bc.Head(tx)
 	if err != nil {
 		logger.Errorf("InjectTransaction bc.Head() failed: %v", err)
@@ -311,10 +453,30 @@ func (utp *UnconfirmedTransactionPool) AllRawTransactions(tx *dbutil.Tx) (coin.T
 
 // Remove a single txn by hash
 func (utp *UnconfirmedTransactionPool) removeTransaction(tx *dbutil.Tx, txHash cipher.SHA256) error {
+	txn, err := utp.txns.get(tx, txHash)
+	if err != nil {
+		return err
+	}
+
+	if txn != nil {
+		n, size, err := utp.Size(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := utp.meta.setSize(tx, n-1, size-uint64(txn.Transaction.Length)); err != nil {
+			return err
+		}
+	}
+
 	if err := utp.txns.delete(tx, txHash); err != nil {
 		return err
 	}
 
+	if err := utp.firstSeen.delete(tx, txHash); err != nil {
+		return err
+	}
+
 	return utp.unspent.delete(tx, txHash)
 }
 
@@ -545,6 +707,153 @@ func (utp *UnconfirmedTransactionPool) GetUnspentsOfAddr(tx *dbutil.Tx, addr cip
 	return utp.unspent.getByAddr(tx, addr)
 }
 
+// Size returns the number of transactions in the pool and their total size in bytes
+func (utp *UnconfirmedTransactionPool) Size(tx *dbutil.Tx) (uint64, uint64, error) {
+	n, size, ok, err := utp.meta.getSize(tx)
+	if err != nil {
+		return 0, 0, err
+	} else if ok {
+		return n, size, nil
+	}
+
+	// The size is not recorded in a db created before it was tracked, count the pool
+	return utp.countSize(tx)
+}
+
+func (utp *UnconfirmedTransactionPool) countSize(tx *dbutil.Tx) (uint64, uint64, error) {
+	var n, size uint64
+	if err := utp.txns.forEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
+		n++
+		size += uint64(txn.Transaction.Length)
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	return n, size, nil
+}
+
+// RemoveExpired removes the transactions that were first received longer than ttl ago.
+// Receiving a transaction again does not extend its lifetime.
+// The transactions that were removed are returned.
+func (utp *UnconfirmedTransactionPool) RemoveExpired(tx *dbutil.Tx, ttl time.Duration, now time.Time) ([]cipher.SHA256, error) {
+	cutoff := now.Add(-ttl).UnixNano()
+
+	var expired []cipher.SHA256
+	if err := utp.txns.forEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
+		firstSeen, ok, err := utp.firstSeen.get(tx, hash)
+		if err != nil {
+			return err
+		}
+
+		// The first seen time is not recorded for txns added before it was tracked
+		if !ok {
+			firstSeen = txn.Received
+		}
+
+		if firstSeen < cutoff {
+			expired = append(expired, hash)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := utp.RemoveTransactions(tx, expired); err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// RemoveCheapest removes the transactions with the lowest fee per kB until the pool has
+// at most maxTxns transactions whose total size is at most maxBytes. A limit of 0 is unlimited.
+// Transactions whose fee can't be computed are removed first. If the fee per kB is equal,
+// the most recently received transaction is removed first.
+// The transactions that were removed are returned.
+func (utp *UnconfirmedTransactionPool) RemoveCheapest(tx *dbutil.Tx, bc Blockchainer, maxTxns, maxBytes uint64) ([]cipher.SHA256, error) {
+	if maxTxns == 0 && maxBytes == 0 {
+		return nil, nil
+	}
+
+	overLimit := func(n, size uint64) bool {
+		return (maxTxns != 0 && n > maxTxns) || (maxBytes != 0 && size > maxBytes)
+	}
+
+	// Only scan the pool when it exceeds a limit
+	n, totalBytes, err := utp.Size(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !overLimit(n, totalBytes) {
+		return nil, nil
+	}
+
+	type pooledTxn struct {
+		hash     cipher.SHA256
+		txn      coin.Transaction
+		size     uint64
+		feeKB    uint64
+		received int64
+	}
+
+	var txns []pooledTxn
+	if err := utp.txns.forEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
+		txns = append(txns, pooledTxn{
+			hash:     hash,
+			txn:      txn.Transaction,
+			size:     uint64(txn.Transaction.Length),
+			received: txn.Received,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+	feeCalc := bc.TransactionFee(tx, head.Time())
+
+	for i := range txns {
+		if fee, err := feeCalc(&txns[i].txn); err == nil {
+			txns[i].feeKB = PendingTransaction{
+				Fee:  fee,
+				Size: uint32(txns[i].size),
+			}.FeePerKB()
+		}
+	}
+
+	sort.Slice(txns, func(i, j int) bool {
+		if txns[i].feeKB != txns[j].feeKB {
+			return txns[i].feeKB < txns[j].feeKB
+		}
+		if txns[i].received != txns[j].received {
+			return txns[i].received > txns[j].received
+		}
+		return bytes.Compare(txns[i].hash[:], txns[j].hash[:]) < 0
+	})
+
+	var removed []cipher.SHA256
+	for _, p := range txns {
+		if !overLimit(n, totalBytes) {
+			break
+		}
+
+		removed = append(removed, p.hash)
+		n--
+		totalBytes -= p.size
+	}
+
+	if err := utp.RemoveTransactions(tx, removed); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
 // IsValid can be used as filter function
 func IsValid(tx UnconfirmedTransaction) bool {
 	return tx.IsValid == 1
@@ -559,3 +868,69 @@ func All(tx UnconfirmedTransaction) bool {
 func (utp *UnconfirmedTransactionPool) Len(tx *dbutil.Tx) (uint64, error) {
 	return utp.txns.len(tx)
 }
+
+// maxRecentEvictions is the number of recently evicted transactions kept by unconfirmedEvictions
+const maxRecentEvictions = 1000
+
+// unconfirmedEvictions counts the transactions evicted from the unconfirmed pool by reason,
+// and keeps the most recently evicted transactions
+type unconfirmedEvictions struct {
+	sync.Mutex
+	counts map[EvictionReason]uint64
+	recent []EvictedTransaction
+}
+
+func newUnconfirmedEvictions() *unconfirmedEvictions {
+	return &unconfirmedEvictions{
+		counts: make(map[EvictionReason]uint64),
+	}
+}
+
+func (e *unconfirmedEvictions) add(evicted []EvictedTransaction) {
+	e.Lock()
+	defer e.Unlock()
+
+	for _, t := range evicted {
+		e.counts[t.Reason]++
+	}
+
+	e.recent = append(e.recent, evicted...)
+	if len(e.recent) > maxRecentEvictions {
+		e.recent = append([]EvictedTransaction{}, e.recent[len(e.recent)-maxRecentEvictions:]...)
+	}
+}
+
+func (e *unconfirmedEvictions) getCounts() map[EvictionReason]uint64 {
+	e.Lock()
+	defer e.Unlock()
+
+	counts := make(map[EvictionReason]uint64, len(e.counts))
+	for k, v := range e.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// getRecent returns the recently evicted transactions, most recent first
+func (e *unconfirmedEvictions) getRecent() []EvictedTransaction {
+	e.Lock()
+	defer e.Unlock()
+
+	recent := make([]EvictedTransaction, len(e.recent))
+	for i, t := range e.recent {
+		recent[len(e.recent)-1-i] = t
+	}
+	return recent
+}
+
+func newEvictedTransactions(hashes []cipher.SHA256, reason EvictionReason, now time.Time) []EvictedTransaction {
+	evicted := make([]EvictedTransaction, len(hashes))
+	for i, h := range hashes {
+		evicted[i] = EvictedTransaction{
+			Hash:   h,
+			Reason: reason,
+			Time:   now,
+		}
+	}
+	return evicted
+}
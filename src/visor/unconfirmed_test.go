@@ -0,0 +1,317 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// makeUnconfirmedLimitsChain creates a chain with split genesis outputs, and spending transactions with increasing fees
+func makeUnconfirmedLimitsChain(t *testing.T, n int) (*testChain, []coin.Transaction) {
+	c := newTestChain(t)
+	c.v.evictions = newUnconfirmedEvictions()
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, n, params.UserVerifyTxn.MaxDropletPrecision)
+	b1 := c.addBlock(splitTxn)
+	uxs := coin.CreateUnspents(b1.Head, splitTxn)
+
+	toAddr := testutil.MakeAddress()
+	txns := make([]coin.Transaction, n)
+	for i := range txns {
+		txns[i] = makeSpendTxWithFee(t, coin.UxArray{uxs[i]}, []cipher.SecKey{genSecret}, toAddr, 9e6, uint64(i+1)*10)
+	}
+
+	return c, txns
+}
+
+func requireUnconfirmedHashes(t *testing.T, v *Visor, txns ...coin.Transaction) {
+	utxns, err := v.GetAllUnconfirmedTransactions()
+	require.NoError(t, err)
+
+	expected := make(map[cipher.SHA256]struct{}, len(txns))
+	for _, txn := range txns {
+		expected[txn.Hash()] = struct{}{}
+	}
+
+	actual := make(map[cipher.SHA256]struct{}, len(utxns))
+	for _, utxn := range utxns {
+		actual[utxn.Transaction.Hash()] = struct{}{}
+	}
+
+	require.Equal(t, expected, actual)
+}
+
+func TestUnconfirmedPoolMaxTransactions(t *testing.T) {
+	c, txns := makeUnconfirmedLimitsChain(t, 4)
+	defer c.shutdown()
+
+	c.v.Config.UnconfirmedPoolLimits = UnconfirmedPoolLimits{
+		MaxTransactions: 2,
+	}
+
+	for _, txn := range []coin.Transaction{txns[1], txns[3]} {
+		known, softErr, err := c.v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		require.Nil(t, softErr)
+		require.False(t, known)
+	}
+
+	// Injecting a transaction with a higher fee evicts the cheapest transaction
+	_, _, err := c.v.InjectForeignTransaction(txns[2])
+	require.NoError(t, err)
+	requireUnconfirmedHashes(t, c.v, txns[2], txns[3])
+
+	// Injecting a transaction with a lower fee than the pool is rejected
+	_, _, err = c.v.InjectForeignTransaction(txns[0])
+	require.Equal(t, ErrUnconfirmedPoolFull, err)
+	requireUnconfirmedHashes(t, c.v, txns[2], txns[3])
+
+	_, _, _, err = c.v.InjectUserTransaction(txns[0])
+	require.Equal(t, ErrUnconfirmedPoolFull, err)
+	requireUnconfirmedHashes(t, c.v, txns[2], txns[3])
+
+	// Injecting a known transaction does not evict anything
+	known, _, err := c.v.InjectForeignTransaction(txns[2])
+	require.NoError(t, err)
+	require.True(t, known)
+
+	evicted := c.v.GetEvictedTransactions()
+	require.Len(t, evicted, 1)
+	require.Equal(t, txns[1].Hash(), evicted[0].Hash)
+	require.Equal(t, EvictPoolFull, evicted[0].Reason)
+
+	size2, err := txns[2].Size()
+	require.NoError(t, err)
+	size3, err := txns[3].Size()
+	require.NoError(t, err)
+
+	status, err := c.v.GetUnconfirmedPoolStatus()
+	require.NoError(t, err)
+	require.Equal(t, &UnconfirmedPoolStatus{
+		Transactions: 2,
+		Bytes:        uint64(size2 + size3),
+		Limits:       c.v.Config.UnconfirmedPoolLimits,
+		Evicted: map[EvictionReason]uint64{
+			EvictPoolFull: 1,
+		},
+	}, status)
+}
+
+func TestUnconfirmedPoolMaxBytes(t *testing.T) {
+	c, txns := makeUnconfirmedLimitsChain(t, 3)
+	defer c.shutdown()
+
+	for _, txn := range txns {
+		_, _, err := c.v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	size, err := txns[0].Size()
+	require.NoError(t, err)
+
+	// Lowering the limits evicts the cheapest transactions on the next eviction
+	c.v.Config.UnconfirmedPoolLimits = UnconfirmedPoolLimits{
+		MaxBytes: uint64(size) + 1,
+	}
+
+	evicted, err := c.v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Len(t, evicted, 2)
+	require.Equal(t, txns[0].Hash(), evicted[0].Hash)
+	require.Equal(t, txns[1].Hash(), evicted[1].Hash)
+	requireUnconfirmedHashes(t, c.v, txns[2])
+
+	evicted, err = c.v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+}
+
+func TestUnconfirmedPoolTTL(t *testing.T) {
+	c, txns := makeUnconfirmedLimitsChain(t, 2)
+	defer c.shutdown()
+
+	c.v.Config.UnconfirmedPoolLimits = UnconfirmedPoolLimits{
+		TTL: time.Hour,
+	}
+
+	for _, txn := range txns {
+		_, _, err := c.v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	// Nothing has expired yet
+	evicted, err := c.v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+
+	// Make the first transaction older than the TTL
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return c.v.unconfirmed.(*UnconfirmedTransactionPool).firstSeen.put(tx, txns[0].Hash(), time.Now().Add(-time.Hour*2).UnixNano())
+	})
+	require.NoError(t, err)
+
+	// Receiving the transaction again does not extend its lifetime
+	known, _, err := c.v.InjectForeignTransaction(txns[0])
+	require.NoError(t, err)
+	require.True(t, known)
+
+	evicted, err = c.v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	require.Equal(t, txns[0].Hash(), evicted[0].Hash)
+	require.Equal(t, EvictExpired, evicted[0].Reason)
+	requireUnconfirmedHashes(t, c.v, txns[1])
+
+	status, err := c.v.GetUnconfirmedPoolStatus()
+	require.NoError(t, err)
+	require.Equal(t, map[EvictionReason]uint64{
+		EvictExpired: 1,
+	}, status.Evicted)
+}
+
+func TestUnconfirmedPoolSize(t *testing.T) {
+	c, txns := makeUnconfirmedLimitsChain(t, 3)
+	defer c.shutdown()
+
+	utp := c.v.unconfirmed.(*UnconfirmedTransactionPool)
+
+	requireSize := func(n int, size uint64) {
+		err := c.v.db.View("", func(tx *dbutil.Tx) error {
+			actualN, actualSize, err := utp.Size(tx)
+			require.NoError(t, err)
+			require.Equal(t, uint64(n), actualN)
+			require.Equal(t, size, actualSize)
+
+			countN, countSize, err := utp.countSize(tx)
+			require.NoError(t, err)
+			require.Equal(t, actualN, countN)
+			require.Equal(t, actualSize, countSize)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	var size uint64
+	for i, txn := range txns {
+		_, _, err := c.v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		size += uint64(txn.Length)
+		requireSize(i+1, size)
+	}
+
+	// Receiving a transaction again does not change the size
+	_, _, err := c.v.InjectForeignTransaction(txns[0])
+	require.NoError(t, err)
+	requireSize(3, size)
+
+	// The size is counted from the pool if it was not recorded
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return dbutil.Reset(tx, UnconfirmedMetaBkt)
+	})
+	require.NoError(t, err)
+	requireSize(3, size)
+
+	err = c.v.db.Update("", func(tx *dbutil.Tx) error {
+		return utp.RemoveTransactions(tx, []cipher.SHA256{txns[0].Hash(), testutil.RandSHA256(t)})
+	})
+	require.NoError(t, err)
+	requireSize(2, size-uint64(txns[0].Length))
+}
+
+func TestUnconfirmedEvictionsRecent(t *testing.T) {
+	e := newUnconfirmedEvictions()
+	now := time.Now()
+
+	var hashes []cipher.SHA256
+	for i := 0; i < maxRecentEvictions+10; i++ {
+		hashes = append(hashes, testutil.RandSHA256(t))
+	}
+
+	e.add(newEvictedTransactions(hashes[:10], EvictExpired, now))
+	e.add(newEvictedTransactions(hashes[10:], EvictPoolFull, now))
+
+	recent := e.getRecent()
+	require.Len(t, recent, maxRecentEvictions)
+	require.Equal(t, hashes[len(hashes)-1], recent[0].Hash)
+	require.Equal(t, hashes[10], recent[len(recent)-1].Hash)
+
+	require.Equal(t, map[EvictionReason]uint64{
+		EvictExpired:  10,
+		EvictPoolFull: maxRecentEvictions,
+	}, e.getCounts())
+}
+
+func drainEvents(s *eventbus.Subscription) []eventbus.Event {
+	var events []eventbus.Event
+	for {
+		select {
+		case e := <-s.Events():
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestVisorPublishesEvents(t *testing.T) {
+	c, txns := makeUnconfirmedLimitsChain(t, 2)
+	defer c.shutdown()
+
+	bus := eventbus.New()
+	defer bus.Close()
+	sub := bus.Subscribe(0)
+	c.v.Config.EventBus = bus
+
+	_, _, err := c.v.InjectForeignTransaction(txns[0])
+	require.NoError(t, err)
+	require.Equal(t, []eventbus.Event{
+		eventbus.TransactionAdded{
+			Transaction: txns[0],
+			Hash:        txns[0].Hash(),
+		},
+	}, drainEvents(sub))
+
+	// A transaction evicted by a new transaction is published as removed
+	c.v.Config.UnconfirmedPoolLimits.MaxTransactions = 1
+	_, _, _, err = c.v.InjectUserTransaction(txns[1])
+	require.NoError(t, err)
+	require.Equal(t, []eventbus.Event{
+		eventbus.TransactionAdded{
+			Transaction: txns[1],
+			Hash:        txns[1].Hash(),
+		},
+		eventbus.TransactionRemoved{
+			Hash:   txns[0].Hash(),
+			Reason: eventbus.RemovedPoolFull,
+		},
+	}, drainEvents(sub))
+
+	// Known and rejected transactions are not published
+	_, _, err = c.v.InjectForeignTransaction(txns[1])
+	require.NoError(t, err)
+	_, _, err = c.v.InjectForeignTransaction(txns[0])
+	require.Equal(t, ErrUnconfirmedPoolFull, err)
+	require.Empty(t, drainEvents(sub))
+
+	sb, err := c.v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Equal(t, []eventbus.Event{
+		eventbus.BlockExecuted{
+			Block: sb,
+		},
+		eventbus.TransactionRemoved{
+			Hash:   txns[1].Hash(),
+			Reason: eventbus.RemovedConfirmed,
+		},
+	}, drainEvents(sub))
+}
@@ -82,6 +82,9 @@ const (
 	TxnSigned TxnSignedFlag = 1
 	// TxnUnsigned is used for unsigned transactions
 	TxnUnsigned TxnSignedFlag = 2
+	// TxnAssumeSigned is used for signed transactions in blocks that are assumed to be valid.
+	// The signatures are not verified.
+	TxnAssumeSigned TxnSignedFlag = 3
 )
 
 // ErrTxnViolatesHardConstraint is returned when a transaction violates hard constraints
@@ -285,6 +288,10 @@ func verifyTxnHardConstraints(txn coin.Transaction, head coin.BlockHeader, uxIn
 		if err := txn.VerifyInputSignatures(uxIn); err != nil {
 			return err
 		}
+	case TxnAssumeSigned:
+		if err := txn.VerifyAssumeSigned(); err != nil {
+			return err
+		}
 	case TxnUnsigned:
 		if err := txn.VerifyUnsigned(); err != nil {
 			return err
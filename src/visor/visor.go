@@ -14,14 +14,15 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/eventbus"
 	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/fee"
 	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/skycoin/skycoin/src/util/mathutil"
 	"github.com/skycoin/skycoin/src/util/timeutil"
@@ -33,6 +34,11 @@ import (
 
 var logger = logging.MustGetLogger("visor")
 
+var (
+	// ErrPruningRequired is returned if a database with pruned blocks is opened without block pruning enabled
+	ErrPruningRequired = errors.New("The database has pruned block bodies, it can only be used with block pruning enabled")
+)
+
 // Visor manages the blockchain
 type Visor struct {
 	Config Config
@@ -43,6 +49,21 @@ type Visor struct {
 	blockchain  Blockchainer
 	history     Historyer
 	wallets     *wallet.Service
+
+	// historyRebuild is set if the historydb is rebuilt in the background by RunHistoryRebuild
+	historyRebuild *rebuildingHistory
+
+	// light is set in light client mode
+	light *LightClient
+
+	// blockAssembly records the result of the most recent block assembly, if a block publisher
+	blockAssembly *lastBlockAssembly
+
+	// evictions records the transactions evicted from the unconfirmed pool
+	evictions *unconfirmedEvictions
+
+	// distOutputs caches the outputs of the distribution addresses used by the supply stats
+	distOutputs *distributionOutputsCache
 }
 
 // New creates a Visor for managing the blockchain database
@@ -71,15 +92,69 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 		}
 	}
 
+	if len(c.Checkpoints) != 0 {
+		logger.Infof("Using %d checkpoints", len(c.Checkpoints))
+	}
+	if !c.AssumeValid.Hash.Null() {
+		logger.Infof("Not verifying the transaction signatures of the assume-valid block %s and its main chain ancestors once it is reached", c.AssumeValid)
+	}
+
 	bc, err := NewBlockchain(db, BlockchainConfig{
-		Pubkey:      c.BlockchainPubkey,
-		Arbitrating: c.Arbitrating,
+		Pubkey:           c.BlockchainPubkey,
+		Arbitrating:      c.Arbitrating,
+		Checkpoints:      c.checkpoints(),
+		AssumeValid:      c.AssumeValid,
+		SigVerifyWorkers: c.SigVerifyWorkers,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	history := historydb.New()
+	if c.PruneBlocks == 0 {
+		// A pruned database can't serve or rebuild from the pruned block bodies
+		if err := db.View("check pruned blocks", func(tx *dbutil.Tx) error {
+			if !dbutil.Exists(tx, blockdb.BlockchainMetaBkt) {
+				return nil
+			}
+
+			if _, ok, err := bc.PrunedSeq(tx); err != nil {
+				return err
+			} else if ok {
+				return ErrPruningRequired
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	} else {
+		logger.Infof("Block pruning enabled, keeping the bodies of the last %d blocks. The historydb is disabled", c.PruneBlocks)
+	}
+
+	var light *LightClient
+	if c.LightClient {
+		logger.Infof("Visor running in light client mode, watching %d addresses. The historydb is disabled", len(c.WatchAddresses))
+		light = NewLightClient(c.BlockchainPubkey, c.WatchAddresses)
+	}
+
+	if err := db.View("verify checkpoints", func(tx *dbutil.Tx) error {
+		return verifyStoredCheckpoints(tx, c.checkpoints(), bc, light)
+	}); err != nil {
+		return nil, err
+	}
+
+	if c.DisableHistory && c.PruneBlocks == 0 && !c.LightClient {
+		logger.Info("The historydb is disabled")
+	}
+
+	historyDB := historydb.New()
+
+	var history Historyer = historyDB
+	if c.PruneBlocks > 0 || c.LightClient || c.DisableHistory {
+		history = disabledHistory{}
+	}
+
+	var historyRebuild *rebuildingHistory
 
 	if !db.IsReadOnly() {
 		if err := db.Update("build unspent indexes and init history", func(tx *dbutil.Tx) error {
@@ -92,25 +167,51 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 				return err
 			}
 
-			return initHistory(tx, bc, history)
+			if c.LightClient {
+				return eraseHistory(tx, historyDB)
+			}
+
+			if c.PruneBlocks == 0 {
+				if c.DisableHistory {
+					return eraseHistory(tx, historyDB)
+				}
+
+				historyRebuild, err = initHistory(tx, bc, historyDB)
+				return err
+			}
+
+			if err := eraseHistory(tx, historyDB); err != nil {
+				return err
+			}
+
+			return pruneBlocks(tx, bc, c.PruneBlocks)
 		}); err != nil {
 			return nil, err
 		}
 	}
 
+	if historyRebuild != nil {
+		history = historyRebuild
+	}
+
 	utp, err := NewUnconfirmedTransactionPool(db)
 	if err != nil {
 		return nil, err
 	}
 
 	v := &Visor{
-		Config:      c,
-		startedAt:   time.Now(),
-		db:          db,
-		blockchain:  bc,
-		unconfirmed: utp,
-		history:     history,
-		wallets:     wltServ,
+		Config:         c,
+		startedAt:      time.Now(),
+		db:             db,
+		blockchain:     bc,
+		unconfirmed:    utp,
+		history:        history,
+		wallets:        wltServ,
+		historyRebuild: historyRebuild,
+		light:          light,
+		blockAssembly:  &lastBlockAssembly{},
+		evictions:      newUnconfirmedEvictions(),
+		distOutputs:    &distributionOutputsCache{},
 	}
 
 	return v, nil
@@ -134,6 +235,17 @@ func (vs *Visor) Init() error {
 			return err
 		}
 
+		if vs.light != nil {
+			gb, err := vs.blockchain.GetGenesisBlock(tx)
+			if err != nil {
+				return err
+			}
+
+			if err := vs.light.Init(tx, gb.SignedHeader()); err != nil {
+				return err
+			}
+		}
+
 		removed, err := vs.unconfirmed.RemoveInvalid(tx, vs.blockchain)
 		if err != nil {
 			return err
@@ -144,62 +256,48 @@ func (vs *Visor) Init() error {
 	})
 }
 
-func initHistory(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB) error {
+// initHistory erases the historydb if it needs to be reset. If the historydb has not parsed
+// all of the blocks, it returns a rebuildingHistory for RunHistoryRebuild to parse the missing blocks.
+func initHistory(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB) (*rebuildingHistory, error) {
 	logger.Info("Visor initHistory")
 
 	shouldReset, err := history.NeedsReset(tx)
 	if err != nil {
-		return err
-	}
-
-	if !shouldReset {
-		return nil
+		return nil, err
 	}
 
-	logger.Info("Resetting historyDB")
+	if shouldReset {
+		logger.Info("Resetting historyDB")
 
-	if err := history.Erase(tx); err != nil {
-		return err
+		if err := history.Erase(tx); err != nil {
+			return nil, err
+		}
 	}
 
-	// Reparse the history up to the blockchain head
-	headSeq, _, err := bc.HeadSeq(tx)
+	headSeq, ok, err := bc.HeadSeq(tx)
 	if err != nil {
-		return err
-	}
-
-	if err := parseHistoryTo(tx, history, bc, headSeq); err != nil {
-		logger.WithError(err).Error("parseHistoryTo failed")
-		return err
+		return nil, err
+	} else if !ok {
+		return nil, nil
 	}
 
-	return nil
-}
-
-func parseHistoryTo(tx *dbutil.Tx, history *historydb.HistoryDB, bc *Blockchain, height uint64) error {
-	logger.Info("Visor parseHistoryTo")
-
-	parsedBlockSeq, _, err := history.ParsedBlockSeq(tx)
+	var parsedBlocks uint64
+	parsedSeq, ok, err := history.ParsedBlockSeq(tx)
 	if err != nil {
-		return err
+		return nil, err
+	} else if ok {
+		parsedBlocks = parsedSeq + 1
 	}
 
-	for i := uint64(0); i < height-parsedBlockSeq; i++ {
-		b, err := bc.GetSignedBlockBySeq(tx, parsedBlockSeq+i+1)
-		if err != nil {
-			return err
-		}
-
-		if b == nil {
-			return fmt.Errorf("no block exists in depth: %d", parsedBlockSeq+i+1)
-		}
-
-		if err := history.ParseBlock(tx, b.Block); err != nil {
-			return err
-		}
+	if parsedBlocks > headSeq {
+		return nil, nil
 	}
 
-	return nil
+	logger.Infof("HistoryDB has parsed %d of %d blocks, it will be rebuilt in the background", parsedBlocks, headSeq+1)
+
+	h := newRebuildingHistory(history)
+	h.setProgress(parsedBlocks, headSeq+1)
+	return h, nil
 }
 
 // maybeCreateGenesisBlock creates a genesis block if necessary
@@ -277,9 +375,137 @@ func (vs *Visor) RemoveInvalidUnconfirmed() ([]cipher.SHA256, error) {
 		return nil, err
 	}
 
+	vs.recordEvictions(newEvictedTransactions(hashes, EvictInvalid, time.Now().UTC()))
+
 	return hashes, nil
 }
 
+// EvictUnconfirmed removes the transactions that expired from the unconfirmed pool,
+// and evicts the transactions with the lowest fee if the pool exceeds its limits.
+// Returns the transactions that were evicted.
+func (vs *Visor) EvictUnconfirmed() ([]EvictedTransaction, error) {
+	var evicted []EvictedTransaction
+	if err := vs.db.Update("EvictUnconfirmed", func(tx *dbutil.Tx) error {
+		var err error
+		evicted, err = vs.limitUnconfirmed(tx, time.Now().UTC())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	vs.recordEvictions(evicted)
+
+	return evicted, nil
+}
+
+// limitUnconfirmed applies the UnconfirmedPoolLimits to the unconfirmed pool
+func (vs *Visor) limitUnconfirmed(tx *dbutil.Tx, now time.Time) ([]EvictedTransaction, error) {
+	limits := vs.Config.UnconfirmedPoolLimits
+
+	var evicted []EvictedTransaction
+	if limits.TTL != 0 {
+		hashes, err := vs.unconfirmed.RemoveExpired(tx, limits.TTL, now)
+		if err != nil {
+			return nil, err
+		}
+		evicted = append(evicted, newEvictedTransactions(hashes, EvictExpired, now)...)
+	}
+
+	hashes, err := vs.unconfirmed.RemoveCheapest(tx, vs.blockchain, limits.MaxTransactions, limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	evicted = append(evicted, newEvictedTransactions(hashes, EvictPoolFull, now)...)
+
+	return evicted, nil
+}
+
+// limitUnconfirmedAfterInject applies the size limits of the UnconfirmedPoolLimits after a new transaction was injected.
+// Expired transactions are left to EvictUnconfirmed, which runs periodically.
+// Returns ErrUnconfirmedPoolFull if the injected transaction itself was evicted.
+func (vs *Visor) limitUnconfirmedAfterInject(tx *dbutil.Tx, txn coin.Transaction) ([]EvictedTransaction, error) {
+	limits := vs.Config.UnconfirmedPoolLimits
+
+	hashes, err := vs.unconfirmed.RemoveCheapest(tx, vs.blockchain, limits.MaxTransactions, limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	evicted := newEvictedTransactions(hashes, EvictPoolFull, time.Now().UTC())
+
+	hash := txn.Hash()
+	for _, e := range evicted {
+		if e.Hash == hash {
+			return nil, ErrUnconfirmedPoolFull
+		}
+	}
+
+	return evicted, nil
+}
+
+func (vs *Visor) recordEvictions(evicted []EvictedTransaction) {
+	if len(evicted) == 0 {
+		return
+	}
+
+	for _, e := range evicted {
+		logger.WithFields(logrus.Fields{
+			"txid":   e.Hash.Hex(),
+			"reason": e.Reason,
+		}).Debug("Evicted unconfirmed transaction")
+	}
+	logger.Infof("Evicted %d transactions from the unconfirmed pool", len(evicted))
+
+	if vs.evictions != nil {
+		vs.evictions.add(evicted)
+	}
+
+	for _, e := range evicted {
+		vs.Config.EventBus.Publish(eventbus.TransactionRemoved{
+			Hash:   e.Hash,
+			Reason: e.Reason.removalReason(),
+		})
+	}
+}
+
+// UnconfirmedPoolStatus is the size, limits and eviction counts of the unconfirmed pool
+type UnconfirmedPoolStatus struct {
+	Transactions uint64
+	Bytes        uint64
+	Limits       UnconfirmedPoolLimits
+	// Number of transactions evicted since startup, by reason
+	Evicted map[EvictionReason]uint64
+}
+
+// GetUnconfirmedPoolStatus returns the size, limits and eviction counts of the unconfirmed pool
+func (vs *Visor) GetUnconfirmedPoolStatus() (*UnconfirmedPoolStatus, error) {
+	status := &UnconfirmedPoolStatus{
+		Limits:  vs.Config.UnconfirmedPoolLimits,
+		Evicted: make(map[EvictionReason]uint64),
+	}
+
+	if err := vs.db.View("GetUnconfirmedPoolStatus", func(tx *dbutil.Tx) error {
+		var err error
+		status.Transactions, status.Bytes, err = vs.unconfirmed.Size(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if vs.evictions != nil {
+		status.Evicted = vs.evictions.getCounts()
+	}
+
+	return status, nil
+}
+
+// GetEvictedTransactions returns the transactions most recently evicted from the unconfirmed pool, most recent first
+func (vs *Visor) GetEvictedTransactions() []EvictedTransaction {
+	if vs.evictions == nil {
+		return nil
+	}
+	return vs.evictions.getRecent()
+}
+
 // CreateBlock creates a SignedBlock from pending transactions
 func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, error) {
 	if !vs.Config.IsBlockPublisher {
@@ -298,58 +524,73 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 
 	logger.Infof("unconfirmed pool has %d transactions pending", len(txns))
 
-	// Filter transactions that violate all constraints
-	var filteredTxns coin.Transactions
+	head, err := vs.blockchain.Head(tx)
+	if err != nil {
+		return coin.SignedBlock{}, err
+	}
+
+	// Filter transactions that violate all constraints, and compute the fee and senders of the others
+	var skipped []SkippedTransaction
+	pending := make([]PendingTransaction, 0, len(txns))
 	for _, txn := range txns {
-		if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, vs.Config.CreateBlockVerifyTxn, TxnSigned); err != nil {
-			switch err.(type) {
-			case ErrTxnViolatesHardConstraint, ErrTxnViolatesSoftConstraint:
-				logger.Warningf("Transaction %s violates constraints: %v", txn.Hash().Hex(), err)
-			default:
-				return coin.SignedBlock{}, err
-			}
-		} else {
-			filteredTxns = append(filteredTxns, txn)
+		p, reason, err := vs.newPendingTransaction(tx, txn, head.Time())
+		if err != nil {
+			return coin.SignedBlock{}, err
 		}
+
+		if reason != "" {
+			skipped = append(skipped, SkippedTransaction{
+				Hash:   txn.Hash(),
+				Reason: reason,
+			})
+			continue
+		}
+
+		pending = append(pending, *p)
 	}
 
-	nRemoved := len(txns) - len(filteredTxns)
+	nRemoved := len(txns) - len(pending)
 	if nRemoved > 0 {
-		logger.Infof("CreateBlock ignored %d transactions violating constraints", nRemoved)
+		logger.Infof("CreateBlock ignored %d transactions violating constraints or with an invalid fee", nRemoved)
 	}
 
-	txns = filteredTxns
-
-	if len(txns) == 0 {
+	if len(pending) == 0 {
+		vs.setLastBlockAssembly(when, nil, skipped, false)
 		logger.Info("No transactions after filtering for constraint violations")
 		return coin.SignedBlock{}, errors.New("No transactions after filtering for constraint violations")
 	}
 
-	head, err := vs.blockchain.Head(tx)
+	ba, err := vs.blockAssembler().AssembleBlock(pending, BlockAssemblyParams{
+		HeadTime:             head.Time(),
+		Time:                 when,
+		MaxBlockSize:         vs.Config.MaxBlockTransactionsSize,
+		MaxBlockTransactions: coin.MaxBlockTransactions,
+	})
 	if err != nil {
+		logger.Critical().WithError(err).Error("AssembleBlock failed")
 		return coin.SignedBlock{}, err
 	}
 
-	// Sort them by highest fee per kilobyte
-	txns, err = coin.SortTransactions(txns, vs.blockchain.TransactionFee(tx, head.Time()))
-	if err != nil {
-		logger.Critical().WithError(err).Error("SortTransactions failed, no block can be made until the offending transaction is removed")
-		return coin.SignedBlock{}, err
-	}
+	skipped = append(skipped, ba.Skipped...)
+	vs.setLastBlockAssembly(when, ba.Transactions, skipped, ba.Hold)
 
-	// Apply block size transaction limit
-	txns, err = txns.TruncateBytesTo(vs.Config.MaxBlockTransactionsSize)
-	if err != nil {
-		logger.Critical().WithError(err).Error("TruncateBytesTo failed, no block can be made until the offending transaction is removed")
-		return coin.SignedBlock{}, err
+	for _, s := range ba.Skipped {
+		logger.WithFields(logrus.Fields{
+			"txid":   s.Hash.Hex(),
+			"reason": s.Reason,
+		}).Debug("CreateBlock skipped transaction")
 	}
 
-	if len(txns) > coin.MaxBlockTransactions {
-		txns = txns[:coin.MaxBlockTransactions]
+	if ba.Hold {
+		logger.Infof("Holding a partial block with %d transactions", len(ba.Transactions))
+		return coin.SignedBlock{}, ErrPartialBlockHeld
 	}
 
+	txns = ba.Transactions
+
 	if len(txns) == 0 {
-		logger.Panic("TruncateBytesTo removed all transactions")
+		logger.Info("No transactions selected by the block assembler")
+		return coin.SignedBlock{}, errors.New("No transactions selected by the block assembler")
 	}
 
 	logger.Infof("Creating new block with %d transactions, head time %d", len(txns), when)
@@ -363,6 +604,89 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 	return vs.signBlock(*b), nil
 }
 
+// newPendingTransaction computes the fee and senders of an unconfirmed transaction for creating a block.
+// If the transaction can't be included in a block, the reason is returned instead.
+func (vs *Visor) newPendingTransaction(tx *dbutil.Tx, txn coin.Transaction, headTime uint64) (*PendingTransaction, SkipReason, error) {
+	if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.Distribution, vs.Config.CreateBlockVerifyTxn, TxnSigned); err != nil {
+		switch err.(type) {
+		case ErrTxnViolatesHardConstraint, ErrTxnViolatesSoftConstraint:
+			logger.Warningf("Transaction %s violates constraints: %v", txn.Hash().Hex(), err)
+			return nil, SkipViolatesConstraints, nil
+		default:
+			return nil, "", err
+		}
+	}
+
+	size, hash, err := txn.SizeHash()
+	if err != nil {
+		logger.Critical().WithError(err).Error("txn.SizeHash failed, no block can be made until the offending transaction is removed")
+		return nil, "", err
+	}
+
+	inUxs, err := vs.blockchain.Unspent().GetArray(tx, txn.In)
+	if err != nil {
+		return nil, SkipInvalidFee, nil
+	}
+
+	f, err := fee.TransactionFee(&txn, headTime, inUxs)
+	if err != nil {
+		return nil, SkipInvalidFee, nil
+	}
+
+	senders := make([]cipher.Address, 0, len(inUxs))
+	seen := make(map[cipher.Address]struct{}, len(inUxs))
+	for _, ux := range inUxs {
+		if _, ok := seen[ux.Body.Address]; ok {
+			continue
+		}
+		seen[ux.Body.Address] = struct{}{}
+		senders = append(senders, ux.Body.Address)
+	}
+
+	return &PendingTransaction{
+		Transaction: txn,
+		Hash:        hash,
+		Size:        size,
+		Fee:         f,
+		Senders:     senders,
+	}, "", nil
+}
+
+// blockAssembler returns the configured BlockAssembler, or the DefaultBlockAssembler if not set
+func (vs *Visor) blockAssembler() BlockAssembler {
+	if vs.Config.BlockAssembler == nil {
+		return DefaultBlockAssembler{}
+	}
+	return vs.Config.BlockAssembler
+}
+
+func (vs *Visor) setLastBlockAssembly(when uint64, txns coin.Transactions, skipped []SkippedTransaction, held bool) {
+	if vs.blockAssembly == nil {
+		return
+	}
+
+	included := make([]cipher.SHA256, len(txns))
+	for i := range txns {
+		included[i] = txns[i].Hash()
+	}
+
+	vs.blockAssembly.set(&BlockAssemblyReport{
+		Time:     when,
+		Included: included,
+		Skipped:  skipped,
+		Held:     held,
+	})
+}
+
+// LastBlockAssembly returns the result of the most recent block assembly.
+// Returns nil if no block has been assembled since startup.
+func (vs *Visor) LastBlockAssembly() *BlockAssemblyReport {
+	if vs.blockAssembly == nil {
+		return nil
+	}
+	return vs.blockAssembly.get()
+}
+
 // CreateAndExecuteBlock creates a SignedBlock from pending transactions and executes it
 func (vs *Visor) CreateAndExecuteBlock() (coin.SignedBlock, error) {
 	var sb coin.SignedBlock
@@ -388,29 +712,210 @@ func (vs *Visor) ExecuteSignedBlock(b coin.SignedBlock) error {
 	})
 }
 
+// VerifyPendingBlockSignatures verifies the transaction signatures of blocks that are about to be executed
+// in parallel, so that they are not verified one block at a time when the blocks are executed.
+// The blocks are expected to be in sequence and follow the head block, the verification stops at the first
+// block that is not signed by the block publisher or does not follow the previous block
+func (vs *Visor) VerifyPendingBlockSignatures(blocks []coin.SignedBlock) error {
+	return vs.db.View("VerifyPendingBlockSignatures", func(tx *dbutil.Tx) error {
+		return vs.blockchain.VerifyPendingBlockSignatures(tx, blocks)
+	})
+}
+
 // executeSignedBlock adds a block to the blockchain, or returns error.
 // Blocks must be executed in sequence, and be signed by a block publisher node
 func (vs *Visor) executeSignedBlock(tx *dbutil.Tx, b coin.SignedBlock) error {
-	if err := b.VerifySignature(vs.Config.BlockchainPubkey); err != nil {
+	if err := vs.blockchain.VerifySignature(&b); err != nil {
 		return err
 	}
 
+	isFork, err := vs.blockchain.IsForkBlock(tx, b.Block)
+	if err != nil {
+		return err
+	}
+
+	if isFork {
+		if err := vs.executeForkBlock(tx, b); err != nil {
+			return err
+		}
+
+		return vs.maybePruneBlocks(tx)
+	}
+
 	if err := vs.blockchain.ExecuteBlock(tx, &b); err != nil {
 		return err
 	}
 
-	// Remove the transactions in the Block from the unconfirmed pool
-	txnHashes := make([]cipher.SHA256, 0, len(b.Block.Body.Transactions))
-	for _, txn := range b.Block.Body.Transactions {
-		txnHashes = append(txnHashes, txn.Hash())
-	}
+	// Remove the transactions in the Block from the unconfirmed pool
+	txnHashes := make([]cipher.SHA256, 0, len(b.Block.Body.Transactions))
+	for _, txn := range b.Block.Body.Transactions {
+		txnHashes = append(txnHashes, txn.Hash())
+	}
+
+	if err := vs.removeConfirmedTransactions(tx, b, txnHashes); err != nil {
+		return err
+	}
+
+	// Update the HistoryDB
+	if err := vs.history.ParseBlock(tx, b.Block); err != nil {
+		return err
+	}
+
+	return vs.maybePruneBlocks(tx)
+}
+
+// removeConfirmedTransactions removes the transactions of an executed block from the unconfirmed pool.
+// If an event bus is configured, the block and the removal of the transactions that were in the pool
+// are published after the db transaction is committed.
+func (vs *Visor) removeConfirmedTransactions(tx *dbutil.Tx, b coin.SignedBlock, txnHashes []cipher.SHA256) error {
+	var removed coin.Transactions
+	if vs.Config.EventBus != nil {
+		var err error
+		removed, err = vs.unconfirmed.GetKnown(tx, txnHashes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := vs.unconfirmed.RemoveTransactions(tx, txnHashes); err != nil {
+		return err
+	}
+
+	if vs.Config.EventBus != nil {
+		tx.OnCommit(func() {
+			vs.Config.EventBus.Publish(eventbus.BlockExecuted{
+				Block: b,
+			})
+			for _, txn := range removed {
+				vs.Config.EventBus.Publish(eventbus.TransactionRemoved{
+					Hash:   txn.Hash(),
+					Reason: eventbus.RemovedConfirmed,
+				})
+			}
+		})
+	}
+
+	return nil
+}
+
+// publishTransactionAdded publishes a transaction added to the unconfirmed pool after the db transaction is committed
+func (vs *Visor) publishTransactionAdded(tx *dbutil.Tx, txn coin.Transaction) {
+	if vs.Config.EventBus == nil {
+		return
+	}
+
+	tx.OnCommit(func() {
+		vs.Config.EventBus.Publish(eventbus.TransactionAdded{
+			Transaction: txn,
+			Hash:        txn.Hash(),
+		})
+	})
+}
+
+// publishBlocksRolledBack publishes the blocks removed from the main chain after the db transaction is committed
+func (vs *Visor) publishBlocksRolledBack(tx *dbutil.Tx, blocks []coin.SignedBlock) {
+	if vs.Config.EventBus == nil {
+		return
+	}
+
+	tx.OnCommit(func() {
+		for _, b := range blocks {
+			vs.Config.EventBus.Publish(eventbus.BlockRolledBack{
+				Block: b,
+			})
+		}
+	})
+}
+
+// maybePruneBlocks prunes the bodies of blocks that are older than the configured number of blocks to keep
+func (vs *Visor) maybePruneBlocks(tx *dbutil.Tx) error {
+	if vs.Config.PruneBlocks == 0 {
+		return nil
+	}
+
+	return pruneBlocks(tx, vs.blockchain, vs.Config.PruneBlocks)
+}
+
+// pruneBlocks prunes the bodies of all blocks except the most recent keep blocks
+func pruneBlocks(tx *dbutil.Tx, bc Blockchainer, keep uint64) error {
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok || headSeq < keep {
+		return nil
+	}
+
+	return bc.PruneBlocks(tx, headSeq-keep)
+}
+
+// executeForkBlock adds a block of a chain competing with the main chain.
+// If the blockchain is reorganized, the HistoryDB is rolled back to the fork point and rebuilt
+// from the new main chain blocks, and the transactions of the rolled back blocks that are not
+// in the new main chain are returned to the unconfirmed pool.
+func (vs *Visor) executeForkBlock(tx *dbutil.Tx, b coin.SignedBlock) error {
+	reorg, err := vs.blockchain.ExecuteForkBlock(tx, &b)
+	if err != nil {
+		return err
+	}
+
+	if reorg == nil {
+		return nil
+	}
+
+	for _, rb := range reorg.RolledBack {
+		if err := vs.history.RollbackBlock(tx, rb.Block); err != nil {
+			return err
+		}
+	}
+
+	vs.publishBlocksRolledBack(tx, reorg.RolledBack)
+
+	applied := make(map[cipher.SHA256]struct{})
+	for _, ab := range reorg.Applied {
+		txnHashes := make([]cipher.SHA256, 0, len(ab.Block.Body.Transactions))
+		for _, txn := range ab.Block.Body.Transactions {
+			h := txn.Hash()
+			txnHashes = append(txnHashes, h)
+			applied[h] = struct{}{}
+		}
+
+		if err := vs.removeConfirmedTransactions(tx, ab, txnHashes); err != nil {
+			return err
+		}
+
+		if err := vs.history.ParseBlock(tx, ab.Block); err != nil {
+			return err
+		}
+	}
+
+	// Return the orphaned transactions to the unconfirmed pool, oldest block first.
+	// Transactions that conflict with the new main chain are dropped.
+	for i := len(reorg.RolledBack) - 1; i >= 0; i-- {
+		for _, txn := range reorg.RolledBack[i].Block.Body.Transactions {
+			if _, ok := applied[txn.Hash()]; ok {
+				continue
+			}
 
-	if err := vs.unconfirmed.RemoveTransactions(tx, txnHashes); err != nil {
-		return err
+			known, _, err := vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, vs.Config.UnconfirmedVerifyTxn)
+			if err != nil {
+				switch err.(type) {
+				case ErrTxnViolatesHardConstraint:
+					logger.WithError(err).WithField("txid", txn.Hash().Hex()).Info("Dropped orphaned transaction")
+					continue
+				default:
+					return err
+				}
+			}
+
+			if !known {
+				vs.publishTransactionAdded(tx, txn)
+			}
+		}
 	}
 
-	// Update the HistoryDB
-	return vs.history.ParseBlock(tx, b.Block)
+	logger.Infof("Blockchain reorganized, rolled back %d blocks and applied %d blocks", len(reorg.RolledBack), len(reorg.Applied))
+
+	return nil
 }
 
 // signBlock signs a block for a block publisher node. Will panic if anything is invalid
@@ -514,10 +1019,21 @@ func (vs *Visor) unconfirmedIncomingOutputs(tx *dbutil.Tx) (coin.UxArray, error)
 }
 
 // GetSignedBlocksSince returns N signed blocks more recent than Seq. Does not return nil.
+// Returns no blocks if the bodies of the requested blocks have been pruned.
 func (vs *Visor) GetSignedBlocksSince(seq, ct uint64) ([]coin.SignedBlock, error) {
 	var blocks []coin.SignedBlock
 
 	if err := vs.db.View("GetSignedBlocksSince", func(tx *dbutil.Tx) error {
+		// The bodies of pruned blocks are not available
+		prunedSeq, _, err := vs.blockchain.PrunedSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		if seq < prunedSeq {
+			return nil
+		}
+
 		avail := uint64(0)
 		head, err := vs.blockchain.Head(tx)
 		if err != nil {
@@ -612,7 +1128,11 @@ func (vs *Visor) GetBlock(seq uint64) (*coin.SignedBlock, error) {
 		}
 
 		b, err = vs.blockchain.GetSignedBlockBySeq(tx, seq)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlockNotPruned(tx, b)
 	}); err != nil {
 		return nil, err
 	}
@@ -627,7 +1147,11 @@ func (vs *Visor) GetBlocks(seqs []uint64) ([]coin.SignedBlock, error) {
 	if err := vs.db.View("GetBlocks", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetBlocks(tx, seqs)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlocksNotPruned(tx, blocks)
 	}); err != nil {
 		return nil, err
 	}
@@ -661,7 +1185,11 @@ func (vs *Visor) GetBlocksInRange(start, end uint64) ([]coin.SignedBlock, error)
 	if err := vs.db.View("GetBlocksInRange", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetBlocksInRange(tx, start, end)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlocksNotPruned(tx, blocks)
 	}); err != nil {
 		return nil, err
 	}
@@ -696,7 +1224,11 @@ func (vs *Visor) GetLastBlocks(num uint64) ([]coin.SignedBlock, error) {
 	if err := vs.db.View("GetLastBlocks", func(tx *dbutil.Tx) error {
 		var err error
 		blocks, err = vs.blockchain.GetLastBlocks(tx, num)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlocksNotPruned(tx, blocks)
 	}); err != nil {
 		return nil, err
 	}
@@ -732,6 +1264,10 @@ func (vs *Visor) getBlocksVerbose(tx *dbutil.Tx, getBlocks func(*dbutil.Tx) ([]c
 		return nil, nil, nil
 	}
 
+	if err := vs.verifyBlocksNotPruned(tx, blocks); err != nil {
+		return nil, nil, err
+	}
+
 	inputs := make([][][]TransactionInput, len(blocks))
 	for i, b := range blocks {
 		blockInputs, err := vs.getBlockInputs(tx, &b)
@@ -744,6 +1280,34 @@ func (vs *Visor) getBlocksVerbose(tx *dbutil.Tx, getBlocks func(*dbutil.Tx) ([]c
 	return blocks, inputs, nil
 }
 
+// verifyBlockNotPruned returns ErrBlockPruned if the body of the block has been pruned.
+// The genesis block is never pruned. A nil block is ignored.
+func (vs *Visor) verifyBlockNotPruned(tx *dbutil.Tx, b *coin.SignedBlock) error {
+	if b == nil {
+		return nil
+	}
+
+	return vs.verifyBlocksNotPruned(tx, []coin.SignedBlock{*b})
+}
+
+// verifyBlocksNotPruned returns ErrBlockPruned for the first block whose body has been pruned
+func (vs *Visor) verifyBlocksNotPruned(tx *dbutil.Tx, blocks []coin.SignedBlock) error {
+	prunedSeq, ok, err := vs.blockchain.PrunedSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	for _, b := range blocks {
+		if seq := b.Seq(); seq != 0 && seq <= prunedSeq {
+			return NewErrBlockPruned(seq)
+		}
+	}
+
+	return nil
+}
+
 // InjectForeignTransaction records a coin.Transaction to the UnconfirmedTransactionPool if the txn is not
 // already in the blockchain.
 // The bool return value is whether or not the transaction was already in the pool.
@@ -753,15 +1317,28 @@ func (vs *Visor) getBlocksVerbose(tx *dbutil.Tx, getBlocks func(*dbutil.Tx) ([]c
 func (vs *Visor) InjectForeignTransaction(txn coin.Transaction) (bool, *ErrTxnViolatesSoftConstraint, error) {
 	var known bool
 	var softErr *ErrTxnViolatesSoftConstraint
+	var evicted []EvictedTransaction
 
 	if err := vs.db.Update("InjectForeignTransaction", func(tx *dbutil.Tx) error {
 		var err error
 		known, softErr, err = vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.Distribution, vs.Config.UnconfirmedVerifyTxn)
-		return err
+		if err != nil || known {
+			return err
+		}
+
+		evicted, err = vs.limitUnconfirmedAfterInject(tx, txn)
+		if err != nil {
+			return err
+		}
+
+		vs.publishTransactionAdded(tx, txn)
+		return nil
 	}); err != nil {
 		return false, nil, err
 	}
 
+	vs.recordEvictions(evicted)
+
 	return known, softErr, nil
 }
 
@@ -804,8 +1381,21 @@ func (vs *Visor) InjectUserTransactionTx(tx *dbutil.Tx, txn coin.Transaction) (b
 	if softErr != nil {
 		logger.WithError(softErr).Warning("InjectUserTransaction vs.unconfirmed.InjectTransaction returned a softErr unexpectedly")
 	}
+	if err != nil || known {
+		return known, head, inputs, err
+	}
+
+	evicted, err := vs.limitUnconfirmedAfterInject(tx, txn)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	vs.publishTransactionAdded(tx, txn)
+	tx.OnCommit(func() {
+		vs.recordEvictions(evicted)
+	})
 
-	return known, head, inputs, err
+	return known, head, inputs, nil
 }
 
 // GetTransactionsForAddress returns the Transactions whose unspents give coins to a cipher.Address.
@@ -824,6 +1414,88 @@ func (vs *Visor) GetTransactionsForAddress(a cipher.Address) ([]Transaction, err
 	return txns[a], nil
 }
 
+// AddressTransactionsPage is a page of the confirmed transactions of an address
+type AddressTransactionsPage struct {
+	Transactions []Transaction
+	// Inputs are the transaction inputs of each transaction, if requested
+	Inputs [][]TransactionInput
+	// Next is the cursor of the next page, nil if the page is the last one
+	Next *cipher.SHA256
+}
+
+// GetTransactionsPageForAddress returns a page of the confirmed transactions of an address.
+// If verbose is true, the transaction inputs are included.
+func (vs *Visor) GetTransactionsPageForAddress(a cipher.Address, p historydb.AddressTxnsPageParams, verbose bool) (*AddressTransactionsPage, error) {
+	var page *AddressTransactionsPage
+
+	if err := vs.db.View("GetTransactionsPageForAddress", func(tx *dbutil.Tx) error {
+		headBkSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("No head block seq")
+		}
+
+		hPage, err := vs.history.GetTransactionsPageForAddress(tx, a, p)
+		if err != nil {
+			return err
+		}
+
+		page = &AddressTransactionsPage{
+			Transactions: make([]Transaction, len(hPage.Transactions)),
+			Next:         hPage.Next,
+		}
+
+		for i, txn := range hPage.Transactions {
+			if headBkSeq < txn.BlockSeq {
+				return errors.New("Transaction block sequence is greater than the head block sequence")
+			}
+
+			bk, err := vs.blockchain.GetSignedBlockBySeq(tx, txn.BlockSeq)
+			if err != nil {
+				return err
+			}
+
+			if bk == nil {
+				return fmt.Errorf("block seq=%d doesn't exist", txn.BlockSeq)
+			}
+
+			page.Transactions[i] = Transaction{
+				Transaction: txn.Txn,
+				Status:      NewConfirmedTransactionStatus(headBkSeq-txn.BlockSeq+1, txn.BlockSeq),
+				Time:        bk.Time(),
+			}
+		}
+
+		if !verbose {
+			return nil
+		}
+
+		page.Inputs = make([][]TransactionInput, len(page.Transactions))
+		for i, txn := range page.Transactions {
+			feeCalcTime, err := vs.getFeeCalcTimeForTransaction(tx, txn)
+			if err != nil {
+				return err
+			}
+			if feeCalcTime == nil {
+				continue
+			}
+
+			page.Inputs[i], err = vs.getTransactionInputs(tx, *feeCalcTime, txn.Transaction.In)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
 // GetTransaction returns a Transaction by hash.
 func (vs *Visor) GetTransaction(txnHash cipher.SHA256) (*Transaction, error) {
 	var txn *Transaction
@@ -872,6 +1544,58 @@ func (vs *Visor) GetTransactionWithInputs(txnHash cipher.SHA256) (*Transaction,
 	return txn, inputs, nil
 }
 
+// GetTransactionProof returns a merkle proof that a confirmed transaction is included in its block.
+// Returns nil if the transaction is not confirmed
+func (vs *Visor) GetTransactionProof(txnHash cipher.SHA256) (*TransactionProof, error) {
+	var proof *TransactionProof
+
+	if err := vs.db.View("GetTransactionProof", func(tx *dbutil.Tx) error {
+		htxn, err := vs.history.GetTransaction(tx, txnHash)
+		if err != nil {
+			return err
+		}
+
+		if htxn == nil {
+			return nil
+		}
+
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, htxn.BlockSeq)
+		if err != nil {
+			return err
+		}
+
+		if b == nil {
+			return fmt.Errorf("found no block in seq %v", htxn.BlockSeq)
+		}
+
+		for i, txn := range b.Body.Transactions {
+			if txn.Hash() != txnHash {
+				continue
+			}
+
+			hashes, err := b.Body.TransactionProof(uint64(i))
+			if err != nil {
+				return err
+			}
+
+			proof = &TransactionProof{
+				Txid:   txnHash,
+				Header: b.Head,
+				Sig:    b.Sig,
+				Index:  uint64(i),
+				Hashes: hashes,
+			}
+			return nil
+		}
+
+		return fmt.Errorf("transaction %s not found in block %d", txnHash.Hex(), htxn.BlockSeq)
+	}); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
 func (vs *Visor) getTransaction(tx *dbutil.Tx, txnHash cipher.SHA256) (*Transaction, error) {
 	// Look in the unconfirmed pool
 	utxn, err := vs.unconfirmed.Get(tx, txnHash)
@@ -1482,7 +2206,11 @@ func (vs *Visor) GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, er
 	if err := vs.db.View("GetSignedBlockByHash", func(tx *dbutil.Tx) error {
 		var err error
 		sb, err = vs.blockchain.GetSignedBlockByHash(tx, hash)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlockNotPruned(tx, sb)
 	}); err != nil {
 		return nil, err
 	}
@@ -1497,7 +2225,11 @@ func (vs *Visor) GetSignedBlockBySeq(seq uint64) (*coin.SignedBlock, error) {
 	if err := vs.db.View("GetSignedBlockBySeq", func(tx *dbutil.Tx) error {
 		var err error
 		b, err = vs.blockchain.GetSignedBlockBySeq(tx, seq)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return vs.verifyBlockNotPruned(tx, b)
 	}); err != nil {
 		return nil, err
 	}
@@ -1551,6 +2283,10 @@ func (vs *Visor) getBlockVerbose(tx *dbutil.Tx, getBlock func(*dbutil.Tx) (*coin
 		return nil, nil, nil
 	}
 
+	if err := vs.verifyBlockNotPruned(tx, b); err != nil {
+		return nil, nil, err
+	}
+
 	inputs, err := vs.getBlockInputs(tx, b)
 	if err != nil {
 		return nil, nil, err
@@ -1705,6 +2441,29 @@ func (vs Visor) GetSpentOutputsForAddresses(addresses []cipher.Address) ([][]his
 	return out, nil
 }
 
+// GetAddressSummaries returns the aggregates of the history of a set of addresses.
+// The summary of an address without history is nil.
+func (vs Visor) GetAddressSummaries(addrs []cipher.Address) ([]*historydb.AddressSummary, error) {
+	out := make([]*historydb.AddressSummary, len(addrs))
+
+	if err := vs.db.View("GetAddressSummaries", func(tx *dbutil.Tx) error {
+		for i, addr := range addrs {
+			s, err := vs.history.GetAddressSummary(tx, addr)
+			if err != nil {
+				return err
+			}
+
+			out[i] = s
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
 // RecvOfAddresses returns unconfirmed receiving uxouts of addresses
 func (vs *Visor) RecvOfAddresses(addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	var uxouts coin.AddressUxOuts
@@ -1847,12 +2606,17 @@ func (vs *Visor) SetTransactionsAnnounced(hashes map[cipher.SHA256]int64) error
 	})
 }
 
-// GetBalanceOfAddrs returns balance pairs of given addreses
+// GetBalanceOfAddrs returns balance pairs of given addreses.
+// In light client mode, only watched addresses are supported, otherwise ErrAddressNotWatched is returned.
 func (vs Visor) GetBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair, error) {
 	if len(addrs) == 0 {
 		return nil, nil
 	}
 
+	if vs.light != nil {
+		return vs.getLightBalanceOfAddrs(addrs)
+	}
+
 	auxs := make(coin.AddressUxOuts, len(addrs))
 	recvUxs := make(coin.AddressUxOuts, len(addrs))
 	var uxa coin.UxArray
@@ -2303,49 +3067,111 @@ func (vs *Visor) GetUnspentOutputsSummary(filters []OutputsFilter) (*UnspentOutp
 	}, nil
 }
 
-// GetRichlist returns a Richlist
-func (vs *Visor) GetRichlist(includeDistribution bool) (Richlist, error) {
-	rbOuts, err := vs.GetUnspentOutputsSummary(nil)
-	if err != nil {
-		return nil, err
+// GetRichlist returns n address balances of the richlist, after skipping the first offset.
+// If n is 0, all balances after offset are returned.
+// The richlist is read from an index of the unspent pool, ordered by balance,
+// with locked distribution addresses ahead of other addresses of the same balance.
+func (vs *Visor) GetRichlist(includeDistribution bool, offset, n uint64) (Richlist, error) {
+	lockedAddrs := vs.Config.Distribution.LockedAddressesDecoded()
+	lockedMap := make(map[cipher.Address]struct{}, len(lockedAddrs))
+	exclude := make(map[cipher.Address]struct{}, len(vs.Config.Distribution.Addresses))
+	for _, a := range lockedAddrs {
+		lockedMap[a] = struct{}{}
+		exclude[a] = struct{}{}
 	}
 
-	// Build a map from addresses to total coins held
-	allAccounts := map[cipher.Address]uint64{}
-	for _, out := range rbOuts.Confirmed {
-		if _, ok := allAccounts[out.Body.Address]; ok {
-			var err error
-			allAccounts[out.Body.Address], err = mathutil.AddUint64(allAccounts[out.Body.Address], out.Body.Coins)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			allAccounts[out.Body.Address] = out.Body.Coins
+	if !includeDistribution {
+		for _, a := range vs.Config.Distribution.UnlockedAddressesDecoded() {
+			exclude[a] = struct{}{}
 		}
 	}
 
-	lockedAddrs := vs.Config.Distribution.LockedAddressesDecoded()
-	addrsMap := make(map[cipher.Address]struct{}, len(lockedAddrs))
-	for _, a := range lockedAddrs {
-		addrsMap[a] = struct{}{}
+	var limit uint64
+	if n > 0 {
+		var err error
+		limit, err = mathutil.AddUint64(offset, n)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var balances []blockdb.AddressBalance
+	var lockedBalances map[cipher.Address]uint64
+	if err := vs.db.View("GetRichlist", func(tx *dbutil.Tx) error {
+		var err error
+		balances, err = vs.blockchain.Unspent().GetRichlist(tx, limit, exclude)
+		if err != nil {
+			return err
+		}
+
+		if includeDistribution {
+			lockedBalances, err = vs.blockchain.Unspent().GetAddressBalances(tx, lockedAddrs)
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// Merge the locked distribution addresses into the top balances of the other addresses.
+	// The first limit balances of the merged richlist are either locked or among the other top balances.
+	allAccounts := make(map[cipher.Address]uint64, len(balances)+len(lockedBalances))
+	for _, b := range balances {
+		allAccounts[b.Address] = b.Coins
+	}
+	for a, coins := range lockedBalances {
+		allAccounts[a] = coins
 	}
 
-	richlist, err := NewRichlist(allAccounts, addrsMap)
+	richlist, err := NewRichlist(allAccounts, lockedMap)
 	if err != nil {
 		return nil, err
 	}
 
-	if !includeDistribution {
-		unlockedAddrs := vs.Config.Distribution.UnlockedAddressesDecoded()
-		for _, a := range unlockedAddrs {
-			addrsMap[a] = struct{}{}
-		}
-		richlist = richlist.FilterAddresses(addrsMap)
+	if offset >= uint64(len(richlist)) {
+		return Richlist{}, nil
+	}
+	richlist = richlist[offset:]
+
+	if n > 0 && n < uint64(len(richlist)) {
+		richlist = richlist[:n]
 	}
 
 	return richlist, nil
 }
 
+// GetRichlistHolders returns the number of addresses holding coins
+func (vs *Visor) GetRichlistHolders(includeDistribution bool) (*RichlistHolders, error) {
+	var h blockdb.BalanceHistogram
+	if err := vs.db.View("GetRichlistHolders", func(tx *dbutil.Tx) error {
+		var err error
+		h, err = vs.blockchain.Unspent().GetBalanceHistogram(tx)
+		if err != nil {
+			return err
+		}
+
+		if includeDistribution {
+			return nil
+		}
+
+		distBalances, err := vs.blockchain.Unspent().GetAddressBalances(tx, vs.Config.Distribution.AddressesDecoded())
+		if err != nil {
+			return err
+		}
+
+		for _, coins := range distBalances {
+			if err := h.Remove(coins); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return NewRichlistHolders(h), nil
+}
+
 // WithUpdateTx executes a function inside of a db.Update transaction.
 // This is exported for use by the daemon gateway's InjectBroadcastTransaction method.
 // Do not use it for other purposes.
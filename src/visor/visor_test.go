@@ -33,7 +33,7 @@ const (
 	blockchainPubkeyStr = "0328c576d3f420e7682058a981173a4b374c7cc5ff55bf394d3cf57059bbe6456a"
 )
 
-func prepareDB(t *testing.T) (*dbutil.DB, func()) {
+func prepareDB(t testing.TB) (*dbutil.DB, func()) {
 	db, shutdown := testutil.PrepareDB(t)
 
 	err := CreateBuckets(db)
@@ -88,7 +88,7 @@ func removeCorruptDBFiles(t *testing.T, badDBFile string) {
 	}
 }
 
-func addGenesisBlockToVisor(t *testing.T, vs *Visor) *coin.SignedBlock {
+func addGenesisBlockToVisor(t testing.TB, vs *Visor) *coin.SignedBlock {
 	// create genesis block
 	gb, err := coin.NewGenesisBlock(genAddress, genCoins, genTime)
 	require.NoError(t, err)
@@ -1919,6 +1919,69 @@ func TestGetTransactions(t *testing.T) {
 	}
 }
 
+func TestGetTransactionsPageForAddress(t *testing.T) {
+	addr := testutil.MakeAddress()
+	txns, blocks, _, _ := makeTestData(t, 3)
+
+	matchDBTx := mock.MatchedBy(func(tx *dbutil.Tx) bool {
+		return true
+	})
+
+	next := txns[1].Hash()
+	p := historydb.AddressTxnsPageParams{
+		MaxSeq: 10,
+		Limit:  2,
+	}
+
+	his := &MockHistoryer{}
+	his.On("GetTransactionsPageForAddress", matchDBTx, addr, p).Return(&historydb.AddressTxnsPage{
+		Transactions: txns[:2],
+		Next:         &next,
+	}, nil)
+
+	bc := &MockBlockchainer{}
+	bc.On("HeadSeq", matchDBTx).Return(uint64(2), true, nil)
+	for i, b := range blocks {
+		bc.On("GetSignedBlockBySeq", matchDBTx, b.Seq()).Return(&blocks[i], nil)
+	}
+
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := &Visor{
+		db:         db,
+		history:    his,
+		blockchain: bc,
+	}
+
+	page, err := v.GetTransactionsPageForAddress(addr, p, false)
+	require.NoError(t, err)
+	require.Equal(t, &AddressTransactionsPage{
+		Transactions: []Transaction{
+			{
+				Transaction: txns[0].Txn,
+				Status:      NewConfirmedTransactionStatus(3, 0),
+				Time:        blocks[0].Time(),
+			},
+			{
+				Transaction: txns[1].Txn,
+				Status:      NewConfirmedTransactionStatus(2, 1),
+				Time:        blocks[1].Time(),
+			},
+		},
+		Next: &next,
+	}, page)
+
+	// The head block is behind the transactions of the page
+	bc = &MockBlockchainer{}
+	bc.On("HeadSeq", matchDBTx).Return(uint64(0), true, nil)
+	bc.On("GetSignedBlockBySeq", matchDBTx, uint64(0)).Return(&blocks[0], nil)
+	v.blockchain = bc
+
+	_, err = v.GetTransactionsPageForAddress(addr, p, false)
+	require.Equal(t, errors.New("Transaction block sequence is greater than the head block sequence"), err)
+}
+
 func TestRefreshUnconfirmed(t *testing.T) {
 	db, shutdown := prepareDB(t)
 	defer shutdown()
@@ -2732,3 +2795,53 @@ func TestFbyHashes(t *testing.T) {
 		require.Equal(t, outs, tt.want)
 	}
 }
+
+func TestGetTransactionProof(t *testing.T) {
+	c := newTestChain(t)
+	defer c.shutdown()
+
+	gb := c.block(0)
+	genUxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+	splitTxn := makeUnspentsTxn(t, genUxs, []cipher.SecKey{genSecret}, genAddress, 5, params.UserVerifyTxn.MaxDropletPrecision)
+	sb := c.addBlock(splitTxn)
+	splitUxs := coin.CreateUnspents(sb.Head, splitTxn)
+
+	var txns coin.Transactions
+	for i := 0; i < 3; i++ {
+		txns = append(txns, makeSpendTxn(t, coin.UxArray{splitUxs[i]}, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6))
+	}
+	sb = c.addBlock(txns...)
+	require.Len(t, sb.Body.Transactions, 3)
+
+	otherPublic, _ := cipher.GenerateKeyPair()
+
+	for i, txn := range sb.Body.Transactions {
+		proof, err := c.v.GetTransactionProof(txn.Hash())
+		require.NoError(t, err)
+		require.NotNil(t, proof)
+		require.Equal(t, txn.Hash(), proof.Txid)
+		require.Equal(t, sb.Head, proof.Header)
+		require.Equal(t, sb.Sig, proof.Sig)
+		require.Equal(t, uint64(i), proof.Index)
+		require.Len(t, proof.Hashes, 2)
+		require.NoError(t, proof.Verify(genPublic))
+
+		// A proof does not verify with another signer or for another transaction
+		require.Error(t, proof.Verify(otherPublic))
+		p := *proof
+		p.Txid = splitTxn.Hash()
+		require.Equal(t, cipher.ErrMerkleProofInvalid, p.Verify(genPublic))
+	}
+
+	// The genesis transaction is the only transaction of its block
+	proof, err := c.v.GetTransactionProof(gb.Body.Transactions[0].Hash())
+	require.NoError(t, err)
+	require.NotNil(t, proof)
+	require.Empty(t, proof.Hashes)
+	require.NoError(t, proof.Verify(genPublic))
+
+	// Unknown transactions have no proof
+	proof, err = c.v.GetTransactionProof(testutil.RandSHA256(t))
+	require.NoError(t, err)
+	require.Nil(t, proof)
+}